@@ -21,8 +21,10 @@ package account
 import (
 	"github.com/ontio/ontology-crypto/keypair"
 	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology-crypto/vrf"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/core/types"
 )
 
@@ -93,17 +95,26 @@ func (this *Account) Scheme() s.SignatureScheme {
 	return this.SigScheme
 }
 
+func (this *Account) Sign(data []byte) ([]byte, error) {
+	return signature.Sign(this, data)
+}
+
+func (this *Account) Vrf(data []byte) ([]byte, []byte, error) {
+	return vrf.Vrf(this.PrivateKey, data)
+}
+
 //AccountMetadata all account info without private key
 type AccountMetadata struct {
-	IsDefault bool   //Is default account
-	Label     string //Lable of account
-	KeyType   string //KeyType ECDSA,SM2 or EDDSA
-	Curve     string //Curve of key type
-	Address   string //Address(base58) of account
-	PubKey    string //Public  key
-	SigSch    string //Signature scheme
-	Salt      []byte //Salt
-	Key       []byte //PrivateKey in encrypted
-	EncAlg    string //Encrypt alg of private key
-	Hash      string //Hash alg
+	IsDefault  bool   //Is default account
+	Label      string //Lable of account
+	KeyType    string //KeyType ECDSA,SM2 or EDDSA
+	Curve      string //Curve of key type
+	Address    string //Address(base58) of account
+	PubKey     string //Public  key
+	SigSch     string //Signature scheme
+	Salt       []byte //Salt
+	Key        []byte //PrivateKey in encrypted
+	EncAlg     string //Encrypt alg of private key
+	Hash       string //Hash alg
+	DerivePath string //BIP-32 path if account is derived from HD seed
 }
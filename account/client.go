@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	s "github.com/ontio/ontology-crypto/signature"
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/log"
 	"github.com/qbyyf/ontology/core/types"
 )
 
@@ -38,6 +40,8 @@ type Client interface {
 	NewAccount(label string, typeCode keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme, passwd []byte) (*Account, error)
 	//ImportAccount import a already exist account to wallet
 	ImportAccount(accMeta *AccountMetadata) error
+	//ImportPrivateKey import a plain private key to wallet, which is encrypted by passwd
+	ImportPrivateKey(label string, prvkey keypair.PrivateKey, sigScheme s.SignatureScheme, passwd []byte) (*Account, error)
 	//GetAccountByAddress return account object by address
 	GetAccountByAddress(address string, passwd []byte) (*Account, error)
 	//GetAccountByLabel return account object by label
@@ -74,6 +78,10 @@ type Client interface {
 	ChangeSigScheme(address string, sigScheme s.SignatureScheme) error
 	//Get the underlying wallet data
 	GetWalletData() *WalletData
+	//ImportMnemonic store the seed of BIP-39 mnemonic to wallet, which is encrypted by passwd
+	ImportMnemonic(mnemonic, passphrase string, passwd []byte) error
+	//DeriveAccount create a new account derived from HD seed by next BIP-44 path of coin type
+	DeriveAccount(label string, coinType uint32, passwd []byte) (*Account, error)
 }
 
 func Open(path string) (Client, error) {
@@ -122,6 +130,9 @@ func (this *ClientImpl) load() error {
 	if err != nil {
 		return fmt.Errorf("error loading wallet %s: %s", this.path, err)
 	}
+	for _, err := range this.walletData.CheckIntegrity() {
+		log.Warnf("wallet %s integrity check: %s", this.path, err)
+	}
 	for _, accData := range this.walletData.Accounts {
 		this.accAddrs[accData.Address] = accData
 		if accData.Label != "" {
@@ -223,6 +234,38 @@ func (this *ClientImpl) ImportAccount(accMeta *AccountMetadata) error {
 	return this.addAccountData(accData)
 }
 
+func (this *ClientImpl) ImportPrivateKey(label string, prvkey keypair.PrivateKey, sigScheme s.SignatureScheme, passwd []byte) (*Account, error) {
+	if len(passwd) == 0 {
+		return nil, fmt.Errorf("password cannot empty")
+	}
+	pubkey := prvkey.Public()
+	address := types.AddressFromPubKey(pubkey)
+	addressBase58 := address.ToBase58()
+	if this.GetAccountMetadataByAddress(addressBase58) != nil {
+		return nil, fmt.Errorf("account %s already exists", addressBase58)
+	}
+	prvSecret, err := keypair.EncryptWithCustomScrypt(prvkey, addressBase58, passwd, this.walletData.Scrypt)
+	if err != nil {
+		return nil, fmt.Errorf("encryptPrivateKey error: %s", err)
+	}
+	accData := &AccountData{}
+	accData.Label = label
+	accData.SetKeyPair(prvSecret)
+	accData.SigSch = sigScheme.Name()
+	accData.PubKey = hex.EncodeToString(keypair.SerializePublicKey(pubkey))
+
+	err = this.addAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		PrivateKey: prvkey,
+		PublicKey:  pubkey,
+		Address:    address,
+		SigScheme:  sigScheme,
+	}, nil
+}
+
 func (this *ClientImpl) GetAccountByAddress(address string, passwd []byte) (*Account, error) {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
@@ -341,6 +384,7 @@ func (this *ClientImpl) getAccountMetadata(accData *AccountData) *AccountMetadat
 	accMeta.Hash = accData.Hash
 	accMeta.Curve = accData.Param["curve"]
 	accMeta.Salt = accData.Salt
+	accMeta.DerivePath = accData.DerivePath
 	return accMeta
 }
 
@@ -531,6 +575,11 @@ func (this *ClientImpl) ChangeSigScheme(address string, sigScheme s.SignatureSch
 }
 
 func (this *ClientImpl) checkSigScheme(keyType, sigScheme string) bool {
+	return matchSigScheme(keyType, sigScheme)
+}
+
+//matchSigScheme return whether the signature scheme can be used by key type
+func matchSigScheme(keyType, sigScheme string) bool {
 	switch strings.ToUpper(keyType) {
 	case "ECDSA":
 		switch strings.ToUpper(sigScheme) {
@@ -567,3 +616,104 @@ func (this *ClientImpl) checkSigScheme(keyType, sigScheme string) bool {
 func (this *ClientImpl) GetWalletData() *WalletData {
 	return this.walletData
 }
+
+func (this *ClientImpl) ImportMnemonic(mnemonic, passphrase string, passwd []byte) error {
+	if len(passwd) == 0 {
+		return fmt.Errorf("password cannot empty")
+	}
+	err := ValidateMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+	hdSeed, err := EncryptHDSeed(MnemonicToSeed(mnemonic, passphrase), passwd, this.walletData.Scrypt)
+	if err != nil {
+		return fmt.Errorf("encrypt HD seed error: %s", err)
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.walletData.HDSeed != nil {
+		return fmt.Errorf("wallet already has HD seed")
+	}
+	this.walletData.HDSeed = hdSeed
+	err = this.save()
+	if err != nil {
+		this.walletData.HDSeed = nil
+		return fmt.Errorf("save error: %s", err)
+	}
+	return nil
+}
+
+func (this *ClientImpl) DeriveAccount(label string, coinType uint32, passwd []byte) (*Account, error) {
+	curve, err := coinCurve(coinType)
+	if err != nil {
+		return nil, err
+	}
+	this.lock.RLock()
+	hdSeed := this.walletData.HDSeed
+	index := this.nextDeriveIndex(coinType)
+	this.lock.RUnlock()
+	if hdSeed == nil {
+		return nil, fmt.Errorf("wallet has no HD seed")
+	}
+	seed, err := DecryptHDSeed(hdSeed, passwd)
+	if err != nil {
+		return nil, err
+	}
+
+	var prvkey keypair.PrivateKey
+	var pubkey keypair.PublicKey
+	var path string
+	for ; index < HARDENED_KEY_START; index++ {
+		//skip the index if derived key is invalid on curve as BIP-32 suggests
+		path = Bip44Path(coinType, index)
+		prvkey, pubkey, err = DeriveKeyPair(seed, path, curve)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	address := types.AddressFromPubKey(pubkey)
+	addressBase58 := address.ToBase58()
+	if this.GetAccountMetadataByAddress(addressBase58) != nil {
+		return nil, fmt.Errorf("account %s of %s already exists", addressBase58, path)
+	}
+	prvSecret, err := keypair.EncryptWithCustomScrypt(prvkey, addressBase58, passwd, this.walletData.Scrypt)
+	if err != nil {
+		return nil, fmt.Errorf("encryptPrivateKey error: %s", err)
+	}
+	accData := &AccountData{}
+	accData.Label = label
+	accData.SetKeyPair(prvSecret)
+	accData.SigSch = s.SHA256withECDSA.Name()
+	accData.PubKey = hex.EncodeToString(keypair.SerializePublicKey(pubkey))
+	accData.DerivePath = path
+
+	err = this.addAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		PrivateKey: prvkey,
+		PublicKey:  pubkey,
+		Address:    address,
+		SigScheme:  s.SHA256withECDSA,
+	}, nil
+}
+
+//nextDeriveIndex return the index after the largest one of accounts derived by BIP-44 path of coin type
+func (this *ClientImpl) nextDeriveIndex(coinType uint32) uint32 {
+	prefix := strings.TrimSuffix(Bip44Path(coinType, 0), "0")
+	next := uint32(0)
+	for _, accData := range this.walletData.Accounts {
+		if !strings.HasPrefix(accData.DerivePath, prefix) {
+			continue
+		}
+		index, err := strconv.ParseUint(strings.TrimPrefix(accData.DerivePath, prefix), 10, 32)
+		if err == nil && uint32(index) >= next {
+			next = uint32(index) + 1
+		}
+	}
+	return next
+}
@@ -0,0 +1,494 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package credential issues and verifies W3C verifiable credentials and presentations
+// signed by ONT ID keys, as plain json with an embedded proof or in JWT format.
+//
+// The credentials are not processed as JSON-LD, and the embedded proof is not a
+// Linked Data Proof: the signed data is the canonical json of the credential, with
+// sorted keys and no whitespace, instead of the URDNA2015 normalized RDF dataset.
+// Its proof types are therefore ontology specific.
+package credential
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/signature"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	CREDENTIAL_CONTEXT = "https://www.w3.org/2018/credentials/v1"
+	CREDENTIAL_TYPE    = "VerifiableCredential"
+	PRESENTATION_TYPE  = "VerifiablePresentation"
+
+	PROOF_PURPOSE_ASSERTION      = "assertionMethod"
+	PROOF_PURPOSE_AUTHENTICATION = "authentication"
+
+	// proof types of the embedded proof over canonical json
+	PROOF_TYPE_ECDSA   = "OntCanonicalJsonEcdsaSignature"
+	PROOF_TYPE_ED25519 = "OntCanonicalJsonEd25519Signature"
+	PROOF_TYPE_SM2     = "OntCanonicalJsonSM2Signature"
+
+	// STATUS_TYPE is the credentialStatus type of credentials recorded in a claim
+	// registry contract, whose id is the hex address of the contract
+	STATUS_TYPE = "AttestContract"
+)
+
+// Status is the state of a credential in the claim registry contract
+type Status byte
+
+const (
+	STATUS_NOT_FOUND Status = iota
+	STATUS_VALID
+	STATUS_REVOKED
+)
+
+// Resolver reads the chain state needed to verify credentials
+type Resolver interface {
+	// GetPublicKey returns the public key of ontId at keyIndex, or an error if
+	// the key does not exist or is revoked
+	GetPublicKey(ontId string, keyIndex uint32) (keypair.PublicKey, error)
+	// GetStatus returns the status of credential id in the claim registry contract
+	GetStatus(contract common.Address, id string) (Status, error)
+}
+
+// Signer signs with the key at KeyIndex of an ONT ID
+type Signer struct {
+	OntId    string
+	KeyIndex uint32
+	Account  *account.Account
+}
+
+// VerificationMethod returns the id of the signing key in the DID document
+func (this *Signer) VerificationMethod() string {
+	return KeyId(this.OntId, this.KeyIndex)
+}
+
+// KeyId returns the id of the key at keyIndex in the DID document of ontId
+func KeyId(ontId string, keyIndex uint32) string {
+	return fmt.Sprintf("%s#keys-%d", ontId, keyIndex)
+}
+
+// ParseKeyId splits a key id returned by KeyId into ONT ID and key index
+func ParseKeyId(keyId string) (string, uint32, error) {
+	pos := strings.LastIndex(keyId, "#keys-")
+	if pos < 0 {
+		return "", 0, fmt.Errorf("invalid key id %s", keyId)
+	}
+	index, err := strconv.ParseUint(keyId[pos+len("#keys-"):], 10, 32)
+	if err != nil || index == 0 {
+		return "", 0, fmt.Errorf("invalid key id %s", keyId)
+	}
+	return keyId[:pos], uint32(index), nil
+}
+
+type Proof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	Challenge          string `json:"challenge,omitempty"`
+	Domain             string `json:"domain,omitempty"`
+	ProofPurpose       string `json:"proofPurpose"`
+	VerificationMethod string `json:"verificationMethod"`
+	Hex                string `json:"hex,omitempty"`
+}
+
+type CredentialStatus struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Credential is a W3C verifiable credential. CredentialSubject is kept as raw json,
+// so that the signed content survives decoding and encoding.
+type Credential struct {
+	Context           []string          `json:"@context"`
+	Id                string            `json:"id,omitempty"`
+	Type              []string          `json:"type"`
+	Issuer            string            `json:"issuer"`
+	IssuanceDate      string            `json:"issuanceDate"`
+	ExpirationDate    string            `json:"expirationDate,omitempty"`
+	CredentialSubject json.RawMessage   `json:"credentialSubject"`
+	CredentialStatus  *CredentialStatus `json:"credentialStatus,omitempty"`
+	Proof             *Proof            `json:"proof,omitempty"`
+}
+
+// Presentation is a W3C verifiable presentation. Each of VerifiableCredential is
+// a credential object with embedded proof or a JWT string.
+type Presentation struct {
+	Context              []string          `json:"@context"`
+	Id                   string            `json:"id,omitempty"`
+	Type                 []string          `json:"type"`
+	Holder               string            `json:"holder,omitempty"`
+	VerifiableCredential []json.RawMessage `json:"verifiableCredential"`
+	Proof                *Proof            `json:"proof,omitempty"`
+}
+
+// NewCredential returns an unsigned credential issued now by issuer. The context
+// and type of verifiable credentials are added to contexts and types.
+func NewCredential(id, issuer string, contexts, types []string, subject json.RawMessage,
+	expiration time.Time) *Credential {
+	cred := &Credential{
+		Context:           append([]string{CREDENTIAL_CONTEXT}, contexts...),
+		Id:                id,
+		Type:              append([]string{CREDENTIAL_TYPE}, types...),
+		Issuer:            issuer,
+		IssuanceDate:      formatTime(time.Now()),
+		CredentialSubject: subject,
+	}
+	if !expiration.IsZero() {
+		cred.ExpirationDate = formatTime(expiration)
+	}
+	return cred
+}
+
+// NewPresentation returns an unsigned presentation of credentials by holder
+func NewPresentation(id, holder string, credentials []json.RawMessage) *Presentation {
+	return &Presentation{
+		Context:              []string{CREDENTIAL_CONTEXT},
+		Id:                   id,
+		Type:                 []string{PRESENTATION_TYPE},
+		Holder:               holder,
+		VerifiableCredential: credentials,
+	}
+}
+
+// SetRegistry records that the status of credential is kept in the claim registry contract
+func (this *Credential) SetRegistry(contract common.Address) {
+	this.CredentialStatus = &CredentialStatus{Id: contract.ToHexString(), Type: STATUS_TYPE}
+}
+
+// SubjectId returns the id of credential subject, empty if there is no such field
+func (this *Credential) SubjectId() string {
+	var subject struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(this.CredentialSubject, &subject)
+	return subject.Id
+}
+
+// subjectIds returns the ids of credential subject, which is an object or an array of objects
+func (this *Credential) subjectIds() ([]string, error) {
+	var subjects []struct {
+		Id string `json:"id"`
+	}
+	data := bytes.TrimSpace(this.CredentialSubject)
+	if len(data) != 0 && data[0] != '[' {
+		data = append(append([]byte{'['}, data...), ']')
+	}
+	if err := json.Unmarshal(data, &subjects); err != nil {
+		return nil, fmt.Errorf("invalid credentialSubject: %s", err)
+	}
+	ids := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		ids = append(ids, subject.Id)
+	}
+	return ids, nil
+}
+
+// Sign adds embedded proof of signer, who must be the issuer, to credential
+func (this *Credential) Sign(signer *Signer) error {
+	if signer.OntId != this.Issuer {
+		return fmt.Errorf("signer %s is not the issuer %s", signer.OntId, this.Issuer)
+	}
+	this.Proof = newProof(signer, PROOF_PURPOSE_ASSERTION)
+	data, err := canonicalJson(this)
+	if err != nil {
+		return err
+	}
+	return this.Proof.sign(signer, data)
+}
+
+// Sign adds embedded proof of signer, who must be the holder if there is one, to presentation.
+// challenge and domain prevent the presentation to be replayed to other verifiers.
+func (this *Presentation) Sign(signer *Signer, challenge, domain string) error {
+	if this.Holder != "" && signer.OntId != this.Holder {
+		return fmt.Errorf("signer %s is not the holder %s", signer.OntId, this.Holder)
+	}
+	this.Proof = newProof(signer, PROOF_PURPOSE_AUTHENTICATION)
+	this.Proof.Challenge, this.Proof.Domain = challenge, domain
+	data, err := canonicalJson(this)
+	if err != nil {
+		return err
+	}
+	return this.Proof.sign(signer, data)
+}
+
+// Verify checks the embedded proof, validity period and revocation status of credential at now
+func (this *Credential) Verify(resolver Resolver, now time.Time) error {
+	if err := checkType(this.Context, this.Type, CREDENTIAL_TYPE); err != nil {
+		return err
+	}
+	if this.Proof == nil {
+		return fmt.Errorf("credential has no proof")
+	}
+	if this.Proof.ProofPurpose != PROOF_PURPOSE_ASSERTION {
+		return fmt.Errorf("invalid proof purpose %s", this.Proof.ProofPurpose)
+	}
+	unsigned, proof := *this, *this.Proof
+	proof.Hex = ""
+	unsigned.Proof = &proof
+	data, err := canonicalJson(&unsigned)
+	if err != nil {
+		return err
+	}
+	if err := this.Proof.verify(resolver, this.Issuer, data); err != nil {
+		return err
+	}
+	return this.checkState(resolver, now)
+}
+
+// Verify checks the embedded proof of presentation and all the credentials in it,
+// whose subjects must be the holder
+func (this *Presentation) Verify(resolver Resolver, challenge, domain string, now time.Time) error {
+	if err := checkType(this.Context, this.Type, PRESENTATION_TYPE); err != nil {
+		return err
+	}
+	if this.Proof == nil {
+		return fmt.Errorf("presentation has no proof")
+	}
+	if this.Proof.ProofPurpose != PROOF_PURPOSE_AUTHENTICATION {
+		return fmt.Errorf("invalid proof purpose %s", this.Proof.ProofPurpose)
+	}
+	if this.Proof.Challenge != challenge || this.Proof.Domain != domain {
+		return fmt.Errorf("challenge or domain mismatch")
+	}
+	unsigned, proof := *this, *this.Proof
+	proof.Hex = ""
+	unsigned.Proof = &proof
+	data, err := canonicalJson(&unsigned)
+	if err != nil {
+		return err
+	}
+	holder := this.Holder
+	if holder == "" {
+		holder, _, err = ParseKeyId(this.Proof.VerificationMethod)
+		if err != nil {
+			return err
+		}
+	}
+	if err := this.Proof.verify(resolver, holder, data); err != nil {
+		return err
+	}
+	creds, err := VerifyCredentials(resolver, this.VerifiableCredential, now)
+	if err != nil {
+		return err
+	}
+	return checkSubjects(creds, holder)
+}
+
+// VerifyCredentials verifies each of credentials with embedded proof or in JWT format, and
+// returns them decoded
+func VerifyCredentials(resolver Resolver, credentials []json.RawMessage, now time.Time) ([]*Credential, error) {
+	res := make([]*Credential, 0, len(credentials))
+	for i, raw := range credentials {
+		cred, err := VerifyRawCredential(resolver, raw, now)
+		if err != nil {
+			return nil, fmt.Errorf("credential %d: %s", i, err)
+		}
+		res = append(res, cred)
+	}
+	return res, nil
+}
+
+// VerifyRawCredential verifies a credential which is either a json object with
+// embedded proof or a json string of JWT
+func VerifyRawCredential(resolver Resolver, raw json.RawMessage, now time.Time) (*Credential, error) {
+	var token string
+	if err := json.Unmarshal(raw, &token); err == nil {
+		return VerifyCredentialJWT(resolver, token, now)
+	}
+	cred := new(Credential)
+	if err := json.Unmarshal(raw, cred); err != nil {
+		return nil, fmt.Errorf("invalid credential: %s", err)
+	}
+	if err := cred.Verify(resolver, now); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// checkSubjects checks that holder is the subject of each of credentials
+func checkSubjects(creds []*Credential, holder string) error {
+	for i, cred := range creds {
+		ids, err := cred.subjectIds()
+		if err != nil {
+			return fmt.Errorf("credential %d: %s", i, err)
+		}
+		if len(ids) == 0 {
+			return fmt.Errorf("credential %d has no subject", i)
+		}
+		for _, id := range ids {
+			if id != holder {
+				return fmt.Errorf("credential %d: subject %s is not the holder %s", i, id, holder)
+			}
+		}
+	}
+	return nil
+}
+
+func (this *Credential) checkState(resolver Resolver, now time.Time) error {
+	issuance, err := parseTime(this.IssuanceDate)
+	if err != nil {
+		return fmt.Errorf("invalid issuanceDate: %s", err)
+	}
+	if now.Before(issuance) {
+		return fmt.Errorf("credential is not valid until %s", this.IssuanceDate)
+	}
+	if this.ExpirationDate != "" {
+		expiration, err := parseTime(this.ExpirationDate)
+		if err != nil {
+			return fmt.Errorf("invalid expirationDate: %s", err)
+		}
+		if !now.Before(expiration) {
+			return fmt.Errorf("credential expired at %s", this.ExpirationDate)
+		}
+	}
+	if this.CredentialStatus == nil {
+		return nil
+	}
+	if this.CredentialStatus.Type != STATUS_TYPE {
+		return fmt.Errorf("unsupported credential status type %s", this.CredentialStatus.Type)
+	}
+	if this.Id == "" {
+		return fmt.Errorf("credential with status has no id")
+	}
+	contract, err := common.AddressFromHexString(this.CredentialStatus.Id)
+	if err != nil {
+		return fmt.Errorf("invalid credential status id %s", this.CredentialStatus.Id)
+	}
+	status, err := resolver.GetStatus(contract, this.Id)
+	if err != nil {
+		return fmt.Errorf("get credential status error: %s", err)
+	}
+	switch status {
+	case STATUS_VALID:
+		return nil
+	case STATUS_REVOKED:
+		return fmt.Errorf("credential %s is revoked", this.Id)
+	default:
+		return fmt.Errorf("credential %s is not committed to registry", this.Id)
+	}
+}
+
+func newProof(signer *Signer, purpose string) *Proof {
+	return &Proof{
+		Type:               proofType(signer.Account.PublicKey),
+		Created:            formatTime(time.Now()),
+		ProofPurpose:       purpose,
+		VerificationMethod: signer.VerificationMethod(),
+	}
+}
+
+func (this *Proof) sign(signer *Signer, data []byte) error {
+	sig, err := signature.Sign(signer.Account, data)
+	if err != nil {
+		return fmt.Errorf("sign error: %s", err)
+	}
+	this.Hex = hex.EncodeToString(sig)
+	return nil
+}
+
+// verify checks the proof is signed over data by a key of ontId
+func (this *Proof) verify(resolver Resolver, ontId string, data []byte) error {
+	owner, index, err := ParseKeyId(this.VerificationMethod)
+	if err != nil {
+		return err
+	}
+	if owner != ontId {
+		return fmt.Errorf("verification method %s does not belong to %s", this.VerificationMethod, ontId)
+	}
+	sig, err := hex.DecodeString(this.Hex)
+	if err != nil {
+		return fmt.Errorf("invalid proof signature")
+	}
+	pub, err := resolver.GetPublicKey(owner, index)
+	if err != nil {
+		return fmt.Errorf("get key %s error: %s", this.VerificationMethod, err)
+	}
+	if typ := proofType(pub); this.Type != typ {
+		return fmt.Errorf("proof type %s mismatch the key of %s, expect %s", this.Type, this.VerificationMethod, typ)
+	}
+	return signature.Verify(pub, data, sig)
+}
+
+func proofType(pub keypair.PublicKey) string {
+	switch pub.(type) {
+	case *ec.PublicKey:
+		if keypair.GetKeyType(pub) == keypair.PK_SM2 {
+			return PROOF_TYPE_SM2
+		}
+		return PROOF_TYPE_ECDSA
+	case ed25519.PublicKey:
+		return PROOF_TYPE_ED25519
+	default:
+		return ""
+	}
+}
+
+// canonicalJson encodes v as json with the keys of all the objects sorted, no
+// whitespace and no html escaping, so that the signed data does not depend on
+// the formatting of credential subject
+func canonicalJson(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+func checkType(contexts, types []string, typ string) error {
+	if len(contexts) == 0 || contexts[0] != CREDENTIAL_CONTEXT {
+		return fmt.Errorf("the first context must be %s", CREDENTIAL_CONTEXT)
+	}
+	for _, t := range types {
+		if t == typ {
+			return nil
+		}
+	}
+	return fmt.Errorf("type %s is missing", typ)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(str string) (time.Time, error) {
+	return time.Parse(time.RFC3339, str)
+}
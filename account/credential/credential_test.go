@@ -0,0 +1,215 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package credential
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type testResolver struct {
+	keys   map[string]keypair.PublicKey
+	status map[string]Status
+}
+
+func (this *testResolver) GetPublicKey(ontId string, keyIndex uint32) (keypair.PublicKey, error) {
+	pub, ok := this.keys[KeyId(ontId, keyIndex)]
+	if !ok {
+		return nil, fmt.Errorf("key not exist")
+	}
+	return pub, nil
+}
+
+func (this *testResolver) GetStatus(contract common.Address, id string) (Status, error) {
+	return this.status[id], nil
+}
+
+func newTestSigner(resolver *testResolver, ontId, scheme string) *Signer {
+	acc := account.NewAccount(scheme)
+	signer := &Signer{OntId: ontId, KeyIndex: 1, Account: acc}
+	resolver.keys[signer.VerificationMethod()] = acc.PublicKey
+	return signer
+}
+
+func TestCredential(t *testing.T) {
+	resolver := &testResolver{keys: make(map[string]keypair.PublicKey), status: make(map[string]Status)}
+	issuer := newTestSigner(resolver, "did:ont:issuer", "")
+	holder := newTestSigner(resolver, "did:ont:holder", "SHA512withEDDSA")
+
+	subject := json.RawMessage(`{"id":"did:ont:holder","degree":"bachelor"}`)
+	cred := NewCredential("urn:uuid:1", issuer.OntId, nil, []string{"DegreeCredential"}, subject,
+		time.Now().Add(time.Hour))
+	cred.SetRegistry(common.ADDRESS_EMPTY)
+	assert.Equal(t, "did:ont:holder", cred.SubjectId())
+	assert.NotNil(t, cred.Sign(holder))
+	assert.Nil(t, cred.Sign(issuer))
+	token, err := cred.SignJWT(issuer)
+	assert.Nil(t, err)
+
+	// not committed to registry
+	assert.NotNil(t, cred.Verify(resolver, time.Now()))
+	resolver.status[cred.Id] = STATUS_VALID
+	assert.Nil(t, cred.Verify(resolver, time.Now()))
+	_, err = VerifyCredentialJWT(resolver, token, time.Now())
+	assert.Nil(t, err)
+	assert.NotNil(t, cred.Verify(resolver, time.Now().Add(2*time.Hour)))
+
+	// decoding keeps the signed content
+	data, err := json.Marshal(cred)
+	assert.Nil(t, err)
+	decoded, err := VerifyRawCredential(resolver, data, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, cred.Proof.Hex, decoded.Proof.Hex)
+
+	tampered := *cred
+	tampered.CredentialSubject = json.RawMessage(`{"id":"did:ont:holder","degree":"doctor"}`)
+	assert.NotNil(t, tampered.Verify(resolver, time.Now()))
+
+	tokenJson, _ := json.Marshal(token)
+	pres := NewPresentation("", holder.OntId, []json.RawMessage{data, tokenJson})
+	assert.Nil(t, pres.Sign(holder, "challenge", "example.com"))
+	assert.Nil(t, pres.Verify(resolver, "challenge", "example.com", time.Now()))
+	assert.NotNil(t, pres.Verify(resolver, "other", "example.com", time.Now()))
+	presToken, err := pres.SignJWT(holder, "example.com", "nonce")
+	assert.Nil(t, err)
+	_, err = VerifyPresentationJWT(resolver, presToken, "example.com", "nonce", time.Now())
+	assert.Nil(t, err)
+
+	resolver.status[cred.Id] = STATUS_REVOKED
+	assert.NotNil(t, cred.Verify(resolver, time.Now()))
+	_, err = VerifyCredentialJWT(resolver, token, time.Now())
+	assert.NotNil(t, err)
+	assert.NotNil(t, pres.Verify(resolver, "challenge", "example.com", time.Now()))
+
+	// revoked key
+	resolver.status[cred.Id] = STATUS_VALID
+	delete(resolver.keys, issuer.VerificationMethod())
+	assert.NotNil(t, cred.Verify(resolver, time.Now()))
+}
+
+func TestParseKeyId(t *testing.T) {
+	ontId, index, err := ParseKeyId("did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa#keys-2")
+	assert.Nil(t, err)
+	assert.Equal(t, "did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa", ontId)
+	assert.Equal(t, uint32(2), index)
+	_, _, err = ParseKeyId("did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa#keys-0")
+	assert.NotNil(t, err)
+	_, _, err = ParseKeyId("did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa")
+	assert.NotNil(t, err)
+}
+
+func TestCanonicalProof(t *testing.T) {
+	resolver := &testResolver{keys: make(map[string]keypair.PublicKey), status: make(map[string]Status)}
+	issuer := newTestSigner(resolver, "did:ont:issuer", "SM3withSM2")
+	cred := NewCredential("", issuer.OntId, nil, nil, json.RawMessage(`{"id":"did:ont:holder","degree":"bachelor"}`),
+		time.Time{})
+	assert.Nil(t, cred.Sign(issuer))
+	assert.Equal(t, PROOF_TYPE_SM2, cred.Proof.Type)
+
+	// the proof does not depend on the key order and whitespace of subject
+	reformatted := *cred
+	reformatted.CredentialSubject = json.RawMessage(`{ "degree": "bachelor", "id": "did:ont:holder" }`)
+	assert.Nil(t, reformatted.Verify(resolver, time.Now()))
+
+	proof := *cred.Proof
+	proof.Type = PROOF_TYPE_ECDSA
+	reformatted.Proof = &proof
+	assert.NotNil(t, reformatted.Verify(resolver, time.Now()))
+}
+
+func TestJWTAlg(t *testing.T) {
+	resolver := &testResolver{keys: make(map[string]keypair.PublicKey), status: make(map[string]Status)}
+	issuer := newTestSigner(resolver, "did:ont:issuer", "SHA384withECDSA")
+	cred := NewCredential("", issuer.OntId, nil, nil, json.RawMessage(`{"id":"did:ont:holder"}`), time.Time{})
+	token, err := cred.SignJWT(issuer)
+	assert.Nil(t, err)
+	header := new(jwtHeader)
+	assert.Nil(t, decodeJWTPart(strings.Split(token, ".")[0], header))
+	assert.Equal(t, "ES384", header.Alg)
+	_, err = VerifyCredentialJWT(resolver, token, time.Now())
+	assert.Nil(t, err)
+
+	// the alg follows the curve instead of the hash of signature scheme
+	p256 := newTestSigner(resolver, "did:ont:p256", "")
+	p256.Account.SigScheme = s.SHA384withECDSA
+	cred.Issuer = p256.OntId
+	_, err = cred.SignJWT(p256)
+	assert.NotNil(t, err)
+
+	p256.Account.SigScheme = s.SHA256withECDSA
+	token, err = cred.SignJWT(p256)
+	assert.Nil(t, err)
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(&jwtHeader{Alg: "ES384", Kid: p256.VerificationMethod(), Typ: "JWT"})
+	parts[0] = base64.RawURLEncoding.EncodeToString(forged)
+	_, err = VerifyCredentialJWT(resolver, strings.Join(parts, "."), time.Now())
+	assert.NotNil(t, err)
+}
+
+func TestPresentationSubject(t *testing.T) {
+	resolver := &testResolver{keys: make(map[string]keypair.PublicKey), status: make(map[string]Status)}
+	issuer := newTestSigner(resolver, "did:ont:issuer", "")
+	holder := newTestSigner(resolver, "did:ont:holder", "")
+	other := newTestSigner(resolver, "did:ont:other", "")
+
+	sign := func(subject string) json.RawMessage {
+		cred := NewCredential("", issuer.OntId, nil, nil, json.RawMessage(subject), time.Time{})
+		assert.Nil(t, cred.Sign(issuer))
+		data, err := json.Marshal(cred)
+		assert.Nil(t, err)
+		return data
+	}
+	own := sign(`{"id":"did:ont:holder"}`)
+	array := sign(`[{"id":"did:ont:holder"},{"id":"did:ont:holder","name":"alice"}]`)
+	foreign := sign(`{"id":"did:ont:other"}`)
+	anonymous := sign(`{"name":"alice"}`)
+
+	pres := NewPresentation("", holder.OntId, []json.RawMessage{own, array})
+	assert.Nil(t, pres.Sign(holder, "", ""))
+	assert.Nil(t, pres.Verify(resolver, "", "", time.Now()))
+
+	for _, cred := range []json.RawMessage{foreign, anonymous} {
+		pres = NewPresentation("", holder.OntId, []json.RawMessage{own, cred})
+		assert.Nil(t, pres.Sign(holder, "", ""))
+		assert.NotNil(t, pres.Verify(resolver, "", "", time.Now()))
+		token, err := pres.SignJWT(holder, "", "")
+		assert.Nil(t, err)
+		_, err = VerifyPresentationJWT(resolver, token, "", "", time.Now())
+		assert.NotNil(t, err)
+	}
+
+	// without holder, the signer of presentation must be the subject
+	pres = NewPresentation("", "", []json.RawMessage{own})
+	assert.Nil(t, pres.Sign(other, "", ""))
+	assert.NotNil(t, pres.Verify(resolver, "", "", time.Now()))
+	token, err := pres.SignJWT(other, "", "")
+	assert.Nil(t, err)
+	_, err = VerifyPresentationJWT(resolver, token, "", "", time.Now())
+	assert.NotNil(t, err)
+}
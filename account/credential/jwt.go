@@ -0,0 +1,249 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package credential
+
+import (
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/qbyyf/ontology/core/signature"
+	"golang.org/x/crypto/ed25519"
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// jwtClaims is the JWT encoding of credentials and presentations, which keeps
+// the whole credential or presentation without proof in vc or vp
+type jwtClaims struct {
+	Iss   string        `json:"iss,omitempty"`
+	Sub   string        `json:"sub,omitempty"`
+	Aud   string        `json:"aud,omitempty"`
+	Jti   string        `json:"jti,omitempty"`
+	Nbf   int64         `json:"nbf,omitempty"`
+	Iat   int64         `json:"iat,omitempty"`
+	Exp   int64         `json:"exp,omitempty"`
+	Nonce string        `json:"nonce,omitempty"`
+	VC    *Credential   `json:"vc,omitempty"`
+	VP    *Presentation `json:"vp,omitempty"`
+}
+
+// jwtAlg returns the JWT alg of pub and the signature scheme it requires. The
+// alg is determined by the curve, ES384 is only for P-384 keys for example.
+func jwtAlg(pub keypair.PublicKey) (string, s.SignatureScheme, error) {
+	switch key := pub.(type) {
+	case *ec.PublicKey:
+		if key.Algorithm != ec.ECDSA {
+			break
+		}
+		switch key.Params().Name {
+		case elliptic.P256().Params().Name:
+			return "ES256", s.SHA256withECDSA, nil
+		case elliptic.P384().Params().Name:
+			return "ES384", s.SHA384withECDSA, nil
+		case elliptic.P521().Params().Name:
+			return "ES512", s.SHA512withECDSA, nil
+		}
+	case ed25519.PublicKey:
+		return "EdDSA", s.SHA512withEDDSA, nil
+	}
+	return "", 0, fmt.Errorf("key type is not supported by JWT")
+}
+
+// SignJWT returns credential as a JWT signed by signer, who must be the issuer
+func (this *Credential) SignJWT(signer *Signer) (string, error) {
+	if signer.OntId != this.Issuer {
+		return "", fmt.Errorf("signer %s is not the issuer %s", signer.OntId, this.Issuer)
+	}
+	vc := *this
+	vc.Proof = nil
+	claims := &jwtClaims{Iss: this.Issuer, Sub: this.SubjectId(), Jti: this.Id, VC: &vc}
+	issuance, err := parseTime(this.IssuanceDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid issuanceDate: %s", err)
+	}
+	claims.Nbf, claims.Iat = issuance.Unix(), time.Now().Unix()
+	if this.ExpirationDate != "" {
+		expiration, err := parseTime(this.ExpirationDate)
+		if err != nil {
+			return "", fmt.Errorf("invalid expirationDate: %s", err)
+		}
+		claims.Exp = expiration.Unix()
+	}
+	return signJWT(signer, claims)
+}
+
+// SignJWT returns presentation as a JWT signed by signer, who must be the holder
+// if there is one. audience and nonce prevent the presentation to be replayed.
+func (this *Presentation) SignJWT(signer *Signer, audience, nonce string) (string, error) {
+	if this.Holder != "" && signer.OntId != this.Holder {
+		return "", fmt.Errorf("signer %s is not the holder %s", signer.OntId, this.Holder)
+	}
+	vp := *this
+	vp.Proof = nil
+	claims := &jwtClaims{Iss: signer.OntId, Aud: audience, Jti: this.Id, Nonce: nonce, VP: &vp}
+	claims.Iat = time.Now().Unix()
+	return signJWT(signer, claims)
+}
+
+// VerifyCredentialJWT checks the signature, validity period and revocation status of
+// a credential JWT at now, and returns the credential in it
+func VerifyCredentialJWT(resolver Resolver, token string, now time.Time) (*Credential, error) {
+	claims, err := verifyJWT(resolver, token)
+	if err != nil {
+		return nil, err
+	}
+	cred := claims.VC
+	if cred == nil {
+		return nil, fmt.Errorf("JWT has no vc claim")
+	}
+	if err := checkType(cred.Context, cred.Type, CREDENTIAL_TYPE); err != nil {
+		return nil, err
+	}
+	if claims.Iss != cred.Issuer || claims.Jti != cred.Id {
+		return nil, fmt.Errorf("JWT claims mismatch the credential")
+	}
+	if err := cred.checkState(resolver, now); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// VerifyPresentationJWT checks the signature of a presentation JWT and all the
+// credentials in it, whose subjects must be the holder, and returns the presentation
+func VerifyPresentationJWT(resolver Resolver, token, audience, nonce string, now time.Time) (*Presentation, error) {
+	claims, err := verifyJWT(resolver, token)
+	if err != nil {
+		return nil, err
+	}
+	pres := claims.VP
+	if pres == nil {
+		return nil, fmt.Errorf("JWT has no vp claim")
+	}
+	if err := checkType(pres.Context, pres.Type, PRESENTATION_TYPE); err != nil {
+		return nil, err
+	}
+	if pres.Holder != "" && claims.Iss != pres.Holder {
+		return nil, fmt.Errorf("JWT is not signed by the holder %s", pres.Holder)
+	}
+	if claims.Aud != audience || claims.Nonce != nonce {
+		return nil, fmt.Errorf("audience or nonce mismatch")
+	}
+	creds, err := VerifyCredentials(resolver, pres.VerifiableCredential, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSubjects(creds, claims.Iss); err != nil {
+		return nil, err
+	}
+	return pres, nil
+}
+
+func signJWT(signer *Signer, claims *jwtClaims) (string, error) {
+	alg, scheme, err := jwtAlg(signer.Account.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	if signer.Account.SigScheme != scheme {
+		return "", fmt.Errorf("JWT alg %s requires signature scheme %s, got %s", alg, scheme.Name(),
+			signer.Account.SigScheme.Name())
+	}
+	header, err := json.Marshal(&jwtHeader{Alg: alg, Kid: signer.VerificationMethod(), Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig, err := signature.Sign(signer.Account, []byte(input))
+	if err != nil {
+		return "", fmt.Errorf("sign error: %s", err)
+	}
+	// the serialized signature has a leading scheme byte except for SHA256withECDSA
+	if signer.Account.SigScheme != s.SHA256withECDSA {
+		sig = sig[1:]
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func verifyJWT(resolver Resolver, token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid JWT")
+	}
+	header, payload := new(jwtHeader), new(jwtClaims)
+	if err := decodeJWTPart(parts[0], header); err != nil {
+		return nil, err
+	}
+	if err := decodeJWTPart(parts[1], payload); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT signature")
+	}
+	ontId, index, err := ParseKeyId(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if ontId != payload.Iss {
+		return nil, fmt.Errorf("JWT kid %s does not belong to issuer %s", header.Kid, payload.Iss)
+	}
+	pub, err := resolver.GetPublicKey(ontId, index)
+	if err != nil {
+		return nil, fmt.Errorf("get key %s error: %s", header.Kid, err)
+	}
+	alg, scheme, err := jwtAlg(pub)
+	if err != nil {
+		return nil, err
+	}
+	if header.Alg != alg {
+		return nil, fmt.Errorf("JWT alg %s mismatch the key %s, expect %s", header.Alg, header.Kid, alg)
+	}
+	if scheme != s.SHA256withECDSA {
+		sig = append([]byte{byte(scheme)}, sig...)
+	}
+	if err := signature.Verify(pub, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("invalid JWT encoding")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid JWT: %s", err)
+	}
+	return nil
+}
@@ -19,6 +19,7 @@
 package account
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,7 +27,15 @@ import (
 	"os"
 
 	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+)
+
+const (
+	WALLET_VERSION_1_0 = "1.0" //private keys are encrypted by aes-256-ctr, with salt derived from address
+	WALLET_VERSION_1_1 = "1.1" //private keys are encrypted by aes-256-gcm, with random salt
+	WALLET_VERSION     = WALLET_VERSION_1_1
 )
 
 /** AccountData - for wallet read and save, no crypto object included **/
@@ -38,6 +47,8 @@ type AccountData struct {
 	SigSch    string `json:"signatureScheme"`
 	IsDefault bool   `json:"isDefault"`
 	Lock      bool   `json:"lock"`
+	//DerivePath is the BIP-32 path of account derived from HD seed of wallet
+	DerivePath string `json:"derivePath,omitempty"`
 }
 
 func (this *AccountData) SetKeyPair(keyinfo *keypair.ProtectedKey) {
@@ -66,19 +77,28 @@ func (this *AccountData) SetLabel(label string) {
 	this.Label = label
 }
 
+/** HDSeed - the encrypted BIP-39 seed of HD wallet **/
+type HDSeed struct {
+	EncAlg string               `json:"enc-alg"`
+	Key    []byte               `json:"key"`
+	Salt   []byte               `json:"salt"`
+	Scrypt *keypair.ScryptParam `json:"scrypt"`
+}
+
 type WalletData struct {
 	Name       string               `json:"name"`
 	Version    string               `json:"version"`
 	Scrypt     *keypair.ScryptParam `json:"scrypt"`
 	Identities []Identity           `json:"identities,omitempty"`
 	Accounts   []*AccountData       `json:"accounts,omitempty"`
+	HDSeed     *HDSeed              `json:"hdSeed,omitempty"`
 	Extra      string               `json:"extra,omitempty"`
 }
 
 func NewWalletData() *WalletData {
 	return &WalletData{
 		Name:       "MyWallet",
-		Version:    "1.1",
+		Version:    WALLET_VERSION,
 		Scrypt:     keypair.GetScryptParameters(),
 		Identities: nil,
 		Extra:      "",
@@ -99,6 +119,10 @@ func (this *WalletData) Clone() *WalletData {
 		w.Accounts[i] = &ac
 	}
 	w.Identities = this.Identities
+	if this.HDSeed != nil {
+		seed := *this.HDSeed
+		w.HDSeed = &seed
+	}
 	w.Extra = this.Extra
 	return &w
 }
@@ -178,13 +202,52 @@ func (this *WalletData) ToDefaultSecurity(passwords [][]byte) error {
 	return this.reencrypt(passwords, nil)
 }
 
+// Reencrypt re-encrypts the private keys of all accounts with new scrypt parameters.
+// passwords are the passwords of accounts in order
+func (this *WalletData) Reencrypt(passwords [][]byte, param *keypair.ScryptParam) error {
+	err := CheckScryptParam(param, keypair.DEFAULT_DERIVED_KEY_LENGTH)
+	if err != nil {
+		return err
+	}
+	return this.reencrypt(passwords, param)
+}
+
+// ReencryptHDSeed re-encrypts the HD seed with new scrypt parameters
+func (this *WalletData) ReencryptHDSeed(passwd []byte, param *keypair.ScryptParam) error {
+	if this.HDSeed == nil {
+		return fmt.Errorf("wallet has no HD seed")
+	}
+	err := CheckScryptParam(param, keypair.DEFAULT_DERIVED_KEY_LENGTH)
+	if err != nil {
+		return err
+	}
+	seed, err := DecryptHDSeed(this.HDSeed, passwd)
+	if err != nil {
+		return err
+	}
+	hdSeed, err := EncryptHDSeed(seed, passwd, param)
+	if err != nil {
+		return fmt.Errorf("encrypt HD seed error: %s", err)
+	}
+	this.HDSeed = hdSeed
+	return nil
+}
+
 func (this *WalletData) reencrypt(passwords [][]byte, param *keypair.ScryptParam) error {
+	if param == nil {
+		// default parameters
+		param = keypair.GetScryptParameters()
+	}
 	if len(passwords) != len(this.Accounts) {
 		return errors.New("not enough passwords for the accounts")
 	}
 	keys := make([]*keypair.ProtectedKey, len(this.Accounts))
 	for i, v := range this.Accounts {
-		prot, err := keypair.ReencryptPrivateKey(&v.ProtectedKey, passwords[i], passwords[i], this.Scrypt, param)
+		prvkey, err := this.decryptAccount(v, passwords[i])
+		if err != nil {
+			return fmt.Errorf("re-encrypt account %d failed: %s", i, err)
+		}
+		prot, err := keypair.EncryptWithCustomScrypt(prvkey, v.Address, passwords[i], param)
 		if err != nil {
 			return fmt.Errorf("re-encrypt account %d failed: %s", i, err)
 		}
@@ -194,15 +257,131 @@ func (this *WalletData) reencrypt(passwords [][]byte, param *keypair.ScryptParam
 	for i, v := range keys {
 		this.Accounts[i].SetKeyPair(v)
 	}
-	if param != nil {
-		this.Scrypt = param
-	} else {
-		// default parameters
+	sp := *param
+	this.Scrypt = &sp
+	return nil
+}
+
+// decryptAccount decrypts the private key of account, and checks the key matches the address.
+// Key encrypted by aes-256-ctr can be "decrypted" by wrong password, so the check is necessary
+func (this *WalletData) decryptAccount(acc *AccountData, passwd []byte) (keypair.PrivateKey, error) {
+	param := this.Scrypt
+	if param == nil {
+		param = keypair.GetScryptParameters()
+	} else if param.DKLen == 0 {
+		// dkLen is absent in wallet of version 1.0
+		sp := *param
+		sp.DKLen = keypair.DEFAULT_DERIVED_KEY_LENGTH
+		param = &sp
+	}
+	prvkey, err := keypair.DecryptWithCustomScrypt(&acc.ProtectedKey, passwd, param)
+	if err != nil {
+		return nil, err
+	}
+	address := types.AddressFromPubKey(prvkey.Public())
+	if address.ToBase58() != acc.Address {
+		return nil, fmt.Errorf("key of account %s does not match the address, wrong password or corrupted key", acc.Address)
+	}
+	return prvkey, nil
+}
+
+// NeedUpgrade return whether the wallet is in an old format, which should be upgraded by Upgrade
+func (this *WalletData) NeedUpgrade() bool {
+	if this.Version != WALLET_VERSION {
+		return true
+	}
+	for i := range this.Accounts {
+		if this.AccountNeedUpgrade(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountNeedUpgrade return whether the account of index should be decrypted to upgrade. Index start from 0
+func (this *WalletData) AccountNeedUpgrade(index int) bool {
+	acc := this.GetAccountByIndex(index)
+	if acc == nil {
+		return false
+	}
+	return acc.EncAlg != "aes-256-gcm" || acc.PubKey == "" || acc.SigSch == ""
+}
+
+// Upgrade upgrades the wallet to current version. Private keys encrypted by aes-256-ctr are re-encrypted by
+// aes-256-gcm, and the missing public key and signature scheme of accounts are filled.
+// passwords are the passwords of accounts in order, only used by the accounts which AccountNeedUpgrade
+func (this *WalletData) Upgrade(passwords [][]byte) error {
+	switch this.Version {
+	case "", WALLET_VERSION_1_0, WALLET_VERSION_1_1:
+	default:
+		return fmt.Errorf("unsupported wallet version: %s", this.Version)
+	}
+	if len(passwords) != len(this.Accounts) {
+		return errors.New("not enough passwords for the accounts")
+	}
+	if this.Scrypt == nil {
 		this.Scrypt = keypair.GetScryptParameters()
+	} else if this.Scrypt.DKLen == 0 {
+		this.Scrypt.DKLen = keypair.DEFAULT_DERIVED_KEY_LENGTH
 	}
+	accounts := make([]*AccountData, len(this.Accounts))
+	for i, v := range this.Accounts {
+		if !this.AccountNeedUpgrade(i) {
+			accounts[i] = v
+			continue
+		}
+		prvkey, err := this.decryptAccount(v, passwords[i])
+		if err != nil {
+			return fmt.Errorf("upgrade account %d failed: %s", i, err)
+		}
+		acc := *v
+		if acc.EncAlg != "aes-256-gcm" {
+			prot, err := keypair.EncryptWithCustomScrypt(prvkey, v.Address, passwords[i], this.Scrypt)
+			if err != nil {
+				return fmt.Errorf("upgrade account %d failed: %s", i, err)
+			}
+			acc.SetKeyPair(prot)
+		}
+		if acc.PubKey == "" {
+			acc.PubKey = hex.EncodeToString(keypair.SerializePublicKey(prvkey.Public()))
+		}
+		if acc.SigSch == "" {
+			scheme, err := defaultSigScheme(prvkey.Public())
+			if err != nil {
+				return fmt.Errorf("upgrade account %d failed: %s", i, err)
+			}
+			acc.SigSch = scheme.Name()
+		}
+		accounts[i] = &acc
+	}
+	this.Accounts = accounts
+	this.Version = WALLET_VERSION
 	return nil
 }
 
+// defaultSigScheme return the default signature scheme of key type
+func defaultSigScheme(pubkey keypair.PublicKey) (s.SignatureScheme, error) {
+	switch keypair.GetKeyType(pubkey) {
+	case keypair.PK_ECDSA:
+		return s.SHA256withECDSA, nil
+	case keypair.PK_SM2:
+		return s.SM3withSM2, nil
+	case keypair.PK_EDDSA:
+		return s.SHA512withEDDSA, nil
+	default:
+		return 0, fmt.Errorf("unsupported key type")
+	}
+}
+
 func (this *WalletData) AddIdentity(id *Identity) {
 	this.Identities = append(this.Identities, *id)
 }
+
+func (this *WalletData) GetIdentity(id string) *Identity {
+	for i := range this.Identities {
+		if this.Identities[i].ID == id {
+			return &this.Identities[i]
+		}
+	}
+	return nil
+}
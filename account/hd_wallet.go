@@ -0,0 +1,220 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	ONT_COIN_TYPE = 1024 //BIP-44 coin type of ontology, derived key is used on P-256 curve
+	ETH_COIN_TYPE = 60   //BIP-44 coin type of ethereum, derived key is used on secp256k1 curve
+
+	HARDENED_KEY_START = 0x80000000
+)
+
+// Bip44Path returns the BIP-44 path m/44'/coinType'/0'/0/index of the external chain of first account
+func Bip44Path(coinType, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/0'/0/%d", coinType, index)
+}
+
+// ParseDerivePath parses BIP-32 derivation path like m/44'/1024'/0'/0/0 to child indexes
+func ParseDerivePath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("derive path should start with m: %s", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := false
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			hardened = true
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || index >= HARDENED_KEY_START {
+			return nil, fmt.Errorf("invalid derive path: %s", path)
+		}
+		if hardened {
+			index += HARDENED_KEY_START
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// coinCurve returns the curve of keys derived for coin type
+func coinCurve(coinType uint32) (byte, error) {
+	switch coinType {
+	case ONT_COIN_TYPE:
+		return keypair.P256, nil
+	case ETH_COIN_TYPE:
+		return keypair.SECP256K1, nil
+	default:
+		return 0, fmt.Errorf("unsupported coin type: %d", coinType)
+	}
+}
+
+// DeriveKey derives the BIP-32 private key and chain code of path from seed on secp256k1 curve
+func DeriveKey(seed []byte, path string) ([]byte, []byte, error) {
+	indexes, err := ParseDerivePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	curve, err := keypair.GetCurve(keypair.SECP256K1)
+	if err != nil {
+		return nil, nil, err
+	}
+	n := curve.Params().N
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid master key")
+	}
+
+	for _, index := range indexes {
+		data := make([]byte, 0, 37)
+		if index >= HARDENED_KEY_START {
+			data = append(data, 0)
+			data = append(data, paddedBytes(key, 32)...)
+		} else {
+			x, y := curve.ScalarBaseMult(paddedBytes(key, 32))
+			data = append(data, byte(2+y.Bit(0)))
+			data = append(data, paddedBytes(x, 32)...)
+		}
+		var indexBytes [4]byte
+		binary.BigEndian.PutUint32(indexBytes[:], index)
+		data = append(data, indexBytes[:]...)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		key.Add(key, tweak)
+		key.Mod(key, n)
+		if key.Sign() == 0 {
+			return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		chainCode = sum[32:]
+	}
+	return paddedBytes(key, 32), chainCode, nil
+}
+
+// DeriveKeyPair derives the key pair of path from seed, and uses the key on curve.
+// The derivation always runs on secp256k1 as BIP-32 defines, which is compatible with other ontology wallets
+func DeriveKeyPair(seed []byte, path string, curveLabel byte) (keypair.PrivateKey, keypair.PublicKey, error) {
+	key, _, err := DeriveKey(seed, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	curve, err := keypair.GetCurve(curveLabel)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := new(big.Int).SetBytes(key)
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, nil, fmt.Errorf("derived key of %s is out of range of curve %s", path, curve.Params().Name)
+	}
+	pri := &ec.PrivateKey{
+		Algorithm:  ec.ECDSA,
+		PrivateKey: ec.ConstructPrivateKey(key, curve),
+	}
+	return pri, pri.Public(), nil
+}
+
+func paddedBytes(num *big.Int, size int) []byte {
+	buf := make([]byte, size)
+	data := num.Bytes()
+	copy(buf[size-len(data):], data)
+	return buf
+}
+
+// EncryptHDSeed encrypts seed with password, the same way as private key encryption of wallet
+func EncryptHDSeed(seed, passwd []byte, param *keypair.ScryptParam) (*HDSeed, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, nonce, err := hdSeedCipher(passwd, salt, param)
+	if err != nil {
+		return nil, err
+	}
+	sp := *param
+	return &HDSeed{
+		EncAlg: "aes-256-gcm",
+		Key:    gcm.Seal(nil, nonce, seed, nil),
+		Salt:   salt,
+		Scrypt: &sp,
+	}, nil
+}
+
+// DecryptHDSeed decrypts seed with password
+func DecryptHDSeed(hdSeed *HDSeed, passwd []byte) ([]byte, error) {
+	if hdSeed.EncAlg != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported encryption algorithm: %s", hdSeed.EncAlg)
+	}
+	gcm, nonce, err := hdSeedCipher(passwd, hdSeed.Salt, hdSeed.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := gcm.Open(nil, nonce, hdSeed.Key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt HD seed failed, wrong password")
+	}
+	return seed, nil
+}
+
+func hdSeedCipher(passwd, salt []byte, param *keypair.ScryptParam) (cipher.AEAD, []byte, error) {
+	if param == nil || param.DKLen < 44 {
+		return nil, nil, fmt.Errorf("invalid scrypt parameters")
+	}
+	dkey, err := scrypt.Key(passwd, salt, param.N, param.R, param.P, param.DKLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := aes.NewCipher(dkey[len(dkey)-32:])
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gcm, dkey[:12], nil
+}
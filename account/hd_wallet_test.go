@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestMnemonic(t *testing.T) {
+	mnemonic, err := EntropyToMnemonic(make([]byte, 16))
+	assert.Nil(t, err)
+	assert.Equal(t, testMnemonic, mnemonic)
+	seed := MnemonicToSeed(mnemonic, "TREZOR")
+	assert.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		hex.EncodeToString(seed))
+
+	entropy, _ := hex.DecodeString("7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f")
+	mnemonic, err = EntropyToMnemonic(entropy)
+	assert.Nil(t, err)
+	assert.Equal(t, "legal winner thank year wave sausage worth useful legal winner thank yellow", mnemonic)
+
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err = NewMnemonic(bits)
+		assert.Nil(t, err)
+		entropy, err = MnemonicToEntropy(mnemonic)
+		assert.Nil(t, err)
+		assert.Equal(t, bits/8, len(entropy))
+	}
+	_, err = NewMnemonic(100)
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"))
+	assert.NotNil(t, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon xyz"))
+}
+
+func TestDeriveKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	key, chainCode, err := DeriveKey(seed, "m")
+	assert.Nil(t, err)
+	assert.Equal(t, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", hex.EncodeToString(key))
+	assert.Equal(t, "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", hex.EncodeToString(chainCode))
+	key, chainCode, err = DeriveKey(seed, "m/0'")
+	assert.Nil(t, err)
+	assert.Equal(t, "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", hex.EncodeToString(key))
+	assert.Equal(t, "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141", hex.EncodeToString(chainCode))
+	key, _, err = DeriveKey(seed, "m/0H/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", hex.EncodeToString(key))
+
+	_, _, err = DeriveKey(seed, "44'/0'")
+	assert.NotNil(t, err)
+	_, _, err = DeriveKey(seed, "m/2147483648")
+	assert.NotNil(t, err)
+
+	_, pub, err := DeriveKeyPair(MnemonicToSeed(testMnemonic, ""), Bip44Path(ETH_COIN_TYPE, 0), keypair.SECP256K1)
+	assert.Nil(t, err)
+	assert.Equal(t, "0x9858EfFD232B4033E47d90003D41EC34EcaEda94", crypto.PubkeyToAddress(*pub.(*ec.PublicKey).PublicKey).Hex())
+}
+
+func TestDeriveAccount(t *testing.T) {
+	path := "./wallet_hd_test.dat"
+	defer os.Remove(path)
+	wallet, err := Open(path)
+	assert.Nil(t, err)
+	_, err = wallet.DeriveAccount("", ONT_COIN_TYPE, testPasswd)
+	assert.NotNil(t, err, "derive without HD seed")
+
+	wallet.GetWalletData().Scrypt = &lowSecurityParam
+	err = wallet.ImportMnemonic(testMnemonic, "", testPasswd)
+	assert.Nil(t, err)
+	err = wallet.ImportMnemonic(testMnemonic, "", testPasswd)
+	assert.NotNil(t, err, "import mnemonic twice")
+
+	acc1, err := wallet.DeriveAccount("ont1", ONT_COIN_TYPE, testPasswd)
+	assert.Nil(t, err)
+	acc2, err := wallet.DeriveAccount("ont2", ONT_COIN_TYPE, testPasswd)
+	assert.Nil(t, err)
+	acc3, err := wallet.DeriveAccount("eth1", ETH_COIN_TYPE, testPasswd)
+	assert.Nil(t, err)
+	_, err = wallet.DeriveAccount("eth2", ETH_COIN_TYPE, []byte("wrong"))
+	assert.NotNil(t, err)
+	_, err = wallet.DeriveAccount("", 0, testPasswd)
+	assert.NotNil(t, err)
+	assert.Equal(t, keypair.P256, mustCurveLabel(t, acc1.PublicKey))
+	assert.Equal(t, keypair.SECP256K1, mustCurveLabel(t, acc3.PublicKey))
+	assert.Equal(t, "m/44'/1024'/0'/0/1", wallet.GetAccountMetadataByAddress(acc2.Address.ToBase58()).DerivePath)
+	assert.Equal(t, "m/44'/60'/0'/0/0", wallet.GetAccountMetadataByLabel("eth1").DerivePath)
+
+	//restore the same accounts from mnemonic in another wallet
+	restorePath := "./wallet_hd_restore_test.dat"
+	defer os.Remove(restorePath)
+	restored, err := Open(restorePath)
+	assert.Nil(t, err)
+	restored.GetWalletData().Scrypt = &lowSecurityParam
+	err = restored.ImportMnemonic(testMnemonic, "", testPasswd)
+	assert.Nil(t, err)
+	for _, acc := range []*Account{acc1, acc2} {
+		restoredAcc, err := restored.DeriveAccount("", ONT_COIN_TYPE, testPasswd)
+		assert.Nil(t, err)
+		assert.Equal(t, acc.Address, restoredAcc.Address)
+	}
+
+	//reload wallet from file
+	reopened, err := Open(path)
+	assert.Nil(t, err)
+	acc4, err := reopened.DeriveAccount("ont3", ONT_COIN_TYPE, testPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, "m/44'/1024'/0'/0/2", reopened.GetAccountMetadataByAddress(acc4.Address.ToBase58()).DerivePath)
+	acc, err := reopened.GetAccountByLabel("eth1", testPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, acc3.Address, acc.Address)
+}
+
+func mustCurveLabel(t *testing.T, pub keypair.PublicKey) byte {
+	label, err := keypair.GetCurveLabel(pub.(*ec.PublicKey).Curve)
+	assert.Nil(t, err)
+	return label
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/itchyny/base58-go"
 	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
 	"github.com/qbyyf/ontology/core/types"
 	"golang.org/x/crypto/ripemd160"
 )
@@ -141,3 +142,40 @@ func NewIdentity(label string, keyType keypair.KeyType, param interface{}, passw
 
 	return &res, nil
 }
+
+// GetController returns the controller of identity with the key id, nil if not found
+func (this *Identity) GetController(keyId string) *Controller {
+	for i := range this.Control {
+		if this.Control[i].ID == keyId {
+			return &this.Control[i]
+		}
+	}
+	return nil
+}
+
+// GetAccount decrypts the controller key with password, and returns it as an account
+// signing with the default scheme of its key type
+func (this *Controller) GetAccount(password []byte) (*Account, error) {
+	pri, err := keypair.DecryptPrivateKey(&this.ProtectedKey, password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key error, %s", err)
+	}
+	pub := pri.Public()
+	var scheme s.SignatureScheme
+	switch keypair.GetKeyType(pub) {
+	case keypair.PK_ECDSA:
+		scheme = s.SHA256withECDSA
+	case keypair.PK_SM2:
+		scheme = s.SM3withSM2
+	case keypair.PK_EDDSA:
+		scheme = s.SHA512withEDDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type")
+	}
+	return &Account{
+		PrivateKey: pri,
+		PublicKey:  pub,
+		Address:    types.AddressFromPubKey(pub),
+		SigScheme:  scheme,
+	}, nil
+}
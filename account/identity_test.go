@@ -21,6 +21,8 @@ package account
 import (
 	"encoding/hex"
 	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
 )
 
 var id = "did:ont:TSS6S4Xhzt5wtvRBTm4y3QCTRqB4BnU7vT"
@@ -58,3 +60,30 @@ func TestVerify(t *testing.T) {
 		}
 	}
 }
+
+func TestIdentityControllerAccount(t *testing.T) {
+	passwd := []byte("passwd")
+	identity, err := NewIdentity("label", keypair.PK_ECDSA, keypair.P256, passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd := NewWalletData()
+	wd.AddIdentity(identity)
+	if wd.GetIdentity("did:ont:unknown") != nil {
+		t.Fatal("unknown identity should not be found")
+	}
+	controller := wd.GetIdentity(identity.ID).GetController("1")
+	if controller == nil {
+		t.Fatal("controller 1 not found")
+	}
+	acc, err := controller.GetAccount(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)) != controller.Public {
+		t.Fatal("public key of controller account mismatch")
+	}
+	if _, err = controller.GetAccount([]byte("wrong")); err == nil {
+		t.Fatal("decrypt with wrong password should fail")
+	}
+}
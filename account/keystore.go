@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/go-ethereum/accounts/keystore"
+	"github.com/qbyyf/go-ethereum/crypto"
+)
+
+const (
+	ETH_KEYSTORE_SCRYPT_N = keystore.StandardScryptN //default scrypt N of exported ethereum keystore
+	ETH_KEYSTORE_SCRYPT_P = keystore.StandardScryptP //default scrypt P of exported ethereum keystore
+)
+
+// ExportEthKeystore encrypts the secp256k1 private key to ethereum V3 keystore json with password
+func ExportEthKeystore(prvkey keypair.PrivateKey, passwd []byte, scryptN, scryptP int) ([]byte, error) {
+	d, err := secp256k1KeyBytes(prvkey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.ToECDSA(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secp256k1 private key: %s", err)
+	}
+	ksKey := &keystore.Key{
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: key,
+	}
+	//random UUID of version 4 as keystore id
+	_, err = rand.Read(ksKey.Id[:])
+	if err != nil {
+		return nil, err
+	}
+	ksKey.Id[6] = (ksKey.Id[6] & 0x0f) | 0x40
+	ksKey.Id[8] = (ksKey.Id[8] & 0x3f) | 0x80
+	return keystore.EncryptKey(ksKey, string(passwd), scryptN, scryptP)
+}
+
+// ImportEthKeystore decrypts ethereum keystore json with password, and returns the private key on secp256k1 curve
+func ImportEthKeystore(keyJson []byte, passwd []byte) (keypair.PrivateKey, error) {
+	ksKey, err := keystore.DecryptKey(keyJson, string(passwd))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore error: %s", err)
+	}
+	curve, err := keypair.GetCurve(keypair.SECP256K1)
+	if err != nil {
+		return nil, err
+	}
+	return &ec.PrivateKey{
+		Algorithm:  ec.ECDSA,
+		PrivateKey: ec.ConstructPrivateKey(crypto.FromECDSA(ksKey.PrivateKey), curve),
+	}, nil
+}
+
+// secp256k1KeyBytes returns the 32 bytes scalar of secp256k1 private key
+func secp256k1KeyBytes(prvkey keypair.PrivateKey) ([]byte, error) {
+	switch key := prvkey.(type) {
+	case *ec.EthereumPrivateKey:
+		return paddedBytes(key.D, 32), nil
+	case *ec.PrivateKey:
+		label, err := keypair.GetCurveLabel(key.Curve)
+		if err != nil || label != keypair.SECP256K1 || key.Algorithm != ec.ECDSA {
+			return nil, fmt.Errorf("only ECDSA key on secp256k1 curve can be exported to ethereum keystore")
+		}
+		return paddedBytes(key.D, 32), nil
+	default:
+		return nil, fmt.Errorf("only ECDSA key on secp256k1 curve can be exported to ethereum keystore")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/qbyyf/go-ethereum/accounts/keystore"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthKeystore(t *testing.T) {
+	prvkey, _, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.SECP256K1)
+	assert.Nil(t, err)
+	passwd := []byte("123456")
+	keyJson, err := ExportEthKeystore(prvkey, passwd, keystore.LightScryptN, keystore.LightScryptP)
+	assert.Nil(t, err)
+
+	ethKey, err := crypto.ToECDSA(prvkey.(*ec.PrivateKey).D.Bytes())
+	assert.Nil(t, err)
+	v3 := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(keyJson, &v3))
+	assert.Equal(t, float64(3), v3["version"])
+	assert.Equal(t, hex.EncodeToString(crypto.PubkeyToAddress(ethKey.PublicKey).Bytes()), v3["address"])
+
+	imported, err := ImportEthKeystore(keyJson, passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, keypair.SerializePrivateKey(prvkey), keypair.SerializePrivateKey(imported))
+
+	_, err = ImportEthKeystore(keyJson, []byte("654321"))
+	assert.NotNil(t, err)
+
+	p256Key, _, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	_, err = ExportEthKeystore(p256Key, passwd, keystore.LightScryptN, keystore.LightScryptP)
+	assert.NotNil(t, err)
+}
+
+func TestClientImportPrivateKey(t *testing.T) {
+	prvkey, pubkey, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.SECP256K1)
+	assert.Nil(t, err)
+	acc, err := testWallet.ImportPrivateKey("", prvkey, s.SHA256withECDSA, testPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, keypair.SerializePublicKey(pubkey), keypair.SerializePublicKey(acc.PublicKey))
+
+	acc2, err := testWallet.GetAccountByAddress(acc.Address.ToBase58(), testPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, keypair.SerializePrivateKey(prvkey), keypair.SerializePrivateKey(acc2.PrivateKey))
+
+	_, err = testWallet.ImportPrivateKey("", prvkey, s.SHA256withECDSA, testPasswd)
+	assert.NotNil(t, err)
+}
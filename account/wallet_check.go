@@ -0,0 +1,234 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+)
+
+// CheckIntegrity checks the wallet data without password, and returns the problems found.
+// Corrupted entries are detected here, instead of failing to sign transaction later
+func (this *WalletData) CheckIntegrity() []error {
+	errs := make([]error, 0)
+	switch this.Version {
+	case "", WALLET_VERSION_1_0, WALLET_VERSION_1_1:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported wallet version: %s", this.Version))
+	}
+	err := CheckScryptParam(this.Scrypt, 32)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("wallet scrypt: %s", err))
+	}
+
+	addresses := make(map[string]int)
+	labels := make(map[string]int)
+	defaults := 0
+	for i, acc := range this.Accounts {
+		if acc == nil {
+			errs = append(errs, fmt.Errorf("account %d: empty account", i+1))
+			continue
+		}
+		err = checkAccountData(acc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %d (%s): %s", i+1, acc.Address, err))
+		}
+		if j, ok := addresses[acc.Address]; ok {
+			errs = append(errs, fmt.Errorf("account %d (%s): duplicate address of account %d", i+1, acc.Address, j))
+		} else {
+			addresses[acc.Address] = i + 1
+		}
+		if acc.Label != "" {
+			if j, ok := labels[acc.Label]; ok {
+				errs = append(errs, fmt.Errorf("account %d (%s): duplicate label %s of account %d", i+1, acc.Address, acc.Label, j))
+			} else {
+				labels[acc.Label] = i + 1
+			}
+		}
+		if acc.IsDefault {
+			defaults++
+		}
+	}
+	if len(this.Accounts) > 0 && defaults != 1 {
+		errs = append(errs, fmt.Errorf("wallet should have one default account, but has %d", defaults))
+	}
+
+	if this.HDSeed != nil {
+		err = checkHDSeed(this.HDSeed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("HD seed: %s", err))
+		}
+	}
+	for i := range this.Identities {
+		identity := &this.Identities[i]
+		err = checkIdentity(identity)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("identity %d (%s): %s", i+1, identity.ID, err))
+		}
+	}
+	return errs
+}
+
+// VerifyAccountKey decrypts the private key of account with password, and checks it matches the address and
+// public key (if present) of account. Index start from 0
+func (this *WalletData) VerifyAccountKey(index int, passwd []byte) error {
+	acc := this.GetAccountByIndex(index)
+	if acc == nil {
+		return fmt.Errorf("cannot find account by index: %d", index+1)
+	}
+	prvkey, err := this.decryptAccount(acc, passwd)
+	if err != nil {
+		return err
+	}
+	if acc.PubKey != "" && acc.PubKey != hex.EncodeToString(keypair.SerializePublicKey(prvkey.Public())) {
+		return fmt.Errorf("key of account %s does not match the public key", acc.Address)
+	}
+	return nil
+}
+
+func checkAccountData(acc *AccountData) error {
+	address, err := common.AddressFromBase58(acc.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address: %s", err)
+	}
+	err = checkProtectedKey(&acc.ProtectedKey)
+	if err != nil {
+		return err
+	}
+	if !matchSigScheme(acc.Alg, acc.SigSch) {
+		return fmt.Errorf("sigScheme: %s does not match KeyType: %s", acc.SigSch, acc.Alg)
+	}
+	if acc.PubKey == "" {
+		return fmt.Errorf("missing public key")
+	}
+	err = checkPubKey(acc.PubKey, address)
+	if err != nil {
+		return err
+	}
+	if acc.DerivePath != "" {
+		_, err = ParseDerivePath(acc.DerivePath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkProtectedKey(prot *keypair.ProtectedKey) error {
+	switch prot.EncAlg {
+	case "aes-256-gcm":
+		if len(prot.Salt) != 16 {
+			return fmt.Errorf("invalid salt length: %d", len(prot.Salt))
+		}
+		//ciphertext of gcm contains 16 bytes tag
+		if len(prot.Key) <= 16 {
+			return fmt.Errorf("invalid encrypted key length: %d", len(prot.Key))
+		}
+	case "aes-256-ctr":
+		if len(prot.Key) == 0 {
+			return fmt.Errorf("empty encrypted key")
+		}
+	default:
+		return fmt.Errorf("unsupported encryption algorithm: %s", prot.EncAlg)
+	}
+	switch prot.Alg {
+	case "ECDSA", "SM2":
+		_, err := keypair.GetNamedCurve(prot.Param["curve"])
+		if err != nil {
+			return fmt.Errorf("invalid curve: %s", err)
+		}
+	case "Ed25519":
+	default:
+		return fmt.Errorf("unsupported key type: %s", prot.Alg)
+	}
+	return nil
+}
+
+func checkPubKey(pubKey string, address common.Address) error {
+	data, err := hex.DecodeString(pubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %s", err)
+	}
+	pub, err := keypair.DeserializePublicKey(data)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %s", err)
+	}
+	if types.AddressFromPubKey(pub) != address {
+		return fmt.Errorf("public key does not match the address")
+	}
+	return nil
+}
+
+func checkHDSeed(hdSeed *HDSeed) error {
+	if hdSeed.EncAlg != "aes-256-gcm" {
+		return fmt.Errorf("unsupported encryption algorithm: %s", hdSeed.EncAlg)
+	}
+	if len(hdSeed.Salt) != 16 {
+		return fmt.Errorf("invalid salt length: %d", len(hdSeed.Salt))
+	}
+	//64 bytes BIP-39 seed with 16 bytes tag of gcm
+	if len(hdSeed.Key) != 64+16 {
+		return fmt.Errorf("invalid encrypted seed length: %d", len(hdSeed.Key))
+	}
+	return CheckScryptParam(hdSeed.Scrypt, 44)
+}
+
+func checkIdentity(identity *Identity) error {
+	if !VerifyID(identity.ID) {
+		return fmt.Errorf("invalid ONT ID")
+	}
+	for _, ctrl := range identity.Control {
+		address, err := common.AddressFromBase58(ctrl.Address)
+		if err != nil {
+			return fmt.Errorf("controller %s: invalid address: %s", ctrl.ID, err)
+		}
+		err = checkProtectedKey(&ctrl.ProtectedKey)
+		if err != nil {
+			return fmt.Errorf("controller %s: %s", ctrl.ID, err)
+		}
+		if ctrl.Public != "" {
+			err = checkPubKey(ctrl.Public, address)
+			if err != nil {
+				return fmt.Errorf("controller %s: %s", ctrl.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckScryptParam checks the scrypt parameters can be used to derive key of minimal length
+func CheckScryptParam(param *keypair.ScryptParam, minDKLen int) error {
+	if param == nil {
+		return fmt.Errorf("missing scrypt parameters")
+	}
+	if param.N <= 1 || param.N&(param.N-1) != 0 {
+		return fmt.Errorf("scrypt N should be a power of 2 greater than 1")
+	}
+	if param.R <= 0 || param.P <= 0 || uint64(param.R)*uint64(param.P) >= 1<<30 {
+		return fmt.Errorf("invalid scrypt R or P")
+	}
+	if param.DKLen < minDKLen {
+		return fmt.Errorf("scrypt dkLen should not be less than %d", minDKLen)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/scrypt"
+)
+
+var testScryptParam = keypair.ScryptParam{N: 1024, R: 8, P: 1, DKLen: 64}
+
+// genCtrAccountData creates account encrypted by aes-256-ctr as wallet of version 1.0 does
+func genCtrAccountData(t *testing.T, passwd []byte, param *keypair.ScryptParam) (*AccountData, keypair.PrivateKey) {
+	prvkey, pubkey, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	address := types.AddressFromPubKey(pubkey)
+	addressBase58 := address.ToBase58()
+	digest := sha256.Sum256([]byte(addressBase58))
+	digest = sha256.Sum256(digest[:])
+	dkey, err := scrypt.Key(passwd, digest[:4], param.N, param.R, param.P, param.DKLen)
+	assert.Nil(t, err)
+	block, err := aes.NewCipher(dkey[len(dkey)-32:])
+	assert.Nil(t, err)
+	plaintext := prvkey.(*ec.PrivateKey).D.Bytes()
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCTR(block, dkey[:16]).XORKeyStream(ciphertext, plaintext)
+
+	acc := &AccountData{}
+	acc.Address = addressBase58
+	acc.EncAlg = "aes-256-ctr"
+	acc.Alg = "ECDSA"
+	acc.Key = ciphertext
+	acc.Param = map[string]string{"curve": "P-256"}
+	return acc, prvkey
+}
+
+func genTestWallet(t *testing.T, passwd []byte, size int) *WalletData {
+	wallet := NewWalletData()
+	sp := testScryptParam
+	wallet.Scrypt = &sp
+	for i := 0; i < size; i++ {
+		prvkey, pubkey, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+		assert.Nil(t, err)
+		address := types.AddressFromPubKey(pubkey)
+		prot, err := keypair.EncryptWithCustomScrypt(prvkey, address.ToBase58(), passwd, wallet.Scrypt)
+		assert.Nil(t, err)
+		acc := &AccountData{}
+		acc.SetKeyPair(prot)
+		acc.SigSch = "SHA256withECDSA"
+		acc.PubKey = hex.EncodeToString(keypair.SerializePublicKey(pubkey))
+		acc.IsDefault = i == 0
+		wallet.AddAccount(acc)
+	}
+	return wallet
+}
+
+func TestWalletUpgrade(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := genTestWallet(t, passwd, 1)
+	wallet.Version = WALLET_VERSION_1_0
+	ctrAcc, prvkey := genCtrAccountData(t, passwd, wallet.Scrypt)
+	wallet.AddAccount(ctrAcc)
+	assert.True(t, wallet.NeedUpgrade())
+	assert.False(t, wallet.AccountNeedUpgrade(0))
+	assert.True(t, wallet.AccountNeedUpgrade(1))
+
+	err := wallet.Upgrade([][]byte{nil, []byte("654321")})
+	assert.NotNil(t, err)
+	assert.Equal(t, "aes-256-ctr", wallet.Accounts[1].EncAlg)
+
+	err = wallet.Upgrade([][]byte{nil, passwd})
+	assert.Nil(t, err)
+	assert.False(t, wallet.NeedUpgrade())
+	assert.Equal(t, WALLET_VERSION, wallet.Version)
+	acc := wallet.Accounts[1]
+	assert.Equal(t, "aes-256-gcm", acc.EncAlg)
+	assert.Equal(t, "SHA256withECDSA", acc.SigSch)
+	assert.Equal(t, hex.EncodeToString(keypair.SerializePublicKey(prvkey.Public())), acc.PubKey)
+	assert.Nil(t, wallet.VerifyAccountKey(1, passwd))
+	assert.Equal(t, 0, len(wallet.CheckIntegrity()))
+
+	wallet.Version = "2.0"
+	assert.NotNil(t, wallet.Upgrade([][]byte{nil, passwd}))
+}
+
+func TestWalletReencrypt(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := genTestWallet(t, passwd, 2)
+	param := &keypair.ScryptParam{N: 2048, R: 8, P: 2, DKLen: 64}
+	err := wallet.Reencrypt([][]byte{passwd, []byte("654321")}, param)
+	assert.NotNil(t, err)
+	assert.Equal(t, testScryptParam, *wallet.Scrypt)
+
+	err = wallet.Reencrypt([][]byte{passwd, passwd}, &keypair.ScryptParam{N: 1000, R: 8, P: 2, DKLen: 64})
+	assert.NotNil(t, err)
+
+	err = wallet.Reencrypt([][]byte{passwd, passwd}, param)
+	assert.Nil(t, err)
+	assert.Equal(t, *param, *wallet.Scrypt)
+	assert.Nil(t, wallet.VerifyAccountKey(0, passwd))
+	assert.Nil(t, wallet.VerifyAccountKey(1, passwd))
+	assert.NotNil(t, wallet.VerifyAccountKey(1, []byte("654321")))
+}
+
+func TestWalletCheckIntegrity(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := genTestWallet(t, passwd, 3)
+	assert.Equal(t, 0, len(wallet.CheckIntegrity()))
+
+	wallet.Accounts[0].PubKey = wallet.Accounts[1].PubKey
+	assert.Equal(t, 1, len(wallet.CheckIntegrity()))
+	assert.NotNil(t, wallet.VerifyAccountKey(0, passwd))
+
+	wallet = genTestWallet(t, passwd, 3)
+	wallet.Accounts[1].Label = "a"
+	wallet.Accounts[2].Label = "a"
+	wallet.Accounts[2].IsDefault = true
+	wallet.Accounts[1].Salt = wallet.Accounts[1].Salt[:8]
+	assert.Equal(t, 3, len(wallet.CheckIntegrity()))
+
+	wallet = genTestWallet(t, passwd, 1)
+	wallet.Scrypt.N = 1000
+	wallet.Accounts[0].SigSch = "SM3withSM2"
+	assert.Equal(t, 2, len(wallet.CheckIntegrity()))
+}
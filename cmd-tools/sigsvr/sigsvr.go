@@ -25,12 +25,16 @@ import (
 
 	"github.com/qbyyf/ontology/cmd"
 	"github.com/qbyyf/ontology/cmd/abi"
+	cmdcom "github.com/qbyyf/ontology/cmd/common"
 	cmdsvr "github.com/qbyyf/ontology/cmd/sigsvr"
+	"github.com/qbyyf/ontology/cmd/sigsvr/audit"
 	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	"github.com/qbyyf/ontology/cmd/sigsvr/policy"
 	"github.com/qbyyf/ontology/cmd/sigsvr/store"
 	"github.com/qbyyf/ontology/cmd/utils"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/core/types"
 	"github.com/urfave/cli"
 )
 
@@ -47,9 +51,25 @@ func setupSigSvr() *cli.App {
 		utils.CliAddressFlag,
 		utils.CliRpcPortFlag,
 		utils.CliABIPathFlag,
+		utils.RPCPortFlag,
+		utils.ETHRPCPortFlag,
+		//security setting
+		utils.CliPolicyFileFlag,
+		utils.CliAuditLogFlag,
+		utils.CliTLSCertFlag,
+		utils.CliTLSKeyFlag,
+		utils.CliTLSClientCAFlag,
+		//remote signer setting
+		utils.RemoteSignerFlag,
+		utils.RemoteSignerKeyFlag,
+		utils.RemoteSignerTokenFlag,
+		utils.RemoteSignerCAFlag,
+		utils.RemoteSignerCertFlag,
+		utils.RemoteSignerCertKeyFlag,
 	}
 	app.Commands = []cli.Command{
 		cmdsvr.ImportWalletCommand,
+		cmdsvr.VerifyAuditLogCommand,
 	}
 	app.Before = func(context *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
@@ -82,14 +102,39 @@ func startSigSvr(ctx *cli.Context) {
 	}
 	log.Infof("Load wallet data success. Account number:%d", accountNum)
 
+	signerUrl := ctx.String(utils.GetFlagName(utils.RemoteSignerFlag))
+	if signerUrl != "" {
+		signer, err := cmdcom.NewRemoteSigner(signerUrl, ctx.String(utils.GetFlagName(utils.RemoteSignerKeyFlag)),
+			ctx.String(utils.GetFlagName(utils.RemoteSignerTokenFlag)),
+			ctx.String(utils.GetFlagName(utils.RemoteSignerCAFlag)),
+			ctx.String(utils.GetFlagName(utils.RemoteSignerCertFlag)),
+			ctx.String(utils.GetFlagName(utils.RemoteSignerCertKeyFlag)))
+		if err != nil {
+			log.Errorf("%s", err)
+			return
+		}
+		clisvrcom.DefRemoteSigner = signer
+		address := types.AddressFromPubKey(signer.PubKey())
+		log.Infof("Init remote signer success. Account:%s", address.ToBase58())
+	}
+
 	rpcAddress := ctx.String(utils.GetFlagName(utils.CliAddressFlag))
 	rpcPort := ctx.Uint(utils.GetFlagName(utils.CliRpcPortFlag))
 	if rpcPort == 0 {
 		log.Errorf("Please using sig server port by --%s flag", utils.GetFlagName(utils.CliRpcPortFlag))
 		return
 	}
+
+	if !initSecurity(ctx) {
+		return
+	}
 	go cmdsvr.DefCliRpcSvr.Start(rpcAddress, rpcPort)
 
+	//node json rpc port, which is used to verify credentials
+	cmd.SetRpcPort(ctx)
+	//node eth rpc port, which is used to query the nonce of EIP155 transactions
+	cmd.SetEthRpcPort(ctx)
+
 	abiPath := ctx.GlobalString(utils.GetFlagName(utils.CliABIPathFlag))
 	abi.DefAbiMgr.Init(abiPath)
 
@@ -109,6 +154,65 @@ func startSigSvr(ctx *cli.Context) {
 	<-exit
 }
 
+func initSecurity(ctx *cli.Context) bool {
+	var engine *policy.Engine
+	policyFile := ctx.String(utils.GetFlagName(utils.CliPolicyFileFlag))
+	if policyFile != "" {
+		cfg, err := policy.LoadConfig(policyFile)
+		if err != nil {
+			log.Errorf("Load policy error:%s", err)
+			return false
+		}
+		engine, err = policy.NewEngine(cfg)
+		if err != nil {
+			log.Errorf("Init policy error:%s", err)
+			return false
+		}
+		if len(cfg.Clients) == 0 {
+			log.Warnf("No api client in policy, requests are not authenticated")
+		}
+		cmdsvr.DefCliRpcSvr.SetPolicy(engine)
+		log.Infof("Load policy:%s success. Client number:%d, account policy number:%d", policyFile, len(cfg.Clients), len(cfg.Accounts))
+	} else {
+		log.Warnf("No signing policy, sig server will sign any request. Using --%s flag to set policy", utils.CliPolicyFileFlag.Name)
+	}
+
+	auditFile := ctx.String(utils.GetFlagName(utils.CliAuditLogFlag))
+	if auditFile != "" {
+		auditLog, entries, err := audit.Open(auditFile)
+		if err != nil {
+			log.Errorf("Open audit log error:%s", err)
+			return false
+		}
+		if engine != nil {
+			err = engine.RestoreUsage(entries)
+			if err != nil {
+				log.Errorf("Restore usage from audit log error:%s", err)
+				return false
+			}
+		}
+		cmdsvr.DefCliRpcSvr.SetAuditLog(auditLog)
+		seq, hash := auditLog.Head()
+		log.Infof("Open audit log:%s success. Last seq:%d hash:%s", auditFile, seq, hash)
+	}
+
+	tlsCert := ctx.String(utils.GetFlagName(utils.CliTLSCertFlag))
+	tlsKey := ctx.String(utils.GetFlagName(utils.CliTLSKeyFlag))
+	tlsClientCA := ctx.String(utils.GetFlagName(utils.CliTLSClientCAFlag))
+	if tlsCert != "" || tlsKey != "" {
+		if tlsCert == "" || tlsKey == "" {
+			log.Errorf("Please using --%s and --%s flag together", utils.CliTLSCertFlag.Name, utils.CliTLSKeyFlag.Name)
+			return false
+		}
+		cmdsvr.DefCliRpcSvr.SetTLS(tlsCert, tlsKey, tlsClientCA)
+	} else if tlsClientCA != "" {
+		log.Errorf("Please using --%s flag with --%s and --%s flag", utils.CliTLSClientCAFlag.Name,
+			utils.CliTLSCertFlag.Name, utils.CliTLSKeyFlag.Name)
+		return false
+	}
+	return true
+}
+
 func main() {
 	if err := setupSigSvr().Run(os.Args); err != nil {
 		cmd.PrintErrorMsg(err.Error())
@@ -22,13 +22,16 @@ import (
 	"bufio"
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology-crypto/signature"
 	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/cmd/common"
 	"github.com/qbyyf/ontology/cmd/utils"
+	comm "github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/password"
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/urfave/cli"
@@ -56,9 +59,14 @@ You can use ./Ontology account --help command to view help information of wallet
 					utils.AccountDefaultFlag,
 					utils.AccountLabelFlag,
 					utils.IdentityFlag,
+					utils.AccountDeriveFlag,
+					utils.AccountCoinTypeFlag,
+					utils.AccountMnemonicWordsFlag,
 					utils.WalletFileFlag,
 				},
 				Description: ` Add a new account to wallet.
+   With --derive option, account is derived from HD seed of wallet by BIP-44 path m/44'/<coin>'/0'/0/<index>, and the mnemonic of
+   the HD seed is created at the first time. Coin type ont(1024) derives P-256 key, and coin type eth(60) derives secp256k1 key.
    Ontology support three type of key: ecdsa, sm2 and ed25519, and support 224、256、384、521 bits length of key in ecdsa, but only support 256 bits length of key in sm2 and ed25519.
    Ontology support multiple signature scheme.
    For ECDSA support SHA224withECDSA、SHA256withECDSA、SHA384withECDSA、SHA512withEdDSA、SHA3-224withECDSA、SHA3-256withECDSA、SHA3-384withECDSA、SHA3-512withECDSA、RIPEMD160withECDSA;
@@ -133,24 +141,78 @@ You can use ./Ontology account --help command to view help information of wallet
 					utils.WalletFileFlag,
 					utils.AccountSourceFileFlag,
 					utils.AccountWIFFlag,
+					utils.AccountKeystoreFlag,
+					utils.AccountMnemonicFlag,
+					utils.AccountCoinTypeFlag,
+					utils.AccountQuantityFlag,
 				},
-				Description: "Import accounts of wallet to another. If not specific accounts in args, all account in source will be import",
+				Description: `Import accounts of wallet to another. If not specific accounts in args, all account in source will be import.
+   With --mnemonic option, HD seed of wallet is restored from BIP-39 mnemonic, and the first <quantity> accounts of coin type are derived.
+   With --keystore option, secp256k1 account is imported from the Ethereum V3 keystore file specified by --source option.`,
 			},
 			{
 				Action:    accountExport,
 				Name:      "export",
 				Usage:     "Export accounts to a specified wallet file",
-				ArgsUsage: "[sub-command options] <filename>",
+				ArgsUsage: "[sub-command options] <filename> [address|label|index]",
 				Flags: []cli.Flag{
 					utils.WalletFileFlag,
 					utils.AccountLowSecurityFlag,
+					utils.AccountKeystoreFlag,
+					utils.AccountScryptNFlag,
+					utils.AccountScryptPFlag,
+				},
+				Description: `Export accounts to a specified wallet file.
+   With --keystore option, the secp256k1 account specified in args (default account if not specified) is exported to Ethereum V3
+   keystore file, encrypted with scrypt N:262144 p:1 as Ethereum clients do, unless --scrypt-n or --scrypt-p is set.`,
+			},
+			{
+				Action:    accountUpgrade,
+				Name:      "upgrade",
+				Usage:     "Upgrade wallet file to the current format version",
+				ArgsUsage: "[sub-command options]",
+				Flags: []cli.Flag{
+					utils.WalletFileFlag,
+				},
+				Description: `Upgrade wallet file to the current format version. Private keys encrypted by aes-256-ctr are re-encrypted by
+   aes-256-gcm, and the missing public keys and signature schemes of accounts are filled. Only the passwords of accounts to upgrade
+   are required. The old wallet file is backed up to <wallet file>.bak.`,
+			},
+			{
+				Action:    accountReencrypt,
+				Name:      "reencrypt",
+				Usage:     "Re-encrypt all accounts of wallet with new scrypt parameters",
+				ArgsUsage: "[sub-command options]",
+				Flags: []cli.Flag{
+					utils.WalletFileFlag,
+					utils.AccountScryptNFlag,
+					utils.AccountScryptRFlag,
+					utils.AccountScryptPFlag,
 				},
+				Description: `Re-encrypt the private keys of all accounts and the HD seed of wallet with new scrypt parameters. Password of
+   every account is required, and keeps unchanged. Larger parameters make brute-force attack harder, but slow down the account unlock.`,
+			},
+			{
+				Action:    accountCheck,
+				Name:      "check",
+				Usage:     "Check integrity of wallet file",
+				ArgsUsage: "[sub-command options]",
+				Flags: []cli.Flag{
+					utils.WalletFileFlag,
+					utils.AccountDecryptFlag,
+				},
+				Description: `Check integrity of wallet file, includes the address, public key, encryption and signature scheme of accounts,
+   the HD seed and the identities, so that corrupted entries are found before signing transaction fails.
+   With --decrypt option, private key of every account is decrypted with password, and checked to match its address.`,
 			},
 		},
 	}
 )
 
 func accountCreate(ctx *cli.Context) error {
+	if ctx.Bool(utils.GetFlagName(utils.AccountDeriveFlag)) {
+		return accountDerive(ctx)
+	}
 	reader := bufio.NewReader(os.Stdin)
 	optionType := ""
 	optionCurve := ""
@@ -215,6 +277,83 @@ func accountCreate(ctx *cli.Context) error {
 	return nil
 }
 
+func checkCoinType(ctx *cli.Context) (uint32, error) {
+	coin := ctx.String(utils.GetFlagName(utils.AccountCoinTypeFlag))
+	switch strings.ToLower(coin) {
+	case "ont", "1024":
+		return account.ONT_COIN_TYPE, nil
+	case "eth", "60":
+		return account.ETH_COIN_TYPE, nil
+	default:
+		return 0, fmt.Errorf("unsupported coin type: %s", coin)
+	}
+}
+
+func accountDerive(ctx *cli.Context) error {
+	coinType, err := checkCoinType(ctx)
+	if err != nil {
+		return err
+	}
+	optionFile := checkFileName(ctx)
+	optionNumber := checkNumber(ctx)
+	optionLabel := checkLabel(ctx)
+	wallet, err := account.Open(optionFile)
+	if err != nil {
+		return fmt.Errorf("error opening wallet: %s", err)
+	}
+	var pass []byte
+	if wallet.GetWalletData().HDSeed == nil {
+		words := ctx.Uint(utils.GetFlagName(utils.AccountMnemonicWordsFlag))
+		mnemonic, err := account.NewMnemonic(int(words) * 32 / 3)
+		if err != nil {
+			return fmt.Errorf("error creating mnemonic: %s", err)
+		}
+		PrintInfoMsg("Please input a password to encrypt the HD seed and derived accounts")
+		pass, err = password.GetConfirmedPassword()
+		if err != nil {
+			return err
+		}
+		err = wallet.ImportMnemonic(mnemonic, "", pass)
+		if err != nil {
+			return fmt.Errorf("error saving HD seed: %s", err)
+		}
+		PrintInfoMsg("HD seed created, please write down the mnemonic and keep it safe:")
+		PrintInfoMsg("  %s", mnemonic)
+	} else {
+		pass, err = password.GetPassword()
+		if err != nil {
+			return err
+		}
+	}
+	defer common.ClearPasswd(pass)
+	for i := 0; i < optionNumber; i++ {
+		label := optionLabel
+		if label != "" && optionNumber > 1 {
+			label = fmt.Sprintf("%s%d", label, i+1)
+		}
+		acc, err := wallet.DeriveAccount(label, coinType, pass)
+		if err != nil {
+			return fmt.Errorf("error deriving account: %s", err)
+		}
+		printDerivedAccount(wallet, acc, label)
+	}
+	PrintInfoMsg("Derive account successfully.")
+	return nil
+}
+
+func printDerivedAccount(wallet account.Client, acc *account.Account, label string) {
+	accMeta := wallet.GetAccountMetadataByAddress(acc.Address.ToBase58())
+	PrintInfoMsg("Index:%d", wallet.GetAccountNum())
+	PrintInfoMsg("Label:%s", label)
+	PrintInfoMsg("Derive path:%s", accMeta.DerivePath)
+	PrintInfoMsg("Address:%s", acc.Address.ToBase58())
+	if ethAddr, err := utils.GetEthAddress(acc.PublicKey); err == nil {
+		PrintInfoMsg("EVM address:%s", ethAddr.Hex())
+	}
+	PrintInfoMsg("Public key:%s", hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)))
+	PrintInfoMsg("Signature scheme:%s", acc.SigScheme.Name())
+}
+
 func accountList(ctx *cli.Context) error {
 	optionFile := checkFileName(ctx)
 	wallet, err := account.Open(optionFile)
@@ -265,6 +404,9 @@ func accountList(ctx *cli.Context) error {
 		PrintInfoMsg("	Curve: %v", accMeta.Curve)
 		PrintInfoMsg("	Key length: %v bits", len(accMeta.Key)*8)
 		PrintInfoMsg("	Public key: %v", accMeta.PubKey)
+		if accMeta.DerivePath != "" {
+			PrintInfoMsg("	Derive path: %v", accMeta.DerivePath)
+		}
 		PrintInfoMsg("	Signature scheme: %v\n", accMeta.SigSch)
 	}
 	return nil
@@ -391,7 +533,65 @@ func accountDelete(ctx *cli.Context) error {
 	return nil
 }
 
+func accountImportMnemonic(ctx *cli.Context) error {
+	coinType, err := checkCoinType(ctx)
+	if err != nil {
+		return err
+	}
+	optionFile := checkFileName(ctx)
+	wallet, err := account.Open(optionFile)
+	if err != nil {
+		return fmt.Errorf("error opening wallet: %s", err)
+	}
+	if wallet.GetWalletData().HDSeed != nil {
+		return fmt.Errorf("wallet %s already has HD seed", optionFile)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("Mnemonic:")
+	mnemonic, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	mnemonic = strings.TrimSpace(mnemonic)
+	err = account.ValidateMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Please input the passphrase of mnemonic, or press enter if no passphrase")
+	passphrase, err := password.GetPassword()
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Please input a password to encrypt the HD seed and derived accounts")
+	pass, err := password.GetConfirmedPassword()
+	if err != nil {
+		return err
+	}
+	defer common.ClearPasswd(pass)
+	err = wallet.ImportMnemonic(mnemonic, string(passphrase), pass)
+	common.ClearPasswd(passphrase)
+	if err != nil {
+		return fmt.Errorf("error saving HD seed: %s", err)
+	}
+	optionNumber := checkNumber(ctx)
+	for i := 0; i < optionNumber; i++ {
+		acc, err := wallet.DeriveAccount("", coinType, pass)
+		if err != nil {
+			return fmt.Errorf("error deriving account: %s", err)
+		}
+		printDerivedAccount(wallet, acc, "")
+	}
+	PrintInfoMsg("Restore HD seed to %s successfully.", optionFile)
+	return nil
+}
+
 func accountImport(ctx *cli.Context) error {
+	if ctx.Bool(utils.GetFlagName(utils.AccountMnemonicFlag)) {
+		return accountImportMnemonic(ctx)
+	}
+	if ctx.Bool(utils.GetFlagName(utils.AccountKeystoreFlag)) {
+		return accountImportKeystore(ctx)
+	}
 	source := ctx.String(utils.GetFlagName(utils.AccountSourceFileFlag))
 	if source == "" {
 		PrintErrorMsg("Missing source wallet path argument to import.")
@@ -537,6 +737,9 @@ func accountExport(ctx *cli.Context) error {
 		cli.ShowSubcommandHelp(ctx)
 		return nil
 	}
+	if ctx.Bool(utils.GetFlagName(utils.AccountKeystoreFlag)) {
+		return accountExportKeystore(ctx)
+	}
 	target := ctx.Args().First()
 	client, err := common.OpenWallet(ctx)
 	if err != nil {
@@ -544,26 +747,13 @@ func accountExport(ctx *cli.Context) error {
 	}
 	wallet := client.GetWalletData()
 	if ctx.IsSet(utils.GetFlagName(utils.AccountLowSecurityFlag)) {
-		n := client.GetAccountNum()
-		passwords := make([][]byte, n)
-		for i := 0; i < n; i++ {
-			ac := client.GetAccountMetadataByIndex(i + 1)
-			PrintInfoMsg("Account %d %s: %s", i+1, ac.Label, ac.Address)
-			for j := 0; j < 3; j++ {
-				pwd, err := password.GetPassword()
-				if err != nil {
-					fmt.Println(err)
-				} else {
-					passwords[i] = pwd
-					break
-				}
-			}
+		passwords, err := readAccountPasswords(wallet, nil)
+		if err != nil {
+			return err
 		}
 		wallet = wallet.Clone()
-		err := wallet.ToLowSecurity(passwords)
-		for _, v := range passwords {
-			common.ClearPasswd(v)
-		}
+		err = wallet.ToLowSecurity(passwords)
+		clearPasswords(passwords)
 		if err != nil {
 			return fmt.Errorf("export failed: %s", err)
 		}
@@ -576,3 +766,268 @@ func accountExport(ctx *cli.Context) error {
 	PrintInfoMsg("Export wallet success.")
 	return nil
 }
+
+func accountImportKeystore(ctx *cli.Context) error {
+	source := ctx.String(utils.GetFlagName(utils.AccountSourceFileFlag))
+	if source == "" {
+		PrintErrorMsg("Missing source keystore file argument to import.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	keyJson, err := ioutil.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("read keystore file error: %s", err)
+	}
+	optionFile := checkFileName(ctx)
+	wallet, err := account.Open(optionFile)
+	if err != nil {
+		return fmt.Errorf("error opening wallet: %s", err)
+	}
+	PrintInfoMsg("Please input the password of keystore")
+	ksPasswd, err := password.GetPassword()
+	if err != nil {
+		return err
+	}
+	prvkey, err := account.ImportEthKeystore(keyJson, ksPasswd)
+	common.ClearPasswd(ksPasswd)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Please input a password to encrypt the imported key")
+	pass, err := password.GetConfirmedPassword()
+	if err != nil {
+		return err
+	}
+	defer common.ClearPasswd(pass)
+	acc, err := wallet.ImportPrivateKey("", prvkey, signature.SHA256withECDSA, pass)
+	if err != nil {
+		return fmt.Errorf("import keystore error: %s", err)
+	}
+	PrintInfoMsg("Index:%d", wallet.GetAccountNum())
+	PrintInfoMsg("Address:%s", acc.Address.ToBase58())
+	if ethAddr, err := utils.GetEthAddress(acc.PublicKey); err == nil {
+		PrintInfoMsg("EVM address:%s", ethAddr.Hex())
+	}
+	PrintInfoMsg("Public key:%s", hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)))
+	PrintInfoMsg("Import keystore %s to %s successfully.", source, optionFile)
+	return nil
+}
+
+func accountExportKeystore(ctx *cli.Context) error {
+	target := ctx.Args().First()
+	if comm.FileExisted(target) {
+		return fmt.Errorf("keystore file %s already exists", target)
+	}
+	client, err := common.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	accAddr := ctx.Args().Get(1)
+	accMeta := common.GetAccountMetadataMulti(client, accAddr)
+	if accMeta == nil {
+		return fmt.Errorf("cannot find account by %s", accAddr)
+	}
+	PrintInfoMsg("Account %s: %s", accMeta.Label, accMeta.Address)
+	pass, err := password.GetAccountPassword()
+	if err != nil {
+		return err
+	}
+	acc, err := common.GetAccountMulti(client, pass, accMeta.Address)
+	common.ClearPasswd(pass)
+	if err != nil {
+		return err
+	}
+	scryptN := account.ETH_KEYSTORE_SCRYPT_N
+	if ctx.IsSet(utils.GetFlagName(utils.AccountScryptNFlag)) {
+		scryptN = int(ctx.Uint(utils.GetFlagName(utils.AccountScryptNFlag)))
+	}
+	scryptP := account.ETH_KEYSTORE_SCRYPT_P
+	if ctx.IsSet(utils.GetFlagName(utils.AccountScryptPFlag)) {
+		scryptP = int(ctx.Uint(utils.GetFlagName(utils.AccountScryptPFlag)))
+	}
+	PrintInfoMsg("Please input a password to encrypt the keystore")
+	ksPasswd, err := password.GetConfirmedPassword()
+	if err != nil {
+		return err
+	}
+	keyJson, err := account.ExportEthKeystore(acc.PrivateKey, ksPasswd, scryptN, scryptP)
+	common.ClearPasswd(ksPasswd)
+	if err != nil {
+		return fmt.Errorf("export keystore error: %s", err)
+	}
+	err = ioutil.WriteFile(target, keyJson, 0600)
+	if err != nil {
+		return fmt.Errorf("write keystore file error: %s", err)
+	}
+	if ethAddr, err := utils.GetEthAddress(acc.PublicKey); err == nil {
+		PrintInfoMsg("EVM address:%s", ethAddr.Hex())
+	}
+	PrintInfoMsg("Export account %s to keystore %s successfully.", accMeta.Address, target)
+	return nil
+}
+
+func accountUpgrade(ctx *cli.Context) error {
+	client, err := common.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	optionFile := checkFileName(ctx)
+	wallet := client.GetWalletData()
+	if !wallet.NeedUpgrade() {
+		PrintInfoMsg("Wallet %s is already of the current version %s.", optionFile, account.WALLET_VERSION)
+		return nil
+	}
+	backup := optionFile + ".bak"
+	if comm.FileExisted(backup) {
+		return fmt.Errorf("backup file %s already exists", backup)
+	}
+	passwords, err := readAccountPasswords(wallet, wallet.AccountNeedUpgrade)
+	if err != nil {
+		return err
+	}
+	defer clearPasswords(passwords)
+	data, err := ioutil.ReadFile(optionFile)
+	if err != nil {
+		return fmt.Errorf("read wallet file error: %s", err)
+	}
+	err = ioutil.WriteFile(backup, data, 0600)
+	if err != nil {
+		return fmt.Errorf("backup wallet file error: %s", err)
+	}
+	oldVersion := wallet.Version
+	err = wallet.Upgrade(passwords)
+	if err != nil {
+		return fmt.Errorf("upgrade wallet error: %s", err)
+	}
+	err = wallet.Save(optionFile)
+	if err != nil {
+		return fmt.Errorf("save wallet file error: %s", err)
+	}
+	PrintInfoMsg("Upgrade wallet %s from version %s to %s successfully.", optionFile, oldVersion, wallet.Version)
+	PrintInfoMsg("The old wallet file is backed up to %s.", backup)
+	return nil
+}
+
+func accountReencrypt(ctx *cli.Context) error {
+	client, err := common.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	optionFile := checkFileName(ctx)
+	param := &keypair.ScryptParam{
+		N:     int(ctx.Uint(utils.GetFlagName(utils.AccountScryptNFlag))),
+		R:     int(ctx.Uint(utils.GetFlagName(utils.AccountScryptRFlag))),
+		P:     int(ctx.Uint(utils.GetFlagName(utils.AccountScryptPFlag))),
+		DKLen: keypair.DEFAULT_DERIVED_KEY_LENGTH,
+	}
+	err = account.CheckScryptParam(param, param.DKLen)
+	if err != nil {
+		return err
+	}
+	wallet := client.GetWalletData().Clone()
+	passwords, err := readAccountPasswords(wallet, nil)
+	if err != nil {
+		return err
+	}
+	defer clearPasswords(passwords)
+	err = wallet.Reencrypt(passwords, param)
+	if err != nil {
+		return err
+	}
+	if wallet.HDSeed != nil {
+		PrintInfoMsg("Please input the password of HD seed")
+		pass, err := password.GetPassword()
+		if err != nil {
+			return err
+		}
+		err = wallet.ReencryptHDSeed(pass, param)
+		common.ClearPasswd(pass)
+		if err != nil {
+			return err
+		}
+	}
+	err = wallet.Save(optionFile)
+	if err != nil {
+		return fmt.Errorf("save wallet file error: %s", err)
+	}
+	PrintInfoMsg("Re-encrypt wallet %s with scrypt N:%d r:%d p:%d successfully.", optionFile, param.N, param.R, param.P)
+	return nil
+}
+
+func accountCheck(ctx *cli.Context) error {
+	optionFile := checkFileName(ctx)
+	if !comm.FileExisted(optionFile) {
+		return fmt.Errorf("cannot find wallet file: %s", optionFile)
+	}
+	wallet := account.NewWalletData()
+	err := wallet.Load(optionFile)
+	if err != nil {
+		return fmt.Errorf("error loading wallet %s: %s", optionFile, err)
+	}
+	errs := wallet.CheckIntegrity()
+	for _, err := range errs {
+		PrintErrorMsg("%s", err)
+	}
+	problems := len(errs)
+	if ctx.Bool(utils.GetFlagName(utils.AccountDecryptFlag)) {
+		for i, acc := range wallet.Accounts {
+			PrintInfoMsg("Account %d %s: %s", i+1, acc.Label, acc.Address)
+			pass, err := password.GetPassword()
+			if err != nil {
+				return err
+			}
+			err = wallet.VerifyAccountKey(i, pass)
+			common.ClearPasswd(pass)
+			if err != nil {
+				PrintErrorMsg("account %d (%s): %s", i+1, acc.Address, err)
+				problems++
+			}
+		}
+	}
+	if wallet.NeedUpgrade() {
+		PrintWarnMsg("Wallet version %s is outdated, use 'account upgrade' to upgrade it to %s.", wallet.Version, account.WALLET_VERSION)
+	}
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s) in wallet %s", problems, optionFile)
+	}
+	PrintInfoMsg("Wallet %s is OK, %d accounts checked.", optionFile, len(wallet.Accounts))
+	return nil
+}
+
+//readAccountPasswords reads the passwords of accounts in wallet, and verifies them by decrypting the keys.
+//need decides whether the password of account is required, index start from 0
+func readAccountPasswords(wallet *account.WalletData, need func(index int) bool) ([][]byte, error) {
+	passwords := make([][]byte, len(wallet.Accounts))
+	for i, acc := range wallet.Accounts {
+		if need != nil && !need(i) {
+			continue
+		}
+		PrintInfoMsg("Account %d %s: %s", i+1, acc.Label, acc.Address)
+		for j := 0; j < 3; j++ {
+			pass, err := password.GetPassword()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			err = wallet.VerifyAccountKey(i, pass)
+			if err != nil {
+				common.ClearPasswd(pass)
+				fmt.Println(err)
+				continue
+			}
+			passwords[i] = pass
+			break
+		}
+		if passwords[i] == nil {
+			clearPasswords(passwords)
+			return nil, fmt.Errorf("cannot get password of account %s", acc.Address)
+		}
+	}
+	return passwords, nil
+}
+
+func clearPasswords(passwords [][]byte) {
+	for _, v := range passwords {
+		common.ClearPasswd(v)
+	}
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/password"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/signature/remote"
 	"github.com/urfave/cli"
 )
 
@@ -136,6 +138,35 @@ func GetAccount(ctx *cli.Context, address ...string) (*account.Account, error) {
 	return GetAccountMulti(wallet, passwd, accAddr)
 }
 
+// GetSigner returns the remote signer if --remote-signer is set, otherwise the account in wallet
+func GetSigner(ctx *cli.Context) (signature.DataSigner, error) {
+	signerUrl := ctx.String(utils.GetFlagName(utils.RemoteSignerFlag))
+	if signerUrl == "" {
+		return GetAccount(ctx)
+	}
+	return NewRemoteSigner(signerUrl, ctx.String(utils.GetFlagName(utils.RemoteSignerKeyFlag)),
+		ctx.String(utils.GetFlagName(utils.RemoteSignerTokenFlag)), ctx.String(utils.GetFlagName(utils.RemoteSignerCAFlag)),
+		ctx.String(utils.GetFlagName(utils.RemoteSignerCertFlag)), ctx.String(utils.GetFlagName(utils.RemoteSignerCertKeyFlag)))
+}
+
+// NewRemoteSigner creates the signer of key in remote signing daemon at signerUrl
+func NewRemoteSigner(signerUrl, keyId, token, caFile, certFile, keyFile string) (*remote.Signer, error) {
+	tlsConfig, err := remote.NewTLSConfig(caFile, certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("init remote signer error: %s", err)
+	}
+	signer, err := remote.NewSigner(&remote.Config{
+		Url:   signerUrl,
+		KeyId: keyId,
+		Token: token,
+		TLS:   tlsConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("init remote signer error: %s", err)
+	}
+	return signer, nil
+}
+
 func IsBase58Address(address string) bool {
 	if address == "" {
 		return false
@@ -37,12 +37,13 @@ Select a signature algorithm from the following:
 		fmt.Printf(`
 Select a curve from the following:
 
-    | NAME  | KEY LENGTH (bits)
- ---|-------|------------------
-  1 | P-224 | 224
-  2 | P-256 | 256
-  3 | P-384 | 384
-  4 | P-521 | 521
+    | NAME      | KEY LENGTH (bits)
+ ---|-----------|------------------
+  1 | P-224     | 224
+  2 | P-256     | 256
+  3 | P-384     | 384
+  4 | P-521     | 521
+  5 | secp256k1 | 256, for EIP155 transactions
 
 This determines the length of the private key [default is 2]: `)
 
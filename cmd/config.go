@@ -53,6 +53,13 @@ func SetOntologyConfig(ctx *cli.Context) (*config.OntologyConfig, error) {
 		cfg.P2PNode.EVMChainId = config.GetEip155ChainID(cfg.P2PNode.NetworkId)
 		cfg.Common.GasPrice = 0
 	}
+	if ctx.String(utils.GetFlagName(utils.ForkRpcFlag)) != "" {
+		if !ctx.Bool(utils.GetFlagName(utils.EnableTestModeFlag)) {
+			return nil, fmt.Errorf("--%s is only supported in test mode", utils.GetFlagName(utils.ForkRpcFlag))
+		}
+		//the states of remote chain are cached in data dir, which can not be mixed with test mode
+		cfg.P2PNode.NetworkName = utils.FORK_NETWORK_NAME
+	}
 	if cfg.P2PNode.NetworkId == config.NETWORK_ID_MAIN_NET ||
 		cfg.P2PNode.NetworkId == config.NETWORK_ID_POLARIS_NET {
 		defNetworkId, err := cfg.GetDefaultNetworkId()
@@ -212,3 +219,37 @@ func SetRpcPort(ctx *cli.Context) {
 		config.DefConfig.Rpc.HttpJsonPort = ctx.Uint(utils.GetFlagName(utils.RPCPortFlag))
 	}
 }
+
+func SetEthRpcPort(ctx *cli.Context) {
+	if ctx.IsSet(utils.GetFlagName(utils.ETHRPCPortFlag)) {
+		config.DefConfig.Rpc.EthJsonPort = ctx.Uint(utils.GetFlagName(utils.ETHRPCPortFlag))
+	}
+}
+
+// SetDevnetConfig sets the config of devnet, which is a solo network with all http servers enabled,
+// and saves events of transactions for waiting and querying. The consensus of a multi-node devnet is
+// set after its bookkeepers are derived
+func SetDevnetConfig(ctx *cli.Context) *config.OntologyConfig {
+	cfg := config.DefConfig
+	cfg.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	cfg.Genesis.SOLO.GenBlockTime = ctx.Uint(utils.GetFlagName(utils.DevnetBlockTimeFlag))
+	cfg.Common.DataDir = ctx.String(utils.GetFlagName(utils.DevnetDataDirFlag))
+	cfg.Common.EnableEventLog = true
+	cfg.Common.GasPrice = 0
+	cfg.Consensus.EnableConsensus = true
+	cfg.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	cfg.P2PNode.NetworkName = config.GetNetworkName(cfg.P2PNode.NetworkId)
+	cfg.P2PNode.NetworkMagic = config.GetNetworkMagic(cfg.P2PNode.NetworkId)
+	cfg.P2PNode.EVMChainId = config.GetEip155ChainID(cfg.P2PNode.NetworkId)
+	cfg.P2PNode.HttpInfoPort = 0
+	cfg.Rpc.EnableHttpJsonRpc = true
+	cfg.Rpc.HttpJsonPort = ctx.Uint(utils.GetFlagName(utils.RPCPortFlag))
+	cfg.Rpc.EthJsonPort = ctx.Uint(utils.GetFlagName(utils.ETHRPCPortFlag))
+	cfg.Restful.EnableHttpRestful = true
+	cfg.Restful.HttpRestPort = ctx.Uint(utils.GetFlagName(utils.RestfulPortFlag))
+	cfg.Ws.EnableHttpWs = true
+	cfg.Ws.HttpWsPort = ctx.Uint(utils.GetFlagName(utils.WsPortFlag))
+	cfg.GraphQL.EnableGraphQL = true
+	cfg.GraphQL.GraphQLPort = ctx.Uint(utils.GetFlagName(utils.GraphQLPortFlag))
+	return cfg
+}
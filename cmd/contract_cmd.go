@@ -23,12 +23,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
+	"github.com/qbyyf/ontology/account"
 	cmdcom "github.com/qbyyf/ontology/cmd/common"
 	"github.com/qbyyf/ontology/cmd/utils"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
 	httpcom "github.com/qbyyf/ontology/http/base/common"
 	"github.com/urfave/cli"
 )
@@ -39,17 +43,21 @@ var (
 		Action:      cli.ShowSubcommandHelp,
 		Usage:       "Deploy or invoke smart contract",
 		ArgsUsage:   " ",
-		Description: `Smart contract operations support the deployment of NeoVM / WasmVM smart contract, and the pre-execution and execution of NeoVM / WasmVM smart contract.`,
+		Description: `Smart contract operations support the deployment of NeoVM / WasmVM / EVM smart contract, the pre-execution and execution of NeoVM / WasmVM smart contract, and the migration and destruction of NeoVM smart contract.`,
 		Subcommands: []cli.Command{
 			{
 				Action:    deployContract,
 				Name:      "deploy",
 				Usage:     "Deploy a smart contract to ontology",
 				ArgsUsage: " ",
+				Description: "Deploy NeoVM or WasmVM contract code in hex in --code file. With --vmtype 4, the Solidity " +
+					"bytecode in --code file is deployed by EIP155 contract creation transaction, and the constructor " +
+					"--params are encoded by the Solidity ABI in --abi file. The gas limit is estimated by " +
+					"pre-execution if --gaslimit is not set.",
 				Flags: []cli.Flag{
 					utils.RPCPortFlag,
 					utils.TransactionGasPriceFlag,
-					utils.TransactionGasLimitFlag,
+					utils.ContractGasLimitFlag,
 					utils.ContractVmTypeFlag,
 					utils.ContractCodeFileFlag,
 					utils.ContractNameFlag,
@@ -58,15 +66,22 @@ var (
 					utils.ContractEmailFlag,
 					utils.ContractDescFlag,
 					utils.ContractPrepareDeployFlag,
+					utils.ContractWaitFlag,
+					utils.ContractWaitTimeoutFlag,
 					utils.WalletFileFlag,
 					utils.AccountAddressFlag,
+					utils.ETHRPCPortFlag,
+					utils.EthAbiFlag,
+					utils.EthParamsFlag,
+					utils.EthNonceFlag,
+					utils.EthChainIdFlag,
 				},
 			},
 			{
 				Action: invokeContract,
 				Name:   "invoke",
 				Usage:  "Invoke smart contract",
-				ArgsUsage: `Ontology contract support bytearray(need encode to hex string), string, integer, boolean parameter type.
+				ArgsUsage: `Ontology contract support bytearray(need encode to hex string), string, integer, boolean, address(base58), h256(hex string) parameter type.
 
   Parameter 
      Contract parameters separate with comma ',' to split params. and must add type prefix to params.
@@ -77,6 +92,9 @@ var (
   Note that if string contain some special char like :,[,] and so one, please use '/' char to escape. 
   For example: string:did/:ed1e25c9dccae0c694ee892231407afa20b76008
 
+  Params of WasmVM contract are encoded in the format of ontology-wasm-cdt by default,
+  use --crossvm flag to encode them by the crossvm codec instead.
+
   Return type
      When invoke contract with --prepare flag, you need specifies return type by --return flag, to decode the return value.
      Return type support bytearray(encoded to hex string), string, integer, boolean. 
@@ -86,13 +104,16 @@ var (
 				Flags: []cli.Flag{
 					utils.RPCPortFlag,
 					utils.TransactionGasPriceFlag,
-					utils.TransactionGasLimitFlag,
+					utils.ContractGasLimitFlag,
 					utils.ContractAddrFlag,
 					utils.ContractVmTypeFlag,
 					utils.ContractParamsFlag,
+					utils.ContractCrossVMFlag,
 					utils.ContractVersionFlag,
 					utils.ContractPrepareInvokeFlag,
 					utils.ContractReturnTypeFlag,
+					utils.ContractWaitFlag,
+					utils.ContractWaitTimeoutFlag,
 					utils.WalletFileFlag,
 					utils.AccountAddressFlag,
 				},
@@ -106,9 +127,63 @@ var (
 					utils.RPCPortFlag,
 					utils.ContractCodeFileFlag,
 					utils.TransactionGasPriceFlag,
-					utils.TransactionGasLimitFlag,
+					utils.ContractGasLimitFlag,
 					utils.WalletFileFlag,
 					utils.ContractPrepareInvokeFlag,
+					utils.ContractWaitFlag,
+					utils.ContractWaitTimeoutFlag,
+					utils.AccountAddressFlag,
+				},
+			},
+			{
+				Action:    migrateContract,
+				Name:      "migrate",
+				Usage:     "Migrate NeoVM smart contract to new code",
+				ArgsUsage: " ",
+				Description: "Migrate the NeoVM contract at --address to the code in --code file. The contract " +
+					"--method is invoked with params [code, vmtype, name, version, author, email, desc], and it should " +
+					"call the migrate syscall with them. The storage of contract is moved to the new contract, and the " +
+					"old contract is removed.",
+				Flags: []cli.Flag{
+					utils.RPCPortFlag,
+					utils.TransactionGasPriceFlag,
+					utils.ContractGasLimitFlag,
+					utils.ContractAddrFlag,
+					utils.ContractMigrateMethodFlag,
+					utils.ContractVmTypeFlag,
+					utils.ContractCodeFileFlag,
+					utils.ContractNameFlag,
+					utils.ContractVersionFlag,
+					utils.ContractAuthorFlag,
+					utils.ContractEmailFlag,
+					utils.ContractDescFlag,
+					utils.ContractPrepareInvokeFlag,
+					utils.ContractWaitFlag,
+					utils.ContractWaitTimeoutFlag,
+					utils.AssumeYesFlag,
+					utils.WalletFileFlag,
+					utils.AccountAddressFlag,
+				},
+			},
+			{
+				Action:    destroyContract,
+				Name:      "destroy",
+				Usage:     "Destroy NeoVM smart contract",
+				ArgsUsage: " ",
+				Description: "Destroy the NeoVM contract at --address. The contract --method is invoked with empty " +
+					"params, and it should call the destroy syscall. The code and storage of contract are removed " +
+					"and can not be recovered.",
+				Flags: []cli.Flag{
+					utils.RPCPortFlag,
+					utils.TransactionGasPriceFlag,
+					utils.ContractGasLimitFlag,
+					utils.ContractAddrFlag,
+					utils.ContractDestroyMethodFlag,
+					utils.ContractPrepareInvokeFlag,
+					utils.ContractWaitFlag,
+					utils.ContractWaitTimeoutFlag,
+					utils.AssumeYesFlag,
+					utils.WalletFileFlag,
 					utils.AccountAddressFlag,
 				},
 			},
@@ -118,6 +193,10 @@ var (
 
 func deployContract(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	vmtypeFlag := ctx.Uint(utils.GetFlagName(utils.ContractVmTypeFlag))
+	if vmtypeFlag == utils.CONTRACT_VM_TYPE_EVM {
+		return ethDeploy(ctx)
+	}
 	if !ctx.IsSet(utils.GetFlagName(utils.ContractCodeFileFlag)) ||
 		!ctx.IsSet(utils.GetFlagName(utils.ContractNameFlag)) {
 		PrintErrorMsg("Missing %s or %s argument.", utils.ContractCodeFileFlag.Name, utils.ContractNameFlag.Name)
@@ -125,7 +204,6 @@ func deployContract(ctx *cli.Context) error {
 		return nil
 	}
 
-	vmtypeFlag := ctx.Uint(utils.GetFlagName(utils.ContractVmTypeFlag))
 	vmtype, err := payload.VmTypeFromByte(byte(vmtypeFlag))
 	if err != nil {
 		return err
@@ -147,7 +225,7 @@ func deployContract(ctx *cli.Context) error {
 	desc := ctx.String(utils.GetFlagName(utils.ContractDescFlag))
 	code := strings.TrimSpace(string(codeStr))
 	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
-	gasLimit := ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag))
+	gasLimit := ctx.Uint64(utils.GetFlagName(utils.ContractGasLimitFlag))
 	networkId, err := utils.GetNetworkId()
 	if err != nil {
 		return err
@@ -171,23 +249,28 @@ func deployContract(ctx *cli.Context) error {
 		return nil
 	}
 
+	c, err := common.HexToBytes(code)
+	if err != nil {
+		return fmt.Errorf("contract code convert hex to bytes error:%s", err)
+	}
+	mutable, err := utils.NewDeployCodeTransaction(gasPrice, gasLimit, c, vmtype, name, cversion, author, email, desc)
+	if err != nil {
+		return err
+	}
+
 	signer, err := cmdcom.GetAccount(ctx)
 	if err != nil {
 		return fmt.Errorf("get signer account error:%s", err)
 	}
-
-	txHash, err := utils.DeployContract(gasPrice, gasLimit, signer, vmtype, code, name, cversion, author, email, desc)
+	err = estimateContractGas(ctx, signer, mutable)
 	if err != nil {
-		return fmt.Errorf("DeployContract error:%s", err)
+		return err
 	}
-	c, _ := common.HexToBytes(code)
+
 	address := common.AddressFromVmCode(c)
 	PrintInfoMsg("Deploy contract:")
 	PrintInfoMsg("  Contract Address:%s", address.ToHexString())
-	PrintInfoMsg("  TxHash:%s", txHash)
-	PrintInfoMsg("\nTip:")
-	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
-	return nil
+	return sendContractTransaction(ctx, signer, mutable)
 }
 
 func invokeCodeContract(ctx *cli.Context) error {
@@ -243,7 +326,7 @@ func invokeCodeContract(ctx *cli.Context) error {
 		return nil
 	}
 	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
-	gasLimit := ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag))
+	gasLimit := ctx.Uint64(utils.GetFlagName(utils.ContractGasLimitFlag))
 	networkId, err := utils.GetNetworkId()
 	if err != nil {
 		return err
@@ -261,25 +344,11 @@ func invokeCodeContract(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("get signer account error:%s", err)
 	}
-
-	err = utils.SignTransaction(signer, invokeTx)
-	if err != nil {
-		return fmt.Errorf("SignTransaction error:%s", err)
-	}
-	tx, err := invokeTx.IntoImmutable()
+	err = estimateContractGas(ctx, signer, invokeTx)
 	if err != nil {
 		return err
 	}
-
-	txHash, err := utils.SendRawTransaction(tx)
-	if err != nil {
-		return fmt.Errorf("SendTransaction error:%s", err)
-	}
-
-	PrintInfoMsg("TxHash:%s", txHash)
-	PrintInfoMsg("\nTip:")
-	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
-	return nil
+	return sendContractTransaction(ctx, signer, invokeTx)
 }
 
 func invokeContract(ctx *cli.Context) error {
@@ -299,6 +368,10 @@ func invokeContract(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	crossVM := ctx.Bool(utils.GetFlagName(utils.ContractCrossVMFlag))
+	if crossVM && vmtype != payload.WASMVM_TYPE {
+		return fmt.Errorf("flag --%s only supports Wasmvm contract", utils.ContractCrossVMFlag.Name)
+	}
 	paramsStr := ctx.String(utils.GetFlagName(utils.ContractParamsFlag))
 	params, err := utils.ParseParams(paramsStr)
 	if err != nil {
@@ -308,18 +381,13 @@ func invokeContract(ctx *cli.Context) error {
 	paramData, _ := json.Marshal(params)
 	PrintInfoMsg("Invoke:%x Params:%s", contractAddr[:], paramData)
 	if ctx.IsSet(utils.GetFlagName(utils.ContractPrepareInvokeFlag)) {
-
-		var preResult *httpcom.PreExecuteResult
-		if vmtype == payload.NEOVM_TYPE {
-			preResult, err = utils.PrepareInvokeNeoVMContract(contractAddr, params)
-
-		}
-		if vmtype == payload.WASMVM_TYPE {
-			preResult, err = utils.PrepareInvokeWasmVMContract(contractAddr, params)
+		mutable, err := newContractInvokeTx(0, 0, vmtype, crossVM, contractAddr, params)
+		if err != nil {
+			return err
 		}
-
+		preResult, err := utils.PrepareTransaction(mutable)
 		if err != nil {
-			return fmt.Errorf("PrepareInvokeNeoVMSmartContact error:%s", err)
+			return fmt.Errorf("PrepareInvokeContract error:%s", err)
 		}
 		if preResult.State == 0 {
 			return fmt.Errorf("contract invoke failed")
@@ -352,7 +420,7 @@ func invokeContract(ctx *cli.Context) error {
 		return fmt.Errorf("get signer account error:%s", err)
 	}
 	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
-	gasLimit := ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag))
+	gasLimit := ctx.Uint64(utils.GetFlagName(utils.ContractGasLimitFlag))
 	networkId, err := utils.GetNetworkId()
 	if err != nil {
 		return err
@@ -361,22 +429,259 @@ func invokeContract(ctx *cli.Context) error {
 		gasPrice = 0
 	}
 
-	var txHash string
-	if vmtype == payload.NEOVM_TYPE {
-		txHash, err = utils.InvokeNeoVMContract(gasPrice, gasLimit, signer, contractAddr, params)
+	mutable, err := newContractInvokeTx(gasPrice, gasLimit, vmtype, crossVM, contractAddr, params)
+	if err != nil {
+		return err
+	}
+	err = estimateContractGas(ctx, signer, mutable)
+	if err != nil {
+		return err
+	}
+	return sendContractTransaction(ctx, signer, mutable)
+}
+
+func migrateContract(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.ContractAddrFlag)) ||
+		!ctx.IsSet(utils.GetFlagName(utils.ContractCodeFileFlag)) ||
+		!ctx.IsSet(utils.GetFlagName(utils.ContractNameFlag)) {
+		PrintErrorMsg("Missing %s, %s or %s argument.", utils.ContractAddrFlag.Name, utils.ContractCodeFileFlag.Name,
+			utils.ContractNameFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	contractAddr, err := getNeoVMContractAddr(ctx)
+	if err != nil {
+		return err
+	}
+	vmtype, err := payload.VmTypeFromByte(byte(ctx.Uint(utils.GetFlagName(utils.ContractVmTypeFlag))))
+	if err != nil {
+		return err
+	}
+	codeFile := ctx.String(utils.GetFlagName(utils.ContractCodeFileFlag))
+	codeStr, err := ioutil.ReadFile(codeFile)
+	if err != nil {
+		return fmt.Errorf("read code:%s error:%s", codeFile, err)
+	}
+	code, err := common.HexToBytes(strings.TrimSpace(string(codeStr)))
+	if err != nil {
+		return fmt.Errorf("contract code convert hex to bytes error:%s", err)
+	}
+	newAddr := common.AddressFromVmCode(code)
+	if newAddr == contractAddr {
+		return fmt.Errorf("new contract code is the same as the old one")
+	}
+	newContract, err := utils.GetContractState(newAddr)
+	if err != nil {
+		return fmt.Errorf("get contract:%s error:%s", newAddr.ToHexString(), err)
+	}
+	if newContract != nil {
+		return fmt.Errorf("new contract:%s has already been deployed", newAddr.ToHexString())
+	}
+
+	name := ctx.String(utils.GetFlagName(utils.ContractNameFlag))
+	version := ctx.String(utils.GetFlagName(utils.ContractVersionFlag))
+	params := []interface{}{
+		ctx.String(utils.GetFlagName(utils.ContractMigrateMethodFlag)),
+		[]interface{}{
+			code,
+			int64(vmtype),
+			name,
+			version,
+			ctx.String(utils.GetFlagName(utils.ContractAuthorFlag)),
+			ctx.String(utils.GetFlagName(utils.ContractEmailFlag)),
+			ctx.String(utils.GetFlagName(utils.ContractDescFlag)),
+		},
+	}
+	PrintInfoMsg("Migrate contract:")
+	PrintInfoMsg("  Old Contract Address:%s", contractAddr.ToHexString())
+	PrintInfoMsg("  New Contract Address:%s", newAddr.ToHexString())
+	PrintInfoMsg("  Name:%s Version:%s", name, version)
+	return sendContractLifecycleTx(ctx, contractAddr, params, func() bool {
+		PrintWarnMsg("\nThe storage of contract %s will be moved to %s, and the old contract will be removed.",
+			contractAddr.ToHexString(), newAddr.ToHexString())
+		fmt.Printf("Migrate the contract? (y/n): ")
+		var answer string
+		fmt.Scanln(&answer)
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes"
+	})
+}
+
+func destroyContract(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.ContractAddrFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.ContractAddrFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	contractAddr, err := getNeoVMContractAddr(ctx)
+	if err != nil {
+		return err
+	}
+	params := []interface{}{
+		ctx.String(utils.GetFlagName(utils.ContractDestroyMethodFlag)),
+		[]interface{}{},
+	}
+	PrintInfoMsg("Destroy contract:")
+	PrintInfoMsg("  Contract Address:%s", contractAddr.ToHexString())
+	return sendContractLifecycleTx(ctx, contractAddr, params, func() bool {
+		PrintWarnMsg("\nThe code and storage of contract %s will be removed, and can not be recovered.",
+			contractAddr.ToHexString())
+		fmt.Printf("Type the contract address to confirm: ")
+		var answer string
+		fmt.Scanln(&answer)
+		return strings.TrimSpace(answer) == contractAddr.ToHexString()
+	})
+}
+
+// getNeoVMContractAddr returns the contract address of --address flag, and checks the contract is a deployed NeoVM contract
+func getNeoVMContractAddr(ctx *cli.Context) (common.Address, error) {
+	contractAddr, err := common.AddressFromHexString(ctx.String(utils.GetFlagName(utils.ContractAddrFlag)))
+	if err != nil {
+		return common.ADDRESS_EMPTY, fmt.Errorf("invalid contract address error:%s", err)
+	}
+	contract, err := utils.GetContractState(contractAddr)
+	if err != nil {
+		return common.ADDRESS_EMPTY, fmt.Errorf("get contract:%s error:%s", contractAddr.ToHexString(), err)
+	}
+	if contract == nil {
+		return common.ADDRESS_EMPTY, fmt.Errorf("contract:%s is not deployed", contractAddr.ToHexString())
+	}
+	if contract.VmType() != payload.NEOVM_TYPE {
+		return common.ADDRESS_EMPTY, fmt.Errorf("contract:%s is not a NeoVM contract", contractAddr.ToHexString())
+	}
+	return contractAddr, nil
+}
+
+// sendContractLifecycleTx invokes the NeoVM contract to migrate or destroy itself. The transaction is always
+// pre-executed by the signer, and is sent after confirm returns true unless --yes is set
+func sendContractLifecycleTx(ctx *cli.Context, contractAddr common.Address, params []interface{}, confirm func() bool) error {
+	if ctx.IsSet(utils.GetFlagName(utils.ContractPrepareInvokeFlag)) {
+		mutable, err := httpcom.NewNeovmInvokeTransaction(0, 0, contractAddr, params)
 		if err != nil {
-			return fmt.Errorf("invoke NeoVM contract error:%s", err)
+			return err
 		}
-	}
-	if vmtype == payload.WASMVM_TYPE {
-		txHash, err = utils.InvokeWasmVMContract(gasPrice, gasLimit, signer, contractAddr, params)
+		preResult, err := utils.PrepareTransaction(mutable)
 		if err != nil {
-			return fmt.Errorf("invoke NeoVM contract error:%s", err)
+			return fmt.Errorf("PrepareInvokeContract error:%s", err)
 		}
+		if preResult.State == 0 {
+			return fmt.Errorf("contract pre-invoke failed")
+		}
+		PrintInfoMsg("Contract pre-invoke successfully")
+		PrintInfoMsg("  Gas limit:%d", preResult.Gas)
+		return nil
+	}
+	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+	mutable, err := httpcom.NewNeovmInvokeTransaction(gasPrice, 0, contractAddr, params)
+	if err != nil {
+		return err
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("get signer account error:%s", err)
+	}
+	gasLimit, err := utils.EstimateGasLimit(signer, mutable)
+	if err != nil {
+		return fmt.Errorf("estimate gas limit error:%s", err)
 	}
+	if ctx.IsSet(utils.GetFlagName(utils.ContractGasLimitFlag)) {
+		gasLimit = ctx.Uint64(utils.GetFlagName(utils.ContractGasLimitFlag))
+	} else {
+		PrintInfoMsg("  Estimated gas limit:%d", gasLimit)
+	}
+	mutable.GasLimit = gasLimit
+	if !ctx.Bool(utils.GetFlagName(utils.AssumeYesFlag)) && !confirm() {
+		PrintInfoMsg("Transaction is not sent.")
+		return nil
+	}
+	return sendContractTransaction(ctx, signer, mutable)
+}
 
+func newContractInvokeTx(gasPrice, gasLimit uint64, vmtype payload.VmType, crossVM bool, contractAddr common.Address,
+	params []interface{}) (*types.MutableTransaction, error) {
+	switch {
+	case vmtype == payload.NEOVM_TYPE:
+		return httpcom.NewNeovmInvokeTransaction(gasPrice, gasLimit, contractAddr, params)
+	case crossVM:
+		return cutils.NewWasmVMCrossVMInvokeTransaction(gasPrice, gasLimit, contractAddr, params)
+	default:
+		return cutils.NewWasmVMInvokeTransaction(gasPrice, gasLimit, contractAddr, params)
+	}
+}
+
+// estimateContractGas sets the gas limit of transaction by pre-execution if --gaslimit is not set
+func estimateContractGas(ctx *cli.Context, signer *account.Account, mutable *types.MutableTransaction) error {
+	if ctx.IsSet(utils.GetFlagName(utils.ContractGasLimitFlag)) {
+		return nil
+	}
+	gasLimit, err := utils.EstimateGasLimit(signer, mutable)
+	if err != nil {
+		return fmt.Errorf("estimate gas limit error:%s", err)
+	}
+	mutable.GasLimit = gasLimit
+	PrintInfoMsg("Estimated gas limit:%d", gasLimit)
+	return nil
+}
+
+// sendContractTransaction signs and sends the transaction, and waits for it to be committed if --wait is set
+func sendContractTransaction(ctx *cli.Context, signer *account.Account, mutable *types.MutableTransaction) error {
+	txHash, err := utils.InvokeSmartContract(signer, mutable)
+	if err != nil {
+		return err
+	}
 	PrintInfoMsg("  TxHash:%s", txHash)
-	PrintInfoMsg("\nTips:")
-	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
+	return waitContractTransaction(ctx, txHash)
+}
+
+// waitContractTransaction prints the events of transaction after it is committed if --wait is set,
+// otherwise prints the tip to query transaction status
+func waitContractTransaction(ctx *cli.Context, txHash string) error {
+	if !ctx.Bool(utils.GetFlagName(utils.ContractWaitFlag)) {
+		PrintInfoMsg("\nTip:")
+		PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
+		return nil
+	}
+	timeout := time.Duration(ctx.Uint(utils.GetFlagName(utils.ContractWaitTimeoutFlag))) * time.Second
+	if timeout == 0 {
+		timeout = utils.DEFAULT_WAIT_TX_TIMEOUT * time.Second
+	}
+	PrintInfoMsg("\nWaiting for transaction to be committed...")
+	notify, err := utils.WaitTxConfirmed(txHash, timeout)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Transaction committed:")
+	if height, err := utils.GetTxHeight(txHash); err == nil {
+		PrintInfoMsg("  Height:%d", height)
+	}
+	state := "success"
+	if notify.State == 0 {
+		state = "failed"
+	}
+	PrintInfoMsg("  State:%s", state)
+	PrintInfoMsg("  Gas consumed:%d", notify.GasConsumed)
+	if notify.CreatedContract != "" && notify.CreatedContract != common.ADDRESS_EMPTY.ToHexString() {
+		PrintInfoMsg("  Created contract:%s", notify.CreatedContract)
+	}
+	PrintInfoMsg("  Events:")
+	for _, event := range notify.Notify {
+		states, err := json.Marshal(event.States)
+		if err != nil {
+			return fmt.Errorf("json.Marshal states error:%s", err)
+		}
+		PrintInfoMsg("    Contract:%s States:%s", event.ContractAddress, states)
+	}
+	if notify.State == 0 {
+		return fmt.Errorf("transaction:%s execute failed", txHash)
+	}
 	return nil
 }
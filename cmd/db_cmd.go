@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/core/genesis"
+	"github.com/qbyyf/ontology/core/store/ledgerstore"
+	"github.com/urfave/cli"
+)
+
+var DbCommand = cli.Command{
+	Name:  "db",
+	Usage: "Maintain the ledger database of a stopped node",
+	Subcommands: []cli.Command{
+		{
+			Action:    verifyDb,
+			Name:      "verify",
+			Usage:     "Verify the integrity of ledger database",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+				utils.DbVerifyFullFlag,
+				utils.DbVerifyReportFlag,
+				utils.DbVerifyMaxErrorsFlag,
+			},
+			Description: `Verify checks the ledger database in data dir without starting the node, the node must be stopped.
+It walks the block store to check the header hash chain and transactions roots, rebuilds the block merkle
+tree and compares it with the merkle hash file and the saved tree, and checks the saved state merkle roots.
+With --full, all blocks are re-executed in a scratch ledger to compare the state transition hash of each block.
+The report is in JSON, and the command exits with error if any inconsistency is found.
+Note that verify cmd doesn't support testmode`,
+		},
+	},
+	Description: `Ledger database commands work on the data dir of a stopped node.
+You can use the ./ontology db verify --help command to view help information.`,
+}
+
+func verifyDb(ctx *cli.Context) error {
+	//report may be printed to stdout, so log to stderr
+	log.InitLog(log.InfoLog, os.Stderr)
+
+	cfg, err := SetOntologyConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetOntologyConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error:%s", err)
+	}
+	report, err := ledgerstore.VerifyLedger(dbDir, ledgerstore.VerifyOption{
+		StateHashCheckHeight: config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId),
+		GenesisBlock:         genesisBlock,
+		Bookkeepers:          bookKeepers,
+		FullExecution:        ctx.Bool(utils.GetFlagName(utils.DbVerifyFullFlag)),
+		MaxErrors:            ctx.Int(utils.GetFlagName(utils.DbVerifyMaxErrorsFlag)),
+	})
+	if err != nil {
+		return fmt.Errorf("VerifyLedger error:%s", err)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.Marshal error:%s", err)
+	}
+	reportFile := ctx.String(utils.GetFlagName(utils.DbVerifyReportFlag))
+	if reportFile == "" {
+		fmt.Println(string(data))
+	} else {
+		err = ioutil.WriteFile(reportFile, data, 0644)
+		if err != nil {
+			return fmt.Errorf("write report error:%s", err)
+		}
+		PrintInfoMsg("Verify report is written to %s", reportFile)
+	}
+	if !report.Passed {
+		//exit with error message in stderr, to keep the report in stdout parsable
+		return cli.NewExitError(fmt.Sprintf("ledger %s is inconsistent at block height %d, state height %d",
+			dbDir, report.BlockHeight, report.StateHeight), 1)
+	}
+	if reportFile != "" {
+		PrintInfoMsg("Ledger %s is consistent at block height %d, state height %d",
+			dbDir, report.BlockHeight, report.StateHeight)
+	}
+	return nil
+}
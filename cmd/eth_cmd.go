@@ -0,0 +1,362 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	ethcrypto "github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/ontology/account"
+	cmdcom "github.com/qbyyf/ontology/cmd/common"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/urfave/cli"
+)
+
+var ethTxFlags = []cli.Flag{
+	utils.ETHRPCPortFlag,
+	utils.WalletFileFlag,
+	utils.AccountAddressFlag,
+	utils.EthNonceFlag,
+	utils.EthChainIdFlag,
+	utils.EthGasPriceFlag,
+	utils.EthGasLimitFlag,
+	utils.EthSignOnlyFlag,
+	utils.ContractWaitFlag,
+	utils.ContractWaitTimeoutFlag,
+}
+
+var EthCommand = cli.Command{
+	Name:  "eth",
+	Usage: "Build and send EIP155 transactions",
+	Description: "EIP155 commands build legacy EIP155 transactions to transfer ONG on EVM, invoke or deploy EVM contracts, " +
+		"sign them by a secp256k1 account in wallet, and send them to the eth rpc server of the node. The nonce and " +
+		"chain id are queried from the node if not specified, and the nonce includes the pending transactions in tx pool.",
+	Subcommands: []cli.Command{
+		{
+			Action:    ethAddress,
+			Name:      "address",
+			Usage:     "Show the EVM address of a secp256k1 account",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:    ethTransfer,
+			Name:      "transfer",
+			Usage:     "Transfer ONG to an EVM address",
+			ArgsUsage: " ",
+			Flags: append([]cli.Flag{
+				utils.EthToFlag,
+				utils.EthAmountFlag,
+			}, ethTxFlags...),
+		},
+		{
+			Action:    ethInvoke,
+			Name:      "invoke",
+			Usage:     "Invoke an EVM contract",
+			ArgsUsage: " ",
+			Description: "Invoke --method of the contract with --params encoded by the Solidity ABI in --abi file, " +
+				"or invoke the contract with the encoded --data.",
+			Flags: append([]cli.Flag{
+				utils.EthContractFlag,
+				utils.EthAbiFlag,
+				utils.EthMethodFlag,
+				utils.EthParamsFlag,
+				utils.EthDataFlag,
+				utils.EthAmountFlag,
+			}, ethTxFlags...),
+		},
+		{
+			Action:    ethDeploy,
+			Name:      "deploy",
+			Usage:     "Deploy an EVM contract",
+			ArgsUsage: " ",
+			Description: "Deploy the contract bytecode in hex in --code file. The constructor --params are encoded by " +
+				"the Solidity ABI in --abi file.",
+			Flags: append([]cli.Flag{
+				utils.ContractCodeFileFlag,
+				utils.EthAbiFlag,
+				utils.EthParamsFlag,
+				utils.EthAmountFlag,
+			}, ethTxFlags...),
+		},
+	},
+}
+
+func ethAddress(ctx *cli.Context) error {
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	address := ctx.String(utils.GetFlagName(utils.AccountAddressFlag))
+	accMeta := cmdcom.GetAccountMetadataMulti(wallet, address)
+	if accMeta == nil {
+		return fmt.Errorf("cannot find account info by: %s", address)
+	}
+	pubKey, err := hex.DecodeString(accMeta.PubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key of account:%s", accMeta.Address)
+	}
+	pk, err := keypair.DeserializePublicKey(pubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key of account:%s", accMeta.Address)
+	}
+	ethAddr, err := utils.GetEthAddress(pk)
+	if err != nil {
+		return fmt.Errorf("account:%s %s", accMeta.Address, err)
+	}
+	PrintInfoMsg("Account:%s", accMeta.Address)
+	PrintInfoMsg("  EVM address:%s", ethAddr.Hex())
+	return nil
+}
+
+func ethTransfer(ctx *cli.Context) error {
+	SetEthRpcPort(ctx)
+	toArg := ctx.String(utils.GetFlagName(utils.EthToFlag))
+	amountArg := ctx.String(utils.GetFlagName(utils.EthAmountFlag))
+	if toArg == "" || amountArg == "" {
+		PrintErrorMsg("Missing %s or %s argument.", utils.EthToFlag.Name, utils.EthAmountFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	to, err := utils.ParseEthAddress(toArg)
+	if err != nil {
+		return err
+	}
+	amount, err := utils.ParseEthAmount(amountArg)
+	if err != nil {
+		return err
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("GetAccount error:%s", err)
+	}
+	from, err := utils.GetEthAddress(signer.PublicKey)
+	if err != nil {
+		return err
+	}
+	gasLimit := uint64(utils.ETH_TRANSFER_GAS)
+	if ctx.IsSet(utils.GetFlagName(utils.EthGasLimitFlag)) {
+		gasLimit = ctx.Uint64(utils.GetFlagName(utils.EthGasLimitFlag))
+	}
+	nonce, err := getEthNonce(ctx, from)
+	if err != nil {
+		return err
+	}
+	tx := utils.NewEthTransferTx(nonce, to, amount, ctx.Uint64(utils.GetFlagName(utils.EthGasPriceFlag)), gasLimit)
+
+	PrintInfoMsg("Transfer ONG")
+	PrintInfoMsg("  From:%s", from.Hex())
+	PrintInfoMsg("  To:%s", to.Hex())
+	PrintInfoMsg("  Amount:%s", utils.FormatEthAmount(amount))
+	return signAndSendEthTx(ctx, signer, tx)
+}
+
+func ethInvoke(ctx *cli.Context) error {
+	SetEthRpcPort(ctx)
+	contractArg := ctx.String(utils.GetFlagName(utils.EthContractFlag))
+	if contractArg == "" {
+		PrintErrorMsg("Missing %s argument.", utils.EthContractFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	contract, err := utils.ParseEthAddress(contractArg)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	method := ctx.String(utils.GetFlagName(utils.EthMethodFlag))
+	if ctx.IsSet(utils.GetFlagName(utils.EthDataFlag)) {
+		data, err = hex.DecodeString(strings.TrimPrefix(ctx.String(utils.GetFlagName(utils.EthDataFlag)), "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid data:%s", err)
+		}
+	} else {
+		if method == "" {
+			PrintErrorMsg("Missing %s or %s argument.", utils.EthMethodFlag.Name, utils.EthDataFlag.Name)
+			cli.ShowSubcommandHelp(ctx)
+			return nil
+		}
+		data, err = packEthAbiCall(ctx, method)
+		if err != nil {
+			return err
+		}
+	}
+	amount, err := utils.ParseEthAmount(ctx.String(utils.GetFlagName(utils.EthAmountFlag)))
+	if err != nil {
+		return err
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("GetAccount error:%s", err)
+	}
+	from, err := utils.GetEthAddress(signer.PublicKey)
+	if err != nil {
+		return err
+	}
+	gasLimit, err := getEthGasLimit(ctx, from, &contract, amount, data)
+	if err != nil {
+		return err
+	}
+	nonce, err := getEthNonce(ctx, from)
+	if err != nil {
+		return err
+	}
+	tx := utils.NewEthInvokeTx(nonce, contract, amount, ctx.Uint64(utils.GetFlagName(utils.EthGasPriceFlag)), gasLimit, data)
+
+	PrintInfoMsg("Invoke EVM contract:%s", contract.Hex())
+	if method != "" {
+		PrintInfoMsg("  Method:%s", method)
+	}
+	PrintInfoMsg("  Data:%x", data)
+	return signAndSendEthTx(ctx, signer, tx)
+}
+
+func ethDeploy(ctx *cli.Context) error {
+	SetEthRpcPort(ctx)
+	codeFile := ctx.String(utils.GetFlagName(utils.ContractCodeFileFlag))
+	if codeFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.ContractCodeFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	codeStr, err := ioutil.ReadFile(codeFile)
+	if err != nil {
+		return fmt.Errorf("read code:%s error:%s", codeFile, err)
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(codeStr)), "0x"))
+	if err != nil {
+		return fmt.Errorf("contract code is not in hex:%s", err)
+	}
+	if ctx.IsSet(utils.GetFlagName(utils.EthAbiFlag)) {
+		args, err := packEthAbiCall(ctx, "")
+		if err != nil {
+			return err
+		}
+		code = append(code, args...)
+	}
+	amount, err := utils.ParseEthAmount(ctx.String(utils.GetFlagName(utils.EthAmountFlag)))
+	if err != nil {
+		return err
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("GetAccount error:%s", err)
+	}
+	from, err := utils.GetEthAddress(signer.PublicKey)
+	if err != nil {
+		return err
+	}
+	gasLimit, err := getEthGasLimit(ctx, from, nil, amount, code)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool(utils.GetFlagName(utils.ContractPrepareDeployFlag)) {
+		PrintInfoMsg("Contract pre-deploy successfully.")
+		PrintInfoMsg("Gas limit:%d.", gasLimit)
+		return nil
+	}
+	nonce, err := getEthNonce(ctx, from)
+	if err != nil {
+		return err
+	}
+	tx := utils.NewEthDeployTx(nonce, amount, ctx.Uint64(utils.GetFlagName(utils.EthGasPriceFlag)), gasLimit, code)
+
+	PrintInfoMsg("Deploy EVM contract")
+	PrintInfoMsg("  Deployer:%s", from.Hex())
+	PrintInfoMsg("  Contract address:%s", ethcrypto.CreateAddress(from, nonce).Hex())
+	return signAndSendEthTx(ctx, signer, tx)
+}
+
+func packEthAbiCall(ctx *cli.Context, method string) ([]byte, error) {
+	abiFile := ctx.String(utils.GetFlagName(utils.EthAbiFlag))
+	if abiFile == "" {
+		return nil, fmt.Errorf("missing %s argument", utils.EthAbiFlag.Name)
+	}
+	abiData, err := ioutil.ReadFile(abiFile)
+	if err != nil {
+		return nil, fmt.Errorf("read abi:%s error:%s", abiFile, err)
+	}
+	return utils.PackEthAbiCall(abiData, method, ctx.String(utils.GetFlagName(utils.EthParamsFlag)))
+}
+
+func getEthNonce(ctx *cli.Context, from ethcommon.Address) (uint64, error) {
+	if ctx.IsSet(utils.GetFlagName(utils.EthNonceFlag)) {
+		return ctx.Uint64(utils.GetFlagName(utils.EthNonceFlag)), nil
+	}
+	nonce, err := utils.GetEthNonce(from)
+	if err != nil {
+		return 0, fmt.Errorf("get nonce of %s error:%s", from.Hex(), err)
+	}
+	return nonce, nil
+}
+
+func getEthGasLimit(ctx *cli.Context, from ethcommon.Address, to *ethcommon.Address, amount *big.Int, data []byte) (uint64, error) {
+	if ctx.IsSet(utils.GetFlagName(utils.EthGasLimitFlag)) {
+		return ctx.Uint64(utils.GetFlagName(utils.EthGasLimitFlag)), nil
+	}
+	gasLimit, err := utils.EstimateEthGas(from, to, amount, data)
+	if err != nil {
+		return 0, fmt.Errorf("estimate gas error:%s", err)
+	}
+	return gasLimit, nil
+}
+
+// signAndSendEthTx signs the EIP155 transaction with the chain id, and sends it unless --sign-only is set
+func signAndSendEthTx(ctx *cli.Context, signer *account.Account, tx *ethtypes.Transaction) error {
+	chainId := ctx.Uint64(utils.GetFlagName(utils.EthChainIdFlag))
+	if !ctx.IsSet(utils.GetFlagName(utils.EthChainIdFlag)) {
+		var err error
+		chainId, err = utils.GetEthChainId()
+		if err != nil {
+			return fmt.Errorf("get chain id error:%s", err)
+		}
+	}
+	signedTx, err := utils.SignEthTransaction(signer, tx, chainId)
+	if err != nil {
+		return err
+	}
+	rawTx, err := utils.EncodeEthTransaction(signedTx)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("  Nonce:%d", signedTx.Nonce())
+	PrintInfoMsg("  GasPrice:%s", signedTx.GasPrice().String())
+	PrintInfoMsg("  GasLimit:%d", signedTx.Gas())
+	if ctx.Bool(utils.GetFlagName(utils.EthSignOnlyFlag)) {
+		PrintInfoMsg("  TxHash:%s", signedTx.Hash().Hex())
+		PrintInfoMsg("  RawTx:%s", rawTx)
+		return nil
+	}
+	txHash, err := utils.SendEthRawTransaction(rawTx)
+	if err != nil {
+		return fmt.Errorf("send transaction error:%s", err)
+	}
+	PrintInfoMsg("  TxHash:%s", txHash)
+	return waitContractTransaction(ctx, strings.TrimPrefix(txHash, "0x"))
+}
@@ -0,0 +1,678 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	cmdcom "github.com/qbyyf/ontology/cmd/common"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/smartcontract/service/native/governance"
+	"github.com/urfave/cli"
+)
+
+var governanceTxFlags = []cli.Flag{
+	utils.RPCPortFlag,
+	utils.WalletFileFlag,
+	utils.AccountAddressFlag,
+	utils.AccountMultiMFlag,
+	utils.AccountMultiPubKeyFlag,
+	utils.TransactionGasPriceFlag,
+	utils.TransactionGasLimitFlag,
+	utils.PrepareExecTransactionFlag,
+}
+
+var GovernanceCommand = cli.Command{
+	Name:  "governance",
+	Usage: "Manage consensus nodes and stakes",
+	Description: "Governance management commands can register and quit candidate nodes, authorize or unauthorize ONT to nodes, " +
+		"withdraw ONT and fee, and view peer pool and stake status. Transactions are signed by the wallet account, or by one " +
+		"signer of a multi signature account if --pubkey is specified.",
+	Subcommands: []cli.Command{
+		{
+			Action:    registerCandidate,
+			Name:      "register",
+			Usage:     "Register a candidate node",
+			ArgsUsage: " ",
+			Description: "Register a candidate node with init pos. The owner account pays the init pos in ONT and " +
+				"the candidate fee in ONG.",
+			Flags: append([]cli.Flag{
+				utils.GovernancePeerPubkeyFlag,
+				utils.GovernancePosFlag,
+			}, governanceTxFlags...),
+		},
+		{
+			Action:    quitNode,
+			Name:      "quitnode",
+			Usage:     "Quit a candidate or consensus node",
+			ArgsUsage: " ",
+			Flags: append([]cli.Flag{
+				utils.GovernancePeerPubkeyFlag,
+			}, governanceTxFlags...),
+		},
+		{
+			Action:    authorizeForPeer,
+			Name:      "authorize",
+			Usage:     "Authorize ONT to nodes",
+			ArgsUsage: " ",
+			Flags: append([]cli.Flag{
+				utils.GovernancePeerPubkeyFlag,
+				utils.GovernancePosFlag,
+			}, governanceTxFlags...),
+		},
+		{
+			Action:    unAuthorizeForPeer,
+			Name:      "unauthorize",
+			Usage:     "Cancel ONT authorized to nodes",
+			ArgsUsage: " ",
+			Flags: append([]cli.Flag{
+				utils.GovernancePeerPubkeyFlag,
+				utils.GovernancePosFlag,
+			}, governanceTxFlags...),
+		},
+		{
+			Action:    withdrawPos,
+			Name:      "withdraw",
+			Usage:     "Withdraw unfrozen ONT from nodes",
+			ArgsUsage: " ",
+			Description: "Withdraw unfrozen ONT from nodes. If --pos does not specified, withdraw all withdrawable ONT " +
+				"of the peers, and of all peers if --peer-pubkey does not specified either.",
+			Flags: append([]cli.Flag{
+				utils.GovernancePeerPubkeyFlag,
+				utils.GovernancePosFlag,
+			}, governanceTxFlags...),
+		},
+		{
+			Action:    withdrawFee,
+			Name:      "withdrawfee",
+			Usage:     "Withdraw ONG reward of staking",
+			ArgsUsage: " ",
+			Flags:     governanceTxFlags,
+		},
+		{
+			Action:    setPeerCost,
+			Name:      "setpeercost",
+			Usage:     "Set the percentage of init pos income the node keeps",
+			ArgsUsage: " ",
+			Flags: append([]cli.Flag{
+				utils.GovernancePeerPubkeyFlag,
+				utils.GovernancePeerCostFlag,
+			}, governanceTxFlags...),
+		},
+		{
+			Action:    addInitPos,
+			Name:      "addinitpos",
+			Usage:     "Add init pos of a node",
+			ArgsUsage: " ",
+			Flags: append([]cli.Flag{
+				utils.GovernancePeerPubkeyFlag,
+				utils.GovernancePosFlag,
+			}, governanceTxFlags...),
+		},
+		{
+			Action: showPeerPool,
+			Name:   "peerpool",
+			Usage:  "Show peer pool of current view",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+			},
+		},
+		{
+			Action:    showStakingInfo,
+			Name:      "stake",
+			Usage:     "Show stakes and unclaimed reward of specified account, in the peers of peer pool by default",
+			ArgsUsage: "<address|label|index>",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.WalletFileFlag,
+				utils.GovernancePeerPubkeyFlag,
+			},
+		},
+	},
+}
+
+// governanceSigner signs governance transactions either by a wallet account, or as one
+// signer of a multi signature account which owns the stakes.
+type governanceSigner struct {
+	acc     *account.Account
+	m       uint16
+	pubKeys []keypair.PublicKey
+}
+
+func (this *governanceSigner) isMultiSig() bool {
+	return len(this.pubKeys) > 0
+}
+
+func (this *governanceSigner) owner() (common.Address, error) {
+	if this.isMultiSig() {
+		return types.AddressFromMultiPubKeys(this.pubKeys, int(this.m))
+	}
+	return this.acc.Address, nil
+}
+
+func getGovernanceSigner(ctx *cli.Context) (*governanceSigner, error) {
+	signer := &governanceSigner{}
+	pkstr := strings.TrimSpace(strings.Trim(ctx.String(utils.GetFlagName(utils.AccountMultiPubKeyFlag)), ","))
+	if pkstr != "" {
+		m := ctx.Uint(utils.GetFlagName(utils.AccountMultiMFlag))
+		for _, pk := range strings.Split(pkstr, ",") {
+			pk = strings.TrimSpace(pk)
+			if pk == "" {
+				continue
+			}
+			data, err := hex.DecodeString(pk)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pub key:%s", pk)
+			}
+			pubKey, err := keypair.DeserializePublicKey(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pub key:%s", pk)
+			}
+			signer.pubKeys = append(signer.pubKeys, pubKey)
+		}
+		pkSize := len(signer.pubKeys)
+		if !(1 <= m && int(m) <= pkSize && pkSize > 1 && pkSize <= constants.MULTI_SIG_MAX_PUBKEY_SIZE) {
+			return nil, fmt.Errorf("invalid argument. %s must > 1 and <= %d, and m must > 0 and < number of pub key",
+				utils.GetFlagName(utils.AccountMultiPubKeyFlag), constants.MULTI_SIG_MAX_PUBKEY_SIZE)
+		}
+		signer.m = uint16(m)
+	}
+	acc, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccount error:%s", err)
+	}
+	signer.acc = acc
+	return signer, nil
+}
+
+func getGovernanceGas(ctx *cli.Context) (uint64, uint64, error) {
+	gasPrice := ctx.Uint64(utils.TransactionGasPriceFlag.Name)
+	gasLimit := ctx.Uint64(utils.TransactionGasLimitFlag.Name)
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return 0, 0, err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+	return gasPrice, gasLimit, nil
+}
+
+// sendGovernanceTx builds, signs and sends a governance transaction. A multi signature transaction
+// is only sent when enough signatures are collected, otherwise the raw tx is printed for other signers.
+func sendGovernanceTx(ctx *cli.Context, signer *governanceSigner, method string, param interface{}) error {
+	gasPrice, gasLimit, err := getGovernanceGas(ctx)
+	if err != nil {
+		return err
+	}
+	mutTx, err := utils.GovernanceTx(gasPrice, gasLimit, method, param)
+	if err != nil {
+		return err
+	}
+	if signer.isMultiSig() {
+		err = utils.MultiSigTransaction(mutTx, signer.m, signer.pubKeys, signer.acc)
+		if err != nil {
+			return fmt.Errorf("MultiSigTransaction error:%s", err)
+		}
+	} else {
+		err = utils.SignTransaction(signer.acc, mutTx)
+		if err != nil {
+			return fmt.Errorf("SignTransaction error:%s", err)
+		}
+	}
+	tx, err := mutTx.IntoImmutable()
+	if err != nil {
+		return fmt.Errorf("IntoImmutable error:%s", err)
+	}
+	rawTx := hex.EncodeToString(common.SerializeToBytes(tx))
+
+	if ctx.IsSet(utils.GetFlagName(utils.PrepareExecTransactionFlag)) {
+		preResult, err := utils.PrepareSendRawTransaction(rawTx)
+		if err != nil {
+			return err
+		}
+		if preResult.State == 0 {
+			return fmt.Errorf("prepare execute transaction failed. %v", preResult)
+		}
+		PrintInfoMsg("Prepare execute transaction success.")
+		PrintInfoMsg("  Gas limit:%d", preResult.Gas)
+		PrintInfoMsg("  Result:%v", preResult.Result)
+		return nil
+	}
+
+	if signer.isMultiSig() && len(mutTx.Sigs[0].SigData) < int(signer.m) {
+		PrintInfoMsg("  Signatures:%d/%d", len(mutTx.Sigs[0].SigData), signer.m)
+		PrintInfoMsg("RawTx after multi signed:")
+		PrintInfoMsg(rawTx)
+		PrintInfoMsg("\nTip:")
+		PrintInfoMsg("  Using './ontology multisigtx --%s %d --%s <pubkeys> --%s <rawtx>' to add signatures of other signers.",
+			utils.GetFlagName(utils.AccountMultiMFlag), signer.m, utils.GetFlagName(utils.AccountMultiPubKeyFlag),
+			utils.GetFlagName(utils.SendTxFlag))
+		return nil
+	}
+	txHash, err := utils.SendRawTransactionData(rawTx)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
+	return nil
+}
+
+func parsePeerPubkeys(ctx *cli.Context) ([]string, error) {
+	pkstr := strings.TrimSpace(strings.Trim(ctx.String(utils.GetFlagName(utils.GovernancePeerPubkeyFlag)), ","))
+	if pkstr == "" {
+		return nil, nil
+	}
+	peerPubkeys := make([]string, 0)
+	for _, pk := range strings.Split(pkstr, ",") {
+		pk = strings.ToLower(strings.TrimSpace(pk))
+		data, err := hex.DecodeString(pk)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer pub key:%s", pk)
+		}
+		if _, err := keypair.DeserializePublicKey(data); err != nil {
+			return nil, fmt.Errorf("invalid peer pub key:%s", pk)
+		}
+		peerPubkeys = append(peerPubkeys, pk)
+	}
+	return peerPubkeys, nil
+}
+
+func parsePosList(ctx *cli.Context) ([]uint32, error) {
+	posStr := strings.TrimSpace(strings.Trim(ctx.String(utils.GetFlagName(utils.GovernancePosFlag)), ","))
+	if posStr == "" {
+		return nil, nil
+	}
+	posList := make([]uint32, 0)
+	for _, s := range strings.Split(posStr, ",") {
+		pos, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+		if err != nil || pos == 0 {
+			return nil, fmt.Errorf("invalid pos:%s, should be a positive integer", s)
+		}
+		posList = append(posList, uint32(pos))
+	}
+	return posList, nil
+}
+
+// parsePeerPos return peer pubkeys and the pos of each peer. When single is true, only one peer is accepted
+func parsePeerPos(ctx *cli.Context, single bool) ([]string, []uint32, error) {
+	peerPubkeys, err := parsePeerPubkeys(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	posList, err := parsePosList(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(peerPubkeys) == 0 || len(posList) == 0 {
+		return nil, nil, fmt.Errorf("missing %s or %s argument", utils.GovernancePeerPubkeyFlag.Name, utils.GovernancePosFlag.Name)
+	}
+	if single && len(peerPubkeys) > 1 {
+		return nil, nil, fmt.Errorf("only one peer is accepted")
+	}
+	if len(peerPubkeys) != len(posList) {
+		return nil, nil, fmt.Errorf("number of %s and %s not match", utils.GovernancePeerPubkeyFlag.Name, utils.GovernancePosFlag.Name)
+	}
+	return peerPubkeys, posList, nil
+}
+
+func parseSinglePeerPubkey(ctx *cli.Context) (string, error) {
+	peerPubkeys, err := parsePeerPubkeys(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(peerPubkeys) != 1 {
+		return "", fmt.Errorf("%s should be one peer pub key", utils.GovernancePeerPubkeyFlag.Name)
+	}
+	return peerPubkeys[0], nil
+}
+
+func printPeerPos(peerPubkeys []string, posList []uint32) {
+	for i, peerPubkey := range peerPubkeys {
+		PrintInfoMsg("  Peer:%s ONT:%d", peerPubkey, posList[i])
+	}
+}
+
+func registerCandidate(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	peerPubkeys, posList, err := parsePeerPos(ctx, true)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getGovernanceSigner(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := signer.owner()
+	if err != nil {
+		return err
+	}
+	param := &governance.RegisterCandidateParam{
+		PeerPubkey: peerPubkeys[0],
+		Address:    owner,
+		InitPos:    posList[0],
+		Caller:     []byte{},
+	}
+	PrintInfoMsg("Register candidate:")
+	PrintInfoMsg("  Owner:%s", owner.ToBase58())
+	printPeerPos(peerPubkeys, posList)
+	return sendGovernanceTx(ctx, signer, governance.REGISTER_CANDIDATE, param)
+}
+
+func quitNode(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	peerPubkey, err := parseSinglePeerPubkey(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getGovernanceSigner(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := signer.owner()
+	if err != nil {
+		return err
+	}
+	param := &governance.QuitNodeParam{
+		PeerPubkey: peerPubkey,
+		Address:    owner,
+	}
+	PrintInfoMsg("Quit node:")
+	PrintInfoMsg("  Owner:%s", owner.ToBase58())
+	PrintInfoMsg("  Peer:%s", peerPubkey)
+	return sendGovernanceTx(ctx, signer, governance.QUIT_NODE, param)
+}
+
+func authorizeForPeer(ctx *cli.Context) error {
+	return changeAuthorization(ctx, governance.AUTHORIZE_FOR_PEER, "Authorize for peer:")
+}
+
+func unAuthorizeForPeer(ctx *cli.Context) error {
+	return changeAuthorization(ctx, governance.UNAUTHORIZE_FOR_PEER, "UnAuthorize for peer:")
+}
+
+func changeAuthorization(ctx *cli.Context, method, title string) error {
+	SetRpcPort(ctx)
+	peerPubkeys, posList, err := parsePeerPos(ctx, false)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getGovernanceSigner(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := signer.owner()
+	if err != nil {
+		return err
+	}
+	param := &governance.AuthorizeForPeerParam{
+		Address:        owner,
+		PeerPubkeyList: peerPubkeys,
+		PosList:        posList,
+	}
+	PrintInfoMsg(title)
+	PrintInfoMsg("  Account:%s", owner.ToBase58())
+	printPeerPos(peerPubkeys, posList)
+	return sendGovernanceTx(ctx, signer, method, param)
+}
+
+func withdrawPos(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	peerPubkeys, err := parsePeerPubkeys(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	posList, err := parsePosList(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	if len(posList) > 0 && len(peerPubkeys) != len(posList) {
+		PrintErrorMsg("Number of %s and %s not match.", utils.GovernancePeerPubkeyFlag.Name, utils.GovernancePosFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getGovernanceSigner(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := signer.owner()
+	if err != nil {
+		return err
+	}
+	if len(posList) == 0 {
+		peerPubkeys, posList, err = getWithdrawablePos(owner.ToBase58(), peerPubkeys)
+		if err != nil {
+			return err
+		}
+		if len(peerPubkeys) == 0 {
+			return fmt.Errorf("account:%s has no withdrawable ont in the peers of peer pool, use --%s for the peers removed from peer pool",
+				owner.ToBase58(), utils.GovernancePeerPubkeyFlag.Name)
+		}
+	}
+	param := &governance.WithdrawParam{
+		Address:        owner,
+		PeerPubkeyList: peerPubkeys,
+		WithdrawList:   posList,
+	}
+	PrintInfoMsg("Withdraw:")
+	PrintInfoMsg("  Account:%s", owner.ToBase58())
+	printPeerPos(peerPubkeys, posList)
+	return sendGovernanceTx(ctx, signer, governance.WITHDRAW, param)
+}
+
+// getWithdrawablePos return the withdrawable pos of address in peers, or in the peers of peer pool if peers is empty
+func getWithdrawablePos(address string, peers []string) ([]string, []uint32, error) {
+	info, err := utils.GetStakingInfo(address, peers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return utils.WithdrawablePos(info, peers)
+}
+
+func withdrawFee(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	signer, err := getGovernanceSigner(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := signer.owner()
+	if err != nil {
+		return err
+	}
+	param := &governance.WithdrawFeeParam{
+		Address: owner,
+	}
+	PrintInfoMsg("Withdraw fee:")
+	PrintInfoMsg("  Account:%s", owner.ToBase58())
+	return sendGovernanceTx(ctx, signer, governance.WITHDRAW_FEE, param)
+}
+
+func setPeerCost(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	peerPubkey, err := parseSinglePeerPubkey(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	if !ctx.IsSet(utils.GetFlagName(utils.GovernancePeerCostFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.GovernancePeerCostFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	peerCost := ctx.Uint(utils.GetFlagName(utils.GovernancePeerCostFlag))
+	if peerCost > 100 {
+		return fmt.Errorf("%s should be in 0~100", utils.GovernancePeerCostFlag.Name)
+	}
+	signer, err := getGovernanceSigner(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := signer.owner()
+	if err != nil {
+		return err
+	}
+	param := &governance.SetPeerCostParam{
+		PeerPubkey: peerPubkey,
+		Address:    owner,
+		PeerCost:   uint32(peerCost),
+	}
+	PrintInfoMsg("Set peer cost:")
+	PrintInfoMsg("  Owner:%s", owner.ToBase58())
+	PrintInfoMsg("  Peer:%s", peerPubkey)
+	PrintInfoMsg("  PeerCost:%d%%", peerCost)
+	return sendGovernanceTx(ctx, signer, governance.SET_PEER_COST, param)
+}
+
+func addInitPos(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	peerPubkeys, posList, err := parsePeerPos(ctx, true)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getGovernanceSigner(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := signer.owner()
+	if err != nil {
+		return err
+	}
+	param := &governance.ChangeInitPosParam{
+		PeerPubkey: peerPubkeys[0],
+		Address:    owner,
+		Pos:        posList[0],
+	}
+	PrintInfoMsg("Add init pos:")
+	PrintInfoMsg("  Owner:%s", owner.ToBase58())
+	printPeerPos(peerPubkeys, posList)
+	return sendGovernanceTx(ctx, signer, governance.ADD_INIT_POS, param)
+}
+
+func peerStatusName(status governance.Status) string {
+	switch status {
+	case governance.RegisterCandidateStatus:
+		return "registered"
+	case governance.CandidateStatus:
+		return "candidate"
+	case governance.ConsensusStatus:
+		return "consensus"
+	case governance.QuitConsensusStatus:
+		return "quit consensus"
+	case governance.QuitingStatus:
+		return "quiting"
+	case governance.BlackStatus:
+		return "black"
+	default:
+		return fmt.Sprintf("unknown(%d)", status)
+	}
+}
+
+func showPeerPool(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	view, err := utils.GetGovernanceView()
+	if err != nil {
+		return err
+	}
+	peerPoolMap, err := utils.GetPeerPoolMap(view.View)
+	if err != nil {
+		return err
+	}
+	peers := make([]*governance.PeerPoolItem, 0, len(peerPoolMap.PeerPoolMap))
+	for _, item := range peerPoolMap.PeerPoolMap {
+		peers = append(peers, item)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].InitPos+peers[i].TotalPos != peers[j].InitPos+peers[j].TotalPos {
+			return peers[i].InitPos+peers[i].TotalPos > peers[j].InitPos+peers[j].TotalPos
+		}
+		return peers[i].PeerPubkey < peers[j].PeerPubkey
+	})
+
+	PrintInfoMsg("Peer pool of view:%d height:%d", view.View, view.Height)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tPEER PUBKEY\tOWNER\tSTATUS\tINIT POS\tTOTAL POS")
+	for _, item := range peers {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d\n", item.Index, item.PeerPubkey, item.Address.ToBase58(),
+			peerStatusName(item.Status), item.InitPos, item.TotalPos)
+	}
+	return w.Flush()
+}
+
+func showStakingInfo(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing account argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	accAddr, err := cmdcom.ParseAddress(ctx.Args().First(), ctx)
+	if err != nil {
+		return err
+	}
+	peerPubkeys, err := parsePeerPubkeys(ctx)
+	if err != nil {
+		return err
+	}
+	info, err := utils.GetStakingInfo(accAddr, peerPubkeys)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Stake of:%s", info.Address)
+	PrintInfoMsg("  Height:%s", info.Height)
+	PrintInfoMsg("  TotalStake:%d", info.TotalStake)
+	PrintInfoMsg("  UnclaimedFee:%s ONG", utils.FormatOng(info.UnclaimedFee))
+	if len(info.Authorizations) == 0 {
+		return nil
+	}
+	PrintInfoMsg("")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PEER PUBKEY\tSTATUS\tCONSENSUS\tCANDIDATE\tPENDING\tFROZEN\tWITHDRAWABLE")
+	for _, v := range info.Authorizations {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", v.PeerPubkey, peerStatusName(governance.Status(v.PeerStatus)),
+			v.ConsensusPos, v.CandidatePos, v.PendingPos, v.FrozenPos, v.WithdrawablePos)
+	}
+	return w.Flush()
+}
@@ -21,8 +21,10 @@ package cmd
 import (
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"strconv"
 
+	"github.com/qbyyf/ontology/cmd/abi"
 	"github.com/qbyyf/ontology/cmd/utils"
 	"github.com/qbyyf/ontology/core/types"
 	httpcom "github.com/qbyyf/ontology/http/base/common"
@@ -89,6 +91,23 @@ var ShowTxCommand = cli.Command{
 	Description: "Show info of raw transaction.",
 }
 
+var DecodeTxCommand = cli.Command{
+	Action:    decodeTx,
+	Name:      "decodetx",
+	Usage:     "Decode the contract call of raw transaction.",
+	ArgsUsage: "<rawtx>",
+	Flags: []cli.Flag{
+		utils.RPCPortFlag,
+		utils.TransactionHashFlag,
+		utils.CliABIPathFlag,
+		utils.NeoVMAbiFileFlag,
+		utils.EthAbiFileFlag,
+	},
+	Description: `Decode the contract call of ontology or EIP155 raw transaction, or the transaction queried by --hash.
+Params of native contract are decoded by the native abi in --abi path, params of NeoVM contract are decoded by --neovm-abi,
+params of EVM contract are decoded by --eth-abi, and params of wasm contract are decoded if they are in cross vm codec.`,
+}
+
 func blockInfo(ctx *cli.Context) error {
 	SetRpcPort(ctx)
 	if ctx.NArg() < 1 {
@@ -190,3 +209,55 @@ func showTx(ctx *cli.Context) error {
 	PrintJsonObject(txInfo)
 	return nil
 }
+
+func decodeTx(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	txHash := ctx.String(utils.GetFlagName(utils.TransactionHashFlag))
+	if ctx.NArg() < 1 && txHash == "" {
+		PrintErrorMsg("Missing raw tx argument or %s.", utils.GetFlagName(utils.TransactionHashFlag))
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	abis, err := getTxDecodeAbi(ctx)
+	if err != nil {
+		return err
+	}
+	var txInfo *utils.TxInfo
+	if txHash != "" {
+		txInfo, err = utils.DecodeTransactionByHash(txHash, abis)
+	} else {
+		txInfo, err = utils.DecodeRawTransaction(ctx.Args().First(), abis)
+	}
+	if err != nil {
+		return fmt.Errorf("decode transaction error:%s", err)
+	}
+	PrintJsonObject(txInfo)
+	return nil
+}
+
+// getTxDecodeAbi loads the native abi, and the NeoVM and Solidity abi of contract if they are set
+func getTxDecodeAbi(ctx *cli.Context) (*utils.TxDecodeAbi, error) {
+	abi.DefAbiMgr.Init(ctx.String(utils.GetFlagName(utils.CliABIPathFlag)))
+	abis := &utils.TxDecodeAbi{}
+	if abiFile := ctx.String(utils.GetFlagName(utils.NeoVMAbiFileFlag)); abiFile != "" {
+		data, err := ioutil.ReadFile(abiFile)
+		if err != nil {
+			return nil, fmt.Errorf("read abi file:%s error:%s", abiFile, err)
+		}
+		abis.Neovm, err = utils.NewNeovmContractAbi(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if abiFile := ctx.String(utils.GetFlagName(utils.EthAbiFileFlag)); abiFile != "" {
+		data, err := ioutil.ReadFile(abiFile)
+		if err != nil {
+			return nil, fmt.Errorf("read abi file:%s error:%s", abiFile, err)
+		}
+		abis.Eth, err = utils.NewEthContractAbi(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return abis, nil
+}
@@ -0,0 +1,354 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	cmdcom "github.com/qbyyf/ontology/cmd/common"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/urfave/cli"
+)
+
+var MultiSigCommand = cli.Command{
+	Name:  "multisig",
+	Usage: "Coordinate multi-signature transaction with partially signed transaction file",
+	Subcommands: []cli.Command{
+		{
+			Action:      multiSigCreate,
+			Name:        "create",
+			Usage:       "Create partially signed transaction file from unsigned transaction",
+			ArgsUsage:   "<rawtx>",
+			Description: "Create partially signed transaction file from unsigned transaction. The payer of transaction must be the multi-signature address.",
+			Flags: []cli.Flag{
+				utils.AccountMultiMFlag,
+				utils.AccountMultiPubKeyFlag,
+				utils.MultiSigFileFlag,
+				utils.CliABIPathFlag,
+				utils.NeoVMAbiFileFlag,
+			},
+		},
+		{
+			Action:      multiSigInspect,
+			Name:        "inspect",
+			Usage:       "Show what is signed and who has signed in partially signed transaction file",
+			ArgsUsage:   "<file>",
+			Description: "Show what is signed and who has signed in partially signed transaction file. Transaction is decoded locally, and every signature is verified.",
+			Flags: []cli.Flag{
+				utils.CliABIPathFlag,
+				utils.NeoVMAbiFileFlag,
+			},
+		},
+		{
+			Action:      multiSigSign,
+			Name:        "sign",
+			Usage:       "Sign partially signed transaction file",
+			ArgsUsage:   "<file>",
+			Description: "Sign partially signed transaction file. The decoded transaction is shown to confirm before signing. The signed file is written to --file, or back to <file> by default.",
+			Flags: []cli.Flag{
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+				utils.MultiSigFileFlag,
+				utils.CliABIPathFlag,
+				utils.NeoVMAbiFileFlag,
+				utils.AssumeYesFlag,
+				utils.RemoteSignerFlag,
+				utils.RemoteSignerKeyFlag,
+				utils.RemoteSignerTokenFlag,
+				utils.RemoteSignerCAFlag,
+				utils.RemoteSignerCertFlag,
+				utils.RemoteSignerCertKeyFlag,
+			},
+		},
+		{
+			Action:      multiSigMerge,
+			Name:        "merge",
+			Usage:       "Merge signatures of partially signed transaction files",
+			ArgsUsage:   "<file> <file>...",
+			Description: "Merge signatures of partially signed transaction files of the same transaction. The merged file is written to --file, or to the first <file> by default.",
+			Flags: []cli.Flag{
+				utils.MultiSigFileFlag,
+				utils.CliABIPathFlag,
+				utils.NeoVMAbiFileFlag,
+			},
+		},
+		{
+			Action:      multiSigFinalize,
+			Name:        "finalize",
+			Usage:       "Build multi-signed transaction from partially signed transaction file",
+			ArgsUsage:   "<file>",
+			Description: "Build multi-signed transaction from partially signed transaction file, after enough signatures are collected.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.CliABIPathFlag,
+				utils.NeoVMAbiFileFlag,
+				utils.SendTxFlag,
+				utils.PrepareExecTransactionFlag,
+			},
+		},
+	},
+	Description: "Coordinate multi-signature transaction. Partially signed transaction file records the pub keys and m of multi-signature address, the collected signatures and the decoded transaction, and is passed among signers until m signatures are collected.",
+}
+
+func multiSigCreate(ctx *cli.Context) error {
+	pkstr := strings.TrimSpace(strings.Trim(ctx.String(utils.GetFlagName(utils.AccountMultiPubKeyFlag)), ","))
+	m := ctx.Uint(utils.GetFlagName(utils.AccountMultiMFlag))
+	file := ctx.String(utils.GetFlagName(utils.MultiSigFileFlag))
+	if pkstr == "" || m == 0 || file == "" {
+		PrintErrorMsg("Missing argument. %s, %s or %s expected.",
+			utils.GetFlagName(utils.AccountMultiMFlag),
+			utils.GetFlagName(utils.AccountMultiPubKeyFlag),
+			utils.GetFlagName(utils.MultiSigFileFlag))
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing <rawtx> argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	pubKeys, err := parseMultiSigPubKeys(pkstr)
+	if err != nil {
+		return err
+	}
+	abis, err := getTxDecodeAbi(ctx)
+	if err != nil {
+		return err
+	}
+	txData, err := hex.DecodeString(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("RawTx hex decode error:%s", err)
+	}
+	tx, err := types.TransactionFromRawBytes(txData)
+	if err != nil {
+		return fmt.Errorf("TransactionFromRawBytes error:%s", err)
+	}
+	mutTx, err := tx.IntoMutable()
+	if err != nil {
+		return fmt.Errorf("IntoMutable error:%s", err)
+	}
+	ptx, err := utils.NewPartialTx(mutTx, uint16(m), pubKeys, abis.Neovm)
+	if err != nil {
+		return err
+	}
+	err = ptx.Save(file)
+	if err != nil {
+		return err
+	}
+	printPartialTx(ptx)
+	PrintInfoMsg("\nPartially signed transaction is written to:%s", file)
+	return nil
+}
+
+func multiSigInspect(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing <file> argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	ptx, err := loadPartialTx(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	printPartialTx(ptx)
+	return nil
+}
+
+func multiSigSign(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing <file> argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	file := ctx.Args().First()
+	ptx, err := loadPartialTx(ctx, file)
+	if err != nil {
+		return err
+	}
+	printPartialTx(ptx)
+	if !ctx.Bool(utils.GetFlagName(utils.AssumeYesFlag)) {
+		fmt.Printf("\nSign the transaction above? (y/n): ")
+		//read without buffer, the password is read from stdin after
+		var answer string
+		fmt.Scanln(&answer)
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			PrintInfoMsg("Transaction is not signed.")
+			return nil
+		}
+	}
+	signer, err := cmdcom.GetSigner(ctx)
+	if err != nil {
+		return fmt.Errorf("GetSigner error:%s", err)
+	}
+	err = ptx.Sign(signer)
+	if err != nil {
+		return err
+	}
+	if ctx.IsSet(utils.GetFlagName(utils.MultiSigFileFlag)) {
+		file = ctx.String(utils.GetFlagName(utils.MultiSigFileFlag))
+	}
+	err = ptx.Save(file)
+	if err != nil {
+		return err
+	}
+	signerAddr := types.AddressFromPubKey(signer.PubKey())
+	PrintInfoMsg("\nSigned by:%s, %d of %d signatures are collected.", signerAddr.ToBase58(), len(ptx.Sigs), ptx.M)
+	PrintInfoMsg("Partially signed transaction is written to:%s", file)
+	return nil
+}
+
+func multiSigMerge(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		PrintErrorMsg("Missing <file> argument, at least two files expected.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	files := ctx.Args()
+	ptx, err := loadPartialTx(ctx, files[0])
+	if err != nil {
+		return err
+	}
+	for _, file := range files[1:] {
+		other, err := loadPartialTx(ctx, file)
+		if err != nil {
+			return err
+		}
+		err = ptx.Merge(other)
+		if err != nil {
+			return fmt.Errorf("merge file:%s error:%s", file, err)
+		}
+	}
+	file := files[0]
+	if ctx.IsSet(utils.GetFlagName(utils.MultiSigFileFlag)) {
+		file = ctx.String(utils.GetFlagName(utils.MultiSigFileFlag))
+	}
+	err = ptx.Save(file)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("%d of %d signatures are collected.", len(ptx.Sigs), ptx.M)
+	PrintInfoMsg("Partially signed transaction is written to:%s", file)
+	return nil
+}
+
+func multiSigFinalize(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing <file> argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	ptx, err := loadPartialTx(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	tx, err := ptx.Finalize()
+	if err != nil {
+		return err
+	}
+	sink := common.ZeroCopySink{}
+	tx.Serialization(&sink)
+
+	rawTx := hex.EncodeToString(sink.Bytes())
+	PrintInfoMsg("RawTx after multi signed:")
+	PrintInfoMsg(rawTx)
+	PrintInfoMsg("")
+
+	if ctx.IsSet(utils.GetFlagName(utils.PrepareExecTransactionFlag)) {
+		preResult, err := utils.PrepareSendRawTransaction(rawTx)
+		if err != nil {
+			return err
+		}
+		if preResult.State == 0 {
+			return fmt.Errorf("prepare execute transaction failed. %v", preResult)
+		}
+		PrintInfoMsg("Prepare execute transaction success.")
+		PrintInfoMsg("Gas limit:%d", preResult.Gas)
+		PrintInfoMsg("Result:%v", preResult.Result)
+		return nil
+	}
+
+	if ctx.IsSet(utils.GetFlagName(utils.SendTxFlag)) {
+		txHash, err := utils.SendRawTransactionData(rawTx)
+		if err != nil {
+			return err
+		}
+		PrintInfoMsg("Send transaction success.")
+		PrintInfoMsg("  TxHash:%s", txHash)
+		PrintInfoMsg("\nTip:")
+		PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
+	}
+	return nil
+}
+
+func parseMultiSigPubKeys(pkstr string) ([]keypair.PublicKey, error) {
+	pks := strings.Split(pkstr, ",")
+	pubKeys := make([]keypair.PublicKey, 0, len(pks))
+	for _, pk := range pks {
+		pk := strings.TrimSpace(pk)
+		if pk == "" {
+			continue
+		}
+		data, err := hex.DecodeString(pk)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pub key:%s", pk)
+		}
+		pubKey, err := keypair.DeserializePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pub key:%s", pk)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, nil
+}
+
+func loadPartialTx(ctx *cli.Context, file string) (*utils.PartialTx, error) {
+	abis, err := getTxDecodeAbi(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ptx, err := utils.LoadPartialTx(file, abis.Neovm)
+	if err != nil {
+		return nil, fmt.Errorf("load file:%s error:%s", file, err)
+	}
+	return ptx, nil
+}
+
+func printPartialTx(ptx *utils.PartialTx) {
+	PrintInfoMsg("Transaction:")
+	PrintJsonObject(ptx.Decoded)
+	if ptx.Decoded.Invoke != nil && !ptx.Decoded.Invoke.ByAbi {
+		PrintWarnMsg("Params are not decoded by abi, check the raw params carefully.")
+	}
+	PrintInfoMsg("\nMultiSigAddress:%s", ptx.Address)
+	PrintInfoMsg("Signatures:%d of %d", len(ptx.Sigs), ptx.M)
+	for i, pubKey := range ptx.GetPubKeys() {
+		addr := types.AddressFromPubKey(pubKey)
+		signed := "not signed"
+		if ptx.HasSigned(pubKey) {
+			signed = "signed"
+		}
+		PrintInfoMsg("Index %d Address:%s PubKey:%s %s", i+1, addr.ToBase58(), ptx.PubKeys[i], signed)
+	}
+}
@@ -0,0 +1,647 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	cmdcom "github.com/qbyyf/ontology/cmd/common"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/password"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ontid"
+	"github.com/urfave/cli"
+)
+
+var ontIdTxFlags = []cli.Flag{
+	utils.RPCPortFlag,
+	utils.WalletFileFlag,
+	utils.AccountAddressFlag,
+	utils.OntIdKeyIndexFlag,
+	utils.TransactionGasPriceFlag,
+	utils.TransactionGasLimitFlag,
+	utils.PrepareExecTransactionFlag,
+}
+
+var OntIdCommand = cli.Command{
+	Name:  "ontid",
+	Usage: "Manage ONT IDs",
+	Description: "ONT ID management commands can create ONT IDs in wallet, register them on chain, manage their keys, " +
+		"controller, recovery, attributes and services, and resolve their DID documents. Transactions are signed by " +
+		"the key of ONT ID specified by --key-index, and the gas is paid by the wallet account.",
+	Subcommands: []cli.Command{
+		{
+			Action:    ontIdCreate,
+			Name:      "create",
+			Usage:     "Create an ONT ID in wallet",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.WalletFileFlag,
+				utils.AccountLabelFlag,
+				utils.AccountTypeFlag,
+				utils.AccountKeylenFlag,
+			},
+		},
+		{
+			Action:    ontIdList,
+			Name:      "list",
+			Usage:     "List ONT IDs in wallet",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.WalletFileFlag,
+			},
+		},
+		{
+			Action:    ontIdRegister,
+			Name:      "register",
+			Usage:     "Register an ONT ID on chain",
+			ArgsUsage: "<ontid>",
+			Description: "Register an ONT ID with its key in wallet. If --controller is specified, the ONT ID is " +
+				"registered with the controller instead, and the transaction is signed by the controller key specified by --key-index.",
+			Flags: append([]cli.Flag{
+				utils.OntIdControllerFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdAddKey,
+			Name:      "addkey",
+			Usage:     "Add a public key to an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags: append([]cli.Flag{
+				utils.OntIdPublicKeyFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdRemoveKey,
+			Name:      "removekey",
+			Usage:     "Remove a public key from an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags: append([]cli.Flag{
+				utils.OntIdPublicKeyFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdAddAttribute,
+			Name:      "addattr",
+			Usage:     "Add an attribute to an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags: append([]cli.Flag{
+				utils.OntIdAttrKeyFlag,
+				utils.OntIdAttrTypeFlag,
+				utils.OntIdAttrValueFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdRemoveAttribute,
+			Name:      "removeattr",
+			Usage:     "Remove an attribute from an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags: append([]cli.Flag{
+				utils.OntIdAttrKeyFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdAddService,
+			Name:      "addservice",
+			Usage:     "Add a service to an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags: append([]cli.Flag{
+				utils.OntIdServiceIdFlag,
+				utils.OntIdServiceTypeFlag,
+				utils.OntIdServiceEndpointFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdRemoveService,
+			Name:      "removeservice",
+			Usage:     "Remove a service from an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags: append([]cli.Flag{
+				utils.OntIdServiceIdFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdSetRecovery,
+			Name:      "setrecovery",
+			Usage:     "Set recovery of an ONT ID",
+			ArgsUsage: "<ontid>",
+			Description: "Set recovery of an ONT ID to a group of registered ONT IDs, of which --threshold members " +
+				"are required to recover the keys. Recovery can be set only once.",
+			Flags: append([]cli.Flag{
+				utils.OntIdRecoveryFlag,
+				utils.OntIdThresholdFlag,
+			}, ontIdTxFlags...),
+		},
+		{
+			Action:    ontIdRemoveController,
+			Name:      "removecontroller",
+			Usage:     "Remove the controller of an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags:     ontIdTxFlags,
+		},
+		{
+			Action:    ontIdResolve,
+			Name:      "resolve",
+			Usage:     "Resolve the DID document of an ONT ID",
+			ArgsUsage: "<ontid>",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+			},
+		},
+	},
+}
+
+func ontIdCreate(ctx *cli.Context) error {
+	optionType := strings.ToLower(ctx.String(utils.GetFlagName(utils.AccountTypeFlag)))
+	keyType, ok := keyTypeMap[optionType]
+	if !ok {
+		PrintErrorMsg("Invalid key type:%s.", optionType)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	var curve byte
+	switch keyType.code {
+	case keypair.PK_SM2:
+		curve = keypair.SM2P256V1
+	case keypair.PK_EDDSA:
+		curve = keypair.ED25519
+	default:
+		optionCurve := ctx.String(utils.GetFlagName(utils.AccountKeylenFlag))
+		info, ok := curveMap[optionCurve]
+		if !ok || info.code == keypair.SM2P256V1 || info.code == keypair.ED25519 {
+			PrintErrorMsg("Invalid bit length:%s.", optionCurve)
+			cli.ShowSubcommandHelp(ctx)
+			return nil
+		}
+		curve = info.code
+	}
+	walletFile := checkFileName(ctx)
+	wallet, err := account.Open(walletFile)
+	if err != nil {
+		return fmt.Errorf("error opening wallet: %s", err)
+	}
+	pass, err := password.GetConfirmedPassword()
+	if err != nil {
+		return fmt.Errorf("input password error: %s", err)
+	}
+	defer cmdcom.ClearPasswd(pass)
+	wd := wallet.GetWalletData()
+	identity, err := account.NewIdentity(ctx.String(utils.GetFlagName(utils.AccountLabelFlag)), keyType.code, curve, pass)
+	if err != nil {
+		return fmt.Errorf("error creating ONT ID: %s", err)
+	}
+	wd.AddIdentity(identity)
+	err = wd.Save(walletFile)
+	if err != nil {
+		return fmt.Errorf("error saving to %s: %s", walletFile, err)
+	}
+	PrintInfoMsg("ONT ID created: %s", identity.ID)
+	PrintInfoMsg("Bind public key: %s", identity.Control[0].Public)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './ontology ontid register %s' to register the ONT ID on chain.", identity.ID)
+	return nil
+}
+
+func ontIdList(ctx *cli.Context) error {
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return err
+	}
+	identities := wallet.GetWalletData().Identities
+	if len(identities) == 0 {
+		PrintInfoMsg("No ONT ID in wallet.")
+		return nil
+	}
+	for i, identity := range identities {
+		PrintInfoMsg("Index:%d", i+1)
+		PrintInfoMsg("  ONT ID:%s", identity.ID)
+		if identity.Label != "" {
+			PrintInfoMsg("  Label:%s", identity.Label)
+		}
+		for _, controller := range identity.Control {
+			PrintInfoMsg("  Key #%s:%s", controller.ID, controller.Public)
+		}
+	}
+	return nil
+}
+
+// getOntIdArg return the ONT ID in the first argument
+func getOntIdArg(ctx *cli.Context) (string, error) {
+	if ctx.NArg() < 1 {
+		return "", fmt.Errorf("missing ONT ID argument")
+	}
+	id := ctx.Args().First()
+	if !account.VerifyID(id) {
+		return "", fmt.Errorf("invalid ONT ID:%s", id)
+	}
+	return id, nil
+}
+
+// getOntIdSigner return the account of the ONT ID key specified by --key-index in wallet
+func getOntIdSigner(ctx *cli.Context, id string) (*account.Account, error) {
+	wallet, err := cmdcom.OpenWallet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	identity := wallet.GetWalletData().GetIdentity(id)
+	if identity == nil {
+		return nil, fmt.Errorf("cannot find ONT ID %s in wallet", id)
+	}
+	keyIndex := strconv.FormatUint(ctx.Uint64(utils.GetFlagName(utils.OntIdKeyIndexFlag)), 10)
+	controller := identity.GetController(keyIndex)
+	if controller == nil {
+		return nil, fmt.Errorf("cannot find key #%s of ONT ID %s in wallet", keyIndex, id)
+	}
+	PrintInfoMsg("Unlock key #%s of %s", keyIndex, id)
+	passwd, err := password.GetAccountPassword()
+	if err != nil {
+		return nil, fmt.Errorf("input password error: %s", err)
+	}
+	defer cmdcom.ClearPasswd(passwd)
+	return controller.GetAccount(passwd)
+}
+
+// sendOntIdTx builds a transaction invoking ontid contract, which is signed by the ONT ID key
+// and paid by the wallet account.
+func sendOntIdTx(ctx *cli.Context, signer *account.Account, method string, param interface{}) error {
+	gasPrice := ctx.Uint64(utils.TransactionGasPriceFlag.Name)
+	gasLimit := ctx.Uint64(utils.TransactionGasLimitFlag.Name)
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+	mutTx, err := utils.OntIdTx(gasPrice, gasLimit, method, param)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Unlock payer account")
+	payer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("GetAccount error:%s", err)
+	}
+	err = utils.SignTransaction(payer, mutTx)
+	if err != nil {
+		return fmt.Errorf("SignTransaction error:%s", err)
+	}
+	err = utils.SignTransaction(signer, mutTx)
+	if err != nil {
+		return fmt.Errorf("SignTransaction error:%s", err)
+	}
+	tx, err := mutTx.IntoImmutable()
+	if err != nil {
+		return fmt.Errorf("IntoImmutable error:%s", err)
+	}
+	rawTx := hex.EncodeToString(common.SerializeToBytes(tx))
+
+	if ctx.IsSet(utils.GetFlagName(utils.PrepareExecTransactionFlag)) {
+		preResult, err := utils.PrepareSendRawTransaction(rawTx)
+		if err != nil {
+			return err
+		}
+		if preResult.State == 0 {
+			return fmt.Errorf("prepare execute transaction failed. %v", preResult)
+		}
+		PrintInfoMsg("Prepare execute transaction success.")
+		PrintInfoMsg("  Gas limit:%d", preResult.Gas)
+		PrintInfoMsg("  Result:%v", preResult.Result)
+		return nil
+	}
+	txHash, err := utils.SendRawTransactionData(rawTx)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './ontology info status %s' to query transaction status.", txHash)
+	return nil
+}
+
+func ontIdRegister(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	controllerId := ctx.String(utils.GetFlagName(utils.OntIdControllerFlag))
+	if controllerId != "" {
+		if !account.VerifyID(controllerId) {
+			PrintErrorMsg("Invalid controller:%s.", controllerId)
+			cli.ShowSubcommandHelp(ctx)
+			return nil
+		}
+		signer, err := getOntIdSigner(ctx, controllerId)
+		if err != nil {
+			return err
+		}
+		param := &utils.RegIdWithControllerParam{
+			OntId:      []byte(id),
+			Controller: []byte(controllerId),
+			Index:      uint32(ctx.Uint(utils.GetFlagName(utils.OntIdKeyIndexFlag))),
+		}
+		PrintInfoMsg("Register ONT ID:%s", id)
+		PrintInfoMsg("  Controller:%s", controllerId)
+		return sendOntIdTx(ctx, signer, "regIDWithController", param)
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	pubKey := keypair.SerializePublicKey(signer.PublicKey)
+	param := &utils.RegIdWithPublicKeyParam{
+		OntId:  []byte(id),
+		PubKey: pubKey,
+	}
+	PrintInfoMsg("Register ONT ID:%s", id)
+	PrintInfoMsg("  Public key:%x", pubKey)
+	return sendOntIdTx(ctx, signer, "regIDWithPublicKey", param)
+}
+
+func parseOntIdPublicKey(ctx *cli.Context) ([]byte, error) {
+	pk := strings.TrimSpace(ctx.String(utils.GetFlagName(utils.OntIdPublicKeyFlag)))
+	if pk == "" {
+		return nil, fmt.Errorf("missing %s argument", utils.OntIdPublicKeyFlag.Name)
+	}
+	data, err := hex.DecodeString(pk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key:%s", pk)
+	}
+	if _, err := keypair.DeserializePublicKey(data); err != nil {
+		return nil, fmt.Errorf("invalid public key:%s", pk)
+	}
+	return data, nil
+}
+
+func ontIdAddKey(ctx *cli.Context) error {
+	return changeOntIdKey(ctx, "addKey", "Add key to ONT ID:%s")
+}
+
+func ontIdRemoveKey(ctx *cli.Context) error {
+	return changeOntIdKey(ctx, "removeKey", "Remove key from ONT ID:%s")
+}
+
+func changeOntIdKey(ctx *cli.Context, method, title string) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	pubKey, err := parseOntIdPublicKey(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	param := &utils.OntIdKeyParam{
+		OntId:    []byte(id),
+		PubKey:   pubKey,
+		Operator: keypair.SerializePublicKey(signer.PublicKey),
+	}
+	PrintInfoMsg(title, id)
+	PrintInfoMsg("  Public key:%x", pubKey)
+	return sendOntIdTx(ctx, signer, method, param)
+}
+
+func ontIdAddAttribute(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	key := ctx.String(utils.GetFlagName(utils.OntIdAttrKeyFlag))
+	if key == "" {
+		PrintErrorMsg("Missing %s argument.", utils.OntIdAttrKeyFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	attr := &utils.OntIdAttribute{
+		Key:   []byte(key),
+		Type:  []byte(ctx.String(utils.GetFlagName(utils.OntIdAttrTypeFlag))),
+		Value: []byte(ctx.String(utils.GetFlagName(utils.OntIdAttrValueFlag))),
+	}
+	param := &utils.AddAttributesParam{
+		OntId:      []byte(id),
+		Attributes: []*utils.OntIdAttribute{attr},
+		Operator:   keypair.SerializePublicKey(signer.PublicKey),
+	}
+	PrintInfoMsg("Add attribute to ONT ID:%s", id)
+	PrintInfoMsg("  Key:%s Type:%s Value:%s", attr.Key, attr.Type, attr.Value)
+	return sendOntIdTx(ctx, signer, "addAttributes", param)
+}
+
+func ontIdRemoveAttribute(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	key := ctx.String(utils.GetFlagName(utils.OntIdAttrKeyFlag))
+	if key == "" {
+		PrintErrorMsg("Missing %s argument.", utils.OntIdAttrKeyFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	param := &utils.RemoveAttributeParam{
+		OntId:    []byte(id),
+		Key:      []byte(key),
+		Operator: keypair.SerializePublicKey(signer.PublicKey),
+	}
+	PrintInfoMsg("Remove attribute from ONT ID:%s", id)
+	PrintInfoMsg("  Key:%s", key)
+	return sendOntIdTx(ctx, signer, "removeAttribute", param)
+}
+
+func ontIdAddService(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	serviceId := ctx.String(utils.GetFlagName(utils.OntIdServiceIdFlag))
+	serviceType := ctx.String(utils.GetFlagName(utils.OntIdServiceTypeFlag))
+	endpoint := ctx.String(utils.GetFlagName(utils.OntIdServiceEndpointFlag))
+	if serviceId == "" || serviceType == "" || endpoint == "" {
+		PrintErrorMsg("Missing %s, %s or %s argument.", utils.OntIdServiceIdFlag.Name, utils.OntIdServiceTypeFlag.Name,
+			utils.OntIdServiceEndpointFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	param := &ontid.ServiceParam{
+		OntId:          []byte(id),
+		ServiceId:      []byte(serviceId),
+		Type:           []byte(serviceType),
+		ServiceEndpint: []byte(endpoint),
+		Index:          uint32(ctx.Uint(utils.GetFlagName(utils.OntIdKeyIndexFlag))),
+	}
+	PrintInfoMsg("Add service to ONT ID:%s", id)
+	PrintInfoMsg("  Id:%s Type:%s Endpoint:%s", serviceId, serviceType, endpoint)
+	return sendOntIdTx(ctx, signer, "addService", param)
+}
+
+func ontIdRemoveService(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	serviceId := ctx.String(utils.GetFlagName(utils.OntIdServiceIdFlag))
+	if serviceId == "" {
+		PrintErrorMsg("Missing %s argument.", utils.OntIdServiceIdFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	param := &ontid.ServiceRemoveParam{
+		OntId:     []byte(id),
+		ServiceId: []byte(serviceId),
+		Index:     uint32(ctx.Uint(utils.GetFlagName(utils.OntIdKeyIndexFlag))),
+	}
+	PrintInfoMsg("Remove service from ONT ID:%s", id)
+	PrintInfoMsg("  Id:%s", serviceId)
+	return sendOntIdTx(ctx, signer, "removeService", param)
+}
+
+func ontIdSetRecovery(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	members := make([]string, 0)
+	recovery := strings.Trim(ctx.String(utils.GetFlagName(utils.OntIdRecoveryFlag)), ",")
+	for _, member := range strings.Split(recovery, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		if !account.VerifyID(member) {
+			PrintErrorMsg("Invalid recovery member:%s.", member)
+			cli.ShowSubcommandHelp(ctx)
+			return nil
+		}
+		members = append(members, member)
+	}
+	threshold := ctx.Uint(utils.GetFlagName(utils.OntIdThresholdFlag))
+	group, err := utils.SerializeOntIdGroup(members, threshold)
+	if err != nil {
+		PrintErrorMsg("Invalid recovery: %s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	param := &utils.SetRecoveryParam{
+		OntId:    []byte(id),
+		Recovery: group,
+		Index:    uint32(ctx.Uint(utils.GetFlagName(utils.OntIdKeyIndexFlag))),
+	}
+	PrintInfoMsg("Set recovery of ONT ID:%s", id)
+	PrintInfoMsg("  Members:%s", strings.Join(members, ","))
+	PrintInfoMsg("  Threshold:%d", threshold)
+	return sendOntIdTx(ctx, signer, "setRecovery", param)
+}
+
+func ontIdRemoveController(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := getOntIdSigner(ctx, id)
+	if err != nil {
+		return err
+	}
+	param := &utils.OntIdIndexParam{
+		OntId: []byte(id),
+		Index: uint32(ctx.Uint(utils.GetFlagName(utils.OntIdKeyIndexFlag))),
+	}
+	PrintInfoMsg("Remove controller of ONT ID:%s", id)
+	return sendOntIdTx(ctx, signer, "removeController", param)
+}
+
+func ontIdResolve(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	id, err := getOntIdArg(ctx)
+	if err != nil {
+		PrintErrorMsg("%s.", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	doc, err := utils.GetOntIdDocument(id)
+	if err != nil {
+		return err
+	}
+	if len(doc) == 0 {
+		return fmt.Errorf("ONT ID %s is not registered", id)
+	}
+	var out bytes.Buffer
+	err = json.Indent(&out, doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.Indent error:%s", err)
+	}
+	PrintInfoMsg(out.String())
+	return nil
+}
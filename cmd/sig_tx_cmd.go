@@ -56,6 +56,12 @@ var MultiSigTxCommand = cli.Command{
 		utils.AccountAddressFlag,
 		utils.SendTxFlag,
 		utils.PrepareExecTransactionFlag,
+		utils.RemoteSignerFlag,
+		utils.RemoteSignerKeyFlag,
+		utils.RemoteSignerTokenFlag,
+		utils.RemoteSignerCAFlag,
+		utils.RemoteSignerCertFlag,
+		utils.RemoteSignerCertKeyFlag,
 	},
 }
 
@@ -71,6 +77,12 @@ var SigTxCommand = cli.Command{
 		utils.AccountAddressFlag,
 		utils.SendTxFlag,
 		utils.PrepareExecTransactionFlag,
+		utils.RemoteSignerFlag,
+		utils.RemoteSignerKeyFlag,
+		utils.RemoteSignerTokenFlag,
+		utils.RemoteSignerCAFlag,
+		utils.RemoteSignerCertFlag,
+		utils.RemoteSignerCertKeyFlag,
 	},
 }
 
@@ -182,11 +194,11 @@ func multiSigToTx(ctx *cli.Context) error {
 		return fmt.Errorf("IntoMutable error:%s", err)
 	}
 
-	acc, err := cmdcom.GetAccount(ctx)
+	signer, err := cmdcom.GetSigner(ctx)
 	if err != nil {
-		return fmt.Errorf("GetAccount error:%s", err)
+		return fmt.Errorf("GetSigner error:%s", err)
 	}
-	err = utils.MultiSigTransaction(mutTx, uint16(m), pubKeys, acc)
+	err = utils.MultiSigTransaction(mutTx, uint16(m), pubKeys, signer)
 	if err != nil {
 		return fmt.Errorf("MultiSigTransaction error:%s", err)
 	}
@@ -252,12 +264,12 @@ func sigToTx(ctx *cli.Context) error {
 		return fmt.Errorf("IntoMutable error:%s", err)
 	}
 
-	acc, err := cmdcom.GetAccount(ctx)
+	signer, err := cmdcom.GetSigner(ctx)
 	if err != nil {
-		return fmt.Errorf("GetAccount error:%s", err)
+		return fmt.Errorf("GetSigner error:%s", err)
 	}
 
-	err = utils.SignTransaction(acc, mutTx)
+	err = utils.SignTransaction(signer, mutTx)
 	if err != nil {
 		return fmt.Errorf("SignTransaction error:%s", err)
 	}
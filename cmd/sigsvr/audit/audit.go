@@ -0,0 +1,178 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package audit implements the append-only audit log of sig server. Each entry contains the hash of
+// previous entry, so that any modification or deletion of entries breaks the hash chain.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	DECISION_ALLOW = "allow"
+	DECISION_DENY  = "deny"
+	DECISION_FAIL  = "fail"
+
+	GENESIS_HASH = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+type Transfer struct {
+	Asset  string `json:"asset"`
+	To     string `json:"to"`
+	Amount string `json:"amount"` //in 18 decimals
+}
+
+type Entry struct {
+	Seq            uint64      `json:"seq"`
+	Time           int64       `json:"time"`
+	Remote         string      `json:"remote"`
+	Client         string      `json:"client,omitempty"`
+	Qid            string      `json:"qid"`
+	Method         string      `json:"method"`
+	Account        string      `json:"account,omitempty"`
+	Decision       string      `json:"decision"`
+	ErrorCode      int         `json:"error_code"`
+	Reason         string      `json:"reason,omitempty"`
+	TxHash         string      `json:"tx_hash,omitempty"`
+	Contract       string      `json:"contract,omitempty"`
+	ContractMethod string      `json:"contract_method,omitempty"`
+	Transfers      []*Transfer `json:"transfers,omitempty"`
+	PrevHash       string      `json:"prev_hash"`
+	Hash           string      `json:"hash"`
+}
+
+// ComputeHash returns sha256 of entry without hash field in hex
+func (this *Entry) ComputeHash() string {
+	e := *this
+	e.Hash = ""
+	data, _ := json.Marshal(&e)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+type Log struct {
+	file     *os.File
+	seq      uint64
+	lastHash string
+	lock     sync.Mutex
+}
+
+// Open opens audit log file for appending, and verifies existing entries
+func Open(path string) (*Log, []*Entry, error) {
+	entries, err := ReadEntries(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open audit log:%s error:%s", path, err)
+	}
+	l := &Log{file: file, lastHash: GENESIS_HASH}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		l.seq = last.Seq
+		l.lastHash = last.Hash
+	}
+	return l, entries, nil
+}
+
+// Append sets the sequence and hashes of entry, and writes it to log file
+func (this *Log) Append(entry *Entry) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	entry.Seq = this.seq + 1
+	entry.PrevHash = this.lastHash
+	entry.Hash = entry.ComputeHash()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = this.file.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("write audit log error:%s", err)
+	}
+	err = this.file.Sync()
+	if err != nil {
+		return fmt.Errorf("sync audit log error:%s", err)
+	}
+	this.seq = entry.Seq
+	this.lastHash = entry.Hash
+	return nil
+}
+
+// Head returns the sequence and hash of the last entry, which can be recorded elsewhere to detect truncation of log
+func (this *Log) Head() (uint64, string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.seq, this.lastHash
+}
+
+func (this *Log) Close() error {
+	return this.file.Close()
+}
+
+// ReadEntries reads all entries of audit log file, and verifies the hash chain
+func ReadEntries(path string) ([]*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open audit log:%s error:%s", path, err)
+	}
+	defer file.Close()
+
+	var entries []*Entry
+	prevHash := GENESIS_HASH
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		entry := &Entry{}
+		err = json.Unmarshal([]byte(text), entry)
+		if err != nil {
+			return nil, fmt.Errorf("audit log line:%d invalid:%s", line, err)
+		}
+		if entry.Seq != uint64(len(entries))+1 {
+			return nil, fmt.Errorf("audit log line:%d seq:%d mismatch, expect:%d", line, entry.Seq, len(entries)+1)
+		}
+		if entry.PrevHash != prevHash {
+			return nil, fmt.Errorf("audit log seq:%d prev hash mismatch", entry.Seq)
+		}
+		if entry.Hash != entry.ComputeHash() {
+			return nil, fmt.Errorf("audit log seq:%d hash mismatch", entry.Seq)
+		}
+		prevHash = entry.Hash
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log:%s error:%s", path, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	log, entries, err := Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(entries))
+	assert.Nil(t, log.Append(&Entry{Method: "sigtransfertx", Decision: DECISION_ALLOW}))
+	assert.Nil(t, log.Append(&Entry{Method: "sigdata", Decision: DECISION_DENY, Reason: "denied"}))
+	seq, hash := log.Head()
+	assert.Nil(t, log.Close())
+
+	log, entries, err = Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, GENESIS_HASH, entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.Equal(t, uint64(2), seq)
+	assert.Equal(t, hash, entries[1].Hash)
+	assert.Nil(t, log.Append(&Entry{Method: "sigrawtx", Decision: DECISION_FAIL}))
+	assert.Nil(t, log.Close())
+	entries, err = ReadEntries(path)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), entries[2].Seq)
+	assert.Equal(t, hash, entries[2].PrevHash)
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	tampered := strings.Replace(string(data), DECISION_DENY, DECISION_ALLOW, 1)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(tampered), 0600))
+	_, err = ReadEntries(path)
+	assert.NotNil(t, err)
+
+	lines := strings.SplitAfter(string(data), "\n")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(lines[0]+lines[2]), 0600))
+	_, _, err = Open(path)
+	assert.NotNil(t, err)
+}
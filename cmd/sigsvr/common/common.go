@@ -24,10 +24,15 @@ import (
 
 	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/cmd/sigsvr/store"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
 )
 
 var DefWalletStore *store.WalletStore
 
+// DefRemoteSigner is the signer of the key in remote signing daemon, nil if not configured
+var DefRemoteSigner signature.DataSigner
+
 type CliRpcRequest struct {
 	Qid     string          `json:"qid"`
 	Params  json.RawMessage `json:"params"`
@@ -57,6 +62,17 @@ func (this *CliRpcRequest) GetAccount() (*account.Account, error) {
 	return acc, nil
 }
 
+// GetSigner returns the remote signer if the request account is its address, otherwise the account in wallet
+func (this *CliRpcRequest) GetSigner() (signature.DataSigner, error) {
+	if DefRemoteSigner != nil {
+		address := types.AddressFromPubKey(DefRemoteSigner.PubKey())
+		if this.Account == address.ToBase58() {
+			return DefRemoteSigner, nil
+		}
+	}
+	return this.GetAccount()
+}
+
 type CliRpcResponse struct {
 	Qid       string      `json:"qid"`
 	Method    string      `json:"method"`
@@ -29,6 +29,9 @@ const (
 	CLIERR_ABI_NOT_FOUND       = 1007
 	CLIERR_ABI_UNMATCH         = 1008
 	CLIERR_DUPLICATE_SIG       = 1009
+	CLIERR_INVALID_CREDENTIAL  = 1010
+	CLIERR_UNAUTHORIZED        = 1011
+	CLIERR_POLICY_DENIED       = 1012
 	CLIERR_INTERNAL_ERR        = 900
 )
 
@@ -42,6 +45,9 @@ var RPCErrorDesc = map[int]string{
 	CLIERR_ABI_NOT_FOUND:       "abi not found",
 	CLIERR_ABI_UNMATCH:         "abi unmatch",
 	CLIERR_DUPLICATE_SIG:       "Duplicate sig",
+	CLIERR_INVALID_CREDENTIAL:  "invalid credential",
+	CLIERR_UNAUTHORIZED:        "unauthorized",
+	CLIERR_POLICY_DENIED:       "denied by policy",
 	CLIERR_INTERNAL_ERR:        "internal error",
 }
 
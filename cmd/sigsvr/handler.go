@@ -30,4 +30,24 @@ func init() {
 	DefCliRpcSvr.RegHandler("signeovminvoketx", handlers.SigNeoVMInvokeTx)
 	DefCliRpcSvr.RegHandler("signeovminvokeabitx", handlers.SigNeoVMInvokeAbiTx)
 	DefCliRpcSvr.RegHandler("signativeinvoketx", handlers.SigNativeInvokeTx)
+	DefCliRpcSvr.RegHandler("createontid", handlers.CreateOntId)
+	DefCliRpcSvr.RegHandler("sigregontidtx", handlers.SigRegOntIdTx)
+	DefCliRpcSvr.RegHandler("sigaddontidkeytx", handlers.SigAddOntIdKeyTx)
+	DefCliRpcSvr.RegHandler("sigremoveontidkeytx", handlers.SigRemoveOntIdKeyTx)
+	DefCliRpcSvr.RegHandler("sigaddontidattributestx", handlers.SigAddOntIdAttributesTx)
+	DefCliRpcSvr.RegHandler("sigremoveontidattributetx", handlers.SigRemoveOntIdAttributeTx)
+	DefCliRpcSvr.RegHandler("sigaddontidservicetx", handlers.SigAddOntIdServiceTx)
+	DefCliRpcSvr.RegHandler("sigremoveontidservicetx", handlers.SigRemoveOntIdServiceTx)
+	DefCliRpcSvr.RegHandler("sigsetontidrecoverytx", handlers.SigSetOntIdRecoveryTx)
+	DefCliRpcSvr.RegHandler("sigremoveontidcontrollertx", handlers.SigRemoveOntIdControllerTx)
+	DefCliRpcSvr.RegHandler("issuecredential", handlers.IssueCredential)
+	DefCliRpcSvr.RegHandler("createpresentation", handlers.CreatePresentation)
+	DefCliRpcSvr.RegHandler("verifycredential", handlers.VerifyCredential)
+	DefCliRpcSvr.RegHandler("verifypresentation", handlers.VerifyPresentation)
+	DefCliRpcSvr.RegHandler("sigcommitcredentialtx", handlers.SigCommitCredentialTx)
+	DefCliRpcSvr.RegHandler("sigrevokecredentialtx", handlers.SigRevokeCredentialTx)
+	DefCliRpcSvr.RegHandler("sigethtransfertx", handlers.SigEthTransferTx)
+	DefCliRpcSvr.RegHandler("sigethinvoketx", handlers.SigEthInvokeTx)
+	DefCliRpcSvr.RegHandler("sigethdeploytx", handlers.SigEthDeployTx)
+	DefCliRpcSvr.RegHandler("decodetx", handlers.DecodeTx)
 }
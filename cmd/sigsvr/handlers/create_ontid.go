@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	"github.com/qbyyf/ontology/common/log"
+)
+
+type CreateOntIdReq struct {
+	Label string `json:"label"`
+}
+
+type CreateOntIdRsp struct {
+	OntId     string `json:"ont_id"`
+	PublicKey string `json:"public_key"`
+}
+
+func CreateOntId(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	pwd := req.Pwd
+	if pwd == "" {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "pwd cannot empty"
+		return
+	}
+	rawReq := &CreateOntIdReq{}
+	if len(req.Params) > 0 {
+		err := json.Unmarshal(req.Params, rawReq)
+		if err != nil {
+			log.Infof("Cli Qid:%s CreateOntId json.Unmarshal CreateOntIdReq:%s error:%s", req.Qid, req.Params, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			return
+		}
+	}
+	identity, err := account.NewIdentity(rawReq.Label, keypair.PK_ECDSA, keypair.P256, []byte(pwd))
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = "create ONT ID failed"
+		log.Errorf("CreateOntId Qid:%s NewIdentity error:%s", req.Qid, err)
+		return
+	}
+	_, err = clisvrcom.DefWalletStore.AddIdentity(identity)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = "create ONT ID failed"
+		log.Errorf("CreateOntId Qid:%s AddIdentity error:%s", req.Qid, err)
+		return
+	}
+	resp.Result = &CreateOntIdRsp{
+		OntId:     identity.ID,
+		PublicKey: identity.Control[0].Public,
+	}
+	log.Infof("[CreateOntId]%s", identity.ID)
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"testing"
+
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+)
+
+func TestCreateOntId(t *testing.T) {
+	req := &clisvrcom.CliRpcRequest{
+		Qid:    "t",
+		Method: "createontid",
+		Pwd:    string(pwd),
+	}
+	resp := &clisvrcom.CliRpcResponse{}
+	CreateOntId(req, resp)
+	if resp.ErrorCode != 0 {
+		t.Errorf("CreateOntId failed. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	createRsp, ok := resp.Result.(*CreateOntIdRsp)
+	if !ok {
+		t.Errorf("CreateOntId resp asset to CreateOntIdRsp failed")
+		return
+	}
+	identity, err := clisvrcom.DefWalletStore.GetIdentity(createRsp.OntId)
+	if err != nil || identity == nil {
+		t.Errorf("Test CreateOntId failed GetIdentity error:%v", err)
+		return
+	}
+	_, err = identity.GetController("1").GetAccount(pwd)
+	if err != nil {
+		t.Errorf("Test CreateOntId failed unlock ONT ID error:%s", err)
+		return
+	}
+}
@@ -0,0 +1,345 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/account/credential"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/log"
+)
+
+const (
+	CREDENTIAL_FORMAT_JSON = "json"
+	CREDENTIAL_FORMAT_JWT  = "jwt"
+)
+
+// CredentialSignerReq is the common part of credential requests. The credential is signed
+// by key of the ONT ID at KeyIndex, which is unlocked by OntIdPwd, in proof Format.
+type CredentialSignerReq struct {
+	OntId    string `json:"ont_id"`
+	KeyIndex uint32 `json:"key_index"`
+	OntIdPwd string `json:"ont_id_pwd"`
+	Format   string `json:"format"`
+}
+
+type IssueCredentialReq struct {
+	CredentialSignerReq
+	Id                string          `json:"id"`
+	Context           []string        `json:"context"`
+	Type              []string        `json:"type"`
+	CredentialSubject json.RawMessage `json:"credential_subject"`
+	ExpirationDate    string          `json:"expiration_date"`
+	Registry          string          `json:"registry"`
+}
+
+type IssueCredentialRsp struct {
+	Credential json.RawMessage `json:"credential"`
+}
+
+type CreatePresentationReq struct {
+	CredentialSignerReq
+	Id          string            `json:"id"`
+	Credentials []json.RawMessage `json:"credentials"`
+	Challenge   string            `json:"challenge"`
+	Domain      string            `json:"domain"`
+}
+
+type CreatePresentationRsp struct {
+	Presentation json.RawMessage `json:"presentation"`
+}
+
+type VerifyCredentialReq struct {
+	Credential json.RawMessage `json:"credential"`
+}
+
+type VerifyCredentialRsp struct {
+	Credential *credential.Credential `json:"credential"`
+}
+
+type VerifyPresentationReq struct {
+	Presentation json.RawMessage `json:"presentation"`
+	Challenge    string          `json:"challenge"`
+	Domain       string          `json:"domain"`
+}
+
+type VerifyPresentationRsp struct {
+	Presentation *credential.Presentation `json:"presentation"`
+}
+
+type SigCredentialRecordTxReq struct {
+	SigOntIdTxReq
+	Registry     string `json:"registry"`
+	CredentialId string `json:"credential_id"`
+	Holder       string `json:"holder"`
+}
+
+func IssueCredential(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &IssueCredentialReq{}
+	if !parseCredentialReq(req, resp, rawReq, &rawReq.CredentialSignerReq) {
+		return
+	}
+	if len(rawReq.CredentialSubject) == 0 {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "credential_subject cannot empty"
+		return
+	}
+	var expiration time.Time
+	if rawReq.ExpirationDate != "" {
+		var err error
+		expiration, err = time.Parse(time.RFC3339, rawReq.ExpirationDate)
+		if err != nil {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = "invalid expiration_date"
+			return
+		}
+	}
+	cred := credential.NewCredential(rawReq.Id, rawReq.OntId, rawReq.Context, rawReq.Type,
+		rawReq.CredentialSubject, expiration)
+	if rawReq.Registry != "" {
+		contract, err := common.AddressFromHexString(rawReq.Registry)
+		if err != nil {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = "invalid registry"
+			return
+		}
+		if rawReq.Id == "" {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = "id cannot empty with registry"
+			return
+		}
+		cred.SetRegistry(contract)
+	}
+	signer := getCredentialSigner(req, resp, &rawReq.CredentialSignerReq)
+	if signer == nil {
+		return
+	}
+	var data []byte
+	var err error
+	if rawReq.Format == CREDENTIAL_FORMAT_JWT {
+		var token string
+		token, err = cred.SignJWT(signer)
+		if err == nil {
+			data, err = json.Marshal(token)
+		}
+	} else {
+		err = cred.Sign(signer)
+		if err == nil {
+			data, err = json.Marshal(cred)
+		}
+	}
+	if err != nil {
+		log.Infof("Cli Qid:%s IssueCredential sign error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	resp.Result = &IssueCredentialRsp{Credential: data}
+}
+
+func CreatePresentation(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &CreatePresentationReq{}
+	if !parseCredentialReq(req, resp, rawReq, &rawReq.CredentialSignerReq) {
+		return
+	}
+	if len(rawReq.Credentials) == 0 {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "credentials cannot empty"
+		return
+	}
+	signer := getCredentialSigner(req, resp, &rawReq.CredentialSignerReq)
+	if signer == nil {
+		return
+	}
+	pres := credential.NewPresentation(rawReq.Id, rawReq.OntId, rawReq.Credentials)
+	var data []byte
+	var err error
+	if rawReq.Format == CREDENTIAL_FORMAT_JWT {
+		var token string
+		token, err = pres.SignJWT(signer, rawReq.Domain, rawReq.Challenge)
+		if err == nil {
+			data, err = json.Marshal(token)
+		}
+	} else {
+		err = pres.Sign(signer, rawReq.Challenge, rawReq.Domain)
+		if err == nil {
+			data, err = json.Marshal(pres)
+		}
+	}
+	if err != nil {
+		log.Infof("Cli Qid:%s CreatePresentation sign error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	resp.Result = &CreatePresentationRsp{Presentation: data}
+}
+
+func VerifyCredential(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &VerifyCredentialReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil || len(rawReq.Credential) == 0 {
+		log.Infof("Cli Qid:%s VerifyCredential json.Unmarshal VerifyCredentialReq:%s error:%s", req.Qid, req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	cred, err := credential.VerifyRawCredential(&cliutil.RpcCredentialResolver{}, rawReq.Credential, time.Now())
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_CREDENTIAL
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	resp.Result = &VerifyCredentialRsp{Credential: cred}
+}
+
+func VerifyPresentation(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &VerifyPresentationReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil || len(rawReq.Presentation) == 0 {
+		log.Infof("Cli Qid:%s VerifyPresentation json.Unmarshal VerifyPresentationReq:%s error:%s", req.Qid, req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	resolver := &cliutil.RpcCredentialResolver{}
+	var pres *credential.Presentation
+	var token string
+	if json.Unmarshal(rawReq.Presentation, &token) == nil {
+		pres, err = credential.VerifyPresentationJWT(resolver, token, rawReq.Domain, rawReq.Challenge, time.Now())
+	} else {
+		pres = new(credential.Presentation)
+		err = json.Unmarshal(rawReq.Presentation, pres)
+		if err == nil {
+			err = pres.Verify(resolver, rawReq.Challenge, rawReq.Domain, time.Now())
+		}
+	}
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_CREDENTIAL
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	resp.Result = &VerifyPresentationRsp{Presentation: pres}
+}
+
+func SigCommitCredentialTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigCredentialRecordTxReq{}
+	contract, ok := parseCredentialRecordTxReq(req, resp, rawReq)
+	if !ok {
+		return
+	}
+	if !account.VerifyID(rawReq.Holder) {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "invalid holder"
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	tx, err := cliutil.CommitCredentialTx(rawReq.GasPrice, rawReq.GasLimit, contract, rawReq.CredentialId,
+		rawReq.OntId, rawReq.KeyIndex, rawReq.Holder)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	sigTxWithOntId(req, resp, signer, tx)
+}
+
+func SigRevokeCredentialTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigCredentialRecordTxReq{}
+	contract, ok := parseCredentialRecordTxReq(req, resp, rawReq)
+	if !ok {
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	tx, err := cliutil.RevokeCredentialTx(rawReq.GasPrice, rawReq.GasLimit, contract, rawReq.CredentialId,
+		rawReq.OntId, rawReq.KeyIndex)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	sigTxWithOntId(req, resp, signer, tx)
+}
+
+// parseCredentialReq unmarshals params of request to rawReq, and checks the common part of it
+func parseCredentialReq(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, rawReq interface{},
+	base *CredentialSignerReq) bool {
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		log.Infof("Cli Qid:%s %s json.Unmarshal:%s error:%s", req.Qid, req.Method, req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return false
+	}
+	if !account.VerifyID(base.OntId) {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "invalid ont_id"
+		return false
+	}
+	if base.KeyIndex == 0 {
+		base.KeyIndex = 1
+	}
+	switch base.Format {
+	case "":
+		base.Format = CREDENTIAL_FORMAT_JSON
+	case CREDENTIAL_FORMAT_JSON, CREDENTIAL_FORMAT_JWT:
+	default:
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = fmt.Sprintf("invalid format %s", base.Format)
+		return false
+	}
+	return true
+}
+
+func getCredentialSigner(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse,
+	base *CredentialSignerReq) *credential.Signer {
+	acc := getOntIdSigner(req, resp, base.OntId, base.KeyIndex, base.OntIdPwd)
+	if acc == nil {
+		return nil
+	}
+	return &credential.Signer{OntId: base.OntId, KeyIndex: base.KeyIndex, Account: acc}
+}
+
+func parseCredentialRecordTxReq(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse,
+	rawReq *SigCredentialRecordTxReq) (common.Address, bool) {
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return common.ADDRESS_EMPTY, false
+	}
+	contract, err := common.AddressFromHexString(rawReq.Registry)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "invalid registry"
+		return common.ADDRESS_EMPTY, false
+	}
+	if rawReq.CredentialId == "" {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "credential_id cannot empty"
+		return common.ADDRESS_EMPTY, false
+	}
+	return contract, true
+}
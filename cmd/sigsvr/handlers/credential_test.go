@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/account/credential"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	"github.com/qbyyf/ontology/common"
+)
+
+type testCredentialResolver struct {
+	ontId string
+	pub   keypair.PublicKey
+}
+
+func (this *testCredentialResolver) GetPublicKey(ontId string, keyIndex uint32) (keypair.PublicKey, error) {
+	if ontId != this.ontId || keyIndex != 1 {
+		return nil, fmt.Errorf("key not exist")
+	}
+	return this.pub, nil
+}
+
+func (this *testCredentialResolver) GetStatus(contract common.Address, id string) (credential.Status, error) {
+	return credential.STATUS_VALID, nil
+}
+
+func TestIssueCredential(t *testing.T) {
+	ontIdPwd := "ontidpwd"
+	identity, err := account.NewIdentity("", keypair.PK_ECDSA, keypair.P256, []byte(ontIdPwd))
+	if err != nil {
+		t.Errorf("NewIdentity error:%s", err)
+		return
+	}
+	_, err = clisvrcom.DefWalletStore.AddIdentity(identity)
+	if err != nil {
+		t.Errorf("AddIdentity error:%s", err)
+		return
+	}
+	acc, err := identity.GetController("1").GetAccount([]byte(ontIdPwd))
+	if err != nil {
+		t.Errorf("GetAccount error:%s", err)
+		return
+	}
+	resolver := &testCredentialResolver{ontId: identity.ID, pub: acc.PublicKey}
+
+	for _, format := range []string{CREDENTIAL_FORMAT_JSON, CREDENTIAL_FORMAT_JWT} {
+		rawReq := &IssueCredentialReq{
+			CredentialSignerReq: CredentialSignerReq{OntId: identity.ID, OntIdPwd: ontIdPwd, Format: format},
+			Id:                  "urn:uuid:1",
+			Type:                []string{"DegreeCredential"},
+			CredentialSubject:   json.RawMessage(`{"id":"did:ont:holder","degree":"bachelor"}`),
+			ExpirationDate:      time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			Registry:            common.ADDRESS_EMPTY.ToHexString(),
+		}
+		data, _ := json.Marshal(rawReq)
+		req := &clisvrcom.CliRpcRequest{Qid: "t", Method: "issuecredential", Params: data}
+		resp := &clisvrcom.CliRpcResponse{}
+		IssueCredential(req, resp)
+		if resp.ErrorCode != 0 {
+			t.Errorf("IssueCredential %s failed. ErrorCode:%d ErrorInfo:%s", format, resp.ErrorCode, resp.ErrorInfo)
+			return
+		}
+		cred := resp.Result.(*IssueCredentialRsp).Credential
+		_, err = credential.VerifyRawCredential(resolver, cred, time.Now())
+		if err != nil {
+			t.Errorf("verify %s credential error:%s", format, err)
+			return
+		}
+
+		data, _ = json.Marshal(&CreatePresentationReq{
+			CredentialSignerReq: CredentialSignerReq{OntId: identity.ID, OntIdPwd: ontIdPwd, Format: format},
+			Credentials:         []json.RawMessage{cred},
+			Challenge:           "challenge",
+		})
+		req = &clisvrcom.CliRpcRequest{Qid: "t", Method: "createpresentation", Params: data}
+		resp = &clisvrcom.CliRpcResponse{}
+		CreatePresentation(req, resp)
+		if resp.ErrorCode != 0 {
+			t.Errorf("CreatePresentation %s failed. ErrorCode:%d ErrorInfo:%s", format, resp.ErrorCode, resp.ErrorInfo)
+			return
+		}
+	}
+
+	rawReq := &IssueCredentialReq{
+		CredentialSignerReq: CredentialSignerReq{OntId: identity.ID, OntIdPwd: "wrong"},
+		CredentialSubject:   json.RawMessage(`{}`),
+	}
+	data, _ := json.Marshal(rawReq)
+	resp := &clisvrcom.CliRpcResponse{}
+	IssueCredential(&clisvrcom.CliRpcRequest{Qid: "t", Method: "issuecredential", Params: data}, resp)
+	if resp.ErrorCode != clisvrcom.CLIERR_ACCOUNT_UNLOCK {
+		t.Errorf("IssueCredential with wrong password should fail, ErrorCode:%d", resp.ErrorCode)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common/log"
+)
+
+type DecodeTxReq struct {
+	RawTx    string          `json:"raw_tx"`
+	NeovmAbi json.RawMessage `json:"neovm_abi"`
+	EthAbi   json.RawMessage `json:"eth_abi"`
+}
+
+// DecodeTx decodes the contract call of ontology or EIP155 raw transaction, without signing
+func DecodeTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &DecodeTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		log.Infof("DecodeTx json.Unmarshal DecodeTxReq:%s error:%s", req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	abis, err := cliutil.NewTxDecodeAbi(rawReq.NeovmAbi, rawReq.EthAbi)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_ABI_UNMATCH
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	txInfo, err := cliutil.DecodeRawTransaction(rawReq.RawTx, abis)
+	if err != nil {
+		log.Infof("Cli Qid:%s DecodeTx DecodeRawTransaction error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_TX
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	resp.Result = txInfo
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/cmd/abi"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+)
+
+func TestDecodeTx(t *testing.T) {
+	acc := account.NewAccount("")
+	mutable, err := utils.TransferTx(0, 20000, "ont", acc.Address.ToBase58(), acc.Address.ToBase58(), 10)
+	if err != nil {
+		t.Errorf("TransferTx error:%s", err)
+		return
+	}
+	mutable.Payer = acc.Address
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		t.Errorf("IntoImmutable error:%s", err)
+		return
+	}
+	data, _ := json.Marshal(&DecodeTxReq{RawTx: hex.EncodeToString(common.SerializeToBytes(tx))})
+	req := &clisvrcom.CliRpcRequest{
+		Qid:    "t",
+		Method: "decodetx",
+		Params: data,
+	}
+	abi.DefAbiMgr.Init("../../abi/native_abi_script")
+	resp := &clisvrcom.CliRpcResponse{}
+	DecodeTx(req, resp)
+	if resp.ErrorCode != 0 {
+		t.Errorf("DecodeTx failed. ErrorCode:%d ErrorInfo:%s", resp.ErrorCode, resp.ErrorInfo)
+		return
+	}
+	txInfo := resp.Result.(*utils.TxInfo)
+	hash := tx.Hash()
+	if txInfo.TxHash != hash.ToHexString() || txInfo.Invoke == nil || txInfo.Invoke.Method != "transfer" || !txInfo.Invoke.ByAbi {
+		t.Errorf("unexpected decoded tx:%+v", txInfo)
+		return
+	}
+
+	data, _ = json.Marshal(&DecodeTxReq{RawTx: "00d1"})
+	req.Params = data
+	resp = &clisvrcom.CliRpcResponse{}
+	DecodeTx(req, resp)
+	if resp.ErrorCode != clisvrcom.CLIERR_INVALID_TX {
+		t.Errorf("DecodeTx of invalid tx should fail")
+	}
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common/log"
+)
+
+// EthTxReq is the common params of EIP155 transactions. Nonce and chain id are queried
+// from the node if they are not set, and gas limit is estimated by the node if it is 0
+type EthTxReq struct {
+	Nonce    *uint64 `json:"nonce"`
+	ChainId  uint64  `json:"chain_id"`
+	GasPrice uint64  `json:"gas_price"`
+	GasLimit uint64  `json:"gas_limit"`
+}
+
+type SigEthTransferTxReq struct {
+	EthTxReq
+	To     string `json:"to"`
+	Amount string `json:"amount"`
+}
+
+type SigEthInvokeTxReq struct {
+	EthTxReq
+	Contract    string          `json:"contract"`
+	ContractAbi json.RawMessage `json:"contract_abi"`
+	Method      string          `json:"method"`
+	Params      json.RawMessage `json:"params"`
+	Data        string          `json:"data"`
+	Amount      string          `json:"amount"`
+}
+
+type SigEthDeployTxReq struct {
+	EthTxReq
+	Code        string          `json:"code"`
+	ContractAbi json.RawMessage `json:"contract_abi"`
+	Params      json.RawMessage `json:"params"`
+	Amount      string          `json:"amount"`
+}
+
+type SigEthTxRsp struct {
+	SignedTx        string `json:"signed_tx"`
+	TxHash          string `json:"tx_hash"`
+	From            string `json:"from"`
+	Nonce           uint64 `json:"nonce"`
+	ContractAddress string `json:"contract_address,omitempty"`
+}
+
+func SigEthTransferTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigEthTransferTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		log.Infof("SigEthTransferTx json.Unmarshal SigEthTransferTxReq:%s error:%s", req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	to, err := cliutil.ParseEthAddress(rawReq.To)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	amount, err := cliutil.ParseEthAmount(rawReq.Amount)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	if rawReq.GasLimit == 0 {
+		rawReq.GasLimit = cliutil.ETH_TRANSFER_GAS
+	}
+	sigEthTx(req, resp, &rawReq.EthTxReq, &to, amount, nil, func(nonce, gasLimit uint64) *ethtypes.Transaction {
+		return cliutil.NewEthTransferTx(nonce, to, amount, rawReq.GasPrice, gasLimit)
+	})
+}
+
+func SigEthInvokeTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigEthInvokeTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		log.Infof("SigEthInvokeTx json.Unmarshal SigEthInvokeTxReq:%s error:%s", req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	contract, err := cliutil.ParseEthAddress(rawReq.Contract)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	var data []byte
+	if rawReq.Data != "" {
+		data, err = hex.DecodeString(strings.TrimPrefix(rawReq.Data, "0x"))
+		if err != nil {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = fmt.Sprintf("invalid data:%s", err)
+			return
+		}
+	} else {
+		if rawReq.Method == "" {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = "method or data is required"
+			return
+		}
+		data, err = cliutil.PackEthAbiCall(rawReq.ContractAbi, rawReq.Method, string(rawReq.Params))
+		if err != nil {
+			resp.ErrorCode = clisvrcom.CLIERR_ABI_UNMATCH
+			resp.ErrorInfo = err.Error()
+			return
+		}
+	}
+	amount, err := cliutil.ParseEthAmount(rawReq.Amount)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	sigEthTx(req, resp, &rawReq.EthTxReq, &contract, amount, data, func(nonce, gasLimit uint64) *ethtypes.Transaction {
+		return cliutil.NewEthInvokeTx(nonce, contract, amount, rawReq.GasPrice, gasLimit, data)
+	})
+}
+
+func SigEthDeployTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigEthDeployTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		log.Infof("SigEthDeployTx json.Unmarshal SigEthDeployTxReq:%s error:%s", req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(rawReq.Code, "0x"))
+	if err != nil || len(code) == 0 {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "code should be contract bytecode in hex"
+		return
+	}
+	if len(rawReq.ContractAbi) != 0 {
+		args, err := cliutil.PackEthAbiCall(rawReq.ContractAbi, "", string(rawReq.Params))
+		if err != nil {
+			resp.ErrorCode = clisvrcom.CLIERR_ABI_UNMATCH
+			resp.ErrorInfo = err.Error()
+			return
+		}
+		code = append(code, args...)
+	}
+	amount, err := cliutil.ParseEthAmount(rawReq.Amount)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	sigEthTx(req, resp, &rawReq.EthTxReq, nil, amount, code, func(nonce, gasLimit uint64) *ethtypes.Transaction {
+		return cliutil.NewEthDeployTx(nonce, amount, rawReq.GasPrice, gasLimit, code)
+	})
+}
+
+// sigEthTx fills the nonce, chain id and gas limit of the transaction from the node if necessary, and signs it
+func sigEthTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, ethReq *EthTxReq, to *ethcommon.Address,
+	amount *big.Int, data []byte, newTx func(nonce, gasLimit uint64) *ethtypes.Transaction) {
+	signer, err := req.GetAccount()
+	if err != nil {
+		log.Infof("Cli Qid:%s SigEthTx GetAccount:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return
+	}
+	from, err := cliutil.GetEthAddress(signer.PublicKey)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	var nonce uint64
+	if ethReq.Nonce != nil {
+		nonce = *ethReq.Nonce
+	} else if nonce, err = cliutil.GetEthNonce(from); err != nil {
+		log.Infof("Cli Qid:%s SigEthTx GetEthNonce error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = fmt.Sprintf("get nonce error:%s", err)
+		return
+	}
+	chainId := ethReq.ChainId
+	if chainId == 0 {
+		if chainId, err = cliutil.GetEthChainId(); err != nil {
+			log.Infof("Cli Qid:%s SigEthTx GetEthChainId error:%s", req.Qid, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+			resp.ErrorInfo = fmt.Sprintf("get chain id error:%s", err)
+			return
+		}
+	}
+	gasLimit := ethReq.GasLimit
+	if gasLimit == 0 {
+		if gasLimit, err = cliutil.EstimateEthGas(from, to, amount, data); err != nil {
+			log.Infof("Cli Qid:%s SigEthTx EstimateEthGas error:%s", req.Qid, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+			resp.ErrorInfo = fmt.Sprintf("estimate gas error:%s", err)
+			return
+		}
+	}
+	signedTx, err := cliutil.SignEthTransaction(signer, newTx(nonce, gasLimit), chainId)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigEthTx SignEthTransaction error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	rawTx, err := cliutil.EncodeEthTransaction(signedTx)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigEthTx EncodeEthTransaction error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	rsp := &SigEthTxRsp{
+		SignedTx: rawTx,
+		TxHash:   signedTx.Hash().Hex(),
+		From:     from.Hex(),
+		Nonce:    nonce,
+	}
+	if to == nil {
+		rsp.ContractAddress = crypto.CreateAddress(from, nonce).Hex()
+	}
+	resp.Result = rsp
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+)
+
+func TestSigEthTransferTx(t *testing.T) {
+	acc, err := clisvrcom.DefWalletStore.NewAccountData(keypair.PK_ECDSA, keypair.SECP256K1, signature.SHA256withECDSA, pwd)
+	if err != nil {
+		t.Errorf("wallet.NewAccount error:%s", err)
+		return
+	}
+	clisvrcom.DefWalletStore.AddAccountData(acc)
+	nonce := uint64(3)
+	sigReq := &SigEthTransferTxReq{
+		EthTxReq: EthTxReq{
+			Nonce:    &nonce,
+			ChainId:  5851,
+			GasPrice: 2500,
+		},
+		To:     "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4",
+		Amount: "1.5",
+	}
+	data, err := json.Marshal(sigReq)
+	if err != nil {
+		t.Errorf("json.Marshal SigEthTransferTxReq error:%s", err)
+		return
+	}
+	req := &clisvrcom.CliRpcRequest{
+		Qid:     "t",
+		Method:  "sigethtransfertx",
+		Params:  data,
+		Account: acc.Address,
+		Pwd:     string(pwd),
+	}
+	resp := &clisvrcom.CliRpcResponse{}
+	SigEthTransferTx(req, resp)
+	if resp.ErrorCode != 0 {
+		t.Errorf("SigEthTransferTx failed. ErrorCode:%d ErrorInfo:%s", resp.ErrorCode, resp.ErrorInfo)
+		return
+	}
+	rsp := resp.Result.(*SigEthTxRsp)
+	tx, err := cliutil.DecodeEthTransaction(rsp.SignedTx)
+	if err != nil {
+		t.Errorf("DecodeEthTransaction error:%s", err)
+		return
+	}
+	from, err := ethtypes.Sender(ethtypes.NewEIP155Signer(tx.ChainId()), tx)
+	if err != nil {
+		t.Errorf("recover sender error:%s", err)
+		return
+	}
+	if from.Hex() != rsp.From {
+		t.Errorf("sender %s != %s", from.Hex(), rsp.From)
+		return
+	}
+	if tx.Nonce() != nonce || tx.Gas() != cliutil.ETH_TRANSFER_GAS || tx.Hash().Hex() != rsp.TxHash {
+		t.Errorf("unexpected tx nonce:%d gas:%d hash:%s", tx.Nonce(), tx.Gas(), tx.Hash().Hex())
+		return
+	}
+	if cliutil.FormatEthAmount(tx.Value()) != "1.5" {
+		t.Errorf("unexpected tx value:%s", tx.Value())
+	}
+}
+
+func TestSigEthTxWrongCurve(t *testing.T) {
+	defAcc, err := testWallet.GetDefaultAccount(pwd)
+	if err != nil {
+		t.Errorf("GetDefaultAccount error:%s", err)
+		return
+	}
+	nonce := uint64(0)
+	data, _ := json.Marshal(&SigEthTransferTxReq{
+		EthTxReq: EthTxReq{Nonce: &nonce, ChainId: 5851, GasPrice: 2500},
+		To:       "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4",
+		Amount:   "1",
+	})
+	req := &clisvrcom.CliRpcRequest{
+		Qid:     "t",
+		Method:  "sigethtransfertx",
+		Params:  data,
+		Account: defAcc.Address.ToBase58(),
+		Pwd:     string(pwd),
+	}
+	resp := &clisvrcom.CliRpcResponse{}
+	SigEthTransferTx(req, resp)
+	if resp.ErrorCode == 0 {
+		t.Errorf("SigEthTransferTx with P256 account should fail")
+	}
+}
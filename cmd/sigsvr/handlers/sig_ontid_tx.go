@@ -0,0 +1,400 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ontid"
+)
+
+// SigOntIdTxReq is the common part of ONT ID transaction requests. The transaction is signed by
+// key of the ONT ID at KeyIndex, which is unlocked by OntIdPwd, and paid by the account of request.
+type SigOntIdTxReq struct {
+	GasPrice uint64 `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
+	OntId    string `json:"ont_id"`
+	KeyIndex uint32 `json:"key_index"`
+	OntIdPwd string `json:"ont_id_pwd"`
+}
+
+type SigRegOntIdTxReq struct {
+	SigOntIdTxReq
+	Controller string `json:"controller"`
+}
+
+type SigOntIdKeyTxReq struct {
+	SigOntIdTxReq
+	PublicKey string `json:"public_key"`
+}
+
+type OntIdAttribute struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type SigAddOntIdAttributesTxReq struct {
+	SigOntIdTxReq
+	Attributes []*OntIdAttribute `json:"attributes"`
+}
+
+type SigRemoveOntIdAttributeTxReq struct {
+	SigOntIdTxReq
+	Key string `json:"key"`
+}
+
+type SigOntIdServiceTxReq struct {
+	SigOntIdTxReq
+	ServiceId string `json:"service_id"`
+	Type      string `json:"type"`
+	Endpoint  string `json:"endpoint"`
+}
+
+type SigSetOntIdRecoveryTxReq struct {
+	SigOntIdTxReq
+	Members   []string `json:"members"`
+	Threshold uint     `json:"threshold"`
+}
+
+type SigOntIdTxRsp struct {
+	SignedTx string `json:"signed_tx"`
+}
+
+func SigRegOntIdTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigRegOntIdTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return
+	}
+	if rawReq.Controller != "" {
+		if !account.VerifyID(rawReq.Controller) {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = "invalid controller"
+			return
+		}
+		signer := getOntIdSigner(req, resp, rawReq.Controller, rawReq.KeyIndex, rawReq.OntIdPwd)
+		if signer == nil {
+			return
+		}
+		param := &cliutil.RegIdWithControllerParam{
+			OntId:      []byte(rawReq.OntId),
+			Controller: []byte(rawReq.Controller),
+			Index:      rawReq.KeyIndex,
+		}
+		sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, "regIDWithController", param)
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &cliutil.RegIdWithPublicKeyParam{
+		OntId:  []byte(rawReq.OntId),
+		PubKey: keypair.SerializePublicKey(signer.PublicKey),
+	}
+	sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, "regIDWithPublicKey", param)
+}
+
+func SigAddOntIdKeyTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	sigOntIdKeyTx(req, resp, "addKey")
+}
+
+func SigRemoveOntIdKeyTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	sigOntIdKeyTx(req, resp, "removeKey")
+}
+
+func sigOntIdKeyTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, method string) {
+	rawReq := &SigOntIdKeyTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return
+	}
+	pubKey, err := hex.DecodeString(rawReq.PublicKey)
+	if err == nil {
+		_, err = keypair.DeserializePublicKey(pubKey)
+	}
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "invalid public_key"
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &cliutil.OntIdKeyParam{
+		OntId:    []byte(rawReq.OntId),
+		PubKey:   pubKey,
+		Operator: keypair.SerializePublicKey(signer.PublicKey),
+	}
+	sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, method, param)
+}
+
+func SigAddOntIdAttributesTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigAddOntIdAttributesTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return
+	}
+	if len(rawReq.Attributes) == 0 {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "attributes cannot empty"
+		return
+	}
+	attributes := make([]*cliutil.OntIdAttribute, 0, len(rawReq.Attributes))
+	for _, attr := range rawReq.Attributes {
+		if attr == nil || attr.Key == "" {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = "attribute key cannot empty"
+			return
+		}
+		attributes = append(attributes, &cliutil.OntIdAttribute{
+			Key:   []byte(attr.Key),
+			Type:  []byte(attr.Type),
+			Value: []byte(attr.Value),
+		})
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &cliutil.AddAttributesParam{
+		OntId:      []byte(rawReq.OntId),
+		Attributes: attributes,
+		Operator:   keypair.SerializePublicKey(signer.PublicKey),
+	}
+	sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, "addAttributes", param)
+}
+
+func SigRemoveOntIdAttributeTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigRemoveOntIdAttributeTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return
+	}
+	if rawReq.Key == "" {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "key cannot empty"
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &cliutil.RemoveAttributeParam{
+		OntId:    []byte(rawReq.OntId),
+		Key:      []byte(rawReq.Key),
+		Operator: keypair.SerializePublicKey(signer.PublicKey),
+	}
+	sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, "removeAttribute", param)
+}
+
+func SigAddOntIdServiceTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigOntIdServiceTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return
+	}
+	if rawReq.ServiceId == "" || rawReq.Type == "" || rawReq.Endpoint == "" {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "service_id, type and endpoint cannot empty"
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &ontid.ServiceParam{
+		OntId:          []byte(rawReq.OntId),
+		ServiceId:      []byte(rawReq.ServiceId),
+		Type:           []byte(rawReq.Type),
+		ServiceEndpint: []byte(rawReq.Endpoint),
+		Index:          rawReq.KeyIndex,
+	}
+	sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, "addService", param)
+}
+
+func SigRemoveOntIdServiceTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigOntIdServiceTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return
+	}
+	if rawReq.ServiceId == "" {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "service_id cannot empty"
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &ontid.ServiceRemoveParam{
+		OntId:     []byte(rawReq.OntId),
+		ServiceId: []byte(rawReq.ServiceId),
+		Index:     rawReq.KeyIndex,
+	}
+	sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, "removeService", param)
+}
+
+func SigSetOntIdRecoveryTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigSetOntIdRecoveryTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, &rawReq.SigOntIdTxReq) {
+		return
+	}
+	for _, member := range rawReq.Members {
+		if !account.VerifyID(member) {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = fmt.Sprintf("invalid member:%s", member)
+			return
+		}
+	}
+	group, err := cliutil.SerializeOntIdGroup(rawReq.Members, rawReq.Threshold)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &cliutil.SetRecoveryParam{
+		OntId:    []byte(rawReq.OntId),
+		Recovery: group,
+		Index:    rawReq.KeyIndex,
+	}
+	sigOntIdTx(req, resp, &rawReq.SigOntIdTxReq, signer, "setRecovery", param)
+}
+
+func SigRemoveOntIdControllerTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigOntIdTxReq{}
+	if !parseOntIdTxReq(req, resp, rawReq, rawReq) {
+		return
+	}
+	signer := getOntIdSigner(req, resp, rawReq.OntId, rawReq.KeyIndex, rawReq.OntIdPwd)
+	if signer == nil {
+		return
+	}
+	param := &cliutil.OntIdIndexParam{
+		OntId: []byte(rawReq.OntId),
+		Index: rawReq.KeyIndex,
+	}
+	sigOntIdTx(req, resp, rawReq, signer, "removeController", param)
+}
+
+// parseOntIdTxReq unmarshals params of request to rawReq, and checks the common part of it
+func parseOntIdTxReq(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, rawReq interface{}, base *SigOntIdTxReq) bool {
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		log.Infof("Cli Qid:%s %s json.Unmarshal:%s error:%s", req.Qid, req.Method, req.Params, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return false
+	}
+	if !account.VerifyID(base.OntId) {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "invalid ont_id"
+		return false
+	}
+	if base.KeyIndex == 0 {
+		base.KeyIndex = 1
+	}
+	return true
+}
+
+// getOntIdSigner return the account of key at keyIndex of ONT ID in wallet store, which is unlocked
+// by ontIdPwd or password of request if empty, nil if failed
+func getOntIdSigner(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, ontId string, keyIndex uint32,
+	ontIdPwd string) *account.Account {
+	identity, err := clisvrcom.DefWalletStore.GetIdentity(ontId)
+	if err != nil {
+		log.Infof("Cli Qid:%s %s GetIdentity:%s error:%s", req.Qid, req.Method, ontId, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return nil
+	}
+	if identity == nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = fmt.Sprintf("cannot find ONT ID:%s", ontId)
+		return nil
+	}
+	controller := identity.GetController(strconv.FormatUint(uint64(keyIndex), 10))
+	if controller == nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = fmt.Sprintf("cannot find key #%d of ONT ID:%s", keyIndex, ontId)
+		return nil
+	}
+	pwd := ontIdPwd
+	if pwd == "" {
+		pwd = req.Pwd
+	}
+	signer, err := controller.GetAccount([]byte(pwd))
+	if err != nil {
+		log.Infof("Cli Qid:%s %s unlock ONT ID:%s error:%s", req.Qid, req.Method, ontId, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return nil
+	}
+	return signer
+}
+
+// sigOntIdTx signs the transaction invoking method of ontid contract by the account of request,
+// who pays the gas, and the key of ONT ID
+func sigOntIdTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, base *SigOntIdTxReq,
+	signer *account.Account, method string, param interface{}) {
+	tx, err := cliutil.OntIdTx(base.GasPrice, base.GasLimit, method, param)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	sigTxWithOntId(req, resp, signer, tx)
+}
+
+// sigTxWithOntId signs tx by the account of request, who pays the gas, and then the key of ONT ID
+func sigTxWithOntId(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, signer *account.Account,
+	tx *types.MutableTransaction) {
+	payer, err := req.GetSigner()
+	if err != nil {
+		log.Infof("Cli Qid:%s %s GetSigner:%s", req.Qid, req.Method, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return
+	}
+	err = cliutil.SignTransaction(payer, tx)
+	if err == nil {
+		err = cliutil.SignTransaction(signer, tx)
+	}
+	if err != nil {
+		log.Infof("Cli Qid:%s %s SignTransaction error:%s", req.Qid, req.Method, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	immutable, err := tx.IntoImmutable()
+	if err != nil {
+		log.Infof("Cli Qid:%s %s IntoImmutable error:%s", req.Qid, req.Method, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	resp.Result = &SigOntIdTxRsp{
+		SignedTx: hex.EncodeToString(common.SerializeToBytes(immutable)),
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	clisvrcom "github.com/qbyyf/ontology/cmd/sigsvr/common"
+	"github.com/qbyyf/ontology/core/types"
+)
+
+func TestSigOntIdTx(t *testing.T) {
+	defAcc, err := testWallet.GetDefaultAccount(pwd)
+	if err != nil {
+		t.Errorf("GetDefaultAccount error:%s", err)
+		return
+	}
+	ontIdPwd := "ontidpwd"
+	identity, err := account.NewIdentity("", keypair.PK_ECDSA, keypair.P256, []byte(ontIdPwd))
+	if err != nil {
+		t.Errorf("NewIdentity error:%s", err)
+		return
+	}
+	_, err = clisvrcom.DefWalletStore.AddIdentity(identity)
+	if err != nil {
+		t.Errorf("AddIdentity error:%s", err)
+		return
+	}
+	base := SigOntIdTxReq{
+		GasPrice: 0,
+		GasLimit: 20000,
+		OntId:    identity.ID,
+		OntIdPwd: ontIdPwd,
+	}
+	tests := []struct {
+		method  string
+		handler func(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse)
+		rawReq  interface{}
+	}{
+		{"sigregontidtx", SigRegOntIdTx, &SigRegOntIdTxReq{SigOntIdTxReq: base}},
+		{"sigaddontidkeytx", SigAddOntIdKeyTx, &SigOntIdKeyTxReq{SigOntIdTxReq: base, PublicKey: identity.Control[0].Public}},
+		{"sigaddontidattributestx", SigAddOntIdAttributesTx, &SigAddOntIdAttributesTxReq{SigOntIdTxReq: base,
+			Attributes: []*OntIdAttribute{{Key: "name", Type: "string", Value: "alice"}}}},
+		{"sigaddontidservicetx", SigAddOntIdServiceTx, &SigOntIdServiceTxReq{SigOntIdTxReq: base,
+			ServiceId: "hub", Type: "IdentityHub", Endpoint: "https://hub.example.com"}},
+		{"sigsetontidrecoverytx", SigSetOntIdRecoveryTx, &SigSetOntIdRecoveryTxReq{SigOntIdTxReq: base,
+			Members: []string{identity.ID}, Threshold: 1}},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(test.rawReq)
+		if err != nil {
+			t.Errorf("json.Marshal %s error:%s", test.method, err)
+			return
+		}
+		req := &clisvrcom.CliRpcRequest{
+			Qid:     "t",
+			Method:  test.method,
+			Params:  data,
+			Account: defAcc.Address.ToBase58(),
+			Pwd:     string(pwd),
+		}
+		resp := &clisvrcom.CliRpcResponse{}
+		test.handler(req, resp)
+		if resp.ErrorCode != 0 {
+			t.Errorf("%s failed. ErrorCode:%d ErrorInfo:%s", test.method, resp.ErrorCode, resp.ErrorInfo)
+			return
+		}
+		rawTx, _ := hex.DecodeString(resp.Result.(*SigOntIdTxRsp).SignedTx)
+		tx, err := types.TransactionFromRawBytes(rawTx)
+		if err != nil {
+			t.Errorf("%s TransactionFromRawBytes error:%s", test.method, err)
+			return
+		}
+		if tx.Payer != defAcc.Address || len(tx.Sigs) != 2 {
+			t.Errorf("%s should be paid by account and signed by account and ONT ID", test.method)
+			return
+		}
+	}
+
+	data, _ := json.Marshal(&SigOntIdTxReq{OntId: identity.ID, OntIdPwd: "wrong"})
+	req := &clisvrcom.CliRpcRequest{
+		Qid:     "t",
+		Method:  "sigremoveontidcontrollertx",
+		Params:  data,
+		Account: defAcc.Address.ToBase58(),
+		Pwd:     string(pwd),
+	}
+	resp := &clisvrcom.CliRpcResponse{}
+	SigRemoveOntIdControllerTx(req, resp)
+	if resp.ErrorCode != clisvrcom.CLIERR_ACCOUNT_UNLOCK {
+		t.Errorf("SigRemoveOntIdControllerTx with wrong ont_id_pwd should fail to unlock")
+	}
+}
@@ -63,15 +63,15 @@ func SigRawTransaction(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcRespo
 		resp.ErrorCode = clisvrcom.CLIERR_INVALID_TX
 		return
 	}
-	signer, err := req.GetAccount()
+	signer, err := req.GetSigner()
 	if err != nil {
-		log.Infof("Cli Qid:%s SigRawTransaction GetAccount:%s", req.Qid, err)
+		log.Infof("Cli Qid:%s SigRawTransaction GetSigner:%s", req.Qid, err)
 		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
 		return
 	}
 	var emptyAddress = common.Address{}
 	if mutable.Payer == emptyAddress {
-		mutable.Payer = signer.Address
+		mutable.Payer = types.AddressFromPubKey(signer.PubKey())
 	}
 
 	txHash := mutable.Hash()
@@ -85,7 +85,7 @@ func SigRawTransaction(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcRespo
 		mutable.Sigs = make([]types.Sig, 0)
 	}
 	mutable.Sigs = append(mutable.Sigs, types.Sig{
-		PubKeys: []keypair.PublicKey{signer.PublicKey},
+		PubKeys: []keypair.PublicKey{signer.PubKey()},
 		M:       1,
 		SigData: [][]byte{sigData},
 	})
@@ -32,6 +32,8 @@ import (
 	"github.com/qbyyf/ontology/cmd/utils"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/log"
+	sig "github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -112,3 +114,45 @@ func TestSigRawTx(t *testing.T) {
 		return
 	}
 }
+
+// dataSigner signs without exposing the private key, like the remote signer
+type dataSigner struct {
+	acc *account.Account
+}
+
+func (this *dataSigner) PubKey() keypair.PublicKey         { return this.acc.PublicKey }
+func (this *dataSigner) Scheme() signature.SignatureScheme { return this.acc.SigScheme }
+func (this *dataSigner) Sign(data []byte) ([]byte, error)  { return this.acc.Sign(data) }
+
+func TestSigRawTxByRemoteSigner(t *testing.T) {
+	acc := account.NewAccount("")
+	clisvrcom.DefRemoteSigner = &dataSigner{acc: acc}
+	defer func() { clisvrcom.DefRemoteSigner = nil }()
+
+	mutable, err := utils.TransferTx(0, 0, "ont", acc.Address.ToBase58(), acc.Address.ToBase58(), 10)
+	assert.Nil(t, err)
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	data, err := json.Marshal(&SigRawTransactionReq{RawTx: hex.EncodeToString(common.SerializeToBytes(tx))})
+	assert.Nil(t, err)
+	//no password is needed for the account of remote signer
+	req := &clisvrcom.CliRpcRequest{
+		Qid:     "t",
+		Method:  "sigrawtx",
+		Params:  data,
+		Account: acc.Address.ToBase58(),
+	}
+	resp := &clisvrcom.CliRpcResponse{}
+	SigRawTransaction(req, resp)
+	assert.Equal(t, 0, resp.ErrorCode)
+
+	signedData, err := hex.DecodeString(resp.Result.(*SigRawTransactionRsp).SignedTx)
+	assert.Nil(t, err)
+	signed, err := types.TransactionFromRawBytes(signedData)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Address, signed.Payer)
+	txSig, err := signed.Sigs[0].GetSig()
+	assert.Nil(t, err)
+	hash := signed.Hash()
+	assert.Nil(t, sig.Verify(acc.PublicKey, hash.ToArray(), txSig.SigData[0]))
+}
@@ -19,23 +19,40 @@
 package sigsvr
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
+	"github.com/qbyyf/ontology/cmd/sigsvr/audit"
 	"github.com/qbyyf/ontology/cmd/sigsvr/common"
+	"github.com/qbyyf/ontology/cmd/sigsvr/policy"
 	"github.com/qbyyf/ontology/common/log"
 )
 
 var DefCliRpcSvr = NewCliRpcServer()
 
+// signDataMethods sign data other than transaction, which cannot be checked by policy
+var signDataMethods = map[string]bool{
+	"sigdata":            true,
+	"issuecredential":    true,
+	"createpresentation": true,
+}
+
 type CliRpcServer struct {
-	address    string
-	port       uint
-	handlers   map[string]func(req *common.CliRpcRequest, resp *common.CliRpcResponse)
-	httpSvr    *http.Server
-	httpSvtMux *http.ServeMux
+	address     string
+	port        uint
+	handlers    map[string]func(req *common.CliRpcRequest, resp *common.CliRpcResponse)
+	httpSvr     *http.Server
+	httpSvtMux  *http.ServeMux
+	policy      *policy.Engine
+	auditLog    *audit.Log
+	tlsCert     string
+	tlsKey      string
+	tlsClientCA string
 }
 
 func NewCliRpcServer() *CliRpcServer {
@@ -44,6 +61,23 @@ func NewCliRpcServer() *CliRpcServer {
 	}
 }
 
+// SetPolicy sets the engine to authenticate clients and check signing requests
+func (this *CliRpcServer) SetPolicy(engine *policy.Engine) {
+	this.policy = engine
+}
+
+// SetAuditLog sets the log to record every signing request and decision
+func (this *CliRpcServer) SetAuditLog(auditLog *audit.Log) {
+	this.auditLog = auditLog
+}
+
+// SetTLS enables https. Client certificate is required if clientCA is not empty
+func (this *CliRpcServer) SetTLS(cert, key, clientCA string) {
+	this.tlsCert = cert
+	this.tlsKey = key
+	this.tlsClientCA = clientCA
+}
+
 func (this *CliRpcServer) Start(address string, port uint) {
 	this.address = address
 	this.port = port
@@ -53,7 +87,26 @@ func (this *CliRpcServer) Start(address string, port uint) {
 		Handler: this.httpSvtMux,
 	}
 	this.httpSvtMux.HandleFunc("/cli", this.Handler)
-	err := this.httpSvr.ListenAndServe()
+	var err error
+	if this.tlsCert != "" {
+		if this.tlsClientCA != "" {
+			caData, err := ioutil.ReadFile(this.tlsClientCA)
+			if err != nil {
+				panic(fmt.Sprintf("read client ca:%s error:%s", this.tlsClientCA, err))
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caData) {
+				panic(fmt.Sprintf("invalid client ca:%s", this.tlsClientCA))
+			}
+			this.httpSvr.TLSConfig = &tls.Config{
+				ClientCAs:  pool,
+				ClientAuth: tls.RequireAndVerifyClientCert,
+			}
+		}
+		err = this.httpSvr.ListenAndServeTLS(this.tlsCert, this.tlsKey)
+	} else {
+		err = this.httpSvr.ListenAndServe()
+	}
 	if err != nil {
 		if err == http.ErrServerClosed {
 			return
@@ -76,10 +129,21 @@ func (this *CliRpcServer) GetHandler(method string) func(req *common.CliRpcReque
 
 func (this *CliRpcServer) Handler(w http.ResponseWriter, r *http.Request) {
 	resp := &common.CliRpcResponse{}
+	entry := &audit.Entry{
+		Time:   time.Now().Unix(),
+		Remote: r.RemoteAddr,
+	}
 	defer func() {
+		if this.auditLog != nil && entry.Method != "" {
+			this.recordAudit(entry, resp)
+		}
 		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
 		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if this.policy == nil {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if origin := this.policy.CorsOrigin(); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
 		w.WriteHeader(http.StatusOK)
 
 		if resp.ErrorInfo == "" {
@@ -127,6 +191,25 @@ func (this *CliRpcServer) Handler(w http.ResponseWriter, r *http.Request) {
 	req.Pwd = pwd
 	resp.Method = req.Method
 	resp.Qid = req.Qid
+	entry.Method = req.Method
+	entry.Qid = req.Qid
+	entry.Account = req.Account
+
+	if this.policy != nil {
+		client, err := this.policy.Authenticate(r, data, time.Now())
+		if err == nil {
+			if client != nil {
+				entry.Client = client.Id
+			}
+			err = this.policy.Authorize(client, req.Method, req.Account)
+		}
+		if err != nil {
+			log.Warnf("CliRpcServer unauthorized request from:%s error:%s", r.RemoteAddr, err)
+			resp.ErrorCode = common.CLIERR_UNAUTHORIZED
+			resp.ErrorInfo = err.Error()
+			return
+		}
+	}
 
 	handler := this.GetHandler(req.Method)
 	if handler == nil {
@@ -135,6 +218,85 @@ func (this *CliRpcServer) Handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	handler(req, resp)
+	if this.policy != nil && resp.ErrorCode == common.CLIERR_OK {
+		this.checkPolicy(req, resp, entry)
+	}
+}
+
+// checkPolicy checks the transaction signed by handler, and drops the result if it is denied
+func (this *CliRpcServer) checkPolicy(req *common.CliRpcRequest, resp *common.CliRpcResponse, entry *audit.Entry) {
+	signedTx := getSignedTx(resp.Result)
+	if signedTx == "" && !signDataMethods[req.Method] {
+		return
+	}
+	var action *policy.SignAction
+	if signedTx != "" {
+		var err error
+		action, err = policy.ParseSignedTx(signedTx)
+		if err != nil {
+			log.Warnf("CliRpcServer parse signed tx of method:%s error:%s", req.Method, err)
+		}
+	}
+	if action != nil {
+		entry.TxHash = action.TxHash
+		if action.VM != policy.VM_DEPLOY {
+			entry.Contract = action.Contract.ToHexString()
+		}
+		entry.ContractMethod = action.Method
+		for _, transfer := range action.Transfers {
+			entry.Transfers = append(entry.Transfers, &audit.Transfer{
+				Asset:  transfer.Asset,
+				To:     transfer.To.ToBase58(),
+				Amount: transfer.Amount.String(),
+			})
+		}
+	}
+	err := this.policy.Check(req.Account, action, time.Now())
+	if err != nil {
+		log.Warnf("CliRpcServer method:%s account:%s denied by policy:%s", req.Method, req.Account, err)
+		resp.Result = nil
+		resp.ErrorCode = common.CLIERR_POLICY_DENIED
+		resp.ErrorInfo = err.Error()
+	}
+}
+
+func getSignedTx(result interface{}) string {
+	if result == nil {
+		return ""
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return ""
+	}
+	rsp := &struct {
+		SignedTx string `json:"signed_tx"`
+	}{}
+	if json.Unmarshal(data, rsp) != nil {
+		return ""
+	}
+	return rsp.SignedTx
+}
+
+// recordAudit appends entry of request to audit log. The result is dropped if it cannot be recorded
+func (this *CliRpcServer) recordAudit(entry *audit.Entry, resp *common.CliRpcResponse) {
+	entry.ErrorCode = resp.ErrorCode
+	switch resp.ErrorCode {
+	case common.CLIERR_OK:
+		entry.Decision = audit.DECISION_ALLOW
+	case common.CLIERR_UNAUTHORIZED, common.CLIERR_POLICY_DENIED:
+		entry.Decision = audit.DECISION_DENY
+		entry.Reason = resp.ErrorInfo
+	default:
+		entry.Decision = audit.DECISION_FAIL
+		entry.Reason = resp.ErrorInfo
+	}
+	err := this.auditLog.Append(entry)
+	if err != nil {
+		log.Errorf("CliRpcServer append audit log error:%s", err)
+		resp.Result = nil
+		resp.ErrorCode = common.CLIERR_INTERNAL_ERR
+		resp.ErrorInfo = "audit log unavailable"
+	}
 }
 
 func (this *CliRpcServer) Close() {
@@ -30,7 +30,7 @@ import (
 
 var ImportWalletCommand = cli.Command{
 	Name:      "import",
-	Usage:     "Import accounts from a wallet file",
+	Usage:     "Import accounts and ONT IDs from a wallet file",
 	ArgsUsage: "",
 	Action:    importWallet,
 	Flags: []cli.Flag{
@@ -76,9 +76,27 @@ func importWallet(ctx *cli.Context) error {
 			updateNum++
 		}
 	}
+	addIdNum := 0
+	updateIdNum := 0
+	for i := 0; i < len(walletData.Identities); i++ {
+		ok, err := walletStore.AddIdentity(&walletData.Identities[i])
+		if err != nil {
+			return fmt.Errorf("import ONT ID:%s error:%s", walletData.Identities[i].ID, err)
+		}
+		if ok {
+			addIdNum++
+		} else {
+			updateIdNum++
+		}
+	}
 	cmd.PrintInfoMsg("Import account success.")
 	cmd.PrintInfoMsg("Total account number:%d", len(walletData.Accounts))
 	cmd.PrintInfoMsg("Add account number:%d", addNum)
 	cmd.PrintInfoMsg("Update account number:%d", updateNum)
+	if len(walletData.Identities) > 0 {
+		cmd.PrintInfoMsg("Total ONT ID number:%d", len(walletData.Identities))
+		cmd.PrintInfoMsg("Add ONT ID number:%d", addIdNum)
+		cmd.PrintInfoMsg("Update ONT ID number:%d", updateIdNum)
+	}
 	return nil
 }
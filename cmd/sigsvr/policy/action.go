@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	VM_NATIVE = "native"
+	VM_NEOVM  = "neovm"
+	VM_WASM   = "wasm"
+	VM_EVM    = "evm"
+	VM_DEPLOY = "deploy"
+
+	ASSET_ONT = "ont"
+	ASSET_ONG = "ong"
+)
+
+// Transfer is a native token transfer in signed transaction. Amount is always in 18 decimals.
+// An approval is a transfer to the spender, since the spender can take the amount at any time
+type Transfer struct {
+	Asset  string
+	To     common.Address
+	Amount *big.Int
+}
+
+// SignAction is what a signed transaction will do on chain, which is checked by policy
+type SignAction struct {
+	TxHash    string
+	VM        string
+	Contract  common.Address
+	Method    string
+	Transfers []*Transfer
+}
+
+// ParseSignedTx decodes signed transaction of ontology or EIP155 in hex to SignAction
+func ParseSignedTx(signedTx string) (*SignAction, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(signedTx, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signed tx:%s", err)
+	}
+	tx, err := types.TransactionFromRawBytes(raw)
+	if err != nil {
+		ethTx, ethErr := cliutil.DecodeEthTransaction(signedTx)
+		if ethErr != nil {
+			return nil, fmt.Errorf("decode signed tx error:%s", err)
+		}
+		return parseEthTx(ethTx)
+	}
+	var action *SignAction
+	switch tx.TxType {
+	case types.Deploy:
+		action = &SignAction{VM: VM_DEPLOY}
+	case types.InvokeNeo:
+		code, ok := tx.Payload.(*payload.InvokeCode)
+		if !ok {
+			return nil, fmt.Errorf("invalid invoke payload")
+		}
+		action, err = parseNeoVMCode(code.Code)
+	case types.InvokeWasm:
+		code, ok := tx.Payload.(*payload.InvokeCode)
+		if !ok {
+			return nil, fmt.Errorf("invalid invoke payload")
+		}
+		action, err = parseWasmCode(code.Code)
+	case types.EIP155:
+		ethTx, ethErr := tx.GetEIP155Tx()
+		if ethErr != nil {
+			return nil, ethErr
+		}
+		action, err = parseEthTx(ethTx)
+	default:
+		return nil, fmt.Errorf("unsupported tx type:%d", tx.TxType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	hash := tx.Hash()
+	action.TxHash = hash.ToHexString()
+	return action, nil
+}
+
+func parseEthTx(tx *ethtypes.Transaction) (*SignAction, error) {
+	action := &SignAction{
+		TxHash: tx.Hash().Hex(),
+		VM:     VM_EVM,
+	}
+	if tx.To() == nil {
+		action.VM = VM_DEPLOY
+	} else if len(tx.Data()) == 0 {
+		//plain value transfer of EVM is the same as ong transfer
+		action.VM = VM_NATIVE
+		action.Contract = utils.OngContractAddress
+		action.Method = "transfer"
+	} else {
+		action.Contract = common.Address(*tx.To())
+		if len(tx.Data()) >= 4 {
+			action.Method = "0x" + hex.EncodeToString(tx.Data()[:4])
+		}
+		if action.Contract == utils.OngContractAddress {
+			transfers, err := parseOngCalldata(tx.Data())
+			if err != nil {
+				return nil, err
+			}
+			action.Transfers = transfers
+		}
+	}
+	if tx.Value().Sign() > 0 && tx.To() != nil {
+		action.Transfers = append(action.Transfers, &Transfer{
+			Asset:  ASSET_ONG,
+			To:     common.Address(*tx.To()),
+			Amount: new(big.Int).Set(tx.Value()),
+		})
+	}
+	return action, nil
+}
+
+// ERC20 selectors of ong contract
+var (
+	erc20Transfers = map[string]int{ //selector => number of params, the last two are to and amount
+		"a9059cbb": 2, //transfer(address,uint256)
+		"095ea7b3": 2, //approve(address,uint256)
+		"23b872dd": 3, //transferFrom(address,address,uint256)
+	}
+	erc20Views = map[string]bool{
+		"06fdde03": true, //name()
+		"95d89b41": true, //symbol()
+		"313ce567": true, //decimals()
+		"18160ddd": true, //totalSupply()
+		"70a08231": true, //balanceOf(address)
+		"dd62ed3e": true, //allowance(address,address)
+	}
+)
+
+// parseOngCalldata decodes ERC20 calldata of ong, which is in 18 decimals. Unknown calldata is denied
+func parseOngCalldata(data []byte) ([]*Transfer, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("invalid ong calldata")
+	}
+	selector := hex.EncodeToString(data[:4])
+	if erc20Views[selector] {
+		return nil, nil
+	}
+	count, ok := erc20Transfers[selector]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ong method:0x%s", selector)
+	}
+	params := data[4:]
+	if len(params) != count*32 {
+		return nil, fmt.Errorf("invalid ong calldata length of method:0x%s", selector)
+	}
+	to := params[len(params)-64 : len(params)-32]
+	for _, b := range to[:12] {
+		if b != 0 {
+			return nil, fmt.Errorf("invalid ong calldata address of method:0x%s", selector)
+		}
+	}
+	var addr common.Address
+	copy(addr[:], to[12:])
+	return []*Transfer{{
+		Asset:  ASSET_ONG,
+		To:     addr,
+		Amount: new(big.Int).SetBytes(params[len(params)-32:]),
+	}}, nil
+}
+
+func parseWasmCode(code []byte) (*SignAction, error) {
+	source := common.NewZeroCopySource(code)
+	contract, eof := source.NextAddress()
+	if eof {
+		return nil, fmt.Errorf("invalid wasm invoke code")
+	}
+	action := &SignAction{VM: VM_WASM, Contract: contract}
+	args, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, fmt.Errorf("invalid wasm invoke code")
+	}
+	method, _, irregular, eof := common.NewZeroCopySource(args).NextString()
+	if !irregular && !eof {
+		action.Method = method
+	}
+	return action, nil
+}
+
+// parseNeoVMCode finds out the contract, method and transfers of invoke code
+func parseNeoVMCode(code []byte) (*SignAction, error) {
+	invoke, err := cutils.ParseNeoVMInvokeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if !invoke.Native {
+		return &SignAction{VM: VM_NEOVM, Contract: invoke.Contract, Method: invoke.Method}, nil
+	}
+	return parseNativeInvoke(invoke)
+}
+
+func parseNativeInvoke(invoke *cutils.NeoVMInvoke) (*SignAction, error) {
+	contract := invoke.Contract
+	action := &SignAction{
+		VM:       VM_NATIVE,
+		Contract: contract,
+		Method:   invoke.Method,
+	}
+	var asset string
+	var decimals int
+	switch contract {
+	case utils.OntContractAddress:
+		asset, decimals = ASSET_ONT, constants.ONT_DECIMALS
+	case utils.OngContractAddress:
+		asset, decimals = ASSET_ONG, constants.ONG_DECIMALS
+	default:
+		return action, nil
+	}
+	args := invoke.Args
+	var states []*cutils.NeoVMItem
+	switch action.Method {
+	case "transfer":
+		states = args.Items
+	case "transferV2":
+		states = args.Items
+		decimals += constants.ONT_DECIMALS_V2 - constants.ONT_DECIMALS
+	case "transferFrom", "approve":
+		states = []*cutils.NeoVMItem{args}
+	case "transferFromV2", "approveV2":
+		states = []*cutils.NeoVMItem{args}
+		decimals += constants.ONT_DECIMALS_V2 - constants.ONT_DECIMALS
+	case "name", "symbol", "decimals", "decimalsV2", "totalSupply", "totalSupplyV2", "balanceOf", "balanceOfV2",
+		"allowance", "allowanceV2", "totalAllowance", "totalAllowanceV2":
+		return action, nil
+	default:
+		//methods which may move the asset in an unknown way are denied
+		return nil, fmt.Errorf("unsupported %s method:%s", asset, action.Method)
+	}
+	if !args.IsArray {
+		return nil, fmt.Errorf("invalid %s params", action.Method)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(constants.ONG_DECIMALS_V2-decimals)), nil)
+	for _, state := range states {
+		//transfer and approve state is [from, to, value], and transferFrom state is [sender, from, to, value]
+		if !state.IsArray || len(state.Items) < 3 {
+			return nil, fmt.Errorf("invalid %s params", action.Method)
+		}
+		to, err := common.AddressParseFromBytes(state.Items[len(state.Items)-2].Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s to address", action.Method)
+		}
+		value, err := state.Items[len(state.Items)-1].Integer()
+		if err != nil || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid %s amount", action.Method)
+		}
+		action.Transfers = append(action.Transfers, &Transfer{
+			Asset:  asset,
+			To:     to,
+			Amount: value.Mul(value, scale),
+		})
+	}
+	return action, nil
+}
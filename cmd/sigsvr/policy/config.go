@@ -0,0 +1,165 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/go-ethereum/crypto"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+)
+
+// DEFAULT_POLICY is the key of policy which applies to accounts without their own policy
+const DEFAULT_POLICY = "*"
+
+// Config is the declarative access control and signing policy of sig server
+type Config struct {
+	//CorsOrigin is the Access-Control-Allow-Origin of response, no CORS header if empty
+	CorsOrigin string `json:"cors_origin"`
+	//Clients can access sig server. Authentication is not required if it is empty
+	Clients []*ClientConfig `json:"clients"`
+	//Accounts is the signing policy of account address(base58) or DEFAULT_POLICY
+	Accounts map[string]*AccountPolicy `json:"accounts"`
+}
+
+// ClientConfig is the API client, which is authenticated by HMAC of Secret, or by client certificate with common name of Id
+type ClientConfig struct {
+	Id       string   `json:"id"`
+	Secret   string   `json:"secret"`
+	Methods  []string `json:"methods"`  //allowed sig server methods, empty for all
+	Accounts []string `json:"accounts"` //allowed accounts, empty for all
+}
+
+// AccountPolicy is the rules of transactions signed by account. Empty rule means no restriction
+type AccountPolicy struct {
+	Contracts    []*ContractRule   `json:"contracts"`    //allowed contracts and methods
+	Destinations []string          `json:"destinations"` //allowed transfer destinations
+	MaxPerTx     map[string]string `json:"max_per_tx"`   //asset(ont or ong) => max transfer amount per tx
+	MaxPerDay    map[string]string `json:"max_per_day"`  //asset(ont or ong) => max transfer amount per day in UTC
+}
+
+// ContractRule allows methods of contract. Method of EVM contract is function signature or 4 bytes selector in hex
+type ContractRule struct {
+	Address string   `json:"address"`
+	Methods []string `json:"methods"` //empty for all methods
+}
+
+// LoadConfig loads policy config from json file
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy file:%s error:%s", path, err)
+	}
+	cfg := &Config{}
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal policy file:%s error:%s", path, err)
+	}
+	return cfg, nil
+}
+
+type accountPolicy struct {
+	contracts    map[common.Address]map[string]bool //nil map of methods for all methods
+	destinations map[common.Address]bool
+	maxPerTx     map[string]*big.Int
+	maxPerDay    map[string]*big.Int
+}
+
+func (this *accountPolicy) unrestricted() bool {
+	return len(this.contracts) == 0 && len(this.destinations) == 0 && len(this.maxPerTx) == 0 && len(this.maxPerDay) == 0
+}
+
+func compileAccountPolicy(p *AccountPolicy) (*accountPolicy, error) {
+	res := &accountPolicy{
+		contracts:    make(map[common.Address]map[string]bool),
+		destinations: make(map[common.Address]bool),
+	}
+	for _, rule := range p.Contracts {
+		addr, err := parseAddress(rule.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contract address:%s", rule.Address)
+		}
+		var methods map[string]bool
+		if len(rule.Methods) > 0 {
+			methods = make(map[string]bool, len(rule.Methods))
+			for _, method := range rule.Methods {
+				methods[method] = true
+				if strings.Contains(method, "(") {
+					methods["0x"+hex.EncodeToString(crypto.Keccak256([]byte(method))[:4])] = true
+				}
+			}
+		}
+		res.contracts[addr] = methods
+	}
+	for _, dest := range p.Destinations {
+		addr, err := parseAddress(dest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination address:%s", dest)
+		}
+		res.destinations[addr] = true
+	}
+	var err error
+	res.maxPerTx, err = parseLimits(p.MaxPerTx)
+	if err != nil {
+		return nil, err
+	}
+	res.maxPerDay, err = parseLimits(p.MaxPerDay)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func parseLimits(limits map[string]string) (map[string]*big.Int, error) {
+	res := make(map[string]*big.Int, len(limits))
+	for asset, limit := range limits {
+		asset = strings.ToLower(asset)
+		if asset != ASSET_ONT && asset != ASSET_ONG {
+			return nil, fmt.Errorf("unsupported asset:%s", asset)
+		}
+		amount, err := cliutil.ParseEthAmount(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s limit:%s", asset, err)
+		}
+		res[asset] = amount
+	}
+	return res, nil
+}
+
+// parseAddress parses address in base58, hex of ontology, or EVM hex with 0x prefix
+func parseAddress(address string) (common.Address, error) {
+	if strings.HasPrefix(address, "0x") {
+		if !ethcommon.IsHexAddress(address) {
+			return common.ADDRESS_EMPTY, fmt.Errorf("invalid address")
+		}
+		return common.Address(ethcommon.HexToAddress(address)), nil
+	}
+	addr, err := common.AddressFromBase58(address)
+	if err == nil {
+		return addr, nil
+	}
+	return common.AddressFromHexString(address)
+}
@@ -0,0 +1,308 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qbyyf/ontology/cmd/sigsvr/audit"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+)
+
+const (
+	HEADER_API_KEY       = "X-Api-Key"
+	HEADER_API_TIMESTAMP = "X-Api-Timestamp"
+	HEADER_API_SIGNATURE = "X-Api-Signature"
+
+	//MAX_CLOCK_SKEW is the max seconds between timestamp of request and local time
+	MAX_CLOCK_SKEW = 300
+)
+
+// Engine authenticates clients and checks signing requests by policy config
+type Engine struct {
+	config   *Config
+	clients  map[string]*ClientConfig
+	accounts map[string]*accountPolicy
+	usageDay string
+	usage    map[string]map[string]*big.Int //account => asset => amount signed in usageDay
+	seenSigs map[string]int64               //hmac signature => timestamp, to reject replayed requests
+	lock     sync.Mutex
+}
+
+func NewEngine(cfg *Config) (*Engine, error) {
+	engine := &Engine{
+		config:   cfg,
+		clients:  make(map[string]*ClientConfig, len(cfg.Clients)),
+		accounts: make(map[string]*accountPolicy, len(cfg.Accounts)),
+		usage:    make(map[string]map[string]*big.Int),
+		seenSigs: make(map[string]int64),
+	}
+	for _, client := range cfg.Clients {
+		if client.Id == "" {
+			return nil, fmt.Errorf("client id cannot be empty")
+		}
+		if _, ok := engine.clients[client.Id]; ok {
+			return nil, fmt.Errorf("duplicate client:%s", client.Id)
+		}
+		engine.clients[client.Id] = client
+	}
+	for account, p := range cfg.Accounts {
+		compiled, err := compileAccountPolicy(p)
+		if err != nil {
+			return nil, fmt.Errorf("policy of account:%s error:%s", account, err)
+		}
+		key := account
+		if account != DEFAULT_POLICY {
+			addr, err := parseAddress(account)
+			if err != nil {
+				return nil, fmt.Errorf("invalid account:%s in policy", account)
+			}
+			key = addr.ToBase58()
+		}
+		if _, ok := engine.accounts[key]; ok {
+			return nil, fmt.Errorf("duplicate policy of account:%s", account)
+		}
+		engine.accounts[key] = compiled
+	}
+	return engine, nil
+}
+
+func (this *Engine) CorsOrigin() string {
+	return this.config.CorsOrigin
+}
+
+// Authenticate returns the client of request by HMAC headers or client certificate.
+// It returns nil client without error if no client is configured
+func (this *Engine) Authenticate(r *http.Request, body []byte, now time.Time) (*ClientConfig, error) {
+	if len(this.clients) == 0 {
+		return nil, nil
+	}
+	keyId := r.Header.Get(HEADER_API_KEY)
+	if keyId != "" {
+		client, ok := this.clients[keyId]
+		if !ok || client.Secret == "" {
+			return nil, fmt.Errorf("unknown api key:%s", keyId)
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get(HEADER_API_TIMESTAMP), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header", HEADER_API_TIMESTAMP)
+		}
+		if timestamp < now.Unix()-MAX_CLOCK_SKEW || timestamp > now.Unix()+MAX_CLOCK_SKEW {
+			return nil, fmt.Errorf("request timestamp expired")
+		}
+		sig, err := hex.DecodeString(r.Header.Get(HEADER_API_SIGNATURE))
+		if err != nil || !hmac.Equal(sig, SignRequest(client.Secret, timestamp, body)) {
+			return nil, fmt.Errorf("invalid request signature")
+		}
+		return client, this.checkReplay(hex.EncodeToString(sig), timestamp, now)
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		cn := r.TLS.PeerCertificates[0].Subject.CommonName
+		client, ok := this.clients[cn]
+		if !ok {
+			return nil, fmt.Errorf("unknown client certificate:%s", cn)
+		}
+		return client, nil
+	}
+	return nil, fmt.Errorf("authentication required")
+}
+
+func (this *Engine) checkReplay(sig string, timestamp int64, now time.Time) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for s, t := range this.seenSigs {
+		if t < now.Unix()-MAX_CLOCK_SKEW {
+			delete(this.seenSigs, s)
+		}
+	}
+	if _, ok := this.seenSigs[sig]; ok {
+		return fmt.Errorf("replayed request")
+	}
+	this.seenSigs[sig] = timestamp
+	return nil
+}
+
+// SignRequest returns HMAC-SHA256 of timestamp and request body by secret, which is the X-Api-Signature header in hex
+func SignRequest(secret string, timestamp int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Authorize checks whether client can call method with account
+func (this *Engine) Authorize(client *ClientConfig, method, account string) error {
+	if client == nil {
+		return nil
+	}
+	if len(client.Methods) > 0 && !contains(client.Methods, method) {
+		return fmt.Errorf("method:%s is not allowed for client:%s", method, client.Id)
+	}
+	if len(client.Accounts) > 0 && !contains(client.Accounts, account) {
+		return fmt.Errorf("account:%s is not allowed for client:%s", account, client.Id)
+	}
+	return nil
+}
+
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Engine) getAccountPolicy(account string) *accountPolicy {
+	if p, ok := this.accounts[account]; ok {
+		return p
+	}
+	return this.accounts[DEFAULT_POLICY]
+}
+
+// Check checks the action of transaction signed by account, and records the transfer amount if it passes.
+// action is nil if the signed transaction cannot be decoded
+func (this *Engine) Check(account string, action *SignAction, now time.Time) error {
+	p := this.getAccountPolicy(account)
+	if p == nil {
+		return fmt.Errorf("no signing policy for account:%s", account)
+	}
+	if p.unrestricted() {
+		return nil
+	}
+	if action == nil {
+		return fmt.Errorf("cannot decode signed tx to check policy")
+	}
+	if len(p.contracts) > 0 {
+		methods, ok := p.contracts[action.Contract]
+		if !ok || action.VM == VM_DEPLOY {
+			return fmt.Errorf("contract:%s is not allowed", action.Contract.ToHexString())
+		}
+		if methods != nil && !methods[action.Method] {
+			return fmt.Errorf("method:%s of contract:%s is not allowed", action.Method, action.Contract.ToHexString())
+		}
+	}
+	amounts := make(map[string]*big.Int)
+	for _, transfer := range action.Transfers {
+		if len(p.destinations) > 0 && !p.destinations[transfer.To] {
+			return fmt.Errorf("destination:%s is not allowed", transfer.To.ToBase58())
+		}
+		if amounts[transfer.Asset] == nil {
+			amounts[transfer.Asset] = new(big.Int)
+		}
+		amounts[transfer.Asset].Add(amounts[transfer.Asset], transfer.Amount)
+	}
+	for asset, amount := range amounts {
+		if limit, ok := p.maxPerTx[asset]; ok && amount.Cmp(limit) > 0 {
+			return fmt.Errorf("%s amount:%s exceeds limit per tx:%s", asset, cliutil.FormatEthAmount(amount), cliutil.FormatEthAmount(limit))
+		}
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	usage := this.accountUsage(account, now)
+	for asset, amount := range amounts {
+		limit, ok := p.maxPerDay[asset]
+		if !ok {
+			continue
+		}
+		used := usage[asset]
+		if used == nil {
+			used = new(big.Int)
+		}
+		if new(big.Int).Add(used, amount).Cmp(limit) > 0 {
+			return fmt.Errorf("%s amount:%s exceeds limit per day:%s, used:%s", asset, cliutil.FormatEthAmount(amount),
+				cliutil.FormatEthAmount(limit), cliutil.FormatEthAmount(used))
+		}
+	}
+	addUsage(usage, amounts)
+	return nil
+}
+
+// RecordUsage adds transfers signed by account at time to daily usage, which is used to restore usage from audit log
+func (this *Engine) RecordUsage(account string, transfers []*Transfer, at time.Time) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if at.UTC().Format("2006-01-02") != time.Now().UTC().Format("2006-01-02") {
+		return
+	}
+	amounts := make(map[string]*big.Int)
+	for _, transfer := range transfers {
+		if amounts[transfer.Asset] == nil {
+			amounts[transfer.Asset] = new(big.Int)
+		}
+		amounts[transfer.Asset].Add(amounts[transfer.Asset], transfer.Amount)
+	}
+	addUsage(this.accountUsage(account, at), amounts)
+}
+
+// RestoreUsage restores daily usage from allowed entries of audit log, so that restarting sig server cannot reset the limits
+func (this *Engine) RestoreUsage(entries []*audit.Entry) error {
+	for _, entry := range entries {
+		if entry.Decision != audit.DECISION_ALLOW || len(entry.Transfers) == 0 {
+			continue
+		}
+		transfers := make([]*Transfer, 0, len(entry.Transfers))
+		for _, t := range entry.Transfers {
+			to, err := common.AddressFromBase58(t.To)
+			if err != nil {
+				return fmt.Errorf("audit log seq:%d invalid transfer to:%s", entry.Seq, t.To)
+			}
+			amount, ok := new(big.Int).SetString(t.Amount, 10)
+			if !ok {
+				return fmt.Errorf("audit log seq:%d invalid transfer amount:%s", entry.Seq, t.Amount)
+			}
+			transfers = append(transfers, &Transfer{Asset: t.Asset, To: to, Amount: amount})
+		}
+		this.RecordUsage(entry.Account, transfers, time.Unix(entry.Time, 0))
+	}
+	return nil
+}
+
+func (this *Engine) accountUsage(account string, now time.Time) map[string]*big.Int {
+	day := now.UTC().Format("2006-01-02")
+	if day != this.usageDay {
+		this.usageDay = day
+		this.usage = make(map[string]map[string]*big.Int)
+	}
+	usage, ok := this.usage[account]
+	if !ok {
+		usage = make(map[string]*big.Int)
+		this.usage[account] = usage
+	}
+	return usage
+}
+
+func addUsage(usage map[string]*big.Int, amounts map[string]*big.Int) {
+	for asset, amount := range amounts {
+		if usage[asset] == nil {
+			usage[asset] = new(big.Int)
+		}
+		usage[asset].Add(usage[asset], amount)
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"encoding/hex"
+	"math/big"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/go-ethereum/rlp"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/cmd/sigsvr/audit"
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedTransferTx(t *testing.T, asset string, from, to common.Address, amount uint64) string {
+	mutable, err := cliutil.TransferTx(2500, 20000, asset, from.ToBase58(), to.ToBase58(), amount)
+	assert.Nil(t, err)
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return hex.EncodeToString(common.SerializeToBytes(tx))
+}
+
+func ontAmount(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1000000000000000000))
+}
+
+func TestParseSignedTx(t *testing.T) {
+	from := account.NewAccount("")
+	to := account.NewAccount("")
+	action, err := ParseSignedTx(signedTransferTx(t, "ont", from.Address, to.Address, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, VM_NATIVE, action.VM)
+	assert.Equal(t, utils.OntContractAddress, action.Contract)
+	assert.Equal(t, "transfer", action.Method)
+	assert.Equal(t, 1, len(action.Transfers))
+	assert.Equal(t, ASSET_ONT, action.Transfers[0].Asset)
+	assert.Equal(t, to.Address, action.Transfers[0].To)
+	assert.Equal(t, ontAmount(10), action.Transfers[0].Amount)
+
+	_, err = ParseSignedTx("00ff")
+	assert.NotNil(t, err)
+}
+
+func signedNativeTx(t *testing.T, mutable *types.MutableTransaction) string {
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return hex.EncodeToString(common.SerializeToBytes(tx))
+}
+
+func TestParseNativeApprove(t *testing.T) {
+	from := account.NewAccount("")
+	spender := account.NewAccount("")
+	mutable, err := cliutil.ApproveTx(2500, 20000, "ong", from.Address.ToBase58(), spender.Address.ToBase58(), 10)
+	assert.Nil(t, err)
+	action, err := ParseSignedTx(signedNativeTx(t, mutable))
+	assert.Nil(t, err)
+	assert.Equal(t, "approve", action.Method)
+	assert.Equal(t, 1, len(action.Transfers))
+	assert.Equal(t, ASSET_ONG, action.Transfers[0].Asset)
+	assert.Equal(t, spender.Address, action.Transfers[0].To)
+	assert.Equal(t, big.NewInt(10000000000), action.Transfers[0].Amount)
+
+	//unknown methods of ont and ong are denied
+	code, err := cutils.BuildNativeInvokeCode(utils.OntContractAddress, 0, "unboundOngToGovernance", []interface{}{})
+	assert.Nil(t, err)
+	_, err = ParseSignedTx(signedNativeTx(t, cliutil.NewInvokeTransaction(2500, 20000, code)))
+	assert.NotNil(t, err)
+}
+
+func signedEthTx(t *testing.T, to ethcommon.Address, data []byte) string {
+	key, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	tx := ethtypes.NewTransaction(0, to, big.NewInt(0), 100000, big.NewInt(2500), data)
+	tx, err = ethtypes.SignTx(tx, ethtypes.NewEIP155Signer(big.NewInt(5851)), key)
+	assert.Nil(t, err)
+	raw, err := rlp.EncodeToBytes(tx)
+	assert.Nil(t, err)
+	return hex.EncodeToString(raw)
+}
+
+func TestParseOngCalldata(t *testing.T) {
+	ong := ethcommon.Address(utils.OngContractAddress)
+	from := account.NewAccount("")
+	to := account.NewAccount("")
+	word := func(b []byte) []byte {
+		return ethcommon.LeftPadBytes(b, 32)
+	}
+	calldata := func(selector string, words ...[]byte) []byte {
+		data, _ := hex.DecodeString(selector)
+		for _, w := range words {
+			data = append(data, w...)
+		}
+		return data
+	}
+	amount := big.NewInt(123456789)
+	for _, data := range [][]byte{
+		calldata("a9059cbb", word(to.Address[:]), word(amount.Bytes())),
+		calldata("095ea7b3", word(to.Address[:]), word(amount.Bytes())),
+		calldata("23b872dd", word(from.Address[:]), word(to.Address[:]), word(amount.Bytes())),
+	} {
+		action, err := ParseSignedTx(signedEthTx(t, ong, data))
+		assert.Nil(t, err)
+		assert.Equal(t, VM_EVM, action.VM)
+		assert.Equal(t, "0x"+hex.EncodeToString(data[:4]), action.Method)
+		assert.Equal(t, []*Transfer{{Asset: ASSET_ONG, To: to.Address, Amount: amount}}, action.Transfers)
+	}
+
+	action, err := ParseSignedTx(signedEthTx(t, ong, calldata("70a08231", word(to.Address[:]))))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(action.Transfers))
+
+	_, err = ParseSignedTx(signedEthTx(t, ong, calldata("a9059cbb", word(to.Address[:]))))
+	assert.NotNil(t, err, "short calldata")
+	_, err = ParseSignedTx(signedEthTx(t, ong, calldata("12345678", word(to.Address[:]), word(amount.Bytes()))))
+	assert.NotNil(t, err, "unknown method")
+
+	//erc20 calldata of other contracts is not ong
+	action, err = ParseSignedTx(signedEthTx(t, ethcommon.HexToAddress("0x1234"),
+		calldata("a9059cbb", word(to.Address[:]), word(amount.Bytes()))))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(action.Transfers))
+}
+
+func TestEngineCheck(t *testing.T) {
+	acc := account.NewAccount("")
+	dest := account.NewAccount("")
+	other := account.NewAccount("")
+	engine, err := NewEngine(&Config{
+		Accounts: map[string]*AccountPolicy{
+			acc.Address.ToBase58(): {
+				Contracts:    []*ContractRule{{Address: utils.OntContractAddress.ToHexString(), Methods: []string{"transfer"}}},
+				Destinations: []string{dest.Address.ToBase58()},
+				MaxPerTx:     map[string]string{"ont": "100"},
+				MaxPerDay:    map[string]string{"ont": "150"},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	now := time.Now()
+	check := func(asset string, to common.Address, amount uint64) error {
+		action, err := ParseSignedTx(signedTransferTx(t, asset, acc.Address, to, amount))
+		assert.Nil(t, err)
+		return engine.Check(acc.Address.ToBase58(), action, now)
+	}
+
+	assert.Nil(t, check("ont", dest.Address, 100))
+	assert.NotNil(t, check("ont", dest.Address, 101), "exceeds limit per tx")
+	assert.NotNil(t, check("ont", other.Address, 1), "destination not allowed")
+	assert.NotNil(t, check("ong", dest.Address, 1), "contract not allowed")
+	assert.NotNil(t, check("ont", dest.Address, 51), "exceeds limit per day")
+	assert.Nil(t, check("ont", dest.Address, 50))
+	assert.NotNil(t, check("ont", dest.Address, 1), "exceeds limit per day")
+	assert.Nil(t, engine.Check(acc.Address.ToBase58(), &SignAction{VM: VM_NATIVE, Contract: utils.OntContractAddress,
+		Method: "transfer"}, now.Add(24*time.Hour)))
+
+	assert.NotNil(t, engine.Check(acc.Address.ToBase58(), nil, now), "undecodable tx")
+	assert.NotNil(t, engine.Check(other.Address.ToBase58(), nil, now), "no policy")
+}
+
+func TestEngineRestoreUsage(t *testing.T) {
+	acc := account.NewAccount("")
+	engine, err := NewEngine(&Config{
+		Accounts: map[string]*AccountPolicy{
+			DEFAULT_POLICY: {MaxPerDay: map[string]string{"ong": "1.5"}},
+		},
+	})
+	assert.Nil(t, err)
+	now := time.Now()
+	err = engine.RestoreUsage([]*audit.Entry{
+		{Time: now.Unix(), Account: acc.Address.ToBase58(), Decision: audit.DECISION_ALLOW,
+			Transfers: []*audit.Transfer{{Asset: ASSET_ONG, To: acc.Address.ToBase58(), Amount: "1000000000000000000"}}},
+		{Time: now.Unix(), Account: acc.Address.ToBase58(), Decision: audit.DECISION_DENY,
+			Transfers: []*audit.Transfer{{Asset: ASSET_ONG, To: acc.Address.ToBase58(), Amount: "1000000000000000000"}}},
+	})
+	assert.Nil(t, err)
+	action := &SignAction{Transfers: []*Transfer{{Asset: ASSET_ONG, To: acc.Address, Amount: big.NewInt(500000000000000000)}}}
+	assert.Nil(t, engine.Check(acc.Address.ToBase58(), action, now))
+	assert.NotNil(t, engine.Check(acc.Address.ToBase58(), action, now))
+}
+
+func TestEngineAuthenticate(t *testing.T) {
+	engine, err := NewEngine(&Config{
+		Clients: []*ClientConfig{{Id: "app", Secret: "secret", Methods: []string{"sigtransfertx"}}},
+	})
+	assert.Nil(t, err)
+	body := []byte(`{"qid":"1","method":"sigtransfertx"}`)
+	now := time.Now()
+	request := func(secret string, timestamp int64) (*ClientConfig, error) {
+		r := httptest.NewRequest("POST", "/cli", strings.NewReader(string(body)))
+		r.Header.Set(HEADER_API_KEY, "app")
+		r.Header.Set(HEADER_API_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+		r.Header.Set(HEADER_API_SIGNATURE, hex.EncodeToString(SignRequest(secret, timestamp, body)))
+		return engine.Authenticate(r, body, now)
+	}
+
+	client, err := request("secret", now.Unix())
+	assert.Nil(t, err)
+	assert.Equal(t, "app", client.Id)
+	_, err = request("secret", now.Unix())
+	assert.NotNil(t, err, "replayed request")
+	_, err = request("wrong", now.Unix()-1)
+	assert.NotNil(t, err, "wrong secret")
+	_, err = request("secret", now.Unix()-MAX_CLOCK_SKEW-1)
+	assert.NotNil(t, err, "expired timestamp")
+	_, err = engine.Authenticate(httptest.NewRequest("POST", "/cli", nil), body, now)
+	assert.NotNil(t, err, "no credential")
+
+	assert.Nil(t, engine.Authorize(client, "sigtransfertx", ""))
+	assert.NotNil(t, engine.Authorize(client, "sigdata", ""))
+}
@@ -35,6 +35,7 @@ const (
 	WALLET_ACCOUNT_PREFIX            = 0x06
 	WALLET_EXTRA_PREFIX              = 0x07
 	WALLET_ACCOUNT_NUMBER            = 0x08
+	WALLET_IDENTITY_PREFIX           = 0x09
 )
 
 func GetWalletInitKey() []byte {
@@ -74,3 +75,7 @@ func GetWalletExtraKey() []byte {
 func GetWalletAccountNumberKey() []byte {
 	return []byte{WALLET_ACCOUNT_NUMBER}
 }
+
+func GetIdentityKey(ontId string) []byte {
+	return append([]byte{WALLET_IDENTITY_PREFIX}, []byte(ontId)...)
+}
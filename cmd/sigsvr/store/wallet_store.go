@@ -315,6 +315,40 @@ func (this *WalletStore) AddAccountData(accData *account.AccountData) (bool, err
 	return isAdd, nil
 }
 
+// AddIdentity saves the ONT ID to store, and return true if the ONT ID is new
+func (this *WalletStore) AddIdentity(identity *account.Identity) (bool, error) {
+	old, err := this.GetIdentity(identity.ID)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return false, err
+	}
+	err = this.db.Put(GetIdentityKey(identity.ID), data, nil)
+	if err != nil {
+		return false, err
+	}
+	return old == nil, nil
+}
+
+// GetIdentity return the ONT ID in store, nil if not found
+func (this *WalletStore) GetIdentity(ontId string) (*account.Identity, error) {
+	data, err := this.db.Get(GetIdentityKey(ontId), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	identity := &account.Identity{}
+	err = json.Unmarshal(data, identity)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
 func (this *WalletStore) getNextAccountIndex() (uint32, error) {
 	data, err := this.db.Get(GetNextAccountIndexKey(), nil)
 	if err != nil {
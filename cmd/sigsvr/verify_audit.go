@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sigsvr
+
+import (
+	"fmt"
+
+	"github.com/qbyyf/ontology/cmd/sigsvr/audit"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/urfave/cli"
+)
+
+var VerifyAuditLogCommand = cli.Command{
+	Name:      "verifyaudit",
+	Usage:     "Verify the hash chain of audit log",
+	ArgsUsage: "",
+	Action:    verifyAuditLog,
+	Flags: []cli.Flag{
+		utils.CliAuditLogFlag,
+	},
+	Description: "Verify the hash chain of audit log, and print the sequence and hash of the last entry. Compare the hash with the one recorded before to detect truncation of log.",
+}
+
+func verifyAuditLog(ctx *cli.Context) error {
+	path := ctx.String(utils.GetFlagName(utils.CliAuditLogFlag))
+	if path == "" {
+		return fmt.Errorf("missing --%s flag", utils.CliAuditLogFlag.Name)
+	}
+	entries, err := audit.ReadEntries(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("Audit log:%s is empty\n", path)
+		return nil
+	}
+	allow, deny := 0, 0
+	for _, entry := range entries {
+		switch entry.Decision {
+		case audit.DECISION_ALLOW:
+			allow++
+		case audit.DECISION_DENY:
+			deny++
+		}
+	}
+	last := entries[len(entries)-1]
+	fmt.Printf("Audit log:%s verified\n", path)
+	fmt.Printf("  Entries:%d Allowed:%d Denied:%d Failed:%d\n", len(entries), allow, deny, len(entries)-allow-deny)
+	fmt.Printf("  Last seq:%d hash:%s\n", last.Seq, last.Hash)
+	return nil
+}
@@ -125,6 +125,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.AccountChangePasswdFlag,
 			utils.AccountSourceFileFlag,
 			utils.AccountWIFFlag,
+			utils.AccountKeystoreFlag,
+			utils.AccountScryptNFlag,
+			utils.AccountScryptRFlag,
+			utils.AccountScryptPFlag,
+			utils.AccountDecryptFlag,
+			utils.AccountDeriveFlag,
+			utils.AccountMnemonicFlag,
+			utils.AccountMnemonicWordsFlag,
+			utils.AccountCoinTypeFlag,
 			utils.AccountLowSecurityFlag,
 			utils.AccountMultiMFlag,
 			utils.AccountMultiPubKeyFlag,
@@ -136,6 +145,12 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.EnableConsensusFlag,
 			utils.MaxTxInBlockFlag,
+			utils.RemoteSignerFlag,
+			utils.RemoteSignerKeyFlag,
+			utils.RemoteSignerTokenFlag,
+			utils.RemoteSignerCAFlag,
+			utils.RemoteSignerCertFlag,
+			utils.RemoteSignerCertKeyFlag,
 		},
 	},
 	{
@@ -199,6 +214,24 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.EnableTestModeFlag,
 			utils.TestModeGenBlockTimeFlag,
+			utils.ForkRpcFlag,
+			utils.ForkEthRpcFlag,
+			utils.ForkBlockFlag,
+			utils.ForkImpersonateFlag,
+		},
+	},
+	{
+		Name: "DEVNET",
+		Flags: []cli.Flag{
+			utils.DevnetDataDirFlag,
+			utils.DevnetMnemonicFlag,
+			utils.DevnetAccountNumFlag,
+			utils.DevnetOntBalanceFlag,
+			utils.DevnetOngBalanceFlag,
+			utils.DevnetBlockTimeFlag,
+			utils.DevnetPasswordFlag,
+			utils.DevnetResetFlag,
+			utils.DevnetNodesFlag,
 		},
 	},
 	{
@@ -216,6 +249,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ContractPrepareInvokeFlag,
 			utils.ContractParamsFlag,
 			utils.ContractReturnTypeFlag,
+			utils.ContractGasLimitFlag,
+			utils.ContractCrossVMFlag,
+			utils.ContractMigrateMethodFlag,
+			utils.ContractDestroyMethodFlag,
+			utils.ContractWaitFlag,
+			utils.ContractWaitTimeoutFlag,
 		},
 	},
 	{
@@ -240,6 +279,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TransferFromAmountFlag,
 			utils.WithdrawONGReceiveAccountFlag,
 			utils.WithdrawONGAmountFlag,
+			utils.MultiSigFileFlag,
+			utils.NeoVMAbiFileFlag,
+			utils.EthAbiFileFlag,
+			utils.AssumeYesFlag,
 		},
 	},
 	{
@@ -249,6 +292,30 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ApproveAssetToFlag,
 		},
 	},
+	{
+		Name: "GOVERNANCE",
+		Flags: []cli.Flag{
+			utils.GovernancePeerPubkeyFlag,
+			utils.GovernancePosFlag,
+			utils.GovernancePeerCostFlag,
+		},
+	},
+	{
+		Name: "ONT ID",
+		Flags: []cli.Flag{
+			utils.OntIdKeyIndexFlag,
+			utils.OntIdPublicKeyFlag,
+			utils.OntIdControllerFlag,
+			utils.OntIdRecoveryFlag,
+			utils.OntIdThresholdFlag,
+			utils.OntIdAttrKeyFlag,
+			utils.OntIdAttrTypeFlag,
+			utils.OntIdAttrValueFlag,
+			utils.OntIdServiceIdFlag,
+			utils.OntIdServiceTypeFlag,
+			utils.OntIdServiceEndpointFlag,
+		},
+	},
 	{
 		Name: "EXPORT",
 		Flags: []cli.Flag{
@@ -265,6 +332,14 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ImportEndHeightFlag,
 		},
 	},
+	{
+		Name: "DB VERIFY",
+		Flags: []cli.Flag{
+			utils.DbVerifyFullFlag,
+			utils.DbVerifyReportFlag,
+			utils.DbVerifyMaxErrorsFlag,
+		},
+	},
 	{
 		Name: "MISC",
 	},
@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account/credential"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+	httpcom "github.com/qbyyf/ontology/http/base/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+// RpcCredentialResolver resolves ONT ID keys and credential status from the
+// chain state through the json rpc of node
+type RpcCredentialResolver struct{}
+
+// GetPublicKey returns the key in use at keyIndex of ontId, by ontid method getPublicKeys
+func (this *RpcCredentialResolver) GetPublicKey(ontId string, keyIndex uint32) (keypair.PublicKey, error) {
+	preResult, err := PrepareInvokeNativeContract(utils.OntIDContractAddress, VERSION_CONTRACT_ONTID,
+		"getPublicKeys", []interface{}{[]byte(ontId)})
+	if err != nil {
+		return nil, err
+	}
+	if preResult.State == 0 {
+		return nil, fmt.Errorf("prepare invoke failed")
+	}
+	data, err := preResultBytes(preResult)
+	if err != nil {
+		return nil, err
+	}
+	return FindOntIdPublicKey(data, keyIndex)
+}
+
+// FindOntIdPublicKey returns the key at keyIndex in the result of ontid method
+// getPublicKeys, which lists the keys in use only
+func FindOntIdPublicKey(data []byte, keyIndex uint32) (keypair.PublicKey, error) {
+	source := common.NewZeroCopySource(data)
+	for source.Len() > 0 {
+		index, eof := source.NextUint32()
+		if eof {
+			return nil, fmt.Errorf("invalid public keys data")
+		}
+		key, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			return nil, fmt.Errorf("invalid public keys data")
+		}
+		if index == keyIndex {
+			return keypair.DeserializePublicKey(key)
+		}
+	}
+	return nil, fmt.Errorf("key #%d not exist or revoked", keyIndex)
+}
+
+// GetStatus returns the status of credential id by method GetStatus of the claim
+// registry contract, which returns 01 if id is committed, 00 if id is revoked
+func (this *RpcCredentialResolver) GetStatus(contract common.Address, id string) (credential.Status, error) {
+	preResult, err := PrepareInvokeNeoVMContract(contract, []interface{}{"GetStatus", []interface{}{id}})
+	if err != nil {
+		return 0, err
+	}
+	if preResult.State == 0 {
+		return 0, fmt.Errorf("prepare invoke failed")
+	}
+	data, err := preResultBytes(preResult)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case len(data) == 0:
+		return credential.STATUS_NOT_FOUND, nil
+	case data[len(data)-1] == 1:
+		return credential.STATUS_VALID, nil
+	case data[len(data)-1] == 0:
+		return credential.STATUS_REVOKED, nil
+	}
+	return 0, fmt.Errorf("unknown credential status %x", data)
+}
+
+// CommitCredentialTx returns a transaction recording credential id issued by issuer to
+// holder in the claim registry contract, which is signed by key at index of issuer
+func CommitCredentialTx(gasPrice, gasLimit uint64, contract common.Address, id, issuer string, index uint32,
+	holder string) (*types.MutableTransaction, error) {
+	return httpcom.NewNeovmInvokeTransaction(gasPrice, gasLimit, contract,
+		[]interface{}{"Commit", []interface{}{id, issuer, index, holder}})
+}
+
+// RevokeCredentialTx returns a transaction revoking credential id in the claim registry
+// contract, which is signed by key at index of ontId, the issuer or holder
+func RevokeCredentialTx(gasPrice, gasLimit uint64, contract common.Address, id, ontId string,
+	index uint32) (*types.MutableTransaction, error) {
+	return httpcom.NewNeovmInvokeTransaction(gasPrice, gasLimit, contract,
+		[]interface{}{"Revoke", []interface{}{id, ontId, index}})
+}
+
+func preResultBytes(preResult *httpcom.PreExecuteResult) ([]byte, error) {
+	hexStr, ok := preResult.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid result type")
+	}
+	data, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	return data, nil
+}
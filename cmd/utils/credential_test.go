@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOntIdPublicKey(t *testing.T) {
+	acc1, acc3 := account.NewAccount(""), account.NewAccount("")
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(1)
+	sink.WriteVarBytes(keypair.SerializePublicKey(acc1.PublicKey))
+	sink.WriteUint32(3)
+	sink.WriteVarBytes(keypair.SerializePublicKey(acc3.PublicKey))
+
+	pub, err := FindOntIdPublicKey(sink.Bytes(), 3)
+	assert.Nil(t, err)
+	assert.True(t, keypair.ComparePublicKey(acc3.PublicKey, pub))
+	_, err = FindOntIdPublicKey(sink.Bytes(), 2)
+	assert.NotNil(t, err)
+	_, err = FindOntIdPublicKey(sink.Bytes()[:10], 3)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,403 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/go-ethereum/accounts/abi"
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/go-ethereum/common/hexutil"
+	ethmath "github.com/qbyyf/go-ethereum/common/math"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/go-ethereum/rlp"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common/constants"
+)
+
+const (
+	PRECISION_ONG_EVM = constants.ONG_DECIMALS_V2
+	ETH_TRANSFER_GAS  = 21000
+
+	// CONTRACT_VM_TYPE_EVM is the --vmtype of contract deploy command to deploy EVM contract by EIP155 transaction
+	CONTRACT_VM_TYPE_EVM = 4
+)
+
+// GetEthPrivateKey returns the secp256k1 private key of account to sign EIP155 transaction
+func GetEthPrivateKey(signer *account.Account) (*ecdsa.PrivateKey, error) {
+	var key *ecdsa.PrivateKey
+	switch pri := signer.PrivateKey.(type) {
+	case *ec.PrivateKey:
+		key = pri.PrivateKey
+	case *ec.EthereumPrivateKey:
+		key = pri.PrivateKey
+	default:
+		return nil, fmt.Errorf("account:%s is not a secp256k1 account", signer.Address.ToBase58())
+	}
+	if label, err := keypair.GetCurveLabel(key.Curve); err != nil || label != keypair.SECP256K1 {
+		return nil, fmt.Errorf("account:%s is not a secp256k1 account", signer.Address.ToBase58())
+	}
+	return crypto.ToECDSA(ethmath.PaddedBigBytes(key.D, 32))
+}
+
+// GetEthAddress returns the EVM address of secp256k1 public key
+func GetEthAddress(pubKey keypair.PublicKey) (ethcommon.Address, error) {
+	var key *ecdsa.PublicKey
+	switch pub := pubKey.(type) {
+	case *ec.PublicKey:
+		key = pub.PublicKey
+	case *ec.EthereumPublicKey:
+		key = pub.PublicKey
+	default:
+		return ethcommon.Address{}, fmt.Errorf("not a secp256k1 public key")
+	}
+	if label, err := keypair.GetCurveLabel(key.Curve); err != nil || label != keypair.SECP256K1 {
+		return ethcommon.Address{}, fmt.Errorf("not a secp256k1 public key")
+	}
+	return crypto.PubkeyToAddress(*key), nil
+}
+
+// ParseEthAddress parses EVM address in hex
+func ParseEthAddress(address string) (ethcommon.Address, error) {
+	if !ethcommon.IsHexAddress(address) {
+		return ethcommon.Address{}, fmt.Errorf("invalid EVM address:%s", address)
+	}
+	return ethcommon.HexToAddress(address), nil
+}
+
+// ParseEthAmount return raw float string of ONG to wei with 18 decimals of EVM
+// For example 1.5 => 1500000000000000000
+func ParseEthAmount(rawAmount string) (*big.Int, error) {
+	rawAmount = strings.TrimSpace(rawAmount)
+	if rawAmount == "" {
+		return big.NewInt(0), nil
+	}
+	parts := strings.Split(rawAmount, ".")
+	if len(parts) > 2 || len(parts[0]) == 0 && (len(parts) == 1 || len(parts[1]) == 0) {
+		return nil, fmt.Errorf("invalid amount:%s", rawAmount)
+	}
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if len(frac) > PRECISION_ONG_EVM {
+		return nil, fmt.Errorf("amount:%s has more than %d decimals", rawAmount, PRECISION_ONG_EVM)
+	}
+	frac += strings.Repeat("0", PRECISION_ONG_EVM-len(frac))
+	amount, ok := new(big.Int).SetString(parts[0]+frac, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount:%s", rawAmount)
+	}
+	return amount, nil
+}
+
+// FormatEthAmount return wei with 18 decimals of EVM to raw float string of ONG
+func FormatEthAmount(amount *big.Int) string {
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(PRECISION_ONG_EVM), nil)
+	intPart, fracPart := new(big.Int).QuoRem(amount, divisor, new(big.Int))
+	if fracPart.Sign() == 0 {
+		return intPart.String()
+	}
+	frac := fmt.Sprintf("%018s", fracPart.String())
+	return intPart.String() + "." + strings.TrimRight(frac, "0")
+}
+
+// EthGasPrice return gas price in GWei to wei
+func EthGasPrice(gasPrice uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gasPrice), big.NewInt(constants.GWei))
+}
+
+// NewEthTransferTx return a legacy EIP155 transaction transferring ONG to address, gas price is in GWei
+func NewEthTransferTx(nonce uint64, to ethcommon.Address, amount *big.Int, gasPrice, gasLimit uint64) *ethtypes.Transaction {
+	return ethtypes.NewTransaction(nonce, to, amount, gasLimit, EthGasPrice(gasPrice), nil)
+}
+
+// NewEthInvokeTx return a legacy EIP155 transaction calling contract with data
+func NewEthInvokeTx(nonce uint64, contract ethcommon.Address, amount *big.Int, gasPrice, gasLimit uint64, data []byte) *ethtypes.Transaction {
+	return ethtypes.NewTransaction(nonce, contract, amount, gasLimit, EthGasPrice(gasPrice), data)
+}
+
+// NewEthDeployTx return a legacy EIP155 transaction deploying contract code, which includes the encoded constructor args
+func NewEthDeployTx(nonce uint64, amount *big.Int, gasPrice, gasLimit uint64, code []byte) *ethtypes.Transaction {
+	return ethtypes.NewContractCreation(nonce, amount, gasLimit, EthGasPrice(gasPrice), code)
+}
+
+// SignEthTransaction sign EIP155 transaction with secp256k1 account
+func SignEthTransaction(signer *account.Account, tx *ethtypes.Transaction, chainId uint64) (*ethtypes.Transaction, error) {
+	key, err := GetEthPrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := ethtypes.SignTx(tx, ethtypes.NewEIP155Signer(new(big.Int).SetUint64(chainId)), key)
+	if err != nil {
+		return nil, fmt.Errorf("sign EIP155 transaction error:%s", err)
+	}
+	return signedTx, nil
+}
+
+// EncodeEthTransaction return the raw transaction in hex, which can be sent by eth_sendRawTransaction
+func EncodeEthTransaction(tx *ethtypes.Transaction) (string, error) {
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return "", fmt.Errorf("rlp encode EIP155 transaction error:%s", err)
+	}
+	return hexutil.Encode(raw), nil
+}
+
+// DecodeEthTransaction decode raw EIP155 transaction in hex
+func DecodeEthTransaction(rawTx string) (*ethtypes.Transaction, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(rawTx, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("hex decode raw transaction error:%s", err)
+	}
+	tx := new(ethtypes.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, fmt.Errorf("rlp decode EIP155 transaction error:%s", err)
+	}
+	return tx, nil
+}
+
+// NewEthContractAbi parse Solidity ABI in JSON
+func NewEthContractAbi(abiData []byte) (*abi.ABI, error) {
+	contractAbi, err := abi.JSON(bytes.NewReader(abiData))
+	if err != nil {
+		return nil, fmt.Errorf("parse ABI error:%s", err)
+	}
+	return &contractAbi, nil
+}
+
+// PackEthAbiCall encode method call with Solidity ABI, method is empty for constructor.
+// rawParams is a JSON array, integer can be number or string, bytes are in hex, arrays are JSON arrays
+func PackEthAbiCall(abiData []byte, method string, rawParams string) ([]byte, error) {
+	contractAbi, err := abi.JSON(bytes.NewReader(abiData))
+	if err != nil {
+		return nil, fmt.Errorf("parse ABI error:%s", err)
+	}
+	inputs := contractAbi.Constructor.Inputs
+	if method != "" {
+		m, ok := contractAbi.Methods[method]
+		if !ok {
+			return nil, fmt.Errorf("method:%s not found in ABI", method)
+		}
+		inputs = m.Inputs
+	}
+	var params []interface{}
+	if strings.TrimSpace(rawParams) != "" {
+		decoder := json.NewDecoder(strings.NewReader(rawParams))
+		decoder.UseNumber()
+		if err := decoder.Decode(&params); err != nil {
+			return nil, fmt.Errorf("params should be a JSON array, error:%s", err)
+		}
+	}
+	if len(params) != len(inputs) {
+		return nil, fmt.Errorf("method:%s expects %d params, got %d", method, len(inputs), len(params))
+	}
+	args := make([]interface{}, 0, len(params))
+	for i, input := range inputs {
+		arg, err := parseEthAbiParam(input.Type, params[i])
+		if err != nil {
+			return nil, fmt.Errorf("param %d %s error:%s", i, input.Name, err)
+		}
+		args = append(args, arg)
+	}
+	return contractAbi.Pack(method, args...)
+}
+
+func parseEthAbiParam(t abi.Type, raw interface{}) (interface{}, error) {
+	v, err := parseEthAbiValue(t, raw)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+func parseEthAbiValue(t abi.Type, raw interface{}) (reflect.Value, error) {
+	switch t.T {
+	case abi.AddressTy:
+		str, ok := raw.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%s should be a string", t.String())
+		}
+		address, err := ParseEthAddress(str)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(address), nil
+	case abi.BoolTy:
+		switch b := raw.(type) {
+		case bool:
+			return reflect.ValueOf(b), nil
+		case string:
+			if b == "true" || b == "false" {
+				return reflect.ValueOf(b == "true"), nil
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("%v is not a bool", raw)
+	case abi.StringTy:
+		str, ok := raw.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%s should be a string", t.String())
+		}
+		return reflect.ValueOf(str), nil
+	case abi.BytesTy, abi.FixedBytesTy:
+		str, ok := raw.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%s should be a hex string", t.String())
+		}
+		data, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("hex decode %s error:%s", t.String(), err)
+		}
+		if t.T == abi.BytesTy {
+			return reflect.ValueOf(data), nil
+		}
+		if len(data) > t.Size {
+			return reflect.Value{}, fmt.Errorf("%s is longer than %d bytes", str, t.Size)
+		}
+		value := reflect.New(t.GetType()).Elem()
+		reflect.Copy(value, reflect.ValueOf(data))
+		return value, nil
+	case abi.IntTy, abi.UintTy:
+		var str string
+		switch n := raw.(type) {
+		case json.Number:
+			str = n.String()
+		case string:
+			str = n
+		default:
+			return reflect.Value{}, fmt.Errorf("%v is not an integer", raw)
+		}
+		num, ok := new(big.Int).SetString(str, 0)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%s is not an integer", str)
+		}
+		if t.T == abi.UintTy && (num.Sign() < 0 || num.BitLen() > t.Size) ||
+			t.T == abi.IntTy && num.BitLen() > t.Size-1 && !(num.Sign() < 0 && isMinInt(num, t.Size)) {
+			return reflect.Value{}, fmt.Errorf("%s overflows %s", str, t.String())
+		}
+		typ := t.GetType()
+		switch typ.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return reflect.ValueOf(num.Int64()).Convert(typ), nil
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.ValueOf(num.Uint64()).Convert(typ), nil
+		default:
+			return reflect.ValueOf(num), nil
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%s should be a JSON array", t.String())
+		}
+		var value reflect.Value
+		if t.T == abi.SliceTy {
+			value = reflect.MakeSlice(t.GetType(), len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("%s expects %d items, got %d", t.String(), t.Size, len(items))
+			}
+			value = reflect.New(t.GetType()).Elem()
+		}
+		for i, item := range items {
+			elem, err := parseEthAbiValue(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("item %d error:%s", i, err)
+			}
+			value.Index(i).Set(elem)
+		}
+		return value, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type:%s", t.String())
+	}
+}
+
+func isMinInt(num *big.Int, size int) bool {
+	min := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), uint(size-1)))
+	return num.Cmp(min) == 0
+}
+
+// GetEthChainId return the EIP155 chain id of the node
+func GetEthChainId() (uint64, error) {
+	data, ontErr := sendEthRpcRequest("eth_chainId", []interface{}{})
+	if ontErr != nil {
+		return 0, ontErr.Error
+	}
+	var chainId hexutil.Uint64
+	if err := json.Unmarshal(data, &chainId); err != nil {
+		return 0, fmt.Errorf("json.Unmarshal chain id:%s error:%s", data, err)
+	}
+	return uint64(chainId), nil
+}
+
+// GetEthNonce return the next nonce of address, including the pending transactions in tx pool
+func GetEthNonce(address ethcommon.Address) (uint64, error) {
+	data, ontErr := sendEthRpcRequest("eth_getTransactionCount", []interface{}{address.Hex(), "pending"})
+	if ontErr != nil {
+		return 0, ontErr.Error
+	}
+	var nonce hexutil.Uint64
+	if err := json.Unmarshal(data, &nonce); err != nil {
+		return 0, fmt.Errorf("json.Unmarshal nonce:%s error:%s", data, err)
+	}
+	return uint64(nonce), nil
+}
+
+// EstimateEthGas return the gas limit estimated by the node, to is nil for deployment
+func EstimateEthGas(from ethcommon.Address, to *ethcommon.Address, amount *big.Int, data []byte) (uint64, error) {
+	args := map[string]interface{}{
+		"from":  from.Hex(),
+		"value": (*hexutil.Big)(amount),
+		"data":  hexutil.Bytes(data),
+	}
+	if to != nil {
+		args["to"] = to.Hex()
+	}
+	res, ontErr := sendEthRpcRequest("eth_estimateGas", []interface{}{args})
+	if ontErr != nil {
+		return 0, ontErr.Error
+	}
+	var gas hexutil.Uint64
+	if err := json.Unmarshal(res, &gas); err != nil {
+		return 0, fmt.Errorf("json.Unmarshal gas:%s error:%s", res, err)
+	}
+	return uint64(gas), nil
+}
+
+// SendEthRawTransaction send raw EIP155 transaction in hex, and return the transaction hash
+func SendEthRawTransaction(rawTx string) (string, error) {
+	data, ontErr := sendEthRpcRequest("eth_sendRawTransaction", []interface{}{rawTx})
+	if ontErr != nil {
+		return "", ontErr.Error
+	}
+	var hash string
+	if err := json.Unmarshal(data, &hash); err != nil {
+		return "", fmt.Errorf("json.Unmarshal hash:%s error:%s", data, err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/ontology/account"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEthAmount(t *testing.T) {
+	amount, err := ParseEthAmount("1.5")
+	assert.Nil(t, err)
+	assert.Equal(t, "1500000000000000000", amount.String())
+	amount, err = ParseEthAmount("0.000000000000000001")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", amount.String())
+	amount, err = ParseEthAmount("")
+	assert.Nil(t, err)
+	assert.Equal(t, "0", amount.String())
+
+	for _, invalid := range []string{".", "1.2.3", "-1", "abc", "0.0000000000000000001"} {
+		_, err = ParseEthAmount(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+
+	for _, value := range []string{"0", "1", "1.5", "1000000000.000000000000000001"} {
+		amount, err = ParseEthAmount(value)
+		assert.Nil(t, err)
+		assert.Equal(t, value, FormatEthAmount(amount))
+	}
+}
+
+func TestPackEthAbiCall(t *testing.T) {
+	abiData := []byte(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}]`)
+	data, err := PackEthAbiCall(abiData, "transfer", `["0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", "1000"]`)
+	assert.Nil(t, err)
+	assert.Equal(t, "a9059cbb"+
+		"0000000000000000000000005b38da6a701c568545dcfcb03fcb875f56beddc4"+
+		"00000000000000000000000000000000000000000000000000000000000003e8", hex.EncodeToString(data))
+
+	_, err = PackEthAbiCall(abiData, "transfer", `["0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"]`)
+	assert.NotNil(t, err)
+	_, err = PackEthAbiCall(abiData, "approve", `[]`)
+	assert.NotNil(t, err)
+}
+
+func TestSignEthTransaction(t *testing.T) {
+	acc := account.NewAccount("")
+	to, err := ParseEthAddress("0x5B38Da6a701c568545dCfcB03FcB875f56beddC4")
+	assert.Nil(t, err)
+	tx := NewEthTransferTx(1, to, EthGasPrice(1), 2500, ETH_TRANSFER_GAS)
+	_, err = SignEthTransaction(acc, tx, 5851)
+	assert.NotNil(t, err, "P256 account should not sign EIP155 transaction")
+
+	priv, pub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.SECP256K1)
+	assert.Nil(t, err)
+	acc = &account.Account{
+		PrivateKey: priv,
+		PublicKey:  pub,
+		SigScheme:  signature.SHA256withECDSA,
+	}
+	signedTx, err := SignEthTransaction(acc, tx, 5851)
+	assert.Nil(t, err)
+	rawTx, err := EncodeEthTransaction(signedTx)
+	assert.Nil(t, err)
+	decoded, err := DecodeEthTransaction(rawTx)
+	assert.Nil(t, err)
+	assert.Equal(t, signedTx.Hash(), decoded.Hash())
+
+	from, err := ethtypes.Sender(ethtypes.NewEIP155Signer(decoded.ChainId()), decoded)
+	assert.Nil(t, err)
+	expect, err := GetEthAddress(pub)
+	assert.Nil(t, err)
+	assert.Equal(t, expect, from)
+	assert.Equal(t, uint64(5851), decoded.ChainId().Uint64())
+}
@@ -21,6 +21,7 @@ package utils
 import (
 	"strings"
 
+	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
 	"github.com/qbyyf/ontology/smartcontract/service/neovm"
@@ -32,6 +33,20 @@ const (
 	DEFAULT_ABI_PATH      = "./abi"
 	DEFAULT_EXPORT_HEIGHT = 0
 	DEFAULT_WALLET_PATH   = "./wallet_data"
+
+	DEFAULT_WAIT_TX_TIMEOUT = 60
+
+	DEFAULT_DB_VERIFY_MAX_ERRORS = 100
+
+	FORK_NETWORK_NAME = "fork"
+
+	DEFAULT_DEVNET_DATA_DIR    = "./DevnetChain"
+	DEFAULT_DEVNET_MNEMONIC    = "test test test test test test test test test test test junk"
+	DEFAULT_DEVNET_ACCOUNT_NUM = 10
+	DEFAULT_DEVNET_ONT_BALANCE = "1000000"
+	DEFAULT_DEVNET_ONG_BALANCE = "10000"
+	DEFAULT_DEVNET_PASSWORD    = "devnet"
+	DEFAULT_DEVNET_NODES       = 1
 )
 
 var (
@@ -77,6 +92,19 @@ var (
 		Usage: "Stop import block `<height>` of the import.",
 		Value: DEFAULT_EXPORT_HEIGHT,
 	}
+	DbVerifyFullFlag = cli.BoolFlag{
+		Name:  "full",
+		Usage: "Re-execute all blocks in a scratch ledger and compare the state transition hash of each block",
+	}
+	DbVerifyReportFlag = cli.StringFlag{
+		Name:  "report",
+		Usage: "Write the JSON report to `<file>`, print it to stdout if not set",
+	}
+	DbVerifyMaxErrorsFlag = cli.IntFlag{
+		Name:  "max-errors",
+		Usage: "Max `<number>` of errors recorded in the report for each check",
+		Value: DEFAULT_DB_VERIFY_MAX_ERRORS,
+	}
 	DataDirFlag = cli.StringFlag{
 		Name:  "data-dir",
 		Usage: "Block data storage `<path>`",
@@ -97,6 +125,31 @@ var (
 		Usage: "Max transaction `<number>` in block",
 		Value: config.DEFAULT_MAX_TX_IN_BLOCK,
 	}
+	RemoteSignerFlag = cli.StringFlag{
+		Name:  "remote-signer",
+		Usage: "Remote signing daemon `<url>` of consensus key, or of the signing key of transactions. The key in wallet is not used if set",
+	}
+	RemoteSignerKeyFlag = cli.StringFlag{
+		Name:  "remote-signer-key",
+		Usage: "Key `<id>` in remote signing daemon",
+	}
+	RemoteSignerTokenFlag = cli.StringFlag{
+		Name:   "remote-signer-token",
+		Usage:  "Bearer `<token>` of remote signing daemon, requires https",
+		EnvVar: "ONTOLOGY_REMOTE_SIGNER_TOKEN",
+	}
+	RemoteSignerCAFlag = cli.StringFlag{
+		Name:  "remote-signer-ca",
+		Usage: "CA certificate `<file>` in PEM to verify remote signing daemon. System roots are used if not set",
+	}
+	RemoteSignerCertFlag = cli.StringFlag{
+		Name:  "remote-signer-cert",
+		Usage: "Client certificate `<file>` in PEM for remote signing daemon",
+	}
+	RemoteSignerCertKeyFlag = cli.StringFlag{
+		Name:  "remote-signer-cert-key",
+		Usage: "Private key `<file>` in PEM of client certificate for remote signing daemon",
+	}
 	GasLimitFlag = cli.Uint64Flag{
 		Name:  "gaslimit",
 		Usage: "Min gas limit `<value>` of transaction to be accepted by tx pool.",
@@ -117,6 +170,67 @@ var (
 		Usage: "Block-out `<time>`(s) in test mode.",
 		Value: config.DEFAULT_GEN_BLOCK_TIME,
 	}
+	ForkRpcFlag = cli.StringFlag{
+		Name:  "fork-rpc",
+		Usage: "Json rpc `<address>` of the remote node to fork from in test mode, e.g. http://127.0.0.1:20336. The states of remote chain are fetched when they are read at the first time",
+	}
+	ForkEthRpcFlag = cli.StringFlag{
+		Name:  "fork-ethrpc",
+		Usage: "EVM rpc `<address>` of the remote node to fork from, e.g. http://127.0.0.1:20339. The EVM accounts and storages are not forked if it is not set",
+	}
+	ForkBlockFlag = cli.UintFlag{
+		Name:  "fork-block",
+		Usage: "Height of the remote `<block>` to fork from, all the states are read at this block. The current block of remote node is used if it is 0. The remote node must keep the states of this block, so it should not advance",
+	}
+	ForkImpersonateFlag = cli.StringFlag{
+		Name:  "fork-impersonate",
+		Usage: "Comma separated `<addresses>` which are witnessed by every transaction in forked chain, so they can be used without signatures",
+	}
+
+	//devnet setting
+	DevnetDataDirFlag = cli.StringFlag{
+		Name:  "data-dir",
+		Usage: "Block and wallet data `<path>` of devnet",
+		Value: DEFAULT_DEVNET_DATA_DIR,
+	}
+	DevnetMnemonicFlag = cli.StringFlag{
+		Name:  "mnemonic",
+		Usage: "BIP-39 `<mnemonic>` to derive the bookkeeper and accounts of devnet",
+		Value: DEFAULT_DEVNET_MNEMONIC,
+	}
+	DevnetAccountNumFlag = cli.UintFlag{
+		Name:  "accounts",
+		Usage: "`<number>` of funded accounts of devnet",
+		Value: DEFAULT_DEVNET_ACCOUNT_NUM,
+	}
+	DevnetOntBalanceFlag = cli.StringFlag{
+		Name:  "ont-balance",
+		Usage: "ONT `<amount>` funded to each account",
+		Value: DEFAULT_DEVNET_ONT_BALANCE,
+	}
+	DevnetOngBalanceFlag = cli.StringFlag{
+		Name:  "ong-balance",
+		Usage: "ONG `<amount>` funded to the ontology address and the EVM address of each account",
+		Value: DEFAULT_DEVNET_ONG_BALANCE,
+	}
+	DevnetBlockTimeFlag = cli.UintFlag{
+		Name:  "block-time",
+		Usage: "Block-out `<time>`(s) of devnet. 0 means generating a block as soon as a transaction arrives",
+	}
+	DevnetPasswordFlag = cli.StringFlag{
+		Name:  "password",
+		Usage: "`<password>` of devnet wallet",
+		Value: DEFAULT_DEVNET_PASSWORD,
+	}
+	DevnetResetFlag = cli.BoolFlag{
+		Name:  "reset",
+		Usage: "Remove the chain and wallet data of devnet before starting",
+	}
+	DevnetNodesFlag = cli.UintFlag{
+		Name:  "nodes",
+		Usage: "`<number>` of consensus nodes of devnet. 1 runs a solo node, 7 or more run a vbft network in one process",
+		Value: DEFAULT_DEVNET_NODES,
+	}
 
 	//P2P setting
 	ReservedPeersOnlyFlag = cli.BoolFlag{
@@ -289,6 +403,47 @@ var (
 		Name:  "wif",
 		Usage: "Import WIF keys from the source file specified by --source option",
 	}
+	AccountKeystoreFlag = cli.BoolFlag{
+		Name:  "keystore",
+		Usage: "Import Ethereum V3 keystore from the source file specified by --source option, or export secp256k1 account to Ethereum V3 keystore file",
+	}
+	AccountScryptNFlag = cli.UintFlag{
+		Name:  "scrypt-n",
+		Value: keypair.DEFAULT_N,
+		Usage: "Scrypt CPU/memory cost `<N>` to encrypt private keys, should be a power of 2",
+	}
+	AccountScryptRFlag = cli.UintFlag{
+		Name:  "scrypt-r",
+		Value: keypair.DEFAULT_R,
+		Usage: "Scrypt block size `<r>` to encrypt private keys",
+	}
+	AccountScryptPFlag = cli.UintFlag{
+		Name:  "scrypt-p",
+		Value: keypair.DEFAULT_P,
+		Usage: "Scrypt parallelization `<p>` to encrypt private keys",
+	}
+	AccountDecryptFlag = cli.BoolFlag{
+		Name:  "decrypt",
+		Usage: "Decrypt private keys of accounts with password, to verify them",
+	}
+	AccountDeriveFlag = cli.BoolFlag{
+		Name:  "derive",
+		Usage: "Derive account from HD seed of wallet by BIP-44 path. A new mnemonic will be created if wallet has no HD seed",
+	}
+	AccountMnemonicFlag = cli.BoolFlag{
+		Name:  "mnemonic",
+		Usage: "Restore HD seed of wallet from BIP-39 mnemonic, and derive accounts of it",
+	}
+	AccountMnemonicWordsFlag = cli.UintFlag{
+		Name:  "words",
+		Value: 12,
+		Usage: "Word `<count>` of the new mnemonic, should be 12, 15, 18, 21 or 24",
+	}
+	AccountCoinTypeFlag = cli.StringFlag{
+		Name:  "coin",
+		Value: "ont",
+		Usage: "Derive account with BIP-44 coin `<type>`, ont (P-256 key) or eth (secp256k1 key)",
+	}
 	AccountMultiMFlag = cli.UintFlag{
 		Name:  "m",
 		Usage: "Min signature `<number>` of multi signature address",
@@ -298,6 +453,22 @@ var (
 		Name:  "pubkey",
 		Usage: "Pub key list of multi `<addresses>`, separate addreses with comma `,`",
 	}
+	MultiSigFileFlag = cli.StringFlag{
+		Name:  "file",
+		Usage: "Partially signed multi signature transaction `<file>` to write",
+	}
+	NeoVMAbiFileFlag = cli.StringFlag{
+		Name:  "neovm-abi",
+		Usage: "Abi `<file>` of NeoVM contract, to decode the params of contract invoke",
+	}
+	EthAbiFileFlag = cli.StringFlag{
+		Name:  "eth-abi",
+		Usage: "Solidity ABI `<file>` of EVM contract, to decode the params of contract call",
+	}
+	AssumeYesFlag = cli.BoolFlag{
+		Name:  "yes,y",
+		Usage: "Sign without confirmation",
+	}
 	IdentityFlag = cli.BoolFlag{
 		Name:  "ontid",
 		Usage: "create an ONT ID instead of account",
@@ -310,7 +481,7 @@ var (
 	}
 	ContractVmTypeFlag = cli.UintFlag{
 		Name:  "vmtype",
-		Usage: "The Contract type: 1 for Neovm ,3 for Wasmvm",
+		Usage: "The Contract type: 1 for Neovm ,3 for Wasmvm, 4 for EVM (deploy only)",
 		Value: 1,
 	}
 	ContractCodeFileFlag = cli.StringFlag{
@@ -356,6 +527,33 @@ var (
 		Name:  "return",
 		Usage: "Return `<type>` of contract. bytearray(hexstring), string, int, boolean",
 	}
+	ContractGasLimitFlag = cli.Uint64Flag{
+		Name:  "gaslimit",
+		Usage: "Gas limit of the transaction. Default is estimated by pre-execution",
+	}
+	ContractCrossVMFlag = cli.BoolFlag{
+		Name:  "crossvm",
+		Usage: "Encode params of Wasmvm contract by crossvm codec",
+	}
+	ContractMigrateMethodFlag = cli.StringFlag{
+		Name:  "method",
+		Usage: "Contract `<method>` which calls the migrate syscall",
+		Value: "migrate",
+	}
+	ContractDestroyMethodFlag = cli.StringFlag{
+		Name:  "method",
+		Usage: "Contract `<method>` which calls the destroy syscall",
+		Value: "destroy",
+	}
+	ContractWaitFlag = cli.BoolFlag{
+		Name:  "wait",
+		Usage: "Wait for the transaction to be committed, and print its events",
+	}
+	ContractWaitTimeoutFlag = cli.UintFlag{
+		Name:  "wait-timeout",
+		Usage: "Timeout `<seconds>` of waiting for the transaction",
+		Value: DEFAULT_WAIT_TX_TIMEOUT,
+	}
 
 	//information cmd settings
 	BlockHashInfoFlag = cli.StringFlag{
@@ -459,6 +657,120 @@ var (
 		Usage: "Force to send transaction",
 	}
 
+	//Governance setting
+	GovernancePeerPubkeyFlag = cli.StringFlag{
+		Name:  "peer-pubkey",
+		Usage: "Peer public `<key>` in hex, separate multiple peers with comma `,`",
+	}
+	GovernancePosFlag = cli.StringFlag{
+		Name:  "pos",
+		Usage: "ONT `<amount>` for each peer, separate multiple amounts with comma `,`",
+	}
+	GovernancePeerCostFlag = cli.UintFlag{
+		Name:  "peer-cost",
+		Usage: "Percentage `<number>` (0~100) of init pos income the node does not share with authorize users",
+	}
+
+	//ONT ID setting
+	OntIdKeyIndexFlag = cli.UintFlag{
+		Name:  "key-index",
+		Usage: "Key `<index>` of the ONT ID signing the transaction",
+		Value: 1,
+	}
+	OntIdPublicKeyFlag = cli.StringFlag{
+		Name:  "public-key",
+		Usage: "Public `<key>` in hex to add to or remove from the ONT ID",
+	}
+	OntIdControllerFlag = cli.StringFlag{
+		Name:  "controller",
+		Usage: "Controller `<ontid>` of the ONT ID",
+	}
+	OntIdRecoveryFlag = cli.StringFlag{
+		Name:  "recovery",
+		Usage: "Recovery `<ontids>` of the ONT ID, separate multiple ONT IDs with comma `,`",
+	}
+	OntIdThresholdFlag = cli.UintFlag{
+		Name:  "threshold",
+		Usage: "Min signature `<number>` of recovery members",
+		Value: 1,
+	}
+	OntIdAttrKeyFlag = cli.StringFlag{
+		Name:  "attr-key",
+		Usage: "Attribute `<key>` of the ONT ID",
+	}
+	OntIdAttrTypeFlag = cli.StringFlag{
+		Name:  "attr-type",
+		Usage: "Attribute value `<type>` of the ONT ID",
+		Value: "string",
+	}
+	OntIdAttrValueFlag = cli.StringFlag{
+		Name:  "attr-value",
+		Usage: "Attribute `<value>` of the ONT ID",
+	}
+	OntIdServiceIdFlag = cli.StringFlag{
+		Name:  "service-id",
+		Usage: "Service `<id>` of the ONT ID",
+	}
+	OntIdServiceTypeFlag = cli.StringFlag{
+		Name:  "service-type",
+		Usage: "Service `<type>` of the ONT ID",
+	}
+	OntIdServiceEndpointFlag = cli.StringFlag{
+		Name:  "service-endpoint",
+		Usage: "Service `<endpoint>` of the ONT ID",
+	}
+
+	//EIP155 transaction setting
+	EthNonceFlag = cli.Uint64Flag{
+		Name:  "nonce",
+		Usage: "Nonce `<number>` of the EIP155 transaction. Default is the next nonce of the account in tx pool",
+	}
+	EthChainIdFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "EIP155 chain `<id>`. Default is the chain id of the node",
+	}
+	EthGasPriceFlag = cli.Uint64Flag{
+		Name:  "gasprice",
+		Usage: "Gas price of the EIP155 transaction in GWei",
+		Value: config.DEFAULT_GAS_PRICE,
+	}
+	EthGasLimitFlag = cli.Uint64Flag{
+		Name:  "gaslimit",
+		Usage: "Gas limit of the EIP155 transaction. Default is estimated by the node",
+	}
+	EthToFlag = cli.StringFlag{
+		Name:  "to",
+		Usage: "Transfer-in EVM `<address>` in hex",
+	}
+	EthAmountFlag = cli.StringFlag{
+		Name:  "amount",
+		Usage: "ONG `<amount>` to transfer or pay to the contract. Float number",
+	}
+	EthContractFlag = cli.StringFlag{
+		Name:  "contract",
+		Usage: "EVM contract `<address>` in hex",
+	}
+	EthAbiFlag = cli.StringFlag{
+		Name:  "abi",
+		Usage: "Solidity ABI `<file>` of the contract",
+	}
+	EthMethodFlag = cli.StringFlag{
+		Name:  "method",
+		Usage: "Contract `<method>` to call",
+	}
+	EthParamsFlag = cli.StringFlag{
+		Name:  "params",
+		Usage: "Contract `<params>` in JSON array, e.g. [\"0x3b6f8e0c...\",\"100\"]. Integers can be strings, bytes are in hex",
+	}
+	EthDataFlag = cli.StringFlag{
+		Name:  "data",
+		Usage: "Call `<data>` in hex, instead of --method and --params",
+	}
+	EthSignOnlyFlag = cli.BoolFlag{
+		Name:  "sign-only",
+		Usage: "Only print the signed raw transaction, without sending it",
+	}
+
 	//Cli setting
 	CliAddressFlag = cli.StringFlag{
 		Name:  "cliaddress",
@@ -480,6 +792,26 @@ var (
 		Usage: "Wallet data `<path>`",
 		Value: DEFAULT_WALLET_PATH,
 	}
+	CliPolicyFileFlag = cli.StringFlag{
+		Name:  "policy",
+		Usage: "Signing policy `<file>`, which defines api clients and the rules of signing accounts",
+	}
+	CliAuditLogFlag = cli.StringFlag{
+		Name:  "auditlog",
+		Usage: "Append-only audit log `<file>` of signing requests",
+	}
+	CliTLSCertFlag = cli.StringFlag{
+		Name:  "tlscert",
+		Usage: "TLS certificate `<file>` of sig server",
+	}
+	CliTLSKeyFlag = cli.StringFlag{
+		Name:  "tlskey",
+		Usage: "TLS private key `<file>` of sig server",
+	}
+	CliTLSClientCAFlag = cli.StringFlag{
+		Name:  "tlsclientca",
+		Usage: "CA certificate `<file>` to verify client certificates. Client certificate is required if set",
+	}
 
 	//Export setting
 	ExportFileFlag = cli.StringFlag{
@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	httpcom "github.com/qbyyf/ontology/http/base/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/governance"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const VERSION_CONTRACT_GOVERNANCE = byte(0)
+
+// GovernanceTx return a transaction invoking method of governance contract with param
+func GovernanceTx(gasPrice, gasLimit uint64, method string, param interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(utils.GovernanceContractAddress, VERSION_CONTRACT_GOVERNANCE,
+		method, []interface{}{param})
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	return NewInvokeTransaction(gasPrice, gasLimit, invokeCode), nil
+}
+
+// GetStorage return the raw storage value of key in contract, nil if not found
+func GetStorage(contract common.Address, key []byte) ([]byte, error) {
+	data, ontErr := sendRpcRequest("getstorage", []interface{}{contract.ToHexString(), hex.EncodeToString(key)})
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	hexStr := ""
+	err := json.Unmarshal(data, &hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error:%s", err)
+	}
+	value, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	return value, nil
+}
+
+func GetGovernanceView() (*governance.GovernanceView, error) {
+	data, err := GetStorage(utils.GovernanceContractAddress, []byte(governance.GOVERNANCE_VIEW))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("governance view not found")
+	}
+	view := new(governance.GovernanceView)
+	err = view.Deserialize(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize governance view error:%s", err)
+	}
+	return view, nil
+}
+
+// GetPeerPoolMap return the peer pool of governance contract in view
+func GetPeerPoolMap(view uint32) (*governance.PeerPoolMap, error) {
+	key := append([]byte(governance.PEER_POOL), governance.GetUint32Bytes(view)...)
+	data, err := GetStorage(utils.GovernanceContractAddress, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("peer pool of view %d not found", view)
+	}
+	peerPoolMap := &governance.PeerPoolMap{
+		PeerPoolMap: make(map[string]*governance.PeerPoolItem),
+	}
+	err = peerPoolMap.Deserialization(common.NewZeroCopySource(data))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize peer pool error:%s", err)
+	}
+	return peerPoolMap, nil
+}
+
+// GetStakingInfo return governance staking info of address in base58 code
+// GetStakingInfo return the staking info of address in peers, or in the peers of peer pool if peers is empty
+func GetStakingInfo(address string, peers []string) (*httpcom.StakingInfoRsp, error) {
+	params := []interface{}{address}
+	if len(peers) > 0 {
+		params = append(params, peers)
+	}
+	data, ontErr := sendRpcRequest("getstakinginfo", params)
+	if ontErr != nil {
+		switch ontErr.ErrorCode {
+		case ERROR_INVALID_PARAMS:
+			return nil, fmt.Errorf("invalid address:%s", address)
+		}
+		return nil, ontErr.Error
+	}
+	info := &httpcom.StakingInfoRsp{}
+	err := json.Unmarshal(data, info)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error:%s", err)
+	}
+	return info, nil
+}
+
+// WithdrawablePos return the withdrawable pos in peers of staking info, or in all peers of staking info if peers is empty
+func WithdrawablePos(info *httpcom.StakingInfoRsp, peers []string) ([]string, []uint32, error) {
+	withdrawable := make(map[string]uint64)
+	for _, v := range info.Authorizations {
+		withdrawable[v.PeerPubkey] = v.WithdrawablePos
+	}
+	if len(peers) == 0 {
+		for _, v := range info.Authorizations {
+			if v.WithdrawablePos > 0 {
+				peers = append(peers, v.PeerPubkey)
+			}
+		}
+	}
+	posList := make([]uint32, 0, len(peers))
+	for _, peer := range peers {
+		pos := withdrawable[peer]
+		if pos == 0 {
+			return nil, nil, fmt.Errorf("no withdrawable ont in peer:%s", peer)
+		}
+		if pos > math.MaxUint32 {
+			return nil, nil, fmt.Errorf("withdrawable ont %d in peer:%s exceeds the max pos of one withdraw:%d",
+				pos, peer, uint32(math.MaxUint32))
+		}
+		posList = append(posList, uint32(pos))
+	}
+	return peers, posList, nil
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/payload"
+	httpcom "github.com/qbyyf/ontology/http/base/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/governance"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGovernanceTx(t *testing.T) {
+	param := &governance.AuthorizeForPeerParam{
+		Address:        common.ADDRESS_EMPTY,
+		PeerPubkeyList: []string{"02bcdd278a27e4969d48de95d6b7b086b65b8d1d4ff6509e7a9eab364a76115af7"},
+		PosList:        []uint32{500},
+	}
+	mutTx, err := GovernanceTx(2500, 20000, governance.AUTHORIZE_FOR_PEER, param)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2500), mutTx.GasPrice)
+	assert.Equal(t, uint64(20000), mutTx.GasLimit)
+	// struct{address, [pubkey], [500]}, "authorizeForPeer", governance address, version 0, native invoke syscall
+	code := "00c66b14" + common.ADDRESS_EMPTY.ToHexString() + "6a7cc842" + hex.EncodeToString([]byte(param.PeerPubkeyList[0])) +
+		"51c16a7cc802f40151c16a7cc86c10" + hex.EncodeToString([]byte(governance.AUTHORIZE_FOR_PEER)) +
+		"14" + hex.EncodeToString(utils.GovernanceContractAddress[:]) + "0068" + "16" +
+		hex.EncodeToString([]byte("Ontology.Native.Invoke"))
+	assert.Equal(t, code, hex.EncodeToString(mutTx.Payload.(*payload.InvokeCode).Code))
+}
+
+func TestWithdrawablePos(t *testing.T) {
+	info := &httpcom.StakingInfoRsp{
+		Authorizations: []httpcom.AuthorizeStakingInfo{
+			{PeerPubkey: "peer1", WithdrawablePos: 100},
+			{PeerPubkey: "peer2"},
+			{PeerPubkey: "peer3", WithdrawablePos: 300},
+		},
+	}
+	peers, posList, err := WithdrawablePos(info, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"peer1", "peer3"}, peers)
+	assert.Equal(t, []uint32{100, 300}, posList)
+
+	peers, posList, err = WithdrawablePos(info, []string{"peer3"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"peer3"}, peers)
+	assert.Equal(t, []uint32{300}, posList)
+
+	_, _, err = WithdrawablePos(info, []string{"peer2"})
+	assert.NotNil(t, err)
+
+	// pos over uint32 is rejected instead of truncated
+	info.Authorizations[0].WithdrawablePos = math.MaxUint32 + 1
+	_, _, err = WithdrawablePos(info, []string{"peer1"})
+	assert.NotNil(t, err)
+	info.Authorizations[0].WithdrawablePos = math.MaxUint32
+	_, posList, err = WithdrawablePos(info, []string{"peer1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{math.MaxUint32}, posList)
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/cmd/abi"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
+)
+
+const PARTIAL_TX_VERSION = 1
+
+// PartialSig is a signature of multi-signature transaction collected from one signer
+type PartialSig struct {
+	PubKey  string `json:"pubKey"`
+	SigData string `json:"sigData"`
+}
+
+// PartialTx is a partially signed multi-signature transaction, which is passed among signers until M signatures are collected.
+// Decoded is only for reading, signers always check it against the transaction decoded locally
+type PartialTx struct {
+	Version int           `json:"version"`
+	TxHash  string        `json:"txHash"`
+	Tx      string        `json:"tx"` //unsigned transaction in hex
+	M       uint16        `json:"m"`
+	PubKeys []string      `json:"pubKeys"`
+	Address string        `json:"address"`
+	Sigs    []*PartialSig `json:"sigs"`
+	Decoded *TxInfo       `json:"decoded"`
+
+	tx      *types.MutableTransaction
+	pubKeys []keypair.PublicKey
+}
+
+// NewPartialTx creates partial tx of the M-of-N multi-signature address. The payer of tx must be the multi-signature address,
+// and existing signatures of tx are dropped
+func NewPartialTx(mutTx *types.MutableTransaction, m uint16, pubKeys []keypair.PublicKey, neovmAbi *abi.NeovmContractAbi) (*PartialTx, error) {
+	pkSize := len(pubKeys)
+	if m == 0 || int(m) > pkSize || pkSize <= 1 || pkSize > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
+		return nil, fmt.Errorf("invalid m:%d of %d pub keys", m, pkSize)
+	}
+	addr, err := types.AddressFromMultiPubKeys(pubKeys, int(m))
+	if err != nil {
+		return nil, fmt.Errorf("AddressFromMultiPubKeys error:%s", err)
+	}
+	if mutTx.Payer == common.ADDRESS_EMPTY {
+		mutTx.Payer = addr
+	}
+	if mutTx.Payer != addr {
+		return nil, fmt.Errorf("payer:%s is not the multi-signature address:%s", mutTx.Payer.ToBase58(), addr.ToBase58())
+	}
+	mutTx.Sigs = nil
+	tx, err := mutTx.IntoImmutable()
+	if err != nil {
+		return nil, fmt.Errorf("IntoImmutable error:%s", err)
+	}
+	if tx.TxType == types.EIP155 {
+		return nil, fmt.Errorf("EIP155 transaction cannot be multi-signed")
+	}
+	decoded, err := DecodeTransaction(tx, &TxDecodeAbi{Neovm: neovmAbi})
+	if err != nil {
+		return nil, fmt.Errorf("DecodeTransaction error:%s", err)
+	}
+	sink := common.ZeroCopySink{}
+	tx.Serialization(&sink)
+	ptx := &PartialTx{
+		Version: PARTIAL_TX_VERSION,
+		TxHash:  decoded.TxHash,
+		Tx:      hex.EncodeToString(sink.Bytes()),
+		M:       m,
+		Address: addr.ToBase58(),
+		Sigs:    make([]*PartialSig, 0),
+		Decoded: decoded,
+		tx:      mutTx,
+		pubKeys: pubKeys,
+	}
+	for _, pubKey := range pubKeys {
+		ptx.PubKeys = append(ptx.PubKeys, hex.EncodeToString(keypair.SerializePublicKey(pubKey)))
+	}
+	return ptx, nil
+}
+
+// ParsePartialTx parses partial tx and checks that everything in it matches the transaction:
+// tx hash, multi-signature address, decoded info and every collected signature
+func ParsePartialTx(data []byte, neovmAbi *abi.NeovmContractAbi) (*PartialTx, error) {
+	ptx := &PartialTx{}
+	err := json.Unmarshal(data, ptx)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal PartialTx error:%s", err)
+	}
+	if ptx.Version != PARTIAL_TX_VERSION {
+		return nil, fmt.Errorf("unsupported partial tx version:%d", ptx.Version)
+	}
+	pubKeys := make([]keypair.PublicKey, 0, len(ptx.PubKeys))
+	for _, pk := range ptx.PubKeys {
+		pkData, err := hex.DecodeString(pk)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pub key:%s", pk)
+		}
+		pubKey, err := keypair.DeserializePublicKey(pkData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pub key:%s", pk)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	rawTx, err := hex.DecodeString(ptx.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("tx hex decode error:%s", err)
+	}
+	tx, err := types.TransactionFromRawBytes(rawTx)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionFromRawBytes error:%s", err)
+	}
+	if len(tx.Sigs) != 0 {
+		return nil, fmt.Errorf("tx of partial tx should be unsigned")
+	}
+	mutTx, err := tx.IntoMutable()
+	if err != nil {
+		return nil, fmt.Errorf("IntoMutable error:%s", err)
+	}
+	expected, err := NewPartialTx(mutTx, ptx.M, pubKeys, neovmAbi)
+	if err != nil {
+		return nil, err
+	}
+	if expected.TxHash != ptx.TxHash {
+		return nil, fmt.Errorf("tx hash:%s mismatch, expected:%s", ptx.TxHash, expected.TxHash)
+	}
+	if expected.Address != ptx.Address {
+		return nil, fmt.Errorf("address:%s mismatch, expected:%s", ptx.Address, expected.Address)
+	}
+	//decoded info in file may be modified to fool signers, so it must be the same as what decoded locally
+	decoded, err := json.Marshal(ptx.Decoded)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal decoded error:%s", err)
+	}
+	expectedDecoded, err := json.Marshal(expected.Decoded)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal decoded error:%s", err)
+	}
+	if !bytes.Equal(decoded, expectedDecoded) && !decodedByDifferentAbi(ptx.Decoded, expected.Decoded) {
+		return nil, fmt.Errorf("decoded info mismatches the transaction, the file may be modified")
+	}
+	for _, sig := range ptx.Sigs {
+		if sig == nil {
+			return nil, fmt.Errorf("invalid nil signature")
+		}
+		if err := expected.addSig(sig.PubKey, sig.SigData); err != nil {
+			return nil, err
+		}
+	}
+	return expected, nil
+}
+
+// LoadPartialTx loads partial tx from file, see ParsePartialTx
+func LoadPartialTx(file string, neovmAbi *abi.NeovmContractAbi) (*PartialTx, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read file:%s error:%s", file, err)
+	}
+	return ParsePartialTx(data, neovmAbi)
+}
+
+// Save writes partial tx to file
+func (this *PartialTx) Save(file string) error {
+	data, err := json.MarshalIndent(this, "", "\t")
+	if err != nil {
+		return fmt.Errorf("json.Marshal PartialTx error:%s", err)
+	}
+	err = ioutil.WriteFile(file, data, 0644)
+	if err != nil {
+		return fmt.Errorf("write file:%s error:%s", file, err)
+	}
+	return nil
+}
+
+// GetPubKeys returns the pub keys of multi-signature address
+func (this *PartialTx) GetPubKeys() []keypair.PublicKey {
+	return this.pubKeys
+}
+
+// HasSigned returns whether pub key has signed
+func (this *PartialTx) HasSigned(pubKey keypair.PublicKey) bool {
+	pk := hex.EncodeToString(keypair.SerializePublicKey(pubKey))
+	for _, sig := range this.Sigs {
+		if sig.PubKey == pk {
+			return true
+		}
+	}
+	return false
+}
+
+// IsComplete returns whether M signatures are collected
+func (this *PartialTx) IsComplete() bool {
+	return len(this.Sigs) >= int(this.M)
+}
+
+// Sign adds the signature of signer, which must be one of the pub keys
+func (this *PartialTx) Sign(signer signature.DataSigner) error {
+	if this.HasSigned(signer.PubKey()) {
+		return fmt.Errorf("signer has already signed")
+	}
+	txHash := this.tx.Hash()
+	sigData, err := Sign(txHash.ToArray(), signer)
+	if err != nil {
+		return fmt.Errorf("sign error:%s", err)
+	}
+	return this.addSig(hex.EncodeToString(keypair.SerializePublicKey(signer.PubKey())), hex.EncodeToString(sigData))
+}
+
+// Merge adds the signatures of other partial tx of the same transaction
+func (this *PartialTx) Merge(other *PartialTx) error {
+	if other.TxHash != this.TxHash {
+		return fmt.Errorf("tx hash:%s mismatch, expected:%s", other.TxHash, this.TxHash)
+	}
+	if other.Address != this.Address {
+		return fmt.Errorf("address:%s mismatch, expected:%s", other.Address, this.Address)
+	}
+	for _, sig := range other.Sigs {
+		if this.hasSig(sig) {
+			continue
+		}
+		if err := this.addSig(sig.PubKey, sig.SigData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finalize builds the multi-signed transaction after M signatures are collected
+func (this *PartialTx) Finalize() (*types.Transaction, error) {
+	if !this.IsComplete() {
+		return nil, fmt.Errorf("only %d of %d signatures are collected", len(this.Sigs), this.M)
+	}
+	//signatures are placed in the order of pub keys
+	sigData := make([][]byte, 0, this.M)
+	for _, pk := range this.PubKeys {
+		for _, sig := range this.Sigs {
+			if sig.PubKey == pk && len(sigData) < int(this.M) {
+				data, _ := hex.DecodeString(sig.SigData)
+				sigData = append(sigData, data)
+			}
+		}
+	}
+	mutTx := *this.tx
+	mutTx.Sigs = []types.Sig{{
+		PubKeys: this.pubKeys,
+		M:       this.M,
+		SigData: sigData,
+	}}
+	txHash := mutTx.Hash()
+	err := signature.VerifyMultiSignature(txHash.ToArray(), this.pubKeys, int(this.M), sigData)
+	if err != nil {
+		return nil, fmt.Errorf("VerifyMultiSignature error:%s", err)
+	}
+	tx, err := mutTx.IntoImmutable()
+	if err != nil {
+		return nil, fmt.Errorf("IntoImmutable error:%s", err)
+	}
+	return tx, nil
+}
+
+// decodedByDifferentAbi returns whether the params are decoded with abi on one side and without abi on the other side,
+// in which case the local decoded info is used
+func decodedByDifferentAbi(decoded, expected *TxInfo) bool {
+	if decoded == nil || decoded.Invoke == nil || expected.Invoke == nil {
+		return false
+	}
+	return decoded.Invoke.ByAbi != expected.Invoke.ByAbi
+}
+
+func (this *PartialTx) hasSig(sig *PartialSig) bool {
+	for _, s := range this.Sigs {
+		if s.PubKey == sig.PubKey && s.SigData == sig.SigData {
+			return true
+		}
+	}
+	return false
+}
+
+// addSig checks that the signature is signed by one of the pub keys which has not signed
+func (this *PartialTx) addSig(pk, sigData string) error {
+	pk = strings.ToLower(pk)
+	index := -1
+	for i, p := range this.PubKeys {
+		if p == pk {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("pub key:%s is not one of the multi-signature pub keys", pk)
+	}
+	for _, sig := range this.Sigs {
+		if sig.PubKey == pk {
+			return fmt.Errorf("pub key:%s has more than one signature", pk)
+		}
+	}
+	data, err := hex.DecodeString(sigData)
+	if err != nil {
+		return fmt.Errorf("invalid signature of pub key:%s", pk)
+	}
+	txHash := this.tx.Hash()
+	err = signature.Verify(this.pubKeys[index], txHash.ToArray(), data)
+	if err != nil {
+		return fmt.Errorf("signature of pub key:%s verify error:%s", pk, err)
+	}
+	this.Sigs = append(this.Sigs, &PartialSig{PubKey: pk, SigData: strings.ToLower(sigData)})
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/cmd/abi"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMultiSigAccounts() ([]*account.Account, []keypair.PublicKey) {
+	accs := []*account.Account{account.NewAccount(""), account.NewAccount(""), account.NewAccount("")}
+	pubKeys := []keypair.PublicKey{accs[0].PublicKey, accs[1].PublicKey, accs[2].PublicKey}
+	return accs, pubKeys
+}
+
+func newMultiSigTransfer(t *testing.T, pubKeys []keypair.PublicKey, to common.Address) *types.MutableTransaction {
+	addr, err := types.AddressFromMultiPubKeys(pubKeys, 2)
+	assert.Nil(t, err)
+	mutTx, err := TransferTx(2500, 20000, "ont", addr.ToBase58(), to.ToBase58(), 10)
+	assert.Nil(t, err)
+	return mutTx
+}
+
+func TestPartialTx(t *testing.T) {
+	abi.DefAbiMgr.Init("../abi/native_abi_script")
+	accs, pubKeys := newMultiSigAccounts()
+	mutTx := newMultiSigTransfer(t, pubKeys, accs[0].Address)
+	ptx, err := NewPartialTx(mutTx, 2, pubKeys, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "invokeNeo", ptx.Decoded.TxType)
+	invoke := ptx.Decoded.Invoke
+	assert.Equal(t, INVOKE_VM_NATIVE, invoke.VM)
+	assert.Equal(t, "transfer", invoke.Method)
+	assert.True(t, invoke.ByAbi)
+	states := invoke.Params[0].Value.([]*DecodedParam)
+	state := states[0].Value.([]*DecodedParam)
+	assert.Equal(t, ptx.Address, state[0].Value)
+	assert.Equal(t, accs[0].Address.ToBase58(), state[1].Value)
+	assert.Equal(t, "10", state[2].Value)
+
+	//signers sign their own copies, then merge
+	assert.Nil(t, ptx.Sign(accs[0]))
+	assert.NotNil(t, ptx.Sign(accs[0]))
+	_, err = ptx.Finalize()
+	assert.NotNil(t, err)
+	data, err := json.Marshal(ptx)
+	assert.Nil(t, err)
+	other, err := ParsePartialTx(data, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, other.Sign(accs[2]))
+	assert.NotNil(t, other.Sign(account.NewAccount("")))
+	assert.Nil(t, ptx.Merge(other))
+	assert.Equal(t, 2, len(ptx.Sigs))
+	assert.True(t, ptx.IsComplete())
+
+	tx, err := ptx.Finalize()
+	assert.Nil(t, err)
+	hash := tx.Hash()
+	assert.Equal(t, ptx.TxHash, hash.ToHexString())
+	sig, err := tx.Sigs[0].GetSig()
+	assert.Nil(t, err)
+	assert.Nil(t, signature.VerifyMultiSignature(hash.ToArray(), sig.PubKeys, int(sig.M), sig.SigData))
+}
+
+func TestPartialTxTamper(t *testing.T) {
+	abi.DefAbiMgr.Init("../abi/native_abi_script")
+	accs, pubKeys := newMultiSigAccounts()
+	mutTx := newMultiSigTransfer(t, pubKeys, accs[0].Address)
+	ptx, err := NewPartialTx(mutTx, 2, pubKeys, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, ptx.Sign(accs[1]))
+
+	//decoded info shows another receiver
+	state := ptx.Decoded.Invoke.Params[0].Value.([]*DecodedParam)[0].Value.([]*DecodedParam)
+	state[1].Value = accs[2].Address.ToBase58()
+	data, _ := json.Marshal(ptx)
+	_, err = ParsePartialTx(data, nil)
+	assert.NotNil(t, err)
+	state[1].Value = accs[0].Address.ToBase58()
+
+	//signature of other tx
+	other := newMultiSigTransfer(t, pubKeys, accs[0].Address)
+	otherPtx, err := NewPartialTx(other, 2, pubKeys, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, otherPtx.Sign(accs[0]))
+	assert.NotNil(t, ptx.Merge(otherPtx))
+	copied := *ptx
+	copied.Sigs = append(copied.Sigs, &PartialSig{PubKey: otherPtx.Sigs[0].PubKey, SigData: otherPtx.Sigs[0].SigData})
+	data, _ = json.Marshal(copied)
+	_, err = ParsePartialTx(data, nil)
+	assert.NotNil(t, err)
+
+	//payer is not the multi-signature address
+	mutTx = newMultiSigTransfer(t, pubKeys, accs[0].Address)
+	mutTx.Payer = accs[0].Address
+	_, err = NewPartialTx(mutTx, 2, pubKeys, nil)
+	assert.NotNil(t, err)
+}
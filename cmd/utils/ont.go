@@ -31,7 +31,6 @@ import (
 	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
-	sig "github.com/ontio/ontology-crypto/signature"
 	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/constants"
@@ -43,6 +42,7 @@ import (
 	httpcom "github.com/qbyyf/ontology/http/base/common"
 	"github.com/qbyyf/ontology/smartcontract/service/native/ont"
 	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/qbyyf/ontology/smartcontract/service/neovm"
 )
 
 const (
@@ -55,6 +55,8 @@ const (
 
 	ASSET_ONT = "ont"
 	ASSET_ONG = "ong"
+
+	WAIT_TX_POLL_INTERVAL = time.Second
 )
 
 func init() {
@@ -293,9 +295,9 @@ func NewInvokeTransaction(gasPrice, gasLimit uint64, invokeCode []byte) *types.M
 	return tx
 }
 
-func SignTransaction(signer *account.Account, tx *types.MutableTransaction) error {
+func SignTransaction(signer signature.DataSigner, tx *types.MutableTransaction) error {
 	if tx.Payer == common.ADDRESS_EMPTY {
-		tx.Payer = signer.Address
+		tx.Payer = types.AddressFromPubKey(signer.PubKey())
 	}
 	txHash := tx.Hash()
 	sigData, err := Sign(txHash.ToArray(), signer)
@@ -304,8 +306,8 @@ func SignTransaction(signer *account.Account, tx *types.MutableTransaction) erro
 	}
 	hasSig := false
 	for i, sig := range tx.Sigs {
-		if len(sig.PubKeys) == 1 && pubKeysEqual(sig.PubKeys, []keypair.PublicKey{signer.PublicKey}) {
-			if hasAlreadySig(txHash.ToArray(), signer.PublicKey, sig.SigData) {
+		if len(sig.PubKeys) == 1 && pubKeysEqual(sig.PubKeys, []keypair.PublicKey{signer.PubKey()}) {
+			if hasAlreadySig(txHash.ToArray(), signer.PubKey(), sig.SigData) {
 				//has already signed
 				return nil
 			}
@@ -316,7 +318,7 @@ func SignTransaction(signer *account.Account, tx *types.MutableTransaction) erro
 	}
 	if !hasSig {
 		tx.Sigs = append(tx.Sigs, types.Sig{
-			PubKeys: []keypair.PublicKey{signer.PublicKey},
+			PubKeys: []keypair.PublicKey{signer.PubKey()},
 			M:       1,
 			SigData: [][]byte{sigData},
 		})
@@ -324,14 +326,14 @@ func SignTransaction(signer *account.Account, tx *types.MutableTransaction) erro
 	return nil
 }
 
-func MultiSigTransaction(mutTx *types.MutableTransaction, m uint16, pubKeys []keypair.PublicKey, signer *account.Account) error {
+func MultiSigTransaction(mutTx *types.MutableTransaction, m uint16, pubKeys []keypair.PublicKey, signer signature.DataSigner) error {
 	pkSize := len(pubKeys)
 	if m == 0 || int(m) > pkSize || pkSize > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
 		return fmt.Errorf("invalid params")
 	}
 	validPubKey := false
 	for _, pk := range pubKeys {
-		if keypair.ComparePublicKey(pk, signer.PublicKey) {
+		if keypair.ComparePublicKey(pk, signer.PubKey()) {
 			validPubKey = true
 			break
 		}
@@ -363,7 +365,7 @@ func MultiSigTransaction(mutTx *types.MutableTransaction, m uint16, pubKeys []ke
 			continue
 		}
 		hasMutilSig = true
-		if hasAlreadySig(txHash.ToArray(), signer.PublicKey, sigs.SigData) {
+		if hasAlreadySig(txHash.ToArray(), signer.PubKey(), sigs.SigData) {
 			break
 		}
 		sigs.SigData = append(sigs.SigData, sigData)
@@ -416,17 +418,9 @@ func pubKeysEqual(pks1, pks2 []keypair.PublicKey) bool {
 	return true
 }
 
-//Sign sign return the signature to the data of private key
-func Sign(data []byte, signer *account.Account) ([]byte, error) {
-	s, err := sig.Sign(signer.SigScheme, signer.PrivateKey, data, nil)
-	if err != nil {
-		return nil, err
-	}
-	sigData, err := sig.Serialize(s)
-	if err != nil {
-		return nil, fmt.Errorf("sig.Serialize error:%s", err)
-	}
-	return sigData, nil
+//Sign sign return the signature to the data of signer
+func Sign(data []byte, signer signature.DataSigner) ([]byte, error) {
+	return signature.Sign(signer, data)
 }
 
 //SendRawTransaction send a transaction to ontology network, and return hash of the transaction
@@ -503,6 +497,33 @@ func GetRawTransaction(txHash string) ([]byte, error) {
 	return nil, ontErr.Error
 }
 
+//GetRawTransactionData return the raw transaction in hex
+func GetRawTransactionData(txHash string) (string, error) {
+	data, ontErr := sendRpcRequest("getrawtransaction", []interface{}{txHash})
+	if ontErr != nil {
+		switch ontErr.ErrorCode {
+		case ERROR_INVALID_PARAMS:
+			return "", fmt.Errorf("invalid TxHash:%s", txHash)
+		}
+		return "", ontErr.Error
+	}
+	rawTx := ""
+	err := json.Unmarshal(data, &rawTx)
+	if err != nil {
+		return "", fmt.Errorf("json.Unmarshal raw tx:%s error:%s", data, err)
+	}
+	return rawTx, nil
+}
+
+//DecodeTransactionByHash query transaction from ontology by hash, and decode it
+func DecodeTransactionByHash(txHash string, abis *TxDecodeAbi) (*TxInfo, error) {
+	rawTx, err := GetRawTransactionData(txHash)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeRawTransaction(rawTx, abis)
+}
+
 func GetBlock(hashOrHeight interface{}) ([]byte, error) {
 	data, ontErr := sendRpcRequest("getblock", []interface{}{hashOrHeight, 1})
 	if ontErr == nil {
@@ -600,6 +621,81 @@ func GetTxHeight(txHash string) (uint32, error) {
 	return height, nil
 }
 
+//GetContractState return the deploy code of contract, or nil if the contract is not deployed
+func GetContractState(contractAddress common.Address) (*payload.DeployCode, error) {
+	data, ontErr := sendRpcRequest("getcontractstate", []interface{}{contractAddress.ToHexString()})
+	if ontErr != nil {
+		switch ontErr.ErrorCode {
+		case ERROR_UNKNOWN_CONTRACT:
+			return nil, nil
+		}
+		return nil, ontErr.Error
+	}
+	hexStr := ""
+	err := json.Unmarshal(data, &hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error:%s", err)
+	}
+	raw, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	contract := &payload.DeployCode{}
+	err = contract.Deserialization(common.NewZeroCopySource(raw))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize contract state error:%s", err)
+	}
+	return contract, nil
+}
+
+//WaitTxConfirmed polls the smart contract event of transaction until it is committed to ledger or timeout
+func WaitTxConfirmed(txHash string, timeout time.Duration) (*httpcom.ExecuteNotify, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		notify, err := GetSmartContractEvent(txHash)
+		if err != nil {
+			return nil, err
+		}
+		if notify != nil {
+			return notify, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("transaction:%s is not confirmed in %s", txHash, timeout)
+		}
+		time.Sleep(WAIT_TX_POLL_INTERVAL)
+	}
+}
+
+//PrepareTransaction pre-execute the transaction without commit to ledger
+func PrepareTransaction(mutable *types.MutableTransaction) (*httpcom.PreExecuteResult, error) {
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	txData := hex.EncodeToString(common.SerializeToBytes(tx))
+	return PrepareSendRawTransaction(txData)
+}
+
+//EstimateGasLimit pre-execute the transaction signed by signer, and return the gas limit it needs.
+//The transaction should be signed again after its gas limit is updated.
+func EstimateGasLimit(signer signature.DataSigner, mutable *types.MutableTransaction) (uint64, error) {
+	err := SignTransaction(signer, mutable)
+	if err != nil {
+		return 0, fmt.Errorf("SignTransaction error:%s", err)
+	}
+	preResult, err := PrepareTransaction(mutable)
+	if err != nil {
+		return 0, fmt.Errorf("pre-execute transaction error:%s", err)
+	}
+	if preResult.State == 0 {
+		return 0, fmt.Errorf("pre-execute transaction failed, result:%v", preResult.Result)
+	}
+	if preResult.Gas < neovm.MIN_TRANSACTION_GAS {
+		return neovm.MIN_TRANSACTION_GAS, nil
+	}
+	return preResult.Gas, nil
+}
+
 func DeployContract(
 	gasPrice,
 	gasLimit uint64,
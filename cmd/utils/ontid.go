@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const VERSION_CONTRACT_ONTID = byte(0)
+
+// RegIdWithPublicKeyParam is the param of ontid method regIDWithPublicKey
+type RegIdWithPublicKeyParam struct {
+	OntId  []byte
+	PubKey []byte
+}
+
+// RegIdWithControllerParam is the param of ontid method regIDWithController,
+// Index is the key index of the controller who signs the transaction
+type RegIdWithControllerParam struct {
+	OntId      []byte
+	Controller []byte
+	Index      uint32
+}
+
+// OntIdKeyParam is the param of ontid method addKey and removeKey
+type OntIdKeyParam struct {
+	OntId    []byte
+	PubKey   []byte
+	Operator []byte
+}
+
+type OntIdAttribute struct {
+	Key   []byte
+	Type  []byte
+	Value []byte
+}
+
+// AddAttributesParam is the param of ontid method addAttributes
+type AddAttributesParam struct {
+	OntId      []byte
+	Attributes []*OntIdAttribute
+	Operator   []byte
+}
+
+// RemoveAttributeParam is the param of ontid method removeAttribute
+type RemoveAttributeParam struct {
+	OntId    []byte
+	Key      []byte
+	Operator []byte
+}
+
+// OntIdIndexParam is the param of ontid method removeController
+type OntIdIndexParam struct {
+	OntId []byte
+	Index uint32
+}
+
+// SetRecoveryParam is the param of ontid method setRecovery
+type SetRecoveryParam struct {
+	OntId    []byte
+	Recovery []byte
+	Index    uint32
+}
+
+// OntIdTx return a transaction invoking method of ontid contract with param
+func OntIdTx(gasPrice, gasLimit uint64, method string, param interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(utils.OntIDContractAddress, VERSION_CONTRACT_ONTID,
+		method, []interface{}{param})
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	return NewInvokeTransaction(gasPrice, gasLimit, invokeCode), nil
+}
+
+// SerializeOntIdGroup return the serialized ontid group of members with threshold,
+// which is used as recovery or controller of an ONT ID
+func SerializeOntIdGroup(members []string, threshold uint) ([]byte, error) {
+	if len(members) == 0 {
+		return nil, fmt.Errorf("group members cannot be empty")
+	}
+	if threshold == 0 || threshold > uint(len(members)) {
+		return nil, fmt.Errorf("invalid threshold %d of %d members", threshold, len(members))
+	}
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, uint64(len(members)))
+	for _, member := range members {
+		utils.EncodeVarBytes(sink, []byte(member))
+	}
+	utils.EncodeVarUint(sink, uint64(threshold))
+	return sink.Bytes(), nil
+}
+
+// GetOntIdDocument return the DID document of ONT ID in json, nil if ONT ID is not registered
+func GetOntIdDocument(ontId string) ([]byte, error) {
+	preResult, err := PrepareInvokeNativeContract(utils.OntIDContractAddress, VERSION_CONTRACT_ONTID,
+		"getDocumentJson", []interface{}{[]byte(ontId)})
+	if err != nil {
+		return nil, err
+	}
+	if preResult.State == 0 {
+		return nil, fmt.Errorf("prepare invoke failed")
+	}
+	hexStr, ok := preResult.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid result type")
+	}
+	data, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	return data, nil
+}
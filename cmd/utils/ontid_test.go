@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOntIdTx(t *testing.T) {
+	param := &RegIdWithPublicKeyParam{
+		OntId:  []byte("did:ont:TSS6S4Xhzt5wtvRBTm4y3QCTRqB4BnU7vT"),
+		PubKey: []byte{0x02, 0x03},
+	}
+	mutTx, err := OntIdTx(2500, 20000, "regIDWithPublicKey", param)
+	assert.Nil(t, err)
+	code := mutTx.Payload.(*payload.InvokeCode).Code
+	assert.True(t, bytes.Contains(code, utils.OntIDContractAddress[:]))
+	assert.True(t, bytes.Contains(code, []byte("regIDWithPublicKey")))
+	assert.True(t, bytes.Contains(code, param.OntId))
+}
+
+func TestSerializeOntIdGroup(t *testing.T) {
+	members := []string{"did:ont:TSS6S4Xhzt5wtvRBTm4y3QCTRqB4BnU7vT", "did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa"}
+	data, err := SerializeOntIdGroup(members, 2)
+	assert.Nil(t, err)
+	source := common.NewZeroCopySource(data)
+	num, err := utils.DecodeVarUint(source)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), num)
+	for _, member := range members {
+		m, err := utils.DecodeVarBytes(source)
+		assert.Nil(t, err)
+		assert.Equal(t, member, string(m))
+	}
+	threshold, err := utils.DecodeVarUint(source)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), threshold)
+
+	_, err = SerializeOntIdGroup(members, 3)
+	assert.NotNil(t, err)
+	_, err = SerializeOntIdGroup(nil, 1)
+	assert.NotNil(t, err)
+}
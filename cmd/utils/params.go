@@ -38,6 +38,7 @@ const (
 	PARAM_TYPE_INTEGER    = "int"
 	PARAM_TYPE_BOOLEAN    = "bool"
 	PARAM_TYPE_ADDRESS    = "address"
+	PARAM_TYPE_H256       = "h256"
 	PARAM_LEFT_BRACKET    = "["
 	PARAM_RIGHT_BRACKET   = "]"
 	PARAM_ESC_CHAR        = `/`
@@ -49,7 +50,7 @@ var (
 )
 
 //ParseParams return interface{} array of encode params item.
-//A param item compose of type and value, type can be: bytearray, string, int, bool
+//A param item compose of type and value, type can be: bytearray, string, int, bool, address, h256
 //Param type and param value split with ":", such as int:10
 //Param array can be express with "[]", such [int:10,string:foo], param array can be nested, such as [int:10,[int:12,bool:true]]
 //A raw params example: string:foo,[int:0,[bool:true,string:bar],bool:false]
@@ -200,6 +201,12 @@ func parseRawParamValue(pType string, pValue string) (interface{}, error) {
 		}
 	case PARAM_TYPE_ADDRESS:
 		return common.AddressFromBase58(pValue)
+	case PARAM_TYPE_H256:
+		value, err := common.Uint256FromHexString(pValue)
+		if err != nil {
+			return nil, fmt.Errorf("parse h256 param:%s error:%s", pValue, err)
+		}
+		return value, nil
 
 	default:
 		return nil, fmt.Errorf("unspport param type:%s", pType)
@@ -22,8 +22,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"testing"
 
+	"github.com/qbyyf/ontology/common"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/vm/crossvm_codec"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -95,3 +99,25 @@ func arrayEqual(a1, a2 []interface{}) (bool, error) {
 	}
 	return string(data1) == string(data2), nil
 }
+
+func TestParseParamsCrossVM(t *testing.T) {
+	hash := common.Uint256{1, 2, 3}
+	addr := common.Address{4, 5, 6}
+	rawParams := fmt.Sprintf("string:transfer,[address:%s,h256:%s,int:-10,bool:true,bytearray:0102]",
+		addr.ToBase58(), hash.ToHexString())
+	params, err := ParseParams(rawParams)
+	assert.Nil(t, err)
+
+	data, err := cutils.BuildWasmContractCrossVMParam(params)
+	assert.Nil(t, err)
+	decoded, err := crossvm_codec.DeserializeCallParam(data)
+	assert.Nil(t, err)
+	expect := []interface{}{
+		"transfer",
+		[]interface{}{addr, hash, big.NewInt(-10), true, []byte{1, 2}},
+	}
+	assert.Equal(t, expect, decoded)
+
+	_, err = ParseParams("h256:0102")
+	assert.NotNil(t, err)
+}
@@ -34,6 +34,7 @@ const JSON_RPC_VERSION = "2.0"
 
 const (
 	ERROR_INVALID_PARAMS   = rpcerr.INVALID_PARAMS
+	ERROR_UNKNOWN_CONTRACT = rpcerr.UNKNOWN_CONTRACT
 	ERROR_ONTOLOGY_COMMON  = 10000
 	ERROR_ONTOLOGY_SUCCESS = 0
 )
@@ -71,6 +72,15 @@ type JsonRpcResponse struct {
 	Result json.RawMessage `json:"result"`
 }
 
+//EthJsonRpcResponse object response for JsonRpcRequest of eth rpc server
+type EthJsonRpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int64  `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 func sendRpcRequest(method string, params []interface{}) ([]byte, *OntologyError) {
 	rpcReq := &JsonRpcRequest{
 		Version: JSON_RPC_VERSION,
@@ -104,3 +114,37 @@ func sendRpcRequest(method string, params []interface{}) ([]byte, *OntologyError
 	}
 	return rpcRsp.Result, nil
 }
+
+func sendEthRpcRequest(method string, params []interface{}) ([]byte, *OntologyError) {
+	rpcReq := &JsonRpcRequest{
+		Version: JSON_RPC_VERSION,
+		Id:      "cli",
+		Method:  method,
+		Params:  params,
+	}
+	data, err := json.Marshal(rpcReq)
+	if err != nil {
+		return nil, NewOntologyError(fmt.Errorf("JsonRpcRequest json.Marshal error:%s", err))
+	}
+
+	addr := fmt.Sprintf("http://localhost:%d", config.DefConfig.Rpc.EthJsonPort)
+	resp, err := http.Post(addr, "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		return nil, NewOntologyError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewOntologyError(fmt.Errorf("read eth rpc response body error:%s", err))
+	}
+	rpcRsp := &EthJsonRpcResponse{}
+	err = json.Unmarshal(body, rpcRsp)
+	if err != nil {
+		return nil, NewOntologyError(fmt.Errorf("json.Unmarshal EthJsonRpcResponse:%s error:%s", body, err))
+	}
+	if rpcRsp.Error != nil {
+		return nil, NewOntologyError(fmt.Errorf("%s", rpcRsp.Error.Message), rpcRsp.Error.Code)
+	}
+	return rpcRsp.Result, nil
+}
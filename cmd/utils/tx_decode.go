@@ -0,0 +1,553 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	ethabi "github.com/qbyyf/go-ethereum/accounts/abi"
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/go-ethereum/common/hexutil"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/ontology/cmd/abi"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/vm/crossvm_codec"
+)
+
+const (
+	INVOKE_VM_NATIVE = "native"
+	INVOKE_VM_NEOVM  = "neovm"
+	INVOKE_VM_WASM   = "wasm"
+	INVOKE_VM_EVM    = "evm"
+)
+
+// DecodedParam is the param of contract call in human readable form. Value is string, bool or []*DecodedParam
+type DecodedParam struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// InvokeInfo is the decoded contract call of invoke transaction
+type InvokeInfo struct {
+	VM       string          `json:"vm"`
+	Contract string          `json:"contract"`
+	Version  byte            `json:"version,omitempty"`
+	Method   string          `json:"method"`
+	Params   []*DecodedParam `json:"params"`
+	ByAbi    bool            `json:"byAbi"`           //whether params are decoded by abi
+	Value    string          `json:"value,omitempty"` //ONG transferred to EVM contract
+}
+
+// DeployInfo is the decoded contract of deploy transaction
+type DeployInfo struct {
+	VmType      string `json:"vmType"`
+	Contract    string `json:"contract"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Author      string `json:"author"`
+	Email       string `json:"email"`
+	Description string `json:"description"`
+	CodeSize    int    `json:"codeSize"`
+}
+
+// TxInfo is the decoded transaction in human readable form
+type TxInfo struct {
+	TxType   string      `json:"txType"`
+	TxHash   string      `json:"txHash"`
+	Payer    string      `json:"payer"`
+	From     string      `json:"from,omitempty"` //sender of EIP155 transaction in hex
+	Nonce    uint64      `json:"nonce"`
+	GasPrice uint64      `json:"gasPrice"`
+	GasLimit uint64      `json:"gasLimit"`
+	Invoke   *InvokeInfo `json:"invoke,omitempty"`
+	Deploy   *DeployInfo `json:"deploy,omitempty"`
+}
+
+// TxDecodeAbi is the contract abi to decode transaction, params are decoded without abi if it is nil
+type TxDecodeAbi struct {
+	Neovm *abi.NeovmContractAbi //abi of invoked NeoVM contract
+	Eth   *ethabi.ABI           //Solidity abi of called EVM contract
+}
+
+// NewTxDecodeAbi parses the NeoVM abi and Solidity abi of the invoked contract in json, the empty one is not set
+func NewTxDecodeAbi(neovmAbi, ethAbi []byte) (*TxDecodeAbi, error) {
+	abis := &TxDecodeAbi{}
+	var err error
+	if len(neovmAbi) != 0 {
+		abis.Neovm, err = NewNeovmContractAbi(neovmAbi)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ethAbi) != 0 {
+		abis.Eth, err = NewEthContractAbi(ethAbi)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return abis, nil
+}
+
+// DecodeRawTransaction decodes ontology transaction or EIP155 transaction in hex
+func DecodeRawTransaction(rawTx string, abis *TxDecodeAbi) (*TxInfo, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(rawTx, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("raw tx hex decode error:%s", err)
+	}
+	tx, err := types.TransactionFromRawBytes(raw)
+	if err != nil {
+		ethTx, ethErr := DecodeEthTransaction(rawTx)
+		if ethErr != nil {
+			return nil, fmt.Errorf("TransactionFromRawBytes error:%s", err)
+		}
+		return DecodeEthTransactionInfo(ethTx, abis)
+	}
+	return DecodeTransaction(tx, abis)
+}
+
+// DecodeTransaction decodes the payload of transaction. Params of native contract are decoded by the abi in abi.DefAbiMgr,
+// and params of NeoVM and EVM contract are decoded by abis
+func DecodeTransaction(tx *types.Transaction, abis *TxDecodeAbi) (*TxInfo, error) {
+	if abis == nil {
+		abis = &TxDecodeAbi{}
+	}
+	if tx.TxType == types.EIP155 {
+		ethTx, err := tx.GetEIP155Tx()
+		if err != nil {
+			return nil, err
+		}
+		return DecodeEthTransactionInfo(ethTx, abis)
+	}
+	hash := tx.Hash()
+	info := &TxInfo{
+		TxHash:   hash.ToHexString(),
+		Payer:    tx.Payer.ToBase58(),
+		Nonce:    uint64(tx.Nonce),
+		GasPrice: tx.GasPrice,
+		GasLimit: tx.GasLimit,
+	}
+	switch pl := tx.Payload.(type) {
+	case *payload.DeployCode:
+		info.TxType = "deploy"
+		vmType := "neovm"
+		if pl.VmType() == payload.WASMVM_TYPE {
+			vmType = "wasm"
+		}
+		contract := pl.Address()
+		info.Deploy = &DeployInfo{
+			VmType:      vmType,
+			Contract:    contract.ToHexString(),
+			Name:        pl.Name,
+			Version:     pl.Version,
+			Author:      pl.Author,
+			Email:       pl.Email,
+			Description: pl.Description,
+			CodeSize:    len(pl.GetRawCode()),
+		}
+	case *payload.InvokeCode:
+		var err error
+		switch tx.TxType {
+		case types.InvokeNeo:
+			info.TxType = "invokeNeo"
+			info.Invoke, err = DecodeNeoVMInvokeCode(pl.Code, abis.Neovm)
+		case types.InvokeWasm:
+			info.TxType = "invokeWasm"
+			info.Invoke, err = DecodeWasmInvokeCode(pl.Code)
+		default:
+			return nil, fmt.Errorf("unsupported tx type:%d", tx.TxType)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tx type:%d", tx.TxType)
+	}
+	return info, nil
+}
+
+// DecodeEthTransactionInfo decodes EIP155 transaction, call data is decoded by abis.Eth if it is not nil
+func DecodeEthTransactionInfo(ethTx *ethtypes.Transaction, abis *TxDecodeAbi) (*TxInfo, error) {
+	if abis == nil {
+		abis = &TxDecodeAbi{}
+	}
+	info := &TxInfo{
+		TxType:   "eip155",
+		TxHash:   ethTx.Hash().Hex(),
+		Nonce:    ethTx.Nonce(),
+		GasPrice: new(big.Int).Div(ethTx.GasPrice(), big.NewInt(constants.GWei)).Uint64(),
+		GasLimit: ethTx.Gas(),
+	}
+	from, err := ethtypes.NewEIP155Signer(ethTx.ChainId()).Sender(ethTx)
+	if err == nil {
+		payer := common.Address(from)
+		info.Payer = payer.ToBase58()
+		info.From = from.Hex()
+	}
+	if ethTx.To() == nil {
+		info.Deploy = &DeployInfo{VmType: INVOKE_VM_EVM, CodeSize: len(ethTx.Data())}
+		if info.From != "" {
+			info.Deploy.Contract = crypto.CreateAddress(from, ethTx.Nonce()).Hex()
+		}
+		return info, nil
+	}
+	info.Invoke = &InvokeInfo{
+		VM:       INVOKE_VM_EVM,
+		Contract: ethTx.To().Hex(),
+		Params:   []*DecodedParam{},
+		Value:    FormatEthAmount(ethTx.Value()),
+	}
+	data := ethTx.Data()
+	if len(data) == 0 {
+		return info, nil
+	}
+	if len(data) >= 4 && abis.Eth != nil {
+		method, err := abis.Eth.MethodById(data[:4])
+		if err == nil {
+			params, err := DecodeEthParams(method.Inputs, data[4:])
+			if err == nil {
+				info.Invoke.Method = method.Name
+				info.Invoke.Params = params
+				info.Invoke.ByAbi = true
+				return info, nil
+			}
+		}
+	}
+	if len(data) >= 4 {
+		info.Invoke.Method = hexutil.Encode(data[:4])
+		data = data[4:]
+	}
+	info.Invoke.Params = []*DecodedParam{{Type: abi.NATIVE_PARAM_TYPE_BYTEARRAY, Value: hex.EncodeToString(data)}}
+	return info, nil
+}
+
+// DecodeEthParams decodes the call data of Solidity method without selector
+func DecodeEthParams(inputs ethabi.Arguments, data []byte) ([]*DecodedParam, error) {
+	values, err := inputs.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("unpack params error:%s", err)
+	}
+	if len(values) != len(inputs) {
+		return nil, fmt.Errorf("abi unmatch")
+	}
+	params := make([]*DecodedParam, 0, len(values))
+	for i, input := range inputs {
+		param := decodeEthValue(input.Type, reflect.ValueOf(values[i]))
+		param.Name = input.Name
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+func decodeEthValue(t ethabi.Type, value reflect.Value) *DecodedParam {
+	param := &DecodedParam{Type: t.String()}
+	switch t.T {
+	case ethabi.SliceTy, ethabi.ArrayTy:
+		values := make([]*DecodedParam, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			values = append(values, decodeEthValue(*t.Elem, value.Index(i)))
+		}
+		param.Value = values
+	case ethabi.TupleTy:
+		values := make([]*DecodedParam, 0, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			field := decodeEthValue(*elem, value.Field(i))
+			field.Name = t.TupleRawNames[i]
+			values = append(values, field)
+		}
+		param.Value = values
+	case ethabi.AddressTy:
+		param.Value = value.Interface().(ethcommon.Address).Hex()
+	case ethabi.BoolTy, ethabi.StringTy:
+		param.Value = value.Interface()
+	case ethabi.BytesTy:
+		param.Value = hex.EncodeToString(value.Bytes())
+	case ethabi.FixedBytesTy:
+		data := make([]byte, value.Len())
+		reflect.Copy(reflect.ValueOf(data), value)
+		param.Value = hex.EncodeToString(data)
+	default:
+		param.Value = fmt.Sprintf("%v", value.Interface())
+	}
+	return param
+}
+
+// DecodeNeoVMInvokeCode decodes NeoVM invoke code, which calls native contract or NeoVM contract
+func DecodeNeoVMInvokeCode(code []byte, neovmAbi *abi.NeovmContractAbi) (*InvokeInfo, error) {
+	invoke, err := cutils.ParseNeoVMInvokeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	info := &InvokeInfo{
+		Contract: invoke.Contract.ToHexString(),
+		Method:   invoke.Method,
+	}
+	if invoke.Native {
+		info.VM = INVOKE_VM_NATIVE
+		info.Version = invoke.Version
+		var funcAbi *abi.NativeContractFunctionAbi
+		if contractAbi := abi.DefAbiMgr.GetNativeAbi(info.Contract); contractAbi != nil {
+			funcAbi = contractAbi.GetFunc(invoke.Method)
+		}
+		if funcAbi != nil {
+			info.Params, err = DecodeNativeFuncParam(invoke.Args, funcAbi.Parameters)
+			info.ByAbi = err == nil
+		}
+		if !info.ByAbi {
+			info.Params = []*DecodedParam{decodeRawItem(invoke.Args)}
+		}
+		return info, nil
+	}
+	info.VM = INVOKE_VM_NEOVM
+	if neovmAbi != nil && strings.TrimPrefix(neovmAbi.Address, "0x") == info.Contract {
+		if funcAbi := neovmAbi.GetFunc(invoke.Method); funcAbi != nil {
+			info.Params, err = DecodeNeovmParams(invoke.Args.Items, funcAbi.Parameters)
+			info.ByAbi = err == nil
+		}
+	}
+	if !info.ByAbi {
+		info.Params = make([]*DecodedParam, 0, len(invoke.Args.Items))
+		for _, item := range invoke.Args.Items {
+			info.Params = append(info.Params, decodeRawItem(item))
+		}
+	}
+	return info, nil
+}
+
+// DecodeWasmInvokeCode decodes wasm invoke code. Args in cross vm codec are fully decoded,
+// otherwise only the method is decoded and the remaining args are in hex
+func DecodeWasmInvokeCode(code []byte) (*InvokeInfo, error) {
+	source := common.NewZeroCopySource(code)
+	contract, eof := source.NextAddress()
+	if eof {
+		return nil, fmt.Errorf("invalid wasm invoke code")
+	}
+	args, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, fmt.Errorf("invalid wasm invoke code")
+	}
+	info := &InvokeInfo{VM: INVOKE_VM_WASM, Contract: contract.ToHexString()}
+	if method, params, ok := decodeCrossVMArgs(args); ok {
+		info.Method = method
+		info.Params = params
+		info.ByAbi = true
+		return info, nil
+	}
+	argSource := common.NewZeroCopySource(args)
+	method, _, irregular, eof := argSource.NextString()
+	if irregular || eof {
+		return nil, fmt.Errorf("invalid wasm invoke method")
+	}
+	info.Method = method
+	info.Params = []*DecodedParam{{Type: abi.NATIVE_PARAM_TYPE_BYTEARRAY, Value: hex.EncodeToString(args[argSource.Pos():])}}
+	return info, nil
+}
+
+// decodeCrossVMArgs decodes args in cross vm codec, which is a list of method and params
+func decodeCrossVMArgs(args []byte) (string, []*DecodedParam, bool) {
+	if len(args) == 0 || args[0] != crossvm_codec.VERSION {
+		return "", nil, false
+	}
+	source := common.NewZeroCopySource(args[1:])
+	value, err := crossvm_codec.DecodeValue(source)
+	if err != nil || source.Len() != 0 {
+		return "", nil, false
+	}
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return "", nil, false
+	}
+	method, ok := list[0].(string)
+	if !ok {
+		return "", nil, false
+	}
+	params := make([]*DecodedParam, 0, len(list)-1)
+	for _, v := range list[1:] {
+		params = append(params, decodeCrossVMValue(v))
+	}
+	return method, params, true
+}
+
+func decodeCrossVMValue(value interface{}) *DecodedParam {
+	switch val := value.(type) {
+	case []byte:
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_BYTEARRAY, Value: hex.EncodeToString(val)}
+	case string:
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_STRING, Value: val}
+	case common.Address:
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_ADDRESS, Value: val.ToBase58()}
+	case bool:
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_BOOL, Value: val}
+	case *big.Int:
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_INTEGER, Value: val.String()}
+	case common.Uint256:
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_UINT256, Value: val.ToHexString()}
+	case []interface{}:
+		values := make([]*DecodedParam, 0, len(val))
+		for _, v := range val {
+			values = append(values, decodeCrossVMValue(v))
+		}
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_ARRAY, Value: values}
+	default:
+		return &DecodedParam{Type: fmt.Sprintf("%T", value), Value: fmt.Sprintf("%v", value)}
+	}
+}
+
+// DecodeNativeFuncParam is the reverse of ParseNativeFuncParam
+func DecodeNativeFuncParam(item *cutils.NeoVMItem, paramsAbi []*abi.NativeContractParamAbi) ([]*DecodedParam, error) {
+	switch len(paramsAbi) {
+	case 0:
+		return []*DecodedParam{}, nil
+	case 1:
+		param, err := DecodeNativeParam(item, paramsAbi[0])
+		if err != nil {
+			return nil, err
+		}
+		return []*DecodedParam{param}, nil
+	default:
+		//more than one param is in a struct
+		if !item.IsArray || len(item.Items) != len(paramsAbi) {
+			return nil, fmt.Errorf("abi unmatch")
+		}
+		params := make([]*DecodedParam, 0, len(paramsAbi))
+		for i, paramAbi := range paramsAbi {
+			param, err := DecodeNativeParam(item.Items[i], paramAbi)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param)
+		}
+		return params, nil
+	}
+}
+
+func DecodeNativeParam(item *cutils.NeoVMItem, paramAbi *abi.NativeContractParamAbi) (*DecodedParam, error) {
+	paramType := strings.ToLower(paramAbi.Type)
+	param := &DecodedParam{Name: paramAbi.Name, Type: paramType}
+	switch paramType {
+	case abi.NATIVE_PARAM_TYPE_STRUCT, abi.NATIVE_PARAM_TYPE_ARRAY:
+		if !item.IsArray {
+			return nil, fmt.Errorf("param:%s is not %s", paramAbi.Name, paramType)
+		}
+		if paramType == abi.NATIVE_PARAM_TYPE_STRUCT && len(item.Items) != len(paramAbi.SubType) {
+			return nil, fmt.Errorf("param:%s struct abi unmatch", paramAbi.Name)
+		}
+		if paramType == abi.NATIVE_PARAM_TYPE_ARRAY && len(paramAbi.SubType) != 1 {
+			return nil, fmt.Errorf("param:%s array abi unmatch", paramAbi.Name)
+		}
+		values := make([]*DecodedParam, 0, len(item.Items))
+		for i, elem := range item.Items {
+			elemAbi := paramAbi.SubType[0]
+			if paramType == abi.NATIVE_PARAM_TYPE_STRUCT {
+				elemAbi = paramAbi.SubType[i]
+			}
+			value, err := DecodeNativeParam(elem, elemAbi)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		param.Value = values
+		return param, nil
+	}
+	if item.IsArray {
+		return nil, fmt.Errorf("param:%s is not %s", paramAbi.Name, paramType)
+	}
+	switch paramType {
+	case abi.NATIVE_PARAM_TYPE_ADDRESS:
+		addr, err := common.AddressParseFromBytes(item.Data)
+		if err != nil {
+			return nil, fmt.Errorf("param:%s invalid address", paramAbi.Name)
+		}
+		param.Value = addr.ToBase58()
+	case abi.NATIVE_PARAM_TYPE_BOOL:
+		param.Value = common.BigIntFromNeoBytes(item.Data).Sign() != 0
+	case abi.NATIVE_PARAM_TYPE_BYTE, abi.NATIVE_PARAM_TYPE_INTEGER:
+		param.Value = common.BigIntFromNeoBytes(item.Data).String()
+	case abi.NATIVE_PARAM_TYPE_STRING:
+		param.Value = string(item.Data)
+	case abi.NATIVE_PARAM_TYPE_BYTEARRAY:
+		param.Value = hex.EncodeToString(item.Data)
+	case abi.NATIVE_PARAM_TYPE_UINT256:
+		hash, err := common.Uint256ParseFromBytes(item.Data)
+		if err != nil {
+			return nil, fmt.Errorf("param:%s invalid uint256", paramAbi.Name)
+		}
+		param.Value = hash.ToHexString()
+	default:
+		return nil, fmt.Errorf("unknown param type:%s", paramAbi.Type)
+	}
+	return param, nil
+}
+
+// DecodeNeovmParams is the reverse of ParseNeovmParam
+func DecodeNeovmParams(items []*cutils.NeoVMItem, paramsAbi []*abi.NeovmContractParamsAbi) ([]*DecodedParam, error) {
+	if len(items) != len(paramsAbi) {
+		return nil, fmt.Errorf("abi unmatch")
+	}
+	params := make([]*DecodedParam, 0, len(items))
+	for i, item := range items {
+		paramAbi := paramsAbi[i]
+		paramType := strings.ToLower(paramAbi.Type)
+		param := &DecodedParam{Name: paramAbi.Name, Type: paramType}
+		if item.IsArray {
+			if paramType != abi.NEOVM_PARAM_TYPE_ARRAY && paramType != abi.NEOVM_PARAM_TYPE_ANY {
+				return nil, fmt.Errorf("param:%s is not %s", paramAbi.Name, paramType)
+			}
+			param.Value = decodeRawItem(item).Value
+			params = append(params, param)
+			continue
+		}
+		switch paramType {
+		case abi.NEOVM_PARAM_TYPE_BOOL:
+			param.Value = common.BigIntFromNeoBytes(item.Data).Sign() != 0
+		case abi.NEOVM_PARAM_TYPE_INTEGER:
+			param.Value = common.BigIntFromNeoBytes(item.Data).String()
+		case abi.NEOVM_PARAM_TYPE_STRING:
+			param.Value = string(item.Data)
+		case abi.NEOVM_PARAM_TYPE_BYTE_ARRAY, abi.NEOVM_PARAM_TYPE_ANY:
+			param.Value = hex.EncodeToString(item.Data)
+		default:
+			return nil, fmt.Errorf("param:%s is not %s", paramAbi.Name, paramType)
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// decodeRawItem decodes item without abi, byte array is in hex
+func decodeRawItem(item *cutils.NeoVMItem) *DecodedParam {
+	if !item.IsArray {
+		return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_BYTEARRAY, Value: hex.EncodeToString(item.Data)}
+	}
+	values := make([]*DecodedParam, 0, len(item.Items))
+	for _, elem := range item.Items {
+		values = append(values, decodeRawItem(elem))
+	}
+	return &DecodedParam{Type: abi.NATIVE_PARAM_TYPE_ARRAY, Value: values}
+}
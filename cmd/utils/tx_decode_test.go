@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/vm/crossvm_codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeNeoVMInvokeCode(t *testing.T) {
+	contractAbi, err := NewNeovmContractAbi([]byte(`{
+  "hash": "0xe827bf96529b5780ad0702757b8bad315e2bb8ce",
+  "functions": [
+    {
+      "name": "transfer",
+      "parameters": [
+        {"name": "from", "type": "ByteArray"},
+        {"name": "amount", "type": "Integer"},
+        {"name": "memo", "type": "String"}
+      ]
+    }
+  ]
+}`))
+	assert.Nil(t, err)
+	contract, err := common.AddressFromHexString("e827bf96529b5780ad0702757b8bad315e2bb8ce")
+	assert.Nil(t, err)
+	code, err := cutils.BuildNeoVMInvokeCode(contract, []interface{}{"transfer", []interface{}{[]byte{1, 2}, 100, "hi"}})
+	assert.Nil(t, err)
+
+	info, err := DecodeNeoVMInvokeCode(code, contractAbi)
+	assert.Nil(t, err)
+	assert.Equal(t, INVOKE_VM_NEOVM, info.VM)
+	assert.Equal(t, "transfer", info.Method)
+	assert.True(t, info.ByAbi)
+	assert.Equal(t, 3, len(info.Params))
+	assert.Equal(t, "from", info.Params[0].Name)
+	assert.Equal(t, "0102", info.Params[0].Value)
+	assert.Equal(t, "100", info.Params[1].Value)
+	assert.Equal(t, "hi", info.Params[2].Value)
+
+	info, err = DecodeNeoVMInvokeCode(code, nil)
+	assert.Nil(t, err)
+	assert.False(t, info.ByAbi)
+	assert.Equal(t, "6869", info.Params[2].Value)
+
+	_, err = DecodeNeoVMInvokeCode(append([]byte{0x61}, code...), nil)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeWasmInvokeCode(t *testing.T) {
+	contract := common.AddressFromVmCode([]byte("wasm"))
+	args, err := crossvm_codec.EncodeValue([]interface{}{"transfer", contract, big.NewInt(100), []byte{1, 2}})
+	assert.Nil(t, err)
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteAddress(contract)
+	sink.WriteVarBytes(append([]byte{crossvm_codec.VERSION}, args...))
+
+	info, err := DecodeWasmInvokeCode(sink.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, INVOKE_VM_WASM, info.VM)
+	assert.Equal(t, "transfer", info.Method)
+	assert.True(t, info.ByAbi)
+	assert.Equal(t, 3, len(info.Params))
+	assert.Equal(t, contract.ToBase58(), info.Params[0].Value)
+	assert.Equal(t, "100", info.Params[1].Value)
+	assert.Equal(t, "0102", info.Params[2].Value)
+
+	//not in cross vm codec
+	code, err := cutils.BuildWasmVMInvokeCode(contract, []interface{}{"transfer", 100})
+	assert.Nil(t, err)
+	info, err = DecodeWasmInvokeCode(code)
+	assert.Nil(t, err)
+	assert.Equal(t, "transfer", info.Method)
+	assert.False(t, info.ByAbi)
+}
+
+func TestDecodeEthTransaction(t *testing.T) {
+	abiData := []byte(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}]`)
+	data, err := PackEthAbiCall(abiData, "transfer", `["0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", "1000"]`)
+	assert.Nil(t, err)
+	contract, err := ParseEthAddress("0xd9145CCE52D386f254917e481eB44e9943F39138")
+	assert.Nil(t, err)
+	priv, pub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.SECP256K1)
+	assert.Nil(t, err)
+	acc := &account.Account{PrivateKey: priv, PublicKey: pub, SigScheme: signature.SHA256withECDSA}
+	tx, err := SignEthTransaction(acc, NewEthInvokeTx(1, contract, big.NewInt(0), 2500, 60000, data), 5851)
+	assert.Nil(t, err)
+	rawTx, err := EncodeEthTransaction(tx)
+	assert.Nil(t, err)
+	ethAbi, err := NewEthContractAbi(abiData)
+	assert.Nil(t, err)
+
+	info, err := DecodeRawTransaction(rawTx, &TxDecodeAbi{Eth: ethAbi})
+	assert.Nil(t, err)
+	from, err := GetEthAddress(pub)
+	assert.Nil(t, err)
+	assert.Equal(t, "eip155", info.TxType)
+	assert.Equal(t, tx.Hash().Hex(), info.TxHash)
+	assert.Equal(t, from.Hex(), info.From)
+	assert.Equal(t, uint64(2500), info.GasPrice)
+	assert.Equal(t, contract.Hex(), info.Invoke.Contract)
+	assert.Equal(t, "transfer", info.Invoke.Method)
+	assert.True(t, info.Invoke.ByAbi)
+	assert.Equal(t, "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", info.Invoke.Params[0].Value)
+	assert.Equal(t, "1000", info.Invoke.Params[1].Value)
+
+	info, err = DecodeRawTransaction(rawTx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "0xa9059cbb", info.Invoke.Method)
+	assert.False(t, info.Invoke.ByAbi)
+}
@@ -261,6 +261,61 @@ func GetAddDecimalsHeight() uint32 {
 	}
 }
 
+func GetSlashEquivocationHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_SLASH_EQUIVOCATION_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_SLASH_EQUIVOCATION_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetAuthDelegationHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_AUTH_DELEGATION_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_AUTH_DELEGATION_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetHeaderVerifierHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_HEADER_VERIFIER_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_HEADER_VERIFIER_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetLockProxyRateLimitHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_LOCK_PROXY_RATE_LIMIT_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_LOCK_PROXY_RATE_LIMIT_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetGovProposalHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_GOV_PROPOSAL_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_GOV_PROPOSAL_POLARIS
+	default:
+		return 0
+	}
+}
+
 // the end of unbound timestamp offset from genesis block's timestamp
 func GetGovUnboundDeadline() (uint32, uint64) {
 	count := uint64(0)
@@ -19,6 +19,7 @@
 package constants
 
 import (
+	"math"
 	"time"
 
 	"github.com/laizy/bigint"
@@ -117,3 +118,26 @@ const BLOCKHEIGHT_TRACK_DESTROYED_CONTRACT_POLARIS = 14100000
 
 const BLOCKHEIGHT_ADD_DECIMALS_MAINNET = 13430000
 const BLOCKHEIGHT_ADD_DECIMALS_POLARIS = 0
+
+//the height of features which are not activated yet, it is replaced with the real height on upgrade
+const BLOCKHEIGHT_NOT_ACTIVATED = math.MaxUint32
+
+//vbft equivocation slashing height
+const BLOCKHEIGHT_SLASH_EQUIVOCATION_MAINNET = BLOCKHEIGHT_NOT_ACTIVATED
+const BLOCKHEIGHT_SLASH_EQUIVOCATION_POLARIS = BLOCKHEIGHT_NOT_ACTIVATED
+
+//scoped, capped and revocable delegations of auth contract height
+const BLOCKHEIGHT_AUTH_DELEGATION_MAINNET = BLOCKHEIGHT_NOT_ACTIVATED
+const BLOCKHEIGHT_AUTH_DELEGATION_POLARIS = BLOCKHEIGHT_NOT_ACTIVATED
+
+//cross chain header verifiers of other chains height
+const BLOCKHEIGHT_HEADER_VERIFIER_MAINNET = BLOCKHEIGHT_NOT_ACTIVATED
+const BLOCKHEIGHT_HEADER_VERIFIER_POLARIS = BLOCKHEIGHT_NOT_ACTIVATED
+
+//lock proxy rate limit, pause and queued unlock height
+const BLOCKHEIGHT_LOCK_PROXY_RATE_LIMIT_MAINNET = BLOCKHEIGHT_NOT_ACTIVATED
+const BLOCKHEIGHT_LOCK_PROXY_RATE_LIMIT_POLARIS = BLOCKHEIGHT_NOT_ACTIVATED
+
+//governance parameter change proposals height
+const BLOCKHEIGHT_GOV_PROPOSAL_MAINNET = BLOCKHEIGHT_NOT_ACTIVATED
+const BLOCKHEIGHT_GOV_PROPOSAL_POLARIS = BLOCKHEIGHT_NOT_ACTIVATED
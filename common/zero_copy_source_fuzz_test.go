@@ -0,0 +1,95 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzZeroCopySource drives the source with a sequence of reads selected by the input itself, every read must
+// keep the cursor inside the buffer and every regular variable length value must be encoded canonically.
+func FuzzZeroCopySource(f *testing.F) {
+	sink := NewZeroCopySink(nil)
+	sink.WriteVarUint(0xfc)
+	sink.WriteVarUint(0xfd)
+	sink.WriteVarUint(0x10000)
+	sink.WriteVarUint(0x100000000)
+	sink.WriteVarBytes([]byte("ontology"))
+	sink.WriteString("zero copy")
+	sink.WriteBool(true)
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, sink.Bytes())
+	f.Add([]byte{7, 7, 7, 7, 8, 9}, sink.Bytes())
+	f.Add([]byte{}, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, ops []byte, data []byte) {
+		source := NewZeroCopySource(data)
+		for _, op := range ops {
+			start := source.Pos()
+			var eof bool
+			switch op % 10 {
+			case 0:
+				_, eof = source.NextByte()
+			case 1:
+				_, _, eof = source.NextBool()
+			case 2:
+				_, eof = source.NextUint16()
+			case 3:
+				_, eof = source.NextUint32()
+			case 4:
+				_, eof = source.NextUint64()
+			case 5:
+				_, eof = source.NextAddress()
+			case 6:
+				_, eof = source.NextHash()
+			case 7:
+				val, _, irregular, e := source.NextVarUint()
+				eof = e
+				if !eof && !irregular {
+					sink := NewZeroCopySink(nil)
+					sink.WriteVarUint(val)
+					if !bytes.Equal(sink.Bytes(), data[start:source.Pos()]) {
+						t.Fatalf("var uint %d is not canonically encoded: %x", val, data[start:source.Pos()])
+					}
+				}
+			case 8:
+				buf, _, irregular, e := source.NextVarBytes()
+				eof = e
+				if !eof && !irregular {
+					sink := NewZeroCopySink(nil)
+					sink.WriteVarBytes(buf)
+					if !bytes.Equal(sink.Bytes(), data[start:source.Pos()]) {
+						t.Fatalf("var bytes are not canonically encoded: %x", data[start:source.Pos()])
+					}
+				}
+			case 9:
+				_, eof = source.NextBytes(uint64(op))
+			}
+			if source.Pos() > source.Size() || source.Pos()+source.Len() != source.Size() {
+				t.Fatalf("cursor out of range: pos %d, len %d, size %d", source.Pos(), source.Len(), source.Size())
+			}
+			if eof {
+				return
+			}
+		}
+	})
+}
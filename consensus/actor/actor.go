@@ -61,3 +61,7 @@ func (self *TxPoolActor) VerifyBlock(txs []*types.Transaction, height uint32) er
 
 	return nil
 }
+
+func (self *TxPoolActor) AppendTx(tx *types.Transaction) {
+	self.Pool.Tell(&txpool.AppendTxReq{Tx: tx})
+}
@@ -19,12 +19,15 @@
 package consensus
 
 import (
+	"fmt"
+
 	"github.com/ontio/ontology-eventbus/actor"
 	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/common/log"
 	"github.com/qbyyf/ontology/consensus/dbft"
 	"github.com/qbyyf/ontology/consensus/solo"
 	"github.com/qbyyf/ontology/consensus/vbft"
+	"github.com/qbyyf/ontology/core/signature"
 	p2p "github.com/qbyyf/ontology/p2pserver/net/protocol"
 )
 
@@ -40,19 +43,26 @@ const (
 	CONSENSUS_VBFT = "vbft"
 )
 
-func NewConsensusService(consensusType string, account *account.Account, txpool *actor.PID, ledger *actor.PID, p2p p2p.P2P) (ConsensusService, error) {
+func NewConsensusService(consensusType string, signer signature.VrfSigner, txpool *actor.PID, ledger *actor.PID, p2p p2p.P2P) (ConsensusService, error) {
 	if consensusType == "" {
 		consensusType = CONSENSUS_DBFT
 	}
 	var consensus ConsensusService
 	var err error
 	switch consensusType {
-	case CONSENSUS_DBFT:
-		consensus, err = dbft.NewDbftService(account, txpool, p2p)
-	case CONSENSUS_SOLO:
-		consensus, err = solo.NewSoloService(account, txpool)
+	case CONSENSUS_DBFT, CONSENSUS_SOLO:
+		//dbft and solo require the private key in local wallet
+		account, ok := signer.(*account.Account)
+		if !ok {
+			return nil, fmt.Errorf("consensus type:%s does not support remote signer", consensusType)
+		}
+		if consensusType == CONSENSUS_DBFT {
+			consensus, err = dbft.NewDbftService(account, txpool, p2p)
+		} else {
+			consensus, err = solo.NewSoloService(account, txpool)
+		}
 	case CONSENSUS_VBFT:
-		consensus, err = vbft.NewVbftServer(account, txpool, p2p)
+		consensus, err = vbft.NewVbftServer(signer, txpool, p2p)
 	}
 	log.Infof("ConsensusType:%s", consensusType)
 	return consensus, err
@@ -41,7 +41,32 @@ import (
 /*
 *Simple consensus for solo node in test environment.
  */
-const ContextVersion uint32 = 0
+const (
+	ContextVersion uint32 = 0
+
+	MINE_BLOCK_TIMEOUT     = 30 * time.Second
+	EXCLUSIVE_TASK_TIMEOUT = 5 * time.Minute
+)
+
+// mineBlock asks the solo actor to generate a block out of schedule
+type mineBlock struct {
+	skipEmpty bool
+}
+
+// mineResult is the reply of mineBlock
+type mineResult struct {
+	mined bool
+	err   error
+}
+
+// exclusiveTask runs fn in the solo actor, so no block is generated while it is running
+type exclusiveTask struct {
+	fn func() error
+}
+
+type exclusiveResult struct {
+	err error
+}
 
 type SoloService struct {
 	Account          *account.Account
@@ -92,8 +117,12 @@ func (self *SoloService) Receive(context actor.Context) {
 
 		self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
 
-		timer := time.NewTicker(self.genBlockInterval)
 		self.existCh = make(chan interface{})
+		if self.genBlockInterval == 0 {
+			log.Info("solo block interval is 0, blocks are only generated on request")
+			return
+		}
+		timer := time.NewTicker(self.genBlockInterval)
 		go func() {
 			defer timer.Stop()
 			existCh := self.existCh
@@ -118,10 +147,18 @@ func (self *SoloService) Receive(context actor.Context) {
 		self.incrValidator.AddBlock(msg.Block)
 
 	case *actorTypes.TimeOut:
-		err := self.genBlock()
+		_, err := self.genBlock(false)
 		if err != nil {
 			log.Errorf("Solo genBlock error %s", err)
 		}
+	case *mineBlock:
+		mined, err := self.genBlock(msg.skipEmpty)
+		context.Respond(&mineResult{mined: mined, err: err})
+	case *exclusiveTask:
+		err := msg.fn()
+		// the ledger may be changed by the task, restart the increment check from the next saved block
+		self.incrValidator.Clean()
+		context.Respond(&exclusiveResult{err: err})
 	default:
 		log.Info("solo actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
 	}
@@ -141,15 +178,42 @@ func (self *SoloService) Halt() error {
 	return nil
 }
 
-func (self *SoloService) genBlock() error {
+// MineBlock generates a block immediately with the transactions in tx pool.
+// If skipEmpty is true, no block is generated when there is no transaction to pack,
+// and the returned bool reports whether a block has been generated
+func (self *SoloService) MineBlock(skipEmpty bool) (bool, error) {
+	future := self.pid.RequestFuture(&mineBlock{skipEmpty: skipEmpty}, MINE_BLOCK_TIMEOUT)
+	res, err := future.Result()
+	if err != nil {
+		return false, fmt.Errorf("mine block error:%s", err)
+	}
+	result := res.(*mineResult)
+	return result.mined, result.err
+}
+
+// RunExclusive runs fn in the solo actor, no block is generated until fn returns.
+// It is used to replace the ledger under the consensus, such as reverting to a snapshot
+func (self *SoloService) RunExclusive(fn func() error) error {
+	future := self.pid.RequestFuture(&exclusiveTask{fn: fn}, EXCLUSIVE_TASK_TIMEOUT)
+	res, err := future.Result()
+	if err != nil {
+		return fmt.Errorf("run exclusive task error:%s", err)
+	}
+	return res.(*exclusiveResult).err
+}
+
+func (self *SoloService) genBlock(skipEmpty bool) (bool, error) {
 	block, err := self.makeBlock()
 	if err != nil {
-		return fmt.Errorf("makeBlock error %s", err)
+		return false, fmt.Errorf("makeBlock error %s", err)
+	}
+	if skipEmpty && len(block.Transactions) == 0 {
+		return false, nil
 	}
 
 	result, err := ledger.DefLedger.ExecuteBlock(block)
 	if err != nil {
-		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
+		return false, fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
 	}
 
 	var msg *types.CrossChainMsg
@@ -162,16 +226,16 @@ func (self *SoloService) genBlock() error {
 		hash := msg.Hash()
 		sig, err := signature.Sign(self.Account, hash[:])
 		if err != nil {
-			return fmt.Errorf("[Signature],Sign error:%s.", err)
+			return false, fmt.Errorf("[Signature],Sign error:%s.", err)
 		}
 		msg.SigData = [][]byte{sig}
 	}
 
 	err = ledger.DefLedger.SubmitBlock(block, msg, result)
 	if err != nil {
-		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
+		return false, fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
 	}
-	return nil
+	return true, nil
 }
 
 func (self *SoloService) makeBlock() (*types.Block, error) {
@@ -219,12 +283,18 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 	txRoot := common.ComputeMerkleRoot(txHash)
 
 	blockRoot := ledger.DefLedger.GetBlockRootWithNewTxRoots(height+1, []common.Uint256{txRoot})
+	// blocks may be generated faster than one per second on request, keep the timestamp increasing
+	timestamp := uint32(time.Now().Unix())
+	prevHeader, err := ledger.DefLedger.GetHeaderByHash(prevHash)
+	if err == nil && timestamp <= prevHeader.Timestamp {
+		timestamp = prevHeader.Timestamp + 1
+	}
 	header := &types.Header{
 		Version:          ContextVersion,
 		PrevBlockHash:    prevHash,
 		TransactionsRoot: txRoot,
 		BlockRoot:        blockRoot,
-		Timestamp:        uint32(time.Now().Unix()),
+		Timestamp:        timestamp,
 		Height:           height + 1,
 		ConsensusData:    common.GetNonce(),
 		NextBookkeeper:   nextBookkeeper,
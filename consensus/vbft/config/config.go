@@ -60,6 +60,7 @@ type VbftBlockInfo struct {
 	VrfProof           []byte       `json:"vrf_proof"`
 	LastConfigBlockNum uint32       `json:"last_config_block_num"`
 	NewChainConfig     *ChainConfig `json:"new_chain_config"`
+	ProposalRound      uint32       `json:"proposal_round,omitempty"` //count of previous proposals of proposer at the height
 }
 
 const (
@@ -0,0 +1,325 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/log"
+	vconfig "github.com/qbyyf/ontology/consensus/vbft/config"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/core/utils"
+	gover "github.com/qbyyf/ontology/smartcontract/service/native/governance"
+	nutils "github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	EQUIVOCATION_REPORT_GAS_LIMIT = 200000
+	PROPOSAL_ROUND_FILE           = "vbft_proposal_round"
+	ENDORSED_BLOCK_FILE           = "vbft_endorsed_block"
+)
+
+type equivocationKey struct {
+	blockNum uint32
+	peerIdx  uint32
+}
+
+// proposalRound is the last proposal round of this server, it is persisted so that
+// the proposals after restart are in new rounds
+type proposalRound struct {
+	Height uint32 `json:"height"`
+	Round  uint32 `json:"round"`
+}
+
+// endorsedBlock is the last block this server endorsed, it is persisted so that
+// no other block at the same height is endorsed after restart
+type endorsedBlock struct {
+	Height uint32         `json:"height"`
+	Hash   common.Uint256 `json:"hash"`
+}
+
+func (self *Server) consensusFile(name string) string {
+	return filepath.Join(self.dataDir, name)
+}
+
+// saveConsensusFile persists v before the message depending on it is signed
+func (self *Server) saveConsensusFile(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("json.Marshal %s error:%s", name, err)
+	}
+	file := self.consensusFile(name)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("create dir of %s error:%s", file, err)
+	}
+	if err := ioutil.WriteFile(file+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("write %s error:%s", name, err)
+	}
+	if err := os.Rename(file+".tmp", file); err != nil {
+		return fmt.Errorf("save %s error:%s", name, err)
+	}
+	return nil
+}
+
+// loadProposalRound loads the last proposal round and endorsed block persisted before restart
+func (self *Server) loadProposalRound() {
+	if data, err := ioutil.ReadFile(self.consensusFile(PROPOSAL_ROUND_FILE)); err == nil {
+		round := &proposalRound{}
+		if err := json.Unmarshal(data, round); err != nil {
+			log.Errorf("server %d failed to load proposal round: %s", self.Index, err)
+		} else {
+			self.lastProposal = round
+		}
+	}
+	if data, err := ioutil.ReadFile(self.consensusFile(ENDORSED_BLOCK_FILE)); err == nil {
+		endorsed := &endorsedBlock{}
+		if err := json.Unmarshal(data, endorsed); err != nil {
+			log.Errorf("server %d failed to load endorsed block: %s", self.Index, err)
+		} else {
+			self.lastEndorsed = endorsed
+		}
+	}
+}
+
+// nextProposalRound returns the round of the new proposal for block blkNum. Every proposal at the same height
+// is in a new round, and the round is persisted before the proposal is signed.
+func (self *Server) nextProposalRound(blkNum uint32) (uint32, error) {
+	if blkNum < config.GetSlashEquivocationHeight() {
+		return 0, nil
+	}
+	self.equivocationLock.Lock()
+	defer self.equivocationLock.Unlock()
+	round := &proposalRound{Height: blkNum}
+	if self.lastProposal != nil && self.lastProposal.Height == blkNum {
+		round.Round = self.lastProposal.Round + 1
+	}
+	if err := self.saveConsensusFile(PROPOSAL_ROUND_FILE, round); err != nil {
+		return 0, err
+	}
+	self.lastProposal = round
+	return round.Round, nil
+}
+
+// recordEndorsement persists the block to be endorsed, and refuses another block at the height of the
+// last endorsed one, since endorsing two blocks at the same height is slashed
+func (self *Server) recordEndorsement(blkNum uint32, blkHash common.Uint256) error {
+	if blkNum < config.GetSlashEquivocationHeight() {
+		return nil
+	}
+	self.equivocationLock.Lock()
+	defer self.equivocationLock.Unlock()
+	if self.lastEndorsed != nil && self.lastEndorsed.Height == blkNum {
+		if self.lastEndorsed.Hash != blkHash {
+			return fmt.Errorf("block %s was endorsed at height %d", self.lastEndorsed.Hash.ToHexString(), blkNum)
+		}
+		return nil
+	}
+	endorsed := &endorsedBlock{Height: blkNum, Hash: blkHash}
+	if err := self.saveConsensusFile(ENDORSED_BLOCK_FILE, endorsed); err != nil {
+		return err
+	}
+	self.lastEndorsed = endorsed
+	return nil
+}
+
+// checkEquivocation compares msg with the msgs from the same peer in msg pool,
+// and reports conflicting proposals and endorsements to governance contract.
+func (self *Server) checkEquivocation(msg ConsensusMsg) {
+	if msg.GetBlockNum() < config.GetSlashEquivocationHeight() {
+		return
+	}
+	switch pMsg := msg.(type) {
+	case *blockProposalMsg:
+		self.checkProposalEquivocation(pMsg)
+	case *blockEndorseMsg:
+		self.checkEndorseEquivocation(pMsg)
+	}
+}
+
+func proposalHeaders(msg *blockProposalMsg) []*types.Header {
+	headers := make([]*types.Header, 0, 2)
+	if msg.Block.Block != nil {
+		headers = append(headers, msg.Block.Block.Header)
+	}
+	if msg.Block.EmptyBlock != nil {
+		headers = append(headers, msg.Block.EmptyBlock.Header)
+	}
+	return headers
+}
+
+// checkProposalEquivocation reports the conflicting proposals which are verified the same as governance contract,
+// so that only valid evidence is submitted
+func (self *Server) checkProposalEquivocation(msg *blockProposalMsg) {
+	blkNum := msg.GetBlockNum()
+	proposer := msg.Block.getProposer()
+	pk := self.peerPool.GetPeerPubKey(proposer)
+	if pk == nil {
+		return
+	}
+	prevBlk, prevBlkHash := self.blockPool.getSealedBlock(blkNum - 1)
+	if prevBlk == nil {
+		return
+	}
+	for _, m := range self.msgPool.GetProposalMsgs(blkNum) {
+		p, ok := m.(*blockProposalMsg)
+		if !ok || p == msg || p.Block.getProposer() != proposer {
+			continue
+		}
+		for _, h1 := range proposalHeaders(msg) {
+			for _, h2 := range proposalHeaders(p) {
+				if h1.PrevBlockHash != prevBlkHash {
+					continue
+				}
+				param, err := newEquivocationParam(pk, h1, h2)
+				if err != nil {
+					continue
+				}
+				if _, err := gover.VerifyEquivocation(proposer, config.DefConfig.P2PNode.NetworkId, param); err != nil {
+					continue
+				}
+				log.Warnf("server %d detected conflicting proposals for block %d from %d: %s, %s",
+					self.Index, blkNum, proposer, h1.Hash().ToHexString(), h2.Hash().ToHexString())
+				self.reportEquivocation(proposer, blkNum, param)
+				return
+			}
+		}
+	}
+}
+
+// proposalHeader returns the header of the proposed block with hash in msg pool
+func (self *Server) proposalHeader(blkNum uint32, hash common.Uint256) *types.Header {
+	for _, m := range self.msgPool.GetProposalMsgs(blkNum) {
+		p, ok := m.(*blockProposalMsg)
+		if ok && p.Block.Block != nil && p.Block.Block.Hash() == hash {
+			return p.Block.Block.Header
+		}
+	}
+	return nil
+}
+
+// checkEndorseEquivocation reports the endorsements of two blocks at the same height, whose headers are in msg
+// pool, by their signatures on the endorsement hash
+func (self *Server) checkEndorseEquivocation(msg *blockEndorseMsg) {
+	if msg.EndorseForEmpty || len(msg.EndorsementSig) == 0 {
+		return
+	}
+	pk := self.peerPool.GetPeerPubKey(msg.Endorser)
+	if pk == nil {
+		return
+	}
+	for _, m := range self.msgPool.GetEndorsementsMsgs(msg.BlockNum) {
+		e, ok := m.(*blockEndorseMsg)
+		if !ok || e.Endorser != msg.Endorser || e.EndorseForEmpty || len(e.EndorsementSig) == 0 ||
+			e.EndorsedBlockHash == msg.EndorsedBlockHash {
+			continue
+		}
+		h1 := self.proposalHeader(msg.BlockNum, msg.EndorsedBlockHash)
+		h2 := self.proposalHeader(msg.BlockNum, e.EndorsedBlockHash)
+		if h1 == nil || h2 == nil {
+			log.Warnf("server %d detected conflicting endorsements for block %d from %d without proposals: %s, %s",
+				self.Index, msg.BlockNum, msg.Endorser,
+				msg.EndorsedBlockHash.ToHexString(), e.EndorsedBlockHash.ToHexString())
+			continue
+		}
+		param := &gover.SlashEquivocationParam{
+			PeerPubkey:   vconfig.PubkeyID(pk),
+			ChainId:      config.DefConfig.P2PNode.NetworkId,
+			EvidenceType: gover.ENDORSEMENT_EVIDENCE,
+			Header1:      h1.ToArray(),
+			Sig1:         msg.EndorsementSig,
+			Header2:      h2.ToArray(),
+			Sig2:         e.EndorsementSig,
+		}
+		if _, err := gover.VerifyEquivocation(msg.Endorser, config.DefConfig.P2PNode.NetworkId, param); err != nil {
+			continue
+		}
+		log.Warnf("server %d detected conflicting endorsements for block %d from %d: %s, %s",
+			self.Index, msg.BlockNum, msg.Endorser,
+			msg.EndorsedBlockHash.ToHexString(), e.EndorsedBlockHash.ToHexString())
+		self.reportEquivocation(msg.Endorser, msg.BlockNum, param)
+		return
+	}
+}
+
+// reportEquivocation submits the verified evidence once for each peer at a height
+func (self *Server) reportEquivocation(peerIdx uint32, blkNum uint32, param *gover.SlashEquivocationParam) {
+	key := equivocationKey{blockNum: blkNum, peerIdx: peerIdx}
+	self.equivocationLock.Lock()
+	if self.equivocationReported[key] {
+		self.equivocationLock.Unlock()
+		return
+	}
+	self.equivocationReported[key] = true
+	self.equivocationLock.Unlock()
+
+	tx, err := self.createEquivocationTransaction(blkNum, param)
+	if err != nil {
+		log.Errorf("server %d failed to create equivocation report of %d for block %d: %s",
+			self.Index, peerIdx, blkNum, err)
+		return
+	}
+	log.Infof("server %d submit equivocation report tx %s of %d for block %d",
+		self.Index, tx.Hash().ToHexString(), peerIdx, blkNum)
+	self.poolActor.AppendTx(tx)
+}
+
+func newEquivocationParam(pk keypair.PublicKey, header1, header2 *types.Header) (*gover.SlashEquivocationParam, error) {
+	if len(header1.SigData) == 0 || len(header2.SigData) == 0 {
+		return nil, fmt.Errorf("no sigdata in header")
+	}
+	return &gover.SlashEquivocationParam{
+		PeerPubkey:   vconfig.PubkeyID(pk),
+		ChainId:      config.DefConfig.P2PNode.NetworkId,
+		EvidenceType: gover.PROPOSAL_EVIDENCE,
+		Header1:      header1.ToArray(),
+		Sig1:         header1.SigData[0],
+		Header2:      header2.ToArray(),
+		Sig2:         header2.SigData[0],
+	}, nil
+}
+
+func (self *Server) createEquivocationTransaction(blkNum uint32, param *gover.SlashEquivocationParam) (*types.Transaction, error) {
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	mutable := utils.BuildNativeTransaction(nutils.GovernanceContractAddress, gover.SLASH_EQUIVOCATION, sink.Bytes())
+	mutable.Nonce = blkNum
+	mutable.GasPrice = config.DefConfig.Common.GasPrice
+	mutable.GasLimit = EQUIVOCATION_REPORT_GAS_LIMIT
+	mutable.Payer = types.AddressFromPubKey(self.account.PubKey())
+
+	txHash := mutable.Hash()
+	sig, err := self.account.Sign(txHash[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign equivocation report: %s", err)
+	}
+	mutable.Sigs = append(mutable.Sigs, types.Sig{
+		PubKeys: []keypair.PublicKey{self.account.PubKey()},
+		M:       1,
+		SigData: [][]byte{sig},
+	})
+	return mutable.IntoImmutable()
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vbft
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	vconfig "github.com/qbyyf/ontology/consensus/vbft/config"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
+	gover "github.com/qbyyf/ontology/smartcontract/service/native/governance"
+)
+
+func constructProposalHeader(t *testing.T, acc *account.Account, proposer uint32, round uint32, timestamp uint32,
+	nonce uint64, txRoot common.Uint256) *types.Header {
+	payload, err := json.Marshal(&vconfig.VbftBlockInfo{Proposer: proposer, ProposalRound: round})
+	if err != nil {
+		t.Fatalf("marshal block info: %s", err)
+	}
+	header := &types.Header{
+		Height:           10,
+		PrevBlockHash:    common.Uint256{9},
+		Timestamp:        timestamp,
+		TransactionsRoot: txRoot,
+		ConsensusData:    nonce,
+		ConsensusPayload: payload,
+	}
+	hash := header.Hash()
+	sig, err := signature.Sign(acc, hash[:])
+	if err != nil {
+		t.Fatalf("sign header: %s", err)
+	}
+	header.SigData = [][]byte{sig}
+	return header
+}
+
+func TestCheckConflictingProposals(t *testing.T) {
+	acc := account.NewAccount("SHA256withECDSA")
+	block := constructProposalHeader(t, acc, 1, 0, 100, 1, common.Uint256{1})
+	emptyBlock := constructProposalHeader(t, acc, 1, 0, 100, 2, common.ComputeMerkleRoot(nil))
+	sameTime := constructProposalHeader(t, acc, 1, 0, 100, 2, common.Uint256{2})
+	conflict := constructProposalHeader(t, acc, 1, 0, 101, 3, common.Uint256{1})
+	other := constructProposalHeader(t, acc, 2, 0, 101, 4, common.Uint256{1})
+	nextRound := constructProposalHeader(t, acc, 1, 1, 101, 5, common.Uint256{1})
+	otherPrev := constructProposalHeader(t, acc, 1, 0, 101, 6, common.Uint256{1})
+	otherPrev.PrevBlockHash = common.Uint256{8}
+
+	if err := gover.CheckConflictingProposals(1, block, emptyBlock); err == nil {
+		t.Errorf("block and empty block of one proposal should not conflict")
+	}
+	if err := gover.CheckConflictingProposals(1, block, sameTime); err != nil {
+		t.Errorf("non-empty blocks with the same timestamp not detected: %s", err)
+	}
+	if err := gover.CheckConflictingProposals(1, block, block); err == nil {
+		t.Errorf("same header should not conflict")
+	}
+	if err := gover.CheckConflictingProposals(1, block, other); err == nil {
+		t.Errorf("headers of different proposers should not conflict")
+	}
+	if err := gover.CheckConflictingProposals(1, block, nextRound); err == nil {
+		t.Errorf("proposals of different rounds should not conflict")
+	}
+	if err := gover.CheckConflictingProposals(1, block, otherPrev); err == nil {
+		t.Errorf("proposals on different previous blocks should not conflict")
+	}
+	if err := gover.CheckConflictingProposals(1, block, conflict); err != nil {
+		t.Errorf("conflicting proposals not detected: %s", err)
+	}
+
+	param, err := newEquivocationParam(acc.PublicKey, block, conflict)
+	if err != nil {
+		t.Fatalf("new equivocation param: %s", err)
+	}
+	if _, err := gover.VerifyEquivocation(1, config.DefConfig.P2PNode.NetworkId, param); err != nil {
+		t.Errorf("verify equivocation failed: %s", err)
+	}
+	param.ChainId++
+	if _, err := gover.VerifyEquivocation(1, config.DefConfig.P2PNode.NetworkId, param); err == nil {
+		t.Errorf("evidence of another chain should be rejected")
+	}
+	param.ChainId--
+	param.Sig2 = emptyBlock.SigData[0]
+	if _, err := gover.VerifyEquivocation(1, config.DefConfig.P2PNode.NetworkId, param); err == nil {
+		t.Errorf("evidence with wrong signature should be rejected")
+	}
+}
+
+func TestNextProposalRound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vbft")
+	if err != nil {
+		t.Fatalf("create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() {
+		config.DefConfig.P2PNode.NetworkId = networkId
+	}()
+
+	server := &Server{dataDir: dir}
+	for i, expect := range []uint32{0, 1, 2} {
+		round, err := server.nextProposalRound(10)
+		if err != nil || round != expect {
+			t.Errorf("proposal %d round %d, expect %d, err: %v", i, round, expect, err)
+		}
+	}
+	//endorsed block is kept after restart
+	if err := server.recordEndorsement(10, common.Uint256{1}); err != nil {
+		t.Errorf("record endorsement: %s", err)
+	}
+	if err := server.recordEndorsement(10, common.Uint256{1}); err != nil {
+		t.Errorf("endorse the same block again: %s", err)
+	}
+	//proposal round is kept after restart
+	restarted := &Server{dataDir: dir}
+	restarted.loadProposalRound()
+	if round, _ := restarted.nextProposalRound(10); round != 3 {
+		t.Errorf("proposal round after restart %d, expect 3", round)
+	}
+	if round, _ := restarted.nextProposalRound(11); round != 0 {
+		t.Errorf("proposal round of next block %d, expect 0", round)
+	}
+	if err := restarted.recordEndorsement(10, common.Uint256{2}); err == nil {
+		t.Errorf("endorsed another block at the same height after restart")
+	}
+	if err := restarted.recordEndorsement(11, common.Uint256{2}); err != nil {
+		t.Errorf("endorse next block: %s", err)
+	}
+}
@@ -25,11 +25,11 @@ import (
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
 	vconfig "github.com/qbyyf/ontology/consensus/vbft/config"
-	"github.com/qbyyf/ontology/core/ledger"
-	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/core/types"
+	gover "github.com/qbyyf/ontology/smartcontract/service/native/governance"
 )
 
 type ConsensusMsgPayload struct {
@@ -196,7 +196,7 @@ func (self *Server) constructBlock(blkNum uint32, prevBlkHash common.Uint256, tx
 	}
 
 	txRoot := common.ComputeMerkleRoot(txHash)
-	blockRoot := ledger.DefLedger.GetBlockRootWithNewTxRoots(lastBlock.Block.Header.Height, []common.Uint256{lastBlock.Block.Header.TransactionsRoot, txRoot})
+	blockRoot := self.ledger.GetBlockRootWithNewTxRoots(lastBlock.Block.Header.Height, []common.Uint256{lastBlock.Block.Header.TransactionsRoot, txRoot})
 
 	blkHeader := &types.Header{
 		PrevBlockHash:    prevBlkHash,
@@ -212,11 +212,11 @@ func (self *Server) constructBlock(blkNum uint32, prevBlkHash common.Uint256, tx
 		Transactions: txs,
 	}
 	blkHash := blk.Hash()
-	sig, err := signature.Sign(self.account, blkHash[:])
+	sig, err := self.account.Sign(blkHash[:])
 	if err != nil {
 		return nil, fmt.Errorf("sign block failed, block hash:%s, error: %s", blkHash.ToHexString(), err)
 	}
-	blkHeader.Bookkeepers = []keypair.PublicKey{self.account.PublicKey}
+	blkHeader.Bookkeepers = []keypair.PublicKey{self.account.PubKey()}
 	blkHeader.SigData = [][]byte{sig}
 
 	return blk, nil
@@ -237,7 +237,7 @@ func (self *Server) constructCrossChainMsg(blkNum uint32) (*types.CrossChainMsg,
 		StatesRoot: root,
 	}
 	hash := msg.Hash()
-	sig, err := signature.Sign(self.account, hash[:])
+	sig, err := self.account.Sign(hash[:])
 	if err != nil {
 		return nil, fmt.Errorf("sign cross chain msg root failed,msg hash:%s,err:%s", hash.ToHexString(), err)
 	}
@@ -245,7 +245,8 @@ func (self *Server) constructCrossChainMsg(blkNum uint32) (*types.CrossChainMsg,
 	return msg, nil
 }
 
-func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types.Transaction, chainconfig *vconfig.ChainConfig) (*blockProposalMsg, error) {
+func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types.Transaction, chainconfig *vconfig.ChainConfig,
+	round uint32) (*blockProposalMsg, error) {
 
 	prevBlk, prevBlkHash := self.blockPool.getSealedBlock(blkNum - 1)
 	if prevBlk == nil {
@@ -256,7 +257,7 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 		blocktimestamp = prevBlk.Block.Header.Timestamp + 1
 	}
 
-	vrfValue, vrfProof, err := computeVrf(self.account.PrivateKey, blkNum, prevBlk.getVrfValue())
+	vrfValue, vrfProof, err := computeVrf(self.account, blkNum, prevBlk.getVrfValue())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get vrf and proof: %s", err)
 	}
@@ -274,6 +275,7 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 		VrfProof:           vrfProof,
 		LastConfigBlockNum: lastConfigBlkNum,
 		NewChainConfig:     chainconfig,
+		ProposalRound:      round,
 	}
 	consensusPayload, err := json.Marshal(vbftBlkInfo)
 	if err != nil {
@@ -328,7 +330,7 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 		proposerSig = proposal.EmptyBlockProposerSig
 		blkHash = proposal.Block.EmptyBlock.Hash()
 	}
-	endorserSig, err = signature.Sign(self.account, blkHash[:])
+	endorserSig, err = self.account.Sign(blkHash[:])
 	if err != nil {
 		return nil, fmt.Errorf("endorser failed to sign block. hash:%x, err: %s", blkHash, err)
 	}
@@ -342,9 +344,15 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 		ProposerSig:       proposerSig,
 		EndorserSig:       endorserSig,
 	}
+	if msg.BlockNum >= config.GetSlashEquivocationHeight() {
+		hash := gover.EndorsementHash(config.DefConfig.P2PNode.NetworkId, blkHash, forEmpty)
+		if msg.EndorsementSig, err = self.account.Sign(hash[:]); err != nil {
+			return nil, fmt.Errorf("endorser failed to sign endorsement. hash:%x, err: %s", blkHash, err)
+		}
+	}
 	if proposal.Block.CrossChainMsg != nil {
 		hash := proposal.Block.CrossChainMsg.Hash()
-		sig, err := signature.Sign(self.account, hash[:])
+		sig, err := self.account.Sign(hash[:])
 		if err != nil {
 			return nil, fmt.Errorf("sign cross chain msg root failed,msg hash:%s,err:%s", hash.ToHexString(), err)
 		}
@@ -374,7 +382,7 @@ func (self *Server) constructCommitMsg(proposal *blockProposalMsg, endorses []*b
 		proposerSig = proposal.EmptyBlockProposerSig
 		blkHash = proposal.Block.EmptyBlock.Hash()
 	}
-	committerSig, err = signature.Sign(self.account, blkHash[:])
+	committerSig, err = self.account.Sign(blkHash[:])
 	if err != nil {
 		return nil, fmt.Errorf("endorser failed to sign block. hash:%x, caused by: %s", blkHash, err)
 	}
@@ -412,7 +420,7 @@ func (self *Server) constructCommitMsg(proposal *blockProposalMsg, endorses []*b
 	}
 
 	if proposal.Block.CrossChainMsg != nil && commitCrossChain {
-		sig, err := signature.Sign(self.account, hash[:])
+		sig, err := self.account.Sign(hash[:])
 		if err != nil {
 			return nil, fmt.Errorf("sign cross chain msg root failed,msg hash:%s,err:%s", hash.ToHexString(), err)
 		}
@@ -455,7 +463,7 @@ func (self *Server) constructProposalFetchMsg(blkNum uint32, proposer uint32) *p
 }
 
 func (self *Server) constructBlockSubmitMsg(blkNum uint32, stateRoot common.Uint256) (*blockSubmitMsg, error) {
-	submitSig, err := signature.Sign(self.account, stateRoot[:])
+	submitSig, err := self.account.Sign(stateRoot[:])
 	if err != nil {
 		return nil, fmt.Errorf("submit failed to sign stateroot hash:%x, err: %s", stateRoot, err)
 	}
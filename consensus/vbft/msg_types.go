@@ -27,8 +27,10 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology-crypto/signature"
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/serialization"
 	vconfig "github.com/qbyyf/ontology/consensus/vbft/config"
+	gover "github.com/qbyyf/ontology/smartcontract/service/native/governance"
 )
 
 type MsgType uint8
@@ -158,6 +160,7 @@ type blockEndorseMsg struct {
 	EndorserSig              []byte          `json:"endorser_sig"`
 	CrossChainMsgHash        common.Uint256  `json:"cross_chain_msg_hash"`
 	CrossChainMsgEndorserSig []byte          `json:"cross_chain_msg_endorser_sig"`
+	EndorsementSig           []byte          `json:"endorsement_sig,omitempty"`
 }
 
 func (msg *blockEndorseMsg) Type() MsgType {
@@ -173,6 +176,16 @@ func (msg *blockEndorseMsg) Verify(pub keypair.PublicKey) error {
 	if !signature.Verify(pub, hash[:], sig) {
 		return fmt.Errorf("failed to verify block sig")
 	}
+	if msg.EndorsementSig != nil {
+		eSig, err := signature.Deserialize(msg.EndorsementSig)
+		if err != nil {
+			return fmt.Errorf("deserialize endorsement sig: %s", err)
+		}
+		eHash := gover.EndorsementHash(config.DefConfig.P2PNode.NetworkId, hash, msg.EndorseForEmpty)
+		if !signature.Verify(pub, eHash[:], eSig) {
+			return fmt.Errorf("failed to verify endorsement sig")
+		}
+	}
 	if msg.CrossChainMsgEndorserSig != nil {
 		//verify cross states endorse sig
 		cSig, err := signature.Deserialize(msg.CrossChainMsgEndorserSig)
@@ -26,7 +26,6 @@ import (
 
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/log"
-	"github.com/qbyyf/ontology/core/ledger"
 )
 
 type SyncCheckReq struct {
@@ -176,7 +175,7 @@ func (self *Syncer) run() {
 			for self.nextReqBlkNum <= self.targetBlkNum {
 				// FIXME: compete with ledger syncing
 				var blk *Block
-				if self.nextReqBlkNum <= ledger.DefLedger.GetCurrentBlockHeight() {
+				if self.nextReqBlkNum <= self.server.ledger.GetCurrentBlockHeight() {
 					blk, _ = self.server.blockPool.getSealedBlock(self.nextReqBlkNum)
 				}
 				if blk == nil {
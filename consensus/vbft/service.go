@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"time"
@@ -29,13 +30,15 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology-crypto/vrf"
 	"github.com/ontio/ontology-eventbus/actor"
-	"github.com/qbyyf/ontology/account"
+	"github.com/ontio/ontology-eventbus/eventhub"
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
 	actorTypes "github.com/qbyyf/ontology/consensus/actor"
 	vconfig "github.com/qbyyf/ontology/consensus/vbft/config"
 	"github.com/qbyyf/ontology/core/ledger"
 	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/qbyyf/ontology/core/utils"
 	"github.com/qbyyf/ontology/events"
@@ -66,6 +69,22 @@ const (
 	CAP_MSG_SEND_CHANNEL = 16
 )
 
+const (
+	SERVER_ACTOR_NAME = "consensus_vbft"
+	STOP_TIMEOUT      = 30 * time.Second
+)
+
+// ServerEnv is the environment a server runs in, which is process wide for the server of node.
+// The servers of an in-process network run in their own environments
+type ServerEnv struct {
+	Ledger  *ledger.Ledger
+	Name    string             // name of the server actor
+	DataDir string             // dir of the consensus files
+	EvtHub  *eventhub.EventHub // event hub where the saved blocks of ledger are published
+}
+
+type stopServer struct{}
+
 type BftAction struct {
 	Type     BftActionType
 	BlockNum uint32
@@ -89,12 +108,13 @@ type p2pMsgPayload struct {
 
 type Server struct {
 	Index         uint32
-	account       *account.Account
+	account       signature.VrfSigner
 	poolActor     *actorTypes.TxPoolActor
 	p2p           p2p.P2P
 	ledger        *ledger.Ledger
 	incrValidator *increment.IncrementValidator
 	pid           *actor.PID
+	dataDir       string
 
 	// some config
 	msgHistoryDuration uint32
@@ -128,29 +148,48 @@ type Server struct {
 	quitC      chan struct{}
 	quit       bool
 	quitWg     sync.WaitGroup
+
+	equivocationLock     sync.Mutex
+	equivocationReported map[equivocationKey]bool
+	lastProposal         *proposalRound
+	lastEndorsed         *endorsedBlock
 }
 
-func NewVbftServer(account *account.Account, txpool *actor.PID, p2p p2p.P2P) (*Server, error) {
+func NewVbftServer(account signature.VrfSigner, txpool *actor.PID, p2p p2p.P2P) (*Server, error) {
+	return NewVbftServerWithEnv(account, txpool, p2p, &ServerEnv{
+		Ledger:  ledger.DefLedger,
+		Name:    SERVER_ACTOR_NAME,
+		DataDir: filepath.Join(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName),
+		EvtHub:  events.DefEvtHub,
+	})
+}
+
+// NewVbftServerWithEnv returns a server running in env instead of the process wide one
+func NewVbftServerWithEnv(account signature.VrfSigner, txpool *actor.PID, p2p p2p.P2P, env *ServerEnv) (*Server, error) {
 	server := &Server{
 		msgHistoryDuration: 64,
 		account:            account,
 		poolActor:          &actorTypes.TxPoolActor{Pool: txpool},
 		p2p:                p2p,
-		ledger:             ledger.DefLedger,
+		ledger:             env.Ledger,
+		dataDir:            env.DataDir,
 		incrValidator:      increment.NewIncrementValidator(20),
+
+		equivocationReported: make(map[equivocationKey]bool),
 	}
 	server.stateMgr = newStateMgr(server)
+	server.loadProposalRound()
 
 	props := actor.FromProducer(func() actor.Actor {
 		return server
 	})
 
-	pid, err := actor.SpawnNamed(props, "consensus_vbft")
+	pid, err := actor.SpawnNamed(props, env.Name)
 	if err != nil {
 		return nil, err
 	}
 	server.pid = pid
-	server.sub = events.NewActorSubscriber(pid)
+	server.sub = events.NewActorSubscriber(pid, env.EvtHub)
 
 	if err := server.initialize(); err != nil {
 		return nil, fmt.Errorf("vbft server start failed: %s", err)
@@ -174,6 +213,9 @@ func (self *Server) Receive(context actor.Context) {
 		log.Info("vbft actor start consensus")
 	case *actorTypes.StopConsensus:
 		self.stop()
+	case *stopServer:
+		self.stop()
+		context.Respond(msg)
 	case *message.SaveBlockCompleteMsg:
 		log.Infof("vbft actor SaveBlockCompleteMsg receives block complete event. block height=%d, numtx=%d",
 			msg.Block.Header.Height, len(msg.Block.Transactions))
@@ -203,6 +245,17 @@ func (self *Server) Halt() error {
 	return nil
 }
 
+// Stop stops the consensus and the actor of server, and returns after they are stopped. Unlike Halt,
+// the server in an in-process network can be replaced by a new one after it is stopped
+func (self *Server) Stop() error {
+	_, err := self.pid.RequestFuture(&stopServer{}, STOP_TIMEOUT).Result()
+	if err != nil {
+		return fmt.Errorf("stop vbft server error:%s", err)
+	}
+	self.pid.GracefulStop()
+	return nil
+}
+
 func (self *Server) handleBlockPersistCompleted(block *types.Block) {
 	log.Infof("persist block: %d, %x", block.Header.Height, block.Hash())
 
@@ -364,7 +417,7 @@ func (self *Server) updateChainConfig() error {
 	// . reset remove peer connections, create new connections with new peers
 	self.updateTimerParams(self.config)
 
-	pubkey := vconfig.PubkeyID(self.account.PublicKey)
+	pubkey := vconfig.PubkeyID(self.account.PubKey())
 	peermap := make(map[uint32]string)
 	for _, p := range self.GetChainConfig().Peers {
 		peermap[p.Index] = p.ID
@@ -423,7 +476,7 @@ func (self *Server) initialize() error {
 	// TODO: load config from chain
 
 	// TODO: configurable log
-	selfNodeId := vconfig.PubkeyID(self.account.PublicKey)
+	selfNodeId := vconfig.PubkeyID(self.account.PubKey())
 	log.Infof("server: %s starting", selfNodeId)
 
 	store, err := OpenBlockStore(self.ledger, self.pid)
@@ -472,7 +525,7 @@ func (self *Server) initialize() error {
 	}
 
 	//index equal math.MaxUint32  is noconsensus node
-	id := vconfig.PubkeyID(self.account.PublicKey)
+	id := vconfig.PubkeyID(self.account.PubKey())
 	index, present := self.peerPool.GetPeerIndex(id)
 	if present {
 		self.Index = index
@@ -512,7 +565,7 @@ func (self *Server) initialize() error {
 
 func (self *Server) start() error {
 	// check if server pubkey support VRF
-	if !vrf.ValidatePrivateKey(self.account.PrivateKey) || !vrf.ValidatePublicKey(self.account.PublicKey) {
+	if !vrf.ValidatePublicKey(self.account.PubKey()) {
 		return fmt.Errorf("server %d consensus start failed: invalid account key for VRF", self.Index)
 	}
 
@@ -769,6 +822,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		log.Debugf("dup msg with msg type %d from %d", msg.Type(), peerIdx)
 		return
 	}
+	self.checkEquivocation(msg)
 
 	switch msg.Type() {
 	case BlockProposalMessage:
@@ -1942,6 +1996,11 @@ func (self *Server) endorseBlock(proposal *blockProposalMsg, forEmpty bool) erro
 			log.Errorf("server %d, endorsing %d, changed from true to false", self.Index, blkNum)
 		}
 	}
+	if !forEmpty {
+		if err := self.recordEndorsement(blkNum, proposal.Block.Block.Hash()); err != nil {
+			return fmt.Errorf("failed to endorse block %d: %s", blkNum, err)
+		}
+	}
 
 	// build endorsement msg
 	endorseMsg, err := self.constructEndorseMsg(proposal, forEmpty)
@@ -2176,7 +2235,7 @@ func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 
 //checkUpdateChainConfig query leveldb check is force update
 func (self *Server) checkUpdateChainConfig(blkNum uint32) bool {
-	force, err := isUpdate(self.blockPool.getExecWriteSet(blkNum-1), self.GetChainConfig().View)
+	force, err := isUpdate(self.blockPool.getExecWriteSet(blkNum-1), self.ledger, self.GetChainConfig().View)
 	if err != nil {
 		log.Errorf("checkUpdateChainConfig err:%s", err)
 		return false
@@ -2224,7 +2283,7 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 	//check need upate chainconfig
 	var cfg *vconfig.ChainConfig
 	if self.checkNeedUpdateChainConfig(blkNum) || self.checkUpdateChainConfig(blkNum) {
-		chainconfig, err := getChainConfig(self.blockPool.getExecWriteSet(blkNum-1), blkNum)
+		chainconfig, err := getChainConfig(self.blockPool.getExecWriteSet(blkNum-1), self.ledger, blkNum)
 		if err != nil {
 			return fmt.Errorf("getChainConfig failed:%s", err)
 		}
@@ -2254,7 +2313,11 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 		log.Infof("make proposal get %d valid tx from pool", len(userTxs))
 	}
 
-	proposal, err := self.constructProposalMsg(blkNum, sysTxs, userTxs, cfg)
+	round, err := self.nextProposalRound(blkNum)
+	if err != nil {
+		return fmt.Errorf("failed to get proposal round: %s", err)
+	}
+	proposal, err := self.constructProposalMsg(blkNum, sysTxs, userTxs, cfg, round)
 	if err != nil {
 		return fmt.Errorf("failed to construct proposal: %s", err)
 	}
@@ -18,7 +18,10 @@
 package genesis
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ontio/ontology-crypto/keypair"
@@ -49,3 +52,33 @@ func TestNewParamDeployAndInit(t *testing.T) {
 	assert.NotNil(t, deployTx)
 	assert.NotNil(t, initTx)
 }
+
+// the hash of block 0 of MainNet
+const MAINNET_GENESIS_HASH = "1b8fa7f242d0eeb4395f89cbb59e4c29634047e33245c4914306e78a88e14ce5"
+
+// TestMainNetFuzzCorpus checks the seed corpus of the block and transaction fuzz targets in core/types is MainNet
+// block 0 and its transactions, which are rebuilt from the MainNet genesis config
+func TestMainNetFuzzCorpus(t *testing.T) {
+	genesis, networkId := config.DefConfig.Genesis, config.DefConfig.P2PNode.NetworkId
+	defer func() {
+		config.DefConfig.Genesis, config.DefConfig.P2PNode.NetworkId = genesis, networkId
+	}()
+	config.DefConfig.Genesis = config.MainNetConfig
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	assert.Nil(t, err)
+	block, err := BuildGenesisBlock(bookkeepers, config.MainNetConfig)
+	assert.Nil(t, err)
+	hash := block.Hash()
+	assert.Equal(t, MAINNET_GENESIS_HASH, hash.ToHexString())
+
+	corpus := func(target, name string, data []byte) {
+		file, err := ioutil.ReadFile(filepath.Join("..", "types", "testdata", "fuzz", target, name))
+		assert.Nil(t, err)
+		assert.Equal(t, fmt.Sprintf("go test fuzz v1\n[]byte(%q)\n", data), string(file), name)
+	}
+	corpus("FuzzBlockFromRawBytes", "mainnet_block_0", block.ToArray())
+	for i, tx := range block.Transactions {
+		corpus("FuzzTransactionFromRawBytes", fmt.Sprintf("mainnet_block_0_tx_%d", i), tx.Raw)
+	}
+}
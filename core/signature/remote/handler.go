@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package remote
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/core/signature"
+)
+
+// Handler serves the remote signer protocol with local keys. It is the reference of signing daemon,
+// and the stand-in of daemon in tests
+type Handler struct {
+	token string
+	keys  map[string]signature.VrfSigner
+	mux   *http.ServeMux
+}
+
+// NewHandler creates handler of keys by key id. Requests must carry the bearer token if it is not empty
+func NewHandler(token string, keys map[string]signature.VrfSigner) *Handler {
+	h := &Handler{
+		token: token,
+		keys:  keys,
+		mux:   http.NewServeMux(),
+	}
+	h.mux.HandleFunc(PATH_PUBKEY, h.handlePubKey)
+	h.mux.HandleFunc(PATH_SIGN, h.handleSign)
+	h.mux.HandleFunc(PATH_VRF, h.handleVrf)
+	return h
+}
+
+func (this *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("invalid http method"))
+		return
+	}
+	if this.token != "" {
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+this.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+			return
+		}
+	}
+	this.mux.ServeHTTP(w, r)
+}
+
+func (this *Handler) handlePubKey(w http.ResponseWriter, r *http.Request) {
+	req := &PubKeyReq{}
+	key, err := this.readRequest(r, req, &req.KeyId)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeResult(w, &PubKeyRsp{
+		PublicKey: hex.EncodeToString(keypair.SerializePublicKey(key.PubKey())),
+		Scheme:    key.Scheme().Name(),
+	})
+}
+
+func (this *Handler) handleSign(w http.ResponseWriter, r *http.Request) {
+	req := &SignReq{}
+	key, err := this.readRequest(r, req, &req.KeyId)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	data, err := hex.DecodeString(req.Data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid data:%s", err))
+		return
+	}
+	sig, err := key.Sign(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeResult(w, &SignRsp{Signature: hex.EncodeToString(sig)})
+}
+
+func (this *Handler) handleVrf(w http.ResponseWriter, r *http.Request) {
+	req := &SignReq{}
+	key, err := this.readRequest(r, req, &req.KeyId)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	data, err := hex.DecodeString(req.Data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid data:%s", err))
+		return
+	}
+	value, proof, err := key.Vrf(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeResult(w, &VrfRsp{Value: hex.EncodeToString(value), Proof: hex.EncodeToString(proof)})
+}
+
+func (this *Handler) readRequest(r *http.Request, req interface{}, keyId *string) (signature.VrfSigner, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body error:%s", err)
+	}
+	err = json.Unmarshal(body, req)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request:%s", err)
+	}
+	key, ok := this.keys[*keyId]
+	if !ok {
+		return nil, fmt.Errorf("unknown key:%s", *keyId)
+	}
+	return key, nil
+}
+
+func writeResult(w http.ResponseWriter, rsp interface{}) {
+	data, _ := json.Marshal(rsp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	data, _ := json.Marshal(&ErrorRsp{Error: err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
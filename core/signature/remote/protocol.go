@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package remote implements signature.VrfSigner by a remote signing daemon, so that the private key,
+// such as the consensus key of validator, never lives in the node process.
+//
+// The daemon serves json over HTTP(S) POST. Every request carries the key id, and may be authorized by
+// "Authorization: Bearer <token>" header, which is only sent over HTTPS. Data and results are in hex.
+// Non 200 status means failure, and the body is ErrorRsp.
+//
+//	POST /pubkey {"key_id"}         => {"public_key", "scheme"}
+//	POST /sign   {"key_id", "data"} => {"signature"}  (serialized signature of ontology-crypto)
+//	POST /vrf    {"key_id", "data"} => {"value", "proof"}
+package remote
+
+const (
+	PATH_PUBKEY = "/pubkey"
+	PATH_SIGN   = "/sign"
+	PATH_VRF    = "/vrf"
+)
+
+type PubKeyReq struct {
+	KeyId string `json:"key_id"`
+}
+
+type PubKeyRsp struct {
+	PublicKey string `json:"public_key"`
+	Scheme    string `json:"scheme"`
+}
+
+type SignReq struct {
+	KeyId string `json:"key_id"`
+	Data  string `json:"data"`
+}
+
+type SignRsp struct {
+	Signature string `json:"signature"`
+}
+
+type VrfRsp struct {
+	Value string `json:"value"`
+	Proof string `json:"proof"`
+}
+
+type ErrorRsp struct {
+	Error string `json:"error"`
+}
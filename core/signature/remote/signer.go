@@ -0,0 +1,211 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package remote
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/ontio/ontology-crypto/vrf"
+	"github.com/qbyyf/ontology/core/signature"
+)
+
+const DEFAULT_TIMEOUT = 5 * time.Second
+
+type Config struct {
+	Url     string        //address of signing daemon, such as https://127.0.0.1:20400
+	KeyId   string        //id of key in signing daemon
+	Token   string        //bearer token, empty for no authorization. Only sent over https
+	Timeout time.Duration //timeout of every request, DEFAULT_TIMEOUT if zero
+	TLS     *tls.Config   //tls config of https, such as client certificate. nil for default
+}
+
+// Signer signs data by remote signing daemon. Results are verified by the public key before returned,
+// so a misbehaving daemon cannot make the node produce invalid signatures
+type Signer struct {
+	config *Config
+	client *http.Client
+	pubKey keypair.PublicKey
+	scheme s.SignatureScheme
+}
+
+// NewSigner creates remote signer, and fetches the public key and signature scheme of key from daemon
+func NewSigner(cfg *Config) (*Signer, error) {
+	if cfg.Url == "" {
+		return nil, fmt.Errorf("remote signer url cannot be empty")
+	}
+	u, err := url.Parse(cfg.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote signer url:%s", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid remote signer url scheme:%s", u.Scheme)
+	}
+	if cfg.Token != "" && u.Scheme != "https" {
+		return nil, fmt.Errorf("bearer token of remote signer can only be sent over https")
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	signer := &Signer{
+		config: cfg,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: cfg.TLS},
+		},
+	}
+	rsp := &PubKeyRsp{}
+	err = signer.request(PATH_PUBKEY, &PubKeyReq{KeyId: cfg.KeyId}, rsp)
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(rsp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of remote signer:%s", err)
+	}
+	signer.pubKey, err = keypair.DeserializePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of remote signer:%s", err)
+	}
+	signer.scheme, err = s.GetScheme(rsp.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature scheme of remote signer:%s", err)
+	}
+	return signer, nil
+}
+
+// NewTLSConfig creates the tls config of https from PEM files. The daemon is verified by the CA certificate in
+// caFile, or by the system roots if caFile is empty. certFile and keyFile are the client certificate, which is
+// not presented if they are empty
+func NewTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read remote signer ca error:%s", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate in remote signer ca:%s", caFile)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load remote signer client certificate error:%s", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func (this *Signer) PubKey() keypair.PublicKey {
+	return this.pubKey
+}
+
+func (this *Signer) Scheme() s.SignatureScheme {
+	return this.scheme
+}
+
+func (this *Signer) Sign(data []byte) ([]byte, error) {
+	rsp := &SignRsp{}
+	err := this.request(PATH_SIGN, &SignReq{KeyId: this.config.KeyId, Data: hex.EncodeToString(data)}, rsp)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(rsp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature of remote signer:%s", err)
+	}
+	err = signature.Verify(this.pubKey, data, sig)
+	if err != nil {
+		return nil, fmt.Errorf("verify signature of remote signer error:%s", err)
+	}
+	return sig, nil
+}
+
+func (this *Signer) Vrf(data []byte) ([]byte, []byte, error) {
+	rsp := &VrfRsp{}
+	err := this.request(PATH_VRF, &SignReq{KeyId: this.config.KeyId, Data: hex.EncodeToString(data)}, rsp)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := hex.DecodeString(rsp.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid vrf value of remote signer:%s", err)
+	}
+	proof, err := hex.DecodeString(rsp.Proof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid vrf proof of remote signer:%s", err)
+	}
+	ok, err := vrf.Verify(this.pubKey, data, value, proof)
+	if err != nil || !ok {
+		return nil, nil, fmt.Errorf("verify vrf of remote signer failed")
+	}
+	return value, proof, nil
+}
+
+func (this *Signer) request(path string, req interface{}, rsp interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(this.config.Url, "/") + path
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("remote signer request error:%s", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if this.config.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+this.config.Token)
+	}
+	httpRsp, err := this.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("remote signer request error:%s", err)
+	}
+	defer httpRsp.Body.Close()
+	body, err := ioutil.ReadAll(httpRsp.Body)
+	if err != nil {
+		return fmt.Errorf("read remote signer response error:%s", err)
+	}
+	if httpRsp.StatusCode != http.StatusOK {
+		errRsp := &ErrorRsp{}
+		if json.Unmarshal(body, errRsp) == nil && errRsp.Error != "" {
+			return fmt.Errorf("remote signer %s error:%s", path, errRsp.Error)
+		}
+		return fmt.Errorf("remote signer %s error:%s", path, httpRsp.Status)
+	}
+	err = json.Unmarshal(body, rsp)
+	if err != nil {
+		return fmt.Errorf("invalid remote signer response:%s", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package remote
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	acc := account.NewAccount("")
+	other := account.NewAccount("")
+	svr := httptest.NewTLSServer(NewHandler("token", map[string]signature.VrfSigner{"consensus": acc}))
+	defer svr.Close()
+
+	//the daemon is verified by the ca file
+	dir, err := ioutil.TempDir("", "remote-signer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: svr.Certificate().Raw})
+	assert.Nil(t, ioutil.WriteFile(caFile, caPem, 0600))
+	tlsConfig, err := NewTLSConfig(caFile, "", "")
+	assert.Nil(t, err)
+	_, err = NewSigner(&Config{Url: svr.URL, KeyId: "consensus", Token: "token"})
+	assert.NotNil(t, err)
+
+	signer, err := NewSigner(&Config{Url: svr.URL, KeyId: "consensus", Token: "token", TLS: tlsConfig})
+	assert.Nil(t, err)
+	assert.True(t, keypair.ComparePublicKey(acc.PublicKey, signer.PubKey()))
+	assert.Equal(t, acc.SigScheme, signer.Scheme())
+
+	data := []byte("block hash")
+	sig, err := signer.Sign(data)
+	assert.Nil(t, err)
+	assert.Nil(t, signature.Verify(acc.PublicKey, data, sig))
+
+	value, proof, err := signer.Vrf(data)
+	assert.Nil(t, err)
+	v, p, err := acc.Vrf(data)
+	assert.Nil(t, err)
+	assert.Equal(t, v, value)
+	assert.Equal(t, len(p), len(proof))
+
+	_, err = NewSigner(&Config{Url: svr.URL, KeyId: "consensus", Token: "wrong", TLS: tlsConfig})
+	assert.NotNil(t, err)
+	_, err = NewSigner(&Config{Url: svr.URL, KeyId: "unknown", Token: "token", TLS: tlsConfig})
+	assert.NotNil(t, err)
+
+	//a daemon signing with a different key is detected
+	signer.pubKey = other.PublicKey
+	_, err = signer.Sign(data)
+	assert.NotNil(t, err)
+	_, _, err = signer.Vrf(data)
+	assert.NotNil(t, err)
+}
+
+func TestRemoteSignerPlainHttp(t *testing.T) {
+	acc := account.NewAccount("")
+	svr := httptest.NewServer(NewHandler("", map[string]signature.VrfSigner{"consensus": acc}))
+	defer svr.Close()
+
+	//the bearer token is never sent over plain http
+	_, err := NewSigner(&Config{Url: svr.URL, KeyId: "consensus", Token: "token"})
+	assert.NotNil(t, err)
+	_, err = NewSigner(&Config{Url: "ftp://127.0.0.1", KeyId: "consensus"})
+	assert.NotNil(t, err)
+
+	signer, err := NewSigner(&Config{Url: svr.URL, KeyId: "consensus"})
+	assert.Nil(t, err)
+	assert.True(t, keypair.ComparePublicKey(acc.PublicKey, signer.PubKey()))
+
+	_, err = NewTLSConfig(filepath.Join(os.TempDir(), "remote-signer-none.pem"), "", "")
+	assert.NotNil(t, err)
+}
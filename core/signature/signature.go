@@ -20,14 +20,20 @@ package signature
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	s "github.com/ontio/ontology-crypto/signature"
 )
 
-// Sign returns the signature of data using privKey
-func Sign(signer Signer, data []byte) ([]byte, error) {
-	signature, err := s.Sign(signer.Scheme(), signer.PrivKey(), data, nil)
+// Sign returns the signature of data. It is signed using the private key if signer has one,
+// otherwise by the signer itself, such as a remote signer
+func Sign(signer DataSigner, data []byte) ([]byte, error) {
+	local, ok := signer.(Signer)
+	if !ok {
+		return signer.Sign(data)
+	}
+	signature, err := s.Sign(local.Scheme(), local.PrivKey(), data, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -83,3 +89,52 @@ func VerifyMultiSignature(data []byte, keys []keypair.PublicKey, m int, sigs [][
 
 	return nil
 }
+
+// VerifyMultiSignatureBatch has the same semantic as VerifyMultiSignature, but verifies the
+// first m sigs concurrently. It falls back to VerifyMultiSignature when two sigs match the
+// same key, so the result never differs from the sequential version.
+func VerifyMultiSignatureBatch(data []byte, keys []keypair.PublicKey, m int, sigs [][]byte) error {
+	n := len(keys)
+
+	if len(sigs) < m {
+		return errors.New("not enough signatures in multi-signature")
+	}
+	if m <= 1 {
+		return VerifyMultiSignature(data, keys, m, sigs)
+	}
+
+	matched := make([]int, m)
+	var wg sync.WaitGroup
+	for i := 0; i < m; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			matched[i] = -1
+			sig, err := s.Deserialize(sigs[i])
+			if err != nil {
+				return
+			}
+			for j := 0; j < n; j++ {
+				if s.Verify(keys[j], data, sig) {
+					matched[i] = j
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	mask := make([]bool, n)
+	for i := 0; i < m; i++ {
+		j := matched[i]
+		if j < 0 {
+			return errors.New("multi-signature verification failed")
+		}
+		if mask[j] {
+			return VerifyMultiSignature(data, keys, m, sigs)
+		}
+		mask[j] = true
+	}
+
+	return nil
+}
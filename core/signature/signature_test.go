@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package signature
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyMultiSignatureBatch(t *testing.T) {
+	data := []byte("multi signature data")
+	var keys []keypair.PublicKey
+	var sigs [][]byte
+	for i := 0; i < 5; i++ {
+		pri, pub, _ := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+		sig, err := s.Sign(s.SHA256withECDSA, pri, data, nil)
+		assert.Nil(t, err)
+		buf, err := s.Serialize(sig)
+		assert.Nil(t, err)
+		keys = append(keys, pub)
+		sigs = append(sigs, buf)
+	}
+
+	assert.Nil(t, VerifyMultiSignatureBatch(data, keys, 3, sigs[:3]))
+	assert.Nil(t, VerifyMultiSignatureBatch(data, keys, 3, [][]byte{sigs[4], sigs[0], sigs[2]}))
+	assert.NotNil(t, VerifyMultiSignatureBatch(data, keys, 3, sigs[:2]))
+	// same signature can not be counted twice
+	assert.NotNil(t, VerifyMultiSignatureBatch(data, keys, 2, [][]byte{sigs[1], sigs[1]}))
+	assert.NotNil(t, VerifyMultiSignatureBatch([]byte("other data"), keys, 3, sigs[:3]))
+	assert.NotNil(t, VerifyMultiSignatureBatch(data, keys, 2, [][]byte{sigs[0], []byte("invalid")}))
+
+	for m := 1; m <= 5; m++ {
+		assert.Equal(t, VerifyMultiSignature(data, keys, m, sigs), VerifyMultiSignatureBatch(data, keys, m, sigs))
+	}
+}
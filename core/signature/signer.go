@@ -33,3 +33,23 @@ type Signer interface {
 
 	Scheme() signature.SignatureScheme
 }
+
+// DataSigner signs data without exposing the private key, which may be kept outside of the process,
+// such as in a remote signer
+type DataSigner interface {
+	//get signer's public key
+	PubKey() keypair.PublicKey
+
+	Scheme() signature.SignatureScheme
+
+	//sign data, and return the serialized signature
+	Sign(data []byte) ([]byte, error)
+}
+
+// VrfSigner is the signer of consensus key, which also computes VRF of block proposals
+type VrfSigner interface {
+	DataSigner
+
+	//compute vrf value and proof of data
+	Vrf(data []byte) ([]byte, []byte, error)
+}
@@ -38,6 +38,9 @@ const (
 	ST_ETH_CODE    DataEntryPrefix = 0x30 // eth contract code:hash -> bytes
 	ST_ETH_ACCOUNT DataEntryPrefix = 0x31 // eth account: address -> [nonce, codeHash]
 
+	// fork state
+	ST_FORK_ABSENT DataEntryPrefix = 0x32 // state key which is absent in remote chain or deleted locally: prefix+key -> nil
+
 	IX_HEADER_HASH_LIST DataEntryPrefix = 0x09 //Block height => block hash key prefix
 
 	//SYSTEM
@@ -48,7 +51,9 @@ const (
 	SYS_STATE_MERKLE_TREE    DataEntryPrefix = 0x20 // state merkle tree root key prefix
 	SYS_CROSS_CHAIN_MSG      DataEntryPrefix = 0x22 // state merkle tree root key prefix
 
-	EVENT_NOTIFY DataEntryPrefix = 0x14 //Event notify key prefix
+	EVENT_NOTIFY        DataEntryPrefix = 0x14 //Event notify key prefix
+	EVENT_DID_INDEX     DataEntryPrefix = 0x15 //ONT ID + block height => nil, blocks which have ontid events of the ID
+	SYS_DID_INDEX_START DataEntryPrefix = 0x16 //Blocks below the height are not indexed by ONT ID yet
 
 	DATA_BLOCK_PRUNE_HEIGHT DataEntryPrefix = 0x80 //  last pruned block height, genesis block can not be pruned
 )
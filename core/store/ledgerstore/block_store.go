@@ -63,6 +63,18 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 	return blockStore, nil
 }
 
+//NewReadOnlyBlockStore return a block store of an existing dbDir opened read only, without block cache
+func NewReadOnlyBlockStore(dbDir string) (*BlockStore, error) {
+	store, err := leveldbstore.NewReadOnlyLevelDBStore(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockStore{
+		dbDir: dbDir,
+		store: store,
+	}, nil
+}
+
 //NewBatch start a commit batch
 func (this *BlockStore) NewBatch() {
 	this.store.NewBatch()
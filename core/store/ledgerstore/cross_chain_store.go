@@ -84,3 +84,8 @@ func (this *CrossChainStore) genCrossChainMsgKey(height uint32) []byte {
 	binary.LittleEndian.PutUint32(temp[1:], height)
 	return temp
 }
+
+//Close cross chain store
+func (this *CrossChainStore) Close() error {
+	return this.store.Close()
+}
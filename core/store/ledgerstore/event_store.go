@@ -30,6 +30,9 @@ import (
 	scom "github.com/qbyyf/ontology/core/store/common"
 	"github.com/qbyyf/ontology/core/store/leveldbstore"
 	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ontid"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // UseNumber can be set to true to enable the use of json.Number when decoding
@@ -130,6 +133,98 @@ func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNo
 	return evtNotifies, nil
 }
 
+//SaveDidEventIndex index the block by the ONT IDs of its ontid events
+func (this *EventStore) SaveDidEventIndex(height uint32, notifies []*event.ExecuteNotify) {
+	for _, did := range didEventSubjects(notifies) {
+		this.store.BatchPut(genDidEventIndexKey(did, height), nil)
+	}
+}
+
+//GetDidEventHeights return the heights of the blocks from height from which have ontid events of did, at most limit
+//heights are returned. It fails if the blocks from height from are not indexed yet
+func (this *EventStore) GetDidEventHeights(did string, from uint32, limit int) ([]uint32, error) {
+	start, err := this.GetDidIndexStart()
+	if err != nil {
+		return nil, err
+	}
+	if from < start {
+		return nil, fmt.Errorf("blocks below %d are not indexed by ONT ID yet", start)
+	}
+	prefix := genDidEventIndexKey(did, 0)
+	prefix = prefix[:len(prefix)-4]
+	iter := this.store.NewRangeIterator(genDidEventIndexKey(did, from), util.BytesPrefix(prefix).Limit)
+	defer iter.Release()
+	heights := make([]uint32, 0)
+	for iter.Next() && len(heights) < limit {
+		key := iter.Key()
+		if len(key) != len(prefix)+4 {
+			continue
+		}
+		heights = append(heights, binary.BigEndian.Uint32(key[len(prefix):]))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return heights, nil
+}
+
+//GetDidIndexStart return the height below which the blocks are not indexed by ONT ID yet
+func (this *EventStore) GetDidIndexStart() (uint32, error) {
+	data, err := this.store.Get([]byte{byte(scom.SYS_DID_INDEX_START)})
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 4 {
+		return 0, fmt.Errorf("invalid ONT ID index start %x", data)
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+//SaveDidIndexStart persist the height below which the blocks are not indexed by ONT ID yet, it is written
+//directly, since the index is built in background besides the block batch
+func (this *EventStore) SaveDidIndexStart(height uint32) error {
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, height)
+	return this.store.Put([]byte{byte(scom.SYS_DID_INDEX_START)}, value)
+}
+
+//IndexDidEvents index the block at height by the ONT IDs of its persisted events, it is written directly
+//for the blocks persisted before the index
+func (this *EventStore) IndexDidEvents(height uint32) error {
+	notifies, err := this.GetEventNotifyByBlock(height)
+	if err != nil && err != scom.ErrNotFound {
+		return err
+	}
+	for _, did := range didEventSubjects(notifies) {
+		if err := this.store.Put(genDidEventIndexKey(did, height), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func didEventSubjects(notifies []*event.ExecuteNotify) []string {
+	var dids []string
+	seen := make(map[string]bool)
+	for _, notify := range notifies {
+		if notify.State != event.CONTRACT_STATE_SUCCESS {
+			continue
+		}
+		for _, n := range notify.Notify {
+			if n.ContractAddress != utils.OntIDContractAddress {
+				continue
+			}
+			did := ontid.EventSubject(n.States)
+			if did == "" || seen[did] {
+				continue
+			}
+			seen[did] = true
+			dids = append(dids, did)
+		}
+	}
+	return dids
+}
+
 func (this *EventStore) PruneBlock(height uint32, hashes []common.Uint256) {
 	key := genEventNotifyByBlockKey(height)
 	this.store.BatchDelete(key)
@@ -202,6 +297,17 @@ func genEventNotifyByBlockKey(height uint32) []byte {
 	return key
 }
 
+//genDidEventIndexKey is prefix + var bytes of did + height in big endian, so that the heights of one ID are in order
+func genDidEventIndexKey(did string, height uint32) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(byte(scom.EVENT_DID_INDEX))
+	sink.WriteVarBytes([]byte(did))
+	key := sink.Bytes()
+	key = append(key, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(key[len(key)-4:], height)
+	return key
+}
+
 func genEventNotifyByTxKey(txHash common.Uint256) []byte {
 	data := txHash.ToArray()
 	key := make([]byte, 1+len(data))
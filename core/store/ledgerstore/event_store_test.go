@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func didNotify(txHash byte, state byte, states ...interface{}) *event.ExecuteNotify {
+	return &event.ExecuteNotify{
+		TxHash: common.Uint256{txHash},
+		State:  state,
+		Notify: []*event.NotifyEventInfo{{ContractAddress: utils.OntIDContractAddress, States: states}},
+	}
+}
+
+func TestDidEventIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	eventStore, err := NewEventStore(dir)
+	assert.Nil(t, err)
+	defer eventStore.Close()
+
+	did, other := "did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa", "did:ont:AMAx993nE6NEqZjwBssUfopxnnvTdob9ij"
+	blocks := map[uint32][]*event.ExecuteNotify{
+		2: {didNotify(1, event.CONTRACT_STATE_SUCCESS, "Register", did)},
+		3: {didNotify(2, event.CONTRACT_STATE_SUCCESS, "Register", other)},
+		5: {didNotify(3, event.CONTRACT_STATE_FAIL, "Revoke", did)},
+		7: {didNotify(4, event.CONTRACT_STATE_SUCCESS, "PublicKey", "add", did, 2),
+			didNotify(5, event.CONTRACT_STATE_SUCCESS, "Attribute", "add", did, []interface{}{"6b6579"})},
+	}
+	for height, notifies := range blocks {
+		eventStore.NewBatch()
+		var hashes []common.Uint256
+		for _, notify := range notifies {
+			assert.Nil(t, eventStore.SaveEventNotifyByTx(notify.TxHash, notify))
+			hashes = append(hashes, notify.TxHash)
+		}
+		eventStore.SaveEventNotifyByBlock(height, hashes)
+		//the blocks from height 5 are indexed when they are saved
+		if height >= 5 {
+			eventStore.SaveDidEventIndex(height, notifies)
+		}
+		assert.Nil(t, eventStore.CommitTo())
+	}
+	assert.Nil(t, eventStore.SaveDidIndexStart(5))
+
+	heights, err := eventStore.GetDidEventHeights(did, 5, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{7}, heights)
+	_, err = eventStore.GetDidEventHeights(did, 0, 10)
+	assert.NotNil(t, err)
+
+	//the blocks saved before are indexed from the persisted events
+	for height := uint32(5); height > 0; height-- {
+		assert.Nil(t, eventStore.IndexDidEvents(height-1))
+		assert.Nil(t, eventStore.SaveDidIndexStart(height-1))
+	}
+	heights, err = eventStore.GetDidEventHeights(did, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{2, 7}, heights)
+	heights, err = eventStore.GetDidEventHeights(did, 0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{2}, heights)
+	heights, err = eventStore.GetDidEventHeights(did, 3, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{7}, heights)
+	heights, err = eventStore.GetDidEventHeights(other, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{3}, heights)
+}
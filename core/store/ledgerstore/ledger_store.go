@@ -101,11 +101,20 @@ type LedgerStoreImp struct {
 
 	savingBlockSemaphore       chan bool
 	closing                    bool
+	publisher                  *events.ActorPublisher // publisher of saved blocks and smart contract events, events.DefActorPublisher if nil
 	preserveBlockHistoryLength uint32 // block could be pruned if blockHeight + preserveBlockHistoryLength < currHeight , disable prune if equals 0
 }
 
+//StoreWrapper wraps the persist store of ledger states, to change how the states are read and written
+type StoreWrapper func(store scom.PersistStore) scom.PersistStore
+
 //NewLedgerStore return LedgerStoreImp instance
 func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, error) {
+	return NewLedgerStoreWithStateDB(dataDir, stateHashHeight, nil)
+}
+
+//NewLedgerStoreWithStateDB return LedgerStoreImp instance whose state db is wrapped by wrapStateDB. State db is not wrapped if wrapStateDB is nil
+func NewLedgerStoreWithStateDB(dataDir string, stateHashHeight uint32, wrapStateDB StoreWrapper) (*LedgerStoreImp, error) {
 	ledgerStore := &LedgerStoreImp{
 		headerIndex:          make(map[uint32]common.Uint256),
 		headerCache:          make(map[common.Uint256]*types.Header, 0),
@@ -128,7 +137,7 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 
 	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
 	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
-	stateStore, err := NewStateStore(dbPath, merklePath, stateHashHeight)
+	stateStore, err := newStateStore(dbPath, merklePath, stateHashHeight, wrapStateDB)
 	if err != nil {
 		return nil, fmt.Errorf("NewStateStore error %s", err)
 	}
@@ -143,6 +152,12 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 	return ledgerStore, nil
 }
 
+//SetEventPublisher sets the publisher of the saved blocks and smart contract events, it should be set before
+//InitLedgerStoreWithGenesisBlock. The events are published by events.DefActorPublisher if it is not set
+func (this *LedgerStoreImp) SetEventPublisher(publisher *events.ActorPublisher) {
+	this.publisher = publisher
+}
+
 //InitLedgerStoreWithGenesisBlock init the ledger store with genesis block. It's the first operation after NewLedgerStore.
 func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.Block, defaultBookkeeper []keypair.PublicKey) error {
 	hasInit, err := this.hasAlreadyInitGenesisBlock()
@@ -162,6 +177,11 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if err != nil {
 			return fmt.Errorf("eventStore.ClearAll error %s", err)
 		}
+		//all the blocks of a new ledger are indexed by ONT ID when they are saved
+		err = this.eventStore.SaveDidIndexStart(0)
+		if err != nil {
+			return fmt.Errorf("eventStore.SaveDidIndexStart error %s", err)
+		}
 		defaultBookkeeper = keypair.SortPublicKeys(defaultBookkeeper)
 		bookkeeperState := &states.BookkeeperState{
 			CurrBookkeeper: defaultBookkeeper,
@@ -268,6 +288,10 @@ func (this *LedgerStoreImp) init() error {
 	if err != nil {
 		return fmt.Errorf("loadHeaderIndexList error %s", err)
 	}
+	err = this.initDidIndex()
+	if err != nil {
+		return fmt.Errorf("initDidIndex error %s", err)
+	}
 	err = this.recoverStore()
 	if err != nil {
 		return fmt.Errorf("recoverStore error %s", err)
@@ -275,6 +299,47 @@ func (this *LedgerStoreImp) init() error {
 	return nil
 }
 
+//initDidIndex starts to index the blocks persisted before the ONT ID index in background. It must be called
+//before any block is saved, since the new blocks are indexed when they are saved
+func (this *LedgerStoreImp) initDidIndex() error {
+	start, err := this.eventStore.GetDidIndexStart()
+	if err == scom.ErrNotFound {
+		_, height, err := this.eventStore.GetCurrentBlock()
+		if err != nil && err != scom.ErrNotFound {
+			return err
+		}
+		start = 0
+		if err == nil {
+			start = height + 1
+		}
+		if err := this.eventStore.SaveDidIndexStart(start); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if start > 0 && sysconfig.DefConfig.Common.EnableEventLog {
+		go this.indexDidEvents(start)
+	}
+	return nil
+}
+
+//indexDidEvents indexes the blocks below start by ONT ID, from the highest one
+func (this *LedgerStoreImp) indexDidEvents(start uint32) {
+	log.Infof("indexing blocks below %d by ONT ID", start)
+	for height := start; height > 0; height-- {
+		if err := this.eventStore.IndexDidEvents(height - 1); err != nil {
+			log.Errorf("index events of block %d by ONT ID error %s", height-1, err)
+			return
+		}
+		if err := this.eventStore.SaveDidIndexStart(height - 1); err != nil {
+			log.Errorf("SaveDidIndexStart %d error %s", height-1, err)
+			return
+		}
+	}
+	log.Infof("all the blocks are indexed by ONT ID")
+}
+
 func (this *LedgerStoreImp) loadCurrentBlock() error {
 	currentBlockHash, currentBlockHeight, err := this.blockStore.GetCurrentBlock()
 	if err != nil {
@@ -816,10 +881,13 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 	blockHeight := block.Header.Height
 
 	for _, notify := range result.Notify {
-		if err := SaveNotify(this.eventStore, notify.TxHash, notify); err != nil {
+		if err := SaveNotify(this.eventStore, this.publisher, notify.TxHash, notify); err != nil {
 			return err
 		}
 	}
+	if sysconfig.DefConfig.Common.EnableEventLog {
+		this.eventStore.SaveDidEventIndex(blockHeight, result.Notify)
+	}
 
 	err := this.stateStore.AddStateMerkleTreeRoot(blockHeight, result.Hash)
 	if err != nil {
@@ -960,8 +1028,12 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, crossChainMsg *types
 	}
 	this.setCurrentBlock(blockHeight, blockHash)
 
-	if events.DefActorPublisher != nil {
-		events.DefActorPublisher.Publish(
+	publisher := this.publisher
+	if publisher == nil {
+		publisher = events.DefActorPublisher
+	}
+	if publisher != nil {
+		publisher.Publish(
 			message.TOPIC_SAVE_BLOCK_COMPLETE,
 			&message.SaveBlockCompleteMsg{
 				Block: block,
@@ -1016,7 +1088,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 			log.Debugf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
 	case types.InvokeNeo, types.InvokeWasm:
-		crossStateHashes, err = this.stateStore.HandleInvokeTransaction(this, overlay, gasTable, cache, tx, block, notify)
+		crossStateHashes, err = this.stateStore.HandleInvokeTransaction(this, overlay, gasTable, cache, tx, block, this.publisher, notify)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -1035,7 +1107,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 			Height:    block.Header.Height,
 			Timestamp: block.Header.Timestamp,
 		}
-		_, err = this.stateStore.HandleEIP155Transaction(this, cache, eiptx, ctx, notify, true)
+		_, err = this.stateStore.HandleEIP155Transaction(this, gasTable, cache, eiptx, ctx, notify, true)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -1208,6 +1280,15 @@ func (this *LedgerStoreImp) GetStorageItem(contract common.Address, key []byte)
 	return storageItem.Value, nil
 }
 
+//GetStorageState return the storage item of the key in smart contract, including its state version. Wrap function of StateStore.GetStorageState
+func (this *LedgerStoreImp) GetStorageState(contract common.Address, key []byte) (*states.StorageItem, error) {
+	storageKey := &states.StorageKey{
+		ContractAddress: contract,
+		Key:             key,
+	}
+	return this.stateStore.GetStorageState(storageKey)
+}
+
 //GetEventNotifyByTx return the events notify gen by executing of smart contract.  Wrap function of EventStore.GetEventNotifyByTx
 func (this *LedgerStoreImp) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
 	return this.eventStore.GetEventNotifyByTx(tx)
@@ -1218,6 +1299,12 @@ func (this *LedgerStoreImp) GetEventNotifyByBlock(height uint32) ([]*event.Execu
 	return this.eventStore.GetEventNotifyByBlock(height)
 }
 
+//GetDidEventHeights return the heights of the blocks from height from which have ontid events of did, at most limit
+//heights are returned. Wrap function of EventStore.GetDidEventHeights
+func (this *LedgerStoreImp) GetDidEventHeights(did string, from uint32, limit int) ([]uint32, error) {
+	return this.eventStore.GetDidEventHeights(did, from, limit)
+}
+
 //PreExecuteContract return the result of smart contract execution without commit to store
 func (this *LedgerStoreImp) PreExecuteContractBatch(txes []*types.Transaction, atomic bool) ([]*sstate.PreExecResult, uint32, error) {
 	if atomic {
@@ -1238,12 +1325,53 @@ func (this *LedgerStoreImp) PreExecuteContractBatch(txes []*types.Transaction, a
 	return results, height, nil
 }
 
+//SimulateBundle execute the transactions sequentially on one overlay without commit to store,
+//so every transaction observes the state changes made by the previous ones
+func (this *LedgerStoreImp) SimulateBundle(txes []*types.Transaction) (*store.BundleResult, error) {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	height := this.GetCurrentBlockHeight()
+	// use previous block time to make it predictable for easy test
+	blockTime := uint32(time.Now().Unix())
+	if header, err := this.GetHeaderByHeight(height); err == nil {
+		blockTime = header.Timestamp + 1
+	}
+	block := &types.Block{
+		Header: &types.Header{
+			PrevBlockHash: this.GetBlockHash(height),
+			Timestamp:     blockTime,
+			Height:        height + 1,
+		},
+		Transactions: txes,
+	}
+	gasTable := newGasTable()
+
+	overlay := this.stateStore.NewOverlayDB()
+	cache := storage.NewCacheDB(overlay)
+	result := &store.BundleResult{Height: height}
+	for i, tx := range txes {
+		cache.Reset()
+		notify, _, err := this.handleTransaction(overlay, cache, gasTable, block, tx, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		if tx.GasPrice != 0 {
+			notify.GasStepUsed = notify.GasConsumed / tx.GasPrice
+		}
+		result.Notify = append(result.Notify, notify)
+		result.WriteSets = append(result.WriteSets, overlay.GetWriteSet().DeepClone())
+	}
+
+	return result, nil
+}
+
 func (this *LedgerStoreImp) PreExecuteEIP155(tx *types3.Transaction, ctx Eip155Context) (*types4.ExecutionResult, *event.ExecuteNotify, error) {
 	overlay := this.stateStore.NewOverlayDB()
 	cache := storage.NewCacheDB(overlay)
 
 	notify := &event.ExecuteNotify{State: event.CONTRACT_STATE_FAIL, TxIndex: ctx.TxIndex}
-	result, err := this.stateStore.HandleEIP155Transaction(this, cache, tx, ctx, notify, false)
+	result, err := this.stateStore.HandleEIP155Transaction(this, newGasTable(), cache, tx, ctx, notify, false)
 	return result, notify, err
 }
 
@@ -1287,6 +1415,7 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 		stf.Notify = notify.Notify
 		stf.Result = result.ReturnData
 		stf.Gas = result.UsedGas
+		stf.StorageFee, stf.StorageRefund = result.StorageFee, result.StorageRefund
 		return stf, nil
 	}
 
@@ -1295,6 +1424,7 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 		Height:    height + 1,
 		Tx:        tx,
 		BlockHash: this.GetBlockHash(height),
+		Publisher: this.publisher,
 	}
 
 	overlay := this.stateStore.NewOverlayDB()
@@ -1333,6 +1463,13 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 			return stf, err
 		}
 		gasCost := math.MaxUint64 - sc.Gas
+		storageGas, err := calcStorageGas(gasTable, cache)
+		if err != nil {
+			return stf, err
+		}
+		if gasCost, err = storageGas.apply(gasCost, math.MaxUint64); err != nil {
+			return stf, err
+		}
 
 		if preParam.MinGas {
 			mixGas := neovm.MIN_TRANSACTION_GAS
@@ -1355,7 +1492,11 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 			cv = common.ToHexString(result.([]byte))
 		}
 
-		return &sstate.PreExecResult{State: event.CONTRACT_STATE_SUCCESS, Gas: gasCost, Result: cv, Notify: sc.Notifications}, nil
+		res := &sstate.PreExecResult{State: event.CONTRACT_STATE_SUCCESS, Gas: gasCost, Result: cv, Notify: sc.Notifications}
+		if storageGas != nil {
+			res.StorageFee, res.StorageRefund = storageGas.fee, storageGas.refund
+		}
+		return res, nil
 	} else if tx.TxType == types.Deploy {
 		deploy := tx.Payload.(*payload.DeployCode)
 
@@ -1412,7 +1553,8 @@ func (this *LedgerStoreImp) PreExecuteEip155Tx(msg types3.Message) (*types4.Exec
 	cache := this.GetCacheDB()
 	statedb := storage.NewStateDB(cache, common2.Hash{}, common2.Hash(ctx.BlockHash), ong.OngBalanceHandle{})
 	vmenv := evm2.NewEVM(blockContext, txContext, statedb, config, evm2.Config{})
-	res, err := evm.ApplyMessage(vmenv, msg, common2.Address(utils.GovernanceContractAddress))
+	res, err := evm.ApplyMessage(vmenv, msg, common2.Address(utils.GovernanceContractAddress),
+		evmStorageGas(newGasTable(), cache, nil))
 	return res, err
 }
 
@@ -1436,6 +1578,10 @@ func (this *LedgerStoreImp) Close() error {
 	if err != nil {
 		return fmt.Errorf("stateStore close error %s", err)
 	}
+	err = this.crossChainStore.Close()
+	if err != nil {
+		return fmt.Errorf("crossChainStore close error %s", err)
+	}
 	return nil
 }
 
@@ -20,6 +20,7 @@ package ledgerstore
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -28,6 +29,13 @@ import (
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
 	"github.com/qbyyf/ontology/core/genesis"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ont"
+	nutils "github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 var testBlockStore *BlockStore
@@ -138,3 +146,61 @@ func TestInitLedgerStoreWithGenesisBlock(t *testing.T) {
 		return
 	}
 }
+
+// signedOntTx build the invoke transaction of ONT contract paid and signed by signer
+func signedOntTx(t *testing.T, signer *account.Account, method string, param interface{}) *types.Transaction {
+	code, err := utils.BuildNativeInvokeCode(nutils.OntContractAddress, 0, method, []interface{}{param})
+	assert.Nil(t, err)
+	mutable := utils.NewInvokeTransaction(code)
+	mutable.GasLimit = 30000
+	mutable.Payer = signer.Address
+	hash := mutable.Hash()
+	sig, err := signature.Sign(signer, hash[:])
+	assert.Nil(t, err)
+	mutable.Sigs = []types.Sig{{PubKeys: []keypair.PublicKey{signer.PublicKey}, M: 1, SigData: [][]byte{sig}}}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+func TestSimulateBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	holder := account.NewAccount("")
+	defer useSoloConsensus(holder.PublicKey)()
+	bookkeepers := []keypair.PublicKey{holder.PublicKey}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	ledgerStore, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	defer ledgerStore.Close()
+	assert.Nil(t, ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+
+	//the genesis ONT is held by the only bookkeeper, spender moves it with the allowance
+	spender := account.NewAccount("")
+	approve := signedOntTx(t, holder, "approve", &ont.TransferState{From: holder.Address, To: spender.Address, Value: 100})
+	transferFrom := signedOntTx(t, spender, "transferFrom", ont.NewTransferFromState(spender.Address, holder.Address, spender.Address, 100))
+
+	result, err := ledgerStore.SimulateBundle([]*types.Transaction{transferFrom})
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), result.Height)
+	assert.Equal(t, event.CONTRACT_STATE_FAIL, result.Notify[0].State)
+
+	result, err = ledgerStore.SimulateBundle([]*types.Transaction{approve, transferFrom})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(result.Notify))
+	assert.Equal(t, 2, len(result.WriteSets))
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, result.Notify[0].State)
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, result.Notify[1].State)
+	assert.Equal(t, transferFrom.Hash(), result.Notify[1].TxHash)
+	assert.Equal(t, uint32(1), result.Notify[1].TxIndex)
+
+	//nothing is committed to the ledger
+	assert.Equal(t, uint32(0), ledgerStore.GetCurrentBlockHeight())
+	_, err = ledgerStore.GetStorageItem(nutils.OntContractAddress, spender.Address[:])
+	assert.NotNil(t, err)
+	result, err = ledgerStore.SimulateBundle([]*types.Transaction{transferFrom})
+	assert.Nil(t, err)
+	assert.Equal(t, event.CONTRACT_STATE_FAIL, result.Notify[0].State)
+}
@@ -57,11 +57,19 @@ type StateStore struct {
 
 //NewStateStore return state store instance
 func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*StateStore, error) {
+	return newStateStore(dbDir, merklePath, stateHashCheckHeight, nil)
+}
+
+func newStateStore(dbDir, merklePath string, stateHashCheckHeight uint32, wrapDB StoreWrapper) (*StateStore, error) {
 	var err error
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	var store scom.PersistStore
+	store, err = leveldbstore.NewLevelDBStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
+	if wrapDB != nil {
+		store = wrapDB(store)
+	}
 	stateStore := &StateStore{
 		dbDir:                dbDir,
 		store:                store,
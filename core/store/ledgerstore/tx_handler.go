@@ -35,6 +35,7 @@ import (
 	"github.com/qbyyf/ontology/core/store/overlaydb"
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/qbyyf/ontology/errors"
+	"github.com/qbyyf/ontology/events"
 	"github.com/qbyyf/ontology/smartcontract"
 	"github.com/qbyyf/ontology/smartcontract/event"
 	evm2 "github.com/qbyyf/ontology/smartcontract/service/evm"
@@ -154,7 +155,7 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 
 //HandleInvokeTransaction deal with smart contract invoke transaction
 func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay *overlaydb.OverlayDB, gasTable map[string]uint64, cache *storage.CacheDB,
-	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify) ([]common.Uint256, error) {
+	tx *types.Transaction, block *types.Block, publisher *events.ActorPublisher, notify *event.ExecuteNotify) ([]common.Uint256, error) {
 	invoke := tx.Payload.(*payload.InvokeCode)
 	code := invoke.Code
 	sysTransFlag := bytes.Compare(code, ninit.COMMIT_DPOS_BYTES) == 0 || block.Header.Height == 0
@@ -167,6 +168,7 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay
 		Height:    block.Header.Height,
 		Tx:        tx,
 		BlockHash: block.Hash(),
+		Publisher: publisher,
 	}
 
 	var (
@@ -246,6 +248,15 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay
 	}
 
 	costGasLimit = availableGasLimit - sc.Gas
+	var storageGas *storageGasInfo
+	if isCharge && err == nil {
+		storageGas, err = calcStorageGas(gasTable, cache)
+		if err != nil {
+			overlay.SetError(fmt.Errorf("[HandleInvokeTransaction] %s", err))
+			return nil, nil
+		}
+		costGasLimit, err = storageGas.apply(costGasLimit, availableGasLimit)
+	}
 	if costGasLimit < neovm.MIN_TRANSACTION_GAS {
 		costGasLimit = neovm.MIN_TRANSACTION_GAS
 	}
@@ -287,18 +298,19 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay
 	notify.Notify = append(notify.Notify, notifies...)
 	notify.GasConsumed = costGas
 	notify.State = event.CONTRACT_STATE_SUCCESS
+	storageGas.fillNotify(notify)
 	sc.CacheDB.Commit()
 	return sc.CrossHashes, nil
 }
 
-func SaveNotify(eventStore scommon.EventStore, txHash common.Uint256, notify *event.ExecuteNotify) error {
+func SaveNotify(eventStore scommon.EventStore, publisher *events.ActorPublisher, txHash common.Uint256, notify *event.ExecuteNotify) error {
 	if !sysconfig.DefConfig.Common.EnableEventLog {
 		return nil
 	}
 	if err := eventStore.SaveEventNotifyByTx(txHash, notify); err != nil {
 		return fmt.Errorf("SaveEventNotifyByTx error %s", err)
 	}
-	event.PushSmartCodeEvent(txHash, 0, event.EVENT_NOTIFY, notify)
+	event.PublishSmartCodeEvent(publisher, txHash, 0, event.EVENT_NOTIFY, notify)
 	return nil
 }
 
@@ -412,6 +424,74 @@ func calcGasByCodeLen(codeLen int, codeGas uint64) uint64 {
 	return uint64(codeLen/neovm.PER_UNIT_CODE_LEN) * codeGas
 }
 
+// storageGasInfo records the storage growth of a transaction and the gas it is charged or refunded for it
+type storageGasInfo struct {
+	addedBytes uint64
+	freedBytes uint64
+	fee        uint64
+	refund     uint64
+}
+
+// calcStorageGas measures the net new and freed storage bytes in the transaction cache and prices them with
+// the storage gas params, it returns nil when storage pricing is not activated by governance
+func calcStorageGas(gasTable map[string]uint64, cache *storage.CacheDB) (*storageGasInfo, error) {
+	price := gasTable[neovm.STORAGE_BYTE_NAME]
+	if price == 0 {
+		return nil, nil
+	}
+	added, freed, err := cache.StorageDelta()
+	if err != nil {
+		return nil, err
+	}
+	percent := gasTable[neovm.STORAGE_REFUND_PERCENT_NAME]
+	if percent > 100 {
+		percent = 100
+	}
+	info := &storageGasInfo{addedBytes: added, freedBytes: freed}
+	if added != 0 && price > math.MaxUint64/added {
+		info.fee = math.MaxUint64
+	} else {
+		info.fee = added * price
+	}
+	if freed != 0 && price > math.MaxUint64/freed/100 {
+		info.refund = math.MaxUint64 / 100 * percent
+	} else {
+		info.refund = freed * price * percent / 100
+	}
+	return info, nil
+}
+
+// apply adds the storage fee to the gas cost of the execution and deducts the refund from it, the refund never
+// brings the cost below MIN_TRANSACTION_GAS
+func (self *storageGasInfo) apply(costGasLimit, availableGasLimit uint64) (uint64, error) {
+	if self == nil {
+		return costGasLimit, nil
+	}
+	if self.fee > availableGasLimit-costGasLimit {
+		return availableGasLimit, fmt.Errorf("storage gas insufficient: need %d, left %d", self.fee,
+			availableGasLimit-costGasLimit)
+	}
+	costGasLimit += self.fee
+	if costGasLimit < neovm.MIN_TRANSACTION_GAS+self.refund {
+		if costGasLimit > neovm.MIN_TRANSACTION_GAS {
+			self.refund = costGasLimit - neovm.MIN_TRANSACTION_GAS
+		} else {
+			self.refund = 0
+		}
+	}
+	return costGasLimit - self.refund, nil
+}
+
+func (self *storageGasInfo) fillNotify(notify *event.ExecuteNotify) {
+	if self == nil {
+		return
+	}
+	notify.StorageAdded = self.addedBytes
+	notify.StorageFreed = self.freedBytes
+	notify.StorageFee = self.fee
+	notify.StorageRefund = self.refund
+}
+
 type Eip155Context struct {
 	BlockHash common.Uint256
 	TxIndex   uint32
@@ -419,13 +499,14 @@ type Eip155Context struct {
 	Timestamp uint32
 }
 
-func (self *StateStore) HandleEIP155Transaction(store store.LedgerStore, cache *storage.CacheDB,
+func (self *StateStore) HandleEIP155Transaction(store store.LedgerStore, gasTable map[string]uint64, cache *storage.CacheDB,
 	tx *types2.Transaction, ctx Eip155Context, notify *event.ExecuteNotify, checkNonce bool) (*types3.ExecutionResult, error) {
 	usedGas := uint64(0)
 	config := params.GetChainConfig(sysconfig.DefConfig.P2PNode.EVMChainId)
 	statedb := storage.NewStateDB(cache, tx.Hash(), common2.Hash(ctx.BlockHash), ong.OngBalanceHandle{})
+	var storageGas *storageGasInfo
 	result, receipt, err := evm2.ApplyTransaction(config, store, statedb, ctx.Height, ctx.Timestamp, tx, &usedGas,
-		utils.GovernanceContractAddress, evm.Config{}, checkNonce)
+		utils.GovernanceContractAddress, evm.Config{}, checkNonce, evmStorageGas(gasTable, cache, &storageGas))
 
 	if err != nil {
 		cache.SetDbErr(err)
@@ -438,6 +519,38 @@ func (self *StateStore) HandleEIP155Transaction(store store.LedgerStore, cache *
 	receipt.TxIndex = ctx.TxIndex
 
 	*notify = *event.ExecuteNotifyFromEthReceipt(receipt)
+	if storageGas != nil && result.Err == nil {
+		storageGas.fee, storageGas.refund = result.StorageFee, result.StorageRefund
+		storageGas.fillNotify(notify)
+	}
 
 	return result, nil
 }
+
+// evmStorageGas prices the storage growth of an EIP155 transaction in cache like calcStorageGas, and records
+// the measured storage to measured if not nil. It returns nil when storage pricing is not activated by governance
+func evmStorageGas(gasTable map[string]uint64, cache *storage.CacheDB, measured **storageGasInfo) evm2.StorageGas {
+	if gasTable[neovm.STORAGE_BYTE_NAME] == 0 {
+		return nil
+	}
+	return func() (uint64, uint64, error) {
+		info, err := calcStorageGas(gasTable, cache)
+		if err != nil {
+			return 0, 0, err
+		}
+		if measured != nil {
+			*measured = info
+		}
+		return info.fee, info.refund, nil
+	}
+}
+
+// newGasTable returns a copy of the current gas table
+func newGasTable() map[string]uint64 {
+	gasTable := make(map[string]uint64)
+	neovm.GAS_TABLE.Range(func(k, value interface{}) bool {
+		gasTable[k.(string)] = value.(uint64)
+		return true
+	})
+	return gasTable
+}
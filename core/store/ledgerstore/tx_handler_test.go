@@ -19,10 +19,27 @@
 package ledgerstore
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"sync"
 	"testing"
+
+	common2 "github.com/qbyyf/go-ethereum/common"
+	types2 "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/ontology/common"
+	sysconfig "github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/core/store/leveldbstore"
+	"github.com/qbyyf/ontology/core/store/overlaydb"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	evm2 "github.com/qbyyf/ontology/smartcontract/service/evm"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ong"
+	"github.com/qbyyf/ontology/smartcontract/service/neovm"
+	"github.com/qbyyf/ontology/smartcontract/storage"
+	"github.com/qbyyf/ontology/vm/evm/params"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSyncMapRange(t *testing.T) {
@@ -75,3 +92,92 @@ func TestSyncMapRW(t *testing.T) {
 func addsync(m *sync.Map, va int) {
 	m.Store("key", va)
 }
+
+func TestCalcStorageGas(t *testing.T) {
+	overlay := overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore())
+	cache := storage.NewCacheDB(overlay)
+	cache.Put([]byte("key1"), []byte("value1"))
+	cache.Put([]byte("key2"), []byte("value2"))
+
+	gasTable := map[string]uint64{neovm.STORAGE_REFUND_PERCENT_NAME: 50}
+	info, err := calcStorageGas(gasTable, cache)
+	assert.Nil(t, err)
+	assert.Nil(t, info)
+	cost, err := info.apply(100, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(100), cost)
+
+	gasTable[neovm.STORAGE_BYTE_NAME] = 10
+	info, err = calcStorageGas(gasTable, cache)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(22), info.addedBytes)
+	assert.Equal(t, uint64(220), info.fee)
+	cost, err = info.apply(100, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(320), cost)
+	_, err = info.apply(900, 1000)
+	assert.NotNil(t, err)
+	cache.Commit()
+
+	cache.Delete([]byte("key1"))
+	cache.Delete([]byte("key2"))
+	info, err = calcStorageGas(gasTable, cache)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(22), info.freedBytes)
+	assert.Equal(t, uint64(110), info.refund)
+	cost, err = info.apply(neovm.MIN_TRANSACTION_GAS+50, 1000000)
+	assert.Nil(t, err)
+	assert.Equal(t, neovm.MIN_TRANSACTION_GAS, cost)
+	assert.Equal(t, uint64(50), info.refund)
+}
+
+func TestEIP155StorageGas(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types2.NewEIP155Signer(params.GetChainConfig(sysconfig.DefConfig.P2PNode.EVMChainId).ChainID)
+
+	transfer := func(gasTable map[string]uint64, gasLimit uint64) (*storage.CacheDB, common2.Address, *event.ExecuteNotify, uint64, error) {
+		overlay := overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore())
+		cache := storage.NewCacheDB(overlay)
+		assert.Nil(t, ong.OngBalanceHandle{}.AddBalance(cache, common.Address(from), big.NewInt(1e18)))
+		cache.Commit()
+
+		to := common2.BytesToAddress([]byte("storage gas receiver"))
+		tx, err := types2.SignTx(types2.NewTransaction(0, to, big.NewInt(1e9), gasLimit, big.NewInt(0), nil), signer, key)
+		assert.Nil(t, err)
+		notify := &event.ExecuteNotify{}
+		store := &StateStore{}
+		result, err := store.HandleEIP155Transaction(nil, gasTable, cache, tx, Eip155Context{}, notify, true)
+		assert.Nil(t, err)
+		return cache, to, notify, result.UsedGas, result.Err
+	}
+	balance := func(cache *storage.CacheDB, addr common2.Address) *big.Int {
+		val, err := ong.OngBalanceHandle{}.GetBalance(cache, common.Address(addr))
+		assert.Nil(t, err)
+		return val
+	}
+
+	// storage pricing not activated
+	cache, to, notify, usedGas, vmerr := transfer(map[string]uint64{}, 21000)
+	assert.Nil(t, vmerr)
+	assert.Equal(t, uint64(21000), usedGas)
+	assert.Equal(t, uint64(0), notify.StorageFee)
+	assert.Equal(t, big.NewInt(1e9), balance(cache, to))
+
+	// the new balance of the receiver is charged and the gas limit does not cover it
+	gasTable := map[string]uint64{neovm.STORAGE_BYTE_NAME: 10}
+	cache, to, _, usedGas, vmerr = transfer(gasTable, 21000)
+	assert.True(t, errors.Is(vmerr, evm2.ErrStorageGasInsufficient))
+	assert.Equal(t, uint64(21000), usedGas)
+	assert.Equal(t, uint64(0), balance(cache, to).Uint64())
+	account, err := cache.GetEthAccount(from)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), account.Nonce)
+
+	cache, to, notify, usedGas, vmerr = transfer(gasTable, 100000)
+	assert.Nil(t, vmerr)
+	assert.NotEqual(t, uint64(0), notify.StorageFee)
+	assert.Equal(t, 21000+notify.StorageFee, usedGas)
+	assert.Equal(t, big.NewInt(1e9), balance(cache, to))
+}
@@ -0,0 +1,594 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/log"
+	scom "github.com/qbyyf/ontology/core/store/common"
+	"github.com/qbyyf/ontology/core/store/leveldbstore"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/merkle"
+)
+
+const (
+	VERIFY_CHECK_GENESIS       = "genesis"           //genesis block is the expected one
+	VERIFY_CHECK_CURRENT_BLOCK = "current_block"     //current block of block store and state store are in the block hash index
+	VERIFY_CHECK_HEADER_CHAIN  = "header_chain"      //headers are chained by hash and match the block hash index
+	VERIFY_CHECK_TX_ROOT       = "tx_root"           //transactions of block match the transactions root of header
+	VERIFY_CHECK_BLOCK_MERKLE  = "block_merkle_tree" //block merkle tree matches headers, the merkle hash file and SYS_BLOCK_MERKLE_TREE
+	VERIFY_CHECK_STATE_MERKLE  = "state_merkle_tree" //DATA_STATE_MERKLE_ROOT entries form the state merkle tree of SYS_STATE_MERKLE_TREE
+	VERIFY_CHECK_EXECUTION     = "execution"         //re-executing blocks gives the stored state transition hashes
+
+	DEFAULT_VERIFY_MAX_ERRORS = 100   //Default max errors recorded of each check
+	verifyProgressInterval    = 10000 //Log progress every verifyProgressInterval blocks
+)
+
+// VerifyOption is the option of VerifyLedger
+type VerifyOption struct {
+	StateHashCheckHeight uint32              //Height from which state merkle roots are saved
+	GenesisBlock         *types.Block        //Expected genesis block, skip genesis check if nil
+	Bookkeepers          []keypair.PublicKey //Genesis bookkeepers, required by full execution
+	FullExecution        bool                //Re-execute all blocks in a scratch ledger and compare the state transition hashes
+	MaxErrors            int                 //Max errors recorded of each check, DEFAULT_VERIFY_MAX_ERRORS if not positive
+}
+
+// VerifyError is an inconsistency found at a block height
+type VerifyError struct {
+	Height  uint32 `json:"height"`
+	Message string `json:"message"`
+}
+
+// VerifyCheck is the result of a kind of check
+type VerifyCheck struct {
+	Name       string         `json:"name"`
+	Passed     bool           `json:"passed"`
+	Checked    uint32         `json:"checked"`
+	Skipped    uint32         `json:"skipped"`
+	ErrorCount uint32         `json:"error_count"`
+	Errors     []*VerifyError `json:"errors"` //The first MaxErrors errors
+	maxErrors  int
+}
+
+func (self *VerifyCheck) addError(height uint32, format string, args ...interface{}) {
+	self.ErrorCount++
+	if len(self.Errors) < self.maxErrors {
+		self.Errors = append(self.Errors, &VerifyError{Height: height, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// VerifyReport is the machine-readable report of VerifyLedger
+type VerifyReport struct {
+	DataDir              string         `json:"data_dir"`
+	BlockHeight          uint32         `json:"block_height"`
+	StateHeight          uint32         `json:"state_height"`
+	StateHashCheckHeight uint32         `json:"state_hash_check_height"`
+	PrunedHeight         uint32         `json:"pruned_height"`
+	FullExecution        bool           `json:"full_execution"`
+	Passed               bool           `json:"passed"`
+	Checks               []*VerifyCheck `json:"checks"`
+}
+
+// Check return the check of name, nil if not exist
+func (self *VerifyReport) Check(name string) *VerifyCheck {
+	for _, check := range self.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return nil
+}
+
+type ledgerVerifier struct {
+	option     VerifyOption
+	blockStore *BlockStore
+	stateStore *StateStore
+	report     *VerifyReport
+}
+
+// VerifyLedger check the consistency of the ledger in dataDir, which must not be opened by a running node.
+// The returned error is only about failing to verify, inconsistencies are in the report.
+func VerifyLedger(dataDir string, option VerifyOption) (*VerifyReport, error) {
+	if option.FullExecution && len(option.Bookkeepers) == 0 {
+		return nil, fmt.Errorf("bookkeepers are required by full execution")
+	}
+	if option.MaxErrors <= 0 {
+		option.MaxErrors = DEFAULT_VERIFY_MAX_ERRORS
+	}
+	blockDir := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock)
+	stateDir := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
+	for _, dir := range []string{blockDir, stateDir} {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("%s is not a ledger data dir: %s", dataDir, err)
+		}
+	}
+	//both stores are opened read only, so the verified ledger is never modified
+	blockStore, err := NewReadOnlyBlockStore(blockDir)
+	if err != nil {
+		return nil, fmt.Errorf("NewReadOnlyBlockStore error %s", err)
+	}
+	defer blockStore.Close()
+	stateDB, err := leveldbstore.NewReadOnlyLevelDBStore(stateDir)
+	if err != nil {
+		return nil, fmt.Errorf("NewReadOnlyLevelDBStore error %s", err)
+	}
+	defer stateDB.Close()
+	//merkle hash file is read directly, the state store is not initialized to avoid touching it
+	stateStore := &StateStore{
+		dbDir:                stateDir,
+		store:                stateDB,
+		merklePath:           fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath),
+		stateHashCheckHeight: option.StateHashCheckHeight,
+	}
+
+	report := &VerifyReport{
+		DataDir:              dataDir,
+		StateHashCheckHeight: option.StateHashCheckHeight,
+		FullExecution:        option.FullExecution,
+	}
+	for _, name := range []string{VERIFY_CHECK_GENESIS, VERIFY_CHECK_CURRENT_BLOCK, VERIFY_CHECK_HEADER_CHAIN,
+		VERIFY_CHECK_TX_ROOT, VERIFY_CHECK_BLOCK_MERKLE, VERIFY_CHECK_STATE_MERKLE, VERIFY_CHECK_EXECUTION} {
+		report.Checks = append(report.Checks, &VerifyCheck{Name: name, Errors: []*VerifyError{}, maxErrors: option.MaxErrors})
+	}
+	_, report.BlockHeight, err = blockStore.GetCurrentBlock()
+	if err != nil {
+		return nil, fmt.Errorf("blockStore.GetCurrentBlock error %s", err)
+	}
+	_, report.StateHeight, err = stateStore.GetCurrentBlock()
+	if err != nil {
+		return nil, fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	report.PrunedHeight, err = blockStore.GetBlockPrunedHeight()
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockPrunedHeight error %s", err)
+	}
+
+	verifier := &ledgerVerifier{
+		option:     option,
+		blockStore: blockStore,
+		stateStore: stateStore,
+		report:     report,
+	}
+	verifier.verifyGenesis()
+	verifier.verifyCurrentBlock()
+	err = verifier.verifyBlocks()
+	if err != nil {
+		return nil, err
+	}
+	verifier.verifyStateMerkleTree()
+	if option.FullExecution {
+		err = verifier.verifyExecution()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		report.Check(VERIFY_CHECK_EXECUTION).Skipped = verifier.stateCheckedHeight() + 1
+	}
+
+	report.Passed = true
+	for _, check := range report.Checks {
+		check.Passed = check.ErrorCount == 0
+		report.Passed = report.Passed && check.Passed
+	}
+	return report, nil
+}
+
+// stateCheckedHeight return the max height whose states are verifiable
+func (self *ledgerVerifier) stateCheckedHeight() uint32 {
+	if self.report.StateHeight > self.report.BlockHeight {
+		return self.report.BlockHeight
+	}
+	return self.report.StateHeight
+}
+
+func (self *ledgerVerifier) verifyGenesis() {
+	check := self.report.Check(VERIFY_CHECK_GENESIS)
+	if self.option.GenesisBlock == nil {
+		check.Skipped++
+		return
+	}
+	check.Checked++
+	hash, err := self.blockStore.GetBlockHash(0)
+	if err != nil {
+		check.addError(0, "GetBlockHash error %s", err)
+		return
+	}
+	if expected := self.option.GenesisBlock.Hash(); hash != expected {
+		check.addError(0, "genesis block hash %s mismatch, expected %s", hash.ToHexString(), expected.ToHexString())
+	}
+}
+
+func (self *ledgerVerifier) verifyCurrentBlock() {
+	check := self.report.Check(VERIFY_CHECK_CURRENT_BLOCK)
+	check.Checked++
+	blockHash, blockHeight, _ := self.blockStore.GetCurrentBlock()
+	if hash, err := self.blockStore.GetBlockHash(blockHeight); err != nil {
+		check.addError(blockHeight, "GetBlockHash error %s", err)
+	} else if hash != blockHash {
+		check.addError(blockHeight, "current block hash %s of block store mismatch with block hash index %s",
+			blockHash.ToHexString(), hash.ToHexString())
+	}
+	stateHash, stateHeight, _ := self.stateStore.GetCurrentBlock()
+	if stateHeight > blockHeight {
+		check.addError(stateHeight, "state store is ahead of block store at height %d", blockHeight)
+		return
+	}
+	if hash, err := self.blockStore.GetBlockHash(stateHeight); err != nil {
+		check.addError(stateHeight, "GetBlockHash error %s", err)
+	} else if hash != stateHash {
+		check.addError(stateHeight, "current block hash %s of state store mismatch with block hash index %s",
+			stateHash.ToHexString(), hash.ToHexString())
+	}
+}
+
+// verifyBlocks check header chain, transactions root and block merkle tree in one pass of the block store
+func (self *ledgerVerifier) verifyBlocks() error {
+	merkleCheck := self.report.Check(VERIFY_CHECK_BLOCK_MERKLE)
+	stateHeight := self.stateCheckedHeight()
+
+	var hashFile *bufio.Reader
+	file, err := os.Open(self.stateStore.merklePath)
+	if err != nil {
+		merkleCheck.addError(0, "open merkle hash store error %s", err)
+	} else {
+		defer file.Close()
+		hashFile = bufio.NewReader(file)
+	}
+	recorder := &hashRecorder{}
+	tree := merkle.NewTree(0, nil, recorder)
+	treeBroken := false
+
+	prevHash := common.UINT256_EMPTY
+	for height := uint32(0); height <= self.report.BlockHeight; height++ {
+		if height > 0 && height%verifyProgressInterval == 0 {
+			log.Infof("verify ledger: %d/%d blocks checked", height, self.report.BlockHeight)
+		}
+		header := self.verifyHeader(height, prevHash)
+		if header != nil {
+			prevHash = header.Hash()
+		} else {
+			//keep chaining with the block hash index
+			prevHash, _ = self.blockStore.GetBlockHash(height)
+		}
+
+		if treeBroken {
+			merkleCheck.Skipped++
+			continue
+		}
+		//the merkle hash file is only persisted along with the state store
+		var stored []common.Uint256
+		if height <= stateHeight && hashFile != nil {
+			stored, err = readHashes(hashFile, appendedHashCount(tree.TreeSize()))
+			if err != nil {
+				merkleCheck.addError(height, "read merkle hash store error %s", err)
+				hashFile = nil
+			}
+		}
+		var leaf common.Uint256
+		if header != nil {
+			leaf = header.TransactionsRoot
+		} else if stored != nil {
+			leaf = stored[0]
+		} else {
+			merkleCheck.addError(height, "block merkle tree can not be rebuilt without header or merkle hash store")
+			treeBroken = true
+			continue
+		}
+		merkleCheck.Checked++
+		tree.AppendHash(leaf)
+		if stored != nil {
+			for i, hash := range recorder.hashes {
+				if hash != stored[i] {
+					merkleCheck.addError(height, "merkle hash store has hash %s at %d, expected %s",
+						stored[i].ToHexString(), i, hash.ToHexString())
+					break
+				}
+			}
+		}
+		if header != nil && height > 0 && tree.Root() != header.BlockRoot {
+			merkleCheck.addError(height, "block root %s mismatch, expected %s",
+				header.BlockRoot.ToHexString(), tree.Root().ToHexString())
+		}
+		if height == stateHeight {
+			self.verifyBlockMerkleTree(tree)
+		}
+	}
+	return nil
+}
+
+// verifyHeader check header and transactions at height, return nil if the header is not available
+func (self *ledgerVerifier) verifyHeader(height uint32, prevHash common.Uint256) *types.Header {
+	headerCheck := self.report.Check(VERIFY_CHECK_HEADER_CHAIN)
+	txCheck := self.report.Check(VERIFY_CHECK_TX_ROOT)
+	hash, err := self.blockStore.GetBlockHash(height)
+	if err != nil {
+		headerCheck.addError(height, "GetBlockHash error %s", err)
+		txCheck.Skipped++
+		return nil
+	}
+	header, txHashes, err := self.blockStore.loadHeaderWithTx(hash)
+	if err == scom.ErrNotFound && height > 0 && height <= self.report.PrunedHeight {
+		headerCheck.Skipped++
+		txCheck.Skipped++
+		return nil
+	}
+	if err != nil {
+		headerCheck.addError(height, "load header %s error %s", hash.ToHexString(), err)
+		txCheck.Skipped++
+		return nil
+	}
+
+	headerCheck.Checked++
+	if header.Height != height {
+		headerCheck.addError(height, "header height %d mismatch", header.Height)
+	}
+	if headerHash := header.Hash(); headerHash != hash {
+		headerCheck.addError(height, "header hash %s mismatch with block hash index %s",
+			headerHash.ToHexString(), hash.ToHexString())
+	}
+	if height > 0 && header.PrevBlockHash != prevHash {
+		headerCheck.addError(height, "previous block hash %s mismatch, expected %s",
+			header.PrevBlockHash.ToHexString(), prevHash.ToHexString())
+	}
+
+	txCheck.Checked++
+	//hashes are used as workspace by ComputeMerkleRoot
+	if root := common.ComputeMerkleRoot(append([]common.Uint256{}, txHashes...)); root != header.TransactionsRoot {
+		txCheck.addError(height, "transactions root %s mismatch, expected %s",
+			header.TransactionsRoot.ToHexString(), root.ToHexString())
+	}
+	for _, txHash := range txHashes {
+		tx, txHeight, err := self.blockStore.GetTransaction(txHash)
+		if err != nil {
+			txCheck.addError(height, "load transaction %s error %s", txHash.ToHexString(), err)
+			continue
+		}
+		if tx.Hash() != txHash {
+			txCheck.addError(height, "transaction %s hash mismatch", txHash.ToHexString())
+		}
+		if txHeight != height {
+			txCheck.addError(height, "transaction %s is saved at height %d", txHash.ToHexString(), txHeight)
+		}
+	}
+	return header
+}
+
+// verifyBlockMerkleTree compare the rebuilt block merkle tree with SYS_BLOCK_MERKLE_TREE
+func (self *ledgerVerifier) verifyBlockMerkleTree(tree *merkle.CompactMerkleTree) {
+	check := self.report.Check(VERIFY_CHECK_BLOCK_MERKLE)
+	height := tree.TreeSize() - 1
+	treeSize, hashes, err := self.stateStore.GetBlockMerkleTree()
+	if err != nil {
+		check.addError(height, "GetBlockMerkleTree error %s", err)
+		return
+	}
+	if treeSize != tree.TreeSize() || !hashesEqual(hashes, tree.Hashes()) {
+		check.addError(height, "SYS_BLOCK_MERKLE_TREE with size %d mismatch with rebuilt tree of size %d",
+			treeSize, tree.TreeSize())
+	}
+}
+
+func (self *ledgerVerifier) verifyStateMerkleTree() {
+	check := self.report.Check(VERIFY_CHECK_STATE_MERKLE)
+	checkHeight := self.option.StateHashCheckHeight
+	stateHeight := self.stateCheckedHeight()
+	if stateHeight < checkHeight {
+		return
+	}
+	tree := merkle.NewTree(0, nil, nil)
+	for height := checkHeight; height <= stateHeight; height++ {
+		writeSetHash, root, err := getStateMerkleRootEntry(self.stateStore, height)
+		if err != nil {
+			check.addError(height, "load state merkle root error %s", err)
+			check.Skipped += stateHeight - height
+			return
+		}
+		check.Checked++
+		tree.AppendHash(writeSetHash)
+		if tree.Root() != root {
+			check.addError(height, "state merkle root %s mismatch, expected %s",
+				root.ToHexString(), tree.Root().ToHexString())
+		}
+	}
+	treeSize, hashes, err := self.stateStore.GetStateMerkleTree()
+	if err != nil {
+		check.addError(stateHeight, "GetStateMerkleTree error %s", err)
+		return
+	}
+	if treeSize != tree.TreeSize() || !hashesEqual(hashes, tree.Hashes()) {
+		check.addError(stateHeight, "SYS_STATE_MERKLE_TREE with size %d mismatch with rebuilt tree of size %d",
+			treeSize, tree.TreeSize())
+	}
+}
+
+// verifyExecution replay blocks in a scratch ledger, and stop at the first block whose execution result diverges
+func (self *ledgerVerifier) verifyExecution() error {
+	check := self.report.Check(VERIFY_CHECK_EXECUTION)
+	if self.report.PrunedHeight > 0 {
+		check.addError(self.report.PrunedHeight, "blocks are pruned, ledger can not be re-executed")
+		return nil
+	}
+	genesisHash, err := self.blockStore.GetBlockHash(0)
+	if err != nil {
+		check.addError(0, "GetBlockHash error %s", err)
+		return nil
+	}
+	genesisBlock, err := self.blockStore.GetBlock(genesisHash)
+	if err != nil {
+		check.addError(0, "GetBlock error %s", err)
+		return nil
+	}
+
+	scratchDir, err := ioutil.TempDir("", "ledger-verify")
+	if err != nil {
+		return fmt.Errorf("create scratch dir error %s", err)
+	}
+	defer os.RemoveAll(scratchDir)
+	scratch, err := NewLedgerStore(scratchDir, self.option.StateHashCheckHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedgerStore error %s", err)
+	}
+	defer scratch.Close()
+	err = scratch.InitLedgerStoreWithGenesisBlock(genesisBlock, self.option.Bookkeepers)
+	if err != nil {
+		check.addError(0, "execute genesis block error %s", err)
+		return nil
+	}
+	check.Checked++
+	if self.option.StateHashCheckHeight == 0 {
+		executed, _, err := getStateMerkleRootEntry(scratch.stateStore, 0)
+		if err != nil {
+			return fmt.Errorf("load executed state merkle root error %s", err)
+		}
+		if !self.compareExecution(0, executed, common.UINT256_EMPTY, false) {
+			return nil
+		}
+	}
+
+	stateHeight := self.stateCheckedHeight()
+	for height := uint32(1); height <= stateHeight; height++ {
+		if height%verifyProgressInterval == 0 {
+			log.Infof("verify ledger: %d/%d blocks executed", height, stateHeight)
+		}
+		hash, err := self.blockStore.GetBlockHash(height)
+		if err != nil {
+			check.addError(height, "GetBlockHash error %s", err)
+			return nil
+		}
+		block, err := self.blockStore.GetBlock(hash)
+		if err != nil {
+			check.addError(height, "GetBlock error %s", err)
+			return nil
+		}
+		result, err := scratch.executeBlock(block)
+		if err != nil {
+			check.addError(height, "execute block error %s", err)
+			return nil
+		}
+		check.Checked++
+		if height >= self.option.StateHashCheckHeight &&
+			!self.compareExecution(height, result.Hash, result.MerkleRoot, true) {
+			return nil
+		}
+		err = scratch.submitBlock(block, nil, result)
+		if err != nil {
+			check.addError(height, "submit block error %s", err)
+			return nil
+		}
+	}
+	return nil
+}
+
+// compareExecution compare executed state transition hash, and state merkle root if withRoot, with the stored ones
+func (self *ledgerVerifier) compareExecution(height uint32, hash, root common.Uint256, withRoot bool) bool {
+	check := self.report.Check(VERIFY_CHECK_EXECUTION)
+	storedHash, storedRoot, err := getStateMerkleRootEntry(self.stateStore, height)
+	if err != nil {
+		check.addError(height, "load state merkle root error %s", err)
+		return false
+	}
+	if storedHash != hash {
+		check.addError(height, "state transition hash %s mismatch, executed %s",
+			storedHash.ToHexString(), hash.ToHexString())
+		return false
+	}
+	if withRoot && storedRoot != root {
+		check.addError(height, "state merkle root %s mismatch, executed %s",
+			storedRoot.ToHexString(), root.ToHexString())
+		return false
+	}
+	return true
+}
+
+// getStateMerkleRootEntry return the state transition hash and state merkle root saved at height
+func getStateMerkleRootEntry(stateStore *StateStore, height uint32) (common.Uint256, common.Uint256, error) {
+	value, err := stateStore.store.Get(stateStore.genStateMerkleRootKey(height))
+	if err != nil {
+		return common.UINT256_EMPTY, common.UINT256_EMPTY, err
+	}
+	source := common.NewZeroCopySource(value)
+	writeSetHash, eof := source.NextHash()
+	if eof {
+		return common.UINT256_EMPTY, common.UINT256_EMPTY, io.ErrUnexpectedEOF
+	}
+	root, eof := source.NextHash()
+	if eof {
+		return common.UINT256_EMPTY, common.UINT256_EMPTY, io.ErrUnexpectedEOF
+	}
+	return writeSetHash, root, nil
+}
+
+// hashRecorder is a merkle.HashStore keeping the hashes of the last append
+type hashRecorder struct {
+	hashes []common.Uint256
+}
+
+func (self *hashRecorder) Append(hashes []common.Uint256) error {
+	self.hashes = hashes
+	return nil
+}
+
+func (self *hashRecorder) Flush() error {
+	return nil
+}
+
+func (self *hashRecorder) Close() {}
+
+func (self *hashRecorder) GetHash(pos uint32) (common.Uint256, error) {
+	return common.UINT256_EMPTY, fmt.Errorf("hashRecorder does not support GetHash")
+}
+
+// appendedHashCount return the count of hashes stored when appending a leaf to a merkle tree of treeSize
+func appendedHashCount(treeSize uint32) int {
+	count := 1
+	for s := treeSize; s%2 == 1; s = s >> 1 {
+		count++
+	}
+	return count
+}
+
+func readHashes(reader io.Reader, count int) ([]common.Uint256, error) {
+	hashes := make([]common.Uint256, count)
+	for i := range hashes {
+		_, err := io.ReadFull(reader, hashes[i][:])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+func hashesEqual(a, b []common.Uint256) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
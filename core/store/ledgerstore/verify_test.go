@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/core/genesis"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/store/leveldbstore"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// useSoloConsensus switch genesis config to solo consensus, and return the function to switch back
+func useSoloConsensus(bookkeeper keypair.PublicKey) func() {
+	genesisConfig := config.DefConfig.Genesis
+	soloConfig := *genesisConfig
+	soloConfig.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	soloConfig.SOLO = &config.SOLOConfig{
+		Bookkeepers: []string{hex.EncodeToString(keypair.SerializePublicKey(bookkeeper))},
+	}
+	config.DefConfig.Genesis = &soloConfig
+	return func() {
+		config.DefConfig.Genesis = genesisConfig
+	}
+}
+
+func buildVerifyLedger(t *testing.T, dataDir string, acc *account.Account, blockNum uint32) ([]keypair.PublicKey, *types.Block) {
+	bookkeepers := []keypair.PublicKey{acc.PublicKey}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	ledgerStore, err := NewLedgerStore(dataDir, 0)
+	assert.Nil(t, err)
+	err = ledgerStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers)
+	assert.Nil(t, err)
+	nextBookkeeper, err := types.AddressFromBookkeepers(bookkeepers)
+	assert.Nil(t, err)
+
+	for height := uint32(1); height <= blockNum; height++ {
+		var txs []*types.Transaction
+		if height%2 == 0 {
+			tx, err := transferTx(acc.Address, common.ADDRESS_EMPTY, uint64(height))
+			assert.Nil(t, err)
+			txs = append(txs, tx)
+		}
+		txHashes := make([]common.Uint256, 0, len(txs))
+		for _, tx := range txs {
+			txHashes = append(txHashes, tx.Hash())
+		}
+		txRoot := common.ComputeMerkleRoot(txHashes)
+		prevHeader, err := ledgerStore.GetHeaderByHeight(height - 1)
+		assert.Nil(t, err)
+		block := &types.Block{
+			Header: &types.Header{
+				PrevBlockHash:    prevHeader.Hash(),
+				TransactionsRoot: txRoot,
+				BlockRoot:        ledgerStore.GetBlockRootWithNewTxRoots(height, []common.Uint256{txRoot}),
+				Timestamp:        prevHeader.Timestamp + 1,
+				Height:           height,
+				NextBookkeeper:   nextBookkeeper,
+			},
+			Transactions: txs,
+		}
+		hash := block.Hash()
+		sig, err := signature.Sign(acc, hash[:])
+		assert.Nil(t, err)
+		block.Header.Bookkeepers = bookkeepers
+		block.Header.SigData = [][]byte{sig}
+		result, err := ledgerStore.ExecuteBlock(block)
+		assert.Nil(t, err)
+		err = ledgerStore.SubmitBlock(block, nil, result)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, ledgerStore.Close())
+	return bookkeepers, genesisBlock
+}
+
+func TestVerifyLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	acc := account.NewAccount("")
+	defer useSoloConsensus(acc.PublicKey)()
+	bookkeepers, genesisBlock := buildVerifyLedger(t, dir, acc, 6)
+
+	report, err := VerifyLedger(dir, VerifyOption{
+		GenesisBlock:  genesisBlock,
+		Bookkeepers:   bookkeepers,
+		FullExecution: true,
+	})
+	assert.Nil(t, err)
+	assert.True(t, report.Passed)
+	assert.Equal(t, uint32(6), report.BlockHeight)
+	assert.Equal(t, uint32(6), report.StateHeight)
+	for _, check := range report.Checks {
+		assert.True(t, check.Passed, check.Name)
+	}
+	assert.Equal(t, uint32(7), report.Check(VERIFY_CHECK_HEADER_CHAIN).Checked)
+	assert.Equal(t, uint32(7), report.Check(VERIFY_CHECK_BLOCK_MERKLE).Checked)
+	assert.Equal(t, uint32(7), report.Check(VERIFY_CHECK_STATE_MERKLE).Checked)
+	assert.Equal(t, uint32(7), report.Check(VERIFY_CHECK_EXECUTION).Checked)
+
+	_, err = VerifyLedger(filepath.Join(dir, "none"), VerifyOption{})
+	assert.NotNil(t, err)
+	_, err = VerifyLedger(dir, VerifyOption{FullExecution: true})
+	assert.NotNil(t, err)
+}
+
+func TestVerifyCorruptedLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	acc := account.NewAccount("")
+	defer useSoloConsensus(acc.PublicKey)()
+	bookkeepers, _ := buildVerifyLedger(t, dir, acc, 6)
+
+	//tamper the state transition hash of block 4
+	stateDB, err := leveldbstore.NewLevelDBStore(filepath.Join(dir, DBDirState))
+	assert.Nil(t, err)
+	stateStore := &StateStore{store: stateDB}
+	_, root, err := getStateMerkleRootEntry(stateStore, 4)
+	assert.Nil(t, err)
+	value := common.NewZeroCopySink(nil)
+	value.WriteHash(common.UINT256_EMPTY)
+	value.WriteHash(root)
+	assert.Nil(t, stateDB.Put(stateStore.genStateMerkleRootKey(4), value.Bytes()))
+	assert.Nil(t, stateDB.Close())
+
+	//tamper the leaf of block 2 in merkle hash file
+	merklePath := filepath.Join(dir, MerkleTreeStorePath)
+	data, err := ioutil.ReadFile(merklePath)
+	assert.Nil(t, err)
+	offset := appendedHashCount(0) + appendedHashCount(1)
+	data[offset*common.UINT256_SIZE] ^= 0xff
+	assert.Nil(t, ioutil.WriteFile(merklePath, data, 0644))
+
+	report, err := VerifyLedger(dir, VerifyOption{
+		GenesisBlock:  &types.Block{Header: &types.Header{}},
+		Bookkeepers:   bookkeepers,
+		FullExecution: true,
+	})
+	assert.Nil(t, err)
+	assert.False(t, report.Passed)
+	assert.False(t, report.Check(VERIFY_CHECK_GENESIS).Passed)
+	assert.True(t, report.Check(VERIFY_CHECK_HEADER_CHAIN).Passed)
+	assert.True(t, report.Check(VERIFY_CHECK_TX_ROOT).Passed)
+
+	merkleCheck := report.Check(VERIFY_CHECK_BLOCK_MERKLE)
+	assert.Equal(t, uint32(1), merkleCheck.ErrorCount)
+	assert.Equal(t, uint32(2), merkleCheck.Errors[0].Height)
+
+	stateCheck := report.Check(VERIFY_CHECK_STATE_MERKLE)
+	assert.False(t, stateCheck.Passed)
+	assert.Equal(t, uint32(4), stateCheck.Errors[0].Height)
+
+	execCheck := report.Check(VERIFY_CHECK_EXECUTION)
+	assert.Equal(t, uint32(1), execCheck.ErrorCount)
+	assert.Equal(t, uint32(4), execCheck.Errors[0].Height)
+}
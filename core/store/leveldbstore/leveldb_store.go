@@ -39,8 +39,8 @@ type LevelDBStore struct {
 // too small will lead to high false positive rate.
 const BITSPERKEY = 10
 
-//NewLevelDBStore return LevelDBStore instance
-func NewLevelDBStore(file string) (*LevelDBStore, error) {
+// defaultOptions return the options to open a leveldb file
+func defaultOptions() opt.Options {
 	openFileCache := opt.DefaultOpenFilesCacheCapacity
 	maxOpenFiles, err := fdlimit.Current()
 	if err == nil && maxOpenFiles < openFileCache*5 {
@@ -51,13 +51,16 @@ func NewLevelDBStore(file string) (*LevelDBStore, error) {
 		openFileCache = 16
 	}
 
-	// default Options
-	o := opt.Options{
+	return opt.Options{
 		NoSync:                 false,
 		OpenFilesCacheCapacity: openFileCache,
 		Filter:                 filter.NewBloomFilter(BITSPERKEY),
 	}
+}
 
+//NewLevelDBStore return LevelDBStore instance
+func NewLevelDBStore(file string) (*LevelDBStore, error) {
+	o := defaultOptions()
 	db, err := leveldb.OpenFile(file, &o)
 
 	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
@@ -74,6 +77,23 @@ func NewLevelDBStore(file string) (*LevelDBStore, error) {
 	}, nil
 }
 
+//NewReadOnlyLevelDBStore return LevelDBStore instance of an existing leveldb file opened read only.
+//A corrupted file is not recovered, and all the writes fail
+func NewReadOnlyLevelDBStore(file string) (*LevelDBStore, error) {
+	o := defaultOptions()
+	o.ReadOnly = true
+	o.ErrorIfMissing = true
+	db, err := leveldb.OpenFile(file, &o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelDBStore{
+		db:    db,
+		batch: nil,
+	}, nil
+}
+
 func NewMemLevelDBStore() *LevelDBStore {
 	store := storage.NewMemStorage()
 	// default Options
@@ -157,3 +177,8 @@ func (self *LevelDBStore) NewIterator(prefix []byte) common.StoreIterator {
 
 	return iter
 }
+
+//NewRangeIterator return a iterator of leveldb with the keys in [start, limit), limit is unbounded if nil
+func (self *LevelDBStore) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	return self.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
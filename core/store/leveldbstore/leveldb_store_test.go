@@ -140,3 +140,47 @@ func TestIterator(t *testing.T) {
 	}
 
 }
+
+func TestReadOnly(t *testing.T) {
+	dbFile := "./test_readonly"
+	defer os.RemoveAll(dbFile)
+	_, err := NewReadOnlyLevelDBStore(dbFile)
+	if err == nil {
+		t.Errorf("NewReadOnlyLevelDBStore should fail on missing db")
+		return
+	}
+
+	store, err := NewLevelDBStore(dbFile)
+	if err != nil {
+		t.Errorf("NewLevelDBStore error:%s", err)
+		return
+	}
+	key := []byte("foo")
+	value := []byte("bar")
+	if err = store.Put(key, value); err != nil {
+		t.Errorf("Put error:%s", err)
+		return
+	}
+	store.Close()
+
+	readOnly, err := NewReadOnlyLevelDBStore(dbFile)
+	if err != nil {
+		t.Errorf("NewReadOnlyLevelDBStore error:%s", err)
+		return
+	}
+	defer readOnly.Close()
+	v, err := readOnly.Get(key)
+	if err != nil || string(v) != string(value) {
+		t.Errorf("Get %s error:%v", v, err)
+		return
+	}
+	if err = readOnly.Put(key, []byte("baz")); err == nil {
+		t.Errorf("Put should fail on read only db")
+		return
+	}
+	readOnly.NewBatch()
+	readOnly.BatchDelete(key)
+	if err = readOnly.BatchCommit(); err == nil {
+		t.Errorf("BatchCommit should fail on read only db")
+	}
+}
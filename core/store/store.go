@@ -42,6 +42,13 @@ type ExecuteResult struct {
 	Notify          []*event.ExecuteNotify
 }
 
+// BundleResult is the result of executing a list of transactions sequentially on one overlay
+type BundleResult struct {
+	Height    uint32                 // height of the block the bundle is simulated on top of
+	Notify    []*event.ExecuteNotify // execute notify of each transaction
+	WriteSets []*overlaydb.MemDB     // cumulative write set after each transaction
+}
+
 // LedgerStore provides func with store package.
 type LedgerStore interface {
 	InitLedgerStoreWithGenesisBlock(genesisblock *types.Block, defaultBookkeeper []keypair.PublicKey) error
@@ -69,11 +76,14 @@ type LedgerStore interface {
 	GetContractState(contractHash common.Address) (*payload.DeployCode, error)
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(codeHash common.Address, key []byte) ([]byte, error)
+	GetStorageState(codeHash common.Address, key []byte) (*states.StorageItem, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
 	PreExecuteContractBatch(txes []*types.Transaction, atomic bool) ([]*cstates.PreExecResult, uint32, error)
 	PreExecuteEip155Tx(msg types2.Message) (*types3.ExecutionResult, error)
+	SimulateBundle(txes []*types.Transaction) (*BundleResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
+	GetDidEventHeights(did string, from uint32, limit int) ([]uint32, error)
 	GetEthCode(hash common2.Hash) ([]byte, error)
 	GetEthState(address common2.Address, key common2.Hash) ([]byte, error)
 	GetEthAccount(address common2.Address) (*storage.EthAccount, error)
@@ -0,0 +1,74 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"testing"
+)
+
+// FuzzTransactionFromRawBytes checks that a decoded transaction keeps exactly the bytes it consumed and decodes
+// to the same transaction again. The seeds in testdata/fuzz/FuzzTransactionFromRawBytes are the transactions of
+// MainNet block 0, mainnet_block_0_tx_i is the i-th one. They are checked against the block rebuilt from the
+// MainNet genesis config by TestMainNetFuzzCorpus in core/genesis.
+func FuzzTransactionFromRawBytes(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx, err := TransactionFromRawBytes(data)
+		if err != nil {
+			return
+		}
+		if len(tx.Raw) > len(data) {
+			t.Fatalf("raw transaction longer than input: %d > %d", len(tx.Raw), len(data))
+		}
+		tx2, err := TransactionFromRawBytes(tx.ToArray())
+		if err != nil {
+			t.Fatalf("decode serialized transaction: %s", err)
+		}
+		if tx.Hash() != tx2.Hash() {
+			t.Fatalf("transaction hash mismatch: %s, %s", tx.Hash().ToHexString(), tx2.Hash().ToHexString())
+		}
+	})
+}
+
+// FuzzBlockFromRawBytes checks that a decoded block serializes back to a block with the same hash and transactions.
+// The seed mainnet_block_0 in testdata/fuzz/FuzzBlockFromRawBytes is MainNet block 0 of hash
+// 1b8fa7f242d0eeb4395f89cbb59e4c29634047e33245c4914306e78a88e14ce5, and empty_block is a synthetic block
+// without transactions.
+func FuzzBlockFromRawBytes(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		block, err := BlockFromRawBytes(data)
+		if err != nil {
+			return
+		}
+		block2, err := BlockFromRawBytes(block.ToArray())
+		if err != nil {
+			t.Fatalf("decode serialized block: %s", err)
+		}
+		if block.Hash() != block2.Hash() || len(block.Transactions) != len(block2.Transactions) {
+			t.Fatalf("block mismatch at height %d", block.Header.Height)
+		}
+		for i, tx := range block.Transactions {
+			if tx.Hash() != block2.Transactions[i].Hash() {
+				t.Fatalf("transaction %d mismatch: %s", i, tx.Hash().ToHexString())
+			}
+		}
+	})
+}
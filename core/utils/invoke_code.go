@@ -0,0 +1,217 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/qbyyf/ontology/common"
+	vm "github.com/qbyyf/ontology/vm/neovm"
+)
+
+// NeoVMItem is the stack item of param building code in NeoVM invoke transaction
+type NeoVMItem struct {
+	Data    []byte
+	Items   []*NeoVMItem
+	IsArray bool
+}
+
+func (this *NeoVMItem) Integer() (*big.Int, error) {
+	if this.IsArray {
+		return nil, fmt.Errorf("array is not integer")
+	}
+	return common.BigIntFromNeoBytes(this.Data), nil
+}
+
+// NeoVMInvoke is the contract call of NeoVM invoke code
+type NeoVMInvoke struct {
+	Native   bool
+	Contract common.Address
+	Version  byte //version of native contract
+	Method   string
+	Args     *NeoVMItem //params of native contract, or the param array of NeoVM contract
+}
+
+// ParseNeoVMInvokeCode runs the param building opcodes of invoke code, to find out the contract, method and params.
+// Code with other opcodes is rejected, since what it does cannot be known without execution
+func ParseNeoVMInvokeCode(code []byte) (*NeoVMInvoke, error) {
+	var stack, altStack []*NeoVMItem
+	pop := func(s *[]*NeoVMItem) (*NeoVMItem, error) {
+		if len(*s) == 0 {
+			return nil, fmt.Errorf("stack underflow")
+		}
+		item := (*s)[len(*s)-1]
+		*s = (*s)[:len(*s)-1]
+		return item, nil
+	}
+	source := common.NewZeroCopySource(code)
+	for source.Len() > 0 {
+		b, _ := source.NextByte()
+		op := vm.OpCode(b)
+		switch {
+		case op == vm.PUSH0:
+			stack = append(stack, &NeoVMItem{Data: []byte{}})
+		case op >= vm.PUSHBYTES1 && op <= vm.PUSHBYTES75:
+			data, eof := source.NextBytes(uint64(op))
+			if eof {
+				return nil, fmt.Errorf("invalid push bytes")
+			}
+			stack = append(stack, &NeoVMItem{Data: data})
+		case op == vm.PUSHDATA1 || op == vm.PUSHDATA2 || op == vm.PUSHDATA4:
+			var size uint64
+			var eof bool
+			switch op {
+			case vm.PUSHDATA1:
+				var l byte
+				l, eof = source.NextByte()
+				size = uint64(l)
+			case vm.PUSHDATA2:
+				var l []byte
+				l, eof = source.NextBytes(2)
+				if !eof {
+					size = uint64(binary.LittleEndian.Uint16(l))
+				}
+			default:
+				var l []byte
+				l, eof = source.NextBytes(4)
+				if !eof {
+					size = uint64(binary.LittleEndian.Uint32(l))
+				}
+			}
+			if eof {
+				return nil, fmt.Errorf("invalid push data")
+			}
+			data, eof := source.NextBytes(size)
+			if eof {
+				return nil, fmt.Errorf("invalid push data")
+			}
+			stack = append(stack, &NeoVMItem{Data: data})
+		case op == vm.PUSHM1 || op >= vm.PUSH1 && op <= vm.PUSH16:
+			num := int64(op) - int64(vm.PUSH1) + 1
+			stack = append(stack, &NeoVMItem{Data: common.BigIntToNeoBytes(big.NewInt(num))})
+		case op == vm.NEWSTRUCT || op == vm.NEWARRAY:
+			size, err := pop(&stack)
+			if err != nil {
+				return nil, err
+			}
+			n, err := size.Integer()
+			if err != nil || n.Sign() < 0 || n.Cmp(big.NewInt(1024)) > 0 {
+				return nil, fmt.Errorf("invalid array size")
+			}
+			item := &NeoVMItem{IsArray: true}
+			for i := int64(0); i < n.Int64(); i++ {
+				item.Items = append(item.Items, &NeoVMItem{})
+			}
+			stack = append(stack, item)
+		case op == vm.PACK:
+			size, err := pop(&stack)
+			if err != nil {
+				return nil, err
+			}
+			n, err := size.Integer()
+			if err != nil || n.Sign() < 0 || n.Cmp(big.NewInt(int64(len(stack)))) > 0 {
+				return nil, fmt.Errorf("invalid pack size")
+			}
+			item := &NeoVMItem{IsArray: true}
+			for i := int64(0); i < n.Int64(); i++ {
+				elem, _ := pop(&stack)
+				item.Items = append(item.Items, elem)
+			}
+			stack = append(stack, item)
+		case op == vm.TOALTSTACK:
+			item, err := pop(&stack)
+			if err != nil {
+				return nil, err
+			}
+			altStack = append(altStack, item)
+		case op == vm.DUPFROMALTSTACK:
+			if len(altStack) == 0 {
+				return nil, fmt.Errorf("alt stack underflow")
+			}
+			stack = append(stack, altStack[len(altStack)-1])
+		case op == vm.FROMALTSTACK:
+			item, err := pop(&altStack)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, item)
+		case op == vm.SWAP:
+			if len(stack) < 2 {
+				return nil, fmt.Errorf("stack underflow")
+			}
+			stack[len(stack)-1], stack[len(stack)-2] = stack[len(stack)-2], stack[len(stack)-1]
+		case op == vm.APPEND:
+			elem, err := pop(&stack)
+			if err != nil {
+				return nil, err
+			}
+			arr, err := pop(&stack)
+			if err != nil {
+				return nil, err
+			}
+			if !arr.IsArray {
+				return nil, fmt.Errorf("append to non array")
+			}
+			arr.Items = append(arr.Items, elem)
+		case op == vm.SYSCALL:
+			name, _, irregular, eof := source.NextString()
+			if irregular || eof || name != NATIVE_INVOKE_NAME || source.Len() != 0 {
+				return nil, fmt.Errorf("unsupported syscall in invoke code")
+			}
+			//native invoke code is: params, method, contract address, version
+			if len(stack) != 4 || stack[1].IsArray || stack[2].IsArray || stack[3].IsArray {
+				return nil, fmt.Errorf("invalid native invoke code")
+			}
+			contract, err := common.AddressParseFromBytes(stack[2].Data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid native contract address")
+			}
+			version, _ := stack[3].Integer()
+			if !version.IsUint64() || version.Uint64() > 255 {
+				return nil, fmt.Errorf("invalid native contract version")
+			}
+			return &NeoVMInvoke{
+				Native:   true,
+				Contract: contract,
+				Version:  byte(version.Uint64()),
+				Method:   string(stack[1].Data),
+				Args:     stack[0],
+			}, nil
+		case op == vm.APPCALL:
+			contract, eof := source.NextAddress()
+			if eof || source.Len() != 0 {
+				return nil, fmt.Errorf("invalid appcall in invoke code")
+			}
+			//neovm invoke code is: param array, method
+			invoke := &NeoVMInvoke{Contract: contract, Args: &NeoVMItem{IsArray: true}}
+			if len(stack) > 0 && !stack[len(stack)-1].IsArray {
+				invoke.Method = string(stack[len(stack)-1].Data)
+				if len(stack) > 1 && stack[len(stack)-2].IsArray {
+					invoke.Args = stack[len(stack)-2]
+				}
+			}
+			return invoke, nil
+		default:
+			return nil, fmt.Errorf("unsupported opcode 0x%x in invoke code", byte(op))
+		}
+	}
+	return nil, fmt.Errorf("invoke code without contract call")
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/qbyyf/ontology/core/payload"
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/qbyyf/ontology/smartcontract/states"
+	"github.com/qbyyf/ontology/vm/crossvm_codec"
 	vm "github.com/qbyyf/ontology/vm/neovm"
 )
 
@@ -252,6 +253,38 @@ func BuildWasmContractParam(params []interface{}) ([]byte, error) {
 	return bf.Bytes(), nil
 }
 
+//build invoke code for wasm contract, which params are encoded by crossvm codec
+func BuildWasmVMCrossVMInvokeCode(contractAddress common.Address, params []interface{}) ([]byte, error) {
+	argbytes, err := BuildWasmContractCrossVMParam(params)
+	if err != nil {
+		return nil, fmt.Errorf("build wasm contract param failed:%s", err)
+	}
+	contract := &states.WasmContractParam{
+		Address: contractAddress,
+		Args:    argbytes,
+	}
+	return common.SerializeToBytes(contract), nil
+}
+
+//build param bytes for wasm contract in crossvm codec format: version + list of params
+func BuildWasmContractCrossVMParam(params []interface{}) ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(crossvm_codec.VERSION)
+	err := crossvm_codec.EncodeList(sink, params)
+	if err != nil {
+		return nil, err
+	}
+	return sink.Bytes(), nil
+}
+
+func NewWasmVMCrossVMInvokeTransaction(gasPrice, gasLimit uint64, contractAddress common.Address, params []interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := BuildWasmVMCrossVMInvokeCode(contractAddress, params)
+	if err != nil {
+		return nil, err
+	}
+	return NewWasmSmartContractTransaction(gasPrice, gasLimit, invokeCode)
+}
+
 func NewWasmVMInvokeTransaction(gasPrice, gasLimit uint64, contractAddress common.Address, params []interface{}) (*types.MutableTransaction, error) {
 	invokeCode, err := BuildWasmVMInvokeCode(contractAddress, params)
 	if err != nil {
@@ -37,7 +37,7 @@ func VerifyBlock(block *types.Block, ld *ledger.Ledger, completely bool) error {
 
 	m := len(header.Bookkeepers) - (len(header.Bookkeepers)-1)/3
 	hash := block.Hash()
-	err := signature.VerifyMultiSignature(hash[:], header.Bookkeepers, m, header.SigData)
+	err := signature.VerifyMultiSignatureBatch(hash[:], header.Bookkeepers, m, header.SigData)
 	if err != nil {
 		return err
 	}
@@ -64,11 +64,12 @@ func VerifyBlock(block *types.Block, ld *ledger.Ledger, completely bool) error {
 				return errors.New(fmt.Sprintf("Bookkeeper is not validate."))
 			}
 		*/
-		for _, txVerify := range block.Transactions {
-			if errCode := VerifyTransaction(txVerify); errCode != ontErrors.ErrNoError {
+		for _, errCode := range VerifyTransactions(block.Transactions) {
+			if errCode != ontErrors.ErrNoError {
 				return errors.New("VerifyTransaction failed when verifiy block")
 			}
-
+		}
+		for _, txVerify := range block.Transactions {
 			if errCode := VerifyTransactionWithLedger(txVerify, ld); errCode != ontErrors.ErrNoError {
 				return errors.New("VerifyTransaction failed when verifiy block")
 			}
@@ -31,6 +31,7 @@ import (
 	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/core/types"
 	ontErrors "github.com/qbyyf/ontology/errors"
+	"github.com/qbyyf/ontology/smartcontract"
 	"github.com/qbyyf/ontology/smartcontract/service/wasmvm"
 )
 
@@ -60,6 +61,11 @@ func checkTransactionSignatures(tx *types.Transaction) error {
 		return nil
 	}
 
+	if signers, ok := getVerifiedSigners(tx); ok {
+		tx.SignedAddr = signers
+		return nil
+	}
+
 	hash := tx.Hash()
 
 	lensig := len(tx.Sigs)
@@ -90,7 +96,7 @@ func checkTransactionSignatures(tx *types.Transaction) error {
 
 			address[types.AddressFromPubKey(sig.PubKeys[0])] = true
 		} else {
-			if err := signature.VerifyMultiSignature(hash[:], sig.PubKeys, m, sig.SigData); err != nil {
+			if err := signature.VerifyMultiSignatureBatch(hash[:], sig.PubKeys, m, sig.SigData); err != nil {
 				return err
 			}
 
@@ -102,8 +108,8 @@ func checkTransactionSignatures(tx *types.Transaction) error {
 		}
 	}
 
-	// check payer in address
-	if !address[tx.Payer] {
+	// check payer in address, impersonated payer needs no signature
+	if !address[tx.Payer] && !smartcontract.IsImpersonated(tx.Payer) {
 		return errors.New("signature missing for payer: " + tx.Payer.ToBase58())
 	}
 
@@ -113,6 +119,7 @@ func checkTransactionSignatures(tx *types.Transaction) error {
 	}
 
 	tx.SignedAddr = addrList
+	addVerifiedSigners(tx, addrList)
 
 	return nil
 }
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package validation
+
+import (
+	"crypto/sha256"
+	"runtime"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+	ontErrors "github.com/qbyyf/ontology/errors"
+)
+
+const VERIFIED_TX_CACHE_SIZE = 100000
+
+// verifiedTxCache records the transactions which passed signature verification, it is shared
+// by the tx pool and the block validation so a transaction is only verified once.
+// The key is the hash of the raw transaction, since tx.Hash() does not cover the signatures.
+var verifiedTxCache, _ = lru.NewARC(VERIFIED_TX_CACHE_SIZE)
+
+func rawTxHash(tx *types.Transaction) (common.Uint256, bool) {
+	if len(tx.Raw) == 0 {
+		return common.UINT256_EMPTY, false
+	}
+	return common.Uint256(sha256.Sum256(tx.Raw)), true
+}
+
+func getVerifiedSigners(tx *types.Transaction) ([]common.Address, bool) {
+	key, ok := rawTxHash(tx)
+	if !ok {
+		return nil, false
+	}
+	value, ok := verifiedTxCache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	signers := value.([]common.Address)
+	return append([]common.Address(nil), signers...), true
+}
+
+func addVerifiedSigners(tx *types.Transaction, signers []common.Address) {
+	if key, ok := rawTxHash(tx); ok {
+		verifiedTxCache.Add(key, append([]common.Address(nil), signers...))
+	}
+}
+
+// IsSignatureVerified checks whether the transaction already passed signature verification
+func IsSignatureVerified(tx *types.Transaction) bool {
+	_, ok := getVerifiedSigners(tx)
+	return ok
+}
+
+// VerifyTransactions verifys the transactions concurrently with one worker per cpu,
+// the error code at index i is the verify result of txs[i]
+func VerifyTransactions(txs []*types.Transaction) []ontErrors.ErrCode {
+	errCodes := make([]ontErrors.ErrCode, len(txs))
+	workers := runtime.NumCPU()
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+
+	tasks := make(chan int, len(txs))
+	for i := range txs {
+		tasks <- i
+	}
+	close(tasks)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				errCodes[i] = VerifyTransaction(txs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errCodes
+}
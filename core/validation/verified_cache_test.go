@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package validation
+
+import (
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/types"
+	ontErrors "github.com/qbyyf/ontology/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifiedTxCache(t *testing.T) {
+	payer := common.Address{1}
+	tx := &types.Transaction{TxType: types.InvokeNeo, Payer: payer, Raw: []byte("raw tx")}
+
+	//miss: no signature for payer
+	assert.NotNil(t, checkTransactionSignatures(tx))
+	_, ok := getVerifiedSigners(&types.Transaction{})
+	assert.False(t, ok)
+
+	//hit: the cached signers are used without verification
+	addVerifiedSigners(tx, []common.Address{payer})
+	tx.SignedAddr = nil
+	assert.Nil(t, checkTransactionSignatures(tx))
+	assert.Equal(t, []common.Address{payer}, tx.SignedAddr)
+
+	//the cached signers are never shared with transactions
+	tx.SignedAddr[0] = common.Address{2}
+	signers, ok := getVerifiedSigners(tx)
+	assert.True(t, ok)
+	assert.Equal(t, []common.Address{payer}, signers)
+
+	//a different raw transaction misses
+	_, ok = getVerifiedSigners(&types.Transaction{Raw: []byte("other raw tx")})
+	assert.False(t, ok)
+}
+
+func TestVerifiedTxCacheEviction(t *testing.T) {
+	cache := verifiedTxCache
+	defer func() { verifiedTxCache = cache }()
+	verifiedTxCache, _ = lru.NewARC(2)
+
+	var txs []*types.Transaction
+	for i := 0; i < 3; i++ {
+		tx := &types.Transaction{Raw: []byte{byte(i)}}
+		addVerifiedSigners(tx, []common.Address{{byte(i)}})
+		txs = append(txs, tx)
+	}
+	_, ok := getVerifiedSigners(txs[0])
+	assert.False(t, ok)
+	for i := 1; i < 3; i++ {
+		signers, ok := getVerifiedSigners(txs[i])
+		assert.True(t, ok)
+		assert.Equal(t, []common.Address{{byte(i)}}, signers)
+	}
+}
+
+func TestVerifyTransactions(t *testing.T) {
+	var txs []*types.Transaction
+	for i := 0; i < 4; i++ {
+		payer := common.Address{byte(i)}
+		tx := &types.Transaction{TxType: types.InvokeNeo, Payer: payer, Payload: &payload.InvokeCode{},
+			Raw: []byte{byte(i), 0xff}}
+		//the txs at odd index are not signed
+		if i%2 == 0 {
+			addVerifiedSigners(tx, []common.Address{payer})
+		}
+		txs = append(txs, tx)
+	}
+	errCodes := VerifyTransactions(txs)
+	assert.Equal(t, []ontErrors.ErrCode{ontErrors.ErrNoError, ontErrors.ErrVerifySignature,
+		ontErrors.ErrNoError, ontErrors.ErrVerifySignature}, errCodes)
+	assert.True(t, IsSignatureVerified(txs[0]))
+	assert.False(t, IsSignatureVerified(txs[1]))
+	assert.Empty(t, VerifyTransactions(nil))
+}
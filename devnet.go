@@ -0,0 +1,262 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/go-ethereum/rpc"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/cmd"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/consensus/solo"
+	"github.com/qbyyf/ontology/core/genesis"
+	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/devnet"
+	"github.com/qbyyf/ontology/events"
+	"github.com/qbyyf/ontology/http/ethrpc/evm"
+	"github.com/qbyyf/ontology/txnpool/proc"
+	"github.com/urfave/cli"
+)
+
+const (
+	DEVNET_WALLET_FILE   = "wallet.dat"
+	DEVNET_SNAPSHOT_DIR  = "snapshots"
+	DEVNET_FUND_TIMEOUT  = 60 * time.Second
+	DEVNET_EVM_NAMESPACE = "evm"
+	DEVNET_NODES_DIR     = "nodes"
+)
+
+var devnetCommand = cli.Command{
+	Name:   "devnet",
+	Usage:  "Start a deterministic local development chain with funded accounts",
+	Action: startDevnet,
+	Flags: []cli.Flag{
+		utils.DevnetDataDirFlag,
+		utils.DevnetMnemonicFlag,
+		utils.DevnetAccountNumFlag,
+		utils.DevnetOntBalanceFlag,
+		utils.DevnetOngBalanceFlag,
+		utils.DevnetBlockTimeFlag,
+		utils.DevnetPasswordFlag,
+		utils.DevnetResetFlag,
+		utils.DevnetNodesFlag,
+		utils.RPCPortFlag,
+		utils.ETHRPCPortFlag,
+		utils.RestfulPortFlag,
+		utils.WsPortFlag,
+		utils.GraphQLPortFlag,
+	},
+	Description: `Devnet starts a solo node whose bookkeeper and accounts are derived from the mnemonic, and funds
+the accounts with ONT and ONG at the first start. The accounts are written to the wallet in data dir.
+The chain state can be saved and reverted by evm_snapshot and evm_revert of the EVM rpc, and a block
+can be generated by evm_mine, and evm_revert drops the transactions in tx pool. With --nodes of 7 or
+more, the blocks are generated by a vbft network in one process, whose nodes are connected by the
+mock p2p network and have their own ledgers, and evm_revert restarts the nodes from the reverted ledger.`,
+}
+
+func startDevnet(ctx *cli.Context) error {
+	initLog(ctx)
+	setMaxOpenFiles()
+	types.CheckChainID = true
+
+	cfg := cmd.SetDevnetConfig(ctx)
+	if ctx.Bool(utils.GetFlagName(utils.DevnetResetFlag)) {
+		err := os.RemoveAll(cfg.Common.DataDir)
+		if err != nil {
+			return fmt.Errorf("remove devnet data error:%s", err)
+		}
+	}
+	err := os.MkdirAll(cfg.Common.DataDir, 0700)
+	if err != nil {
+		return fmt.Errorf("create devnet data dir error:%s", err)
+	}
+
+	mnemonic := ctx.String(utils.GetFlagName(utils.DevnetMnemonicFlag))
+	err = account.ValidateMnemonic(mnemonic)
+	if err != nil {
+		return fmt.Errorf("invalid mnemonic:%s", err)
+	}
+	seed := account.MnemonicToSeed(mnemonic, "")
+	nodes := int(ctx.Uint(utils.GetFlagName(utils.DevnetNodesFlag)))
+	if nodes == 0 {
+		return fmt.Errorf("devnet needs one node at least")
+	}
+	bookkeepers, err := devnet.DeriveBookkeepers(seed, nodes)
+	if err != nil {
+		return err
+	}
+	bookkeeper := bookkeepers[0]
+	if nodes > 1 {
+		vbftConfig, err := devnet.VbftConfig(bookkeepers)
+		if err != nil {
+			return err
+		}
+		cfg.Genesis.ConsensusType = config.CONSENSUS_TYPE_VBFT
+		cfg.Genesis.VBFT = vbftConfig
+	}
+	accounts, err := devnet.DeriveAccounts(seed, int(ctx.Uint(utils.GetFlagName(utils.DevnetAccountNumFlag))))
+	if err != nil {
+		return err
+	}
+	walletFile := filepath.Join(cfg.Common.DataDir, DEVNET_WALLET_FILE)
+	passwd := ctx.String(utils.GetFlagName(utils.DevnetPasswordFlag))
+	err = devnet.WriteWallet(walletFile, mnemonic, len(accounts), []byte(passwd))
+	if err != nil {
+		return fmt.Errorf("write devnet wallet error:%s, use --%s to start a new devnet", err, utils.DevnetResetFlag.Name)
+	}
+	initBookkeeper(bookkeeper)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	chain, err := initDevnetLedger(stateHashHeight)
+	if err != nil {
+		return err
+	}
+	txpool, err := initTxPool(ctx)
+	if err != nil {
+		return fmt.Errorf("initTxPool error:%s", err)
+	}
+	var network *devnet.Network
+	if nodes > 1 {
+		network, err = initDevnetNetwork(chain, bookkeepers, txpool)
+		if err != nil {
+			return fmt.Errorf("initDevnetNetwork error:%s", err)
+		}
+		chain.SetProducer(network)
+	} else {
+		consensusService, err := initConsensus(ctx, nil, txpool, bookkeeper)
+		if err != nil {
+			return fmt.Errorf("initConsensus error:%s", err)
+		}
+		chain.SetProducer(consensusService.(*solo.SoloService))
+	}
+	chain.SetTxPool(txpool)
+	err = initRpc(ctx)
+	if err != nil {
+		return fmt.Errorf("initRpc error:%s", err)
+	}
+	err = initETHRpc(txpool, rpc.API{Namespace: DEVNET_EVM_NAMESPACE, Service: evm.NewAPI(chain), Public: true})
+	if err != nil {
+		return fmt.Errorf("initEthRpc error:%s", err)
+	}
+	initGraphQL(ctx)
+	initRestful(ctx)
+	initWs(ctx)
+
+	if network == nil && cfg.Genesis.SOLO.GenBlockTime == 0 {
+		txpoolService := proc.NewTxPoolService(txpool)
+		go chain.AutoMine(func() int {
+			return int(txpoolService.GetTxAmount()[0])
+		}, make(chan struct{}))
+	}
+
+	ontBalance := ctx.String(utils.GetFlagName(utils.DevnetOntBalanceFlag))
+	ongBalance := ctx.String(utils.GetFlagName(utils.DevnetOngBalanceFlag))
+	ontAmount := utils.ParseOnt(ontBalance)
+	if ontAmount == 0 && ontBalance != "0" {
+		return fmt.Errorf("invalid ONT balance:%s", ontBalance)
+	}
+	ongAmount := utils.ParseOng(ongBalance)
+	if ongAmount == 0 && ongBalance != "0" {
+		return fmt.Errorf("invalid ONG balance:%s", ongBalance)
+	}
+	if ledger.DefLedger.GetCurrentBlockHeight() == 0 {
+		timeout := DEVNET_FUND_TIMEOUT + 2*time.Duration(cfg.Genesis.SOLO.GenBlockTime)*time.Second
+		err = devnet.Fund(bookkeepers, accounts, ontAmount, ongAmount, timeout)
+		if err != nil {
+			return fmt.Errorf("fund devnet accounts error:%s", err)
+		}
+	} else {
+		log.Infof("devnet has been started before, accounts are not funded again")
+	}
+	printDevnetAccounts(walletFile, passwd, bookkeeper, accounts, ontBalance, ongBalance)
+
+	waitToExit(ledger.DefLedger)
+	if network != nil {
+		network.Close()
+	}
+	return nil
+}
+
+func initDevnetNetwork(chain *devnet.Chain, bookkeepers []*account.Account, txpool *proc.TXPoolServer) (*devnet.Network, error) {
+	dataDir := filepath.Join(config.DefConfig.Common.DataDir, DEVNET_NODES_DIR)
+	network, err := devnet.NewNetwork(chain, bookkeepers, txpool.GetPID(), dataDir)
+	if err != nil {
+		return nil, err
+	}
+	err = network.Start()
+	if err != nil {
+		network.Close()
+		return nil, err
+	}
+	log.Infof("Devnet network init success")
+	return network, nil
+}
+
+func initDevnetLedger(stateHashHeight uint32) (*devnet.Chain, error) {
+	events.Init() //Init event hub
+
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return nil, fmt.Errorf("GetBookkeepers error: %s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return nil, fmt.Errorf("genesisBlock error %s", err)
+	}
+	snapshotDir := filepath.Join(config.DefConfig.Common.DataDir, DEVNET_SNAPSHOT_DIR)
+	chain, err := devnet.NewChain(dbDir, snapshotDir, stateHashHeight, bookKeepers, genesisBlock)
+	if err != nil {
+		return nil, fmt.Errorf("NewLedger error: %s", err)
+	}
+	ledger.DefLedger = chain.Ledger()
+
+	log.Infof("Ledger init success")
+	return chain, nil
+}
+
+func printDevnetAccounts(walletFile, passwd string, bookkeeper *account.Account, accounts []*devnet.Account,
+	ontBalance, ongBalance string) {
+	fmt.Printf("\nDevnet wallet: %s, password: %s\n", walletFile, passwd)
+	fmt.Printf("Bookkeeper: %s\n", bookkeeper.Address.ToBase58())
+	fmt.Printf("\nAccounts\n========\n")
+	for _, acc := range accounts {
+		wif, err := keypair.Key2WIF(acc.Ont.PrivateKey)
+		if err != nil {
+			log.Errorf("encode private key of account %d error:%s", acc.Index, err)
+			continue
+		}
+		fmt.Printf("\nAccount #%d\n", acc.Index)
+		fmt.Printf("  Ontology address: %s (%s ONT, %s ONG)\n", acc.Ont.Address.ToBase58(), ontBalance, ongBalance)
+		fmt.Printf("  Ontology private key (WIF): %s\n", wif)
+		fmt.Printf("  EVM address: %s (%s ONG)\n", acc.EthAddress.Hex(), ongBalance)
+		fmt.Printf("  EVM private key: 0x%s\n", hex.EncodeToString(crypto.FromECDSA(acc.Eth)))
+	}
+	fmt.Printf("\nWARNING: the accounts are derived from a public mnemonic, never use them on mainnet\n\n")
+}
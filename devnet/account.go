@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package devnet runs a deterministic local development chain with accounts derived
+// from a mnemonic and funded at startup. The chain is generated by a solo node, or by
+// a multi-node vbft network in one process, whose nodes are connected by the mock p2p network.
+package devnet
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/types"
+)
+
+const (
+	BOOKKEEPER_LABEL   = "bookkeeper"
+	ONT_ACCOUNT_PREFIX = "ont"
+	ETH_ACCOUNT_PREFIX = "eth"
+
+	BOOKKEEPER_DERIVE_PATH = "m/44'/%d'/0'/1/%d"
+)
+
+// Account is a pre-funded account of devnet. It has an ontology account and an EVM account
+// derived from the same mnemonic, the EVM accounts are the same as Hardhat's with the same mnemonic
+type Account struct {
+	Index      uint32
+	Ont        *account.Account  //P-256 account at m/44'/1024'/0'/0/(index+1)
+	Eth        *ecdsa.PrivateKey //secp256k1 key at m/44'/60'/0'/0/index
+	EthAddress ethcommon.Address
+}
+
+// OntAddress returns the ontology address of account
+func (self *Account) OntAddress() common.Address {
+	return self.Ont.Address
+}
+
+// EthOntAddress returns the EVM address of account in ontology address format, which holds its ONG balance
+func (self *Account) EthOntAddress() common.Address {
+	return common.Address(self.EthAddress)
+}
+
+// DeriveBookkeeper derives the bookkeeper of devnet at m/44'/1024'/0'/0/0.
+// All ONT and ONG are allocated to the bookkeeper in genesis block, and it funds the other accounts
+func DeriveBookkeeper(seed []byte) (*account.Account, error) {
+	return deriveOntAccount(seed, account.Bip44Path(account.ONT_COIN_TYPE, 0))
+}
+
+// DeriveBookkeepers derives num bookkeepers of a vbft devnet. The first one is the bookkeeper derived by
+// DeriveBookkeeper, and the others are at m/44'/1024'/0'/1/index, apart from the accounts
+func DeriveBookkeepers(seed []byte, num int) ([]*account.Account, error) {
+	bookkeepers := make([]*account.Account, 0, num)
+	for i := 0; i < num; i++ {
+		path := account.Bip44Path(account.ONT_COIN_TYPE, 0)
+		if i > 0 {
+			path = fmt.Sprintf(BOOKKEEPER_DERIVE_PATH, account.ONT_COIN_TYPE, i)
+		}
+		bookkeeper, err := deriveOntAccount(seed, path)
+		if err != nil {
+			return nil, err
+		}
+		bookkeepers = append(bookkeepers, bookkeeper)
+	}
+	return bookkeepers, nil
+}
+
+// DeriveAccounts derives num accounts of devnet from seed
+func DeriveAccounts(seed []byte, num int) ([]*Account, error) {
+	accounts := make([]*Account, 0, num)
+	for i := uint32(0); i < uint32(num); i++ {
+		ontAcc, err := deriveOntAccount(seed, account.Bip44Path(account.ONT_COIN_TYPE, i+1))
+		if err != nil {
+			return nil, err
+		}
+		key, _, err := account.DeriveKey(seed, account.Bip44Path(account.ETH_COIN_TYPE, i))
+		if err != nil {
+			return nil, fmt.Errorf("derive EVM account %d error:%s", i, err)
+		}
+		ethKey, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, fmt.Errorf("derive EVM account %d error:%s", i, err)
+		}
+		accounts = append(accounts, &Account{
+			Index:      i,
+			Ont:        ontAcc,
+			Eth:        ethKey,
+			EthAddress: crypto.PubkeyToAddress(ethKey.PublicKey),
+		})
+	}
+	return accounts, nil
+}
+
+func deriveOntAccount(seed []byte, path string) (*account.Account, error) {
+	prvkey, pubkey, err := account.DeriveKeyPair(seed, path, keypair.P256)
+	if err != nil {
+		return nil, fmt.Errorf("derive ontology account %s error:%s", path, err)
+	}
+	return &account.Account{
+		PrivateKey: prvkey,
+		PublicKey:  pubkey,
+		Address:    types.AddressFromPubKey(pubkey),
+		SigScheme:  s.SHA256withECDSA,
+	}, nil
+}
+
+// WriteWallet writes the bookkeeper and num accounts derived from mnemonic to wallet file of path,
+// the bookkeeper is the default account. If the wallet exists, it should be written by the same mnemonic before
+func WriteWallet(path, mnemonic string, num int, passwd []byte) error {
+	bookkeeper, err := DeriveBookkeeper(account.MnemonicToSeed(mnemonic, ""))
+	if err != nil {
+		return err
+	}
+	wallet, err := account.NewClientImpl(path)
+	if err != nil {
+		return fmt.Errorf("open wallet error:%s", err)
+	}
+	if wallet.GetAccountNum() > 0 {
+		if wallet.GetAccountMetadataByAddress(bookkeeper.Address.ToBase58()) == nil {
+			return fmt.Errorf("wallet %s is not created by the mnemonic", path)
+		}
+		return nil
+	}
+
+	err = wallet.ImportMnemonic(mnemonic, "", passwd)
+	if err != nil {
+		return fmt.Errorf("import mnemonic error:%s", err)
+	}
+	_, err = wallet.DeriveAccount(BOOKKEEPER_LABEL, account.ONT_COIN_TYPE, passwd)
+	if err != nil {
+		return fmt.Errorf("derive bookkeeper error:%s", err)
+	}
+	for i := 0; i < num; i++ {
+		_, err = wallet.DeriveAccount(fmt.Sprintf("%s%d", ONT_ACCOUNT_PREFIX, i), account.ONT_COIN_TYPE, passwd)
+		if err != nil {
+			return fmt.Errorf("derive ontology account %d error:%s", i, err)
+		}
+		_, err = wallet.DeriveAccount(fmt.Sprintf("%s%d", ETH_ACCOUNT_PREFIX, i), account.ETH_COIN_TYPE, passwd)
+		if err != nil {
+			return fmt.Errorf("derive EVM account %d error:%s", i, err)
+		}
+	}
+	return wallet.SetDefaultAccount(bookkeeper.Address.ToBase58())
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestDeriveAccounts(t *testing.T) {
+	seed := account.MnemonicToSeed(testMnemonic, "")
+	bookkeeper, err := DeriveBookkeeper(seed)
+	assert.Nil(t, err)
+	assert.Equal(t, "AUi2h24uTWRy8TmWC61BMMrfZhNnMEHpdb", bookkeeper.Address.ToBase58())
+
+	accounts, err := DeriveAccounts(seed, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(accounts))
+	//the same EVM accounts as Hardhat
+	assert.Equal(t, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", accounts[0].EthAddress.Hex())
+	assert.Equal(t, "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", accounts[1].EthAddress.Hex())
+	assert.Equal(t, "AQwUST2EotQmpe6xudeDQYZfZLvxgDFQVo", accounts[0].Ont.Address.ToBase58())
+	assert.Equal(t, "APMpuX7uLTMMLAXEsRqStq4RYCJrZM4ePJ", accounts[1].Ont.Address.ToBase58())
+	assert.Equal(t, common.Address(accounts[1].EthAddress), accounts[1].EthOntAddress())
+
+	again, err := DeriveAccounts(seed, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, accounts[0].OntAddress(), again[0].OntAddress())
+	assert.Equal(t, accounts[0].EthAddress, again[0].EthAddress)
+}
+
+func TestFundTxs(t *testing.T) {
+	seed := account.MnemonicToSeed(testMnemonic, "")
+	bookkeeper, err := DeriveBookkeeper(seed)
+	assert.Nil(t, err)
+	accounts, err := DeriveAccounts(seed, 3)
+	assert.Nil(t, err)
+
+	txs, err := FundTxs(bookkeeper.Address, bookkeeper.Address, accounts, 100, 1000000000)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(txs))
+	for _, tx := range txs {
+		assert.Equal(t, bookkeeper.Address, tx.Payer)
+	}
+	txs, err = FundTxs(bookkeeper.Address, bookkeeper.Address, accounts, 0, 1000000000)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(txs))
+
+	_, err = FundTxs(bookkeeper.Address, bookkeeper.Address, accounts, constants.ONT_TOTAL_SUPPLY, 0)
+	assert.NotNil(t, err)
+	_, err = FundTxs(bookkeeper.Address, bookkeeper.Address, accounts, 0, constants.ONG_TOTAL_SUPPLY/2)
+	assert.NotNil(t, err)
+}
+
+func TestGenesisHolders(t *testing.T) {
+	seed := account.MnemonicToSeed(testMnemonic, "")
+	bookkeepers, err := DeriveBookkeepers(seed, 7)
+	assert.Nil(t, err)
+	bookkeeper, err := DeriveBookkeeper(seed)
+	assert.Nil(t, err)
+	assert.Equal(t, bookkeeper.Address, bookkeepers[0].Address)
+	accounts, err := DeriveAccounts(seed, 1)
+	assert.Nil(t, err)
+	for _, bk := range bookkeepers[1:] {
+		assert.NotEqual(t, accounts[0].OntAddress(), bk.Address)
+	}
+
+	ontHolder, ongHolder, err := GenesisHolders([]keypair.PublicKey{bookkeeper.PublicKey})
+	assert.Nil(t, err)
+	assert.Equal(t, bookkeeper.Address, ontHolder)
+	assert.Equal(t, bookkeeper.Address, ongHolder)
+
+	var pubKeys []keypair.PublicKey
+	for _, bk := range bookkeepers {
+		pubKeys = append(pubKeys, bk.PublicKey)
+	}
+	ontHolder, ongHolder, err = GenesisHolders(pubKeys)
+	assert.Nil(t, err)
+	multiSig, err := types.AddressFromMultiPubKeys(pubKeys, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, multiSig, ontHolder)
+	assert.Equal(t, types.AddressFromPubKey(keypair.SortPublicKeys(pubKeys)[0]), ongHolder)
+}
+
+func TestWriteWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "devnet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	passwd := []byte("devnet")
+	err = WriteWallet(path, testMnemonic, 1, passwd)
+	assert.Nil(t, err)
+
+	wallet, err := account.Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, wallet.GetAccountNum())
+	assert.Equal(t, "AUi2h24uTWRy8TmWC61BMMrfZhNnMEHpdb", wallet.GetDefaultAccountMetadata().Address)
+	assert.NotNil(t, wallet.GetAccountMetadataByLabel(BOOKKEEPER_LABEL))
+	assert.Equal(t, "AQwUST2EotQmpe6xudeDQYZfZLvxgDFQVo", wallet.GetAccountMetadataByLabel("ont0").Address)
+	assert.Equal(t, "m/44'/60'/0'/0/0", wallet.GetAccountMetadataByLabel("eth0").DerivePath)
+
+	//wallet of the same mnemonic is kept
+	err = WriteWallet(path, testMnemonic, 1, passwd)
+	assert.Nil(t, err)
+	err = WriteWallet(path, "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", 1, passwd)
+	assert.NotNil(t, err)
+}
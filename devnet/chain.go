@@ -0,0 +1,322 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/store"
+	"github.com/qbyyf/ontology/core/store/ledgerstore"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/events"
+)
+
+const AUTO_MINE_INTERVAL = 100 * time.Millisecond
+
+// Producer generates the blocks of devnet, it is implemented by solo consensus service and vbft Network
+type Producer interface {
+	MineBlock(skipEmpty bool) (bool, error)
+	RunExclusive(fn func() error) error
+}
+
+// Rewinder is implemented by the producers which keep chain state out of the ledger of chain. Rewind is called
+// instead of RunExclusive when the chain is reverted, and the producer restarts from the reverted ledger
+type Rewinder interface {
+	Rewind(fn func() error) error
+}
+
+// TxPool holds the transactions of devnet, which are verified against the state before reverting
+type TxPool interface {
+	Flush()
+}
+
+// Chain is the ledger of devnet, its state can be saved to snapshots and reverted later
+type Chain struct {
+	dataDir         string
+	snapshotDir     string
+	stateHashHeight uint32
+	bookkeepers     []keypair.PublicKey
+	genesisBlock    *types.Block
+	store           *switchableStore
+	producer        Producer
+	txpool          TxPool
+
+	lock      sync.Mutex
+	snapshots []uint64 //ids of snapshots in the order taken
+	nextId    uint64
+}
+
+// NewChain opens the ledger in dataDir. Snapshots are saved in snapshotDir, and are discarded when the chain is opened again
+func NewChain(dataDir, snapshotDir string, stateHashHeight uint32, bookkeepers []keypair.PublicKey,
+	genesisBlock *types.Block) (*Chain, error) {
+	err := os.RemoveAll(snapshotDir)
+	if err != nil {
+		return nil, fmt.Errorf("remove snapshots error:%s", err)
+	}
+	chain := &Chain{
+		dataDir:         dataDir,
+		snapshotDir:     snapshotDir,
+		stateHashHeight: stateHashHeight,
+		bookkeepers:     bookkeepers,
+		genesisBlock:    genesisBlock,
+		nextId:          1,
+	}
+	ledgerStore, err := chain.openStore()
+	if err != nil {
+		return nil, err
+	}
+	chain.store = newSwitchableStore(ledgerStore)
+	return chain, nil
+}
+
+func (self *Chain) openStore() (store.LedgerStore, error) {
+	return self.openStoreAt(self.dataDir, nil)
+}
+
+// openStoreAt opens a ledger store of chain in dir, whose events are published by publisher
+func (self *Chain) openStoreAt(dir string, publisher *events.ActorPublisher) (store.LedgerStore, error) {
+	ledgerStore, err := ledgerstore.NewLedgerStore(dir, self.stateHashHeight)
+	if err != nil {
+		return nil, fmt.Errorf("NewLedgerStore error %s", err)
+	}
+	ledgerStore.SetEventPublisher(publisher)
+	err = ledgerStore.InitLedgerStoreWithGenesisBlock(self.genesisBlock, self.bookkeepers)
+	if err != nil {
+		ledgerStore.Close()
+		return nil, err
+	}
+	return ledgerStore, nil
+}
+
+// copyLedger copies the ledger of chain to dirs, the existing files in dirs are removed
+func (self *Chain) copyLedger(dirs []string) error {
+	return self.store.replace(func(old store.LedgerStore) (store.LedgerStore, error) {
+		err := old.Close()
+		if err != nil {
+			return nil, err
+		}
+		var copyErr error
+		for _, dir := range dirs {
+			if copyErr = os.RemoveAll(dir); copyErr != nil {
+				break
+			}
+			if copyErr = copyDir(self.dataDir, dir); copyErr != nil {
+				break
+			}
+		}
+		ledgerStore, err := self.openStore()
+		if err != nil {
+			return nil, err
+		}
+		return ledgerStore, copyErr
+	})
+}
+
+// Ledger returns the ledger of chain, which is still valid after reverting
+func (self *Chain) Ledger() *ledger.Ledger {
+	return &ledger.Ledger{LedgerStore: self.store}
+}
+
+// SetProducer sets the block producer of chain, it should be set before mining or taking snapshot
+func (self *Chain) SetProducer(producer Producer) {
+	self.producer = producer
+}
+
+// SetTxPool sets the tx pool of chain, which is flushed when the chain is reverted
+func (self *Chain) SetTxPool(txpool TxPool) {
+	self.txpool = txpool
+}
+
+// Mine generates a block immediately, even there is no transaction in tx pool
+func (self *Chain) Mine() error {
+	if self.producer == nil {
+		return fmt.Errorf("devnet has no block producer")
+	}
+	_, err := self.producer.MineBlock(false)
+	return err
+}
+
+// AutoMine generates a block as soon as there are transactions in tx pool, until quit is closed.
+// pendingTxs returns the number of verified transactions in tx pool
+func (self *Chain) AutoMine(pendingTxs func() int, quit <-chan struct{}) {
+	ticker := time.NewTicker(AUTO_MINE_INTERVAL)
+	defer ticker.Stop()
+	//pending transactions which can not be packed, such as EIP155 transactions with nonce gap,
+	//are not retried until the transactions in tx pool change
+	skipped := 0
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+		}
+		count := pendingTxs()
+		if count == 0 || count == skipped {
+			skipped = count
+			continue
+		}
+		mined, err := self.producer.MineBlock(true)
+		if err != nil {
+			log.Errorf("devnet mine block error:%s", err)
+			continue
+		}
+		if mined {
+			skipped = 0
+		} else {
+			skipped = count
+		}
+	}
+}
+
+// Snapshot saves the current state of chain, and returns the id to revert to
+func (self *Chain) Snapshot() (uint64, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	id := self.nextId
+	err := self.replaceStore(func() error {
+		return copyDir(self.dataDir, self.snapshotPath(id))
+	}, nil, false)
+	if err != nil {
+		os.RemoveAll(self.snapshotPath(id))
+		return 0, fmt.Errorf("take snapshot error:%s", err)
+	}
+	self.nextId += 1
+	self.snapshots = append(self.snapshots, id)
+	log.Infof("devnet snapshot %d is taken at block height %d", id, self.store.GetCurrentBlockHeight())
+	return id, nil
+}
+
+// Revert reverts the state of chain to the snapshot id. The snapshot and the snapshots taken after it
+// are discarded, and false is returned if the snapshot does not exist. The transactions in tx pool
+// are dropped, since they may be packed or conflict with the reverted state
+func (self *Chain) Revert(id uint64) (bool, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	index := -1
+	for i, snapshot := range self.snapshots {
+		if snapshot == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false, nil
+	}
+	err := self.replaceStore(func() error {
+		err := os.RemoveAll(self.dataDir)
+		if err != nil {
+			return err
+		}
+		return copyDir(self.snapshotPath(id), self.dataDir)
+	}, func() {
+		if self.txpool != nil {
+			self.txpool.Flush()
+		}
+	}, true)
+	if err != nil {
+		return false, fmt.Errorf("revert to snapshot %d error:%s", id, err)
+	}
+	for _, snapshot := range self.snapshots[index:] {
+		os.RemoveAll(self.snapshotPath(snapshot))
+	}
+	self.snapshots = self.snapshots[:index]
+	log.Infof("devnet is reverted to snapshot %d at block height %d", id, self.store.GetCurrentBlockHeight())
+	return true, nil
+}
+
+// replaceStore closes the ledger store, calls fn with the files of ledger, reopens the ledger store and
+// calls replaced if not nil. No block is generated and the ledger is not accessed meanwhile.
+// rewind is true if the ledger goes back to a previous state, and a Rewinder producer is rewound
+func (self *Chain) replaceStore(fn func() error, replaced func(), rewind bool) error {
+	replace := func() error {
+		err := self.store.replace(func(old store.LedgerStore) (store.LedgerStore, error) {
+			err := old.Close()
+			if err != nil {
+				return nil, err
+			}
+			fnErr := fn()
+			ledgerStore, err := self.openStore()
+			if err != nil {
+				return nil, err
+			}
+			return ledgerStore, fnErr
+		})
+		if err == nil && replaced != nil {
+			replaced()
+		}
+		return err
+	}
+	if self.producer == nil {
+		return replace()
+	}
+	if rewinder, ok := self.producer.(Rewinder); ok && rewind {
+		return rewinder.Rewind(replace)
+	}
+	return self.producer.RunExclusive(replace)
+}
+
+func (self *Chain) snapshotPath(id uint64) string {
+	return filepath.Join(self.snapshotDir, strconv.FormatUint(id, 10))
+}
+
+// copyDir copies the files in directory src to dst recursively
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/core/genesis"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// testProducer generates empty blocks to the chain directly
+type testProducer struct {
+	chain      *Chain
+	bookkeeper *account.Account
+}
+
+func (self *testProducer) MineBlock(skipEmpty bool) (bool, error) {
+	if skipEmpty {
+		return false, nil
+	}
+	ledgerStore := self.chain.store
+	prevHash := ledgerStore.GetCurrentBlockHash()
+	prevHeader, err := ledgerStore.GetHeaderByHash(prevHash)
+	if err != nil {
+		return false, err
+	}
+	nextBookkeeper, err := types.AddressFromBookkeepers([]keypair.PublicKey{self.bookkeeper.PublicKey})
+	if err != nil {
+		return false, err
+	}
+	txRoot := common.ComputeMerkleRoot(nil)
+	block := &types.Block{
+		Header: &types.Header{
+			PrevBlockHash:    prevHash,
+			TransactionsRoot: txRoot,
+			BlockRoot:        ledgerStore.GetBlockRootWithNewTxRoots(prevHeader.Height+1, []common.Uint256{txRoot}),
+			Timestamp:        prevHeader.Timestamp + 1,
+			Height:           prevHeader.Height + 1,
+			NextBookkeeper:   nextBookkeeper,
+		},
+	}
+	hash := block.Hash()
+	sig, err := signature.Sign(self.bookkeeper, hash[:])
+	if err != nil {
+		return false, err
+	}
+	block.Header.Bookkeepers = []keypair.PublicKey{self.bookkeeper.PublicKey}
+	block.Header.SigData = [][]byte{sig}
+	result, err := ledgerStore.ExecuteBlock(block)
+	if err != nil {
+		return false, err
+	}
+	return true, ledgerStore.SubmitBlock(block, nil, result)
+}
+
+func (self *testProducer) RunExclusive(fn func() error) error {
+	return fn()
+}
+
+// testTxPool counts the flushes of tx pool
+type testTxPool struct {
+	flushed int
+}
+
+func (self *testTxPool) Flush() {
+	self.flushed += 1
+}
+
+func TestChainSnapshotRevert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "devnet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	bookkeeper, err := DeriveBookkeeper(account.MnemonicToSeed(testMnemonic, ""))
+	assert.Nil(t, err)
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	config.DefConfig.Genesis.SOLO.Bookkeepers = []string{hex.EncodeToString(keypair.SerializePublicKey(bookkeeper.PublicKey))}
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	bookkeepers := []keypair.PublicKey{bookkeeper.PublicKey}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+
+	chain, err := NewChain(filepath.Join(dir, "chain"), filepath.Join(dir, "snapshots"), 0, bookkeepers, genesisBlock)
+	assert.Nil(t, err)
+	defer chain.Ledger().Close()
+	assert.NotNil(t, chain.Mine())
+	chain.SetProducer(&testProducer{chain: chain, bookkeeper: bookkeeper})
+	txpool := &testTxPool{}
+	chain.SetTxPool(txpool)
+	ldg := chain.Ledger()
+
+	assert.Nil(t, chain.Mine())
+	first, err := chain.Snapshot()
+	assert.Nil(t, err)
+	assert.Nil(t, chain.Mine())
+	second, err := chain.Snapshot()
+	assert.Nil(t, err)
+	assert.Nil(t, chain.Mine())
+	assert.Equal(t, uint32(3), ldg.GetCurrentBlockHeight())
+	hash := ldg.GetCurrentBlockHash()
+
+	ok, err := chain.Revert(second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(2), ldg.GetCurrentBlockHeight())
+	assert.Equal(t, 1, txpool.flushed)
+	exist, err := ldg.IsContainBlock(hash)
+	assert.Nil(t, err)
+	assert.False(t, exist)
+
+	//the snapshot is discarded after reverting
+	ok, err = chain.Revert(second)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 1, txpool.flushed)
+
+	third, err := chain.Snapshot()
+	assert.Nil(t, err)
+	assert.Nil(t, chain.Mine())
+	ok, err = chain.Revert(first)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), ldg.GetCurrentBlockHeight())
+	//snapshots after the reverted one are discarded too
+	ok, err = chain.Revert(third)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, chain.Mine())
+	assert.Equal(t, uint32(2), ldg.GetCurrentBlockHeight())
+}
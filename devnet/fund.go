@@ -0,0 +1,245 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	ontErrors "github.com/qbyyf/ontology/errors"
+	bactor "github.com/qbyyf/ontology/http/base/actor"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ont"
+	nutils "github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	NATIVE_CONTRACT_VERSION = byte(0)
+	TX_POLL_INTERVAL        = 100 * time.Millisecond
+)
+
+// GenesisHolders returns the holders of ONT and ONG in genesis block of devnet. ONT is allocated to the bookkeeper,
+// or the multi-signature address of the bookkeepers of a vbft devnet, and ONG to the first one of the sorted bookkeepers
+func GenesisHolders(bookkeepers []keypair.PublicKey) (ontHolder, ongHolder common.Address, err error) {
+	if len(bookkeepers) == 0 {
+		return common.ADDRESS_EMPTY, common.ADDRESS_EMPTY, fmt.Errorf("devnet has no bookkeeper")
+	}
+	sorted := keypair.SortPublicKeys(append([]keypair.PublicKey{}, bookkeepers...))
+	ongHolder = types.AddressFromPubKey(sorted[0])
+	if len(sorted) == 1 {
+		return ongHolder, ongHolder, nil
+	}
+	ontHolder, err = types.AddressFromMultiPubKeys(sorted, multiSigM(len(sorted)))
+	if err != nil {
+		return common.ADDRESS_EMPTY, common.ADDRESS_EMPTY, fmt.Errorf("build multi-signature address error:%s", err)
+	}
+	return ontHolder, ongHolder, nil
+}
+
+// multiSigM returns the number of signatures required by the ONT holder of n bookkeepers, the same as genesis block
+func multiSigM(n int) int {
+	return (5*n + 6) / 7
+}
+
+// FundTxs builds the transactions to transfer ontAmount ONT from ontHolder and ongAmount ONG from ongHolder to
+// the ontology address of each account, and ongAmount ONG to the EVM address of each account. ongAmount is in
+// the smallest unit of ONG with 9 decimals. The transactions are not signed, and have no gas limit
+func FundTxs(ontHolder, ongHolder common.Address, accounts []*Account, ontAmount, ongAmount uint64) ([]*types.MutableTransaction, error) {
+	num := uint64(len(accounts))
+	if total, overflow := common.SafeMul(ontAmount, num); overflow || total > constants.ONT_TOTAL_SUPPLY {
+		return nil, fmt.Errorf("total supply of ONT is not enough to fund %d accounts", num)
+	}
+	if total, overflow := common.SafeMul(ongAmount, 2*num); overflow || total > constants.ONG_TOTAL_SUPPLY {
+		return nil, fmt.Errorf("total supply of ONG is not enough to fund %d accounts", num)
+	}
+
+	var ontStates, ongStates []*ont.TransferState
+	for _, acc := range accounts {
+		if ontAmount > 0 {
+			ontStates = append(ontStates, &ont.TransferState{From: ontHolder, To: acc.OntAddress(), Value: ontAmount})
+		}
+		if ongAmount > 0 {
+			ongStates = append(ongStates,
+				&ont.TransferState{From: ongHolder, To: acc.OntAddress(), Value: ongAmount},
+				&ont.TransferState{From: ongHolder, To: acc.EthOntAddress(), Value: ongAmount})
+		}
+	}
+
+	var txs []*types.MutableTransaction
+	for _, transfer := range []struct {
+		contract common.Address
+		holder   common.Address
+		states   []*ont.TransferState
+	}{{nutils.OntContractAddress, ontHolder, ontStates}, {nutils.OngContractAddress, ongHolder, ongStates}} {
+		if len(transfer.states) == 0 {
+			continue
+		}
+		code, err := cutils.BuildNativeInvokeCode(transfer.contract, NATIVE_CONTRACT_VERSION, ont.TRANSFER_NAME,
+			[]interface{}{transfer.states})
+		if err != nil {
+			return nil, fmt.Errorf("build invoke code error:%s", err)
+		}
+		txs = append(txs, &types.MutableTransaction{
+			TxType:  types.InvokeNeo,
+			Nonce:   rand.Uint32(),
+			Payer:   transfer.holder,
+			Payload: &payload.InvokeCode{Code: code},
+		})
+	}
+	return txs, nil
+}
+
+// Fund transfers ONT and ONG allocated to bookkeepers in genesis block to accounts in the local ledger,
+// and waits until the transactions are packed in block
+func Fund(bookkeepers []*account.Account, accounts []*Account, ontAmount, ongAmount uint64, timeout time.Duration) error {
+	pubKeys := make([]keypair.PublicKey, 0, len(bookkeepers))
+	for _, bookkeeper := range bookkeepers {
+		pubKeys = append(pubKeys, bookkeeper.PublicKey)
+	}
+	ontHolder, ongHolder, err := GenesisHolders(pubKeys)
+	if err != nil {
+		return err
+	}
+	txs, err := FundTxs(ontHolder, ongHolder, accounts, ontAmount, ongAmount)
+	if err != nil {
+		return err
+	}
+	hashes := make([]common.Uint256, 0, len(txs))
+	for _, mutable := range txs {
+		signers := bookkeepers
+		if mutable.Payer == ongHolder {
+			signers = []*account.Account{bookkeeperOf(bookkeepers, ongHolder)}
+		}
+		tx, err := signTx(signers, mutable)
+		if err != nil {
+			return err
+		}
+		errCode, desc := bactor.AppendTxToPool(tx)
+		if errCode != ontErrors.ErrNoError {
+			return fmt.Errorf("send fund transaction error:%s %s", errCode, desc)
+		}
+		hashes = append(hashes, tx.Hash())
+	}
+	for _, hash := range hashes {
+		err = WaitTx(hash, timeout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bookkeeperOf returns the bookkeeper of address
+func bookkeeperOf(bookkeepers []*account.Account, address common.Address) *account.Account {
+	for _, bookkeeper := range bookkeepers {
+		if bookkeeper.Address == address {
+			return bookkeeper
+		}
+	}
+	return nil
+}
+
+// signTx sets the gas limit of transaction by pre-execution, and signs it by signers. Multiple signers
+// sign it as the multi-signature address of their public keys, like the ONT holder in genesis block
+func signTx(signers []*account.Account, mutable *types.MutableTransaction) (*types.Transaction, error) {
+	mutable.GasPrice = config.DefConfig.Common.GasPrice
+	mutable.GasLimit = config.DefConfig.Common.MinGasLimit
+	tx, err := sign(signers, mutable)
+	if err != nil {
+		return nil, err
+	}
+	result, err := ledger.DefLedger.PreExecuteContract(tx)
+	if err != nil {
+		return nil, fmt.Errorf("pre-execute transaction error:%s", err)
+	}
+	if result.State == event.CONTRACT_STATE_FAIL {
+		return nil, fmt.Errorf("pre-execute transaction failed")
+	}
+	if result.Gas <= mutable.GasLimit {
+		return tx, nil
+	}
+	mutable.GasLimit = result.Gas
+	return sign(signers, mutable)
+}
+
+func sign(signers []*account.Account, mutable *types.MutableTransaction) (*types.Transaction, error) {
+	if len(signers) == 0 || signers[0] == nil {
+		return nil, fmt.Errorf("transaction has no signer")
+	}
+	pubKeys := make([]keypair.PublicKey, 0, len(signers))
+	for _, signer := range signers {
+		pubKeys = append(pubKeys, signer.PublicKey)
+	}
+	pubKeys = keypair.SortPublicKeys(pubKeys)
+	m := 1
+	if len(signers) > 1 {
+		m = multiSigM(len(signers))
+	}
+	hash := mutable.Hash()
+	sigData := make([][]byte, 0, m)
+	//signatures of multi-signature are in the order of sorted public keys
+	for _, pubKey := range pubKeys[:m] {
+		signer := signerOf(signers, pubKey)
+		sig, err := signature.Sign(signer, hash[:])
+		if err != nil {
+			return nil, fmt.Errorf("sign transaction error:%s", err)
+		}
+		sigData = append(sigData, sig)
+	}
+	mutable.Sigs = []types.Sig{{PubKeys: pubKeys, M: uint16(m), SigData: sigData}}
+	return mutable.IntoImmutable()
+}
+
+func signerOf(signers []*account.Account, pubKey keypair.PublicKey) *account.Account {
+	for _, signer := range signers {
+		if types.AddressFromPubKey(signer.PublicKey) == types.AddressFromPubKey(pubKey) {
+			return signer
+		}
+	}
+	return nil
+}
+
+// WaitTx waits until the transaction is packed in block, and returns error if it fails or timeouts
+func WaitTx(hash common.Uint256, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		notify, err := ledger.DefLedger.GetEventNotifyByTx(hash)
+		if err == nil && notify != nil {
+			if notify.State == event.CONTRACT_STATE_FAIL {
+				return fmt.Errorf("transaction %s failed", hash.ToHexString())
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("wait transaction %s timeout", hash.ToHexString())
+		}
+		time.Sleep(TX_POLL_INTERVAL)
+	}
+}
@@ -0,0 +1,354 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology-eventbus/eventhub"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/consensus/vbft"
+	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/store"
+	"github.com/qbyyf/ontology/events"
+	p2pcommon "github.com/qbyyf/ontology/p2pserver/common"
+	msgTypes "github.com/qbyyf/ontology/p2pserver/message/types"
+	"github.com/qbyyf/ontology/p2pserver/mock"
+	"github.com/qbyyf/ontology/p2pserver/net/netserver"
+	p2p "github.com/qbyyf/ontology/p2pserver/net/protocol"
+	"github.com/qbyyf/ontology/p2pserver/peer"
+)
+
+const (
+	MIN_VBFT_NODES       = 7
+	VBFT_MSG_DELAY       = 5000 //ms, the minimum of governance, empty blocks are generated every 3 delays
+	VBFT_INIT_POS        = 10000
+	NODE_DIR_PREFIX      = "node"
+	NODE_LEDGER_DIR      = "ledger"
+	NODE_CONSENSUS_DIR   = "consensus"
+	NODE_SOFT_VERSION    = "1.10"
+	NODE_CONNECT_TIMEOUT = 30 * time.Second
+	MINE_BLOCK_TIMEOUT   = 60 * time.Second
+	NODE_POLL_INTERVAL   = 100 * time.Millisecond
+)
+
+// VbftConfig returns the vbft config of a devnet whose consensus nodes are bookkeepers, in the order of their indexes
+func VbftConfig(bookkeepers []*account.Account) (*config.VBFTConfig, error) {
+	if len(bookkeepers) < MIN_VBFT_NODES {
+		return nil, fmt.Errorf("vbft devnet needs %d nodes at least", MIN_VBFT_NODES)
+	}
+	k := uint32(len(bookkeepers))
+	cfg := &config.VBFTConfig{
+		N:                    k,
+		C:                    (k - 1) / 3,
+		K:                    k,
+		L:                    16 * k,
+		BlockMsgDelay:        VBFT_MSG_DELAY,
+		HashMsgDelay:         VBFT_MSG_DELAY,
+		PeerHandshakeTimeout: 10,
+		MaxBlockChangeView:   120000,
+		MinInitStake:         VBFT_INIT_POS,
+		AdminOntID:           "did:ont:" + bookkeepers[0].Address.ToBase58(),
+		VrfValue:             config.PolarisConfig.VBFT.VrfValue,
+		VrfProof:             config.PolarisConfig.VBFT.VrfProof,
+	}
+	for i, bookkeeper := range bookkeepers {
+		cfg.Peers = append(cfg.Peers, &config.VBFTPeerStakeInfo{
+			Index:      uint32(i + 1),
+			PeerPubkey: hex.EncodeToString(keypair.SerializePublicKey(bookkeeper.PublicKey)),
+			Address:    bookkeeper.Address.ToBase58(),
+			InitPos:    VBFT_INIT_POS,
+		})
+	}
+	return cfg, nil
+}
+
+// consensusProtocol forwards the consensus messages received by a node to its vbft server
+type consensusProtocol struct {
+	lock sync.RWMutex
+	pid  *actor.PID
+}
+
+func (self *consensusProtocol) setPID(pid *actor.PID) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.pid = pid
+}
+
+func (self *consensusProtocol) HandlePeerMessage(ctx *p2p.Context, msg msgTypes.Message) {
+	consensus, ok := msg.(*msgTypes.Consensus)
+	if !ok {
+		return
+	}
+	self.lock.RLock()
+	pid := self.pid
+	self.lock.RUnlock()
+	if pid == nil {
+		return
+	}
+	if err := consensus.Cons.Verify(); err != nil {
+		log.Warn(err)
+		return
+	}
+	consensus.Cons.PeerId = ctx.Sender().GetID()
+	pid.Tell(&consensus.Cons)
+}
+
+func (self *consensusProtocol) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage) {}
+
+// node is a vbft node of devnet. The first node runs on the ledger of chain and the process wide event hub,
+// and the others run on their own ledgers and event hubs
+type node struct {
+	dir      string
+	store    store.LedgerStore //nil for the first node
+	ledger   *ledger.Ledger
+	hub      *eventhub.EventHub
+	net      *netserver.NetServer
+	protocol *consensusProtocol
+	server   *vbft.Server
+}
+
+func (self *node) consensusDir() string {
+	return filepath.Join(self.dir, NODE_CONSENSUS_DIR)
+}
+
+func (self *node) ledgerDir() string {
+	return filepath.Join(self.dir, NODE_LEDGER_DIR)
+}
+
+// Network is a vbft network of devnet in one process, whose nodes are connected by the mock p2p network.
+// The nodes share the tx pool of devnet, and the blocks are saved to the ledger of chain by the first node
+type Network struct {
+	chain       *Chain
+	bookkeepers []*account.Account
+	txpool      *actor.PID
+
+	lock       sync.Mutex
+	nodes      []*node
+	generation int
+}
+
+// NewNetwork creates a network of a node for each bookkeeper, the files of nodes are saved in dataDir.
+// The ledgers of nodes are copied from chain, the first bookkeeper runs on the ledger of chain
+func NewNetwork(chain *Chain, bookkeepers []*account.Account, txpool *actor.PID, dataDir string) (*Network, error) {
+	//the peer ids of nodes are generated in process, no remote peer is connected
+	p2pcommon.Difficulty = 1
+	network := &Network{
+		chain:       chain,
+		bookkeepers: bookkeepers,
+		txpool:      txpool,
+	}
+	nw := mock.NewNetwork()
+	for i := range bookkeepers {
+		keyId := p2pcommon.RandPeerKeyId()
+		info := peer.NewPeerInfo(keyId.Id, 0, 0, true, 0, 0, 0, NODE_SOFT_VERSION, "")
+		logger := p2pcommon.LoggerWithContext(p2pcommon.NewGlobalLoggerWrapper(), fmt.Sprintf("devnet node %d: ", i))
+		protocol := &consensusProtocol{}
+		n := &node{
+			dir:      filepath.Join(dataDir, fmt.Sprintf("%s%d", NODE_DIR_PREFIX, i)),
+			protocol: protocol,
+			net:      mock.NewNode(keyId, "", info, protocol, nw, nil, p2p.AllAddrFilter(), logger),
+		}
+		for _, other := range network.nodes {
+			nw.AllowConnect(other.net.GetID(), n.net.GetID())
+		}
+		network.nodes = append(network.nodes, n)
+	}
+	network.nodes[0].ledger = chain.Ledger()
+	network.nodes[0].hub = events.DefEvtHub
+	err := network.openLedgers()
+	if err != nil {
+		network.closeLedgers()
+		return nil, err
+	}
+	return network, nil
+}
+
+// openLedgers copies the ledger of chain to the nodes, and opens their ledgers
+func (self *Network) openLedgers() error {
+	var dirs []string
+	for _, n := range self.nodes[1:] {
+		dirs = append(dirs, n.ledgerDir())
+	}
+	err := self.chain.copyLedger(dirs)
+	if err != nil {
+		return fmt.Errorf("copy ledger to nodes error:%s", err)
+	}
+	for i, n := range self.nodes[1:] {
+		n.hub = events.NewEventHub()
+		n.store, err = self.chain.openStoreAt(n.ledgerDir(), events.NewActorPublisher(nil, n.hub))
+		if err != nil {
+			return fmt.Errorf("open ledger of node %d error:%s", i+1, err)
+		}
+		n.ledger = &ledger.Ledger{LedgerStore: n.store}
+	}
+	return nil
+}
+
+func (self *Network) closeLedgers() {
+	for i, n := range self.nodes[1:] {
+		if n.store == nil {
+			continue
+		}
+		if err := n.store.Close(); err != nil {
+			log.Errorf("close ledger of node %d error:%s", i+1, err)
+		}
+		n.store = nil
+	}
+}
+
+// Start connects the nodes with each other and starts their vbft servers
+func (self *Network) Start() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, n := range self.nodes {
+		err := n.net.Start()
+		if err != nil {
+			return fmt.Errorf("start p2p of node error:%s", err)
+		}
+	}
+	for j, n := range self.nodes {
+		for _, other := range self.nodes[:j] {
+			n.net.Connect(other.net.GetHostInfo().Addr)
+		}
+	}
+	deadline := time.Now().Add(NODE_CONNECT_TIMEOUT)
+	for i := 0; i < len(self.nodes); {
+		if self.nodes[i].net.GetConnectionCnt() >= uint32(len(self.nodes)-1) {
+			i++
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("connect node %d timeout", i)
+		}
+		time.Sleep(NODE_POLL_INTERVAL)
+	}
+	return self.startServers()
+}
+
+func (self *Network) startServers() error {
+	self.generation += 1
+	for i, n := range self.nodes {
+		server, err := vbft.NewVbftServerWithEnv(self.bookkeepers[i], self.txpool, n.net, &vbft.ServerEnv{
+			Ledger:  n.ledger,
+			Name:    fmt.Sprintf("devnet_vbft_%d_%d", i, self.generation),
+			DataDir: n.consensusDir(),
+			EvtHub:  n.hub,
+		})
+		if err != nil {
+			return fmt.Errorf("create vbft server of node %d error:%s", i, err)
+		}
+		n.server = server
+		n.protocol.setPID(server.GetPID())
+		err = server.Start()
+		if err != nil {
+			return fmt.Errorf("start vbft server of node %d error:%s", i, err)
+		}
+	}
+	log.Infof("devnet vbft network of %d nodes is started", len(self.nodes))
+	return nil
+}
+
+func (self *Network) stopServers() {
+	for i, n := range self.nodes {
+		if n.server == nil {
+			continue
+		}
+		n.protocol.setPID(nil)
+		if err := n.server.Stop(); err != nil {
+			log.Errorf("stop vbft server of node %d error:%s", i, err)
+		}
+		n.server = nil
+	}
+}
+
+// MineBlock waits until the next block is generated by the network, since the vbft nodes generate blocks
+// by themselves. It always returns true
+func (self *Network) MineBlock(skipEmpty bool) (bool, error) {
+	ledger := self.chain.Ledger()
+	height := ledger.GetCurrentBlockHeight()
+	deadline := time.Now().Add(MINE_BLOCK_TIMEOUT)
+	for ledger.GetCurrentBlockHeight() <= height {
+		if time.Now().After(deadline) {
+			return false, fmt.Errorf("wait block %d timeout", height+1)
+		}
+		time.Sleep(NODE_POLL_INTERVAL)
+	}
+	return true, nil
+}
+
+// RunExclusive stops the vbft servers, runs fn and restarts the servers, no block is generated until fn returns
+func (self *Network) RunExclusive(fn func() error) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.stopServers()
+	err := fn()
+	if startErr := self.startServers(); err == nil {
+		err = startErr
+	}
+	return err
+}
+
+// Rewind stops the vbft servers and runs fn, which reverts the ledger of chain. The reverted ledger is copied
+// to the other nodes, and the servers restart from it without the consensus files of the discarded blocks
+func (self *Network) Rewind(fn func() error) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.stopServers()
+	err := fn()
+	if err == nil {
+		self.closeLedgers()
+		err = self.openLedgers()
+	}
+	if err == nil {
+		for _, n := range self.nodes {
+			if err = os.RemoveAll(n.consensusDir()); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return self.startServers()
+}
+
+// Close stops the nodes and closes their ledgers, except the ledger of chain
+func (self *Network) Close() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.stopServers()
+	for _, n := range self.nodes {
+		n.net.Stop()
+	}
+	self.closeLedgers()
+}
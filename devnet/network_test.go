@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/core/genesis"
+	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/events"
+	bactor "github.com/qbyyf/ontology/http/base/actor"
+	"github.com/qbyyf/ontology/txnpool"
+	"github.com/qbyyf/ontology/txnpool/proc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVbftNetwork(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip vbft network in short mode")
+	}
+	dir, err := ioutil.TempDir("", "devnet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	seed := account.MnemonicToSeed(testMnemonic, "")
+	bookkeepers, err := DeriveBookkeepers(seed, MIN_VBFT_NODES)
+	assert.Nil(t, err)
+	_, err = VbftConfig(bookkeepers[:MIN_VBFT_NODES-1])
+	assert.NotNil(t, err)
+	vbftConfig, err := VbftConfig(bookkeepers)
+	assert.Nil(t, err)
+	genesisConfig := config.DefConfig.Genesis
+	config.DefConfig.Genesis = &config.GenesisConfig{ConsensusType: config.CONSENSUS_TYPE_VBFT, VBFT: vbftConfig}
+	defer func() {
+		config.DefConfig.Genesis = genesisConfig
+	}()
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	config.DefConfig.Common.GasPrice = 0
+	pubKeys, err := config.DefConfig.GetBookkeepers()
+	assert.Nil(t, err)
+	genesisBlock, err := genesis.BuildGenesisBlock(pubKeys, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+
+	events.Init()
+	chain, err := NewChain(filepath.Join(dir, "chain"), filepath.Join(dir, "snapshots"), 0, pubKeys, genesisBlock)
+	assert.Nil(t, err)
+	defer chain.Ledger().Close()
+	ledger.DefLedger = chain.Ledger()
+	txpool, err := txnpool.StartTxnPoolServer(false, true)
+	assert.Nil(t, err)
+	bactor.SetTxnPoolPid(txpool.GetPID())
+	bactor.SetTxPoolService(proc.NewTxPoolService(txpool))
+	chain.SetTxPool(txpool)
+
+	network, err := NewNetwork(chain, bookkeepers, txpool.GetPID(), filepath.Join(dir, "nodes"))
+	assert.Nil(t, err)
+	assert.Nil(t, network.Start())
+	defer network.Close()
+	chain.SetProducer(network)
+
+	//ONT is held by the multi-signature address of bookkeepers, and funded with their signatures
+	accounts, err := DeriveAccounts(seed, 1)
+	assert.Nil(t, err)
+	err = Fund(bookkeepers, accounts, 100, 1000, time.Minute)
+	assert.Nil(t, err)
+	ldg := chain.Ledger()
+	height := ldg.GetCurrentBlockHeight()
+	assert.True(t, height > 0)
+
+	id, err := chain.Snapshot()
+	assert.Nil(t, err)
+	assert.Nil(t, chain.Mine())
+	assert.True(t, ldg.GetCurrentBlockHeight() > height)
+	ok, err := chain.Revert(id)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, height, ldg.GetCurrentBlockHeight())
+	//the nodes restart from the reverted ledger
+	assert.Nil(t, chain.Mine())
+	assert.True(t, ldg.GetCurrentBlockHeight() > height)
+}
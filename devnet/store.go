@@ -0,0 +1,304 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package devnet
+
+import (
+	"sync"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	common2 "github.com/qbyyf/go-ethereum/common"
+	types2 "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/states"
+	"github.com/qbyyf/ontology/core/store"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	types3 "github.com/qbyyf/ontology/smartcontract/service/evm/types"
+	cstates "github.com/qbyyf/ontology/smartcontract/states"
+	"github.com/qbyyf/ontology/smartcontract/storage"
+)
+
+// switchableStore is a ledger store whose underlying store can be replaced while it is in use.
+// Calls are blocked during replacing, so the global ledger can be reverted without being replaced
+type switchableStore struct {
+	lock  sync.RWMutex
+	store store.LedgerStore
+}
+
+func newSwitchableStore(ledgerStore store.LedgerStore) *switchableStore {
+	return &switchableStore{store: ledgerStore}
+}
+
+// replace calls fn with the underlying store exclusively, and uses the returned store instead if it is not nil
+func (self *switchableStore) replace(fn func(old store.LedgerStore) (store.LedgerStore, error)) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	ledgerStore, err := fn(self.store)
+	if ledgerStore != nil {
+		self.store = ledgerStore
+	}
+	return err
+}
+
+func (self *switchableStore) InitLedgerStoreWithGenesisBlock(genesisblock *types.Block, defaultBookkeeper []keypair.PublicKey) error {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.InitLedgerStoreWithGenesisBlock(genesisblock, defaultBookkeeper)
+}
+
+func (self *switchableStore) Close() error {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.Close()
+}
+
+func (self *switchableStore) AddHeaders(headers []*types.Header) error {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.AddHeaders(headers)
+}
+
+func (self *switchableStore) AddBlock(block *types.Block, ccMsg *types.CrossChainMsg, stateMerkleRoot common.Uint256) error {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.AddBlock(block, ccMsg, stateMerkleRoot)
+}
+
+func (self *switchableStore) ExecuteBlock(b *types.Block) (store.ExecuteResult, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.ExecuteBlock(b)
+}
+
+func (self *switchableStore) SubmitBlock(b *types.Block, crossChainMsg *types.CrossChainMsg, exec store.ExecuteResult) error {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.SubmitBlock(b, crossChainMsg, exec)
+}
+
+func (self *switchableStore) GetStateMerkleRoot(height uint32) (common.Uint256, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetStateMerkleRoot(height)
+}
+
+func (self *switchableStore) GetCurrentBlockHash() common.Uint256 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCurrentBlockHash()
+}
+
+func (self *switchableStore) GetCurrentBlockHeight() uint32 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCurrentBlockHeight()
+}
+
+func (self *switchableStore) GetCurrentHeaderHeight() uint32 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCurrentHeaderHeight()
+}
+
+func (self *switchableStore) GetCurrentHeaderHash() common.Uint256 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCurrentHeaderHash()
+}
+
+func (self *switchableStore) GetBlockHash(height uint32) common.Uint256 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetBlockHash(height)
+}
+
+func (self *switchableStore) GetHeaderByHash(blockHash common.Uint256) (*types.Header, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetHeaderByHash(blockHash)
+}
+
+func (self *switchableStore) GetRawHeaderByHash(blockHash common.Uint256) (*types.RawHeader, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetRawHeaderByHash(blockHash)
+}
+
+func (self *switchableStore) GetHeaderByHeight(height uint32) (*types.Header, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetHeaderByHeight(height)
+}
+
+func (self *switchableStore) GetBlockByHash(blockHash common.Uint256) (*types.Block, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetBlockByHash(blockHash)
+}
+
+func (self *switchableStore) GetBlockByHeight(height uint32) (*types.Block, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetBlockByHeight(height)
+}
+
+func (self *switchableStore) GetTransaction(txHash common.Uint256) (*types.Transaction, uint32, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetTransaction(txHash)
+}
+
+func (self *switchableStore) IsContainBlock(blockHash common.Uint256) (bool, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.IsContainBlock(blockHash)
+}
+
+func (self *switchableStore) IsContainTransaction(txHash common.Uint256) (bool, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.IsContainTransaction(txHash)
+}
+
+func (self *switchableStore) GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetBlockRootWithNewTxRoots(startHeight, txRoots)
+}
+
+func (self *switchableStore) GetMerkleProof(m, n uint32) ([]common.Uint256, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetMerkleProof(m, n)
+}
+
+func (self *switchableStore) GetContractState(contractHash common.Address) (*payload.DeployCode, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetContractState(contractHash)
+}
+
+func (self *switchableStore) GetBookkeeperState() (*states.BookkeeperState, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetBookkeeperState()
+}
+
+func (self *switchableStore) GetStorageItem(codeHash common.Address, key []byte) ([]byte, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetStorageItem(codeHash, key)
+}
+
+func (self *switchableStore) GetStorageState(codeHash common.Address, key []byte) (*states.StorageItem, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetStorageState(codeHash, key)
+}
+
+func (self *switchableStore) PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.PreExecuteContract(tx)
+}
+
+func (self *switchableStore) PreExecuteContractBatch(txes []*types.Transaction, atomic bool) ([]*cstates.PreExecResult, uint32, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.PreExecuteContractBatch(txes, atomic)
+}
+
+func (self *switchableStore) PreExecuteEip155Tx(msg types2.Message) (*types3.ExecutionResult, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.PreExecuteEip155Tx(msg)
+}
+
+func (self *switchableStore) SimulateBundle(txes []*types.Transaction) (*store.BundleResult, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.SimulateBundle(txes)
+}
+
+func (self *switchableStore) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetEventNotifyByTx(tx)
+}
+
+func (self *switchableStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetEventNotifyByBlock(height)
+}
+
+func (self *switchableStore) GetDidEventHeights(did string, from uint32, limit int) ([]uint32, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetDidEventHeights(did, from, limit)
+}
+
+func (self *switchableStore) GetEthCode(hash common2.Hash) ([]byte, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetEthCode(hash)
+}
+
+func (self *switchableStore) GetEthState(address common2.Address, key common2.Hash) ([]byte, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetEthState(address, key)
+}
+
+func (self *switchableStore) GetEthAccount(address common2.Address) (*storage.EthAccount, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetEthAccount(address)
+}
+
+func (self *switchableStore) GetCrossStatesRoot(height uint32) (common.Uint256, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCrossStatesRoot(height)
+}
+
+func (self *switchableStore) GetCrossChainMsg(height uint32) (*types.CrossChainMsg, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCrossChainMsg(height)
+}
+
+func (self *switchableStore) GetCrossStatesProof(height uint32, key []byte) ([]byte, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCrossStatesProof(height, key)
+}
+
+func (self *switchableStore) EnableBlockPrune(numBeforeCurr uint32) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	self.store.EnableBlockPrune(numBeforeCurr)
+}
+
+func (self *switchableStore) GetCacheDB() *storage.CacheDB {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.store.GetCacheDB()
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/ontio/ontology-eventbus/actor"
 	"github.com/ontio/ontology-eventbus/eventhub"
+	cmap "github.com/orcaman/concurrent-map"
 )
 
 var DefEvtHub *eventhub.EventHub
@@ -41,6 +42,12 @@ func Init() {
 	DefActorPublisher = NewActorPublisher(DefPublisherPID)
 }
 
+// NewEventHub returns an event hub apart from DefEvtHub, to isolate the events of the nodes in one process.
+// Only PublishPolicyAll is supported by it
+func NewEventHub() *eventhub.EventHub {
+	return &eventhub.EventHub{Subscribers: cmap.New()}
+}
+
 func NewActorPublisher(publisher *actor.PID, evtHub ...*eventhub.EventHub) *ActorPublisher {
 	var hub *eventhub.EventHub
 	if len(evtHub) == 0 {
@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/fork"
+	"github.com/urfave/cli"
+)
+
+// initForkLedger opens the ledger of test mode on top of the states of remote node
+func initForkLedger(ctx *cli.Context, dbDir string, stateHashHeight uint32, bookKeepers []keypair.PublicKey,
+	genesisBlock *types.Block) (*ledger.Ledger, error) {
+	rpcAddr := ctx.String(utils.GetFlagName(utils.ForkRpcFlag))
+	ethRpcAddr := ctx.String(utils.GetFlagName(utils.ForkEthRpcFlag))
+	remote := fork.NewRpcRemote(rpcAddr, ethRpcAddr)
+	if ethRpcAddr != "" {
+		//keep the chain id of remote chain, so EVM transactions are signed the same as remote chain
+		chainId, err := remote.GetEthChainId()
+		if err != nil {
+			return nil, fmt.Errorf("get chain id of %s error: %s", ethRpcAddr, err)
+		}
+		config.DefConfig.P2PNode.EVMChainId = uint32(chainId)
+	}
+	if impersonate := ctx.String(utils.GetFlagName(utils.ForkImpersonateFlag)); impersonate != "" {
+		addresses, err := fork.Impersonate(strings.Split(impersonate, ","))
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addresses {
+			log.Infof("Impersonate account: %s", addr.ToBase58())
+		}
+	}
+	forkHeight := uint32(ctx.Uint(utils.GetFlagName(utils.ForkBlockFlag)))
+	ldg, err := fork.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock, remote, forkHeight)
+	if err != nil {
+		return nil, fmt.Errorf("init fork ledger error: %s", err)
+	}
+	log.Infof("Fork from %s", rpcAddr)
+	return ldg, nil
+}
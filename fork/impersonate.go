@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package fork
+
+import (
+	"fmt"
+	"strings"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/smartcontract"
+)
+
+// Impersonate makes the addresses witnessed by every transaction of local chain, so the states of them can be
+// changed without their signatures. The address is in base58, hex of ontology, or EVM hex with 0x prefix.
+// Since the sender of EVM transaction is recovered from its signature, EVM transactions can not be sent
+// from impersonated addresses
+func Impersonate(addresses []string) ([]common.Address, error) {
+	result := make([]common.Address, 0, len(addresses))
+	for _, address := range addresses {
+		addr, err := parseAddress(strings.TrimSpace(address))
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s error:%s", address, err)
+		}
+		result = append(result, addr)
+	}
+	for _, addr := range result {
+		if err := smartcontract.Impersonate(addr); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func parseAddress(address string) (common.Address, error) {
+	if strings.HasPrefix(address, "0x") {
+		if !ethcommon.IsHexAddress(address) {
+			return common.ADDRESS_EMPTY, fmt.Errorf("invalid EVM address")
+		}
+		return common.Address(ethcommon.HexToAddress(address)), nil
+	}
+	addr, err := common.AddressFromBase58(address)
+	if err == nil {
+		return addr, nil
+	}
+	return common.AddressFromHexString(address)
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package fork runs a local chain on top of the states of a remote chain. The states are fetched
+// from remote chain lazily when they are read at the first time.
+package fork
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/log"
+	"github.com/qbyyf/ontology/core/ledger"
+	scom "github.com/qbyyf/ontology/core/store/common"
+	"github.com/qbyyf/ontology/core/store/ledgerstore"
+	"github.com/qbyyf/ontology/core/types"
+)
+
+const (
+	ORIGIN_FILE = "fork_origin"
+)
+
+// origin is the remote chain and the block which the data dir is forked from
+type origin struct {
+	GenesisHash string `json:"genesis_hash"`
+	Height      uint32 `json:"height"`
+	Hash        string `json:"hash"`
+}
+
+// InitLedger opens the ledger of forked chain in dataDir, and initializes it with genesis block.
+// The states are fetched at the block of forkHeight of remote chain, the current block of remote chain
+// is used if forkHeight is 0. The fork block is pinned when the data dir is forked at the first time.
+// The native contracts which have been initialized in remote chain are not initialized again by genesis block
+func InitLedger(dataDir string, stateHashHeight uint32, defaultBookkeeper []keypair.PublicKey,
+	genesisBlock *types.Block, remote Remote, forkHeight uint32) (*ledger.Ledger, error) {
+	block, err := pinBlock(dataDir, remote, forkHeight)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Fork at block %d %s", block.Height, block.Hash.ToHexString())
+	ldgStore, err := ledgerstore.NewLedgerStoreWithStateDB(dataDir, stateHashHeight,
+		func(store scom.PersistStore) scom.PersistStore {
+			return NewStore(store, remote, block)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("NewLedgerStore error %s", err)
+	}
+	err = ldgStore.InitLedgerStoreWithGenesisBlock(genesisBlock, defaultBookkeeper)
+	if err != nil {
+		ldgStore.Close()
+		return nil, err
+	}
+	return &ledger.Ledger{LedgerStore: ldgStore}, nil
+}
+
+// pinBlock returns the fork block of data dir. It checks the data dir is forked from the same remote chain
+// and block, since the cached states of different chains or blocks can not be mixed
+func pinBlock(dataDir string, remote Remote, forkHeight uint32) (ForkBlock, error) {
+	genesisHash, err := remote.GetBlockHash(0)
+	if err != nil {
+		return ForkBlock{}, fmt.Errorf("get genesis block hash of remote chain error:%s", err)
+	}
+	originFile := filepath.Join(dataDir, ORIGIN_FILE)
+	if common.FileExisted(originFile) {
+		data, err := ioutil.ReadFile(originFile)
+		if err != nil {
+			return ForkBlock{}, fmt.Errorf("read %s error:%s", originFile, err)
+		}
+		org := &origin{}
+		err = json.Unmarshal(data, org)
+		if err != nil {
+			return ForkBlock{}, fmt.Errorf("json.Unmarshal %s error:%s", originFile, err)
+		}
+		if org.GenesisHash != genesisHash.ToHexString() {
+			return ForkBlock{}, fmt.Errorf("%s is forked from the chain of genesis block %s, but genesis block of remote chain is %s",
+				dataDir, org.GenesisHash, genesisHash.ToHexString())
+		}
+		if forkHeight != 0 && forkHeight != org.Height {
+			return ForkBlock{}, fmt.Errorf("%s is forked at block %d, can not be forked at block %d",
+				dataDir, org.Height, forkHeight)
+		}
+		hash, err := remote.GetBlockHash(org.Height)
+		if err != nil {
+			return ForkBlock{}, fmt.Errorf("get hash of block %d of remote chain error:%s", org.Height, err)
+		}
+		if org.Hash != hash.ToHexString() {
+			return ForkBlock{}, fmt.Errorf("%s is forked at block %d %s, but the block of remote chain is %s",
+				dataDir, org.Height, org.Hash, hash.ToHexString())
+		}
+		return ForkBlock{Height: org.Height, Hash: hash}, nil
+	}
+	if forkHeight == 0 {
+		forkHeight, err = remote.GetCurrentBlockHeight()
+		if err != nil {
+			return ForkBlock{}, fmt.Errorf("get current block height of remote chain error:%s", err)
+		}
+	}
+	hash, err := remote.GetBlockHash(forkHeight)
+	if err != nil {
+		return ForkBlock{}, fmt.Errorf("get hash of block %d of remote chain error:%s", forkHeight, err)
+	}
+	if hash == common.UINT256_EMPTY {
+		return ForkBlock{}, fmt.Errorf("block %d is not exist in remote chain", forkHeight)
+	}
+	err = os.MkdirAll(dataDir, 0755)
+	if err != nil {
+		return ForkBlock{}, fmt.Errorf("create dir %s error:%s", dataDir, err)
+	}
+	data, err := json.Marshal(&origin{GenesisHash: genesisHash.ToHexString(), Height: forkHeight, Hash: hash.ToHexString()})
+	if err != nil {
+		return ForkBlock{}, fmt.Errorf("json.Marshal origin error:%s", err)
+	}
+	err = ioutil.WriteFile(originFile, data, 0644)
+	if err != nil {
+		return ForkBlock{}, fmt.Errorf("write %s error:%s", originFile, err)
+	}
+	return ForkBlock{Height: forkHeight, Hash: hash}, nil
+}
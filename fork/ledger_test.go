@@ -0,0 +1,133 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package fork
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/genesis"
+	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/states"
+	scom "github.com/qbyyf/ontology/core/store/common"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/smartcontract"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ont"
+	nutils "github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func soloGenesis(t *testing.T, bookkeeper *account.Account) *types.Block {
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	config.DefConfig.Genesis.SOLO.Bookkeepers = []string{hex.EncodeToString(keypair.SerializePublicKey(bookkeeper.PublicKey))}
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	block, err := genesis.BuildGenesisBlock([]keypair.PublicKey{bookkeeper.PublicKey}, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	return block
+}
+
+func ontBalance(t *testing.T, ldg *ledger.Ledger, address common.Address) uint64 {
+	item, err := ldg.GetStorageState(nutils.OntContractAddress, address[:])
+	if err == scom.ErrNotFound {
+		return 0
+	}
+	assert.Nil(t, err)
+	balance, err := states.NativeTokenBalanceFromStorageItem(item)
+	assert.Nil(t, err)
+	return balance.MustToInteger64()
+}
+
+func TestForkLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fork")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	remoteBookkeeper := account.NewAccount("")
+	remoteGenesis := soloGenesis(t, remoteBookkeeper)
+	remoteLedger, err := ledger.InitLedger(filepath.Join(dir, "remote"), 0,
+		[]keypair.PublicKey{remoteBookkeeper.PublicKey}, remoteGenesis)
+	assert.Nil(t, err)
+	defer remoteLedger.Close()
+	remote := NewLedgerRemote(remoteLedger.LedgerStore)
+
+	localBookkeeper := account.NewAccount("")
+	localGenesis := soloGenesis(t, localBookkeeper)
+	localDir := filepath.Join(dir, "local")
+	ldg, err := InitLedger(localDir, 0, []keypair.PublicKey{localBookkeeper.PublicKey}, localGenesis, remote, 0)
+	assert.Nil(t, err)
+
+	//native contracts initialized in remote chain are not initialized again
+	assert.Equal(t, uint64(constants.ONT_TOTAL_SUPPLY), ontBalance(t, ldg, remoteBookkeeper.Address))
+	assert.Equal(t, uint64(0), ontBalance(t, ldg, localBookkeeper.Address))
+
+	//transfer ONT of remote bookkeeper without its signature
+	code, err := cutils.BuildNativeInvokeCode(nutils.OntContractAddress, 0, ont.TRANSFER_NAME,
+		[]interface{}{[]*ont.TransferState{{From: remoteBookkeeper.Address, To: localBookkeeper.Address, Value: 100}}})
+	assert.Nil(t, err)
+	mutable := &types.MutableTransaction{
+		TxType:   types.InvokeNeo,
+		GasLimit: 20000,
+		Payer:    remoteBookkeeper.Address,
+		Payload:  &payload.InvokeCode{Code: code},
+	}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	_, err = ldg.PreExecuteContract(tx)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, smartcontract.Impersonate(remoteBookkeeper.Address))
+	defer smartcontract.StopImpersonating(remoteBookkeeper.Address)
+	result, err := ldg.PreExecuteContract(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, byte(1), result.State)
+
+	//impersonation is ignored out of test mode
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	_, err = ldg.PreExecuteContract(tx)
+	assert.NotNil(t, err)
+	assert.NotNil(t, smartcontract.Impersonate(localBookkeeper.Address))
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	assert.Nil(t, ldg.Close())
+
+	//data dir can not be forked at another block
+	_, err = InitLedger(localDir, 0, []keypair.PublicKey{localBookkeeper.PublicKey}, localGenesis, remote, 1)
+	assert.NotNil(t, err)
+	ldg, err = InitLedger(localDir, 0, []keypair.PublicKey{localBookkeeper.PublicKey}, localGenesis, remote, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, ldg.Close())
+
+	//data dir can not be forked from another chain
+	otherLedger, err := ledger.InitLedger(filepath.Join(dir, "other"), 0,
+		[]keypair.PublicKey{localBookkeeper.PublicKey}, localGenesis)
+	assert.Nil(t, err)
+	defer otherLedger.Close()
+	_, err = InitLedger(localDir, 0, []keypair.PublicKey{localBookkeeper.PublicKey}, localGenesis,
+		NewLedgerRemote(otherLedger.LedgerStore), 0)
+	assert.NotNil(t, err)
+}
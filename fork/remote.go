@@ -0,0 +1,374 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package fork
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/go-ethereum/common/hexutil"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/states"
+	"github.com/qbyyf/ontology/core/store"
+	scom "github.com/qbyyf/ontology/core/store/common"
+	rpcerr "github.com/qbyyf/ontology/http/base/error"
+)
+
+const (
+	JSON_RPC_VERSION    = "2.0"
+	RPC_REQUEST_TIMEOUT = 30 * time.Second
+)
+
+// ForkBlock is the block of remote chain which the local chain is forked from, all the states are
+// fetched at this block
+type ForkBlock struct {
+	Height uint32
+	Hash   common.Uint256
+}
+
+// Remote is the chain which the local chain is forked from
+type Remote interface {
+	//GetStorageItem returns the storage item of contract at block, nil if the key is not exist
+	GetStorageItem(block ForkBlock, contract common.Address, key []byte) (*states.StorageItem, error)
+	//GetContract returns the deployed contract at block, nil if the contract is not exist
+	GetContract(block ForkBlock, contract common.Address) (*payload.DeployCode, error)
+	//GetEthAccount returns the nonce and code of EVM account at block
+	GetEthAccount(block ForkBlock, address common.Address) (uint64, []byte, error)
+	//GetEthStorage returns the storage value of EVM contract in slot at block
+	GetEthStorage(block ForkBlock, contract common.Address, slot common.Uint256) ([]byte, error)
+	//GetCurrentBlockHeight returns the height of current block of remote chain
+	GetCurrentBlockHeight() (uint32, error)
+	//GetBlockHash returns the hash of block at height
+	GetBlockHash(height uint32) (common.Uint256, error)
+}
+
+type jsonRpcRequest struct {
+	Version string        `json:"jsonrpc"`
+	Id      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type jsonRpcResponse struct {
+	Error  int64           `json:"error"`
+	Desc   string          `json:"desc"`
+	Result json.RawMessage `json:"result"`
+}
+
+type ethJsonRpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int64  `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// RpcRemote fetches states from the json rpc and EVM rpc of remote node.
+// EVM accounts and storages are absent if EVM rpc address is empty
+type RpcRemote struct {
+	rpcAddr    string
+	ethRpcAddr string
+	client     *http.Client
+}
+
+// NewRpcRemote returns the remote chain of rpc address and EVM rpc address
+func NewRpcRemote(rpcAddr, ethRpcAddr string) *RpcRemote {
+	return &RpcRemote{
+		rpcAddr:    rpcAddr,
+		ethRpcAddr: ethRpcAddr,
+		client:     &http.Client{Timeout: RPC_REQUEST_TIMEOUT},
+	}
+}
+
+func (self *RpcRemote) post(addr, method string, params []interface{}) ([]byte, error) {
+	data, err := json.Marshal(&jsonRpcRequest{
+		Version: JSON_RPC_VERSION,
+		Id:      "fork",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal JsonRpcRequest error:%s", err)
+	}
+	resp, err := self.client.Post(addr, "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read rpc response body error:%s", err)
+	}
+	return body, nil
+}
+
+// sendRpcRequest sends request to json rpc, and returns the result and error code
+func (self *RpcRemote) sendRpcRequest(method string, params []interface{}) (json.RawMessage, int64, error) {
+	body, err := self.post(self.rpcAddr, method, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	rsp := &jsonRpcResponse{}
+	err = json.Unmarshal(body, rsp)
+	if err != nil {
+		return nil, 0, fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err)
+	}
+	if rsp.Error != 0 {
+		return nil, rsp.Error, fmt.Errorf("%s error:%d %s", method, rsp.Error, rsp.Desc)
+	}
+	return rsp.Result, 0, nil
+}
+
+func (self *RpcRemote) sendEthRpcRequest(method string, params []interface{}, result interface{}) error {
+	body, err := self.post(self.ethRpcAddr, method, params)
+	if err != nil {
+		return err
+	}
+	rsp := &ethJsonRpcResponse{}
+	err = json.Unmarshal(body, rsp)
+	if err != nil {
+		return fmt.Errorf("json.Unmarshal EthJsonRpcResponse:%s error:%s", body, err)
+	}
+	if rsp.Error != nil {
+		return fmt.Errorf("%s error:%d %s", method, rsp.Error.Code, rsp.Error.Message)
+	}
+	return json.Unmarshal(rsp.Result, result)
+}
+
+// getHexResult decodes the hex string result, nil is returned if the result is null
+func getHexResult(result json.RawMessage) ([]byte, error) {
+	var str *string
+	err := json.Unmarshal(result, &str)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal result:%s error:%s", result, err)
+	}
+	if str == nil {
+		return nil, nil
+	}
+	return common.HexToBytes(*str)
+}
+
+// checkPinned converts the error of reading states which are not at the pinned block
+func checkPinned(block ForkBlock, code int64, err error) error {
+	switch code {
+	case rpcerr.INVALID_METHOD:
+		return fmt.Errorf("remote node does not support reading states of a block")
+	case rpcerr.UNKNOWN_BLOCK:
+		return fmt.Errorf("states of fork block %d %s are not available in remote node, the remote chain may have advanced",
+			block.Height, block.Hash.ToHexString())
+	}
+	return err
+}
+
+// GetStorageItem fetches the storage item by getrawstorage at the fork block
+func (self *RpcRemote) GetStorageItem(block ForkBlock, contract common.Address, key []byte) (*states.StorageItem, error) {
+	result, code, err := self.sendRpcRequest("getrawstorage", []interface{}{contract.ToHexString(),
+		common.ToHexString(key), block.Hash.ToHexString()})
+	if err != nil {
+		return nil, checkPinned(block, code, err)
+	}
+	data, err := getHexResult(result)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	item := &states.StorageItem{}
+	err = item.Deserialization(common.NewZeroCopySource(data))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize storage item error:%s", err)
+	}
+	return item, nil
+}
+
+func (self *RpcRemote) GetContract(block ForkBlock, contract common.Address) (*payload.DeployCode, error) {
+	result, code, err := self.sendRpcRequest("getcontractstate", []interface{}{contract.ToHexString(), 0,
+		block.Hash.ToHexString()})
+	if code == rpcerr.UNKNOWN_CONTRACT {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, checkPinned(block, code, err)
+	}
+	data, err := getHexResult(result)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	deploy := &payload.DeployCode{}
+	err = deploy.Deserialization(common.NewZeroCopySource(data))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize contract error:%s", err)
+	}
+	return deploy, nil
+}
+
+func (self *RpcRemote) GetEthAccount(block ForkBlock, address common.Address) (uint64, []byte, error) {
+	if self.ethRpcAddr == "" {
+		return 0, nil, nil
+	}
+	addr := ethcommon.Address(address).Hex()
+	height := hexutil.EncodeUint64(uint64(block.Height))
+	var nonce hexutil.Uint64
+	err := self.sendEthRpcRequest("eth_getTransactionCount", []interface{}{addr, height}, &nonce)
+	if err != nil {
+		return 0, nil, err
+	}
+	var code hexutil.Bytes
+	err = self.sendEthRpcRequest("eth_getCode", []interface{}{addr, height}, &code)
+	if err != nil {
+		return 0, nil, err
+	}
+	return uint64(nonce), code, nil
+}
+
+func (self *RpcRemote) GetEthStorage(block ForkBlock, contract common.Address, slot common.Uint256) ([]byte, error) {
+	if self.ethRpcAddr == "" {
+		return nil, nil
+	}
+	var value hexutil.Bytes
+	err := self.sendEthRpcRequest("eth_getStorageAt", []interface{}{ethcommon.Address(contract).Hex(),
+		hexutil.Encode(slot[:]), hexutil.EncodeUint64(uint64(block.Height))}, &value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (self *RpcRemote) GetCurrentBlockHeight() (uint32, error) {
+	result, _, err := self.sendRpcRequest("getblockcount", nil)
+	if err != nil {
+		return 0, err
+	}
+	var count uint32
+	err = json.Unmarshal(result, &count)
+	if err != nil {
+		return 0, fmt.Errorf("json.Unmarshal result:%s error:%s", result, err)
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("remote chain has no block")
+	}
+	return count - 1, nil
+}
+
+func (self *RpcRemote) GetBlockHash(height uint32) (common.Uint256, error) {
+	result, _, err := self.sendRpcRequest("getblockhash", []interface{}{height})
+	if err != nil {
+		return common.Uint256{}, err
+	}
+	var hash string
+	err = json.Unmarshal(result, &hash)
+	if err != nil {
+		return common.Uint256{}, fmt.Errorf("json.Unmarshal result:%s error:%s", result, err)
+	}
+	return common.Uint256FromHexString(hash)
+}
+
+// GetEthChainId returns the EIP-155 chain id of remote chain
+func (self *RpcRemote) GetEthChainId() (uint64, error) {
+	var chainId hexutil.Uint64
+	err := self.sendEthRpcRequest("eth_chainId", nil, &chainId)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(chainId), nil
+}
+
+// LedgerRemote serves the states of a local ledger as remote chain, it is used as a stand-in
+// of remote node in tests
+type LedgerRemote struct {
+	store store.LedgerStore
+}
+
+// NewLedgerRemote returns the remote chain of ledger store
+func NewLedgerRemote(store store.LedgerStore) *LedgerRemote {
+	return &LedgerRemote{store: store}
+}
+
+// checkPinned returns error if the current block of ledger is not the fork block, since the ledger
+// keeps the states of current block only
+func (self *LedgerRemote) checkPinned(block ForkBlock) error {
+	if hash := self.store.GetCurrentBlockHash(); hash != block.Hash {
+		return fmt.Errorf("states of fork block %d %s are not available, current block is %s",
+			block.Height, block.Hash.ToHexString(), hash.ToHexString())
+	}
+	return nil
+}
+
+func (self *LedgerRemote) GetStorageItem(block ForkBlock, contract common.Address, key []byte) (*states.StorageItem, error) {
+	if err := self.checkPinned(block); err != nil {
+		return nil, err
+	}
+	item, err := self.store.GetStorageState(contract, key)
+	if err == scom.ErrNotFound {
+		return nil, nil
+	}
+	return item, err
+}
+
+func (self *LedgerRemote) GetContract(block ForkBlock, contract common.Address) (*payload.DeployCode, error) {
+	if err := self.checkPinned(block); err != nil {
+		return nil, err
+	}
+	deploy, err := self.store.GetContractState(contract)
+	if err == scom.ErrNotFound {
+		return nil, nil
+	}
+	return deploy, err
+}
+
+func (self *LedgerRemote) GetEthAccount(block ForkBlock, address common.Address) (uint64, []byte, error) {
+	if err := self.checkPinned(block); err != nil {
+		return 0, nil, err
+	}
+	account, err := self.store.GetEthAccount(ethcommon.Address(address))
+	if err != nil {
+		return 0, nil, err
+	}
+	if account.CodeHash == (ethcommon.Hash{}) {
+		return account.Nonce, nil, nil
+	}
+	code, err := self.store.GetEthCode(account.CodeHash)
+	if err != nil && err != scom.ErrNotFound {
+		return 0, nil, err
+	}
+	return account.Nonce, code, nil
+}
+
+func (self *LedgerRemote) GetEthStorage(block ForkBlock, contract common.Address, slot common.Uint256) ([]byte, error) {
+	if err := self.checkPinned(block); err != nil {
+		return nil, err
+	}
+	value, err := self.store.GetEthState(ethcommon.Address(contract), ethcommon.Hash(slot))
+	if err == scom.ErrNotFound {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (self *LedgerRemote) GetCurrentBlockHeight() (uint32, error) {
+	return self.store.GetCurrentBlockHeight(), nil
+}
+
+func (self *LedgerRemote) GetBlockHash(height uint32) (common.Uint256, error) {
+	return self.store.GetBlockHash(height), nil
+}
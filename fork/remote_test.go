@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package fork
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/states"
+	rpcerr "github.com/qbyyf/ontology/http/base/error"
+	"github.com/stretchr/testify/assert"
+)
+
+// errorCode is the result of method which fails with the error code
+type errorCode int64
+
+// rpcHandler answers json rpc requests of ontology and EVM rpc with the results of methods,
+// and records the params of requests
+func rpcHandler(t *testing.T, results map[string]interface{}, params map[string][]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &jsonRpcRequest{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(req))
+		params[req.Method] = req.Params
+		result, ok := results[req.Method]
+		rsp := map[string]interface{}{"jsonrpc": JSON_RPC_VERSION, "id": req.Id, "result": result}
+		if code, isErr := result.(errorCode); isErr {
+			rsp["error"], rsp["result"] = int64(code), ""
+		} else if !ok {
+			rsp["error"] = rpcerr.INVALID_METHOD
+		} else if len(req.Method) < 4 || req.Method[:4] != "eth_" {
+			rsp["error"] = 0
+		}
+		assert.Nil(t, json.NewEncoder(w).Encode(rsp))
+	}
+}
+
+func TestRpcRemote(t *testing.T) {
+	item := &states.StorageItem{StateBase: states.StateBase{StateVersion: states.ScaleDecimal9Version}, Value: []byte{1, 2}}
+	results := map[string]interface{}{
+		"getrawstorage":           common.ToHexString(item.ToArray()),
+		"getblockcount":           11,
+		"getblockhash":            common.Uint256{1}.ToHexString(),
+		"getcontractstate":        errorCode(rpcerr.UNKNOWN_CONTRACT),
+		"eth_chainId":             "0x3a",
+		"eth_getTransactionCount": "0x2",
+		"eth_getCode":             "0x6000",
+		"eth_getStorageAt":        "0x0000000000000000000000000000000000000000000000000000000000000001",
+	}
+	params := make(map[string][]interface{})
+	svr := httptest.NewServer(rpcHandler(t, results, params))
+	defer svr.Close()
+	remote := NewRpcRemote(svr.URL, svr.URL)
+	block := ForkBlock{Height: 10, Hash: common.Uint256{1}}
+
+	height, err := remote.GetCurrentBlockHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(10), height)
+	hash, err := remote.GetBlockHash(0)
+	assert.Nil(t, err)
+	assert.Equal(t, common.Uint256{1}, hash)
+	chainId, err := remote.GetEthChainId()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(58), chainId)
+
+	//all the states are read at the fork block
+	fetched, err := remote.GetStorageItem(block, common.Address{1}, []byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, item, fetched)
+	assert.Equal(t, block.Hash.ToHexString(), params["getrawstorage"][2])
+	nonce, code, err := remote.GetEthAccount(block, common.Address{2})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), nonce)
+	assert.Equal(t, []byte{0x60, 0x00}, code)
+	assert.Equal(t, "0xa", params["eth_getTransactionCount"][1])
+	assert.Equal(t, "0xa", params["eth_getCode"][1])
+	value, err := remote.GetEthStorage(block, common.Address{2}, common.Uint256{})
+	assert.Nil(t, err)
+	assert.Equal(t, byte(1), value[31])
+	assert.Equal(t, "0xa", params["eth_getStorageAt"][2])
+
+	//null result is absent
+	results["getrawstorage"] = nil
+	fetched, err = remote.GetStorageItem(block, common.Address{1}, []byte("key"))
+	assert.Nil(t, err)
+	assert.Nil(t, fetched)
+
+	//states of fork block are not available if remote chain advances
+	results["getrawstorage"] = errorCode(rpcerr.UNKNOWN_BLOCK)
+	_, err = remote.GetStorageItem(block, common.Address{1}, []byte("key"))
+	assert.NotNil(t, err)
+
+	//remote node which does not support getrawstorage can not be pinned
+	delete(results, "getrawstorage")
+	_, err = remote.GetStorageItem(block, common.Address{1}, []byte("key"))
+	assert.NotNil(t, err)
+
+	//unknown contract is absent
+	contract, err := NewRpcRemote(svr.URL, "").GetContract(block, common.Address{1})
+	assert.Nil(t, err)
+	assert.Nil(t, contract)
+	assert.Equal(t, block.Hash.ToHexString(), params["getcontractstate"][2])
+	nonce, code, err = NewRpcRemote(svr.URL, "").GetEthAccount(block, common.Address{2})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), nonce)
+	assert.Nil(t, code)
+}
@@ -0,0 +1,269 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package fork
+
+import (
+	"fmt"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/ontology/common"
+	scom "github.com/qbyyf/ontology/core/store/common"
+	"github.com/qbyyf/ontology/smartcontract/storage"
+)
+
+const (
+	EVM_SLOT_SIZE = 32
+)
+
+var absentValue = []byte{1}
+
+// Store is the state db of forked chain. The states missing in local db are fetched from remote chain
+// and cached in local db. The states deleted locally and the states absent in remote chain are marked,
+// so that they are not fetched again.
+// Only contracts, contract storages and EVM accounts are fetched from remote chain, and the iterator
+// only iterates local db, so the states which are never read are not iterated.
+// All the states are fetched at the fork block, so the fetched states are consistent with each other
+// even if the remote chain advances.
+type Store struct {
+	local  scom.PersistStore
+	remote Remote
+	block  ForkBlock
+}
+
+// NewStore returns the forked state db on top of local db, the states are fetched at block of remote chain
+func NewStore(local scom.PersistStore, remote Remote, block ForkBlock) *Store {
+	return &Store{
+		local:  local,
+		remote: remote,
+		block:  block,
+	}
+}
+
+func isForked(key []byte) bool {
+	if len(key) <= common.ADDR_LEN {
+		return false
+	}
+	switch scom.DataEntryPrefix(key[0]) {
+	case scom.ST_CONTRACT:
+		return len(key) == 1+common.ADDR_LEN
+	case scom.ST_ETH_ACCOUNT:
+		return len(key) == 1+common.ADDR_LEN
+	case scom.ST_STORAGE:
+		return true
+	}
+	return false
+}
+
+func absentKey(key []byte) []byte {
+	return append([]byte{byte(scom.ST_FORK_ABSENT)}, key...)
+}
+
+// Put the key-value pair to store
+func (self *Store) Put(key []byte, value []byte) error {
+	if isForked(key) {
+		if err := self.local.Delete(absentKey(key)); err != nil {
+			return err
+		}
+	}
+	return self.local.Put(key, value)
+}
+
+// Get the value of key, the value is fetched from remote chain if it is not in local db
+func (self *Store) Get(key []byte) ([]byte, error) {
+	value, err := self.local.Get(key)
+	if err != scom.ErrNotFound || !isForked(key) {
+		return value, err
+	}
+	absent, err := self.local.Has(absentKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if absent {
+		return nil, scom.ErrNotFound
+	}
+	value, err = self.fetch(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		err = self.local.Put(absentKey(key), absentValue)
+		if err != nil {
+			return nil, err
+		}
+		return nil, scom.ErrNotFound
+	}
+	err = self.local.Put(key, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Has returns whether the key is exist in local db or remote chain
+func (self *Store) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete the key in store
+func (self *Store) Delete(key []byte) error {
+	if isForked(key) {
+		if err := self.local.Put(absentKey(key), absentValue); err != nil {
+			return err
+		}
+	}
+	return self.local.Delete(key)
+}
+
+// NewBatch start commit batch
+func (self *Store) NewBatch() {
+	self.local.NewBatch()
+}
+
+// BatchPut put a key-value pair to batch
+func (self *Store) BatchPut(key []byte, value []byte) {
+	if isForked(key) {
+		self.local.BatchDelete(absentKey(key))
+	}
+	self.local.BatchPut(key, value)
+}
+
+// BatchDelete delete the key in batch
+func (self *Store) BatchDelete(key []byte) {
+	if isForked(key) {
+		self.local.BatchPut(absentKey(key), absentValue)
+	}
+	self.local.BatchDelete(key)
+}
+
+// BatchCommit commit batch to store
+func (self *Store) BatchCommit() error {
+	return self.local.BatchCommit()
+}
+
+// Close store
+func (self *Store) Close() error {
+	return self.local.Close()
+}
+
+// NewIterator returns the iterator of local db
+func (self *Store) NewIterator(prefix []byte) scom.StoreIterator {
+	return self.local.NewIterator(prefix)
+}
+
+// fetch returns the value of key in remote chain, which is encoded the same as local db.
+// nil is returned if the key is absent in remote chain
+func (self *Store) fetch(key []byte) ([]byte, error) {
+	var address common.Address
+	copy(address[:], key[1:1+common.ADDR_LEN])
+	switch scom.DataEntryPrefix(key[0]) {
+	case scom.ST_CONTRACT:
+		contract, err := self.remote.GetContract(self.block, address)
+		if err != nil {
+			return nil, fmt.Errorf("fetch contract %s error:%s", address.ToHexString(), err)
+		}
+		if contract == nil {
+			return nil, nil
+		}
+		return common.SerializeToBytes(contract), nil
+	case scom.ST_ETH_ACCOUNT:
+		return self.fetchEthAccount(address)
+	}
+
+	storageKey := key[1+common.ADDR_LEN:]
+	isEvm, err := self.isEvmContract(address, storageKey)
+	if err != nil {
+		return nil, err
+	}
+	if isEvm {
+		var slot common.Uint256
+		copy(slot[:], storageKey)
+		value, err := self.remote.GetEthStorage(self.block, address, slot)
+		if err != nil {
+			return nil, fmt.Errorf("fetch storage of EVM contract %s error:%s", address.ToHexString(), err)
+		}
+		if isZero(value) {
+			return nil, nil
+		}
+		return value, nil
+	}
+	item, err := self.remote.GetStorageItem(self.block, address, storageKey)
+	if err != nil {
+		return nil, fmt.Errorf("fetch storage of contract %s error:%s", address.ToHexString(), err)
+	}
+	if item == nil {
+		return nil, nil
+	}
+	return item.ToArray(), nil
+}
+
+// fetchEthAccount fetches the nonce and code of EVM account, the code is saved to local db directly
+// since it is indexed by code hash
+func (self *Store) fetchEthAccount(address common.Address) ([]byte, error) {
+	nonce, code, err := self.remote.GetEthAccount(self.block, address)
+	if err != nil {
+		return nil, fmt.Errorf("fetch EVM account %s error:%s", address.ToHexString(), err)
+	}
+	account := &storage.EthAccount{Nonce: nonce}
+	if len(code) != 0 {
+		account.CodeHash = crypto.Keccak256Hash(code)
+		codeKey := append([]byte{byte(scom.ST_ETH_CODE)}, account.CodeHash[:]...)
+		err = self.local.Put(codeKey, code)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if account.IsEmpty() {
+		return nil, nil
+	}
+	return common.SerializeToBytes(account), nil
+}
+
+// isEvmContract returns whether the storage key is a slot of EVM contract
+func (self *Store) isEvmContract(address common.Address, storageKey []byte) (bool, error) {
+	if len(storageKey) != EVM_SLOT_SIZE {
+		return false, nil
+	}
+	value, err := self.Get(append([]byte{byte(scom.ST_ETH_ACCOUNT)}, address[:]...))
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	account := &storage.EthAccount{}
+	err = account.Deserialization(common.NewZeroCopySource(value))
+	if err != nil {
+		return false, err
+	}
+	return account.CodeHash != (ethcommon.Hash{}), nil
+}
+
+func isZero(value []byte) bool {
+	for _, b := range value {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package fork
+
+import (
+	"testing"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/states"
+	scom "github.com/qbyyf/ontology/core/store/common"
+	"github.com/qbyyf/ontology/core/store/leveldbstore"
+	"github.com/qbyyf/ontology/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type ethAccount struct {
+	nonce uint64
+	code  []byte
+}
+
+// mapRemote is a remote chain whose states are in maps, and counts the fetches
+type mapRemote struct {
+	storages    map[string]*states.StorageItem
+	contracts   map[common.Address]*payload.DeployCode
+	accounts    map[common.Address]ethAccount
+	ethStorages map[string][]byte
+	fetched     int
+}
+
+func newMapRemote() *mapRemote {
+	return &mapRemote{
+		storages:    make(map[string]*states.StorageItem),
+		contracts:   make(map[common.Address]*payload.DeployCode),
+		accounts:    make(map[common.Address]ethAccount),
+		ethStorages: make(map[string][]byte),
+	}
+}
+
+func (self *mapRemote) GetStorageItem(block ForkBlock, contract common.Address, key []byte) (*states.StorageItem, error) {
+	self.fetched++
+	return self.storages[string(append(contract[:], key...))], nil
+}
+
+func (self *mapRemote) GetContract(block ForkBlock, contract common.Address) (*payload.DeployCode, error) {
+	self.fetched++
+	return self.contracts[contract], nil
+}
+
+func (self *mapRemote) GetEthAccount(block ForkBlock, address common.Address) (uint64, []byte, error) {
+	self.fetched++
+	account := self.accounts[address]
+	return account.nonce, account.code, nil
+}
+
+func (self *mapRemote) GetEthStorage(block ForkBlock, contract common.Address, slot common.Uint256) ([]byte, error) {
+	self.fetched++
+	return self.ethStorages[string(append(contract[:], slot[:]...))], nil
+}
+
+func (self *mapRemote) GetCurrentBlockHeight() (uint32, error) {
+	return 1, nil
+}
+
+func (self *mapRemote) GetBlockHash(height uint32) (common.Uint256, error) {
+	return common.Uint256{byte(height)}, nil
+}
+
+func storageKey(contract common.Address, key []byte) []byte {
+	return append(append([]byte{byte(scom.ST_STORAGE)}, contract[:]...), key...)
+}
+
+func TestStoreFetchStorage(t *testing.T) {
+	remote := newMapRemote()
+	contract := common.Address{1}
+	item := &states.StorageItem{StateBase: states.StateBase{StateVersion: states.ScaleDecimal9Version}, Value: []byte("value")}
+	remote.storages[string(append(contract[:], "key"...))] = item
+	store := NewStore(leveldbstore.NewMemLevelDBStore(), remote, ForkBlock{})
+
+	value, err := store.Get(storageKey(contract, []byte("key")))
+	assert.Nil(t, err)
+	assert.Equal(t, item.ToArray(), value)
+	//fetched value is cached locally
+	remote.storages[string(append(contract[:], "key"...))] = &states.StorageItem{Value: []byte("changed")}
+	value, err = store.Get(storageKey(contract, []byte("key")))
+	assert.Nil(t, err)
+	assert.Equal(t, item.ToArray(), value)
+	assert.Equal(t, 1, remote.fetched)
+
+	//absent key is not fetched again
+	_, err = store.Get(storageKey(contract, []byte("absent")))
+	assert.Equal(t, scom.ErrNotFound, err)
+	remote.storages[string(append(contract[:], "absent"...))] = item
+	has, err := store.Has(storageKey(contract, []byte("absent")))
+	assert.Nil(t, err)
+	assert.False(t, has)
+	assert.Equal(t, 2, remote.fetched)
+
+	//states which are not forked are never fetched
+	_, err = store.Get([]byte{byte(scom.SYS_CURRENT_BLOCK)})
+	assert.Equal(t, scom.ErrNotFound, err)
+	assert.Equal(t, 2, remote.fetched)
+}
+
+func TestStoreDeleteAndPut(t *testing.T) {
+	remote := newMapRemote()
+	contract := common.Address{1}
+	remote.storages[string(append(contract[:], "key"...))] = &states.StorageItem{Value: []byte("value")}
+	store := NewStore(leveldbstore.NewMemLevelDBStore(), remote, ForkBlock{})
+	key := storageKey(contract, []byte("key"))
+
+	//deleted remote state is not fetched again
+	store.NewBatch()
+	store.BatchDelete(key)
+	assert.Nil(t, store.BatchCommit())
+	_, err := store.Get(key)
+	assert.Equal(t, scom.ErrNotFound, err)
+	assert.Equal(t, 0, remote.fetched)
+
+	store.NewBatch()
+	store.BatchPut(key, []byte("new"))
+	assert.Nil(t, store.BatchCommit())
+	value, err := store.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("new"), value)
+
+	assert.Nil(t, store.Delete(key))
+	_, err = store.Get(key)
+	assert.Equal(t, scom.ErrNotFound, err)
+	assert.Nil(t, store.Put(key, []byte("put")))
+	value, err = store.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("put"), value)
+	assert.Equal(t, 0, remote.fetched)
+}
+
+func TestStoreFetchContract(t *testing.T) {
+	remote := newMapRemote()
+	deploy, err := payload.NewDeployCode([]byte{0x51, 0x66}, payload.NEOVM_TYPE, "name", "1.0", "author", "email", "desc")
+	assert.Nil(t, err)
+	remote.contracts[deploy.Address()] = deploy
+	store := NewStore(leveldbstore.NewMemLevelDBStore(), remote, ForkBlock{})
+	address := deploy.Address()
+
+	value, err := store.Get(append([]byte{byte(scom.ST_CONTRACT)}, address[:]...))
+	assert.Nil(t, err)
+	assert.Equal(t, common.SerializeToBytes(deploy), value)
+	_, err = store.Get(append([]byte{byte(scom.ST_CONTRACT)}, common.ADDRESS_EMPTY[:]...))
+	assert.Equal(t, scom.ErrNotFound, err)
+}
+
+func TestStoreFetchEvmStates(t *testing.T) {
+	remote := newMapRemote()
+	contract := common.Address{2}
+	code := []byte{0x60, 0x00}
+	remote.accounts[contract] = ethAccount{nonce: 1, code: code}
+	slot := common.Uint256{3}
+	value := ethcommon.BigToHash(ethcommon.Big1).Bytes()
+	remote.ethStorages[string(append(contract[:], slot[:]...))] = value
+	remote.storages[string(append(contract[:], slot[:]...))] = &states.StorageItem{Value: []byte("wrong")}
+	store := NewStore(leveldbstore.NewMemLevelDBStore(), remote, ForkBlock{})
+
+	//EVM storage is fetched by slot, and the account is fetched to know it is an EVM contract
+	data, err := store.Get(storageKey(contract, slot[:]))
+	assert.Nil(t, err)
+	assert.Equal(t, value, data)
+
+	data, err = store.Get(append([]byte{byte(scom.ST_ETH_ACCOUNT)}, contract[:]...))
+	assert.Nil(t, err)
+	account := &storage.EthAccount{}
+	assert.Nil(t, account.Deserialization(common.NewZeroCopySource(data)))
+	assert.Equal(t, uint64(1), account.Nonce)
+	assert.Equal(t, crypto.Keccak256Hash(code), account.CodeHash)
+	data, err = store.Get(append([]byte{byte(scom.ST_ETH_CODE)}, account.CodeHash[:]...))
+	assert.Nil(t, err)
+	assert.Equal(t, code, data)
+
+	//zero slot is absent
+	_, err = store.Get(storageKey(contract, common.UINT256_EMPTY[:]))
+	assert.Equal(t, scom.ErrNotFound, err)
+	//empty account is absent
+	_, err = store.Get(append([]byte{byte(scom.ST_ETH_ACCOUNT)}, common.ADDRESS_EMPTY[:]...))
+	assert.Equal(t, scom.ErrNotFound, err)
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/core/ledger"
 	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/states"
+	"github.com/qbyyf/ontology/core/store"
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/qbyyf/ontology/smartcontract/event"
 	types3 "github.com/qbyyf/ontology/smartcontract/service/evm/types"
@@ -77,6 +79,11 @@ func GetStorageItem(address common.Address, key []byte) ([]byte, error) {
 	return ledger.DefLedger.GetStorageItem(address, key)
 }
 
+//GetStorageState from ledger
+func GetStorageState(address common.Address, key []byte) (*states.StorageItem, error) {
+	return ledger.DefLedger.GetStorageState(address, key)
+}
+
 //GetContractStateFromStore from ledger
 func GetContractStateFromStore(hash common.Address) (*payload.DeployCode, error) {
 	hash = updateNativeSCAddr(hash)
@@ -98,6 +105,11 @@ func PreExecuteContractBatch(tx []*types.Transaction, atomic bool) ([]*cstate.Pr
 	return ledger.DefLedger.PreExecuteContractBatch(tx, atomic)
 }
 
+//SimulateBundle from ledger
+func SimulateBundle(txes []*types.Transaction) (*store.BundleResult, error) {
+	return ledger.DefLedger.SimulateBundle(txes)
+}
+
 //GetEventNotifyByTxHash from ledger
 func GetEventNotifyByTxHash(txHash common.Uint256) (*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByTx(txHash)
@@ -108,6 +120,11 @@ func GetEventNotifyByHeight(height uint32) ([]*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByBlock(height)
 }
 
+//GetDidEventHeights from ledger
+func GetDidEventHeights(did string, from uint32, limit int) ([]uint32, error) {
+	return ledger.DefLedger.GetDidEventHeights(did, from, limit)
+}
+
 //GetMerkleProof from ledger
 func GetMerkleProof(proofHeight uint32, rootHeight uint32) ([]common.Uint256, error) {
 	return ledger.DefLedger.GetMerkleProof(proofHeight, rootHeight)
@@ -32,17 +32,22 @@ import (
 	"github.com/laizy/bigint"
 
 	"github.com/ontio/ontology-crypto/keypair"
+	types2 "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/rlp"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/constants"
 	"github.com/qbyyf/ontology/common/log"
 	"github.com/qbyyf/ontology/core/ledger"
 	"github.com/qbyyf/ontology/core/payload"
+	"github.com/qbyyf/ontology/core/store"
 	"github.com/qbyyf/ontology/core/types"
 	cutils "github.com/qbyyf/ontology/core/utils"
 	ontErrors "github.com/qbyyf/ontology/errors"
 	bactor "github.com/qbyyf/ontology/http/base/actor"
+	berr "github.com/qbyyf/ontology/http/base/error"
 	common2 "github.com/qbyyf/ontology/p2pserver/common"
 	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native/governance"
 	"github.com/qbyyf/ontology/smartcontract/service/native/ont"
 	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
 	cstate "github.com/qbyyf/ontology/smartcontract/states"
@@ -51,6 +56,7 @@ import (
 
 const MAX_SEARCH_HEIGHT uint32 = 100
 const MAX_REQUEST_BODY_SIZE = 1 << 20
+const MAX_BUNDLE_TX_NUM = 32
 
 type BalanceOfRsp struct {
 	Ont    string `json:"ont"`
@@ -68,6 +74,29 @@ type Oep4Balance struct {
 	Balance string `json:"balance"`
 }
 
+type StakingInfoRsp struct {
+	Address        string                 `json:"address"`
+	TotalStake     uint64                 `json:"total_stake"`
+	UnclaimedFee   uint64                 `json:"unclaimed_fee"`
+	Authorizations []AuthorizeStakingInfo `json:"authorizations"`
+	Height         string                 `json:"height"`
+}
+
+type AuthorizeStakingInfo struct {
+	PeerPubkey           string `json:"peer_pubkey"`
+	PeerStatus           uint8  `json:"peer_status"`
+	PeerRemoved          bool   `json:"peer_removed"`
+	ConsensusPos         uint64 `json:"consensus_pos"`
+	CandidatePos         uint64 `json:"candidate_pos"`
+	NewPos               uint64 `json:"new_pos"`
+	WithdrawConsensusPos uint64 `json:"withdraw_consensus_pos"`
+	WithdrawCandidatePos uint64 `json:"withdraw_candidate_pos"`
+	WithdrawUnfreezePos  uint64 `json:"withdraw_unfreeze_pos"`
+	PendingPos           uint64 `json:"pending_pos"`
+	FrozenPos            uint64 `json:"frozen_pos"`
+	WithdrawablePos      uint64 `json:"withdrawable_pos"`
+}
+
 type MerkleProof struct {
 	Type             string
 	TransactionsRoot string
@@ -92,6 +121,11 @@ type ExecuteNotify struct {
 	GasStepUsed     uint64
 	TxIndex         uint32
 	CreatedContract string
+
+	StorageAdded  uint64 `json:",omitempty"`
+	StorageFreed  uint64 `json:",omitempty"`
+	StorageFee    uint64 `json:",omitempty"`
+	StorageRefund uint64 `json:",omitempty"`
 }
 
 type PreExecuteResult struct {
@@ -99,6 +133,26 @@ type PreExecuteResult struct {
 	Gas    uint64
 	Result interface{}
 	Notify []NotifyEventInfo
+
+	StorageFee    uint64 `json:",omitempty"`
+	StorageRefund uint64 `json:",omitempty"`
+}
+
+type StateChange struct {
+	Prefix  byte
+	Key     string
+	Value   string
+	Deleted bool
+}
+
+type BundleTxResult struct {
+	ExecuteNotify
+	StateDiff []StateChange // cumulative state changes after executing this transaction
+}
+
+type SimulateBundleRsp struct {
+	Height  uint32
+	Results []BundleTxResult
 }
 
 type NotifyEventInfo struct {
@@ -212,7 +266,8 @@ func GetExecuteNotify(obj *event.ExecuteNotify) (map[string]bool, ExecuteNotify)
 	}
 	txhash := obj.TxHash.ToHexString()
 	return contractAddrs, ExecuteNotify{txhash, obj.State, obj.GasConsumed, evts,
-		obj.GasStepUsed, obj.TxIndex, obj.CreatedContract.ToHexString()}
+		obj.GasStepUsed, obj.TxIndex, obj.CreatedContract.ToHexString(),
+		obj.StorageAdded, obj.StorageFreed, obj.StorageFee, obj.StorageRefund}
 }
 
 func ConvertPreExecuteResult(obj *cstate.PreExecResult) PreExecuteResult {
@@ -220,7 +275,77 @@ func ConvertPreExecuteResult(obj *cstate.PreExecResult) PreExecuteResult {
 	for _, v := range obj.Notify {
 		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
 	}
-	return PreExecuteResult{obj.State, obj.Gas, obj.Result, evts}
+	return PreExecuteResult{obj.State, obj.Gas, obj.Result, evts, obj.StorageFee, obj.StorageRefund}
+}
+
+func ConvertBundleResult(obj *store.BundleResult) *SimulateBundleRsp {
+	results := make([]BundleTxResult, 0, len(obj.Notify))
+	for i, notify := range obj.Notify {
+		_, evts := GetExecuteNotify(notify)
+		diff := make([]StateChange, 0, obj.WriteSets[i].Len())
+		obj.WriteSets[i].ForEach(func(key, val []byte) {
+			diff = append(diff, StateChange{
+				Prefix:  key[0],
+				Key:     common.ToHexString(key[1:]),
+				Value:   common.ToHexString(val),
+				Deleted: len(val) == 0,
+			})
+		})
+		results = append(results, BundleTxResult{ExecuteNotify: evts, StateDiff: diff})
+	}
+	return &SimulateBundleRsp{Height: obj.Height, Results: results}
+}
+
+// SimulateBundle decodes raws, a list of hex raw transactions which are ontology transactions or RLP
+// encoded ethereum transactions, and executes them sequentially on the current state without broadcasting.
+// It returns the error code of berr and the result, which is the error message on execution failure
+func SimulateBundle(raws interface{}) (int64, interface{}) {
+	txes, errCode := parseBundle(raws)
+	if errCode != berr.SUCCESS {
+		return errCode, ""
+	}
+	result, err := bactor.SimulateBundle(txes)
+	if err != nil {
+		log.Infof("SimulateBundle: %s", err)
+		return berr.SMARTCODE_ERROR, err.Error()
+	}
+	return berr.SUCCESS, ConvertBundleResult(result)
+}
+
+func parseBundle(raws interface{}) ([]*types.Transaction, int64) {
+	list, ok := raws.([]interface{})
+	if !ok || len(list) == 0 || len(list) > MAX_BUNDLE_TX_NUM {
+		return nil, berr.INVALID_PARAMS
+	}
+	txes := make([]*types.Transaction, 0, len(list))
+	for _, raw := range list {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, berr.INVALID_PARAMS
+		}
+		bys, err := common.HexToBytes(strings.TrimPrefix(str, "0x"))
+		if err != nil {
+			return nil, berr.INVALID_PARAMS
+		}
+		txn, err := decodeBundleTx(bys)
+		if err != nil {
+			return nil, berr.INVALID_TRANSACTION
+		}
+		txes = append(txes, txn)
+	}
+	return txes, berr.SUCCESS
+}
+
+// decodeBundleTx decodes a raw ontology transaction, or a RLP encoded ethereum transaction which is a RLP list
+func decodeBundleTx(raw []byte) (*types.Transaction, error) {
+	if kind, _, _, err := rlp.Split(raw); err == nil && kind == rlp.List {
+		eiptx := new(types2.Transaction)
+		if err := rlp.DecodeBytes(raw, eiptx); err != nil {
+			return nil, err
+		}
+		return types.TransactionFromEIP155(eiptx)
+	}
+	return types.TransactionFromRawBytes(raw)
 }
 
 func TransArryByteToHexString(ptx *types.Transaction) *Transactions {
@@ -380,6 +505,60 @@ func GetGrantOng(addr common.Address) (string, error) {
 	return fmt.Sprintf("%v", boundong), nil
 }
 
+// GetStakingInfo returns the authorizations of addr to peers, or to the peers in peer pool if peers is empty
+func GetStakingInfo(addr common.Address, peers []string) (*StakingInfoRsp, error) {
+	params := &governance.GetStakingInfoParam{Address: addr, PeerPubkeyList: peers}
+	mutable, err := NewNativeInvokeTransaction(0, 0, utils.GovernanceContractAddress, 0, governance.GET_STAKING_INFO,
+		[]interface{}{params})
+	if err != nil {
+		return nil, fmt.Errorf("NewNativeInvokeTransaction error:%s", err)
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	results, height, err := bactor.PreExecuteContractBatch([]*types.Transaction{tx}, true)
+	if err != nil {
+		return nil, fmt.Errorf("PrepareInvokeContract error:%s", err)
+	}
+	result := results[0]
+	if result.State == 0 {
+		return nil, fmt.Errorf("prepare invoke failed")
+	}
+	data, err := hex.DecodeString(result.Result.(string))
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	info := new(governance.AddressStakingInfo)
+	if err := info.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize staking info error:%s", err)
+	}
+	rsp := &StakingInfoRsp{
+		Address:        info.Address.ToBase58(),
+		TotalStake:     info.TotalStake,
+		UnclaimedFee:   info.UnclaimedFee,
+		Authorizations: make([]AuthorizeStakingInfo, 0, len(info.Authorizations)),
+		Height:         fmt.Sprintf("%d", height),
+	}
+	for _, v := range info.Authorizations {
+		rsp.Authorizations = append(rsp.Authorizations, AuthorizeStakingInfo{
+			PeerPubkey:           v.AuthorizeInfo.PeerPubkey,
+			PeerStatus:           uint8(v.PeerStatus),
+			PeerRemoved:          v.PeerRemoved,
+			ConsensusPos:         v.AuthorizeInfo.ConsensusPos,
+			CandidatePos:         v.AuthorizeInfo.CandidatePos,
+			NewPos:               v.AuthorizeInfo.NewPos,
+			WithdrawConsensusPos: v.AuthorizeInfo.WithdrawConsensusPos,
+			WithdrawCandidatePos: v.AuthorizeInfo.WithdrawCandidatePos,
+			WithdrawUnfreezePos:  v.AuthorizeInfo.WithdrawUnfreezePos,
+			PendingPos:           v.PendingPos,
+			FrozenPos:            v.FrozenPos,
+			WithdrawablePos:      v.WithdrawablePos,
+		})
+	}
+	return rsp, nil
+}
+
 func GetAllowance(asset string, from, to common.Address) (string, error) {
 	var contractAddr common.Address
 	switch strings.ToLower(asset) {
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/laizy/bigint"
+	ethcomm "github.com/qbyyf/go-ethereum/common"
+	types2 "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/go-ethereum/rlp"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/core/store"
+	"github.com/qbyyf/ontology/core/store/overlaydb"
+	berr "github.com/qbyyf/ontology/http/base/error"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBundle(t *testing.T) {
+	mutable, err := NewNativeInvokeTransaction(2500, 20000, utils.OngContractAddress, 0, "name", []interface{}{})
+	assert.Nil(t, err)
+	ontTx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+
+	key, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	gasPrice := bigint.Mul(2500, constants.GWei).BigInt()
+	ethTx := types2.NewTransaction(0, ethcomm.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d"),
+		big.NewInt(1), 21000, gasPrice, nil)
+	chainId := big.NewInt(int64(config.DefConfig.P2PNode.EVMChainId))
+	ethTx, err = types2.SignTx(ethTx, types2.NewEIP155Signer(chainId), key)
+	assert.Nil(t, err)
+	ethRaw, err := rlp.EncodeToBytes(ethTx)
+	assert.Nil(t, err)
+
+	txes, errCode := parseBundle([]interface{}{common.ToHexString(ontTx.Raw), "0x" + common.ToHexString(ethRaw)})
+	assert.Equal(t, berr.SUCCESS, errCode)
+	assert.Equal(t, 2, len(txes))
+	assert.Equal(t, ontTx.Hash(), txes[0].Hash())
+	assert.True(t, txes[1].IsEipTx())
+	assert.Equal(t, ethTx.Hash(), ethcomm.Hash(txes[1].Hash()))
+	// the ontology encoding of ethereum transaction is accepted too
+	txes, errCode = parseBundle([]interface{}{common.ToHexString(txes[1].Raw)})
+	assert.Equal(t, berr.SUCCESS, errCode)
+	assert.Equal(t, ethTx.Hash(), ethcomm.Hash(txes[0].Hash()))
+
+	tooMany := make([]interface{}, MAX_BUNDLE_TX_NUM+1)
+	for i := range tooMany {
+		tooMany[i] = common.ToHexString(ontTx.Raw)
+	}
+	for _, raws := range []interface{}{nil, "00d1", []interface{}{}, tooMany, []interface{}{1}, []interface{}{"zz"}} {
+		_, errCode = parseBundle(raws)
+		assert.Equal(t, berr.INVALID_PARAMS, errCode)
+	}
+	for _, raw := range []string{"00d1", common.ToHexString(ethRaw[:len(ethRaw)-1])} {
+		_, errCode = parseBundle([]interface{}{raw})
+		assert.Equal(t, berr.INVALID_TRANSACTION, errCode)
+	}
+}
+
+func TestConvertBundleResult(t *testing.T) {
+	writeSet := overlaydb.NewMemDB(0, 0)
+	writeSet.Put([]byte{0x01, 0xaa}, []byte{0xbb})
+	writeSet.Delete([]byte{0x01, 0xcc})
+	rsp := ConvertBundleResult(&store.BundleResult{
+		Height:    10,
+		Notify:    []*event.ExecuteNotify{{State: event.CONTRACT_STATE_SUCCESS, GasConsumed: 1}},
+		WriteSets: []*overlaydb.MemDB{writeSet},
+	})
+	assert.Equal(t, uint32(10), rsp.Height)
+	assert.Equal(t, 1, len(rsp.Results))
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, rsp.Results[0].State)
+	assert.Equal(t, []StateChange{
+		{Prefix: 0x01, Key: "aa", Value: "bb"},
+		{Prefix: 0x01, Key: "cc", Value: "", Deleted: true},
+	}, rsp.Results[0].StateDiff)
+}
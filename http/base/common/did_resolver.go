@@ -0,0 +1,800 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/payload"
+	scom "github.com/qbyyf/ontology/core/store/common"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	bactor "github.com/qbyyf/ontology/http/base/actor"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native/ontid"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	DID_RESOLUTION_CONTEXT    = "https://w3id.org/did-resolution/v1"
+	DID_DOCUMENT_CONTENT_TYPE = "application/did+ld+json"
+	MAX_DID_HISTORY_BLOCKS    = 1000 // max blocks with ontid events of an ID read in one resolution
+)
+
+var errDidHistoryTooLong = fmt.Errorf("ontid events are in more than %d blocks", MAX_DID_HISTORY_BLOCKS)
+
+// DID resolution errors, as defined by the W3C DID resolution specification
+const (
+	DID_ERR_INVALID_DID     = "invalidDid"
+	DID_ERR_INVALID_OPTIONS = "invalidOptions"
+	DID_ERR_NOT_FOUND       = "notFound"
+	DID_ERR_INTERNAL        = "internalError"
+)
+
+type DidPublicKey struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	Controller   string `json:"controller"`
+	PublicKeyHex string `json:"publicKeyHex"`
+}
+
+type DidService struct {
+	Id              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+type DidAttribute struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// DidDocument is the document produced by ontid getDocumentJson
+type DidDocument struct {
+	Contexts       []string        `json:"@context"`
+	Id             string          `json:"id"`
+	PublicKey      []*DidPublicKey `json:"publicKey"`
+	Authentication []interface{}   `json:"authentication"`
+	Controller     interface{}     `json:"controller"`
+	Recovery       interface{}     `json:"recovery"`
+	Service        []*DidService   `json:"service"`
+	Attribute      []*DidAttribute `json:"attribute"`
+	Created        uint32          `json:"created"`
+	Updated        uint32          `json:"updated"`
+	Proof          string          `json:"proof"`
+}
+
+type DidResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+type DidDocumentMetadata struct {
+	Created       string `json:"created,omitempty"`
+	Updated       string `json:"updated,omitempty"`
+	CreatedHeight uint32 `json:"createdHeight,omitempty"`
+	UpdatedHeight uint32 `json:"updatedHeight,omitempty"`
+	VersionId     string `json:"versionId,omitempty"`
+	NextUpdate    string `json:"nextUpdate,omitempty"`
+	NextVersionId string `json:"nextVersionId,omitempty"`
+	Deactivated   bool   `json:"deactivated,omitempty"`
+}
+
+type DidResolutionResult struct {
+	Context               string                `json:"@context"`
+	DidDocument           *DidDocument          `json:"didDocument"`
+	DidResolutionMetadata DidResolutionMetadata `json:"didResolutionMetadata"`
+	DidDocumentMetadata   DidDocumentMetadata   `json:"didDocumentMetadata"`
+}
+
+func didError(code, format string, a ...interface{}) *DidResolutionResult {
+	return &DidResolutionResult{
+		Context: DID_RESOLUTION_CONTEXT,
+		DidResolutionMetadata: DidResolutionMetadata{
+			Error:   code,
+			Message: fmt.Sprintf(format, a...),
+		},
+	}
+}
+
+func formatDidTime(timestamp uint32) string {
+	return time.Unix(int64(timestamp), 0).UTC().Format(time.RFC3339)
+}
+
+// DidResolutionOptions are the options of a DID resolution
+type DidResolutionOptions struct {
+	VersionId   string
+	VersionTime string
+}
+
+// ResolveDid resolves an ONT ID into a W3C DID resolution result. The version
+// is the latest one unless versionId (a block height) or versionTime (RFC3339)
+// is given, in which case the document is rebuilt from the ontid events.
+func ResolveDid(did string, options *DidResolutionOptions) *DidResolutionResult {
+	versionId, versionTime := options.VersionId, options.VersionTime
+	if !account.VerifyID(did) {
+		return didError(DID_ERR_INVALID_DID, "invalid ONT ID %s", did)
+	}
+	if versionId != "" && versionTime != "" {
+		return didError(DID_ERR_INVALID_OPTIONS, "versionId and versionTime are mutually exclusive")
+	}
+
+	stateKey, err := ontid.GetIDStateKey([]byte(did))
+	if err != nil {
+		return didError(DID_ERR_INVALID_DID, "%s", err)
+	}
+	state, err := bactor.GetStorageItem(utils.OntIDContractAddress, stateKey)
+	if err != nil && err != scom.ErrNotFound {
+		return didError(DID_ERR_INTERNAL, "get ID state error:%s", err)
+	}
+	if len(state) == 0 || state[0] == ontid.ID_STATE_NOT_EXIST {
+		return didError(DID_ERR_NOT_FOUND, "%s is not registered", did)
+	}
+	if state[0] == ontid.ID_STATE_REVOKED {
+		return resolveRevokedDid(did)
+	}
+
+	doc, height, err := getDidDocument(did)
+	if err != nil {
+		return didError(DID_ERR_INTERNAL, "%s", err)
+	}
+	if doc == nil {
+		return didError(DID_ERR_NOT_FOUND, "%s is not registered", did)
+	}
+
+	meta := DidDocumentMetadata{}
+	var createdHeight, updatedHeight uint32
+	if doc.Created != 0 {
+		createdHeight, err = searchHeightByTime(doc.Created, height)
+		if err != nil {
+			return didError(DID_ERR_INTERNAL, "%s", err)
+		}
+		meta.Created, meta.CreatedHeight = formatDidTime(doc.Created), createdHeight
+	}
+	if doc.Updated != 0 {
+		updatedHeight, err = searchHeightByTime(doc.Updated+1, height)
+		if err != nil {
+			return didError(DID_ERR_INTERNAL, "%s", err)
+		}
+		updatedHeight -= 1
+		meta.Updated, meta.UpdatedHeight = formatDidTime(doc.Updated), updatedHeight
+		meta.VersionId = strconv.FormatUint(uint64(updatedHeight), 10)
+	}
+	if versionId == "" && versionTime == "" {
+		return &DidResolutionResult{
+			Context:               DID_RESOLUTION_CONTEXT,
+			DidDocument:           doc,
+			DidResolutionMetadata: DidResolutionMetadata{ContentType: DID_DOCUMENT_CONTENT_TYPE},
+			DidDocumentMetadata:   meta,
+		}
+	}
+
+	var target uint32
+	if versionId != "" {
+		h, err := strconv.ParseUint(versionId, 10, 32)
+		if err != nil {
+			return didError(DID_ERR_INVALID_OPTIONS, "invalid versionId %s", versionId)
+		}
+		target = uint32(h)
+	} else {
+		t, err := time.Parse(time.RFC3339, versionTime)
+		if err != nil || t.Unix() < 0 || t.Unix() >= 1<<32-1 {
+			return didError(DID_ERR_INVALID_OPTIONS, "invalid versionTime %s", versionTime)
+		}
+		h, err := searchHeightByTime(uint32(t.Unix())+1, height)
+		if err != nil {
+			return didError(DID_ERR_INTERNAL, "%s", err)
+		}
+		if h == 0 {
+			return didError(DID_ERR_NOT_FOUND, "%s did not exist at %s", did, versionTime)
+		}
+		target = h - 1
+	}
+	if target > height {
+		return didError(DID_ERR_NOT_FOUND, "version %d is beyond the current block height %d", target, height)
+	}
+	if doc.Created == 0 {
+		return didError(DID_ERR_NOT_FOUND, "%s has no recorded history, only the latest version is available", did)
+	}
+	if target < createdHeight {
+		return didError(DID_ERR_NOT_FOUND, "%s did not exist at height %d", did, target)
+	}
+	if target >= updatedHeight {
+		return &DidResolutionResult{
+			Context:               DID_RESOLUTION_CONTEXT,
+			DidDocument:           doc,
+			DidResolutionMetadata: DidResolutionMetadata{ContentType: DID_DOCUMENT_CONTENT_TYPE},
+			DidDocumentMetadata:   meta,
+		}
+	}
+
+	history, err := getDidHistory(did, createdHeight)
+	if err == errDidHistoryTooLong {
+		return didError(DID_ERR_NOT_FOUND, "history of %s is too long, %s", did, err)
+	}
+	if err != nil {
+		return didError(DID_ERR_INTERNAL, "%s", err)
+	}
+	version, versionHeight, nextHeight, err := history.rewind(doc, target)
+	if err != nil {
+		return didError(DID_ERR_NOT_FOUND, "%s", err)
+	}
+	header, err := bactor.GetHeaderByHeight(versionHeight)
+	if err != nil {
+		return didError(DID_ERR_INTERNAL, "get header of height %d error:%s", versionHeight, err)
+	}
+	version.Updated, version.Proof = header.Timestamp, ""
+	meta.Updated, meta.UpdatedHeight = formatDidTime(header.Timestamp), versionHeight
+	meta.VersionId = strconv.FormatUint(uint64(versionHeight), 10)
+	if nextHeight != 0 {
+		header, err := bactor.GetHeaderByHeight(nextHeight)
+		if err != nil {
+			return didError(DID_ERR_INTERNAL, "get header of height %d error:%s", nextHeight, err)
+		}
+		meta.NextUpdate = formatDidTime(header.Timestamp)
+		meta.NextVersionId = strconv.FormatUint(uint64(nextHeight), 10)
+	}
+	return &DidResolutionResult{
+		Context:               DID_RESOLUTION_CONTEXT,
+		DidDocument:           version,
+		DidResolutionMetadata: DidResolutionMetadata{ContentType: DID_DOCUMENT_CONTENT_TYPE},
+		DidDocumentMetadata:   meta,
+	}
+}
+
+// resolveRevokedDid returns the deactivated result of a revoked ID. Revocation deletes the
+// created and updated time, so they are taken from the Register and Revoke events found by
+// the ONT ID index of events
+func resolveRevokedDid(did string) *DidResolutionResult {
+	result := &DidResolutionResult{
+		Context:               DID_RESOLUTION_CONTEXT,
+		DidResolutionMetadata: DidResolutionMetadata{ContentType: DID_DOCUMENT_CONTENT_TYPE},
+		DidDocumentMetadata:   DidDocumentMetadata{Deactivated: true},
+	}
+	//the ID is registered in the first block of its events and revoked in the last one, since a
+	//revoked ID can not be changed any more. Only the index is paged through, which is cheap
+	var first, last uint32
+	found := false
+	for from := uint32(0); ; {
+		heights, err := bactor.GetDidEventHeights(did, from, MAX_DID_HISTORY_BLOCKS)
+		if err != nil {
+			return didError(DID_ERR_INTERNAL, "get event heights of %s error:%s", did, err)
+		}
+		if len(heights) == 0 {
+			break
+		}
+		if !found {
+			first, found = heights[0], true
+		}
+		last = heights[len(heights)-1]
+		if len(heights) < MAX_DID_HISTORY_BLOCKS || last == math.MaxUint32 {
+			break
+		}
+		from = last + 1
+	}
+	if !found {
+		return result
+	}
+	heights := []uint32{first}
+	if last != first {
+		heights = append(heights, last)
+	}
+	events, err := readDidEvents(did, heights)
+	if err != nil {
+		return didError(DID_ERR_INTERNAL, "%s", err)
+	}
+	meta := &result.DidDocumentMetadata
+	for _, evt := range events {
+		header, err := bactor.GetHeaderByHeight(evt.Height)
+		if err != nil {
+			return didError(DID_ERR_INTERNAL, "get header of height %d error:%s", evt.Height, err)
+		}
+		switch evt.States[0] {
+		case "Register":
+			meta.Created, meta.CreatedHeight = formatDidTime(header.Timestamp), evt.Height
+		case "Revoke":
+			meta.Updated, meta.UpdatedHeight = formatDidTime(header.Timestamp), evt.Height
+			meta.VersionId = strconv.FormatUint(uint64(evt.Height), 10)
+		}
+	}
+	return result
+}
+
+func getDidDocument(did string) (*DidDocument, uint32, error) {
+	mutable, err := NewNativeInvokeTransaction(0, 0, utils.OntIDContractAddress, 0, "getDocumentJson",
+		[]interface{}{[]byte(did)})
+	if err != nil {
+		return nil, 0, fmt.Errorf("NewNativeInvokeTransaction error:%s", err)
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, 0, err
+	}
+	results, height, err := bactor.PreExecuteContractBatch([]*types.Transaction{tx}, true)
+	if err != nil {
+		return nil, 0, fmt.Errorf("PrepareInvokeContract error:%s", err)
+	}
+	result := results[0]
+	if result.State == 0 {
+		return nil, 0, fmt.Errorf("prepare invoke failed")
+	}
+	data, err := hex.DecodeString(result.Result.(string))
+	if err != nil {
+		return nil, 0, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	if len(data) == 0 {
+		return nil, height, nil
+	}
+	doc := new(DidDocument)
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal DID document error:%s", err)
+	}
+	return doc, height, nil
+}
+
+// searchHeightByTime returns the first height not after maxHeight whose block
+// timestamp is not before timestamp, or maxHeight+1 if there is none.
+func searchHeightByTime(timestamp uint32, maxHeight uint32) (uint32, error) {
+	low, high := uint32(0), maxHeight+1
+	for low < high {
+		mid := low + (high-low)/2
+		header, err := bactor.GetHeaderByHeight(mid)
+		if err != nil {
+			return 0, fmt.Errorf("get header of height %d error:%s", mid, err)
+		}
+		if header.Timestamp < timestamp {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
+
+type didEvent struct {
+	Height uint32
+	TxHash common.Uint256
+	States []interface{}
+}
+
+// didHistory holds the ontid events of one ONT ID, in execution order
+type didHistory struct {
+	did           string
+	createdHeight uint32
+	registration  string // method used to register the ID, empty if unknown
+	events        []*didEvent
+}
+
+func getDidHistory(did string, from uint32) (*didHistory, error) {
+	heights, err := bactor.GetDidEventHeights(did, from, MAX_DID_HISTORY_BLOCKS+1)
+	if err != nil {
+		return nil, fmt.Errorf("get event heights of %s error:%s", did, err)
+	}
+	if len(heights) > MAX_DID_HISTORY_BLOCKS {
+		return nil, errDidHistoryTooLong
+	}
+	events, err := readDidEvents(did, heights)
+	if err != nil {
+		return nil, err
+	}
+	history := &didHistory{did: did, createdHeight: from, events: events}
+	for _, evt := range history.events {
+		if evt.States[0] != "Register" {
+			continue
+		}
+		tx, err := bactor.GetTransaction(evt.TxHash)
+		if err != nil || tx == nil {
+			break
+		}
+		history.registration = didRegistration(tx, did)
+		break
+	}
+	return history, nil
+}
+
+// didRegistration returns the ontid method invoked by tx to register did, or empty if the
+// tx is not a direct invocation of ontid contract, such as a call from another contract
+func didRegistration(tx *types.Transaction, did string) string {
+	invokeCode, ok := tx.Payload.(*payload.InvokeCode)
+	if !ok || tx.TxType != types.InvokeNeo {
+		return ""
+	}
+	invoke, err := cutils.ParseNeoVMInvokeCode(invokeCode.Code)
+	if err != nil || !invoke.Native || invoke.Contract != utils.OntIDContractAddress {
+		return ""
+	}
+	switch invoke.Method {
+	case "regIDWithPublicKey", "regIDWithAttributes", "regIDWithController":
+	default:
+		return ""
+	}
+	//the first field of the param of registration methods is the ID
+	id := invoke.Args
+	if id.IsArray && len(id.Items) > 0 {
+		id = id.Items[0]
+	}
+	if id.IsArray || string(id.Data) != did {
+		return ""
+	}
+	return invoke.Method
+}
+
+// readDidEvents returns the ontid events of did in the blocks of heights, in execution order
+func readDidEvents(did string, heights []uint32) ([]*didEvent, error) {
+	var events []*didEvent
+	for _, height := range heights {
+		notifies, err := bactor.GetEventNotifyByHeight(height)
+		if err != nil {
+			if err == scom.ErrNotFound {
+				continue
+			}
+			return nil, fmt.Errorf("get events of height %d error:%s", height, err)
+		}
+		for _, notify := range notifies {
+			if notify.State != event.CONTRACT_STATE_SUCCESS {
+				continue
+			}
+			for _, n := range notify.Notify {
+				if n.ContractAddress != utils.OntIDContractAddress {
+					continue
+				}
+				states, ok := n.States.([]interface{})
+				if !ok || ontid.EventSubject(states) != did {
+					continue
+				}
+				events = append(events, &didEvent{Height: height, TxHash: notify.TxHash, States: states})
+			}
+		}
+	}
+	return events, nil
+}
+
+func didEventKeyId(states []interface{}) (uint32, error) {
+	if len(states) < 4 {
+		return 0, fmt.Errorf("invalid %v event", states[0])
+	}
+	id, err := strconv.ParseUint(fmt.Sprint(states[3]), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid key id %v", states[3])
+	}
+	return uint32(id), nil
+}
+
+func didEventHexList(state interface{}) ([]string, error) {
+	var list []interface{}
+	switch v := state.(type) {
+	case string:
+		list = []interface{}{v}
+	case []interface{}:
+		list = v
+	default:
+		return nil, fmt.Errorf("invalid event argument %v", state)
+	}
+	res := make([]string, 0, len(list))
+	for _, item := range list {
+		s, _ := item.(string)
+		data, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event argument %v", item)
+		}
+		res = append(res, string(data))
+	}
+	return res, nil
+}
+
+// didKeyState is what the events tell about a key at the resolved version
+type didKeyState struct {
+	pkList    bool
+	auth      bool
+	authKnown bool
+}
+
+// rewind rebuilds the document as of the end of block target by undoing the
+// events recorded after it. It returns the document, the height of the version
+// and the height of the next version, or an error when the events don't carry
+// enough information to undo a change, such as the old value of an attribute.
+func (this *didHistory) rewind(doc *DidDocument, target uint32) (*DidDocument, uint32, uint32, error) {
+	keys := make(map[uint32]*didKeyState)
+	switch this.registration {
+	case "regIDWithPublicKey":
+		keys[1] = &didKeyState{pkList: true, auth: true, authKnown: true}
+	case "regIDWithAttributes":
+		keys[1] = &didKeyState{pkList: true, authKnown: true}
+	case "":
+		keys[1] = &didKeyState{pkList: true}
+	}
+	// attributes at the version, valid for the keys in touched only
+	attrs := make(map[string]bool)
+	touched := make(map[string]bool)
+	versionHeight, nextHeight := this.createdHeight, uint32(0)
+	var after []*didEvent
+	for _, evt := range this.events {
+		if evt.Height > target {
+			if nextHeight == 0 {
+				nextHeight = evt.Height
+			}
+			after = append(after, evt)
+			continue
+		}
+		versionHeight = evt.Height
+		if len(evt.States) < 4 {
+			continue
+		}
+		op, _ := evt.States[1].(string)
+		switch evt.States[0] {
+		case "PublicKey":
+			id, err := didEventKeyId(evt.States)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			if op == "add" {
+				keys[id] = &didKeyState{pkList: true, authKnown: true}
+			} else {
+				delete(keys, id)
+			}
+		case "AuthKey":
+			id, err := didEventKeyId(evt.States)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			switch op {
+			case "add":
+				keys[id] = &didKeyState{auth: true, authKnown: true}
+			case "set", "remove":
+				if key, ok := keys[id]; ok {
+					key.auth, key.authKnown = op == "set", true
+				}
+			}
+		case "Attribute":
+			names, err := didEventHexList(evt.States[3])
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			for _, name := range names {
+				attrs[name], touched[name] = op == "add", true
+			}
+		}
+	}
+
+	// attributes added after the version, which must not have existed at it
+	added := make(map[string]bool)
+	for _, evt := range after {
+		if evt.States[0] != "Attribute" || len(evt.States) < 4 {
+			continue
+		}
+		names, err := didEventHexList(evt.States[3])
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		for _, name := range names {
+			switch {
+			case evt.States[1] != "add" && !added[name]:
+				return nil, 0, 0, fmt.Errorf("attribute %s was removed at height %d", name, evt.Height)
+			case evt.States[1] != "add":
+				delete(added, name)
+			case added[name]:
+			case attrs[name] || (!touched[name] && this.registration != "regIDWithPublicKey" &&
+				this.registration != "regIDWithController"):
+				return nil, 0, 0, fmt.Errorf("attribute %s may have been overwritten at height %d", name, evt.Height)
+			default:
+				added[name] = true
+			}
+		}
+	}
+
+	version := *doc
+	version.PublicKey = append([]*DidPublicKey{}, doc.PublicKey...)
+	version.Authentication = append([]interface{}{}, doc.Authentication...)
+	version.Service = append([]*DidService{}, doc.Service...)
+	version.Contexts = append([]string{}, doc.Contexts...)
+	version.Attribute = make([]*DidAttribute, 0, len(doc.Attribute))
+	for _, attr := range doc.Attribute {
+		if !added[strings.TrimPrefix(attr.Key, this.did+"#")] {
+			version.Attribute = append(version.Attribute, attr)
+		}
+	}
+
+	for i := len(after) - 1; i >= 0; i-- {
+		evt := after[i]
+		op, _ := evt.States[1].(string)
+		switch evt.States[0] {
+		case "PublicKey":
+			id, err := didEventKeyId(evt.States)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			if op == "add" {
+				version.removeKey(this.keyId(id))
+				continue
+			}
+			key, ok := keys[id]
+			if !ok {
+				continue
+			}
+			if len(evt.States) < 5 {
+				return nil, 0, 0, fmt.Errorf("invalid PublicKey event at height %d", evt.Height)
+			}
+			pk, err := this.publicKey(id, evt.States[4])
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			if !key.authKnown {
+				return nil, 0, 0, fmt.Errorf("authentication of key %d is unknown", id)
+			}
+			version.restoreKey(id, pk, key)
+		case "AuthKey":
+			id, err := didEventKeyId(evt.States)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			if op != "remove" {
+				version.removeAuth(this.keyId(id))
+				continue
+			}
+			key, ok := keys[id]
+			if !ok || (key.authKnown && !key.auth) {
+				continue
+			}
+			if !key.pkList || !key.authKnown {
+				return nil, 0, 0, fmt.Errorf("authentication key %d was removed at height %d", id, evt.Height)
+			}
+			version.addAuth(this.keyId(id))
+		case "Context":
+			if len(evt.States) < 4 {
+				return nil, 0, 0, fmt.Errorf("invalid Context event at height %d", evt.Height)
+			}
+			contexts, err := didEventHexList(evt.States[3])
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			for _, context := range contexts {
+				if op == "add" {
+					version.removeContext(context)
+				} else {
+					version.removeContext(context)
+					version.Contexts = append(version.Contexts, context)
+				}
+			}
+		case "Service":
+			if op != "add" || len(evt.States) < 4 {
+				return nil, 0, 0, fmt.Errorf("service was changed at height %d", evt.Height)
+			}
+			ids, err := didEventHexList(evt.States[3])
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			version.removeService(this.did + "#" + ids[0])
+		case "Attribute":
+		default:
+			return nil, 0, 0, fmt.Errorf("%v was changed at height %d", evt.States[0], evt.Height)
+		}
+	}
+	return &version, versionHeight, nextHeight, nil
+}
+
+func (this *didHistory) keyId(id uint32) string {
+	return fmt.Sprintf("%s#keys-%d", this.did, id)
+}
+
+func (this *didHistory) publicKey(id uint32, state interface{}) (*DidPublicKey, error) {
+	s, _ := state.(string)
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid public key %v", state)
+	}
+	typ, pub, err := ontid.KeyType(data)
+	if err != nil {
+		return nil, err
+	}
+	return &DidPublicKey{Id: this.keyId(id), Type: typ, Controller: this.did, PublicKeyHex: pub}, nil
+}
+
+func didAuthId(auth interface{}) string {
+	switch v := auth.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		id, _ := v["id"].(string)
+		return id
+	case *DidPublicKey:
+		return v.Id
+	}
+	return ""
+}
+
+func (this *DidDocument) removeKey(id string) {
+	keys := this.PublicKey[:0]
+	for _, key := range this.PublicKey {
+		if key.Id != id {
+			keys = append(keys, key)
+		}
+	}
+	this.PublicKey = keys
+	this.removeAuth(id)
+}
+
+func (this *DidDocument) restoreKey(index uint32, pk *DidPublicKey, state *didKeyState) {
+	this.removeKey(pk.Id)
+	if state.pkList {
+		pos := 0
+		for pos < len(this.PublicKey) && didKeyIndex(this.PublicKey[pos].Id) < index {
+			pos++
+		}
+		this.PublicKey = append(this.PublicKey, nil)
+		copy(this.PublicKey[pos+1:], this.PublicKey[pos:])
+		this.PublicKey[pos] = pk
+	}
+	if state.auth {
+		if state.pkList {
+			this.addAuth(pk.Id)
+		} else {
+			this.Authentication = append(this.Authentication, pk)
+		}
+	}
+}
+
+func didKeyIndex(id string) uint32 {
+	index, _ := strconv.ParseUint(id[strings.LastIndex(id, "-")+1:], 10, 32)
+	return uint32(index)
+}
+
+func (this *DidDocument) addAuth(id string) {
+	this.removeAuth(id)
+	this.Authentication = append(this.Authentication, id)
+}
+
+func (this *DidDocument) removeAuth(id string) {
+	auth := this.Authentication[:0]
+	for _, a := range this.Authentication {
+		if didAuthId(a) != id {
+			auth = append(auth, a)
+		}
+	}
+	this.Authentication = auth
+}
+
+func (this *DidDocument) removeContext(context string) {
+	contexts := this.Contexts[:0]
+	for _, c := range this.Contexts {
+		if c != context {
+			contexts = append(contexts, c)
+		}
+	}
+	this.Contexts = contexts
+}
+
+func (this *DidDocument) removeService(id string) {
+	services := this.Service[:0]
+	for _, s := range this.Service {
+		if s.Id != id {
+			services = append(services, s)
+		}
+	}
+	this.Service = services
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDidHistoryRewind(t *testing.T) {
+	did := "did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa"
+	acc1, acc2 := account.NewAccount(""), account.NewAccount("")
+	pub1 := hex.EncodeToString(keypair.SerializePublicKey(acc1.PublicKey))
+	pub2 := hex.EncodeToString(keypair.SerializePublicKey(acc2.PublicKey))
+	key2 := &DidPublicKey{Id: did + "#keys-2", Type: "EcdsaSecp256r1VerificationKey2019", Controller: did, PublicKeyHex: pub2}
+	doc := &DidDocument{
+		Contexts:       []string{"https://www.w3.org/ns/did/v1"},
+		Id:             did,
+		PublicKey:      []*DidPublicKey{key2},
+		Authentication: []interface{}{},
+		Service:        []*DidService{{Id: did + "#hub", Type: "hub", ServiceEndpoint: "https://hub.example"}},
+		Attribute:      []*DidAttribute{{Key: did + "#name", Type: "string", Value: "alice"}},
+	}
+	history := &didHistory{
+		did:           did,
+		createdHeight: 10,
+		registration:  "regIDWithPublicKey",
+		events: []*didEvent{
+			{Height: 10, States: []interface{}{"Register", did}},
+			{Height: 20, States: []interface{}{"PublicKey", "add", did, float64(2), pub2}},
+			{Height: 30, States: []interface{}{"Attribute", "add", did, []interface{}{hex.EncodeToString([]byte("name"))}}},
+			{Height: 40, States: []interface{}{"Service", "add", did, hex.EncodeToString([]byte("hub"))}},
+			{Height: 50, States: []interface{}{"PublicKey", "remove", did, float64(1), pub1}},
+		},
+	}
+
+	version, versionHeight, nextHeight, err := history.rewind(doc, 25)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(20), versionHeight)
+	assert.Equal(t, uint32(30), nextHeight)
+	assert.Equal(t, 2, len(version.PublicKey))
+	assert.Equal(t, did+"#keys-1", version.PublicKey[0].Id)
+	assert.Equal(t, []interface{}{did + "#keys-1"}, version.Authentication)
+	assert.Equal(t, 0, len(version.Attribute))
+	assert.Equal(t, 0, len(version.Service))
+	// the latest document is left untouched
+	assert.Equal(t, 1, len(doc.PublicKey))
+	assert.Equal(t, 1, len(doc.Attribute))
+
+	version, versionHeight, nextHeight, err = history.rewind(doc, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(10), versionHeight)
+	assert.Equal(t, uint32(20), nextHeight)
+	assert.Equal(t, 1, len(version.PublicKey))
+	assert.Equal(t, did+"#keys-1", version.PublicKey[0].Id)
+
+	history.events = append(history.events,
+		&didEvent{Height: 60, States: []interface{}{"Service", "remove", did, hex.EncodeToString([]byte("hub"))}})
+	_, _, _, err = history.rewind(doc, 45)
+	assert.NotNil(t, err)
+}
+
+func TestDidHistoryRewindAttributes(t *testing.T) {
+	did := "did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa"
+	name := hex.EncodeToString([]byte("name"))
+	doc := &DidDocument{
+		Id:        did,
+		Attribute: []*DidAttribute{{Key: did + "#name", Type: "string", Value: "bob"}},
+	}
+	history := &didHistory{
+		did:           did,
+		createdHeight: 10,
+		registration:  "regIDWithPublicKey",
+		events: []*didEvent{
+			{Height: 10, States: []interface{}{"Register", did}},
+			{Height: 20, States: []interface{}{"Attribute", "add", did, []interface{}{name}}},
+			{Height: 30, States: []interface{}{"Attribute", "add", did, []interface{}{name}}},
+		},
+	}
+	// the value before the overwrite at height 30 is not in the events
+	_, _, _, err := history.rewind(doc, 25)
+	assert.NotNil(t, err)
+
+	version, _, _, err := history.rewind(doc, 15)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(version.Attribute))
+
+	// attributes given at registration are unknown
+	history.registration = "regIDWithAttributes"
+	_, _, _, err = history.rewind(doc, 15)
+	assert.NotNil(t, err)
+}
+
+func TestDidRegistration(t *testing.T) {
+	did := "did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa"
+	acc := account.NewAccount("")
+	pub := keypair.SerializePublicKey(acc.PublicKey)
+	registration := func(contract common.Address, method string, params []interface{}) string {
+		mutable, err := NewNativeInvokeTransaction(0, 0, contract, 0, method, params)
+		assert.Nil(t, err)
+		tx, err := mutable.IntoImmutable()
+		assert.Nil(t, err)
+		return didRegistration(tx, did)
+	}
+
+	assert.Equal(t, "regIDWithPublicKey", registration(utils.OntIDContractAddress, "regIDWithPublicKey",
+		[]interface{}{[]interface{}{[]byte(did), pub}}))
+	// the method name in params is not the invoked method
+	assert.Equal(t, "", registration(utils.OntIDContractAddress, "addAttributes",
+		[]interface{}{[]interface{}{[]byte(did), []byte("regIDWithPublicKey")}}))
+	assert.Equal(t, "", registration(utils.OntIDContractAddress, "regIDWithPublicKey",
+		[]interface{}{[]interface{}{[]byte("did:ont:AMAx993nE6NEqZjwBssUfopxnnvTdob9ij"), pub}}))
+	assert.Equal(t, "", registration(utils.OntContractAddress, "regIDWithPublicKey",
+		[]interface{}{[]interface{}{[]byte(did), pub}}))
+}
@@ -19,8 +19,11 @@
 package rest
 
 import (
+	"encoding/json"
 	"strconv"
+	"strings"
 
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
@@ -247,6 +250,51 @@ func GetTransactionByHash(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//decode the contract call of transaction by hash or of raw transaction, with the optional NeoVM abi and Solidity abi
+func DecodeTransaction(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+
+	str, ok := cmd["Data"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	var neovmAbi, ethAbi []byte
+	var err error
+	if cmd["NeovmAbi"] != nil {
+		if neovmAbi, err = json.Marshal(cmd["NeovmAbi"]); err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	if cmd["EthAbi"] != nil {
+		if ethAbi, err = json.Marshal(cmd["EthAbi"]); err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	abis, err := cliutil.NewTxDecodeAbi(neovmAbi, ethAbi)
+	if err != nil {
+		resp = ResponsePack(berr.INVALID_PARAMS)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	var txInfo *cliutil.TxInfo
+	if hash, e := common.Uint256FromHexString(str); e == nil {
+		tx, e := bactor.GetTransaction(hash)
+		if e != nil || tx == nil {
+			return ResponsePack(berr.UNKNOWN_TRANSACTION)
+		}
+		txInfo, err = cliutil.DecodeTransaction(tx, abis)
+	} else {
+		txInfo, err = cliutil.DecodeRawTransaction(str, abis)
+	}
+	if err != nil {
+		resp = ResponsePack(berr.INVALID_TRANSACTION)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = txInfo
+	return resp
+}
+
 //send raw transaction
 func SendRawTransaction(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -291,6 +339,14 @@ func SendRawTransaction(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//simulate a bundle of raw transactions sequentially without broadcasting
+func SimulateBundle(cmd map[string]interface{}) map[string]interface{} {
+	errCode, result := bcomn.SimulateBundle(cmd["Data"])
+	resp := ResponsePack(errCode)
+	resp["Result"] = result
+	return resp
+}
+
 //get smartcontract event by height
 func GetSmartCodeEventTxsByHeight(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -602,6 +658,30 @@ func GetGrantOng(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+// get governance staking info
+func GetStakingInfo(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	addrStr, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	addr, err := bcomn.GetAddress(addrStr)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	var peers []string
+	if str, ok := cmd["Peers"].(string); ok && str != "" {
+		peers = strings.Split(str, ",")
+	}
+	rsp, err := bcomn.GetStakingInfo(addr, peers)
+	if err != nil {
+		log.Errorf("GetStakingInfo error:%s", err)
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
 //get memory pool transaction count
 func GetMemPoolTxCount(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -82,8 +82,16 @@ func (api *EthereumAPI) BlockNumber() (hexutil.Uint64, error) {
 	return hexutil.Uint64(height), nil
 }
 
-func (api *EthereumAPI) GetBalance(address common.Address, _ rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+func (api *EthereumAPI) GetBalance(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
 	log.Debugf("eth_getBalance address %v", address.Hex())
+	if hash, ok := blockNrOrHash.Hash(); ok && oComm.Uint256(hash) != bactor.CurrentBlockHash() {
+		return nil, fmt.Errorf("states of block %s are not available", hash.Hex())
+	}
+	if number, ok := blockNrOrHash.Number(); ok && number > 0 {
+		if err := checkStateBlock(types2.BlockNumber(number)); err != nil {
+			return nil, err
+		}
+	}
 	balance, err := getOngBalance(address)
 	if err != nil {
 		return (*hexutil.Big)(big.NewInt(0)), err
@@ -142,13 +150,28 @@ func (api *EthereumAPI) Accounts() ([]common.Address, error) {
 	return nil, fmt.Errorf("eth_accounts is not supported")
 }
 
+// checkStateBlock returns error if the states are read at a history block, since only the states of
+// current block are kept. "latest", "earliest" and "pending" are treated as current block
+func checkStateBlock(blockNum types2.BlockNumber) error {
+	if blockNum > types2.EarliestBlockNumber && uint32(blockNum) != bactor.GetCurrentBlockHeight() {
+		return fmt.Errorf("states of block %d are not available", blockNum)
+	}
+	return nil
+}
+
 func (api *EthereumAPI) GetStorageAt(address common.Address, key string, blockNum types2.BlockNumber) (hexutil.Bytes, error) {
 	log.Debugf("eth_getStorageAt address %v, key %s, blockNum %v", address.Hex(), key, blockNum)
+	if err := checkStateBlock(blockNum); err != nil {
+		return nil, err
+	}
 	return bactor.GetEthStorage(address, common.HexToHash(key))
 }
 
 func (api *EthereumAPI) GetTransactionCount(address common.Address, blockNum types2.BlockNumber) (*hexutil.Uint64, error) {
 	log.Debugf("eth_getTransactionCount address %v, blockNum %v", address.Hex(), blockNum)
+	if err := checkStateBlock(blockNum); err != nil {
+		return nil, err
+	}
 	addr := utils2.EthToOntAddr(address)
 	if nonce := api.txpool.Nonce(addr); blockNum.IsPending() && nonce != 0 {
 		n := hexutil.Uint64(nonce)
@@ -198,6 +221,9 @@ func (api *EthereumAPI) GetUncleCountByBlockNumber(number int64) hexutil.Uint {
 
 func (api *EthereumAPI) GetCode(address common.Address, blockNumber types2.BlockNumber) (hexutil.Bytes, error) {
 	log.Debugf("eth_getCode address %s, blockNumber %v", address.Hex(), blockNumber)
+	if err := checkStateBlock(blockNumber); err != nil {
+		return nil, err
+	}
 	account, err := bactor.GetEthAccount(address)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package evm
+
+import (
+	"github.com/qbyyf/go-ethereum/common/hexutil"
+)
+
+// Chain is a local development chain which can be mined on request and reverted to snapshots
+type Chain interface {
+	Snapshot() (uint64, error)
+	Revert(id uint64) (bool, error)
+	Mine() error
+}
+
+// PublicEvmAPI is the evm_ prefixed set of development APIs, compatible with Hardhat and Ganache.
+type PublicEvmAPI struct {
+	chain Chain
+}
+
+// NewAPI creates an instance of the evm API on chain.
+func NewAPI(chain Chain) *PublicEvmAPI {
+	return &PublicEvmAPI{chain: chain}
+}
+
+// Snapshot saves the current chain state, and returns the id to revert to.
+func (api *PublicEvmAPI) Snapshot() (hexutil.Uint64, error) {
+	id, err := api.chain.Snapshot()
+	return hexutil.Uint64(id), err
+}
+
+// Revert reverts the chain state to the snapshot id. The snapshot and all snapshots
+// taken after it are discarded, and false is returned if the snapshot does not exist.
+func (api *PublicEvmAPI) Revert(id hexutil.Uint64) (bool, error) {
+	return api.chain.Revert(uint64(id))
+}
+
+// Mine generates a block immediately, even there is no pending transaction.
+func (api *PublicEvmAPI) Mine() (string, error) {
+	err := api.chain.Mine()
+	if err != nil {
+		return "", err
+	}
+	return "0x0", nil
+}
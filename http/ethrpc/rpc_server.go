@@ -31,7 +31,8 @@ import (
 	tp "github.com/qbyyf/ontology/txnpool/proc"
 )
 
-func StartEthServer(txpool *tp.TXPoolServer) error {
+// StartEthServer starts the ethereum compatible rpc server, apis are registered in addition to eth, net and web3
+func StartEthServer(txpool *tp.TXPoolServer, apis ...rpc.API) error {
 	log.Root().SetHandler(utils.OntLogHandler())
 	ethAPI := eth.NewEthereumAPI(txpool)
 	server := rpc.NewServer()
@@ -49,6 +50,12 @@ func StartEthServer(txpool *tp.TXPoolServer) error {
 	if err != nil {
 		return err
 	}
+	for _, api := range apis {
+		err = server.RegisterName(api.Namespace, api.Service)
+		if err != nil {
+			return err
+		}
+	}
 	err = http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.EthJsonPort)), server)
 	if err != nil {
 		return err
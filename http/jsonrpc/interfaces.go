@@ -20,7 +20,9 @@ package jsonrpc
 
 import (
 	"encoding/hex"
+	"encoding/json"
 
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
@@ -212,6 +214,49 @@ func GetRawTransaction(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(common.ToHexString(common.SerializeToBytes(tx)))
 }
 
+// decode the contract call of transaction by hash, or of raw ontology or EIP155 transaction in hex,
+// params of NeoVM and EVM contract are decoded by the optional NeoVM abi and Solidity abi
+// A JSON example for decodetransaction method as following:
+//   {"jsonrpc": "2.0", "method": "decodetransaction", "params": ["transaction hash or raw transaction in hex", {neovm abi}, [solidity abi]], "id": 0}
+func DecodeTransaction(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	abiData := make([][]byte, 2)
+	for i := 1; i < len(params) && i <= len(abiData); i++ {
+		if params[i] == nil {
+			continue
+		}
+		data, err := json.Marshal(params[i])
+		if err != nil {
+			return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+		}
+		abiData[i-1] = data
+	}
+	abis, err := cliutil.NewTxDecodeAbi(abiData[0], abiData[1])
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	var txInfo *cliutil.TxInfo
+	if hash, e := common.Uint256FromHexString(str); e == nil {
+		tx, e := bactor.GetTransaction(hash)
+		if e != nil || tx == nil {
+			return rpc.ResponsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
+		}
+		txInfo, err = cliutil.DecodeTransaction(tx, abis)
+	} else {
+		txInfo, err = cliutil.DecodeRawTransaction(str, abis)
+	}
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_TRANSACTION, err.Error())
+	}
+	return rpc.ResponseSuccess(txInfo)
+}
+
 //get storage from contract
 //   {"jsonrpc": "2.0", "method": "getstorage", "params": ["code hash", "key"], "id": 0}
 func GetStorage(params []interface{}) map[string]interface{} {
@@ -254,6 +299,44 @@ func GetStorage(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(common.ToHexString(value))
 }
 
+//get serialized storage item from contract, which contains the state version of value.
+//the optional block hash requires the states to be read at that block, only current block is supported
+//   {"jsonrpc": "2.0", "method": "getrawstorage", "params": ["code hash", "key", "block hash"], "id": 0}
+func GetRawStorage(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok = params[1].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	key, err := hex.DecodeString(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	if len(params) >= 3 {
+		if errCode := checkStateBlock(params[2]); errCode != berr.SUCCESS {
+			return rpc.ResponsePack(errCode, "")
+		}
+	}
+	item, err := bactor.GetStorageState(address, key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return rpc.ResponseSuccess(nil)
+		}
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	return rpc.ResponseSuccess(common.ToHexString(item.ToArray()))
+}
+
 //send raw transaction
 // A JSON example for sendrawtransaction method as following:
 //   {"jsonrpc": "2.0", "method": "sendrawtransaction", "params": ["raw transactioin in hex"], "id": 0}
@@ -309,11 +392,34 @@ func GetNetworkId(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(config.DefConfig.P2PNode.NetworkId)
 }
 
+//checkStateBlock checks the states are read at current block, since the states of history blocks are not kept
+func checkStateBlock(param interface{}) int64 {
+	str, ok := param.(string)
+	if !ok {
+		return berr.INVALID_PARAMS
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return berr.INVALID_PARAMS
+	}
+	if hash != bactor.CurrentBlockHash() {
+		return berr.UNKNOWN_BLOCK
+	}
+	return berr.SUCCESS
+}
+
 //get contract state
+//the optional block hash requires the state to be read at that block, only current block is supported
+//   {"jsonrpc": "2.0", "method": "getcontractstate", "params": ["contract address", 0, "block hash"], "id": 0}
 func GetContractState(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
 		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
 	}
+	if len(params) >= 3 {
+		if errCode := checkStateBlock(params[2]); errCode != berr.SUCCESS {
+			return rpc.ResponsePack(errCode, "")
+		}
+	}
 	var contract *payload.DeployCode
 	switch params[0].(type) {
 	case string:
@@ -672,6 +778,41 @@ func GetGrantOng(params []interface{}) map[string]interface{} {
 	return rpc.ResponseSuccess(rsp)
 }
 
+// get governance staking info of address
+func GetStakingInfo(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	addr, err := common.AddressFromBase58(str)
+	if err != nil {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+	}
+	var peers []string
+	if len(params) >= 2 {
+		list, ok := params[1].([]interface{})
+		if !ok {
+			return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+		}
+		for _, v := range list {
+			peer, ok := v.(string)
+			if !ok {
+				return rpc.ResponsePack(berr.INVALID_PARAMS, "")
+			}
+			peers = append(peers, peer)
+		}
+	}
+	rsp, err := bcomn.GetStakingInfo(addr, peers)
+	if err != nil {
+		log.Errorf("GetStakingInfo error:%s", err)
+		return rpc.ResponsePack(berr.INTERNAL_ERROR, "")
+	}
+	return rpc.ResponseSuccess(rsp)
+}
+
 //get cross chain message by height
 func GetCrossChainMsg(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
@@ -718,3 +859,15 @@ func GetCrossStatesProof(params []interface{}) map[string]interface{} {
 	}
 	return rpc.ResponseSuccess(bcomn.CrossStatesProof{"CrossStatesProof", hex.EncodeToString(proof)})
 }
+
+// simulate a bundle of raw transactions sequentially on one overlay without broadcasting,
+// ethereum transactions can also be in RLP encoding
+// Input JSON string examples for simulatebundle method as following:
+//   {"jsonrpc": "2.0", "method": "simulatebundle", "params": [["00d1...", "f86b..."]], "id": 0}
+func SimulateBundle(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return rpc.ResponsePack(berr.INVALID_PARAMS, nil)
+	}
+	errCode, result := bcomn.SimulateBundle(params[0])
+	return rpc.ResponsePack(errCode, result)
+}
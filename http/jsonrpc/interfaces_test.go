@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package jsonrpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	cliutil "github.com/qbyyf/ontology/cmd/utils"
+	"github.com/qbyyf/ontology/common"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	berr "github.com/qbyyf/ontology/http/base/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTransaction(t *testing.T) {
+	contract, err := common.AddressFromHexString("e827bf96529b5780ad0702757b8bad315e2bb8ce")
+	assert.Nil(t, err)
+	code, err := cutils.BuildNeoVMInvokeCode(contract, []interface{}{"transfer", []interface{}{[]byte{1, 2}, 100, "hi"}})
+	assert.Nil(t, err)
+	tx, err := cliutil.NewInvokeTransaction(500, 20000, code).IntoImmutable()
+	assert.Nil(t, err)
+	rawTx := common.ToHexString(tx.Raw)
+
+	var neovmAbi map[string]interface{}
+	err = json.Unmarshal([]byte(`{
+  "hash": "0xe827bf96529b5780ad0702757b8bad315e2bb8ce",
+  "functions": [
+    {
+      "name": "transfer",
+      "parameters": [
+        {"name": "from", "type": "ByteArray"},
+        {"name": "amount", "type": "Integer"},
+        {"name": "memo", "type": "String"}
+      ]
+    }
+  ]
+}`), &neovmAbi)
+	assert.Nil(t, err)
+
+	resp := DecodeTransaction([]interface{}{rawTx, neovmAbi})
+	assert.Equal(t, berr.SUCCESS, resp["error"])
+	info := resp["result"].(*cliutil.TxInfo)
+	assert.Equal(t, tx.Hash().ToHexString(), info.TxHash)
+	assert.Equal(t, "transfer", info.Invoke.Method)
+	assert.True(t, info.Invoke.ByAbi)
+	assert.Equal(t, "hi", info.Invoke.Params[2].Value)
+
+	resp = DecodeTransaction([]interface{}{rawTx})
+	assert.Equal(t, berr.SUCCESS, resp["error"])
+	assert.False(t, resp["result"].(*cliutil.TxInfo).Invoke.ByAbi)
+
+	resp = DecodeTransaction([]interface{}{rawTx, nil, "not abi"})
+	assert.Equal(t, berr.INVALID_PARAMS, resp["error"])
+	resp = DecodeTransaction([]interface{}{"00d1"})
+	assert.Equal(t, berr.INVALID_TRANSACTION, resp["error"])
+}
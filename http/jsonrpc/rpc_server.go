@@ -41,8 +41,11 @@ func StartRPCServer() error {
 	//HandleFunc("getrawmempool", GetRawMemPool)
 
 	rpc.HandleFunc("getrawtransaction", GetRawTransaction)
+	rpc.HandleFunc("decodetransaction", DecodeTransaction)
 	rpc.HandleFunc("sendrawtransaction", SendRawTransaction)
+	rpc.HandleFunc("simulatebundle", SimulateBundle)
 	rpc.HandleFunc("getstorage", GetStorage)
+	rpc.HandleFunc("getrawstorage", GetRawStorage)
 	rpc.HandleFunc("getversion", GetNodeVersion)
 	rpc.HandleFunc("getnetworkid", GetNetworkId)
 
@@ -63,6 +66,7 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getgasprice", GetGasPrice)
 	rpc.HandleFunc("getunboundong", GetUnboundOng)
 	rpc.HandleFunc("getgrantong", GetGrantOng)
+	rpc.HandleFunc("getstakinginfo", GetStakingInfo)
 
 	rpc.HandleFunc("getcrosschainmsg", GetCrossChainMsg)
 	rpc.HandleFunc("getcrossstatesproof", GetCrossStatesProof)
@@ -26,6 +26,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -76,13 +77,18 @@ const (
 	GET_ALLOWANCE_V2      = "/api/v1/allowancev2/:asset/:from/:to"
 	GET_UNBOUNDONG        = "/api/v1/unboundong/:addr"
 	GET_GRANTONG          = "/api/v1/grantong/:addr"
+	GET_STAKING_INFO      = "/api/v1/stakinginfo/:addr"
 	GET_MEMPOOL_TXCOUNT   = "/api/v1/mempool/txcount"
 	GET_MEMPOOL_TXSTATE   = "/api/v1/mempool/txstate/:hash"
 	GET_MEMPOOL_TXHASHS   = "/api/v1/mempool/txhashlist"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
 
-	POST_RAW_TX = "/api/v1/transaction"
+	POST_RAW_TX          = "/api/v1/transaction"
+	POST_SIMULATE_BUNDLE = "/api/v1/simulatebundle"
+	POST_DECODE_TX       = "/api/v1/decodetransaction"
+
+	GET_DID_RESOLUTION = "/1.0/identifiers/"
 )
 
 //init restful server
@@ -93,6 +99,7 @@ func InitRestServer() rest.ApiServer {
 	rt.registryMethod()
 	rt.initGetHandler()
 	rt.initPostHandler()
+	rt.initDidHandler()
 	return rt
 }
 
@@ -173,6 +180,7 @@ func (this *restServer) registryMethod() {
 		GET_GAS_PRICE:         {name: "getgasprice", handler: rest.GetGasPrice},
 		GET_UNBOUNDONG:        {name: "getunboundong", handler: rest.GetUnboundOng},
 		GET_GRANTONG:          {name: "getgrantong", handler: rest.GetGrantOng},
+		GET_STAKING_INFO:      {name: "getstakinginfo", handler: rest.GetStakingInfo},
 		GET_MEMPOOL_TXCOUNT:   {name: "getmempooltxcount", handler: rest.GetMemPoolTxCount},
 		GET_MEMPOOL_TXSTATE:   {name: "getmempooltxstate", handler: rest.GetMemPoolTxState},
 		GET_MEMPOOL_TXHASHS:   {name: "getmempooltxhashlist", handler: rest.GetMemPoolTxHashList},
@@ -181,7 +189,9 @@ func (this *restServer) registryMethod() {
 	}
 
 	postMethodMap := map[string]Action{
-		POST_RAW_TX: {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_RAW_TX:          {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_SIMULATE_BUNDLE: {name: "simulatebundle", handler: rest.SimulateBundle},
+		POST_DECODE_TX:       {name: "decodetransaction", handler: rest.DecodeTransaction},
 	}
 	this.postMap = postMethodMap
 	this.getMap = getMethodMap
@@ -223,6 +233,8 @@ func (this *restServer) getPath(url string) string {
 		return GET_UNBOUNDONG
 	} else if strings.Contains(url, strings.TrimRight(GET_GRANTONG, ":addr")) {
 		return GET_GRANTONG
+	} else if strings.Contains(url, strings.TrimRight(GET_STAKING_INFO, ":addr")) {
+		return GET_STAKING_INFO
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
 	}
@@ -267,6 +279,8 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Addr"] = getParam(r, "addr")
 	case GET_GRANTONG:
 		req["Addr"] = getParam(r, "addr")
+	case GET_STAKING_INFO:
+		req["Addr"], req["Peers"] = getParam(r, "addr"), r.FormValue("peers")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
 	default:
@@ -329,6 +343,39 @@ func (this *restServer) initPostHandler() {
 	}
 
 }
+// initDidHandler serves W3C DID resolution, outside the api envelope
+func (this *restServer) initDidHandler() {
+	this.router.Get(regexp.QuoteMeta(GET_DID_RESOLUTION)+"[^/]+", func(w http.ResponseWriter, r *http.Request) {
+		did := strings.TrimPrefix(r.URL.Path, GET_DID_RESOLUTION)
+		result := common.ResolveDid(did, &common.DidResolutionOptions{
+			VersionId:   r.FormValue("versionId"),
+			VersionTime: r.FormValue("versionTime"),
+		})
+		status := http.StatusOK
+		switch {
+		case result.DidDocumentMetadata.Deactivated:
+			status = http.StatusGone
+		case result.DidResolutionMetadata.Error == common.DID_ERR_INVALID_DID,
+			result.DidResolutionMetadata.Error == common.DID_ERR_INVALID_OPTIONS:
+			status = http.StatusBadRequest
+		case result.DidResolutionMetadata.Error == common.DID_ERR_NOT_FOUND:
+			status = http.StatusNotFound
+		case result.DidResolutionMetadata.Error != "":
+			status = http.StatusInternalServerError
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			log.Errorf("HTTP Handle - json.Marshal: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("content-type", `application/ld+json;profile="https://w3id.org/did-resolution"`)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(status)
+		w.Write(data)
+	})
+}
+
 func (this *restServer) write(w http.ResponseWriter, data []byte) {
 	w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
 	w.Header().Set("content-type", "application/json;charset=utf-8")
@@ -201,6 +201,7 @@ func (self *WsServer) registryMethod() {
 		"getgasprice":               {handler: rest.GetGasPrice},
 		"getunboundong":             {handler: rest.GetUnboundOng},
 		"getgrantong":               {handler: rest.GetGrantOng},
+		"getstakinginfo":            {handler: rest.GetStakingInfo},
 		"getmempooltxcount":         {handler: rest.GetMemPoolTxCount},
 		"getmempooltxstate":         {handler: rest.GetMemPoolTxState},
 		"getmempooltxhashlist":      {handler: rest.GetMemPoolTxHashList},
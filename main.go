@@ -30,9 +30,9 @@ import (
 	"time"
 
 	"github.com/qbyyf/go-ethereum/common/fdlimit"
+	"github.com/qbyyf/go-ethereum/rpc"
 	"github.com/ontio/ontology-crypto/keypair"
 	alog "github.com/ontio/ontology-eventbus/log"
-	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/cmd"
 	cmdcom "github.com/qbyyf/ontology/cmd/common"
 	"github.com/qbyyf/ontology/cmd/utils"
@@ -42,6 +42,7 @@ import (
 	"github.com/qbyyf/ontology/consensus"
 	"github.com/qbyyf/ontology/core/genesis"
 	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/qbyyf/ontology/events"
 	bactor "github.com/qbyyf/ontology/http/base/actor"
@@ -73,12 +74,19 @@ func setupAPP() *cli.App {
 		cmd.ContractCommand,
 		cmd.ImportCommand,
 		cmd.ExportCommand,
+		cmd.DbCommand,
 		cmd.TxCommond,
 		cmd.SigTxCommand,
 		cmd.MultiSigAddrCommand,
 		cmd.MultiSigTxCommand,
+		cmd.MultiSigCommand,
 		cmd.SendTxCommand,
 		cmd.ShowTxCommand,
+		cmd.DecodeTxCommand,
+		cmd.GovernanceCommand,
+		cmd.OntIdCommand,
+		cmd.EthCommand,
+		devnetCommand,
 	}
 	app.Flags = []cli.Flag{
 		//common setting
@@ -97,6 +105,12 @@ func setupAPP() *cli.App {
 		//consensus setting
 		utils.EnableConsensusFlag,
 		utils.MaxTxInBlockFlag,
+		utils.RemoteSignerFlag,
+		utils.RemoteSignerKeyFlag,
+		utils.RemoteSignerTokenFlag,
+		utils.RemoteSignerCAFlag,
+		utils.RemoteSignerCertFlag,
+		utils.RemoteSignerCertKeyFlag,
 		//txpool setting
 		utils.GasPriceFlag,
 		utils.GasLimitFlag,
@@ -116,6 +130,10 @@ func setupAPP() *cli.App {
 		//test mode setting
 		utils.EnableTestModeFlag,
 		utils.TestModeGenBlockTimeFlag,
+		utils.ForkRpcFlag,
+		utils.ForkEthRpcFlag,
+		utils.ForkBlockFlag,
+		utils.ForkImpersonateFlag,
 		//rpc setting
 		utils.RPCDisabledFlag,
 		utils.RPCPortFlag,
@@ -238,10 +256,23 @@ func initConfig(ctx *cli.Context) (*config.OntologyConfig, error) {
 	return cfg, nil
 }
 
-func initAccount(ctx *cli.Context) (*account.Account, error) {
+func initAccount(ctx *cli.Context) (signature.VrfSigner, error) {
 	if !config.DefConfig.Consensus.EnableConsensus {
 		return nil, nil
 	}
+	signerUrl := ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerFlag))
+	if signerUrl != "" {
+		signer, err := cmdcom.NewRemoteSigner(signerUrl, ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerKeyFlag)),
+			ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerTokenFlag)),
+			ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerCAFlag)),
+			ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerCertFlag)),
+			ctx.GlobalString(utils.GetFlagName(utils.RemoteSignerCertKeyFlag)))
+		if err != nil {
+			return nil, err
+		}
+		initBookkeeper(signer)
+		return signer, nil
+	}
 	walletFile := ctx.GlobalString(utils.GetFlagName(utils.WalletFileFlag))
 	if walletFile == "" {
 		return nil, fmt.Errorf("please config wallet file using --wallet flag")
@@ -254,15 +285,20 @@ func initAccount(ctx *cli.Context) (*account.Account, error) {
 	if err != nil {
 		return nil, fmt.Errorf("get account error: %s", err)
 	}
-	pubKey := hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey))
-	log.Infof("Using account: %s, pubkey: %s", acc.Address.ToBase58(), pubKey)
+	initBookkeeper(acc)
+	return acc, nil
+}
+
+func initBookkeeper(signer signature.VrfSigner) {
+	pubKey := hex.EncodeToString(keypair.SerializePublicKey(signer.PubKey()))
+	address := types.AddressFromPubKey(signer.PubKey())
+	log.Infof("Using account: %s, pubkey: %s", address.ToBase58(), pubKey)
 
 	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
 		config.DefConfig.Genesis.SOLO.Bookkeepers = []string{pubKey}
 	}
 
 	log.Infof("Account init success")
-	return acc, nil
 }
 
 func initLedger(ctx *cli.Context, stateHashHeight uint32) (*ledger.Ledger, error) {
@@ -279,7 +315,11 @@ func initLedger(ctx *cli.Context, stateHashHeight uint32) (*ledger.Ledger, error
 	if err != nil {
 		return nil, fmt.Errorf("genesisBlock error %s", err)
 	}
-	ledger.DefLedger, err = ledger.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock)
+	if ctx.String(utils.GetFlagName(utils.ForkRpcFlag)) != "" {
+		ledger.DefLedger, err = initForkLedger(ctx, dbDir, stateHashHeight, bookKeepers, genesisBlock)
+	} else {
+		ledger.DefLedger, err = ledger.InitLedger(dbDir, stateHashHeight, bookKeepers, genesisBlock)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("NewLedger error: %s", err)
 	}
@@ -304,7 +344,7 @@ func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	return txPoolServer, nil
 }
 
-func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acct *account.Account) (*p2pserver.P2PServer, p2p.P2P, error) {
+func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acct signature.VrfSigner) (*p2pserver.P2PServer, p2p.P2P, error) {
 	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
 		return nil, nil, nil
 	}
@@ -324,7 +364,7 @@ func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acct *account.A
 	return p2p, p2p.GetNetwork(), nil
 }
 
-func initConsensus(ctx *cli.Context, net p2p.P2P, txpoolSvr *proc.TXPoolServer, acc *account.Account) (consensus.ConsensusService, error) {
+func initConsensus(ctx *cli.Context, net p2p.P2P, txpoolSvr *proc.TXPoolServer, acc signature.VrfSigner) (consensus.ConsensusService, error) {
 	if !config.DefConfig.Consensus.EnableConsensus {
 		return nil, nil
 	}
@@ -368,7 +408,7 @@ func initRpc(ctx *cli.Context) error {
 	return nil
 }
 
-func initETHRpc(txpool *proc.TXPoolServer) error {
+func initETHRpc(txpool *proc.TXPoolServer, apis ...rpc.API) error {
 	if !config.DefConfig.Rpc.EnableHttpJsonRpc {
 		return nil
 	}
@@ -376,7 +416,7 @@ func initETHRpc(txpool *proc.TXPoolServer) error {
 	var err error
 	exitCh := make(chan interface{}, 0)
 	go func() {
-		err = ethrpc.StartEthServer(txpool)
+		err = ethrpc.StartEthServer(txpool, apis...)
 		close(exitCh)
 	}()
 
@@ -23,7 +23,6 @@ import (
 	"errors"
 	"math"
 
-	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/common"
 	vconfig "github.com/qbyyf/ontology/consensus/vbft/config"
 	"github.com/qbyyf/ontology/core/signature"
@@ -140,9 +139,9 @@ func (self *OfflineWitnessMsg) Hash() common.Uint256 {
 	return hash
 }
 
-func (self *OfflineWitnessMsg) AddProposeSig(acct *account.Account) error {
+func (self *OfflineWitnessMsg) AddProposeSig(acct signature.DataSigner) error {
 	hash := self.Hash()
-	sig, err := signature.Sign(acct, hash[:])
+	sig, err := acct.Sign(hash[:])
 	if err != nil {
 		return err
 	}
@@ -151,16 +150,16 @@ func (self *OfflineWitnessMsg) AddProposeSig(acct *account.Account) error {
 	return nil
 }
 
-func (self *OfflineWitnessMsg) VoteFor(acct *account.Account, index []uint8) error {
+func (self *OfflineWitnessMsg) VoteFor(acct signature.DataSigner, index []uint8) error {
 	sink := common.NewZeroCopySink(nil)
 	self.serializeUnsigned(sink)
 	sink.WriteVarBytes(index)
 	hash := common.Uint256(sha256.Sum256(sink.Bytes()))
-	sig, err := signature.Sign(acct, hash[:])
+	sig, err := acct.Sign(hash[:])
 	if err != nil {
 		return err
 	}
-	pubkey := vconfig.PubkeyID(acct.PublicKey)
+	pubkey := vconfig.PubkeyID(acct.PubKey())
 	self.Voters = append(self.Voters, VoterMsg{OfflineIndex: index, PubKey: pubkey, Sig: sig})
 
 	return nil
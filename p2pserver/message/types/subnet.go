@@ -24,7 +24,6 @@ import (
 	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
-	"github.com/qbyyf/ontology/account"
 	comm "github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/p2pserver/common"
@@ -69,15 +68,15 @@ func (self *SubnetMembersRequest) Role() string {
 	return "gov"
 }
 
-func NewMembersRequest(from, to common.PeerId, acc *account.Account) (*SubnetMembersRequest, error) {
+func NewMembersRequest(from, to common.PeerId, acc signature.DataSigner) (*SubnetMembersRequest, error) {
 	request := &SubnetMembersRequest{
 		From:      from,
 		To:        to,
 		Timestamp: uint32(time.Now().Unix()),
-		PubKey:    acc.PublicKey,
+		PubKey:    acc.PubKey(),
 	}
 
-	sig, err := signature.Sign(acc, request.sigdata())
+	sig, err := acc.Sign(request.sigdata())
 	if err != nil {
 		return nil, err
 	}
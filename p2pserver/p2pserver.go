@@ -22,10 +22,10 @@ import (
 	"strings"
 	"time"
 
-	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
 	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/p2pserver/common"
 	"github.com/qbyyf/ontology/p2pserver/connect_controller"
 	"github.com/qbyyf/ontology/p2pserver/net/netserver"
@@ -42,7 +42,7 @@ type P2PServer struct {
 }
 
 //NewServer return a new p2pserver according to the pubkey
-func NewServer(acct *account.Account, txpool common2.TxPoolService) (*P2PServer, error) {
+func NewServer(acct signature.DataSigner, txpool common2.TxPoolService) (*P2PServer, error) {
 	db := ledger.DefLedger
 	var rsv []string
 	var recRsv []string
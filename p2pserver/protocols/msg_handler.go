@@ -23,11 +23,11 @@ import (
 	"fmt"
 
 	lru "github.com/hashicorp/golang-lru"
-	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
 	"github.com/qbyyf/ontology/core/ledger"
+	"github.com/qbyyf/ontology/core/signature"
 	"github.com/qbyyf/ontology/core/types"
 	actor "github.com/qbyyf/ontology/p2pserver/actor/req"
 	msgCommon "github.com/qbyyf/ontology/p2pserver/common"
@@ -62,12 +62,12 @@ type MsgHandler struct {
 	persistRecentPeerService *recent_peers.PersistRecentPeerService
 	subnet                   *subnet.SubNet
 	ledger                   *ledger.Ledger
-	acct                     *account.Account // nil if conenesus is not enabled
+	acct                     signature.DataSigner // nil if conenesus is not enabled
 	staticReserveFilter      p2p.AddressFilter
 	txPoolService            common2.TxPoolService
 }
 
-func NewMsgHandler(acct *account.Account, staticReserveFilter p2p.AddressFilter, ld *ledger.Ledger,
+func NewMsgHandler(acct signature.DataSigner, staticReserveFilter p2p.AddressFilter, ld *ledger.Ledger,
 	txPool common2.TxPoolService, logger msgCommon.Logger) *MsgHandler {
 	gov := utils.NewGovNodeResolver(ld)
 	seedsList := config.DefConfig.Genesis.SeedList
@@ -32,7 +32,16 @@ const (
 
 // PushSmartCodeEvent push event content to socket.io
 func PushSmartCodeEvent(txHash common.Uint256, errcode int64, action string, result interface{}) {
-	if events.DefActorPublisher == nil {
+	PublishSmartCodeEvent(nil, txHash, errcode, action, result)
+}
+
+// PublishSmartCodeEvent push event content by publisher, events.DefActorPublisher is used if publisher is nil
+func PublishSmartCodeEvent(publisher *events.ActorPublisher, txHash common.Uint256, errcode int64, action string,
+	result interface{}) {
+	if publisher == nil {
+		publisher = events.DefActorPublisher
+	}
+	if publisher == nil {
 		return
 	}
 	smartCodeEvt := &types.SmartCodeEvent{
@@ -41,5 +50,5 @@ func PushSmartCodeEvent(txHash common.Uint256, errcode int64, action string, res
 		Result: result,
 		Error:  errcode,
 	}
-	events.DefActorPublisher.Publish(message.TOPIC_SMART_CODE_EVENT, &message.SmartCodeEventMsg{Event: smartCodeEvt})
+	publisher.Publish(message.TOPIC_SMART_CODE_EVENT, &message.SmartCodeEventMsg{Event: smartCodeEvt})
 }
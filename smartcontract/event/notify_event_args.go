@@ -51,6 +51,12 @@ type ExecuteNotify struct {
 	GasStepUsed     uint64
 	TxIndex         uint32
 	CreatedContract common.Address
+
+	// storage resource pricing, gas fields are in gas units
+	StorageAdded  uint64 `json:",omitempty"`
+	StorageFreed  uint64 `json:",omitempty"`
+	StorageFee    uint64 `json:",omitempty"`
+	StorageRefund uint64 `json:",omitempty"`
 }
 
 func ExecuteNotifyFromEthReceipt(receipt *types.Receipt) *ExecuteNotify {
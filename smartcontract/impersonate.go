@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package smartcontract
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+)
+
+var impersonated = struct {
+	sync.RWMutex
+	addrs map[common.Address]bool
+}{addrs: make(map[common.Address]bool)}
+
+// isTestMode returns whether the node runs the single node test network, impersonation is only
+// available in test mode, so the production networks never read the impersonated addresses
+func isTestMode() bool {
+	return config.DefConfig.P2PNode.NetworkId == config.NETWORK_ID_SOLO_NET
+}
+
+// Impersonate makes the address witnessed by every transaction without its signature,
+// it is only used by test nodes, e.g. a local chain forked from main net
+func Impersonate(address common.Address) error {
+	if !isTestMode() {
+		return fmt.Errorf("impersonation is only available in test mode")
+	}
+	impersonated.Lock()
+	defer impersonated.Unlock()
+	impersonated.addrs[address] = true
+	return nil
+}
+
+// StopImpersonating revokes the impersonation of address
+func StopImpersonating(address common.Address) {
+	impersonated.Lock()
+	defer impersonated.Unlock()
+	delete(impersonated.addrs, address)
+}
+
+// IsImpersonated returns whether the address is impersonated, it is always false if not in test mode
+func IsImpersonated(address common.Address) bool {
+	if !isTestMode() {
+		return false
+	}
+	impersonated.RLock()
+	defer impersonated.RUnlock()
+	return impersonated.addrs[address]
+}
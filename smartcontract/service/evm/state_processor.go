@@ -32,7 +32,7 @@ import (
 	"github.com/qbyyf/ontology/vm/evm/params"
 )
 
-func applyTransaction(msg types.Message, statedb *storage.StateDB, blockHeight uint32, tx *types.Transaction, usedGas *uint64, evm *evm.EVM, feeReceiver common.Address) (*types2.ExecutionResult, *otypes.Receipt, error) {
+func applyTransaction(msg types.Message, statedb *storage.StateDB, blockHeight uint32, tx *types.Transaction, usedGas *uint64, evm *evm.EVM, feeReceiver common.Address, storageGas StorageGas) (*types2.ExecutionResult, *otypes.Receipt, error) {
 	// Create a new context to be used in the EVM environment
 	txContext := NewEVMTxContext(msg)
 	// Add addresses to access list if applicable
@@ -52,7 +52,7 @@ func applyTransaction(msg types.Message, statedb *storage.StateDB, blockHeight u
 	// Update the evm with the new transaction context.
 	evm.Reset(txContext, statedb)
 	// Apply the transaction to the current state (included in the env)
-	result, err := ApplyMessage(evm, msg, common2.Address(feeReceiver))
+	result, err := ApplyMessage(evm, msg, common2.Address(feeReceiver), storageGas)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -84,8 +84,9 @@ func applyTransaction(msg types.Message, statedb *storage.StateDB, blockHeight u
 // ApplyTransaction attempts to apply a transaction to the given state database
 // and uses the input parameters for its environment. It returns the receipt
 // for the transaction, gas used and an error if the transaction failed,
-// indicating the block was invalid.
-func ApplyTransaction(config *params.ChainConfig, bc store.LedgerStore, statedb *storage.StateDB, blockHeight, timestamp uint32, tx *types.Transaction, usedGas *uint64, feeReceiver common.Address, cfg evm.Config, checkNonce bool) (*types2.ExecutionResult, *otypes.Receipt, error) {
+// indicating the block was invalid. storageGas prices the storage growth of the transaction, nil if
+// storage pricing is not activated.
+func ApplyTransaction(config *params.ChainConfig, bc store.LedgerStore, statedb *storage.StateDB, blockHeight, timestamp uint32, tx *types.Transaction, usedGas *uint64, feeReceiver common.Address, cfg evm.Config, checkNonce bool, storageGas StorageGas) (*types2.ExecutionResult, *otypes.Receipt, error) {
 	signer := types.NewEIP155Signer(config.ChainID)
 	msg, err := tx.AsMessage(signer)
 	if err != nil {
@@ -97,5 +98,5 @@ func ApplyTransaction(config *params.ChainConfig, bc store.LedgerStore, statedb
 	// Create a new context to be used in the EVM environment
 	blockContext := NewEVMBlockContext(blockHeight, timestamp, bc)
 	vmenv := evm.NewEVM(blockContext, evm.TxContext{}, statedb, config, cfg)
-	return applyTransaction(msg, statedb, blockHeight, tx, usedGas, vmenv, feeReceiver)
+	return applyTransaction(msg, statedb, blockHeight, tx, usedGas, vmenv, feeReceiver, storageGas)
 }
@@ -63,6 +63,10 @@ var (
 	// ErrIntrinsicGas is returned if the transaction is specified to use less gas
 	// than required to start the invocation.
 	ErrIntrinsicGas = errors.New("intrinsic gas too low")
+
+	// ErrStorageGasInsufficient is returned if the gas left after execution can not
+	// pay for the new storage of the transaction.
+	ErrStorageGasInsufficient = errors.New("storage gas insufficient")
 )
 
 /*
@@ -91,10 +95,16 @@ type StateTransition struct {
 	data       []byte
 	state      evm.StateDB
 	evm        *evm.EVM
+	storageGas StorageGas
 
 	GasReceiver common.Address
 }
 
+// StorageGas measures the storage growth of the executed transaction, and returns the gas charged
+// for the new storage and the gas refunded for the freed storage. It is nil if storage pricing is
+// not activated.
+type StorageGas func() (fee, refund uint64, err error)
+
 // Message represents a message sent to a contract.
 type Message interface {
 	From() common.Address
@@ -149,7 +159,7 @@ func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 boo
 }
 
 // NewStateTransition initialises and returns a new state transition object.
-func NewStateTransition(evm *evm.EVM, msg Message, feeReceiver common.Address) *StateTransition {
+func NewStateTransition(evm *evm.EVM, msg Message, feeReceiver common.Address, storageGas StorageGas) *StateTransition {
 	return &StateTransition{
 		evm:         evm,
 		msg:         msg,
@@ -157,6 +167,7 @@ func NewStateTransition(evm *evm.EVM, msg Message, feeReceiver common.Address) *
 		value:       msg.Value(),
 		data:        msg.Data(),
 		state:       evm.StateDB,
+		storageGas:  storageGas,
 		GasReceiver: feeReceiver,
 	}
 }
@@ -167,9 +178,10 @@ func NewStateTransition(evm *evm.EVM, msg Message, feeReceiver common.Address) *
 // ApplyMessage returns the bytes returned by any EVM execution (if it took place),
 // the gas used (which includes gas refunds) and an error if it failed. An error always
 // indicates a core error meaning that the message would always fail for that particular
-// state and would never be accepted within a block.
-func ApplyMessage(evm *evm.EVM, msg Message, feeReceiver common.Address) (*types.ExecutionResult, error) {
-	return NewStateTransition(evm, msg, feeReceiver).TransitionDb()
+// state and would never be accepted within a block. storageGas is nil if storage pricing
+// is not activated.
+func ApplyMessage(evm *evm.EVM, msg Message, feeReceiver common.Address, storageGas StorageGas) (*types.ExecutionResult, error) {
+	return NewStateTransition(evm, msg, feeReceiver, storageGas).TransitionDb()
 }
 
 // to returns the recipient of the message.
@@ -244,8 +256,10 @@ func (st *StateTransition) TransitionDb() (*types.ExecutionResult, error) {
 	contractCreation := msg.To() == nil
 
 	var (
-		ret   []byte
-		vmerr error // vm errors do not effect consensus and are therefore not assigned to err
+		ret           []byte
+		vmerr         error // vm errors do not effect consensus and are therefore not assigned to err
+		storageFee    uint64
+		storageRefund uint64
 	)
 	// Check clauses 4-5, subtract intrinsic gas if everything is correct
 	gas := IntrinsicGas(st.data, contractCreation, homestead, istanbul)
@@ -257,6 +271,11 @@ func (st *StateTransition) TransitionDb() (*types.ExecutionResult, error) {
 		vmerr = fmt.Errorf("%w: address %v", ErrInsufficientFundsForTransfer, msg.From().Hex())
 	}
 	st.gas -= gas
+	nonce := st.state.GetNonce(sender.Address())
+	snapshot := -1
+	if st.storageGas != nil && vmerr == nil {
+		snapshot = st.state.Snapshot()
+	}
 	if vmerr == nil {
 		if contractCreation {
 			ret, _, st.gas, vmerr = st.evm.Create(sender, st.data, st.gas, st.value)
@@ -268,14 +287,37 @@ func (st *StateTransition) TransitionDb() (*types.ExecutionResult, error) {
 	} else {
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 	}
+	if vmerr == nil && st.storageGas != nil {
+		fee, refund, err := st.storageGas()
+		if err != nil {
+			return nil, err
+		}
+		if fee > st.gas {
+			// out of gas for storage, the execution is reverted and all the gas is consumed
+			vmerr = fmt.Errorf("%w: need %d, left %d", ErrStorageGasInsufficient, fee, st.gas)
+			st.state.RevertToSnapshot(snapshot)
+			st.state.SetNonce(msg.From(), nonce+1)
+			st.gas = 0
+		} else {
+			st.gas -= fee
+			// the refund never brings the used gas below the intrinsic gas
+			if used := st.gasUsed(); refund > used-gas {
+				refund = used - gas
+			}
+			st.gas += refund
+			storageFee, storageRefund = fee, refund
+		}
+	}
 	st.refundGas()
 	gAmount := new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice)
 	st.state.AddBalance(st.GasReceiver, gAmount)
 	evm.MakeOngTransferLog(st.state, sender.Address(), st.GasReceiver, gAmount)
 	return &types.ExecutionResult{
-		UsedGas:    st.gasUsed(),
-		Err:        vmerr,
-		ReturnData: ret,
+		UsedGas:       st.gasUsed(),
+		Err:           vmerr,
+		ReturnData:    ret,
+		StorageFee:    storageFee,
+		StorageRefund: storageRefund,
 	}, nil
 }
 
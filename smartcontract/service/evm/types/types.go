@@ -28,6 +28,9 @@ type ExecutionResult struct {
 	UsedGas    uint64 // Total used gas but include the refunded gas
 	Err        error  // Any error encountered during the execution(listed in core/vm/errors.go)
 	ReturnData []byte // Returned data from evm(function result or data supplied with revert opcode)
+
+	StorageFee    uint64 // Gas charged for the new storage, included in UsedGas
+	StorageRefund uint64 // Gas refunded for the freed storage, deducted from UsedGas
 }
 
 // Unwrap returns the internal evm error which allows us for further
@@ -25,7 +25,9 @@ import (
 
 	"github.com/qbyyf/ontology/account"
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/log"
+	cstates "github.com/qbyyf/ontology/core/states"
 	"github.com/qbyyf/ontology/smartcontract/service/native"
 	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
 )
@@ -34,6 +36,9 @@ var (
 	future = time.Date(2100, 1, 1, 12, 0, 0, 0, time.UTC)
 )
 
+// MAX_DELEGATIONS_LIMIT is the max number of delegates listed by one getDelegations call
+const MAX_DELEGATIONS_LIMIT = 100
+
 func Init() {
 	native.Contracts[utils.AuthContractAddress] = RegisterAuthContract
 }
@@ -125,6 +130,9 @@ func Transfer(native *native.NativeService) ([]byte, error) {
 	contract := param.ContractAddr.ToHexString()
 	failState := []interface{}{"transfer", contract, false}
 	sucState := []interface{}{"transfer", contract, true}
+	if isDelegationActivated(native) {
+		sucState = append(sucState, string(param.NewAdminOntID))
+	}
 
 	//call transfer func
 	ret, err := transfer(native, param.ContractAddr, param.NewAdminOntID, param.KeyNo)
@@ -152,6 +160,9 @@ func AssignFuncsToRole(native *native.NativeService) ([]byte, error) {
 	contract := param.ContractAddr.ToHexString()
 	failState := []interface{}{"assignFuncsToRole", contract, false}
 	sucState := []interface{}{"assignFuncsToRole", contract, true}
+	if isDelegationActivated(native) {
+		sucState = append(sucState, string(param.Role), param.FuncNames)
+	}
 
 	if param.Role == nil {
 		return nil, fmt.Errorf("[assignFuncsToRole] invalid param: role is nil")
@@ -290,6 +301,13 @@ func AssignOntIDsToRole(native *native.NativeService) ([]byte, error) {
 	contract := param.ContractAddr.ToHexString()
 	failState := []interface{}{"assignOntIDsToRole", contract, false}
 	sucState := []interface{}{"assignOntIDsToRole", contract, true}
+	if isDelegationActivated(native) {
+		persons := make([]string, 0, len(param.Persons))
+		for _, p := range param.Persons {
+			persons = append(persons, string(p))
+		}
+		sucState = append(sucState, string(param.Role), persons)
+	}
 	if ret {
 		pushEvent(native, sucState)
 		return utils.BYTE_TRUE, nil
@@ -317,12 +335,9 @@ func getAuthToken(native *native.NativeService, contractAddr common.Address, ont
 	}
 	if status != nil {
 		for _, s := range status.status {
-			if bytes.Compare(s.role, role) == 0 && native.Time < s.expireTime { //temporary token
-				token := new(AuthToken)
-				token.role = s.role
-				token.level = s.level
-				token.expireTime = s.expireTime
-				return token, nil
+			if bytes.Compare(s.role, role) == 0 && native.Time < s.expireTime && !s.exhausted() { //temporary token
+				token := s.AuthToken
+				return &token, nil
 			}
 		}
 	}
@@ -356,7 +371,7 @@ func getLevel(native *native.NativeService, contractAddr common.Address, ontID,
  * then make changes to storage as follows:
  */
 func delegate(native *native.NativeService, contractAddr common.Address, from []byte, to []byte,
-	role []byte, period uint32, level uint8, keyNo uint64) (bool, error) {
+	role []byte, period uint32, level uint8, keyNo uint64, funcNames []string, maxUses uint64) (bool, error) {
 	var fromHasRole, toHasRole bool
 	var fromLevel uint8
 	var fromExpireTime uint32
@@ -413,6 +428,26 @@ func delegate(native *native.NativeService, contractAddr common.Address, from []
 		return false, nil
 	}
 
+	//the delegation can not exceed the scope and the remaining uses of the delegator's token
+	if !fromToken.covers(funcNames, maxUses) {
+		log.Debugf("delegation of %s exceeds the scope or the remaining uses of %s", string(to), string(from))
+		return false, nil
+	}
+
+	//a scoped delegation can only cover functions of the role
+	if len(funcNames) != 0 {
+		funcs, err := getRoleFunc(native, contractAddr, role)
+		if err != nil {
+			return false, fmt.Errorf("getRoleFunc failed: %v", err)
+		}
+		for _, fn := range funcNames {
+			if funcs == nil || !funcs.ContainsFunc(fn) {
+				log.Debugf("function %s is not assigned to role %s", fn, string(role))
+				return false, nil
+			}
+		}
+	}
+
 	//check if 'from' has the permission to delegate
 	if fromLevel == 2 {
 		if level < fromLevel && level > 0 && expireTime < fromExpireTime {
@@ -437,16 +472,27 @@ func delegate(native *native.NativeService, contractAddr common.Address, from []
 				newStatus.expireTime = expireTime
 				newStatus.role = role
 				newStatus.level = uint8(level)
+				newStatus.funcNames = funcNames
+				newStatus.maxUses = maxUses
 				status.status = append(status.status, newStatus)
 			} else {
 				status.status[j].level = uint8(level)
 				status.status[j].expireTime = expireTime
 				status.status[j].root = from
+				status.status[j].funcNames = funcNames
+				status.status[j].maxUses = maxUses
+				status.status[j].used = 0
 			}
 			err = putDelegateStatus(native, contractAddr, to, status)
 			if err != nil {
 				return false, fmt.Errorf("putDelegateStatus failed: %v", err)
 			}
+			//the delegated uses are reserved from the delegator's capped token
+			if fromToken.maxUses != 0 {
+				if err := chargeUses(native, contractAddr, from, role, maxUses); err != nil {
+					return false, err
+				}
+			}
 			return true, nil
 		}
 	}
@@ -465,15 +511,26 @@ func Delegate(native *native.NativeService) ([]byte, error) {
 	if param.Period > 1<<32 || param.Level > 1<<8 {
 		return nil, fmt.Errorf("[delegate] period or level is too large")
 	}
+	//the optional limits are ignored before activation, as the param is decoded without them
+	var funcNames []string
+	var maxUses uint64
+	if isDelegationActivated(native) {
+		funcNames = StringsDedupAndSort(param.FuncNames)
+		maxUses = param.MaxUses
+	}
 
 	//prepare event msg
 	contract := param.ContractAddr.ToHexString()
 	failState := []interface{}{"delegate", contract, param.From, param.To, false}
 	sucState := []interface{}{"delegate", contract, param.From, param.To, true}
+	if isDelegationActivated(native) {
+		sucState = append(sucState, string(param.Role), native.Time+uint32(param.Period), param.Level,
+			funcNames, maxUses)
+	}
 
 	//call the delegate func
 	ret, err := delegate(native, param.ContractAddr, param.From, param.To, param.Role,
-		uint32(param.Period), uint8(param.Level), param.KeyNo)
+		uint32(param.Period), uint8(param.Level), param.KeyNo, funcNames, maxUses)
 	if err != nil {
 		return nil, fmt.Errorf("[delegate] failed: %v", err)
 	}
@@ -525,6 +582,9 @@ func withdraw(native *native.NativeService, contractAddr common.Address, initiat
 			if err != nil {
 				return false, err
 			}
+			if err := refundUses(native, contractAddr, s); err != nil {
+				return false, err
+			}
 			return true, nil
 		}
 	}
@@ -544,6 +604,9 @@ func Withdraw(native *native.NativeService) ([]byte, error) {
 	contract := param.ContractAddr.ToHexString()
 	failState := []interface{}{"withdraw", contract, param.Initiator, param.Delegate, false}
 	sucState := []interface{}{"withdraw", contract, param.Initiator, param.Delegate, true}
+	if isDelegationActivated(native) {
+		sucState = append(sucState, string(param.Role))
+	}
 
 	//call the withdraw func
 	ret, err := withdraw(native, param.ContractAddr, param.Initiator, param.Delegate, param.Role, param.KeyNo)
@@ -559,56 +622,69 @@ func Withdraw(native *native.NativeService) ([]byte, error) {
 	}
 }
 
-func verifyToken(native *native.NativeService, contractAddr common.Address, caller []byte, fn string, keyNo uint64) (bool, error) {
+/*
+ * verifyToken checks whether caller can call fn, without changing the storage.
+ * If the token is a capped delegation, it is returned to be consumed by consumeToken.
+ */
+func verifyToken(native *native.NativeService, contractAddr common.Address, caller []byte, fn string,
+	keyNo uint64) (bool, *DelegateStatus, error) {
 	//check caller's identity
 	ret, err := verifySig(native, caller, keyNo)
 	if err != nil {
-		return false, fmt.Errorf("verifySig failed: %v", err)
+		return false, nil, fmt.Errorf("verifySig failed: %v", err)
 	}
 	if !ret {
 		log.Debugf("verifySig return false: caller=%s, keyNo=%d", string(caller), keyNo)
-		return false, nil
+		return false, nil, nil
 	}
 
 	//check if caller has the permanent auth token
 	tokens, err := getOntIDToken(native, contractAddr, caller)
 	if err != nil {
-		return false, fmt.Errorf("getOntIDToken failed: %v", err)
+		return false, nil, fmt.Errorf("getOntIDToken failed: %v", err)
 	}
 	if tokens != nil {
 		for _, token := range tokens.tokens {
 			funcs, err := getRoleFunc(native, contractAddr, token.role)
 			if err != nil {
-				return false, fmt.Errorf("getRoleFunc failed: %v", err)
+				return false, nil, fmt.Errorf("getRoleFunc failed: %v", err)
 			}
 			if funcs == nil || token.expireTime < native.Time {
 				continue
 			}
 			if funcs.ContainsFunc(fn) {
-				return true, nil
+				return true, nil, nil
 			}
 		}
 	}
 
 	status, err := getDelegateStatus(native, contractAddr, caller)
 	if err != nil {
-		return false, fmt.Errorf("getDelegateStatus failed: %v", err)
+		return false, nil, fmt.Errorf("getDelegateStatus failed: %v", err)
 	}
 	if status != nil {
 		for _, s := range status.status {
 			funcs, err := getRoleFunc(native, contractAddr, s.role)
 			if err != nil {
-				return false, fmt.Errorf("getRoleFunc failed: %v", err)
+				return false, nil, fmt.Errorf("getRoleFunc failed: %v", err)
 			}
-			if funcs == nil || s.expireTime < native.Time {
+			if funcs == nil || s.expireTime < native.Time || s.exhausted() {
 				continue
 			}
-			if funcs.ContainsFunc(fn) {
-				return true, nil
+			if funcs.ContainsFunc(fn) && s.permits(fn) {
+				if s.maxUses != 0 && isDelegationActivated(native) {
+					return true, s, nil
+				}
+				return true, nil, nil
 			}
 		}
 	}
-	return false, nil
+	return false, nil, nil
+}
+
+// consumeToken consumes one use of the capped delegation of caller returned by verifyToken
+func consumeToken(native *native.NativeService, contractAddr common.Address, caller []byte, token *DelegateStatus) error {
+	return chargeUses(native, contractAddr, caller, token.role, 1)
 }
 
 func VerifyToken(native *native.NativeService) ([]byte, error) {
@@ -624,10 +700,17 @@ func VerifyToken(native *native.NativeService) ([]byte, error) {
 	failState := []interface{}{"verifyToken", contract, param.Caller, param.Fn, false}
 	sucState := []interface{}{"verifyToken", contract, param.Caller, param.Fn, true}
 
-	ret, err := verifyToken(native, param.ContractAddr, param.Caller, param.Fn, param.KeyNo)
+	ret, token, err := verifyToken(native, param.ContractAddr, param.Caller, param.Fn, param.KeyNo)
 	if err != nil {
 		return nil, fmt.Errorf("[verifyToken] verifyToken failed: %v", err)
 	}
+	//a use is only consumed when the contract checks the token before running fn,
+	//so that a direct call of verifyToken does not spend the caller's uses
+	if ret && token != nil && isCalledBy(native, param.ContractAddr) {
+		if err := consumeToken(native, param.ContractAddr, param.Caller, token); err != nil {
+			return nil, fmt.Errorf("[verifyToken] consumeToken failed: %v", err)
+		}
+	}
 	if ret {
 		pushEvent(native, sucState)
 		return utils.BYTE_TRUE, nil
@@ -636,6 +719,237 @@ func VerifyToken(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_FALSE, nil
 }
 
+/*
+ * the delegator or the contract admin can revoke a delegation before it
+ * expires, whether or not the delegator still holds the role
+ */
+func revokeDelegation(native *native.NativeService, contractAddr common.Address, revoker []byte,
+	delegate []byte, role []byte, keyNo uint64) (bool, error) {
+	ret, err := verifySig(native, revoker, keyNo)
+	if err != nil {
+		return false, fmt.Errorf("verifySig failed: %v", err)
+	}
+	if !ret {
+		log.Debugf("verifySig return false: revoker=%s, keyNo=%d", string(revoker), keyNo)
+		return false, nil
+	}
+
+	admin, err := getContractAdmin(native, contractAddr)
+	if err != nil {
+		return false, fmt.Errorf("getContractAdmin failed: %v", err)
+	}
+	isAdmin := admin != nil && bytes.Compare(admin, revoker) == 0
+
+	status, err := getDelegateStatus(native, contractAddr, delegate)
+	if err != nil {
+		return false, fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status == nil {
+		return false, nil
+	}
+	for i, s := range status.status {
+		if bytes.Compare(s.role, role) != 0 {
+			continue
+		}
+		if !isAdmin && bytes.Compare(s.root, revoker) != 0 {
+			log.Debugf("[revokeDelegation] %s is neither the delegator nor the admin", string(revoker))
+			return false, nil
+		}
+		newStatus := new(Status)
+		newStatus.status = append(status.status[:i], status.status[i+1:]...)
+		err = putDelegateStatus(native, contractAddr, delegate, newStatus)
+		if err != nil {
+			return false, err
+		}
+		if err := refundUses(native, contractAddr, s); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// chargeUses adds uses to the active capped delegation of role held by ontID
+func chargeUses(native *native.NativeService, contractAddr common.Address, ontID, role []byte, uses uint64) error {
+	status, err := getDelegateStatus(native, contractAddr, ontID)
+	if err != nil {
+		return fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status == nil {
+		return fmt.Errorf("%s has no delegation of role %s", string(ontID), string(role))
+	}
+	for _, s := range status.status {
+		if bytes.Compare(s.role, role) != 0 || s.expireTime < native.Time || s.maxUses == 0 {
+			continue
+		}
+		if s.used > s.maxUses || uses > s.maxUses-s.used {
+			return fmt.Errorf("%s has not enough uses of role %s", string(ontID), string(role))
+		}
+		s.used += uses
+		if err := putDelegateStatus(native, contractAddr, ontID, status); err != nil {
+			return fmt.Errorf("putDelegateStatus failed: %v", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s has no capped delegation of role %s", string(ontID), string(role))
+}
+
+// refundUses gives the unused uses of a removed capped delegation back to its delegator, if the
+// delegator still holds the capped delegation they were reserved from
+func refundUses(native *native.NativeService, contractAddr common.Address, removed *DelegateStatus) error {
+	if removed.maxUses == 0 || removed.used >= removed.maxUses {
+		return nil
+	}
+	status, err := getDelegateStatus(native, contractAddr, removed.root)
+	if err != nil {
+		return fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status == nil {
+		return nil
+	}
+	for _, s := range status.status {
+		if bytes.Compare(s.role, removed.role) != 0 || s.maxUses == 0 {
+			continue
+		}
+		refund := removed.maxUses - removed.used
+		if refund > s.used {
+			refund = s.used
+		}
+		s.used -= refund
+		return putDelegateStatus(native, contractAddr, removed.root, status)
+	}
+	return nil
+}
+
+func RevokeDelegation(native *native.NativeService) ([]byte, error) {
+	if !isDelegationActivated(native) {
+		return nil, fmt.Errorf("[revokeDelegation] block num is not reached for this func")
+	}
+	//deserialize param
+	param := &RevokeDelegationParam{}
+	source := common.NewZeroCopySource(native.Input)
+	err := param.Deserialization(source)
+	if err != nil {
+		return nil, fmt.Errorf("[revokeDelegation] deserialize param failed: %v", err)
+	}
+
+	//prepare event msg
+	contract := param.ContractAddr.ToHexString()
+	failState := []interface{}{"revokeDelegation", contract, string(param.Revoker), string(param.Delegate), false}
+	sucState := []interface{}{"revokeDelegation", contract, string(param.Revoker), string(param.Delegate), true,
+		string(param.Role)}
+
+	ret, err := revokeDelegation(native, param.ContractAddr, param.Revoker, param.Delegate, param.Role, param.KeyNo)
+	if err != nil {
+		return nil, fmt.Errorf("[revokeDelegation] revokeDelegation failed: %v", err)
+	}
+	if ret {
+		pushEvent(native, sucState)
+		return utils.BYTE_TRUE, nil
+	}
+	pushEvent(native, failState)
+	return utils.BYTE_FALSE, nil
+}
+
+/*
+ * getDelegations lists the delegations of at most limit delegates, skipping the first offset
+ * delegates of the contract, and reports whether there are more delegates after them.
+ */
+func getDelegations(native *native.NativeService, contractAddr common.Address, fn string, offset,
+	limit uint32) (*Delegations, error) {
+	if limit == 0 || limit > MAX_DELEGATIONS_LIMIT {
+		return nil, fmt.Errorf("limit should be in [1, %d]", MAX_DELEGATIONS_LIMIT)
+	}
+	var funcs map[string]*roleFuncs
+	if fn != "" {
+		funcs = make(map[string]*roleFuncs)
+	}
+	result := &Delegations{Delegations: make([]*Delegation, 0)}
+	prefix := concatDelegateStatusKey(native, contractAddr, nil)
+	iter := native.CacheDB.NewIterator(prefix)
+	defer iter.Release()
+	index := uint32(0)
+	for has := iter.First(); has; has = iter.Next() {
+		if index < offset {
+			index++
+			continue
+		}
+		if index-offset == limit {
+			result.HasMore = true
+			break
+		}
+		index++
+		value, err := cstates.GetValueFromRawStorageItem(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("get delegate status failed: %v", err)
+		}
+		status := new(Status)
+		if err := status.Deserialization(common.NewZeroCopySource(value)); err != nil {
+			return nil, fmt.Errorf("deserialize Status object failed. data: %x", value)
+		}
+		ontID := append([]byte{}, iter.Key()[len(prefix):]...)
+		for _, s := range status.status {
+			if s.expireTime < native.Time || s.exhausted() {
+				continue
+			}
+			if fn != "" {
+				rf, ok := funcs[string(s.role)]
+				if !ok {
+					rf, err = getRoleFunc(native, contractAddr, s.role)
+					if err != nil {
+						return nil, fmt.Errorf("getRoleFunc failed: %v", err)
+					}
+					funcs[string(s.role)] = rf
+				}
+				if rf == nil || !rf.ContainsFunc(fn) || !s.permits(fn) {
+					continue
+				}
+			}
+			result.Delegations = append(result.Delegations, &Delegation{
+				OntID:      ontID,
+				Root:       s.root,
+				Role:       s.role,
+				ExpireTime: s.expireTime,
+				Level:      s.level,
+				FuncNames:  s.funcNames,
+				MaxUses:    s.maxUses,
+				Used:       s.used,
+			})
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func GetDelegations(native *native.NativeService) ([]byte, error) {
+	if !isDelegationActivated(native) {
+		return nil, fmt.Errorf("[getDelegations] block num is not reached for this func")
+	}
+	param := &GetDelegationsParam{}
+	source := common.NewZeroCopySource(native.Input)
+	if err := param.Deserialization(source); err != nil {
+		return nil, fmt.Errorf("[getDelegations] deserialize param failed: %v", err)
+	}
+	delegations, err := getDelegations(native, param.ContractAddr, param.Fn, param.Offset, param.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("[getDelegations] failed: %v", err)
+	}
+	return common.SerializeToBytes(delegations), nil
+}
+
+// isCalledBy reports whether the auth contract is invoked by contractAddr
+func isCalledBy(native *native.NativeService, contractAddr common.Address) bool {
+	ctx := native.ContextRef.CallingContext()
+	return ctx != nil && ctx.ContractAddress == contractAddr
+}
+
+// isDelegationActivated reports whether the scoped, capped and revocable delegations are activated
+func isDelegationActivated(native *native.NativeService) bool {
+	return native.Height >= config.GetAuthDelegationHeight()
+}
+
 func verifySig(native *native.NativeService, ontID []byte, keyNo uint64) (bool, error) {
 	sink := common.NewZeroCopySink(nil)
 	sink.WriteVarBytes(ontID)
@@ -660,4 +974,6 @@ func RegisterAuthContract(native *native.NativeService) {
 	native.Register("assignOntIDsToRole", AssignOntIDsToRole)
 	native.Register("verifyToken", VerifyToken)
 	native.Register("transfer", Transfer)
+	native.Register("revokeDelegation", RevokeDelegation)
+	native.Register("getDelegations", GetDelegations)
 }
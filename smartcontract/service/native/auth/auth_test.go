@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package auth
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qbyyf/ontology/core/store/leveldbstore"
+	"github.com/qbyyf/ontology/core/store/overlaydb"
+	"github.com/qbyyf/ontology/smartcontract/context"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/qbyyf/ontology/smartcontract/storage"
+)
+
+// testContextRef runs the native methods of auth contract
+type testContextRef struct {
+	context.ContextRef
+}
+
+func (this *testContextRef) CurrentContext() *context.Context {
+	return &context.Context{ContractAddress: utils.AuthContractAddress}
+}
+
+func newTestNative() *native.NativeService {
+	return &native.NativeService{
+		CacheDB:    storage.NewCacheDB(overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore())),
+		ContextRef: &testContextRef{},
+		Time:       100,
+	}
+}
+
+func TestChargeAndRefundUses(t *testing.T) {
+	ns := newTestNative()
+	root, delegator, delegate := []byte("did:ont:root"), []byte("did:ont:delegator"), []byte("did:ont:delegate")
+	capped := &DelegateStatus{root: root, AuthToken: AuthToken{role: []byte(role), expireTime: 200, level: 1, maxUses: 5}}
+	assert.Nil(t, putDelegateStatus(ns, OntContractAddr, delegator, &Status{status: []*DelegateStatus{capped}}))
+
+	//the delegated uses are reserved from the delegator
+	assert.Nil(t, chargeUses(ns, OntContractAddr, delegator, []byte(role), 3))
+	assert.NotNil(t, chargeUses(ns, OntContractAddr, delegator, []byte(role), 3))
+	status, err := getDelegateStatus(ns, OntContractAddr, delegator)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), status.status[0].used)
+
+	//the unused uses of a removed delegation are given back
+	removed := &DelegateStatus{root: delegator, AuthToken: AuthToken{role: []byte(role), expireTime: 200, level: 1,
+		maxUses: 3, used: 1}}
+	assert.Nil(t, refundUses(ns, OntContractAddr, removed))
+	status, err = getDelegateStatus(ns, OntContractAddr, delegator)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), status.status[0].used)
+
+	//uncapped and expired delegations are not charged
+	assert.NotNil(t, chargeUses(ns, OntContractAddr, delegate, []byte(role), 1))
+	ns.Time = 201
+	assert.NotNil(t, chargeUses(ns, OntContractAddr, delegator, []byte(role), 1))
+}
+
+func TestGetDelegationsPage(t *testing.T) {
+	ns := newTestNative()
+	for i := 0; i < 5; i++ {
+		s := &DelegateStatus{root: admin, AuthToken: AuthToken{role: []byte(role), expireTime: 200, level: 1}}
+		ontID := []byte(fmt.Sprintf("did:ont:delegate%d", i))
+		assert.Nil(t, putDelegateStatus(ns, OntContractAddr, ontID, &Status{status: []*DelegateStatus{s}}))
+	}
+
+	page, err := getDelegations(ns, OntContractAddr, "", 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(page.Delegations))
+	assert.True(t, page.HasMore)
+	assert.Equal(t, "did:ont:delegate0", string(page.Delegations[0].OntID))
+
+	page, err = getDelegations(ns, OntContractAddr, "", 4, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Delegations))
+	assert.False(t, page.HasMore)
+	assert.Equal(t, "did:ont:delegate4", string(page.Delegations[0].OntID))
+
+	_, err = getDelegations(ns, OntContractAddr, "", 0, 0)
+	assert.NotNil(t, err)
+	_, err = getDelegations(ns, OntContractAddr, "", 0, MAX_DELEGATIONS_LIMIT+1)
+	assert.NotNil(t, err)
+}
@@ -184,6 +184,10 @@ type DelegateParam struct {
 	Period       uint64
 	Level        uint64
 	KeyNo        uint64
+
+	// optional, appended after KeyNo only when set
+	FuncNames []string // restricts the delegation to these functions of the role
+	MaxUses   uint64   // caps how many times the delegation can pass verifyToken
 }
 
 func (this *DelegateParam) Serialization(sink *common.ZeroCopySink) {
@@ -194,6 +198,13 @@ func (this *DelegateParam) Serialization(sink *common.ZeroCopySink) {
 	utils.EncodeVarUint(sink, this.Period)
 	utils.EncodeVarUint(sink, uint64(this.Level))
 	utils.EncodeVarUint(sink, this.KeyNo)
+	if len(this.FuncNames) != 0 || this.MaxUses != 0 {
+		utils.EncodeVarUint(sink, uint64(len(this.FuncNames)))
+		for _, fn := range this.FuncNames {
+			sink.WriteString(fn)
+		}
+		utils.EncodeVarUint(sink, this.MaxUses)
+	}
 }
 
 func (this *DelegateParam) Deserialization(source *common.ZeroCopySource) error {
@@ -224,6 +235,23 @@ func (this *DelegateParam) Deserialization(source *common.ZeroCopySource) error
 		return fmt.Errorf("period or level too large: (%d, %d)", this.Period, level)
 	}
 	this.Level = level
+	if source.Len() == 0 {
+		return nil
+	}
+	fnLen, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < fnLen; i++ {
+		fn, err := utils.DecodeString(source)
+		if err != nil {
+			return fmt.Errorf("FuncNames Deserialization error: %s", err)
+		}
+		this.FuncNames = append(this.FuncNames, fn)
+	}
+	if this.MaxUses, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -292,3 +320,78 @@ func (this *VerifyTokenParam) Deserialization(source *common.ZeroCopySource) err
 	}
 	return nil
 }
+
+type RevokeDelegationParam struct {
+	ContractAddr common.Address
+	Revoker      []byte
+	Delegate     []byte
+	Role         []byte
+	KeyNo        uint64
+}
+
+func (this *RevokeDelegationParam) Serialization(sink *common.ZeroCopySink) {
+	serializeAddress(sink, this.ContractAddr)
+	sink.WriteVarBytes(this.Revoker)
+	sink.WriteVarBytes(this.Delegate)
+	sink.WriteVarBytes(this.Role)
+	utils.EncodeVarUint(sink, this.KeyNo)
+}
+
+func (this *RevokeDelegationParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.ContractAddr, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Revoker, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("Revoker Deserialization error: %s", err)
+	}
+	if this.Delegate, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("Delegate Deserialization error: %s", err)
+	}
+	if this.Role, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("Role Deserialization error: %s", err)
+	}
+	if this.KeyNo, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetDelegationsParam lists the active delegations of a contract, optionally
+// only those which can call Fn, page by page of at most Limit delegates
+type GetDelegationsParam struct {
+	ContractAddr common.Address
+	Fn           string
+	Offset       uint32
+	Limit        uint32
+}
+
+func (this *GetDelegationsParam) Serialization(sink *common.ZeroCopySink) {
+	serializeAddress(sink, this.ContractAddr)
+	sink.WriteString(this.Fn)
+	utils.EncodeVarUint(sink, uint64(this.Offset))
+	utils.EncodeVarUint(sink, uint64(this.Limit))
+}
+
+func (this *GetDelegationsParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.ContractAddr, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Fn, err = utils.DecodeString(source); err != nil {
+		return fmt.Errorf("Fn Deserialization error: %s", err)
+	}
+	offset, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("Offset Deserialization error: %s", err)
+	}
+	limit, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("Limit Deserialization error: %s", err)
+	}
+	if offset > math.MaxUint32 || limit > math.MaxUint32 {
+		return fmt.Errorf("offset or limit is too large")
+	}
+	this.Offset, this.Limit = uint32(offset), uint32(limit)
+	return nil
+}
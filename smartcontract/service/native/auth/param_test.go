@@ -142,6 +142,27 @@ func TestSerialization_Delegate(t *testing.T) {
 	assert.Equal(t, param, param2)
 }
 
+func TestSerialization_DelegateScoped(t *testing.T) {
+	param := &DelegateParam{
+		ContractAddr: OntContractAddr,
+		From:         p1,
+		To:           p2,
+		Role:         []byte(role),
+		Period:       60 * 60 * 24,
+		Level:        1,
+		FuncNames:    []string{"foo1"},
+		MaxUses:      5,
+	}
+	bf := common.NewZeroCopySink(nil)
+	param.Serialization(bf)
+	rd := common.NewZeroCopySource(bf.Bytes())
+	param2 := new(DelegateParam)
+	if err := param2.Deserialization(rd); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, param, param2)
+}
+
 func TestSerialization_Withdraw(t *testing.T) {
 	param := &WithdrawParam{
 		ContractAddr: OntContractAddr,
@@ -174,3 +195,42 @@ func TestSerialization_VerifyToken(t *testing.T) {
 	}
 	assert.Equal(t, param, param2)
 }
+
+func TestSerialization_RevokeDelegation(t *testing.T) {
+	param := &RevokeDelegationParam{
+		ContractAddr: OntContractAddr,
+		Revoker:      p1,
+		Delegate:     p2,
+		Role:         []byte(role),
+		KeyNo:        1,
+	}
+	bf := common.NewZeroCopySink(nil)
+	param.Serialization(bf)
+	rd := common.NewZeroCopySource(bf.Bytes())
+	param2 := new(RevokeDelegationParam)
+	if err := param2.Deserialization(rd); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, param, param2)
+}
+
+func TestSerialization_Delegations(t *testing.T) {
+	param := &Delegations{Delegations: []*Delegation{{
+		OntID:      p2,
+		Root:       p1,
+		Role:       []byte(role),
+		ExpireTime: 1000,
+		Level:      1,
+		FuncNames:  funcs,
+		MaxUses:    2,
+		Used:       1,
+	}}}
+	bf := common.NewZeroCopySink(nil)
+	param.Serialization(bf)
+	rd := common.NewZeroCopySource(bf.Bytes())
+	param2 := new(Delegations)
+	if err := param2.Deserialization(rd); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, param, param2)
+}
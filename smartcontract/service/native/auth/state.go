@@ -19,6 +19,7 @@
 package auth
 
 import (
+	"fmt"
 	"io"
 	"strings"
 
@@ -80,6 +81,88 @@ type AuthToken struct {
 	role       []byte
 	expireTime uint32
 	level      uint8
+
+	// optional limits of a delegated token, they are not part of the token's
+	// own serialization and are carried by Status after all the entries
+	funcNames []string // subset of the role's functions, empty means all
+	maxUses   uint64   // 0 means unlimited
+	used      uint64
+}
+
+// hasLimit reports whether the token is scoped to some functions or capped
+func (this *AuthToken) hasLimit() bool {
+	return len(this.funcNames) != 0 || this.maxUses != 0
+}
+
+// exhausted reports whether a capped token has been used up
+func (this *AuthToken) exhausted() bool {
+	return this.maxUses != 0 && this.used >= this.maxUses
+}
+
+// permits reports whether the token's scope covers fn
+func (this *AuthToken) permits(fn string) bool {
+	if len(this.funcNames) == 0 {
+		return true
+	}
+	for _, f := range this.funcNames {
+		if f == fn {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether a delegation of funcNames and maxUses is within the token's
+// scope and remaining uses
+func (this *AuthToken) covers(funcNames []string, maxUses uint64) bool {
+	if len(this.funcNames) != 0 {
+		if len(funcNames) == 0 {
+			return false
+		}
+		for _, fn := range funcNames {
+			if !this.permits(fn) {
+				return false
+			}
+		}
+	}
+	if this.maxUses != 0 {
+		if maxUses == 0 || this.used >= this.maxUses || maxUses > this.maxUses-this.used {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *AuthToken) serializeLimit(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(len(this.funcNames)))
+	for _, fn := range this.funcNames {
+		sink.WriteString(fn)
+	}
+	utils.EncodeVarUint(sink, this.maxUses)
+	utils.EncodeVarUint(sink, this.used)
+}
+
+func (this *AuthToken) deserializeLimit(source *common.ZeroCopySource) error {
+	fnLen, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	var funcNames []string
+	for i := uint64(0); i < fnLen; i++ {
+		fn, err := utils.DecodeString(source)
+		if err != nil {
+			return err
+		}
+		funcNames = append(funcNames, fn)
+	}
+	if this.maxUses, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	if this.used, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	this.funcNames = funcNames
+	return nil
 }
 
 func (this *AuthToken) Serialization(sink *common.ZeroCopySink) {
@@ -127,14 +210,26 @@ func (this *DelegateStatus) Deserialization(source *common.ZeroCopySource) error
 	return err
 }
 
+/*
+ * the limits of the delegated tokens are appended after all the entries, and
+ * only when at least one entry is limited, so that the status written before
+ * the limits were introduced keeps the same encoding.
+ */
 type Status struct {
 	status []*DelegateStatus
 }
 
 func (this *Status) Serialization(sink *common.ZeroCopySink) {
 	sink.WriteUint32(uint32(len(this.status)))
+	limited := false
 	for _, s := range this.status {
 		s.Serialization(sink)
+		limited = limited || s.hasLimit()
+	}
+	if limited {
+		for _, s := range this.status {
+			s.serializeLimit(sink)
+		}
 	}
 }
 
@@ -152,6 +247,14 @@ func (this *Status) Deserialization(source *common.ZeroCopySource) error {
 		}
 		this.status = append(this.status, s)
 	}
+	if source.Len() == 0 {
+		return nil
+	}
+	for _, s := range this.status {
+		if err := s.deserializeLimit(source); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -182,3 +285,102 @@ func (this *roleTokens) Deserialization(source *common.ZeroCopySource) error {
 	}
 	return nil
 }
+
+// Delegation is an active delegation returned by getDelegations
+type Delegation struct {
+	OntID      []byte
+	Root       []byte
+	Role       []byte
+	ExpireTime uint32
+	Level      uint8
+	FuncNames  []string
+	MaxUses    uint64
+	Used       uint64
+}
+
+func (this *Delegation) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.OntID)
+	sink.WriteVarBytes(this.Root)
+	sink.WriteVarBytes(this.Role)
+	sink.WriteUint32(this.ExpireTime)
+	sink.WriteUint8(this.Level)
+	utils.EncodeVarUint(sink, uint64(len(this.FuncNames)))
+	for _, fn := range this.FuncNames {
+		sink.WriteString(fn)
+	}
+	utils.EncodeVarUint(sink, this.MaxUses)
+	utils.EncodeVarUint(sink, this.Used)
+}
+
+func (this *Delegation) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.OntID, err = utils.DecodeVarBytes(source); err != nil {
+		return err
+	}
+	if this.Root, err = utils.DecodeVarBytes(source); err != nil {
+		return err
+	}
+	if this.Role, err = utils.DecodeVarBytes(source); err != nil {
+		return err
+	}
+	var eof bool
+	if this.ExpireTime, eof = source.NextUint32(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	if this.Level, eof = source.NextUint8(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	fnLen, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	this.FuncNames = nil
+	for i := uint64(0); i < fnLen; i++ {
+		fn, err := utils.DecodeString(source)
+		if err != nil {
+			return err
+		}
+		this.FuncNames = append(this.FuncNames, fn)
+	}
+	if this.MaxUses, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	if this.Used, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delegations is a page of getDelegations, HasMore reports whether there are delegates after the page
+type Delegations struct {
+	Delegations []*Delegation
+	HasMore     bool
+}
+
+func (this *Delegations) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(uint32(len(this.Delegations)))
+	for _, d := range this.Delegations {
+		d.Serialization(sink)
+	}
+	sink.WriteBool(this.HasMore)
+}
+
+func (this *Delegations) Deserialization(source *common.ZeroCopySource) error {
+	n, eof := source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.Delegations = make([]*Delegation, 0)
+	for i := uint32(0); i < n; i++ {
+		d := new(Delegation)
+		if err := d.Deserialization(source); err != nil {
+			return err
+		}
+		this.Delegations = append(this.Delegations, d)
+	}
+	var irregular bool
+	if this.HasMore, irregular, eof = source.NextBool(); irregular || eof {
+		return fmt.Errorf("HasMore Deserialization error")
+	}
+	return nil
+}
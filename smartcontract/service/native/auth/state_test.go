@@ -94,3 +94,65 @@ func TestSerDelegateStatus(t *testing.T) {
 		t.Fatalf("failed")
 	}
 }
+
+func TestSerStatusLimits(t *testing.T) {
+	legacy := &Status{status: []*DelegateStatus{
+		{root: []byte("root"), AuthToken: AuthToken{role: []byte("role"), expireTime: 100, level: 1}},
+	}}
+	bf := common.NewZeroCopySink(nil)
+	legacy.Serialization(bf)
+	// an unlimited status must keep the encoding it had before the limits
+	plain := common.NewZeroCopySink(nil)
+	plain.WriteUint32(1)
+	legacy.status[0].Serialization(plain)
+	if bytes.Compare(bf.Bytes(), plain.Bytes()) != 0 {
+		t.Fatalf("unlimited status encoding changed")
+	}
+
+	limited := &Status{status: []*DelegateStatus{
+		legacy.status[0],
+		{root: []byte("root"), AuthToken: AuthToken{role: []byte("role2"), expireTime: 200, level: 1,
+			funcNames: []string{"foo1"}, maxUses: 3, used: 1}},
+	}}
+	bf = common.NewZeroCopySink(nil)
+	limited.Serialization(bf)
+	s2 := new(Status)
+	if err := s2.Deserialization(common.NewZeroCopySource(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if len(s2.status) != 2 || s2.status[0].hasLimit() {
+		t.Fatalf("failed")
+	}
+	s := s2.status[1]
+	if len(s.funcNames) != 1 || s.funcNames[0] != "foo1" || s.maxUses != 3 || s.used != 1 {
+		t.Fatalf("limits do not match: %v", s.AuthToken)
+	}
+	if !s.permits("foo1") || s.permits("foo2") || s.exhausted() {
+		t.Fatalf("failed")
+	}
+	s.used = 3
+	if !s.exhausted() {
+		t.Fatalf("token should be exhausted")
+	}
+}
+
+func TestAuthTokenCovers(t *testing.T) {
+	unlimited := &AuthToken{role: []byte("role"), level: 2}
+	if !unlimited.covers(nil, 0) || !unlimited.covers([]string{"foo1"}, 5) {
+		t.Fatalf("unlimited token should cover any delegation")
+	}
+
+	scoped := &AuthToken{role: []byte("role"), level: 2, funcNames: []string{"foo1", "foo2"}, maxUses: 5, used: 2}
+	if !scoped.covers([]string{"foo1"}, 3) {
+		t.Fatalf("delegation within scope and remaining uses should be covered")
+	}
+	if scoped.covers(nil, 3) {
+		t.Fatalf("delegation of all functions exceeds the scope")
+	}
+	if scoped.covers([]string{"foo1", "foo3"}, 3) {
+		t.Fatalf("delegation of other functions exceeds the scope")
+	}
+	if scoped.covers([]string{"foo1"}, 0) || scoped.covers([]string{"foo1"}, 4) {
+		t.Fatalf("delegation exceeds the remaining uses")
+	}
+}
@@ -77,31 +77,28 @@ func ProcessCrossChainTx(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, contract params deserialize error: %v", err)
 	}
 
-	//get block header
-	header, err := header_sync.GetHeaderByHeight(native, params.FromChainID, params.Height)
+	//verify the proof by the verifier of the source chain
+	verifier, cv, err := header_sync.GetVerifier(native, params.FromChainID)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, %d, %d", params.FromChainID, params.Height)
+		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, %v", err)
 	}
-	if header == nil {
-		header2 := new(ccom.Header)
-		err := header2.Deserialization(common.NewZeroCopySource(params.Header))
-		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, deserialize header error: %v", err)
-		}
-		if err := header_sync.ProcessHeader(native, header2, params.Header); err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, error: %s", err)
-		}
-		header = header2
-	}
-
 	proof, err := hex.DecodeString(params.Proof)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, proof hex.DecodeString error: %v", err)
 	}
-	merkleValue, err := VerifyToOntTx(native, proof, params.FromChainID, header)
+	value, err := verifier.VerifyProof(native, cv, params.Height, proof, params.Header)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, verify proof error: %v", err)
+	}
+	merkleValue, err := VerifyToOntTx(native, value, params.FromChainID)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, VerifyOntTx error: %v", err)
 	}
+	//a chain which is not ontology can only prove values sent from itself
+	if cv.VerifierType != header_sync.ONTOLOGY_VERIFIER && merkleValue.FromChainID != params.FromChainID {
+		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, value from chain %d is proven by chain %d",
+			merkleValue.FromChainID, params.FromChainID)
+	}
 
 	if merkleValue.MakeTxParam.ToChainID != ONT_CHAIN_ID {
 		return utils.BYTE_FALSE, fmt.Errorf("ProcessCrossChainTx, to chain id is not ont")
@@ -26,7 +26,6 @@ import (
 	"github.com/qbyyf/ontology/common"
 	"github.com/qbyyf/ontology/common/config"
 	cstates "github.com/qbyyf/ontology/core/states"
-	"github.com/qbyyf/ontology/merkle"
 	"github.com/qbyyf/ontology/smartcontract/event"
 	"github.com/qbyyf/ontology/smartcontract/service/native"
 	ccom "github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/common"
@@ -139,12 +138,9 @@ func MakeFromOntProof(native *native.NativeService, params *CreateCrossChainTxPa
 	return nil
 }
 
-func VerifyToOntTx(native *native.NativeService, proof []byte, fromChainid uint64, header *ccom.Header) (*ccom.ToMerkleValue, error) {
-	v, err := merkle.MerkleProve(proof, header.CrossStateRoot)
-	if err != nil {
-		return nil, fmt.Errorf("VerifyToOntTx, merkle.MerkleProve verify merkle proof error: %v", err)
-	}
-
+// VerifyToOntTx decodes the cross chain value proven by the verifier of the
+// source chain and records it as done
+func VerifyToOntTx(native *native.NativeService, v []byte, fromChainid uint64) (*ccom.ToMerkleValue, error) {
 	s := common.NewZeroCopySource(v)
 	merkleValue := new(ccom.ToMerkleValue)
 	if err := merkleValue.Deserialization(s); err != nil {
@@ -161,7 +157,7 @@ func VerifyToOntTx(native *native.NativeService, proof []byte, fromChainid uint6
 		crossChainId = merkleValue.MakeTxParam.CrossChainID
 	}
 
-	err = checkDoneTx(native, crossChainId, fromChainid)
+	err := checkDoneTx(native, crossChainId, fromChainid)
 	if err != nil {
 		return nil, fmt.Errorf("VerifyToOntTx, checkDoneTx, CrossChainId: %x, fromChainId: %d, error:%s", crossChainId, fromChainid, err)
 	}
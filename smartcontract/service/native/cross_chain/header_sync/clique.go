@@ -0,0 +1,835 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package header_sync
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/go-ethereum/ethdb/memorydb"
+	"github.com/qbyyf/go-ethereum/rlp"
+	"github.com/qbyyf/go-ethereum/trie"
+	"github.com/qbyyf/ontology/common"
+	cstates "github.com/qbyyf/ontology/core/states"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	ccom "github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	cliqueExtraVanity = 32 // bytes reserved for signer vanity in the extra data
+	cliqueExtraSeal   = 65 // bytes of the signer's seal at the end of the extra data
+
+	// cliqueAllowedFutureTime is how many seconds a header may be ahead of the
+	// ontology block it is synced in
+	cliqueAllowedFutureTime = 15
+)
+
+var (
+	cliqueDiffInTurn = big.NewInt(2)
+	cliqueDiffNoTurn = big.NewInt(1)
+
+	cliqueNonceAuthVote = ethtypes.BlockNonce{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	cliqueNonceDropVote = ethtypes.BlockNonce{}
+)
+
+// CliqueConfig is the config of a chain verified by the clique verifier
+type CliqueConfig struct {
+	// ContractAddress is the contract whose storage holds the hashes of the
+	// cross chain values sent to ontology
+	ContractAddress ethcommon.Address
+	// Confirmations is the number of headers a proven header must be buried under
+	Confirmations uint32
+	// Epoch is the number of headers between the checkpoint headers, which
+	// list the signers and reset the votes
+	Epoch uint32
+	// Period is the minimum number of seconds between two headers
+	Period uint64
+	// MappingSlot is the storage slot of the contract's mapping from the tx
+	// hash of a cross chain value to the hash of the value
+	MappingSlot uint64
+}
+
+func (this *CliqueConfig) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteBytes(this.ContractAddress[:])
+	utils.EncodeVarUint(sink, uint64(this.Confirmations))
+	utils.EncodeVarUint(sink, uint64(this.Epoch))
+	utils.EncodeVarUint(sink, this.Period)
+	utils.EncodeVarUint(sink, this.MappingSlot)
+}
+
+func (this *CliqueConfig) Deserialization(source *common.ZeroCopySource) error {
+	addr, eof := source.NextBytes(ethcommon.AddressLength)
+	if eof {
+		return fmt.Errorf("deserialize contract address error")
+	}
+	confirmations, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize confirmations error: %v", err)
+	}
+	if confirmations > math.MaxUint32 {
+		return fmt.Errorf("confirmations more than max uint32")
+	}
+	epoch, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize epoch error: %v", err)
+	}
+	if epoch == 0 || epoch > math.MaxUint32 {
+		return fmt.Errorf("invalid epoch %d", epoch)
+	}
+	period, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize period error: %v", err)
+	}
+	mappingSlot, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize mapping slot error: %v", err)
+	}
+	if source.Len() != 0 {
+		return fmt.Errorf("trailing bytes after config")
+	}
+	this.ContractAddress = ethcommon.BytesToAddress(addr)
+	this.Confirmations = uint32(confirmations)
+	this.Epoch = uint32(epoch)
+	this.Period = period
+	this.MappingSlot = mappingSlot
+	return nil
+}
+
+func decodeCliqueConfig(cv *ChainVerifier) (*CliqueConfig, error) {
+	config := new(CliqueConfig)
+	if err := config.Deserialization(common.NewZeroCopySource(cv.Config)); err != nil {
+		return nil, fmt.Errorf("deserialize clique config error: %v", err)
+	}
+	return config, nil
+}
+
+type cliqueRecent struct {
+	Height uint32
+	Signer ethcommon.Address
+}
+
+// cliqueVote is the vote of a signer to authorize or drop Address
+type cliqueVote struct {
+	Signer    ethcommon.Address
+	Address   ethcommon.Address
+	Authorize bool
+}
+
+// cliqueSnapshot is the signer set the next header is verified with, the
+// signers of the latest headers which may not sign again yet and the votes
+// cast since the last checkpoint
+type cliqueSnapshot struct {
+	Signers []ethcommon.Address
+	Recents []cliqueRecent
+	Votes   []cliqueVote
+}
+
+func (this *cliqueSnapshot) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(len(this.Signers)))
+	for _, s := range this.Signers {
+		sink.WriteBytes(s[:])
+	}
+	utils.EncodeVarUint(sink, uint64(len(this.Recents)))
+	for _, r := range this.Recents {
+		sink.WriteUint32(r.Height)
+		sink.WriteBytes(r.Signer[:])
+	}
+	utils.EncodeVarUint(sink, uint64(len(this.Votes)))
+	for _, v := range this.Votes {
+		sink.WriteBytes(v.Signer[:])
+		sink.WriteBytes(v.Address[:])
+		sink.WriteBool(v.Authorize)
+	}
+}
+
+func (this *cliqueSnapshot) Deserialization(source *common.ZeroCopySource) error {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize signers length error: %v", err)
+	}
+	signers := make([]ethcommon.Address, 0)
+	for i := uint64(0); i < n; i++ {
+		addr, eof := source.NextBytes(ethcommon.AddressLength)
+		if eof {
+			return fmt.Errorf("deserialize signer error")
+		}
+		signers = append(signers, ethcommon.BytesToAddress(addr))
+	}
+	m, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize recents length error: %v", err)
+	}
+	recents := make([]cliqueRecent, 0)
+	for i := uint64(0); i < m; i++ {
+		height, eof := source.NextUint32()
+		if eof {
+			return fmt.Errorf("deserialize recent height error")
+		}
+		addr, eof := source.NextBytes(ethcommon.AddressLength)
+		if eof {
+			return fmt.Errorf("deserialize recent signer error")
+		}
+		recents = append(recents, cliqueRecent{Height: height, Signer: ethcommon.BytesToAddress(addr)})
+	}
+	k, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize votes length error: %v", err)
+	}
+	votes := make([]cliqueVote, 0)
+	for i := uint64(0); i < k; i++ {
+		signer, eof := source.NextBytes(ethcommon.AddressLength)
+		if eof {
+			return fmt.Errorf("deserialize vote signer error")
+		}
+		addr, eof := source.NextBytes(ethcommon.AddressLength)
+		if eof {
+			return fmt.Errorf("deserialize vote address error")
+		}
+		authorize, irr, eof := source.NextBool()
+		if irr || eof {
+			return fmt.Errorf("deserialize vote authorize error")
+		}
+		votes = append(votes, cliqueVote{
+			Signer:    ethcommon.BytesToAddress(signer),
+			Address:   ethcommon.BytesToAddress(addr),
+			Authorize: authorize,
+		})
+	}
+	this.Signers = signers
+	this.Recents = recents
+	this.Votes = votes
+	return nil
+}
+
+// limit is the number of consecutive headers a signer may sign only once in
+func (this *cliqueSnapshot) limit() uint32 {
+	return uint32(len(this.Signers)/2 + 1)
+}
+
+func (this *cliqueSnapshot) inTurn(height uint32, signer ethcommon.Address) bool {
+	return this.Signers[int(height)%len(this.Signers)] == signer
+}
+
+func (this *cliqueSnapshot) contains(signer ethcommon.Address) bool {
+	for _, s := range this.Signers {
+		if s == signer {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the snapshot the children of the header at height signed by
+// signer are verified with. As in clique the coinbase of a header which is not
+// a checkpoint is voted for, and it is authorized or dropped once more than
+// half of the signers voted for it.
+func (this *cliqueSnapshot) apply(header *ethtypes.Header, height uint32, signer ethcommon.Address,
+	checkpoint bool) cliqueSnapshot {
+	next := cliqueSnapshot{Signers: append([]ethcommon.Address{}, this.Signers...)}
+	if !checkpoint {
+		candidate := header.Coinbase
+		authorize := header.Nonce == cliqueNonceAuthVote
+		// a new vote of the signer replaces its former vote for the candidate
+		for _, v := range this.Votes {
+			if v.Signer != signer || v.Address != candidate {
+				next.Votes = append(next.Votes, v)
+			}
+		}
+		if next.contains(candidate) != authorize {
+			next.Votes = append(next.Votes, cliqueVote{Signer: signer, Address: candidate, Authorize: authorize})
+		}
+		tally := 0
+		for _, v := range next.Votes {
+			if v.Address == candidate {
+				tally++
+			}
+		}
+		if tally > len(next.Signers)/2 {
+			votes := next.Votes
+			next.Votes = nil
+			for _, v := range votes {
+				// the votes of a dropped signer are discarded with the votes for it
+				if v.Address != candidate && (authorize || v.Signer != candidate) {
+					next.Votes = append(next.Votes, v)
+				}
+			}
+			if authorize {
+				next.Signers = append(next.Signers, candidate)
+				sort.Slice(next.Signers, func(i, j int) bool {
+					return bytes.Compare(next.Signers[i][:], next.Signers[j][:]) < 0
+				})
+			} else {
+				for i, s := range next.Signers {
+					if s == candidate {
+						next.Signers = append(next.Signers[:i], next.Signers[i+1:]...)
+						break
+					}
+				}
+			}
+		}
+	}
+	next.Recents = []cliqueRecent{{Height: height, Signer: signer}}
+	for _, r := range this.Recents {
+		if height-r.Height < next.limit() {
+			next.Recents = append(next.Recents, r)
+		}
+	}
+	return next
+}
+
+// checkpointExtra is the signer list a checkpoint header must carry
+func (this *cliqueSnapshot) checkpointExtra() []byte {
+	list := make([]byte, 0, len(this.Signers)*ethcommon.AddressLength)
+	for _, s := range this.Signers {
+		list = append(list, s[:]...)
+	}
+	return list
+}
+
+// CliqueProof proves that the storage of the configured contract holds the
+// keccak256 hash of Value, a ToMerkleValue, in the slot of its tx hash in the
+// configured mapping
+type CliqueProof struct {
+	AccountProof [][]byte
+	StorageProof [][]byte
+	Value        []byte
+}
+
+func (this *CliqueProof) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(len(this.AccountProof)))
+	for _, node := range this.AccountProof {
+		utils.EncodeVarBytes(sink, node)
+	}
+	utils.EncodeVarUint(sink, uint64(len(this.StorageProof)))
+	for _, node := range this.StorageProof {
+		utils.EncodeVarBytes(sink, node)
+	}
+	utils.EncodeVarBytes(sink, this.Value)
+}
+
+func (this *CliqueProof) Deserialization(source *common.ZeroCopySource) error {
+	accountProof, err := decodeProofNodes(source)
+	if err != nil {
+		return fmt.Errorf("deserialize account proof error: %v", err)
+	}
+	storageProof, err := decodeProofNodes(source)
+	if err != nil {
+		return fmt.Errorf("deserialize storage proof error: %v", err)
+	}
+	value, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("deserialize value error: %v", err)
+	}
+	if source.Len() != 0 {
+		return fmt.Errorf("trailing bytes after proof")
+	}
+	this.AccountProof = accountProof
+	this.StorageProof = storageProof
+	this.Value = value
+	return nil
+}
+
+func decodeProofNodes(source *common.ZeroCopySource) ([][]byte, error) {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return nil, err
+	}
+	var nodes [][]byte
+	for i := uint64(0); i < n; i++ {
+		node, err := utils.DecodeVarBytes(source)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// cliqueVerifier verifies the headers of an ethereum chain running the clique
+// proof of authority engine, and the account and storage proofs against the
+// state root of the headers.
+//
+// The headers are synced from the genesis header, which must be a checkpoint
+// header listing the signers, and the proofs are only verified against the
+// confirmed headers of the branch with the greatest total difficulty. The
+// votes are tallied as in clique and the following checkpoint headers must
+// list the signer set resulting from them.
+type cliqueVerifier struct{}
+
+func (cliqueVerifier) CheckConfig(config []byte) error {
+	return new(CliqueConfig).Deserialization(common.NewZeroCopySource(config))
+}
+
+func decodeEthHeader(raw []byte) (*ethtypes.Header, uint32, error) {
+	header := new(ethtypes.Header)
+	if err := rlp.DecodeBytes(raw, header); err != nil {
+		return nil, 0, fmt.Errorf("rlp decode header error: %v", err)
+	}
+	if header.Number == nil || !header.Number.IsUint64() || header.Number.Uint64() > math.MaxUint32 {
+		return nil, 0, fmt.Errorf("invalid header number %v", header.Number)
+	}
+	if len(header.Extra) < cliqueExtraVanity+cliqueExtraSeal {
+		return nil, 0, fmt.Errorf("extra data of header %d is too short", header.Number)
+	}
+	return header, uint32(header.Number.Uint64()), nil
+}
+
+// cliqueCheckpointSigners returns the signers listed by a checkpoint header
+func cliqueCheckpointSigners(header *ethtypes.Header) ([]ethcommon.Address, error) {
+	list := header.Extra[cliqueExtraVanity : len(header.Extra)-cliqueExtraSeal]
+	if len(list)%ethcommon.AddressLength != 0 {
+		return nil, fmt.Errorf("invalid signer list of header %d", header.Number)
+	}
+	signers := make([]ethcommon.Address, len(list)/ethcommon.AddressLength)
+	for i := range signers {
+		copy(signers[i][:], list[i*ethcommon.AddressLength:])
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+	return signers, nil
+}
+
+// cliqueSealHash is the hash signed by the sealer, the header without its seal
+func cliqueSealHash(header *ethtypes.Header) ethcommon.Hash {
+	enc := []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-cliqueExtraSeal],
+		header.MixDigest,
+		header.Nonce,
+	}
+	if header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	data, _ := rlp.EncodeToBytes(enc)
+	return crypto.Keccak256Hash(data)
+}
+
+func cliqueSigner(header *ethtypes.Header) (ethcommon.Address, error) {
+	seal := header.Extra[len(header.Extra)-cliqueExtraSeal:]
+	pubkey, err := crypto.SigToPub(cliqueSealHash(header).Bytes(), seal)
+	if err != nil {
+		return ethcommon.Address{}, fmt.Errorf("recover signer of header %d error: %v", header.Number, err)
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// cliqueHeader is a synced header together with the total difficulty of the
+// chain it ends and the snapshot its children are verified with. The headers
+// are kept by hash so that competing branches can be synced, the heaviest
+// branch is indexed by height as the chain proofs are verified against.
+type cliqueHeader struct {
+	Raw             []byte
+	TotalDifficulty *big.Int
+	Snapshot        cliqueSnapshot
+}
+
+func (this *cliqueHeader) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarBytes(sink, this.Raw)
+	utils.EncodeVarBytes(sink, this.TotalDifficulty.Bytes())
+	this.Snapshot.Serialization(sink)
+}
+
+func (this *cliqueHeader) Deserialization(source *common.ZeroCopySource) error {
+	raw, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("deserialize raw header error: %v", err)
+	}
+	td, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("deserialize total difficulty error: %v", err)
+	}
+	if err := this.Snapshot.Deserialization(source); err != nil {
+		return err
+	}
+	this.Raw = raw
+	this.TotalDifficulty = new(big.Int).SetBytes(td)
+	return nil
+}
+
+func putCliqueHeader(native *native.NativeService, chainID uint64, hash ethcommon.Hash, header *cliqueHeader) error {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return fmt.Errorf("putCliqueHeader, GetUint64Bytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(CLIQUE_HEADER), chainIDBytes, hash[:]),
+		cstates.GenRawStorageItem(common.SerializeToBytes(header)))
+	return nil
+}
+
+func getCliqueHeader(native *native.NativeService, chainID uint64, hash ethcommon.Hash) (*cliqueHeader, error) {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("getCliqueHeader, GetUint64Bytes error: %v", err)
+	}
+	value, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(CLIQUE_HEADER), chainIDBytes, hash[:]))
+	if err != nil {
+		return nil, fmt.Errorf("getCliqueHeader, get header error: %v", err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(value)
+	if err != nil {
+		return nil, fmt.Errorf("getCliqueHeader, deserialize from raw storage item err:%v", err)
+	}
+	header := new(cliqueHeader)
+	if err := header.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("getCliqueHeader, deserialize header error: %v", err)
+	}
+	return header, nil
+}
+
+// getCliqueHead returns the hash of the head of the heaviest branch
+func getCliqueHead(native *native.NativeService, chainID uint64) (ethcommon.Hash, error) {
+	state, err := getVerifierState(native, chainID)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	if len(state) != ethcommon.HashLength {
+		return ethcommon.Hash{}, fmt.Errorf("invalid clique head of chain %d", chainID)
+	}
+	return ethcommon.BytesToHash(state), nil
+}
+
+func (cliqueVerifier) SyncGenesisHeader(native *native.NativeService, cv *ChainVerifier, raw []byte) error {
+	config, err := decodeCliqueConfig(cv)
+	if err != nil {
+		return err
+	}
+	header, height, err := decodeEthHeader(raw)
+	if err != nil {
+		return err
+	}
+	if height%config.Epoch != 0 {
+		return fmt.Errorf("genesis header %d is not at a checkpoint height", height)
+	}
+	signers, err := cliqueCheckpointSigners(header)
+	if err != nil {
+		return err
+	}
+	if len(signers) == 0 {
+		return fmt.Errorf("genesis header %d is not a checkpoint header", height)
+	}
+	hash := header.Hash()
+	genesis := &cliqueHeader{Raw: raw, TotalDifficulty: new(big.Int), Snapshot: cliqueSnapshot{Signers: signers}}
+	if err := putCliqueHeader(native, cv.ChainID, hash, genesis); err != nil {
+		return err
+	}
+	if err := putVerifierState(native, cv.ChainID, hash[:]); err != nil {
+		return err
+	}
+	return putChainHeader(native, cv.ChainID, height, hash.Bytes(), raw)
+}
+
+// SyncBlockHeader verifies a header against the snapshot of its parent, which
+// may be on any synced branch, and switches the chain to the branch of the
+// header when that branch has the greater total difficulty. As in clique the
+// in turn headers weigh twice as much as the out of turn headers.
+func (cliqueVerifier) SyncBlockHeader(native *native.NativeService, cv *ChainVerifier, raw []byte) error {
+	config, err := decodeCliqueConfig(cv)
+	if err != nil {
+		return err
+	}
+	header, height, err := decodeEthHeader(raw)
+	if err != nil {
+		return err
+	}
+	hash := header.Hash()
+	exist, err := getCliqueHeader(native, cv.ChainID, hash)
+	if err != nil {
+		return err
+	}
+	if exist != nil {
+		return nil
+	}
+	parentState, err := getCliqueHeader(native, cv.ChainID, header.ParentHash)
+	if err != nil {
+		return err
+	}
+	if parentState == nil {
+		return fmt.Errorf("parent of header %d is not synced", height)
+	}
+	parent, parentHeight, err := decodeEthHeader(parentState.Raw)
+	if err != nil {
+		return fmt.Errorf("decode parent header error: %v", err)
+	}
+	if height != parentHeight+1 {
+		return fmt.Errorf("header %d is not next to its parent %d", height, parentHeight)
+	}
+	if header.Time < parent.Time+config.Period {
+		return fmt.Errorf("timestamp of header %d is within the period of its parent", height)
+	}
+	if header.Time > uint64(native.Time)+cliqueAllowedFutureTime {
+		return fmt.Errorf("timestamp of header %d is in the future", height)
+	}
+	if header.UncleHash != ethtypes.EmptyUncleHash || header.MixDigest != (ethcommon.Hash{}) {
+		return fmt.Errorf("header %d is not a clique header", height)
+	}
+	checkpoint := height%config.Epoch == 0
+	list := header.Extra[cliqueExtraVanity : len(header.Extra)-cliqueExtraSeal]
+	if checkpoint {
+		if header.Coinbase != (ethcommon.Address{}) || header.Nonce != cliqueNonceDropVote {
+			return fmt.Errorf("checkpoint header %d casts a vote", height)
+		}
+	} else {
+		if len(list) != 0 {
+			return fmt.Errorf("header %d lists signers but is not a checkpoint header", height)
+		}
+		if header.Nonce != cliqueNonceAuthVote && header.Nonce != cliqueNonceDropVote {
+			return fmt.Errorf("invalid vote nonce of header %d", height)
+		}
+	}
+
+	snap := parentState.Snapshot
+	signer, err := cliqueSigner(header)
+	if err != nil {
+		return err
+	}
+	if !snap.contains(signer) {
+		return fmt.Errorf("signer %s of header %d is not authorized", signer.Hex(), height)
+	}
+	for _, r := range snap.Recents {
+		if r.Signer == signer && height-r.Height < snap.limit() {
+			return fmt.Errorf("signer %s of header %d signed recently", signer.Hex(), height)
+		}
+	}
+	diff := cliqueDiffNoTurn
+	if snap.inTurn(height, signer) {
+		diff = cliqueDiffInTurn
+	}
+	if header.Difficulty == nil || header.Difficulty.Cmp(diff) != 0 {
+		return fmt.Errorf("invalid difficulty %v of header %d", header.Difficulty, height)
+	}
+	if checkpoint && !bytes.Equal(list, snap.checkpointExtra()) {
+		return fmt.Errorf("signers of checkpoint header %d do not match the voted signers", height)
+	}
+
+	state := &cliqueHeader{
+		Raw:             raw,
+		TotalDifficulty: new(big.Int).Add(parentState.TotalDifficulty, diff),
+		Snapshot:        snap.apply(header, height, signer, checkpoint),
+	}
+	if err := putCliqueHeader(native, cv.ChainID, hash, state); err != nil {
+		return err
+	}
+
+	headHash, err := getCliqueHead(native, cv.ChainID)
+	if err != nil {
+		return err
+	}
+	head, err := getCliqueHeader(native, cv.ChainID, headHash)
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		return fmt.Errorf("head of chain %d is not synced", cv.ChainID)
+	}
+	// on a tie the branch synced first is kept
+	if state.TotalDifficulty.Cmp(head.TotalDifficulty) <= 0 {
+		return nil
+	}
+	return setCliqueHead(native, cv.ChainID, hash, state)
+}
+
+// setCliqueHead indexes the branch ending with head by height, replacing the
+// headers of the former branch down to the common ancestor
+func setCliqueHead(native *native.NativeService, chainID uint64, hash ethcommon.Hash, head *cliqueHeader) error {
+	current, _, err := GetCurrentHeight(native, chainID)
+	if err != nil {
+		return err
+	}
+	header, height, err := decodeEthHeader(head.Raw)
+	if err != nil {
+		return err
+	}
+	if err := putVerifierState(native, chainID, hash[:]); err != nil {
+		return err
+	}
+	if err := putChainHeader(native, chainID, height, hash.Bytes(), head.Raw); err != nil {
+		return err
+	}
+	for h := height + 1; h <= current; h++ {
+		if err := deleteChainHeader(native, chainID, h); err != nil {
+			return err
+		}
+	}
+	for height > 0 {
+		height--
+		canonical, err := getChainHeader(native, chainID, height)
+		if err != nil {
+			return err
+		}
+		if canonical != nil && crypto.Keccak256Hash(canonical) == header.ParentHash {
+			break
+		}
+		parent, err := getCliqueHeader(native, chainID, header.ParentHash)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return fmt.Errorf("header %d of the branch is not synced", height)
+		}
+		if err := putChainHeaderAt(native, chainID, height, parent.Raw); err != nil {
+			return err
+		}
+		if header, _, err = decodeEthHeader(parent.Raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this cliqueVerifier) VerifyProof(native *native.NativeService, cv *ChainVerifier, height uint32,
+	proof, raw []byte) ([]byte, error) {
+	config, err := decodeCliqueConfig(cv)
+	if err != nil {
+		return nil, err
+	}
+	headerRaw, err := getChainHeader(native, cv.ChainID, height)
+	if err != nil {
+		return nil, err
+	}
+	if headerRaw == nil && len(raw) != 0 {
+		if err := this.SyncBlockHeader(native, cv, raw); err != nil {
+			return nil, err
+		}
+		// the synced header is only used when its branch became the chain
+		if headerRaw, err = getChainHeader(native, cv.ChainID, height); err != nil {
+			return nil, err
+		}
+	}
+	if headerRaw == nil {
+		return nil, fmt.Errorf("header %d of chain %d is not synced", height, cv.ChainID)
+	}
+	current, _, err := GetCurrentHeight(native, cv.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(height)+uint64(config.Confirmations) > uint64(current) {
+		return nil, fmt.Errorf("header %d has not been confirmed by %d headers", height, config.Confirmations)
+	}
+	header, _, err := decodeEthHeader(headerRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(CliqueProof)
+	if err := p.Deserialization(common.NewZeroCopySource(proof)); err != nil {
+		return nil, fmt.Errorf("deserialize clique proof error: %v", err)
+	}
+	merkleValue := new(ccom.ToMerkleValue)
+	if err := merkleValue.Deserialization(common.NewZeroCopySource(p.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize cross chain value error: %v", err)
+	}
+	key, err := cliqueStorageKey(merkleValue.MakeTxParam.TxHash, config.MappingSlot)
+	if err != nil {
+		return nil, err
+	}
+	storageRoot, err := verifyEthAccountProof(header.Root, config.ContractAddress, p.AccountProof)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := verifyEthStorageProof(storageRoot, key, p.StorageProof)
+	if err != nil {
+		return nil, err
+	}
+	if ethcommon.BytesToHash(stored) != crypto.Keccak256Hash(p.Value) {
+		return nil, fmt.Errorf("stored hash %x does not match the value", stored)
+	}
+	return p.Value, nil
+}
+
+// cliqueStorageKey is the slot of txHash in the solidity mapping at slot
+// mappingSlot, txHash being the 32 bytes key of the mapping
+func cliqueStorageKey(txHash []byte, mappingSlot uint64) ([]byte, error) {
+	if len(txHash) > ethcommon.HashLength {
+		return nil, fmt.Errorf("invalid tx hash length %d", len(txHash))
+	}
+	slot := new(big.Int).SetUint64(mappingSlot)
+	return crypto.Keccak256(ethcommon.LeftPadBytes(txHash, ethcommon.HashLength), ethcommon.BigToHash(slot).Bytes()), nil
+}
+
+func newProofDB(nodes [][]byte) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range nodes {
+		_ = db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// verifyEthAccountProof returns the storage root of the account at addr
+func verifyEthAccountProof(root ethcommon.Hash, addr ethcommon.Address, nodes [][]byte) (ethcommon.Hash, error) {
+	value, err := trie.VerifyProof(root, crypto.Keccak256(addr[:]), newProofDB(nodes))
+	if err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("verify account proof error: %v", err)
+	}
+	if value == nil {
+		return ethcommon.Hash{}, fmt.Errorf("account %s does not exist", addr.Hex())
+	}
+	var account struct {
+		Nonce    uint64
+		Balance  *big.Int
+		Root     ethcommon.Hash
+		CodeHash []byte
+	}
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("decode account error: %v", err)
+	}
+	return account.Root, nil
+}
+
+// verifyEthStorageProof returns the value stored at the 32 bytes slot key
+func verifyEthStorageProof(root ethcommon.Hash, key []byte, nodes [][]byte) ([]byte, error) {
+	if len(key) != ethcommon.HashLength {
+		return nil, fmt.Errorf("invalid storage key length %d", len(key))
+	}
+	value, err := trie.VerifyProof(root, crypto.Keccak256(key), newProofDB(nodes))
+	if err != nil {
+		return nil, fmt.Errorf("verify storage proof error: %v", err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("storage slot %x is empty", key)
+	}
+	var stored []byte
+	if err := rlp.DecodeBytes(value, &stored); err != nil {
+		return nil, fmt.Errorf("decode storage value error: %v", err)
+	}
+	return stored, nil
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/smartcontract/service/native"
 	ccom "github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/common"
 	"github.com/qbyyf/ontology/smartcontract/service/native/global_params"
@@ -32,6 +33,7 @@ const (
 	//function name
 	SYNC_GENESIS_HEADER = "syncGenesisHeader"
 	SYNC_BLOCK_HEADER   = "syncBlockHeader"
+	SET_CHAIN_VERIFIER  = "setChainVerifier"
 
 	//key prefix
 	BLOCK_HEADER   = "blockHeader"
@@ -39,6 +41,10 @@ const (
 	HEADER_INDEX   = "headerIndex"
 	CONSENSUS_PEER = "consensusPeer"
 	KEY_HEIGHTS    = "keyHeights"
+	CHAIN_VERIFIER = "chainVerifier"
+	CHAIN_HEADER   = "chainHeader"
+	VERIFIER_STATE = "verifierState"
+	CLIQUE_HEADER  = "cliqueHeader"
 )
 
 //Init governance contract address
@@ -50,11 +56,12 @@ func InitHeaderSync() {
 func RegisterHeaderSyncContract(native *native.NativeService) {
 	native.Register(SYNC_GENESIS_HEADER, SyncGenesisHeader)
 	native.Register(SYNC_BLOCK_HEADER, SyncBlockHeader)
+	native.Register(SET_CHAIN_VERIFIER, SetChainVerifier)
 }
 
 func SyncGenesisHeader(native *native.NativeService) ([]byte, error) {
 	params := new(SyncGenesisHeaderParam)
-	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+	if err := params.deserialization(common.NewZeroCopySource(native.Input), isVerifierActivated(native)); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, contract params deserialize error: %v", err)
 	}
 
@@ -71,43 +78,109 @@ func SyncGenesisHeader(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, checkWitness error: %v", err)
 	}
 
-	header, err := ccom.HeaderFromRawBytes(params.GenesisHeader)
+	chainID, err := headerChainID(params.ChainID, params.GenesisHeader)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, deserialize header err: %v", err)
 	}
-	//block header storage
-	err = PutBlockHeader(native, header, params.GenesisHeader)
+	verifier, cv, err := GetVerifier(native, chainID)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, put blockHeader error: %v", err)
+		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, %v", err)
 	}
-
-	//consensus node pk storage
-	err = UpdateConsensusPeer(native, header)
-	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, update ConsensusPeer error: %v", err)
+	if err := verifier.SyncGenesisHeader(native, cv, params.GenesisHeader); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, %v", err)
 	}
 	return utils.BYTE_TRUE, nil
 }
 
 func SyncBlockHeader(native *native.NativeService) ([]byte, error) {
 	params := new(SyncBlockHeaderParam)
-	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+	if err := params.deserialization(common.NewZeroCopySource(native.Input), isVerifierActivated(native)); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 	for _, v := range params.Headers {
-		header, err := ccom.HeaderFromRawBytes(v)
+		chainID, err := headerChainID(params.ChainID, v)
 		if err != nil {
 			return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, new_types.HeaderFromRawBytes error: %v", err)
 		}
-		h, err := GetHeaderByHeight(native, header.ChainID, header.Height)
+		verifier, cv, err := GetVerifier(native, chainID)
 		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, %d, %d", header.ChainID, header.Height)
+			return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, %v", err)
 		}
-		if h == nil {
-			if err := ProcessHeader(native, header, v); err != nil {
-				return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, error:%s", err)
-			}
+		if err := verifier.SyncBlockHeader(native, cv, v); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, error:%s", err)
 		}
 	}
 	return utils.BYTE_TRUE, nil
 }
+
+func SetChainVerifier(native *native.NativeService) ([]byte, error) {
+	if !isVerifierActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	params := new(SetChainVerifierParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, contract params deserialize error: %v", err)
+	}
+
+	// get admin from database
+	operatorAddress, err := global_params.GetStorageRole(native,
+		global_params.GenerateOperatorKey(utils.ParamContractAddress))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getAdmin, get admin error: %v", err)
+	}
+
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, checkWitness error: %v", err)
+	}
+
+	verifier, ok := verifiers[params.VerifierType]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, verifier type %d is not registered", params.VerifierType)
+	}
+	if err := verifier.CheckConfig(params.Config); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, invalid config: %v", err)
+	}
+	//the synced headers can only be extended by the verifier which synced them
+	old, err := GetChainVerifier(native, params.ChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, %v", err)
+	}
+	_, synced, err := GetCurrentHeight(native, params.ChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, %v", err)
+	}
+	if synced && old.VerifierType != params.VerifierType {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, headers of chain %d are already synced by verifier %d",
+			params.ChainID, old.VerifierType)
+	}
+	cv := &ChainVerifier{
+		ChainID:      params.ChainID,
+		VerifierType: params.VerifierType,
+		Config:       params.Config,
+	}
+	if err := putChainVerifier(native, cv); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetChainVerifier, %v", err)
+	}
+	notifySetChainVerifier(native, cv)
+	return utils.BYTE_TRUE, nil
+}
+
+//before the activation all the chains are verified as ontology chains and the
+//chain id of the params is ignored
+func isVerifierActivated(native *native.NativeService) bool {
+	return native.Height >= config.GetHeaderVerifierHeight()
+}
+
+//ontology headers carry their chain id, the other headers are synced with an explicit one
+func headerChainID(chainID uint64, raw []byte) (uint64, error) {
+	if chainID != 0 {
+		return chainID, nil
+	}
+	header, err := ccom.HeaderFromRawBytes(raw)
+	if err != nil {
+		return 0, err
+	}
+	return header.ChainID, nil
+}
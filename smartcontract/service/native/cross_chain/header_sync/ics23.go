@@ -0,0 +1,275 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package header_sync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+// hash and length operations of ics23
+const (
+	ICS23_NO_HASH = 0
+	ICS23_SHA256  = 1
+
+	ICS23_NO_PREFIX = 0
+	ICS23_VAR_PROTO = 1
+)
+
+// Ics23LeafOp hashes the key and the value of an ics23 existence proof into a leaf
+type Ics23LeafOp struct {
+	Hash         uint8
+	PrehashKey   uint8
+	PrehashValue uint8
+	Length       uint8
+	Prefix       []byte
+}
+
+func (this *Ics23LeafOp) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint8(this.Hash)
+	sink.WriteUint8(this.PrehashKey)
+	sink.WriteUint8(this.PrehashValue)
+	sink.WriteUint8(this.Length)
+	utils.EncodeVarBytes(sink, this.Prefix)
+}
+
+func (this *Ics23LeafOp) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	for _, op := range []*uint8{&this.Hash, &this.PrehashKey, &this.PrehashValue, &this.Length} {
+		if *op, eof = source.NextUint8(); eof {
+			return fmt.Errorf("deserialize leaf op error")
+		}
+	}
+	var err error
+	if this.Prefix, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize leaf prefix error: %v", err)
+	}
+	return nil
+}
+
+func (this *Ics23LeafOp) apply(key, value []byte) ([]byte, error) {
+	if len(key) == 0 || len(value) == 0 {
+		return nil, fmt.Errorf("leaf op needs key and value")
+	}
+	pkey, err := ics23LeafData(this.PrehashKey, this.Length, key)
+	if err != nil {
+		return nil, err
+	}
+	pvalue, err := ics23LeafData(this.PrehashValue, this.Length, value)
+	if err != nil {
+		return nil, err
+	}
+	data := append(append(append([]byte{}, this.Prefix...), pkey...), pvalue...)
+	return ics23Hash(this.Hash, data)
+}
+
+// Ics23InnerOp hashes a child with its siblings, which are in the prefix and
+// the suffix, into its parent
+type Ics23InnerOp struct {
+	Hash   uint8
+	Prefix []byte
+	Suffix []byte
+}
+
+func (this *Ics23InnerOp) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint8(this.Hash)
+	utils.EncodeVarBytes(sink, this.Prefix)
+	utils.EncodeVarBytes(sink, this.Suffix)
+}
+
+func (this *Ics23InnerOp) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	if this.Hash, eof = source.NextUint8(); eof {
+		return fmt.Errorf("deserialize inner op hash error")
+	}
+	var err error
+	if this.Prefix, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize inner prefix error: %v", err)
+	}
+	if this.Suffix, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize inner suffix error: %v", err)
+	}
+	return nil
+}
+
+func (this *Ics23InnerOp) apply(child []byte) ([]byte, error) {
+	if len(child) == 0 {
+		return nil, fmt.Errorf("inner op needs child")
+	}
+	data := append(append(append([]byte{}, this.Prefix...), child...), this.Suffix...)
+	return ics23Hash(this.Hash, data)
+}
+
+// Ics23ExistenceProof proves that Key holds Value in the tree whose root is
+// computed from the leaf through the path
+type Ics23ExistenceProof struct {
+	Key   []byte
+	Value []byte
+	Leaf  Ics23LeafOp
+	Path  []*Ics23InnerOp
+}
+
+func (this *Ics23ExistenceProof) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarBytes(sink, this.Key)
+	utils.EncodeVarBytes(sink, this.Value)
+	this.Leaf.Serialization(sink)
+	utils.EncodeVarUint(sink, uint64(len(this.Path)))
+	for _, op := range this.Path {
+		op.Serialization(sink)
+	}
+}
+
+func (this *Ics23ExistenceProof) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Key, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize key error: %v", err)
+	}
+	if this.Value, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize value error: %v", err)
+	}
+	if err := this.Leaf.Deserialization(source); err != nil {
+		return err
+	}
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize path length error: %v", err)
+	}
+	this.Path = nil
+	for i := uint64(0); i < n; i++ {
+		op := new(Ics23InnerOp)
+		if err := op.Deserialization(source); err != nil {
+			return err
+		}
+		this.Path = append(this.Path, op)
+	}
+	return nil
+}
+
+// verify checks the proof against the spec of the tree and then the root
+// computed from it, as ics23 VerifyMembership does
+func (this *Ics23ExistenceProof) verify(spec *ics23Spec, root []byte) error {
+	if err := spec.check(this); err != nil {
+		return err
+	}
+	hash, err := this.Leaf.apply(this.Key, this.Value)
+	if err != nil {
+		return err
+	}
+	for _, op := range this.Path {
+		if hash, err = op.apply(hash); err != nil {
+			return err
+		}
+	}
+	if !bytes.Equal(hash, root) {
+		return fmt.Errorf("calculated root %x does not match %x", hash, root)
+	}
+	return nil
+}
+
+// ics23Spec is the shape of the proofs of a kind of tree, the proofs which do
+// not fit it could prove a leaf as an inner node or the other way around
+type ics23Spec struct {
+	leaf            Ics23LeafOp
+	innerHash       uint8
+	childCount      int
+	childSize       int
+	minPrefixLength int
+	maxPrefixLength int
+}
+
+var (
+	// the iavl trees of the cosmos stores
+	ics23IavlSpec = &ics23Spec{
+		leaf: Ics23LeafOp{Hash: ICS23_SHA256, PrehashKey: ICS23_NO_HASH, PrehashValue: ICS23_SHA256,
+			Length: ICS23_VAR_PROTO, Prefix: []byte{0}},
+		innerHash:       ICS23_SHA256,
+		childCount:      2,
+		childSize:       33,
+		minPrefixLength: 4,
+		maxPrefixLength: 12,
+	}
+	// the simple merkle tree of tendermint, which commits the store roots to the app hash
+	ics23TendermintSpec = &ics23Spec{
+		leaf: Ics23LeafOp{Hash: ICS23_SHA256, PrehashKey: ICS23_NO_HASH, PrehashValue: ICS23_SHA256,
+			Length: ICS23_VAR_PROTO, Prefix: []byte{0}},
+		innerHash:       ICS23_SHA256,
+		childCount:      2,
+		childSize:       32,
+		minPrefixLength: 1,
+		maxPrefixLength: 1,
+	}
+)
+
+func (this *ics23Spec) check(proof *Ics23ExistenceProof) error {
+	leaf := &proof.Leaf
+	if leaf.Hash != this.leaf.Hash || leaf.PrehashKey != this.leaf.PrehashKey ||
+		leaf.PrehashValue != this.leaf.PrehashValue || leaf.Length != this.leaf.Length {
+		return fmt.Errorf("leaf op does not match the spec")
+	}
+	if !bytes.HasPrefix(leaf.Prefix, this.leaf.Prefix) {
+		return fmt.Errorf("leaf prefix %x does not start with %x", leaf.Prefix, this.leaf.Prefix)
+	}
+	maxLeftChildBytes := (this.childCount - 1) * this.childSize
+	for i, op := range proof.Path {
+		if op.Hash != this.innerHash {
+			return fmt.Errorf("inner op %d does not match the spec", i)
+		}
+		if bytes.HasPrefix(op.Prefix, this.leaf.Prefix) {
+			return fmt.Errorf("inner op %d has the prefix of a leaf", i)
+		}
+		if len(op.Prefix) < this.minPrefixLength || len(op.Prefix) > this.maxPrefixLength+maxLeftChildBytes {
+			return fmt.Errorf("inner op %d has an invalid prefix length %d", i, len(op.Prefix))
+		}
+		if len(op.Suffix)%this.childSize != 0 || len(op.Suffix) > maxLeftChildBytes {
+			return fmt.Errorf("inner op %d has an invalid suffix length %d", i, len(op.Suffix))
+		}
+	}
+	return nil
+}
+
+func ics23Hash(op uint8, data []byte) ([]byte, error) {
+	switch op {
+	case ICS23_NO_HASH:
+		return data, nil
+	case ICS23_SHA256:
+		hash := sha256.Sum256(data)
+		return hash[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported hash op %d", op)
+	}
+}
+
+func ics23LeafData(hashOp, lengthOp uint8, data []byte) ([]byte, error) {
+	hashed, err := ics23Hash(hashOp, data)
+	if err != nil {
+		return nil, err
+	}
+	switch lengthOp {
+	case ICS23_NO_PREFIX:
+		return hashed, nil
+	case ICS23_VAR_PROTO:
+		return append(appendUvarint(nil, uint64(len(hashed))), hashed...), nil
+	default:
+		return nil, fmt.Errorf("unsupported length op %d", lengthOp)
+	}
+}
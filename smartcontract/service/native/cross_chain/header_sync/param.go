@@ -28,6 +28,9 @@ import (
 type SyncBlockHeaderParam struct {
 	Address common.Address
 	Headers [][]byte
+	// ChainID is optional, the headers are ontology headers carrying their own
+	// chain id when it is 0, otherwise they are verified by the chain's verifier
+	ChainID uint64
 }
 
 func (this *SyncBlockHeaderParam) Serialization(sink *common.ZeroCopySink) {
@@ -36,9 +39,18 @@ func (this *SyncBlockHeaderParam) Serialization(sink *common.ZeroCopySink) {
 	for _, v := range this.Headers {
 		utils.EncodeVarBytes(sink, v)
 	}
+	if this.ChainID != 0 {
+		utils.EncodeVarUint(sink, this.ChainID)
+	}
 }
 
 func (this *SyncBlockHeaderParam) Deserialization(source *common.ZeroCopySource) error {
+	return this.deserialization(source, true)
+}
+
+// deserialization only decodes the chain id when withChainID is set, before
+// the activation of the verifiers any trailing bytes are ignored as they were
+func (this *SyncBlockHeaderParam) deserialization(source *common.ZeroCopySource, withChainID bool) error {
 	address, err := utils.DecodeAddress(source)
 	if err != nil {
 		return fmt.Errorf("utils.DecodeAddress, deserialize address error:%s", err)
@@ -55,24 +67,82 @@ func (this *SyncBlockHeaderParam) Deserialization(source *common.ZeroCopySource)
 		}
 		headers = append(headers, header)
 	}
+	var chainID uint64
+	if withChainID && source.Len() > 0 {
+		chainID, err = utils.DecodeVarUint(source)
+		if err != nil {
+			return fmt.Errorf("utils.DecodeVarUint, deserialize chainID error:%s", err)
+		}
+	}
 	this.Address = address
 	this.Headers = headers
+	this.ChainID = chainID
 	return nil
 }
 
 type SyncGenesisHeaderParam struct {
 	GenesisHeader []byte
+	// ChainID is optional, see SyncBlockHeaderParam
+	ChainID uint64
 }
 
 func (this *SyncGenesisHeaderParam) Serialization(sink *common.ZeroCopySink) {
 	utils.EncodeVarBytes(sink, this.GenesisHeader)
+	if this.ChainID != 0 {
+		utils.EncodeVarUint(sink, this.ChainID)
+	}
 }
 
 func (this *SyncGenesisHeaderParam) Deserialization(source *common.ZeroCopySource) error {
+	return this.deserialization(source, true)
+}
+
+// deserialization only decodes the chain id when withChainID is set, see
+// SyncBlockHeaderParam
+func (this *SyncGenesisHeaderParam) deserialization(source *common.ZeroCopySource, withChainID bool) error {
 	genesisHeader, err := utils.DecodeVarBytes(source)
 	if err != nil {
 		return fmt.Errorf("utils.DecodeVarBytes, deserialize genesisHeader count error:%s", err)
 	}
+	var chainID uint64
+	if withChainID && source.Len() > 0 {
+		chainID, err = utils.DecodeVarUint(source)
+		if err != nil {
+			return fmt.Errorf("utils.DecodeVarUint, deserialize chainID error:%s", err)
+		}
+	}
 	this.GenesisHeader = genesisHeader
+	this.ChainID = chainID
+	return nil
+}
+
+type SetChainVerifierParam struct {
+	ChainID      uint64
+	VerifierType uint64
+	Config       []byte
+}
+
+func (this *SetChainVerifierParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.ChainID)
+	utils.EncodeVarUint(sink, this.VerifierType)
+	utils.EncodeVarBytes(sink, this.Config)
+}
+
+func (this *SetChainVerifierParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize chainID error:%s", err)
+	}
+	verifierType, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize verifierType error:%s", err)
+	}
+	config, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize config error:%s", err)
+	}
+	this.ChainID = chainID
+	this.VerifierType = verifierType
+	this.Config = config
 	return nil
 }
@@ -140,3 +140,35 @@ func (this *ConsensusPeers) Deserialization(source *common.ZeroCopySource) error
 	this.PeerMap = peerMap
 	return nil
 }
+
+// ChainVerifier records which verifier checks the headers and proofs of a chain
+type ChainVerifier struct {
+	ChainID      uint64
+	VerifierType uint64
+	Config       []byte
+}
+
+func (this *ChainVerifier) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.ChainID)
+	utils.EncodeVarUint(sink, this.VerifierType)
+	utils.EncodeVarBytes(sink, this.Config)
+}
+
+func (this *ChainVerifier) Deserialization(source *common.ZeroCopySource) error {
+	chainID, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize chainID error: %v", err)
+	}
+	verifierType, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize verifierType error: %v", err)
+	}
+	config, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize config error: %v", err)
+	}
+	this.ChainID = chainID
+	this.VerifierType = verifierType
+	this.Config = config
+	return nil
+}
@@ -0,0 +1,737 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package header_sync
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	tmPrecommitType     = 2 // SignedMsgType of a precommit vote
+	tmBlockIDFlagCommit = 2 // the validator signed for the block
+	tmAddressSize       = 20
+	tmMaxClockDrift     = 10 // seconds a header may be ahead of the block time
+)
+
+// TendermintConfig is the config of a chain verified by the tendermint verifier
+type TendermintConfig struct {
+	ChainID string
+	// TrustingPeriod is the seconds a header is trusted for, it should be
+	// shorter than the unbonding period of the chain
+	TrustingPeriod uint64
+	// StoreName is the store holding the cross chain values, under KeyPrefix
+	StoreName string
+	KeyPrefix []byte
+}
+
+func (this *TendermintConfig) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeString(sink, this.ChainID)
+	utils.EncodeVarUint(sink, this.TrustingPeriod)
+	utils.EncodeString(sink, this.StoreName)
+	utils.EncodeVarBytes(sink, this.KeyPrefix)
+}
+
+func (this *TendermintConfig) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.ChainID, err = utils.DecodeString(source); err != nil {
+		return fmt.Errorf("deserialize chain id error: %v", err)
+	}
+	if this.TrustingPeriod, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("deserialize trusting period error: %v", err)
+	}
+	if this.StoreName, err = utils.DecodeString(source); err != nil {
+		return fmt.Errorf("deserialize store name error: %v", err)
+	}
+	if this.KeyPrefix, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize key prefix error: %v", err)
+	}
+	if source.Len() != 0 {
+		return fmt.Errorf("trailing bytes after config")
+	}
+	if this.ChainID == "" || this.StoreName == "" {
+		return fmt.Errorf("tendermint chain id or store name is empty")
+	}
+	if this.TrustingPeriod == 0 || this.TrustingPeriod > math.MaxUint32 {
+		return fmt.Errorf("invalid trusting period %d", this.TrustingPeriod)
+	}
+	return nil
+}
+
+func getTendermintConfig(cv *ChainVerifier) (*TendermintConfig, error) {
+	config := new(TendermintConfig)
+	if err := config.Deserialization(common.NewZeroCopySource(cv.Config)); err != nil {
+		return nil, fmt.Errorf("deserialize tendermint config error: %v", err)
+	}
+	return config, nil
+}
+
+type TendermintBlockID struct {
+	Hash       []byte
+	PartsTotal uint32
+	PartsHash  []byte
+}
+
+func (this *TendermintBlockID) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarBytes(sink, this.Hash)
+	sink.WriteUint32(this.PartsTotal)
+	utils.EncodeVarBytes(sink, this.PartsHash)
+}
+
+func (this *TendermintBlockID) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Hash, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize hash error: %v", err)
+	}
+	var eof bool
+	if this.PartsTotal, eof = source.NextUint32(); eof {
+		return fmt.Errorf("deserialize parts total error")
+	}
+	if this.PartsHash, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize parts hash error: %v", err)
+	}
+	return nil
+}
+
+func (this *TendermintBlockID) isZero() bool {
+	return len(this.Hash) == 0 && this.PartsTotal == 0 && len(this.PartsHash) == 0
+}
+
+// TendermintHeader carries the fields of a tendermint header which are hashed
+// into the block hash
+type TendermintHeader struct {
+	VersionBlock       uint64
+	VersionApp         uint64
+	ChainID            string
+	Height             int64
+	TimeSeconds        int64
+	TimeNanos          int32
+	LastBlockID        TendermintBlockID
+	LastCommitHash     []byte
+	DataHash           []byte
+	ValidatorsHash     []byte
+	NextValidatorsHash []byte
+	ConsensusHash      []byte
+	AppHash            []byte
+	LastResultsHash    []byte
+	EvidenceHash       []byte
+	ProposerAddress    []byte
+}
+
+func (this *TendermintHeader) hashes() []*[]byte {
+	return []*[]byte{&this.LastCommitHash, &this.DataHash, &this.ValidatorsHash, &this.NextValidatorsHash,
+		&this.ConsensusHash, &this.AppHash, &this.LastResultsHash, &this.EvidenceHash, &this.ProposerAddress}
+}
+
+func (this *TendermintHeader) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.VersionBlock)
+	sink.WriteUint64(this.VersionApp)
+	utils.EncodeString(sink, this.ChainID)
+	sink.WriteUint64(uint64(this.Height))
+	sink.WriteUint64(uint64(this.TimeSeconds))
+	sink.WriteUint32(uint32(this.TimeNanos))
+	this.LastBlockID.Serialization(sink)
+	for _, h := range this.hashes() {
+		utils.EncodeVarBytes(sink, *h)
+	}
+}
+
+func (this *TendermintHeader) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	if this.VersionBlock, eof = source.NextUint64(); eof {
+		return fmt.Errorf("deserialize version error")
+	}
+	if this.VersionApp, eof = source.NextUint64(); eof {
+		return fmt.Errorf("deserialize version error")
+	}
+	var err error
+	if this.ChainID, err = utils.DecodeString(source); err != nil {
+		return fmt.Errorf("deserialize chain id error: %v", err)
+	}
+	height, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize height error")
+	}
+	seconds, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize time error")
+	}
+	nanos, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("deserialize time error")
+	}
+	this.Height, this.TimeSeconds, this.TimeNanos = int64(height), int64(seconds), int32(nanos)
+	if err := this.LastBlockID.Deserialization(source); err != nil {
+		return fmt.Errorf("deserialize last block id error: %v", err)
+	}
+	for _, h := range this.hashes() {
+		if *h, err = utils.DecodeVarBytes(source); err != nil {
+			return fmt.Errorf("deserialize header hashes error: %v", err)
+		}
+	}
+	return nil
+}
+
+// Hash is the block hash, the merkle root of the proto encoded fields
+func (this *TendermintHeader) Hash() []byte {
+	var version []byte
+	version = pbUvarint(version, 1, this.VersionBlock)
+	version = pbUvarint(version, 2, this.VersionApp)
+	leaves := [][]byte{
+		version,
+		pbBytes(nil, 1, []byte(this.ChainID)),
+		pbUvarint(nil, 1, uint64(this.Height)),
+		pbTimestamp(this.TimeSeconds, this.TimeNanos),
+		pbBlockID(&this.LastBlockID),
+	}
+	for _, h := range this.hashes() {
+		leaves = append(leaves, pbBytes(nil, 1, *h))
+	}
+	return tmMerkleRoot(leaves)
+}
+
+type TendermintValidator struct {
+	PubKey      []byte // ed25519 public key
+	VotingPower int64
+}
+
+func (this *TendermintValidator) address() []byte {
+	hash := sha256.Sum256(this.PubKey)
+	return hash[:tmAddressSize]
+}
+
+type TendermintCommitSig struct {
+	BlockIDFlag      uint8
+	ValidatorAddress []byte
+	TimeSeconds      int64
+	TimeNanos        int32
+	Signature        []byte
+}
+
+type TendermintCommit struct {
+	Height     int64
+	Round      int32
+	BlockID    TendermintBlockID
+	Signatures []*TendermintCommitSig
+}
+
+// voteSignBytes is the length delimited canonical precommit a commit signature signs
+func (this *TendermintCommit) voteSignBytes(chainID string, sig *TendermintCommitSig) []byte {
+	var vote []byte
+	vote = pbUvarint(vote, 1, tmPrecommitType)
+	vote = pbFixed64(vote, 2, uint64(this.Height))
+	vote = pbFixed64(vote, 3, uint64(int64(this.Round)))
+	if !this.BlockID.isZero() {
+		var blockID []byte
+		blockID = pbBytes(blockID, 1, this.BlockID.Hash)
+		blockID = pbMessage(blockID, 2, pbPartSetHeader(&this.BlockID))
+		vote = pbMessage(vote, 4, blockID)
+	}
+	vote = pbMessage(vote, 5, pbTimestamp(sig.TimeSeconds, sig.TimeNanos))
+	vote = pbBytes(vote, 6, []byte(chainID))
+	return append(appendUvarint(nil, uint64(len(vote))), vote...)
+}
+
+// votingPower sums the power of the validators in vals which signed the
+// commit for its block, a bad signature of one of them fails the commit
+func (this *TendermintCommit) votingPower(chainID string, vals []*TendermintValidator) (signed, total int64, err error) {
+	byAddr := make(map[string]*TendermintValidator, len(vals))
+	for _, v := range vals {
+		if len(v.PubKey) != ed25519.PublicKeySize || v.VotingPower < 0 {
+			return 0, 0, fmt.Errorf("invalid validator %x", v.PubKey)
+		}
+		if total > math.MaxInt64-v.VotingPower {
+			return 0, 0, fmt.Errorf("total voting power overflow")
+		}
+		total += v.VotingPower
+		byAddr[string(v.address())] = v
+	}
+	for _, sig := range this.Signatures {
+		if sig.BlockIDFlag != tmBlockIDFlagCommit {
+			continue
+		}
+		v, ok := byAddr[string(sig.ValidatorAddress)]
+		if !ok {
+			continue
+		}
+		//count each validator once
+		delete(byAddr, string(sig.ValidatorAddress))
+		if !ed25519.Verify(v.PubKey, this.voteSignBytes(chainID, sig), sig.Signature) {
+			return 0, 0, fmt.Errorf("invalid commit signature of validator %x", sig.ValidatorAddress)
+		}
+		signed += v.VotingPower
+	}
+	return signed, total, nil
+}
+
+// TendermintSignedHeader is a header with the commit of its block and the
+// validator set which signed it
+type TendermintSignedHeader struct {
+	Header     TendermintHeader
+	Commit     TendermintCommit
+	Validators []*TendermintValidator
+}
+
+func (this *TendermintSignedHeader) Serialization(sink *common.ZeroCopySink) {
+	this.Header.Serialization(sink)
+	sink.WriteUint64(uint64(this.Commit.Height))
+	sink.WriteUint32(uint32(this.Commit.Round))
+	this.Commit.BlockID.Serialization(sink)
+	utils.EncodeVarUint(sink, uint64(len(this.Commit.Signatures)))
+	for _, sig := range this.Commit.Signatures {
+		sink.WriteUint8(sig.BlockIDFlag)
+		utils.EncodeVarBytes(sink, sig.ValidatorAddress)
+		sink.WriteUint64(uint64(sig.TimeSeconds))
+		sink.WriteUint32(uint32(sig.TimeNanos))
+		utils.EncodeVarBytes(sink, sig.Signature)
+	}
+	serializeTmValidators(sink, this.Validators)
+}
+
+func (this *TendermintSignedHeader) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.Header.Deserialization(source); err != nil {
+		return err
+	}
+	height, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize commit height error")
+	}
+	round, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("deserialize commit round error")
+	}
+	this.Commit.Height, this.Commit.Round = int64(height), int32(round)
+	if err := this.Commit.BlockID.Deserialization(source); err != nil {
+		return fmt.Errorf("deserialize commit block id error: %v", err)
+	}
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize signatures length error: %v", err)
+	}
+	this.Commit.Signatures = nil
+	for i := uint64(0); i < n; i++ {
+		sig := new(TendermintCommitSig)
+		if sig.BlockIDFlag, eof = source.NextUint8(); eof {
+			return fmt.Errorf("deserialize block id flag error")
+		}
+		if sig.ValidatorAddress, err = utils.DecodeVarBytes(source); err != nil {
+			return fmt.Errorf("deserialize validator address error: %v", err)
+		}
+		seconds, eof := source.NextUint64()
+		if eof {
+			return fmt.Errorf("deserialize signature time error")
+		}
+		nanos, eof := source.NextUint32()
+		if eof {
+			return fmt.Errorf("deserialize signature time error")
+		}
+		sig.TimeSeconds, sig.TimeNanos = int64(seconds), int32(nanos)
+		if sig.Signature, err = utils.DecodeVarBytes(source); err != nil {
+			return fmt.Errorf("deserialize signature error: %v", err)
+		}
+		this.Commit.Signatures = append(this.Commit.Signatures, sig)
+	}
+	this.Validators, err = deserializeTmValidators(source)
+	return err
+}
+
+func serializeTmValidators(sink *common.ZeroCopySink, vals []*TendermintValidator) {
+	utils.EncodeVarUint(sink, uint64(len(vals)))
+	for _, v := range vals {
+		utils.EncodeVarBytes(sink, v.PubKey)
+		sink.WriteUint64(uint64(v.VotingPower))
+	}
+}
+
+func deserializeTmValidators(source *common.ZeroCopySource) ([]*TendermintValidator, error) {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize validators length error: %v", err)
+	}
+	var vals []*TendermintValidator
+	for i := uint64(0); i < n; i++ {
+		v := new(TendermintValidator)
+		if v.PubKey, err = utils.DecodeVarBytes(source); err != nil {
+			return nil, fmt.Errorf("deserialize validator public key error: %v", err)
+		}
+		power, eof := source.NextUint64()
+		if eof {
+			return nil, fmt.Errorf("deserialize validator voting power error")
+		}
+		v.VotingPower = int64(power)
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// tmValidatorsHash is the merkle root of the proto encoded validators
+func tmValidatorsHash(vals []*TendermintValidator) []byte {
+	leaves := make([][]byte, 0, len(vals))
+	for _, v := range vals {
+		leaf := pbMessage(nil, 1, pbBytes(nil, 1, v.PubKey))
+		leaf = pbUvarint(leaf, 2, uint64(v.VotingPower))
+		leaves = append(leaves, leaf)
+	}
+	return tmMerkleRoot(leaves)
+}
+
+// TendermintProof is the ics23 proof of a value in a store of a cosmos chain,
+// the proof of the value in the iavl tree of the store and the proof of the
+// root of the store in the app hash
+type TendermintProof struct {
+	StoreProof Ics23ExistenceProof
+	AppProof   Ics23ExistenceProof
+}
+
+func (this *TendermintProof) Serialization(sink *common.ZeroCopySink) {
+	this.StoreProof.Serialization(sink)
+	this.AppProof.Serialization(sink)
+}
+
+func (this *TendermintProof) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.StoreProof.Deserialization(source); err != nil {
+		return fmt.Errorf("deserialize store proof error: %v", err)
+	}
+	if err := this.AppProof.Deserialization(source); err != nil {
+		return fmt.Errorf("deserialize app proof error: %v", err)
+	}
+	return nil
+}
+
+// tendermintState is the latest trusted header the next header is verified against
+type tendermintState struct {
+	Height             int64
+	TimeSeconds        int64
+	NextValidatorsHash []byte
+	Validators         []*TendermintValidator
+}
+
+func (this *tendermintState) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(uint64(this.Height))
+	sink.WriteUint64(uint64(this.TimeSeconds))
+	utils.EncodeVarBytes(sink, this.NextValidatorsHash)
+	serializeTmValidators(sink, this.Validators)
+}
+
+func (this *tendermintState) Deserialization(source *common.ZeroCopySource) error {
+	height, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize height error")
+	}
+	seconds, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize time error")
+	}
+	this.Height, this.TimeSeconds = int64(height), int64(seconds)
+	var err error
+	if this.NextValidatorsHash, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize next validators hash error: %v", err)
+	}
+	this.Validators, err = deserializeTmValidators(source)
+	return err
+}
+
+// tendermintVerifier verifies tendermint headers the way a light client does,
+// and ics23 proofs of the values in a store of a cosmos chain against their
+// app hash.
+//
+// A header needs the commit of more than 2/3 of its own validators. A header
+// whose validators are not the next validators of the latest trusted header
+// also needs the commit of more than 1/3 of the validators of the trusted
+// header. The trusted header expires after the trusting period of the config,
+// then the genesis header has to be synced again.
+//
+// The app hash of a header is the state after its previous block, so the value
+// of a query at height h is proven with the header at h+1.
+type tendermintVerifier struct{}
+
+func (tendermintVerifier) CheckConfig(config []byte) error {
+	return new(TendermintConfig).Deserialization(common.NewZeroCopySource(config))
+}
+
+func decodeTmSignedHeader(config *TendermintConfig, raw []byte) (*TendermintSignedHeader, uint32, error) {
+	sh := new(TendermintSignedHeader)
+	if err := sh.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, 0, fmt.Errorf("deserialize tendermint header error: %v", err)
+	}
+	header := &sh.Header
+	if header.ChainID != config.ChainID {
+		return nil, 0, fmt.Errorf("header of chain %s is synced as chain %s", header.ChainID, config.ChainID)
+	}
+	if header.Height <= 0 || header.Height > math.MaxUint32 {
+		return nil, 0, fmt.Errorf("invalid header height %d", header.Height)
+	}
+	if !bytes.Equal(tmValidatorsHash(sh.Validators), header.ValidatorsHash) {
+		return nil, 0, fmt.Errorf("validators of header %d do not match its validators hash", header.Height)
+	}
+	return sh, uint32(header.Height), nil
+}
+
+func getTendermintState(native *native.NativeService, chainID uint64) (*tendermintState, error) {
+	state, err := getVerifierState(native, chainID)
+	if err != nil {
+		return nil, err
+	}
+	s := new(tendermintState)
+	if err := s.Deserialization(common.NewZeroCopySource(state)); err != nil {
+		return nil, fmt.Errorf("deserialize tendermint state error: %v", err)
+	}
+	return s, nil
+}
+
+func putTendermintHeader(native *native.NativeService, chainID uint64, height uint32, sh *TendermintSignedHeader) error {
+	state := &tendermintState{
+		Height:             sh.Header.Height,
+		TimeSeconds:        sh.Header.TimeSeconds,
+		NextValidatorsHash: sh.Header.NextValidatorsHash,
+		Validators:         sh.Validators,
+	}
+	if err := putVerifierState(native, chainID, common.SerializeToBytes(state)); err != nil {
+		return err
+	}
+	return putChainHeader(native, chainID, height, sh.Header.Hash(), common.SerializeToBytes(&sh.Header))
+}
+
+// checkTrustingPeriod rejects a header which is too old to be trusted or ahead
+// of the block time
+func checkTrustingPeriod(native *native.NativeService, config *TendermintConfig, header *TendermintHeader) error {
+	now := int64(native.Time)
+	if header.TimeSeconds+int64(config.TrustingPeriod) <= now {
+		return fmt.Errorf("header %d is older than the trusting period", header.Height)
+	}
+	if header.TimeSeconds > now+tmMaxClockDrift {
+		return fmt.Errorf("header %d is ahead of the block time", header.Height)
+	}
+	return nil
+}
+
+func (tendermintVerifier) SyncGenesisHeader(native *native.NativeService, cv *ChainVerifier, raw []byte) error {
+	config, err := getTendermintConfig(cv)
+	if err != nil {
+		return err
+	}
+	sh, height, err := decodeTmSignedHeader(config, raw)
+	if err != nil {
+		return err
+	}
+	if err := checkTrustingPeriod(native, config, &sh.Header); err != nil {
+		return err
+	}
+	return putTendermintHeader(native, cv.ChainID, height, sh)
+}
+
+func (tendermintVerifier) SyncBlockHeader(native *native.NativeService, cv *ChainVerifier, raw []byte) error {
+	config, err := getTendermintConfig(cv)
+	if err != nil {
+		return err
+	}
+	sh, height, err := decodeTmSignedHeader(config, raw)
+	if err != nil {
+		return err
+	}
+	stored, err := getChainHeader(native, cv.ChainID, height)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		return nil
+	}
+	trusted, err := getTendermintState(native, cv.ChainID)
+	if err != nil {
+		return err
+	}
+	header, commit := &sh.Header, &sh.Commit
+	if header.Height <= trusted.Height {
+		return fmt.Errorf("header %d is before the trusted header %d", header.Height, trusted.Height)
+	}
+	if trusted.TimeSeconds+int64(config.TrustingPeriod) <= int64(native.Time) {
+		return fmt.Errorf("trusted header %d has expired", trusted.Height)
+	}
+	if header.TimeSeconds <= trusted.TimeSeconds {
+		return fmt.Errorf("header %d is not after the trusted header %d", header.Height, trusted.Height)
+	}
+	if err := checkTrustingPeriod(native, config, header); err != nil {
+		return err
+	}
+	if commit.Height != header.Height || !bytes.Equal(commit.BlockID.Hash, header.Hash()) {
+		return fmt.Errorf("commit is not for header %d", header.Height)
+	}
+	signed, total, err := commit.votingPower(header.ChainID, sh.Validators)
+	if err != nil {
+		return err
+	}
+	if signed*3 <= total*2 {
+		return fmt.Errorf("header %d is committed by %d of %d voting power", header.Height, signed, total)
+	}
+	if !bytes.Equal(header.ValidatorsHash, trusted.NextValidatorsHash) {
+		if header.Height == trusted.Height+1 {
+			return fmt.Errorf("validators of header %d are not the next validators of its previous header", header.Height)
+		}
+		signed, total, err := commit.votingPower(header.ChainID, trusted.Validators)
+		if err != nil {
+			return err
+		}
+		if signed*3 <= total {
+			return fmt.Errorf("header %d is committed by %d of %d trusted voting power", header.Height, signed, total)
+		}
+	}
+	return putTendermintHeader(native, cv.ChainID, height, sh)
+}
+
+func (this tendermintVerifier) VerifyProof(native *native.NativeService, cv *ChainVerifier, height uint32,
+	proof, raw []byte) ([]byte, error) {
+	headerRaw, err := getChainHeader(native, cv.ChainID, height)
+	if err != nil {
+		return nil, err
+	}
+	if headerRaw == nil && len(raw) != 0 {
+		if err := this.SyncBlockHeader(native, cv, raw); err != nil {
+			return nil, err
+		}
+		if headerRaw, err = getChainHeader(native, cv.ChainID, height); err != nil {
+			return nil, err
+		}
+	}
+	if headerRaw == nil {
+		return nil, fmt.Errorf("header %d of chain %d is not synced", height, cv.ChainID)
+	}
+	header := new(TendermintHeader)
+	if err := header.Deserialization(common.NewZeroCopySource(headerRaw)); err != nil {
+		return nil, fmt.Errorf("deserialize tendermint header error: %v", err)
+	}
+	config, err := getTendermintConfig(cv)
+	if err != nil {
+		return nil, err
+	}
+	p := new(TendermintProof)
+	if err := p.Deserialization(common.NewZeroCopySource(proof)); err != nil {
+		return nil, fmt.Errorf("deserialize tendermint proof error: %v", err)
+	}
+	if string(p.AppProof.Key) != config.StoreName {
+		return nil, fmt.Errorf("value is not in store %s", config.StoreName)
+	}
+	if !bytes.HasPrefix(p.StoreProof.Key, config.KeyPrefix) {
+		return nil, fmt.Errorf("key %x is not under prefix %x", p.StoreProof.Key, config.KeyPrefix)
+	}
+	if err := p.StoreProof.verify(ics23IavlSpec, p.AppProof.Value); err != nil {
+		return nil, fmt.Errorf("verify store proof error: %v", err)
+	}
+	if err := p.AppProof.verify(ics23TendermintSpec, header.AppHash); err != nil {
+		return nil, fmt.Errorf("verify app proof of header %d error: %v", height, err)
+	}
+	return p.StoreProof.Value, nil
+}
+
+//simple merkle tree of tendermint
+func tmLeafHash(leaf []byte) []byte {
+	hash := sha256.Sum256(append([]byte{0}, leaf...))
+	return hash[:]
+}
+
+func tmInnerHash(left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(append(append(data, 1), left...), right...)
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// tmSplitPoint is the largest power of 2 less than n
+func tmSplitPoint(n uint64) uint64 {
+	k := uint64(1) << uint(bits.Len64(n)-1)
+	if k == n {
+		k >>= 1
+	}
+	return k
+}
+
+func tmMerkleRoot(items [][]byte) []byte {
+	switch len(items) {
+	case 0:
+		hash := sha256.Sum256(nil)
+		return hash[:]
+	case 1:
+		return tmLeafHash(items[0])
+	default:
+		k := tmSplitPoint(uint64(len(items)))
+		return tmInnerHash(tmMerkleRoot(items[:k]), tmMerkleRoot(items[k:]))
+	}
+}
+
+//proto3 encoding of the tendermint messages, the zero scalars are omitted
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+func pbKey(buf []byte, field, wireType int) []byte {
+	return appendUvarint(buf, uint64(field<<3|wireType))
+}
+
+func pbUvarint(buf []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	return appendUvarint(pbKey(buf, field, 0), v)
+}
+
+func pbFixed64(buf []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(pbKey(buf, field, 1), b[:]...)
+}
+
+func pbBytes(buf []byte, field int, b []byte) []byte {
+	if len(b) == 0 {
+		return buf
+	}
+	return pbMessage(buf, field, b)
+}
+
+// pbMessage writes an embedded message, which is kept even when it is empty
+func pbMessage(buf []byte, field int, b []byte) []byte {
+	buf = appendUvarint(pbKey(buf, field, 2), uint64(len(b)))
+	return append(buf, b...)
+}
+
+func pbTimestamp(seconds int64, nanos int32) []byte {
+	ts := pbUvarint(nil, 1, uint64(seconds))
+	return pbUvarint(ts, 2, uint64(int64(nanos)))
+}
+
+func pbPartSetHeader(id *TendermintBlockID) []byte {
+	psh := pbUvarint(nil, 1, uint64(id.PartsTotal))
+	return pbBytes(psh, 2, id.PartsHash)
+}
+
+func pbBlockID(id *TendermintBlockID) []byte {
+	return pbMessage(pbBytes(nil, 1, id.Hash), 2, pbPartSetHeader(id))
+}
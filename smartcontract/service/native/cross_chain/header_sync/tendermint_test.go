@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package header_sync
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/core/store/leveldbstore"
+	"github.com/qbyyf/ontology/core/store/overlaydb"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func tmSum(s string) []byte {
+	hash := sha256.Sum256([]byte(s))
+	return hash[:]
+}
+
+// the header hash test vector of tendermint v0.34
+func TestTendermintHeaderHash(t *testing.T) {
+	ts := time.Date(2019, 10, 13, 16, 14, 44, 0, time.UTC)
+	header := &TendermintHeader{
+		VersionBlock:       1,
+		VersionApp:         2,
+		ChainID:            "chainId",
+		Height:             3,
+		TimeSeconds:        ts.Unix(),
+		LastBlockID:        TendermintBlockID{Hash: make([]byte, 32), PartsTotal: 6, PartsHash: make([]byte, 32)},
+		LastCommitHash:     tmSum("last_commit_hash"),
+		DataHash:           tmSum("data_hash"),
+		ValidatorsHash:     tmSum("validators_hash"),
+		NextValidatorsHash: tmSum("next_validators_hash"),
+		ConsensusHash:      tmSum("consensus_hash"),
+		AppHash:            tmSum("app_hash"),
+		LastResultsHash:    tmSum("last_results_hash"),
+		EvidenceHash:       tmSum("evidence_hash"),
+		ProposerAddress:    tmSum("proposer_address")[:20],
+	}
+	assert.Equal(t, "f740121f553b5418c3efbd343c2dbfe9e007bb67b0d020a0741374bab65242a4", hex.EncodeToString(header.Hash()))
+}
+
+// the vote sign bytes test vector of tendermint v0.34
+func TestTendermintVoteSignBytes(t *testing.T) {
+	zero := time.Time{}
+	commit := &TendermintCommit{Height: 1, Round: 1}
+	sig := &TendermintCommitSig{TimeSeconds: zero.Unix()}
+	expected := []byte{0x21, 0x8, 0x2, 0x11, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x19, 0x1, 0x0, 0x0, 0x0, 0x0,
+		0x0, 0x0, 0x0, 0x2a, 0xb, 0x8, 0x80, 0x92, 0xb8, 0xc3, 0x98, 0xfe, 0xff, 0xff, 0xff, 0x1}
+	assert.Equal(t, expected, commit.voteSignBytes("", sig))
+}
+
+type tmValidatorKey struct {
+	val  *TendermintValidator
+	priv ed25519.PrivateKey
+}
+
+func newTmValidators(n int) ([]*TendermintValidator, []*tmValidatorKey) {
+	var vals []*TendermintValidator
+	var keys []*tmValidatorKey
+	for i := 0; i < n; i++ {
+		pub, priv, _ := ed25519.GenerateKey(nil)
+		val := &TendermintValidator{PubKey: pub, VotingPower: 10}
+		vals = append(vals, val)
+		keys = append(keys, &tmValidatorKey{val: val, priv: priv})
+	}
+	return vals, keys
+}
+
+func newTmSignedHeader(height int64, vals []*TendermintValidator, signers []*tmValidatorKey, appHash []byte) []byte {
+	sh := &TendermintSignedHeader{
+		Header: TendermintHeader{
+			VersionBlock:       11,
+			ChainID:            "test-chain",
+			Height:             height,
+			TimeSeconds:        1600000000 + height,
+			ValidatorsHash:     tmValidatorsHash(vals),
+			NextValidatorsHash: tmValidatorsHash(vals),
+			AppHash:            appHash,
+		},
+		Validators: vals,
+	}
+	sh.Commit = TendermintCommit{
+		Height:  height,
+		BlockID: TendermintBlockID{Hash: sh.Header.Hash(), PartsTotal: 1, PartsHash: tmSum("parts")},
+	}
+	for _, k := range signers {
+		sig := &TendermintCommitSig{
+			BlockIDFlag:      tmBlockIDFlagCommit,
+			ValidatorAddress: k.val.address(),
+			TimeSeconds:      sh.Header.TimeSeconds,
+		}
+		sig.Signature = ed25519.Sign(k.priv, sh.Commit.voteSignBytes(sh.Header.ChainID, sig))
+		sh.Commit.Signatures = append(sh.Commit.Signatures, sig)
+	}
+	return common.SerializeToBytes(sh)
+}
+
+func newTmTestVerifier() (*native.NativeService, *ChainVerifier) {
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore()))
+	ns := &native.NativeService{CacheDB: db, Time: 1600000010}
+	config := &TendermintConfig{ChainID: "test-chain", TrustingPeriod: 100, StoreName: "cross", KeyPrefix: []byte("cc/")}
+	cv := &ChainVerifier{ChainID: 200, VerifierType: TENDERMINT_VERIFIER, Config: common.SerializeToBytes(config)}
+	return ns, cv
+}
+
+// newTmProof returns the proof of value at key in a store of two iavl leaves,
+// and the app hash of two stores which commits it
+func newTmProof(key, value []byte) (*TendermintProof, []byte) {
+	iavlLeaf := &Ics23LeafOp{Hash: ICS23_SHA256, PrehashKey: ICS23_NO_HASH, PrehashValue: ICS23_SHA256,
+		Length: ICS23_VAR_PROTO, Prefix: []byte{0, 2, 2}}
+	leafHash, _ := iavlLeaf.apply(key, value)
+	sibling, _ := iavlLeaf.apply([]byte("cc/0"), []byte("other value"))
+	inner := &Ics23InnerOp{Hash: ICS23_SHA256, Prefix: append(append([]byte{2, 4, 2, 32}, sibling...), 32)}
+	storeRoot, _ := inner.apply(leafHash)
+
+	tmLeaf := &Ics23LeafOp{Hash: ICS23_SHA256, PrehashKey: ICS23_NO_HASH, PrehashValue: ICS23_SHA256,
+		Length: ICS23_VAR_PROTO, Prefix: []byte{0}}
+	storeHash, _ := tmLeaf.apply([]byte("cross"), storeRoot)
+	otherStore := tmSum("other store")
+	proof := &TendermintProof{
+		StoreProof: Ics23ExistenceProof{Key: key, Value: value, Leaf: *iavlLeaf, Path: []*Ics23InnerOp{inner}},
+		AppProof: Ics23ExistenceProof{Key: []byte("cross"), Value: storeRoot, Leaf: *tmLeaf,
+			Path: []*Ics23InnerOp{{Hash: ICS23_SHA256, Prefix: []byte{1}, Suffix: otherStore}}},
+	}
+	return proof, tmInnerHash(storeHash, otherStore)
+}
+
+func TestTendermintVerifier(t *testing.T) {
+	ns, cv := newTmTestVerifier()
+	verifier := tendermintVerifier{}
+
+	vals, keys := newTmValidators(3)
+	assert.NoError(t, verifier.SyncGenesisHeader(ns, cv, newTmSignedHeader(1, vals, nil, nil)))
+
+	// 2 of 3 validators is not more than 2/3 of the voting power
+	assert.Error(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(2, vals, keys[:2], nil)))
+
+	value := []byte("cross chain value")
+	proof, appHash := newTmProof([]byte("cc/1"), value)
+	assert.NoError(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(3, vals, keys, appHash)))
+
+	// a new validator set committed by none of the trusted validators
+	newVals, newKeys := newTmValidators(3)
+	assert.Error(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(5, newVals, newKeys, nil)))
+
+	v, err := verifier.VerifyProof(ns, cv, 3, common.SerializeToBytes(proof), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, value, v)
+
+	proof.StoreProof.Value = []byte("forged value")
+	_, err = verifier.VerifyProof(ns, cv, 3, common.SerializeToBytes(proof), nil)
+	assert.Error(t, err)
+
+	// a key out of the prefix of the config
+	proof, appHash = newTmProof([]byte("other/1"), value)
+	assert.NoError(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(4, vals, keys, appHash)))
+	_, err = verifier.VerifyProof(ns, cv, 4, common.SerializeToBytes(proof), nil)
+	assert.Error(t, err)
+}
+
+func TestTendermintProofSpec(t *testing.T) {
+	proof, appHash := newTmProof([]byte("cc/1"), []byte("value"))
+	assert.NoError(t, proof.StoreProof.verify(ics23IavlSpec, proof.AppProof.Value))
+	assert.NoError(t, proof.AppProof.verify(ics23TendermintSpec, appHash))
+
+	// the store root proven by the spec of the iavl tree
+	assert.Error(t, proof.AppProof.verify(ics23IavlSpec, appHash))
+
+	// an inner node proven as a leaf
+	proof.AppProof.Path[0].Prefix = []byte{0}
+	assert.Error(t, proof.AppProof.verify(ics23TendermintSpec, appHash))
+}
+
+func TestTendermintTrustingPeriod(t *testing.T) {
+	ns, cv := newTmTestVerifier()
+	verifier := tendermintVerifier{}
+	vals, keys := newTmValidators(3)
+
+	// the headers are at 1600000000 + height, the trusting period is 100 seconds
+	ns.Time = 1600000101
+	assert.Error(t, verifier.SyncGenesisHeader(ns, cv, newTmSignedHeader(1, vals, nil, nil)))
+	ns.Time = 1600000050
+	assert.NoError(t, verifier.SyncGenesisHeader(ns, cv, newTmSignedHeader(1, vals, nil, nil)))
+
+	// ahead of the block time
+	assert.Error(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(70, vals, keys, nil)))
+	assert.NoError(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(60, vals, keys, nil)))
+
+	// the trusted header 60 has expired
+	ns.Time = 1600000160
+	assert.Error(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(150, vals, keys, nil)))
+	ns.Time = 1600000159
+	assert.NoError(t, verifier.SyncBlockHeader(ns, cv, newTmSignedHeader(150, vals, keys, nil)))
+}
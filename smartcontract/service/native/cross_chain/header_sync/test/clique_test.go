@@ -0,0 +1,467 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+
+	ethcommon "github.com/qbyyf/go-ethereum/common"
+	ethtypes "github.com/qbyyf/go-ethereum/core/types"
+	"github.com/qbyyf/go-ethereum/crypto"
+	"github.com/qbyyf/go-ethereum/ethdb/memorydb"
+	"github.com/qbyyf/go-ethereum/rlp"
+	"github.com/qbyyf/go-ethereum/trie"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/core/states"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	ccom "github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/header_sync"
+	"github.com/qbyyf/ontology/smartcontract/service/native/global_params"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	cliqueChainID     = 100
+	cliqueEpoch       = 6
+	cliqueMappingSlot = 7
+)
+
+type cliqueSigner struct {
+	key  *ecdsa.PrivateKey
+	addr ethcommon.Address
+}
+
+func newCliqueSigners(n int) []*cliqueSigner {
+	var signers []*cliqueSigner
+	for i := 0; i < n; i++ {
+		key, _ := crypto.GenerateKey()
+		signers = append(signers, &cliqueSigner{key: key, addr: crypto.PubkeyToAddress(key.PublicKey)})
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i].addr[:], signers[j].addr[:]) < 0
+	})
+	return signers
+}
+
+func sealCliqueHeader(header *ethtypes.Header, signer *cliqueSigner) []byte {
+	enc := []interface{}{header.ParentHash, header.UncleHash, header.Coinbase, header.Root, header.TxHash,
+		header.ReceiptHash, header.Bloom, header.Difficulty, header.Number, header.GasLimit, header.GasUsed,
+		header.Time, header.Extra[:len(header.Extra)-65], header.MixDigest, header.Nonce}
+	data, _ := rlp.EncodeToBytes(enc)
+	sig, _ := crypto.Sign(crypto.Keccak256(data), signer.key)
+	copy(header.Extra[len(header.Extra)-65:], sig)
+	raw, _ := rlp.EncodeToBytes(header)
+	return raw
+}
+
+func newCliqueHeader(parent *ethtypes.Header, root ethcommon.Hash, diff int64) *ethtypes.Header {
+	return &ethtypes.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  ethtypes.EmptyUncleHash,
+		Root:       root,
+		Difficulty: big.NewInt(diff),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Time:       parent.Time + 5,
+		Extra:      make([]byte, 32+65),
+	}
+}
+
+func proofNodes(t *trie.Trie, key []byte) [][]byte {
+	db := memorydb.New()
+	_ = t.Prove(key, 0, db)
+	var nodes [][]byte
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		nodes = append(nodes, ethcommon.CopyBytes(it.Value()))
+	}
+	it.Release()
+	return nodes
+}
+
+// newCrossChainValue returns a serialized ToMerkleValue sent to ontology
+func newCrossChainValue(txHash []byte) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(txHash)
+	sink.WriteUint64(cliqueChainID)
+	param := &ccom.MakeTxParam{
+		TxHash:              txHash,
+		CrossChainID:        crypto.Keccak256(txHash),
+		FromContractAddress: []byte("from contract"),
+		ToChainID:           3,
+		ToContractAddress:   utils.LockProxyContractAddress[:],
+		Method:              "unlock",
+		Args:                []byte("args"),
+	}
+	param.Serialization(sink)
+	return sink.Bytes()
+}
+
+// mappingSlot is the slot of txHash in the mapping at slot index
+func mappingSlot(txHash []byte, index int64) []byte {
+	return crypto.Keccak256(ethcommon.LeftPadBytes(txHash, 32), ethcommon.BigToHash(big.NewInt(index)).Bytes())
+}
+
+// newCliqueState returns the state with the hash of value in slot of the
+// contract, and the proof of the value
+func newCliqueState(contract ethcommon.Address, slot, value []byte) (*trie.Trie, *header_sync.CliqueProof) {
+	storage, _ := trie.New(ethcommon.Hash{}, trie.NewDatabase(memorydb.New()))
+	enc, _ := rlp.EncodeToBytes(crypto.Keccak256(value))
+	storage.Update(crypto.Keccak256(slot), enc)
+	account, _ := rlp.EncodeToBytes([]interface{}{uint64(1), big.NewInt(0), storage.Hash(), crypto.Keccak256(nil)})
+	state, _ := trie.New(ethcommon.Hash{}, trie.NewDatabase(memorydb.New()))
+	state.Update(crypto.Keccak256(contract[:]), account)
+	return state, &header_sync.CliqueProof{
+		AccountProof: proofNodes(state, crypto.Keccak256(contract[:])),
+		StorageProof: proofNodes(storage, crypto.Keccak256(slot)),
+		Value:        value,
+	}
+}
+
+func setupCliqueChain(t *testing.T, contract ethcommon.Address, signers []*cliqueSigner) (*native.NativeService, *ethtypes.Header) {
+	bf := common.NewZeroCopySink(nil)
+	utils.EncodeAddress(bf, acct.Address)
+	si := &states.StorageItem{Value: bf.Bytes()}
+
+	config := &header_sync.CliqueConfig{
+		ContractAddress: contract,
+		Confirmations:   1,
+		Epoch:           cliqueEpoch,
+		Period:          5,
+		MappingSlot:     cliqueMappingSlot,
+	}
+	param := &header_sync.SetChainVerifierParam{
+		ChainID:      cliqueChainID,
+		VerifierType: header_sync.ETH_CLIQUE_VERIFIER,
+		Config:       common.SerializeToBytes(config),
+	}
+	ns := getNativeFunc(common.SerializeToBytes(param), nil)
+	ns.Time = 1000
+	ns.CacheDB.Put(global_params.GenerateOperatorKey(utils.ParamContractAddress), si.ToArray())
+	ok, err := header_sync.SetChainVerifier(ns)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, ok)
+
+	extra := make([]byte, 32)
+	for _, s := range signers {
+		extra = append(extra, s.addr[:]...)
+	}
+	genesis := &ethtypes.Header{
+		UncleHash:  ethtypes.EmptyUncleHash,
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(0),
+		Extra:      append(extra, make([]byte, 65)...),
+	}
+	raw, _ := rlp.EncodeToBytes(genesis)
+	ns.Input = common.SerializeToBytes(&header_sync.SyncGenesisHeaderParam{GenesisHeader: raw, ChainID: cliqueChainID})
+	ok, err = header_sync.SyncGenesisHeader(ns)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, ok)
+	return ns, genesis
+}
+
+func syncCliqueHeaders(ns *native.NativeService, headers ...[]byte) error {
+	ns.Input = common.SerializeToBytes(&header_sync.SyncBlockHeaderParam{Headers: headers, ChainID: cliqueChainID})
+	_, err := header_sync.SyncBlockHeader(ns)
+	return err
+}
+
+func TestCliqueVerifier(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	signers := newCliqueSigners(2)
+	contract := ethcommon.HexToAddress("0x1234")
+	ns, genesis := setupCliqueChain(t, contract, signers)
+
+	txHash := []byte{1}
+	value := newCrossChainValue(txHash)
+	state, proof := newCliqueState(contract, mappingSlot(txHash, cliqueMappingSlot), value)
+
+	h1 := newCliqueHeader(genesis, state.Hash(), 2)
+	raw1 := sealCliqueHeader(h1, signers[1])
+	h2 := newCliqueHeader(h1, ethcommon.Hash{}, 2)
+	raw2 := sealCliqueHeader(h2, signers[0])
+	assert.NoError(t, syncCliqueHeaders(ns, raw1, raw2))
+
+	// signers[0] signed header 2 and may not sign header 3
+	h3 := newCliqueHeader(h2, ethcommon.Hash{}, 1)
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h3, signers[0])))
+	// an unknown signer
+	h3 = newCliqueHeader(h2, ethcommon.Hash{}, 2)
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h3, newCliqueSigners(1)[0])))
+
+	verifier, cv, err := header_sync.GetVerifier(ns, cliqueChainID)
+	assert.NoError(t, err)
+	v, err := verifier.VerifyProof(ns, cv, 1, common.SerializeToBytes(proof), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, value, v)
+
+	// header 2 is not confirmed yet
+	_, err = verifier.VerifyProof(ns, cv, 2, common.SerializeToBytes(proof), nil)
+	assert.Error(t, err)
+
+	proof.Value = newCrossChainValue([]byte{2})
+	_, err = verifier.VerifyProof(ns, cv, 1, common.SerializeToBytes(proof), nil)
+	assert.Error(t, err)
+}
+
+func TestCliqueProofSlot(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	signers := newCliqueSigners(1)
+	contract := ethcommon.HexToAddress("0x1234")
+	ns, genesis := setupCliqueChain(t, contract, signers)
+	verifier, cv, err := header_sync.GetVerifier(ns, cliqueChainID)
+	assert.NoError(t, err)
+
+	// the hash of the value is stored, but not in the slot of its tx hash
+	txHash := []byte{1}
+	value := newCrossChainValue(txHash)
+	state, proof := newCliqueState(contract, mappingSlot(txHash, cliqueMappingSlot+1), value)
+	h1 := newCliqueHeader(genesis, state.Hash(), 2)
+	raw1 := sealCliqueHeader(h1, signers[0])
+	h2 := newCliqueHeader(h1, ethcommon.Hash{}, 2)
+	assert.NoError(t, syncCliqueHeaders(ns, raw1, sealCliqueHeader(h2, signers[0])))
+	_, err = verifier.VerifyProof(ns, cv, 1, common.SerializeToBytes(proof), nil)
+	assert.Error(t, err)
+
+	// a value which is not a cross chain value
+	_, proof = newCliqueState(contract, mappingSlot(txHash, cliqueMappingSlot), []byte("cross chain value"))
+	_, err = verifier.VerifyProof(ns, cv, 1, common.SerializeToBytes(proof), nil)
+	assert.Error(t, err)
+}
+
+func TestCliqueHeaderTime(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	signers := newCliqueSigners(1)
+	ns, genesis := setupCliqueChain(t, ethcommon.Address{}, signers)
+
+	// within the period of the parent
+	h1 := newCliqueHeader(genesis, ethcommon.Hash{}, 2)
+	h1.Time = genesis.Time + 4
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h1, signers[0])))
+	// too far in the future
+	h1.Time = uint64(ns.Time) + 16
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h1, signers[0])))
+	h1.Time = uint64(ns.Time) + 15
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(h1, signers[0])))
+}
+
+// cliqueDiff is the difficulty of the header at height signed by signer
+func cliqueDiff(signers []ethcommon.Address, height int, signer *cliqueSigner) int64 {
+	if signers[height%len(signers)] == signer.addr {
+		return 2
+	}
+	return 1
+}
+
+func TestCliqueCheckpoint(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	signers := newCliqueSigners(4)
+	// signers[3] is voted in by the others
+	candidate := signers[3]
+	ns, genesis := setupCliqueChain(t, ethcommon.Address{}, signers[:3])
+	authorized := []ethcommon.Address{signers[0].addr, signers[1].addr, signers[2].addr}
+	all := []ethcommon.Address{signers[0].addr, signers[1].addr, signers[2].addr, candidate.addr}
+
+	newHeader := func(parent *ethtypes.Header, signers []ethcommon.Address, signer *cliqueSigner) *ethtypes.Header {
+		return newCliqueHeader(parent, ethcommon.Hash{}, cliqueDiff(signers, int(parent.Number.Int64())+1, signer))
+	}
+	withSigners := func(header *ethtypes.Header, list ...*cliqueSigner) *ethtypes.Header {
+		extra := make([]byte, 32)
+		for _, s := range list {
+			extra = append(extra, s.addr[:]...)
+		}
+		header.Extra = append(extra, make([]byte, 65)...)
+		return header
+	}
+
+	// a signer can not replace the signers by a header which is not a checkpoint
+	forged := withSigners(newCliqueHeader(genesis, ethcommon.Hash{}, 2), signers[1])
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(forged, signers[1])))
+
+	h1 := newHeader(genesis, authorized, signers[1])
+	h1.Coinbase, h1.Nonce = candidate.addr, ethtypes.BlockNonce{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(h1, signers[1])))
+	h2 := newHeader(h1, authorized, signers[2])
+	h2.Coinbase, h2.Nonce = candidate.addr, h1.Nonce
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(h2, signers[2])))
+
+	// the candidate is authorized by the votes of two of the three signers
+	h3 := newHeader(h2, all, candidate)
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(h3, candidate)))
+	h4 := newHeader(h3, all, signers[0])
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(h4, signers[0])))
+	h5 := newHeader(h4, all, signers[1])
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(h5, signers[1])))
+
+	// an invalid vote nonce
+	h6 := newHeader(h5, all, signers[2])
+	h6.Nonce = ethtypes.BlockNonce{1}
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h6, signers[2])))
+
+	// the checkpoint header must list the voted signers
+	h6 = withSigners(newHeader(h5, all, signers[2]), signers[2])
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h6, signers[2])))
+	h6 = withSigners(newHeader(h5, all, signers[2]), signers[:3]...)
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h6, signers[2])))
+	// and cast no vote
+	h6 = withSigners(newHeader(h5, all, signers[2]), signers...)
+	h6.Coinbase = signers[0].addr
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(h6, signers[2])))
+	h6 = withSigners(newHeader(h5, all, signers[2]), signers...)
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(h6, signers[2])))
+	height, _, err := header_sync.GetCurrentHeight(ns, cliqueChainID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(6), height)
+}
+
+func TestCliqueForkChoice(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	signers := newCliqueSigners(3)
+	contract := ethcommon.HexToAddress("0x1234")
+	ns, genesis := setupCliqueChain(t, contract, signers)
+	txHash := []byte{1}
+	state, proof := newCliqueState(contract, mappingSlot(txHash, cliqueMappingSlot), newCrossChainValue(txHash))
+	verifier, cv, err := header_sync.GetVerifier(ns, cliqueChainID)
+	assert.NoError(t, err)
+	verify := func() error {
+		_, err := verifier.VerifyProof(ns, cv, 1, common.SerializeToBytes(proof), nil)
+		return err
+	}
+	current := func() uint32 {
+		height, _, err := header_sync.GetCurrentHeight(ns, cliqueChainID)
+		assert.NoError(t, err)
+		return height
+	}
+
+	// branch a, in turn headers only with the value in the state of header 1
+	a1 := newCliqueHeader(genesis, state.Hash(), 2)
+	rawA1 := sealCliqueHeader(a1, signers[1])
+	a2 := newCliqueHeader(a1, ethcommon.Hash{}, 2)
+	rawA2 := sealCliqueHeader(a2, signers[2])
+	assert.NoError(t, syncCliqueHeaders(ns, rawA1, rawA2))
+	assert.NoError(t, verify())
+
+	// branch b starts out of turn and is lighter until its third header
+	b1 := newCliqueHeader(genesis, ethcommon.Hash{}, 1)
+	rawB1 := sealCliqueHeader(b1, signers[0])
+	b2 := newCliqueHeader(b1, ethcommon.Hash{}, 2)
+	rawB2 := sealCliqueHeader(b2, signers[2])
+	assert.NoError(t, syncCliqueHeaders(ns, rawB1, rawB2))
+	assert.Equal(t, uint32(2), current())
+	assert.NoError(t, verify())
+
+	b3 := newCliqueHeader(b2, ethcommon.Hash{}, 2)
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(b3, signers[0])))
+	assert.Equal(t, uint32(3), current())
+	// header 1 of branch b does not hold the value
+	assert.Error(t, verify())
+
+	// branch a becomes the heaviest again
+	a3 := newCliqueHeader(a2, ethcommon.Hash{}, 2)
+	assert.NoError(t, syncCliqueHeaders(ns, sealCliqueHeader(a3, signers[0])))
+	assert.Equal(t, uint32(3), current())
+	assert.NoError(t, verify())
+
+	// a header whose parent is not synced
+	orphan := newCliqueHeader(newCliqueHeader(a3, ethcommon.Hash{}, 2), ethcommon.Hash{}, 2)
+	assert.Error(t, syncCliqueHeaders(ns, sealCliqueHeader(orphan, signers[2])))
+}
+
+func TestSetChainVerifier(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	signers := newCliqueSigners(1)
+	ns, _ := setupCliqueChain(t, ethcommon.Address{}, signers)
+
+	// the synced chain can not be switched to another verifier
+	ns.Input = common.SerializeToBytes(&header_sync.SetChainVerifierParam{
+		ChainID:      cliqueChainID,
+		VerifierType: header_sync.TENDERMINT_VERIFIER,
+		Config:       []byte("chain"),
+	})
+	_, err := header_sync.SetChainVerifier(ns)
+	assert.Error(t, err)
+
+	// unknown verifier type
+	ns.Input = common.SerializeToBytes(&header_sync.SetChainVerifierParam{ChainID: cliqueChainID + 1, VerifierType: 100})
+	_, err = header_sync.SetChainVerifier(ns)
+	assert.Error(t, err)
+
+	// an ontology header can not be synced as a clique chain
+	ns.Input = common.SerializeToBytes(&header_sync.SyncBlockHeaderParam{Headers: getHeaders(1), ChainID: cliqueChainID})
+	_, err = header_sync.SyncBlockHeader(ns)
+	assert.Error(t, err)
+}
+
+func TestChainVerifierActivation(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	bf := common.NewZeroCopySink(nil)
+	utils.EncodeAddress(bf, acct.Address)
+	si := &states.StorageItem{Value: bf.Bytes()}
+	param := &header_sync.SetChainVerifierParam{
+		ChainID:      cliqueChainID,
+		VerifierType: header_sync.ETH_CLIQUE_VERIFIER,
+		Config:       common.SerializeToBytes(&header_sync.CliqueConfig{Epoch: cliqueEpoch}),
+	}
+	ns := getNativeFunc(common.SerializeToBytes(param), nil)
+	ns.CacheDB.Put(global_params.GenerateOperatorKey(utils.ParamContractAddress), si.ToArray())
+	_, err := header_sync.SetChainVerifier(ns)
+	assert.Error(t, err)
+
+	// the chain id of the params is ignored and the headers are ontology headers
+	ns.Input = common.SerializeToBytes(&header_sync.SyncGenesisHeaderParam{GenesisHeader: getGenesisHeader(), ChainID: cliqueChainID})
+	ok, err := header_sync.SyncGenesisHeader(ns)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, ok)
+	verifier, cv, err := header_sync.GetVerifier(ns, cliqueChainID)
+	assert.NoError(t, err)
+	assert.NotNil(t, verifier)
+	assert.Equal(t, uint64(header_sync.ONTOLOGY_VERIFIER), cv.VerifierType)
+
+	// the trailing bytes are ignored before the activation as they were
+	ns.Input = append(common.SerializeToBytes(&header_sync.SyncBlockHeaderParam{}), 0xfd)
+	ok, err = header_sync.SyncBlockHeader(ns)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, ok)
+}
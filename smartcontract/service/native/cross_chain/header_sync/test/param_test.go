@@ -54,3 +54,36 @@ func TestSyncGenesisHeaderParam(t *testing.T) {
 
 	assert.Equal(t, p, param)
 }
+
+func TestSyncHeaderParamChainID(t *testing.T) {
+	param := header_sync.SyncBlockHeaderParam{
+		Address: common.ADDRESS_EMPTY,
+		Headers: [][]byte{{1}},
+		ChainID: 5,
+	}
+	var p header_sync.SyncBlockHeaderParam
+	err := p.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(&param)))
+	assert.NoError(t, err)
+	assert.Equal(t, p, param)
+
+	genesis := header_sync.SyncGenesisHeaderParam{
+		GenesisHeader: []byte{1, 2, 3},
+		ChainID:       5,
+	}
+	var g header_sync.SyncGenesisHeaderParam
+	err = g.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(&genesis)))
+	assert.NoError(t, err)
+	assert.Equal(t, g, genesis)
+}
+
+func TestSetChainVerifierParam(t *testing.T) {
+	param := header_sync.SetChainVerifierParam{
+		ChainID:      5,
+		VerifierType: header_sync.TENDERMINT_VERIFIER,
+		Config:       []byte("cosmoshub-4"),
+	}
+	var p header_sync.SetChainVerifierParam
+	err := p.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(&param)))
+	assert.NoError(t, err)
+	assert.Equal(t, p, param)
+}
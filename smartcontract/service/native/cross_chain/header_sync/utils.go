@@ -291,6 +291,17 @@ func notifyPutHeader(native *native.NativeService, chainID uint64, height uint32
 		})
 }
 
+func notifySetChainVerifier(native *native.NativeService, cv *ChainVerifier) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: utils.HeaderSyncContractAddress,
+			States:          []interface{}{SET_CHAIN_VERIFIER, cv.ChainID, cv.VerifierType, common.ToHexString(cv.Config), native.Height},
+		})
+}
+
 func ProcessHeader(native *native.NativeService, header *ccom.Header, h []byte) error {
 	err := VerifyHeader(native, header)
 	if err != nil {
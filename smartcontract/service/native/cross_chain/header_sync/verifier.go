@@ -0,0 +1,319 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package header_sync
+
+import (
+	"fmt"
+
+	"github.com/qbyyf/ontology/common"
+	cstates "github.com/qbyyf/ontology/core/states"
+	"github.com/qbyyf/ontology/merkle"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	ccom "github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/common"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	//verifier type
+	ONTOLOGY_VERIFIER   = 0
+	ETH_CLIQUE_VERIFIER = 1
+	TENDERMINT_VERIFIER = 2
+)
+
+// HeaderVerifier syncs the block headers of a source chain and verifies the
+// cross chain proofs made against them. Verifiers are registered by type and
+// each chain is bound to one of them by setChainVerifier, the chains without a
+// binding use the ontology verifier.
+type HeaderVerifier interface {
+	// SyncGenesisHeader stores the trusted header the chain is synced from
+	SyncGenesisHeader(native *native.NativeService, cv *ChainVerifier, header []byte) error
+	// SyncBlockHeader verifies and stores a header, a header which is already
+	// stored is ignored
+	SyncBlockHeader(native *native.NativeService, cv *ChainVerifier, header []byte) error
+	// VerifyProof verifies proof against the header of the chain at height and
+	// returns the proven cross chain value. header is synced first when the
+	// header at height is missing and header is not empty.
+	VerifyProof(native *native.NativeService, cv *ChainVerifier, height uint32, proof, header []byte) ([]byte, error)
+	// CheckConfig validates the config given to setChainVerifier
+	CheckConfig(config []byte) error
+}
+
+var verifiers = map[uint64]HeaderVerifier{
+	ONTOLOGY_VERIFIER:   ontologyVerifier{},
+	ETH_CLIQUE_VERIFIER: cliqueVerifier{},
+	TENDERMINT_VERIFIER: tendermintVerifier{},
+}
+
+// RegisterVerifier makes a verifier type available to setChainVerifier, it
+// must be called before the ledger starts, by all the nodes alike
+func RegisterVerifier(verifierType uint64, verifier HeaderVerifier) {
+	verifiers[verifierType] = verifier
+}
+
+// GetVerifier returns the verifier bound to chainID together with its binding,
+// which is the ontology verifier before the activation
+func GetVerifier(native *native.NativeService, chainID uint64) (HeaderVerifier, *ChainVerifier, error) {
+	if !isVerifierActivated(native) {
+		return ontologyVerifier{}, &ChainVerifier{ChainID: chainID, VerifierType: ONTOLOGY_VERIFIER}, nil
+	}
+	cv, err := GetChainVerifier(native, chainID)
+	if err != nil {
+		return nil, nil, err
+	}
+	verifier, ok := verifiers[cv.VerifierType]
+	if !ok {
+		return nil, nil, fmt.Errorf("GetVerifier, verifier type %d of chain %d is not registered", cv.VerifierType, chainID)
+	}
+	return verifier, cv, nil
+}
+
+func GetChainVerifier(native *native.NativeService, chainID uint64) (*ChainVerifier, error) {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetChainVerifier, GetUint64Bytes error: %v", err)
+	}
+	value, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(CHAIN_VERIFIER), chainIDBytes))
+	if err != nil {
+		return nil, fmt.Errorf("GetChainVerifier, get chainVerifier error: %v", err)
+	}
+	cv := &ChainVerifier{ChainID: chainID, VerifierType: ONTOLOGY_VERIFIER}
+	if value == nil {
+		return cv, nil
+	}
+	cvBytes, err := cstates.GetValueFromRawStorageItem(value)
+	if err != nil {
+		return nil, fmt.Errorf("GetChainVerifier, deserialize from raw storage item err:%v", err)
+	}
+	if err := cv.Deserialization(common.NewZeroCopySource(cvBytes)); err != nil {
+		return nil, fmt.Errorf("GetChainVerifier, deserialize chainVerifier error: %v", err)
+	}
+	return cv, nil
+}
+
+func putChainVerifier(native *native.NativeService, cv *ChainVerifier) error {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(cv.ChainID)
+	if err != nil {
+		return fmt.Errorf("putChainVerifier, GetUint64Bytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(CHAIN_VERIFIER), chainIDBytes),
+		cstates.GenRawStorageItem(common.SerializeToBytes(cv)))
+	return nil
+}
+
+//raw headers of the chains which are not verified by the ontology verifier
+func putChainHeader(native *native.NativeService, chainID uint64, height uint32, blockHash []byte, header []byte) error {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return fmt.Errorf("putChainHeader, GetUint64Bytes error: %v", err)
+	}
+	heightBytes, err := utils.GetUint32Bytes(height)
+	if err != nil {
+		return fmt.Errorf("putChainHeader, getUint32Bytes error: %v", err)
+	}
+	if err := putChainHeaderAt(native, chainID, height, header); err != nil {
+		return err
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(CURRENT_HEIGHT), chainIDBytes), cstates.GenRawStorageItem(heightBytes))
+	notifyPutHeader(native, chainID, height, common.ToHexString(blockHash))
+	return nil
+}
+
+//replace the header at height without moving the current height
+func putChainHeaderAt(native *native.NativeService, chainID uint64, height uint32, header []byte) error {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return fmt.Errorf("putChainHeaderAt, GetUint64Bytes error: %v", err)
+	}
+	heightBytes, err := utils.GetUint32Bytes(height)
+	if err != nil {
+		return fmt.Errorf("putChainHeaderAt, getUint32Bytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(CHAIN_HEADER), chainIDBytes, heightBytes),
+		cstates.GenRawStorageItem(header))
+	return nil
+}
+
+func deleteChainHeader(native *native.NativeService, chainID uint64, height uint32) error {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return fmt.Errorf("deleteChainHeader, GetUint64Bytes error: %v", err)
+	}
+	heightBytes, err := utils.GetUint32Bytes(height)
+	if err != nil {
+		return fmt.Errorf("deleteChainHeader, getUint32Bytes error: %v", err)
+	}
+	native.CacheDB.Delete(utils.ConcatKey(contract, []byte(CHAIN_HEADER), chainIDBytes, heightBytes))
+	return nil
+}
+
+func getChainHeader(native *native.NativeService, chainID uint64, height uint32) ([]byte, error) {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("getChainHeader, GetUint64Bytes error: %v", err)
+	}
+	heightBytes, err := utils.GetUint32Bytes(height)
+	if err != nil {
+		return nil, fmt.Errorf("getChainHeader, getUint32Bytes error: %v", err)
+	}
+	value, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(CHAIN_HEADER), chainIDBytes, heightBytes))
+	if err != nil {
+		return nil, fmt.Errorf("getChainHeader, get header error: %v", err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return cstates.GetValueFromRawStorageItem(value)
+}
+
+func GetCurrentHeight(native *native.NativeService, chainID uint64) (uint32, bool, error) {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return 0, false, fmt.Errorf("GetCurrentHeight, GetUint64Bytes error: %v", err)
+	}
+	value, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(CURRENT_HEIGHT), chainIDBytes))
+	if err != nil {
+		return 0, false, fmt.Errorf("GetCurrentHeight, get current height error: %v", err)
+	}
+	if value == nil {
+		return 0, false, nil
+	}
+	heightBytes, err := cstates.GetValueFromRawStorageItem(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("GetCurrentHeight, deserialize from raw storage item err:%v", err)
+	}
+	height, err := utils.GetBytesUint32(heightBytes)
+	if err != nil {
+		return 0, false, fmt.Errorf("GetCurrentHeight, GetBytesUint32 error: %v", err)
+	}
+	return height, true, nil
+}
+
+//verifier state, such as the head of the chain or the validators to verify the next header with
+func putVerifierState(native *native.NativeService, chainID uint64, state []byte) error {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return fmt.Errorf("putVerifierState, GetUint64Bytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(VERIFIER_STATE), chainIDBytes), cstates.GenRawStorageItem(state))
+	return nil
+}
+
+func getVerifierState(native *native.NativeService, chainID uint64) ([]byte, error) {
+	contract := utils.HeaderSyncContractAddress
+	chainIDBytes, err := utils.GetUint64Bytes(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("getVerifierState, GetUint64Bytes error: %v", err)
+	}
+	value, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(VERIFIER_STATE), chainIDBytes))
+	if err != nil {
+		return nil, fmt.Errorf("getVerifierState, get state error: %v", err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("getVerifierState, genesis header of chain %d is not synced", chainID)
+	}
+	return cstates.GetValueFromRawStorageItem(value)
+}
+
+// ontologyVerifier verifies ontology headers by the signatures of the
+// bookkeepers and cross chain proofs against the cross state root
+type ontologyVerifier struct{}
+
+func (ontologyVerifier) CheckConfig(config []byte) error {
+	if len(config) != 0 {
+		return fmt.Errorf("ontology verifier takes no config")
+	}
+	return nil
+}
+
+func (ontologyVerifier) decodeHeader(cv *ChainVerifier, raw []byte) (*ccom.Header, error) {
+	header, err := ccom.HeaderFromRawBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize header err: %v", err)
+	}
+	if header.ChainID != cv.ChainID {
+		return nil, fmt.Errorf("header of chain %d is synced as chain %d", header.ChainID, cv.ChainID)
+	}
+	return header, nil
+}
+
+func (this ontologyVerifier) SyncGenesisHeader(native *native.NativeService, cv *ChainVerifier, raw []byte) error {
+	header, err := this.decodeHeader(cv, raw)
+	if err != nil {
+		return err
+	}
+	//block header storage
+	err = PutBlockHeader(native, header, raw)
+	if err != nil {
+		return fmt.Errorf("put blockHeader error: %v", err)
+	}
+	//consensus node pk storage
+	err = UpdateConsensusPeer(native, header)
+	if err != nil {
+		return fmt.Errorf("update ConsensusPeer error: %v", err)
+	}
+	return nil
+}
+
+func (this ontologyVerifier) SyncBlockHeader(native *native.NativeService, cv *ChainVerifier, raw []byte) error {
+	header, err := this.decodeHeader(cv, raw)
+	if err != nil {
+		return err
+	}
+	h, err := GetHeaderByHeight(native, header.ChainID, header.Height)
+	if err != nil {
+		return fmt.Errorf("%d, %d", header.ChainID, header.Height)
+	}
+	if h != nil {
+		return nil
+	}
+	return ProcessHeader(native, header, raw)
+}
+
+func (this ontologyVerifier) VerifyProof(native *native.NativeService, cv *ChainVerifier, height uint32,
+	proof, raw []byte) ([]byte, error) {
+	//get block header
+	header, err := GetHeaderByHeight(native, cv.ChainID, height)
+	if err != nil {
+		return nil, fmt.Errorf("%d, %d", cv.ChainID, height)
+	}
+	if header == nil {
+		header2 := new(ccom.Header)
+		err := header2.Deserialization(common.NewZeroCopySource(raw))
+		if err != nil {
+			return nil, fmt.Errorf("deserialize header error: %v", err)
+		}
+		if err := ProcessHeader(native, header2, raw); err != nil {
+			return nil, err
+		}
+		header = header2
+	}
+	v, err := merkle.MerkleProve(proof, header.CrossStateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("merkle.MerkleProve verify merkle proof error: %v", err)
+	}
+	return v, nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/qbyyf/ontology/smartcontract/event"
 	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/auth"
 	"github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/cross_chain_manager"
 	"github.com/qbyyf/ontology/smartcontract/service/native/global_params"
 	"github.com/qbyyf/ontology/smartcontract/service/native/ont"
@@ -50,6 +51,14 @@ func RegisterLockProxyContract(native *native.NativeService) {
 	native.Register(GET_ASSET_HASH_NAME, GetAssetHash)
 	native.Register(GET_CROSSED_AMOUNT_NAME, GetCrossedAmount)
 	native.Register(GET_CROSSED_LIMIT_NAME, GetCrossedLimit)
+	native.Register(INIT_ADMIN_NAME, InitAdmin)
+	native.Register(SET_RATE_LIMIT_NAME, SetRateLimit)
+	native.Register(PAUSE_NAME, Pause)
+	native.Register(UNPAUSE_NAME, Unpause)
+	native.Register(RELEASE_UNLOCK_NAME, ReleaseUnlock)
+	native.Register(GET_RATE_LIMIT_NAME, GetRateLimit)
+	native.Register(IS_PAUSED_NAME, IsPaused)
+	native.Register(GET_PENDING_UNLOCK_NAME, GetPendingUnlock)
 }
 
 func BindProxyHash(native *native.NativeService) ([]byte, error) {
@@ -152,6 +161,24 @@ func Lock(native *native.NativeService) ([]byte, error) {
 	if lockParam.SourceAssetHash != ontContract && lockParam.SourceAssetHash != ongContract {
 		return utils.BYTE_FALSE, fmt.Errorf("[Lock] only support ont/ong lock, expect:%s or %s, but got:%s", hex.EncodeToString(ontContract[:]), hex.EncodeToString(ongContract[:]), hex.EncodeToString(lockParam.SourceAssetHash[:]))
 	}
+	if isRateLimitActivated(native) {
+		paused, err := isPaused(native, contract, lockParam.SourceAssetHash)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("[Lock] isPaused error:%s", err)
+		}
+		if paused {
+			return utils.BYTE_FALSE, fmt.Errorf("[Lock] asset:%s is paused", hex.EncodeToString(lockParam.SourceAssetHash[:]))
+		}
+		// the lock exceeding the rate limit is rejected without error, so that the event is kept
+		ok, available, err := consumeRateLimit(native, contract, lockParam.SourceAssetHash, lockParam.ToChainID, true, lockParam.Value)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("[Lock] consumeRateLimit error:%s", err)
+		}
+		if !ok {
+			AddRateLimitHitNotifications(native, contract, lockParam.SourceAssetHash, LOCK_NAME, lockParam.ToChainID, lockParam.Value, available)
+			return utils.BYTE_FALSE, nil
+		}
+	}
 
 	// transfer ont or ong from FromAddress to lockContract
 	state := ont.TransferState{
@@ -252,26 +279,222 @@ func Unlock(native *native.NativeService) ([]byte, error) {
 	if args.Value == 0 {
 		return utils.BYTE_TRUE, nil
 	}
+	if isRateLimitActivated(native) {
+		// the cross chain tx can be relayed again after the asset is unpaused
+		paused, err := isPaused(native, contract, assetAddress)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("[Unlock] isPaused error:%s", err)
+		}
+		if paused {
+			return utils.BYTE_FALSE, fmt.Errorf("[Unlock] asset:%s is paused", hex.EncodeToString(assetAddress[:]))
+		}
+		// the unlock exceeding the rate limit is queued, since the cross chain tx is already marked as done
+		ok, available, err := consumeRateLimit(native, contract, assetAddress, unlockParam.FromChainId, false, args.Value)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("[Unlock] consumeRateLimit error:%s", err)
+		}
+		if !ok {
+			pending := &PendingUnlock{
+				AssetHash:          assetAddress,
+				ToAddress:          toAddress,
+				FromChainId:        unlockParam.FromChainId,
+				FromContractHashBs: unlockParam.FromContractHashBs,
+				Value:              args.Value,
+				QueuedTime:         native.Time,
+			}
+			id, err := queueUnlock(native, contract, pending)
+			if err != nil {
+				return utils.BYTE_FALSE, fmt.Errorf("[Unlock] queueUnlock error:%s", err)
+			}
+			AddRateLimitHitNotifications(native, contract, assetAddress, UNLOCK_NAME, unlockParam.FromChainId, args.Value, available)
+			AddUnlockQueuedNotifications(native, contract, id, pending)
+			return utils.BYTE_TRUE, nil
+		}
+	}
+	if err := unlockAsset(native, contract, assetAddress, toAddress, unlockParam.FromChainId, args.Value); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Unlock] %s", err)
+	}
+
+	AddUnLockNotifications(native, contract, unlockParam.FromChainId, unlockParam.FromContractHashBs, assetAddress, toAddress, args.Value)
+
+	return utils.BYTE_TRUE, nil
+}
+
+// unlockAsset transfers value of the asset from current proxy contract into toAddress
+func unlockAsset(native *native.NativeService, contract, assetAddress, toAddress common.Address, fromChainId uint64, value uint64) error {
 	// unlock ont or ong from current proxy contract into toAddress
-	transferInput := getTransferInput(ont.TransferState{contract, toAddress, args.Value})
-	if _, err = native.NativeCall(assetAddress, ont.TRANSFER_NAME, transferInput); err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("[Unlock] NativeCall contract:%s 'transfer(%s, %s, %d)' error:%s", hex.EncodeToString(assetAddress[:]), hex.EncodeToString(contract[:]), toAddress.ToBase58(), args.Value, err)
+	transferInput := getTransferInput(ont.TransferState{From: contract, To: toAddress, Value: value})
+	if _, err := native.NativeCall(assetAddress, ont.TRANSFER_NAME, transferInput); err != nil {
+		return fmt.Errorf("NativeCall contract:%s 'transfer(%s, %s, %d)' error:%s", hex.EncodeToString(assetAddress[:]), hex.EncodeToString(contract[:]), toAddress.ToBase58(), value, err)
 	}
 
 	// make sure new crossed amount is strictly less than old crossed amount and no less than the limit
-	crossedAmount, err := getAmount(native, GenCrossedAmountKey(contract, assetAddress, unlockParam.FromChainId))
+	crossedAmount, err := getAmount(native, GenCrossedAmountKey(contract, assetAddress, fromChainId))
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("[Unlock] getCrossedAmount error:%s", err)
+		return fmt.Errorf("getCrossedAmount error:%s", err)
 	}
-	newCrossedAmount := big.NewInt(0).Sub(crossedAmount, big.NewInt(0).SetUint64(args.Value))
+	newCrossedAmount := big.NewInt(0).Sub(crossedAmount, big.NewInt(0).SetUint64(value))
 	if newCrossedAmount.Cmp(crossedAmount) != -1 {
-		return utils.BYTE_FALSE, fmt.Errorf("[Unlock] new crossedAmount:%s should be less than old crossedAmount:%s", newCrossedAmount.String(), crossedAmount.String())
+		return fmt.Errorf("new crossedAmount:%s should be less than old crossedAmount:%s", newCrossedAmount.String(), crossedAmount.String())
 	}
 	// decrease the new crossed amount by Value
-	native.CacheDB.Put(GenCrossedAmountKey(contract, assetAddress, unlockParam.FromChainId), utils.GenVarBytesStorageItem(newCrossedAmount.Bytes()).ToArray())
+	native.CacheDB.Put(GenCrossedAmountKey(contract, assetAddress, fromChainId), utils.GenVarBytesStorageItem(newCrossedAmount.Bytes()).ToArray())
+	return nil
+}
 
-	AddUnLockNotifications(native, contract, unlockParam.FromChainId, unlockParam.FromContractHashBs, assetAddress, toAddress, args.Value)
+// ReleaseUnlock unlocks as much of a queued unlock as the rate limit has room for, the rest stays
+// queued until the window has room again. Anyone can invoke it
+func ReleaseUnlock(native *native.NativeService) ([]byte, error) {
+	if !isRateLimitActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	id, err := utils.DecodeVarUint(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ReleaseUnlock] input DecodeVarUint id error:%s", err)
+	}
+	pending, err := getPendingUnlock(native, contract, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ReleaseUnlock] %s", err)
+	}
+	if pending == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ReleaseUnlock] pending unlock:%d not found", id)
+	}
+	paused, err := isPaused(native, contract, pending.AssetHash)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ReleaseUnlock] isPaused error:%s", err)
+	}
+	if paused {
+		return utils.BYTE_FALSE, fmt.Errorf("[ReleaseUnlock] asset:%s is paused", hex.EncodeToString(pending.AssetHash[:]))
+	}
+	amount, err := consumeUnlockTranche(native, contract, pending.AssetHash, pending.FromChainId, pending.Value)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ReleaseUnlock] consumeUnlockTranche error:%s", err)
+	}
+	if amount == 0 {
+		AddRateLimitHitNotifications(native, contract, pending.AssetHash, RELEASE_UNLOCK_NAME, pending.FromChainId, pending.Value, big.NewInt(0))
+		return utils.BYTE_FALSE, nil
+	}
+	if amount == pending.Value {
+		native.CacheDB.Delete(GenPendingUnlockKey(contract, id))
+	} else {
+		remaining := *pending
+		remaining.Value -= amount
+		putPendingUnlock(native, contract, id, &remaining)
+	}
+	if err := unlockAsset(native, contract, pending.AssetHash, pending.ToAddress, pending.FromChainId, amount); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ReleaseUnlock] %s", err)
+	}
+
+	AddUnLockNotifications(native, contract, pending.FromChainId, pending.FromContractHashBs, pending.AssetHash, pending.ToAddress, amount)
+	return utils.BYTE_TRUE, nil
+}
+
+// InitAdmin sets the admin ONT ID of lock proxy in the auth contract, who assigns the functions
+// setRateLimit, pause and unpause to roles, e.g. pause to a guardian role separate from the operator
+func InitAdmin(native *native.NativeService) ([]byte, error) {
+	if !isRateLimitActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	adminOntID, err := utils.DecodeVarBytes(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[InitAdmin] input DecodeVarBytes adminOntID error:%s", err)
+	}
+	// get operator from database
+	operatorAddress, err := global_params.GetStorageRole(native,
+		global_params.GenerateOperatorKey(utils.ParamContractAddress))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[InitAdmin] get operator error:%s", err)
+	}
+	//check witness
+	if err = utils.ValidateOwner(native, operatorAddress); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[InitAdmin] checkWitness error:%s", err)
+	}
+	params := &auth.InitContractAdminParam{
+		AdminOntID: adminOntID,
+	}
+	res, err := native.NativeCall(utils.AuthContractAddress, "initContractAdmin", common.SerializeToBytes(params))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[InitAdmin] NativeCall auth contract initContractAdmin error:%s", err)
+	}
+	return res, nil
+}
+
+func SetRateLimit(native *native.NativeService) ([]byte, error) {
+	if !isRateLimitActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param SetRateLimitParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[SetRateLimit] Deserialization SetRateLimitParam error:%s", err)
+	}
+	if err := appCallVerifyToken(native, contract, param.Caller, SET_RATE_LIMIT_NAME, param.KeyNo); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[SetRateLimit] %s", err)
+	}
+	if param.Limit.Sign() < 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("[SetRateLimit] Limit:%s should not be negative", param.Limit.String())
+	}
+	if param.Limit.Sign() > 0 && param.Window == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("[SetRateLimit] Window should be positive")
+	}
+	rateLimit, err := getRateLimit(native, contract, param.SourceAssetHash, param.TargetChainId)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[SetRateLimit] %s", err)
+	}
+	// keep the used amount in the window, so that resetting the limit does not refill it
+	if rateLimit == nil {
+		rateLimit = &RateLimit{LockedUsed: big.NewInt(0), UnlockUsed: big.NewInt(0)}
+	} else {
+		rateLimit.release(native.Time)
+	}
+	rateLimit.Window = param.Window
+	rateLimit.Limit = param.Limit
+	rateLimit.UpdatedTime = native.Time
+	rateLimit.Remainder = 0
+	putRateLimit(native, contract, param.SourceAssetHash, param.TargetChainId, rateLimit)
+	if config.DefConfig.Common.EnableEventLog {
+		native.Notifications = append(native.Notifications,
+			&event.NotifyEventInfo{
+				ContractAddress: contract,
+				States:          []interface{}{SET_RATE_LIMIT_NAME, hex.EncodeToString(param.SourceAssetHash[:]), param.TargetChainId, param.Window, param.Limit.String()},
+			})
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+func Pause(native *native.NativeService) ([]byte, error) {
+	return setPaused(native, PAUSE_NAME, true)
+}
 
+func Unpause(native *native.NativeService) ([]byte, error) {
+	return setPaused(native, UNPAUSE_NAME, false)
+}
+
+func setPaused(native *native.NativeService, method string, paused bool) ([]byte, error) {
+	if !isRateLimitActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	var param PauseParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[%s] Deserialization PauseParam error:%s", method, err)
+	}
+	if err := appCallVerifyToken(native, contract, param.Caller, method, param.KeyNo); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[%s] %s", method, err)
+	}
+	if paused {
+		native.CacheDB.Put(GenPausedKey(contract, param.SourceAssetHash), utils.GenVarBytesStorageItem(utils.BYTE_TRUE).ToArray())
+	} else {
+		native.CacheDB.Delete(GenPausedKey(contract, param.SourceAssetHash))
+	}
+	if config.DefConfig.Common.EnableEventLog {
+		native.Notifications = append(native.Notifications,
+			&event.NotifyEventInfo{
+				ContractAddress: contract,
+				States:          []interface{}{method, hex.EncodeToString(param.SourceAssetHash[:]), string(param.Caller)},
+			})
+	}
 	return utils.BYTE_TRUE, nil
 }
 
@@ -380,3 +603,66 @@ func GetCrossedLimit(native *native.NativeService) ([]byte, error) {
 	}
 	return common.BigIntToNeoBytes(big.NewInt(0).SetBytes(crossedLimitBs)), nil
 }
+
+func GetRateLimit(native *native.NativeService) ([]byte, error) {
+	if !isRateLimitActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	source := common.NewZeroCopySource(native.Input)
+	sourceAssetAddress, err := utils.DecodeAddress(source)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetRateLimit] input DecodeAddress sourceAssetAddress error:%s", err)
+	}
+	toChainId, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetRateLimit] input DecodeVarUint toChainId error:%s", err)
+	}
+	rateLimit, err := getRateLimit(native, contract, sourceAssetAddress, toChainId)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetRateLimit] %s", err)
+	}
+	if rateLimit == nil {
+		return []byte{}, nil
+	}
+	rateLimit.release(native.Time)
+	return common.SerializeToBytes(rateLimit), nil
+}
+
+func IsPaused(native *native.NativeService) ([]byte, error) {
+	if !isRateLimitActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	sourceAssetAddress, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[IsPaused] input DecodeAddress sourceAssetAddress error:%s", err)
+	}
+	paused, err := isPaused(native, contract, sourceAssetAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[IsPaused] %s", err)
+	}
+	if paused {
+		return utils.BYTE_TRUE, nil
+	}
+	return utils.BYTE_FALSE, nil
+}
+
+func GetPendingUnlock(native *native.NativeService) ([]byte, error) {
+	if !isRateLimitActivated(native) {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	id, err := utils.DecodeVarUint(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetPendingUnlock] input DecodeVarUint id error:%s", err)
+	}
+	pending, err := getPendingUnlock(native, contract, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetPendingUnlock] %s", err)
+	}
+	if pending == nil {
+		return []byte{}, nil
+	}
+	return common.SerializeToBytes(pending), nil
+}
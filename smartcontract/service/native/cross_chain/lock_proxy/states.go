@@ -190,3 +190,231 @@ func (this *BindAssetParam) Deserialization(source *common.ZeroCopySource) error
 	}
 	return nil
 }
+
+// SetRateLimitParam sets the rolling window limit of an asset crossing with a chain,
+// the Caller should be authorized to invoke setRateLimit by the auth contract
+type SetRateLimitParam struct {
+	SourceAssetHash common.Address
+	TargetChainId   uint64
+	Window          uint64 // in seconds
+	Limit           *big.Int
+	Caller          []byte
+	KeyNo           uint64
+}
+
+func (this *SetRateLimitParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.SourceAssetHash)
+	utils.EncodeVarUint(sink, this.TargetChainId)
+	utils.EncodeVarUint(sink, this.Window)
+	utils.EncodeVarBytes(sink, common.BigIntToNeoBytes(this.Limit))
+	utils.EncodeVarBytes(sink, this.Caller)
+	utils.EncodeVarUint(sink, this.KeyNo)
+}
+
+func (this *SetRateLimitParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.SourceAssetHash, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("SetRateLimitParam.Deserialization DecodeAddress SourceAssetHash error:%s", err)
+	}
+	if this.TargetChainId, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("SetRateLimitParam.Deserialization DecodeVarUint TargetChainId error:%s", err)
+	}
+	if this.Window, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("SetRateLimitParam.Deserialization DecodeVarUint Window error:%s", err)
+	}
+	limitNeoBytes, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("SetRateLimitParam.Deserialization DecodeVarBytes Limit error:%s", err)
+	}
+	this.Limit = common.BigIntFromNeoBytes(limitNeoBytes)
+	if this.Caller, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("SetRateLimitParam.Deserialization DecodeVarBytes Caller error:%s", err)
+	}
+	if this.KeyNo, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("SetRateLimitParam.Deserialization DecodeVarUint KeyNo error:%s", err)
+	}
+	return nil
+}
+
+// PauseParam pauses or unpauses the lock and unlock of an asset,
+// the Caller should be authorized to invoke pause or unpause by the auth contract
+type PauseParam struct {
+	SourceAssetHash common.Address
+	Caller          []byte
+	KeyNo           uint64
+}
+
+func (this *PauseParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.SourceAssetHash)
+	utils.EncodeVarBytes(sink, this.Caller)
+	utils.EncodeVarUint(sink, this.KeyNo)
+}
+
+func (this *PauseParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.SourceAssetHash, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("PauseParam.Deserialization DecodeAddress SourceAssetHash error:%s", err)
+	}
+	if this.Caller, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("PauseParam.Deserialization DecodeVarBytes Caller error:%s", err)
+	}
+	if this.KeyNo, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("PauseParam.Deserialization DecodeVarUint KeyNo error:%s", err)
+	}
+	return nil
+}
+
+// RateLimit limits the amount of an asset locked to or unlocked from a chain in a rolling window,
+// the used amount of each direction is released linearly, the whole Limit is released in Window seconds.
+// Remainder is the part of Limit*seconds not released yet, which is less than Window
+type RateLimit struct {
+	Window      uint64
+	Limit       *big.Int
+	LockedUsed  *big.Int
+	UnlockUsed  *big.Int
+	UpdatedTime uint32
+	Remainder   uint64
+}
+
+func (this *RateLimit) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.Window)
+	utils.EncodeVarBytes(sink, common.BigIntToNeoBytes(this.Limit))
+	utils.EncodeVarBytes(sink, common.BigIntToNeoBytes(this.LockedUsed))
+	utils.EncodeVarBytes(sink, common.BigIntToNeoBytes(this.UnlockUsed))
+	utils.EncodeVarUint(sink, uint64(this.UpdatedTime))
+	utils.EncodeVarUint(sink, this.Remainder)
+}
+
+func (this *RateLimit) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Window, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("RateLimit.Deserialization DecodeVarUint Window error:%s", err)
+	}
+	limit, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("RateLimit.Deserialization DecodeVarBytes Limit error:%s", err)
+	}
+	lockedUsed, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("RateLimit.Deserialization DecodeVarBytes LockedUsed error:%s", err)
+	}
+	unlockUsed, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("RateLimit.Deserialization DecodeVarBytes UnlockUsed error:%s", err)
+	}
+	updatedTime, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("RateLimit.Deserialization DecodeVarUint UpdatedTime error:%s", err)
+	}
+	if this.Remainder, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("RateLimit.Deserialization DecodeVarUint Remainder error:%s", err)
+	}
+	this.Limit = common.BigIntFromNeoBytes(limit)
+	this.LockedUsed = common.BigIntFromNeoBytes(lockedUsed)
+	this.UnlockUsed = common.BigIntFromNeoBytes(unlockUsed)
+	this.UpdatedTime = uint32(updatedTime)
+	return nil
+}
+
+// release frees the used amount which has left the window before now, the fraction of
+// the amount which is not freed yet is carried in Remainder, so frequent calls free as much as a single one
+func (this *RateLimit) release(now uint32) {
+	if now <= this.UpdatedTime {
+		return
+	}
+	if this.Window != 0 {
+		total := new(big.Int).Mul(this.Limit, new(big.Int).SetUint64(uint64(now-this.UpdatedTime)))
+		total.Add(total, new(big.Int).SetUint64(this.Remainder))
+		freed, remainder := new(big.Int).DivMod(total, new(big.Int).SetUint64(this.Window), new(big.Int))
+		this.LockedUsed = subFloor(this.LockedUsed, freed)
+		this.UnlockUsed = subFloor(this.UnlockUsed, freed)
+		this.Remainder = remainder.Uint64()
+	}
+	this.UpdatedTime = now
+}
+
+// consume takes value from the available amount of the direction at now,
+// and returns false and the available amount if value exceeds it
+func (this *RateLimit) consume(isLock bool, value uint64, now uint32) (bool, *big.Int) {
+	this.release(now)
+	used := this.UnlockUsed
+	if isLock {
+		used = this.LockedUsed
+	}
+	available := subFloor(this.Limit, used)
+	amount := new(big.Int).SetUint64(value)
+	if amount.Cmp(available) == 1 {
+		return false, available
+	}
+	used = new(big.Int).Add(used, amount)
+	if isLock {
+		this.LockedUsed = used
+	} else {
+		this.UnlockUsed = used
+	}
+	return true, new(big.Int).Sub(available, amount)
+}
+
+// tranche takes as much of an unlock of value as is available at now and returns the amount taken,
+// so an unlock exceeding Limit is paid out over several windows
+func (this *RateLimit) tranche(value uint64, now uint32) uint64 {
+	this.release(now)
+	amount := new(big.Int).SetUint64(value)
+	if available := subFloor(this.Limit, this.UnlockUsed); amount.Cmp(available) == 1 {
+		amount = available
+	}
+	this.UnlockUsed = new(big.Int).Add(this.UnlockUsed, amount)
+	return amount.Uint64()
+}
+
+func subFloor(a, b *big.Int) *big.Int {
+	res := new(big.Int).Sub(a, b)
+	if res.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return res
+}
+
+// PendingUnlock is an unlock exceeding the rate limit, which is released in tranches as the window has room for it
+type PendingUnlock struct {
+	AssetHash          common.Address
+	ToAddress          common.Address
+	FromChainId        uint64
+	FromContractHashBs []byte
+	Value              uint64
+	QueuedTime         uint32
+}
+
+func (this *PendingUnlock) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.AssetHash)
+	utils.EncodeAddress(sink, this.ToAddress)
+	utils.EncodeVarUint(sink, this.FromChainId)
+	utils.EncodeVarBytes(sink, this.FromContractHashBs)
+	utils.EncodeVarUint(sink, this.Value)
+	utils.EncodeVarUint(sink, uint64(this.QueuedTime))
+}
+
+func (this *PendingUnlock) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.AssetHash, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("PendingUnlock.Deserialization DecodeAddress AssetHash error:%s", err)
+	}
+	if this.ToAddress, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("PendingUnlock.Deserialization DecodeAddress ToAddress error:%s", err)
+	}
+	if this.FromChainId, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("PendingUnlock.Deserialization DecodeVarUint FromChainId error:%s", err)
+	}
+	if this.FromContractHashBs, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("PendingUnlock.Deserialization DecodeVarBytes FromContractHashBs error:%s", err)
+	}
+	if this.Value, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("PendingUnlock.Deserialization DecodeVarUint Value error:%s", err)
+	}
+	queuedTime, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("PendingUnlock.Deserialization DecodeVarUint QueuedTime error:%s", err)
+	}
+	this.QueuedTime = uint32(queuedTime)
+	return nil
+}
@@ -24,7 +24,9 @@ import (
 	"testing"
 
 	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/common/constants"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
 	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -103,3 +105,173 @@ func TestBindAssetParam_Serialize(t *testing.T) {
 	}
 	assert.Equal(t, bindAssetParam, bindAssetParam2)
 }
+
+func TestSetRateLimitParam_Serialize(t *testing.T) {
+	param := SetRateLimitParam{
+		SourceAssetHash: utils.OngContractAddress,
+		TargetChainId:   2,
+		Window:          86400,
+		Limit:           big.NewInt(0).SetUint64(constants.ONG_TOTAL_SUPPLY),
+		Caller:          []byte("did:ont:AMAx993nE6NEqZjwBssUfopxnnvTdob9ij"),
+		KeyNo:           1,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	param2 := SetRateLimitParam{}
+	if err := param2.Deserialization(common.NewZeroCopySource(sink.Bytes())); err != nil {
+		t.Fatal("SetRateLimitParam deserialize fail!")
+	}
+	assert.Equal(t, param, param2)
+}
+
+func TestPauseParam_Serialize(t *testing.T) {
+	param := PauseParam{
+		SourceAssetHash: utils.OntContractAddress,
+		Caller:          []byte("did:ont:AMAx993nE6NEqZjwBssUfopxnnvTdob9ij"),
+		KeyNo:           1,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	param2 := PauseParam{}
+	if err := param2.Deserialization(common.NewZeroCopySource(sink.Bytes())); err != nil {
+		t.Fatal("PauseParam deserialize fail!")
+	}
+	assert.Equal(t, param, param2)
+}
+
+func TestPendingUnlock_Serialize(t *testing.T) {
+	toAddr, _ := common.AddressFromBase58("AMAx993nE6NEqZjwBssUfopxnnvTdob9ij")
+	pending := PendingUnlock{
+		AssetHash:          utils.OntContractAddress,
+		ToAddress:          toAddr,
+		FromChainId:        2,
+		FromContractHashBs: []byte{1, 2, 3},
+		Value:              100,
+		QueuedTime:         1600000000,
+	}
+	sink := common.NewZeroCopySink(nil)
+	pending.Serialization(sink)
+
+	pending2 := PendingUnlock{}
+	if err := pending2.Deserialization(common.NewZeroCopySource(sink.Bytes())); err != nil {
+		t.Fatal("PendingUnlock deserialize fail!")
+	}
+	assert.Equal(t, pending, pending2)
+}
+
+func TestRateLimit(t *testing.T) {
+	rateLimit := &RateLimit{
+		Window:      100,
+		Limit:       big.NewInt(1000),
+		LockedUsed:  big.NewInt(0),
+		UnlockUsed:  big.NewInt(0),
+		UpdatedTime: 1000,
+	}
+	ok, available := rateLimit.consume(true, 600, 1000)
+	assert.True(t, ok)
+	assert.Equal(t, int64(400), available.Int64())
+	ok, available = rateLimit.consume(true, 500, 1000)
+	assert.False(t, ok)
+	assert.Equal(t, int64(400), available.Int64())
+	// the unlock direction is limited separately
+	ok, _ = rateLimit.consume(false, 1000, 1000)
+	assert.True(t, ok)
+
+	// 10 seconds release a tenth of the limit
+	ok, available = rateLimit.consume(true, 500, 1010)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), available.Int64())
+	ok, _ = rateLimit.consume(false, 101, 1010)
+	assert.False(t, ok)
+
+	// the whole window releases everything
+	ok, available = rateLimit.consume(false, 1000, 1200)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), available.Int64())
+	assert.Equal(t, int64(0), rateLimit.LockedUsed.Int64())
+
+	sink := common.NewZeroCopySink(nil)
+	rateLimit.Serialization(sink)
+	rateLimit2 := &RateLimit{}
+	assert.NoError(t, rateLimit2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, rateLimit, rateLimit2)
+}
+
+func TestRateLimitRemainder(t *testing.T) {
+	rateLimit := &RateLimit{
+		Window:      3,
+		Limit:       big.NewInt(10),
+		LockedUsed:  big.NewInt(10),
+		UnlockUsed:  big.NewInt(0),
+		UpdatedTime: 1000,
+	}
+	// a second frees 3 and carries the third part of a token
+	rateLimit.release(1001)
+	assert.Equal(t, int64(7), rateLimit.LockedUsed.Int64())
+	assert.Equal(t, uint64(1), rateLimit.Remainder)
+	rateLimit.release(1002)
+	assert.Equal(t, int64(4), rateLimit.LockedUsed.Int64())
+	assert.Equal(t, uint64(2), rateLimit.Remainder)
+	// the carried parts add up to a whole token, as one release of 3 seconds does
+	rateLimit.release(1003)
+	assert.Equal(t, int64(0), rateLimit.LockedUsed.Int64())
+	assert.Equal(t, uint64(0), rateLimit.Remainder)
+
+	sink := common.NewZeroCopySink(nil)
+	rateLimit.Remainder = 2
+	rateLimit.Serialization(sink)
+	rateLimit2 := &RateLimit{}
+	assert.NoError(t, rateLimit2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, uint64(2), rateLimit2.Remainder)
+	assert.Equal(t, rateLimit.UpdatedTime, rateLimit2.UpdatedTime)
+}
+
+func TestRateLimitOversizeUnlock(t *testing.T) {
+	rateLimit := &RateLimit{
+		Window:      100,
+		Limit:       big.NewInt(1000),
+		LockedUsed:  big.NewInt(0),
+		UnlockUsed:  big.NewInt(1),
+		UpdatedTime: 1000,
+	}
+	// the oversize unlock is never taken at once, even from a free window
+	ok, available := rateLimit.consume(false, 5000, 1000)
+	assert.False(t, ok)
+	assert.Equal(t, int64(999), available.Int64())
+	ok, _ = rateLimit.consume(false, 5000, 1001)
+	assert.False(t, ok)
+
+	// but paid out in tranches of the available amount
+	assert.Equal(t, uint64(1000), rateLimit.tranche(5000, 1001))
+	assert.Equal(t, int64(1000), rateLimit.UnlockUsed.Int64())
+	assert.Equal(t, uint64(0), rateLimit.tranche(4000, 1001))
+	assert.Equal(t, uint64(500), rateLimit.tranche(4000, 1051))
+	assert.Equal(t, uint64(1000), rateLimit.tranche(3500, 1200))
+	assert.Equal(t, uint64(300), rateLimit.tranche(300, 1400))
+	assert.Equal(t, int64(300), rateLimit.UnlockUsed.Int64())
+
+	// an oversize lock is still rejected
+	ok, _ = rateLimit.consume(true, 5000, 1001)
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), rateLimit.LockedUsed.Int64())
+}
+
+func TestRateLimitActivation(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	service := &native.NativeService{Height: 100}
+	assert.False(t, isRateLimitActivated(service))
+	for _, method := range []func(*native.NativeService) ([]byte, error){
+		InitAdmin, SetRateLimit, Pause, Unpause, ReleaseUnlock, GetRateLimit, IsPaused, GetPendingUnlock,
+	} {
+		_, err := method(service)
+		assert.EqualError(t, err, "block num is not reached for this func")
+	}
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	assert.True(t, isRateLimitActivated(service))
+}
@@ -19,6 +19,7 @@
 package lock_proxy
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -27,6 +28,7 @@ import (
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/smartcontract/event"
 	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/auth"
 	"github.com/qbyyf/ontology/smartcontract/service/native/cross_chain/cross_chain_manager"
 	"github.com/qbyyf/ontology/smartcontract/service/native/ont"
 	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
@@ -42,10 +44,25 @@ const (
 	GET_ASSET_HASH_NAME     = "getAssetHash"
 	GET_CROSSED_LIMIT_NAME  = "getCrossedLimit"
 	GET_CROSSED_AMOUNT_NAME = "getCrossedAmount"
+	INIT_ADMIN_NAME         = "initAdmin"
+	SET_RATE_LIMIT_NAME     = "setRateLimit"
+	PAUSE_NAME              = "pause"
+	UNPAUSE_NAME            = "unpause"
+	RELEASE_UNLOCK_NAME     = "releaseUnlock"
+	GET_RATE_LIMIT_NAME     = "getRateLimit"
+	IS_PAUSED_NAME          = "isPaused"
+	GET_PENDING_UNLOCK_NAME = "getPendingUnlock"
+
+	RATE_LIMIT_HIT_EVENT = "rateLimitHit"
+	UNLOCK_QUEUED_EVENT  = "unlockQueued"
 
 	TARGET_ASSET_HASH_PEFIX = "TargetAssetHash"
 	CROSS_LIMIT_PREFIX      = "AssetCrossLimit"
 	CROSS_AMOUNT_PREFIX     = "AssetCrossedAmount"
+	RATE_LIMIT_PREFIX       = "AssetRateLimit"
+	PAUSED_PREFIX           = "AssetPaused"
+	PENDING_UNLOCK_PREFIX   = "PendingUnlock"
+	PENDING_UNLOCK_ID       = "PendingUnlockId"
 )
 
 func AddLockNotifications(native *native.NativeService, contract, sourceAssetAddress common.Address, toChainId uint64, toContract []byte, targetAssetHash []byte, fromAddress common.Address, toAddress []byte, amount uint64) {
@@ -69,6 +86,28 @@ func AddUnLockNotifications(native *native.NativeService, contract common.Addres
 		})
 }
 
+func AddRateLimitHitNotifications(native *native.NativeService, contract, assetAddress common.Address, method string, chainId uint64, amount uint64, available *big.Int) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          []interface{}{RATE_LIMIT_HIT_EVENT, method, hex.EncodeToString(assetAddress[:]), chainId, amount, available.String()},
+		})
+}
+
+func AddUnlockQueuedNotifications(native *native.NativeService, contract common.Address, id uint64, pending *PendingUnlock) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          []interface{}{UNLOCK_QUEUED_EVENT, id, pending.FromChainId, hex.EncodeToString(pending.AssetHash[:]), pending.ToAddress.ToBase58(), pending.Value},
+		})
+}
+
 func getCreateTxArgs(toChainID uint64, contractHashBytes []byte, method string, argsBytes []byte) []byte {
 	createCrossChainTxParam := &cross_chain_manager.CreateCrossChainTxParam{
 		ToChainID:         toChainID,
@@ -151,3 +190,149 @@ func getTransferFromInput(toAddress common.Address, value uint64) []byte {
 	transferFromState.Serialization(sink)
 	return sink.Bytes()
 }
+
+// isRateLimitActivated reports whether the rate limit, pause and queued unlock of lock proxy are activated
+func isRateLimitActivated(native *native.NativeService) bool {
+	return native.Height >= config.GetLockProxyRateLimitHeight()
+}
+
+func GenRateLimitKey(contract, assetContract common.Address, chainId uint64) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(chainId)
+	chainIdBytes := sink.Bytes()
+	temp := append(contract[:], []byte(RATE_LIMIT_PREFIX)...)
+	temp = append(temp, assetContract[:]...)
+	return append(temp, chainIdBytes...)
+}
+
+func GenPausedKey(contract, assetContract common.Address) []byte {
+	temp := append(contract[:], []byte(PAUSED_PREFIX)...)
+	return append(temp, assetContract[:]...)
+}
+
+func GenPendingUnlockKey(contract common.Address, id uint64) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(id)
+	temp := append(contract[:], []byte(PENDING_UNLOCK_PREFIX)...)
+	return append(temp, sink.Bytes()...)
+}
+
+func GenPendingUnlockIdKey(contract common.Address) []byte {
+	return append(contract[:], []byte(PENDING_UNLOCK_ID)...)
+}
+
+func getRateLimit(native *native.NativeService, contract, assetAddress common.Address, chainId uint64) (*RateLimit, error) {
+	rateLimitBs, err := utils.GetStorageVarBytes(native, GenRateLimitKey(contract, assetAddress, chainId))
+	if err != nil {
+		return nil, fmt.Errorf("getRateLimit, error:%s", err)
+	}
+	if len(rateLimitBs) == 0 {
+		return nil, nil
+	}
+	rateLimit := new(RateLimit)
+	if err := rateLimit.Deserialization(common.NewZeroCopySource(rateLimitBs)); err != nil {
+		return nil, fmt.Errorf("getRateLimit, deserialize rate limit error:%s", err)
+	}
+	return rateLimit, nil
+}
+
+func putRateLimit(native *native.NativeService, contract, assetAddress common.Address, chainId uint64, rateLimit *RateLimit) {
+	native.CacheDB.Put(GenRateLimitKey(contract, assetAddress, chainId), utils.GenVarBytesStorageItem(common.SerializeToBytes(rateLimit)).ToArray())
+}
+
+// consumeRateLimit takes value from the rate limit of the asset crossing with the chain,
+// it returns false and the available amount if the value exceeds the limit
+func consumeRateLimit(native *native.NativeService, contract, assetAddress common.Address, chainId uint64, isLock bool, value uint64) (bool, *big.Int, error) {
+	rateLimit, err := getRateLimit(native, contract, assetAddress, chainId)
+	if err != nil {
+		return false, nil, err
+	}
+	if rateLimit == nil || rateLimit.Limit.Sign() == 0 {
+		return true, nil, nil
+	}
+	ok, available := rateLimit.consume(isLock, value, native.Time)
+	if !ok {
+		return false, available, nil
+	}
+	putRateLimit(native, contract, assetAddress, chainId, rateLimit)
+	return true, available, nil
+}
+
+// consumeUnlockTranche takes at most value from the available unlock amount and returns the amount taken
+func consumeUnlockTranche(native *native.NativeService, contract, assetAddress common.Address, chainId uint64, value uint64) (uint64, error) {
+	rateLimit, err := getRateLimit(native, contract, assetAddress, chainId)
+	if err != nil {
+		return 0, err
+	}
+	if rateLimit == nil || rateLimit.Limit.Sign() == 0 {
+		return value, nil
+	}
+	amount := rateLimit.tranche(value, native.Time)
+	putRateLimit(native, contract, assetAddress, chainId, rateLimit)
+	return amount, nil
+}
+
+func isPaused(native *native.NativeService, contract, assetAddress common.Address) (bool, error) {
+	pausedBs, err := utils.GetStorageVarBytes(native, GenPausedKey(contract, assetAddress))
+	if err != nil {
+		return false, fmt.Errorf("isPaused, error:%s", err)
+	}
+	return bytes.Equal(pausedBs, utils.BYTE_TRUE), nil
+}
+
+func getPendingUnlock(native *native.NativeService, contract common.Address, id uint64) (*PendingUnlock, error) {
+	pendingBs, err := utils.GetStorageVarBytes(native, GenPendingUnlockKey(contract, id))
+	if err != nil {
+		return nil, fmt.Errorf("getPendingUnlock, error:%s", err)
+	}
+	if len(pendingBs) == 0 {
+		return nil, nil
+	}
+	pending := new(PendingUnlock)
+	if err := pending.Deserialization(common.NewZeroCopySource(pendingBs)); err != nil {
+		return nil, fmt.Errorf("getPendingUnlock, deserialize pending unlock error:%s", err)
+	}
+	return pending, nil
+}
+
+// queueUnlock stores the pending unlock with a new id
+func queueUnlock(native *native.NativeService, contract common.Address, pending *PendingUnlock) (uint64, error) {
+	idKey := GenPendingUnlockIdKey(contract)
+	idBs, err := utils.GetStorageVarBytes(native, idKey)
+	if err != nil {
+		return 0, fmt.Errorf("queueUnlock, get pending unlock id error:%s", err)
+	}
+	var id uint64
+	if len(idBs) != 0 {
+		if id, err = utils.DecodeUint64(common.NewZeroCopySource(idBs)); err != nil {
+			return 0, fmt.Errorf("queueUnlock, decode pending unlock id error:%s", err)
+		}
+	}
+	id++
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(id)
+	native.CacheDB.Put(idKey, utils.GenVarBytesStorageItem(sink.Bytes()).ToArray())
+	putPendingUnlock(native, contract, id, pending)
+	return id, nil
+}
+
+func putPendingUnlock(native *native.NativeService, contract common.Address, id uint64, pending *PendingUnlock) {
+	native.CacheDB.Put(GenPendingUnlockKey(contract, id), utils.GenVarBytesStorageItem(common.SerializeToBytes(pending)).ToArray())
+}
+
+func appCallVerifyToken(native *native.NativeService, contract common.Address, caller []byte, fn string, keyNo uint64) error {
+	params := &auth.VerifyTokenParam{
+		ContractAddr: contract,
+		Caller:       caller,
+		Fn:           fn,
+		KeyNo:        keyNo,
+	}
+	ok, err := native.NativeCall(utils.AuthContractAddress, "verifyToken", common.SerializeToBytes(params))
+	if err != nil {
+		return fmt.Errorf("appCallVerifyToken, appCall error:%s", err)
+	}
+	if !bytes.Equal(ok, utils.BYTE_TRUE) {
+		return fmt.Errorf("appCallVerifyToken, %s is not authorized to invoke %s", string(caller), fn)
+	}
+	return nil
+}
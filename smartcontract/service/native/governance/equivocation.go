@@ -0,0 +1,240 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	vbftconfig "github.com/qbyyf/ontology/consensus/vbft/config"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/types"
+	cutils "github.com/qbyyf/ontology/core/utils"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	//evidence type
+	PROPOSAL_EVIDENCE    = 0
+	ENDORSEMENT_EVIDENCE = 1
+
+	ENDORSEMENT_DOMAIN = "ontology vbft endorsement"
+)
+
+// EndorsementHash is the hash an endorser signs besides the block hash. It binds the signature to the endorse
+// phase and the chain, so that unlike the signatures on the bare block hash it can be proved on chain that the
+// endorser endorsed two blocks.
+func EndorsementHash(networkId uint32, blockHash common.Uint256, forEmpty bool) common.Uint256 {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(ENDORSEMENT_DOMAIN)
+	sink.WriteUint32(networkId)
+	sink.WriteHash(blockHash)
+	sink.WriteBool(forEmpty)
+	return common.Uint256(sha256.Sum256(sink.Bytes()))
+}
+
+// IsEmptyBlockHeader returns whether the transactions root of header proves that it is the empty block of a
+// proposal, which has no transactions or only the commitDpos transaction of its height
+func IsEmptyBlockHeader(header *types.Header) (bool, error) {
+	if header.TransactionsRoot == common.ComputeMerkleRoot(nil) {
+		return true, nil
+	}
+	mutable := cutils.BuildNativeTransaction(utils.GovernanceContractAddress, COMMIT_DPOS, []byte{})
+	mutable.Nonce = header.Height
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return false, fmt.Errorf("build commitDpos transaction error: %v", err)
+	}
+	return header.TransactionsRoot == common.ComputeMerkleRoot([]common.Uint256{tx.Hash()}), nil
+}
+
+// checkConflictingHeaders checks whether two block headers are different blocks on the same previous block
+func checkConflictingHeaders(header1, header2 *types.Header) error {
+	if header1.Height == 0 {
+		return fmt.Errorf("genesis block is not proposed")
+	}
+	if header1.Height != header2.Height {
+		return fmt.Errorf("headers at different height %d and %d", header1.Height, header2.Height)
+	}
+	if header1.PrevBlockHash != header2.PrevBlockHash {
+		return fmt.Errorf("headers of different previous blocks")
+	}
+	if header1.Hash() == header2.Hash() {
+		return fmt.Errorf("headers are the same")
+	}
+	return nil
+}
+
+// CheckConflictingProposals checks whether two block headers are conflicting proposals of the peer at peerIndex.
+// An honest proposer may propose again at the same height after a restart or a proposal backoff, each of which
+// is a new proposal round, and in one round it only signs the block and the empty block of a single proposal,
+// which have the same timestamp and consensus payload. So only two different proposals at the same height,
+// previous block and proposal round are conflicting, unless the transactions root of one of them proves it is
+// the empty block.
+func CheckConflictingProposals(peerIndex uint32, header1, header2 *types.Header) error {
+	if err := checkConflictingHeaders(header1, header2); err != nil {
+		return err
+	}
+	rounds := make([]uint32, 0, 2)
+	for _, header := range []*types.Header{header1, header2} {
+		blkInfo := &vbftconfig.VbftBlockInfo{}
+		if err := json.Unmarshal(header.ConsensusPayload, blkInfo); err != nil {
+			return fmt.Errorf("unmarshal consensus payload of block %d: %v", header.Height, err)
+		}
+		if blkInfo.Proposer != peerIndex {
+			return fmt.Errorf("block %d is proposed by peer %d, not peer %d", header.Height, blkInfo.Proposer, peerIndex)
+		}
+		rounds = append(rounds, blkInfo.ProposalRound)
+	}
+	if rounds[0] != rounds[1] {
+		return fmt.Errorf("headers of different proposal rounds %d and %d", rounds[0], rounds[1])
+	}
+	if header1.Timestamp == header2.Timestamp && string(header1.ConsensusPayload) == string(header2.ConsensusPayload) {
+		for _, header := range []*types.Header{header1, header2} {
+			empty, err := IsEmptyBlockHeader(header)
+			if err != nil {
+				return err
+			}
+			if empty {
+				return fmt.Errorf("headers are block and empty block of the same proposal")
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyEquivocation verifies that param proves the peer signed two conflicting block proposals, or endorsed
+// two blocks at the same height, of the chain of networkId, and returns the first header. The caller should
+// check that the previous block of the header is in its chain.
+// Commitments are signatures on the bare block hash, which do not tell which phase they are signed in, so the
+// endorsements are proved by the signatures on their EndorsementHash.
+func VerifyEquivocation(peerIndex uint32, networkId uint32, param *SlashEquivocationParam) (*types.Header, error) {
+	if param.ChainId != networkId {
+		return nil, fmt.Errorf("evidence of chain %d, not chain %d", param.ChainId, networkId)
+	}
+	pubKeyBytes, err := hex.DecodeString(param.PeerPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	pubKey, err := keypair.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("keypair.DeserializePublicKey, deserialize peerPubkey error: %v", err)
+	}
+	header1, err := types.HeaderFromRawBytes(param.Header1)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize header1 error: %v", err)
+	}
+	header2, err := types.HeaderFromRawBytes(param.Header2)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize header2 error: %v", err)
+	}
+	hash1, hash2 := header1.Hash(), header2.Hash()
+	switch param.EvidenceType {
+	case PROPOSAL_EVIDENCE:
+	case ENDORSEMENT_EVIDENCE:
+		hash1, hash2 = EndorsementHash(networkId, hash1, false), EndorsementHash(networkId, hash2, false)
+	default:
+		return nil, fmt.Errorf("unknown evidence type %d", param.EvidenceType)
+	}
+	if err := signature.Verify(pubKey, hash1[:], param.Sig1); err != nil {
+		return nil, fmt.Errorf("verify signature of header1 error: %v", err)
+	}
+	if err := signature.Verify(pubKey, hash2[:], param.Sig2); err != nil {
+		return nil, fmt.Errorf("verify signature of header2 error: %v", err)
+	}
+	if param.EvidenceType == ENDORSEMENT_EVIDENCE {
+		err = checkConflictingHeaders(header1, header2)
+	} else {
+		err = CheckConflictingProposals(peerIndex, header1, header2)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return header1, nil
+}
+
+// Slash a consensus or candidate node which signed conflicting block proposals or endorsements, anyone can submit
+// the evidence
+func SlashEquivocation(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetSlashEquivocationHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	params := new(SlashEquivocationParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[params.PeerPubkey]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Status == BlackStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, peer is already in black list")
+	}
+	header, err := VerifyEquivocation(peerPoolItem.Index, config.DefConfig.P2PNode.NetworkId, params)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, verify evidence error: %v", err)
+	}
+	//the proposals must be on top of the block of this chain
+	if header.Height > native.Height || native.Store.GetBlockHash(header.Height-1) != header.PrevBlockHash {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, proposals of block %d are not on this chain", header.Height)
+	}
+
+	commit, err := blackPeer(native, contract, peerPoolMap, params.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("blackPeer, black peer error: %v", err)
+	}
+	err = putPeerPoolMap(native, contract, view, peerPoolMap)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+	}
+	//commitDpos, initPos and authorize penalty of the peer are moved to penalty stake in blackQuit
+	if commit {
+		err = executeCommitDpos(native, contract, false)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("executeCommitDpos, executeCommitDpos error: %v", err)
+		}
+	}
+	if config.DefConfig.Common.EnableEventLog {
+		native.Notifications = append(native.Notifications,
+			&event.NotifyEventInfo{
+				ContractAddress: contract,
+				States:          []interface{}{SLASH_EQUIVOCATION, params.PeerPubkey, peerPoolItem.InitPos},
+			})
+	}
+	return utils.BYTE_TRUE, nil
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package governance
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	vbftconfig "github.com/qbyyf/ontology/consensus/vbft/config"
+	"github.com/qbyyf/ontology/core/signature"
+	"github.com/qbyyf/ontology/core/store"
+	"github.com/qbyyf/ontology/core/store/leveldbstore"
+	"github.com/qbyyf/ontology/core/store/overlaydb"
+	"github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/smartcontract/context"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/qbyyf/ontology/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStore is the ledger store which only serves block hashes
+type testStore struct {
+	store.LedgerStore
+	hashes map[uint32]common.Uint256
+}
+
+func (self *testStore) GetBlockHash(height uint32) common.Uint256 {
+	return self.hashes[height]
+}
+
+// testContextRef runs the native methods of governance contract, which are witnessed by witnesses
+type testContextRef struct {
+	context.ContextRef
+	witnesses []common.Address
+}
+
+func (self *testContextRef) CheckWitness(address common.Address) bool {
+	for _, witness := range self.witnesses {
+		if witness == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *testContextRef) CurrentContext() *context.Context {
+	return &context.Context{ContractAddress: utils.GovernanceContractAddress}
+}
+
+// newTestNative returns the native service of governance contract at height, whose ledger
+// has the block hashes and the peer pool of peers
+func newTestNative(t *testing.T, height uint32, hashes map[uint32]common.Uint256, peers ...*PeerPoolItem) *native.NativeService {
+	ns := &native.NativeService{
+		Store:      &testStore{hashes: hashes},
+		CacheDB:    storage.NewCacheDB(overlaydb.NewOverlayDB(leveldbstore.NewMemLevelDBStore())),
+		ContextRef: &testContextRef{},
+		Height:     height,
+	}
+	contract := utils.GovernanceContractAddress
+	assert.Nil(t, putGovernanceView(ns, contract, &GovernanceView{View: 1}))
+	peerPoolMap := &PeerPoolMap{PeerPoolMap: make(map[string]*PeerPoolItem)}
+	for _, peer := range peers {
+		peerPoolMap.PeerPoolMap[peer.PeerPubkey] = peer
+	}
+	assert.Nil(t, putPeerPoolMap(ns, contract, 1, peerPoolMap))
+	return ns
+}
+
+func signedProposal(t *testing.T, acc *account.Account, proposer uint32, prevHash common.Uint256, timestamp uint32) *types.Header {
+	return signedProposalWithRoot(t, acc, proposer, prevHash, timestamp, common.Uint256{})
+}
+
+func signedProposalWithRoot(t *testing.T, acc *account.Account, proposer uint32, prevHash common.Uint256, timestamp uint32,
+	txRoot common.Uint256) *types.Header {
+	payload, err := json.Marshal(&vbftconfig.VbftBlockInfo{Proposer: proposer})
+	assert.Nil(t, err)
+	header := &types.Header{
+		Height:           10,
+		PrevBlockHash:    prevHash,
+		Timestamp:        timestamp,
+		TransactionsRoot: txRoot,
+		ConsensusPayload: payload,
+	}
+	hash := header.Hash()
+	sig, err := signature.Sign(acc, hash[:])
+	assert.Nil(t, err)
+	header.SigData = [][]byte{sig}
+	return header
+}
+
+func equivocationInput(pubkey string, header1, header2 *types.Header) []byte {
+	param := &SlashEquivocationParam{
+		PeerPubkey: pubkey,
+		ChainId:    config.DefConfig.P2PNode.NetworkId,
+		Header1:    header1.ToArray(),
+		Sig1:       header1.SigData[0],
+		Header2:    header2.ToArray(),
+		Sig2:       header2.SigData[0],
+	}
+	return common.SerializeToBytes(param)
+}
+
+// endorsementInput is the evidence that acc endorsed both header1 and header2
+func endorsementInput(t *testing.T, acc *account.Account, networkId uint32, header1, header2 *types.Header) []byte {
+	hash1 := EndorsementHash(networkId, header1.Hash(), false)
+	sig1, err := signature.Sign(acc, hash1[:])
+	assert.Nil(t, err)
+	hash2 := EndorsementHash(networkId, header2.Hash(), false)
+	sig2, err := signature.Sign(acc, hash2[:])
+	assert.Nil(t, err)
+	param := &SlashEquivocationParam{
+		PeerPubkey:   vbftconfig.PubkeyID(acc.PublicKey),
+		ChainId:      config.DefConfig.P2PNode.NetworkId,
+		EvidenceType: ENDORSEMENT_EVIDENCE,
+		Header1:      header1.ToArray(),
+		Sig1:         sig1,
+		Header2:      header2.ToArray(),
+		Sig2:         sig2,
+	}
+	return common.SerializeToBytes(param)
+}
+
+func TestSlashEquivocation(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	acc := account.NewAccount("")
+	other := account.NewAccount("")
+	pubkey := vbftconfig.PubkeyID(acc.PublicKey)
+	prevHash := common.Uint256{9}
+	hashes := map[uint32]common.Uint256{9: prevHash}
+	peer := &PeerPoolItem{Index: 1, PeerPubkey: pubkey, Address: acc.Address, Status: CandidateStatus, InitPos: 1000}
+	proposal1 := signedProposal(t, acc, 1, prevHash, 100)
+	proposal2 := signedProposal(t, acc, 1, prevHash, 101)
+
+	//valid evidence blacks the peer
+	ns := newTestNative(t, 20, hashes, peer)
+	ns.Input = equivocationInput(pubkey, proposal1, proposal2)
+	result, err := SlashEquivocation(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, result)
+	peerPoolMap, err := GetPeerPoolMap(ns, utils.GovernanceContractAddress, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, BlackStatus, peerPoolMap.PeerPoolMap[pubkey].Status)
+
+	//duplicate evidence is rejected
+	_, err = SlashEquivocation(ns)
+	assert.NotNil(t, err)
+
+	//forged evidence is rejected
+	forged := signedProposal(t, other, 1, prevHash, 102)
+	ns = newTestNative(t, 20, hashes, peer)
+	ns.Input = equivocationInput(pubkey, proposal1, forged)
+	_, err = SlashEquivocation(ns)
+	assert.NotNil(t, err)
+
+	//proposals on another chain are rejected
+	ns = newTestNative(t, 20, map[uint32]common.Uint256{9: {8}}, peer)
+	ns.Input = equivocationInput(pubkey, proposal1, proposal2)
+	_, err = SlashEquivocation(ns)
+	assert.NotNil(t, err)
+
+	//evidence is rejected before activation
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	ns = newTestNative(t, 20, hashes, peer)
+	ns.Input = equivocationInput(pubkey, proposal1, proposal2)
+	_, err = SlashEquivocation(ns)
+	assert.NotNil(t, err)
+}
+
+func TestEmptyBlockExemption(t *testing.T) {
+	acc := account.NewAccount("")
+	prevHash := common.Uint256{9}
+	block := signedProposalWithRoot(t, acc, 1, prevHash, 100, common.Uint256{1})
+	other := signedProposalWithRoot(t, acc, 1, prevHash, 100, common.Uint256{2})
+	empty := signedProposalWithRoot(t, acc, 1, prevHash, 100, common.ComputeMerkleRoot(nil))
+
+	//the empty block of a proposal is not an equivocation
+	assert.NotNil(t, CheckConflictingProposals(1, block, empty))
+	//two blocks with transactions are, even with the same timestamp and payload
+	assert.Nil(t, CheckConflictingProposals(1, block, other))
+}
+
+func TestSlashEndorsementEquivocation(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	proposer := account.NewAccount("")
+	endorser := account.NewAccount("")
+	pubkey := vbftconfig.PubkeyID(endorser.PublicKey)
+	prevHash := common.Uint256{9}
+	hashes := map[uint32]common.Uint256{9: prevHash}
+	peer := &PeerPoolItem{Index: 2, PeerPubkey: pubkey, Address: endorser.Address, Status: CandidateStatus, InitPos: 1000}
+	block1 := signedProposalWithRoot(t, proposer, 1, prevHash, 100, common.Uint256{1})
+	block2 := signedProposalWithRoot(t, proposer, 3, prevHash, 100, common.Uint256{2})
+
+	//endorsing two blocks at the same height blacks the endorser
+	ns := newTestNative(t, 20, hashes, peer)
+	ns.Input = endorsementInput(t, endorser, config.NETWORK_ID_SOLO_NET, block1, block2)
+	result, err := SlashEquivocation(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, result)
+	peerPoolMap, err := GetPeerPoolMap(ns, utils.GovernanceContractAddress, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, BlackStatus, peerPoolMap.PeerPoolMap[pubkey].Status)
+
+	//signatures on the bare block hash are not endorsements
+	param := &SlashEquivocationParam{
+		PeerPubkey:   pubkey,
+		ChainId:      config.DefConfig.P2PNode.NetworkId,
+		EvidenceType: ENDORSEMENT_EVIDENCE,
+		Header1:      block1.ToArray(),
+		Sig1:         block1.SigData[0],
+		Header2:      block2.ToArray(),
+		Sig2:         block2.SigData[0],
+	}
+	_, err = VerifyEquivocation(1, config.DefConfig.P2PNode.NetworkId, param)
+	assert.NotNil(t, err)
+
+	//endorsements of another network are rejected
+	ns = newTestNative(t, 20, hashes, peer)
+	ns.Input = endorsementInput(t, endorser, config.NETWORK_ID_POLARIS_NET, block1, block2)
+	_, err = SlashEquivocation(ns)
+	assert.NotNil(t, err)
+}
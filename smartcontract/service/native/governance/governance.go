@@ -81,6 +81,20 @@ const (
 	GET_PEER_POOL                    = "getPeerPool"
 	GET_PEER_INFO                    = "getPeerInfo"
 	GET_PEER_POOL_BY_ADDRESS         = "getPeerPoolByAddress"
+	SLASH_EQUIVOCATION               = "slashEquivocation"
+	SUBMIT_PROPOSAL                  = "submitProposal"
+	VOTE_PROPOSAL                    = "voteProposal"
+	GET_PROPOSAL                     = "getProposal"
+	GET_ACTIVE_PROPOSALS             = "getActiveProposals"
+	GET_AUTHORIZE_INFO               = "getAuthorizeInfo"
+	GET_ADDRESS_FEE                  = "getAddressFee"
+	GET_TOTAL_STAKE                  = "getTotalStake"
+	GET_PENALTY_STAKE                = "getPenaltyStake"
+	GET_PROMISE_POS                  = "getPromisePos"
+	GET_PEER_ATTRIBUTES              = "getPeerAttributes"
+	GET_GLOBAL_PARAM2                = "getGlobalParam2"
+	GET_SPLIT_CURVE                  = "getSplitCurve"
+	GET_STAKING_INFO                 = "getStakingInfo"
 
 	//key prefix
 	GLOBAL_PARAM      = "globalParam"
@@ -100,12 +114,20 @@ const (
 	PROMISE_POS       = "promisePos"
 	PRE_CONFIG        = "preConfig"
 	GAS_ADDRESS       = "gasAddress"
+	PROPOSAL          = "proposal"
+	PROPOSAL_ID       = "proposalID"
+	ACTIVE_PROPOSALS  = "activeProposals"
 
 	//global
 	PRECISE            = 1000000
 	NEW_VERSION_VIEW   = 6
 	NEW_VERSION_BLOCK  = 414100
 	NEW_WITHDRAW_BLOCK = 2800000
+
+	//proposal
+	PROPOSAL_VOTING_VIEWS    = 1   //proposal is tallied at the commitDpos after PROPOSAL_VOTING_VIEWS full views
+	MAX_ACTIVE_PROPOSALS     = 16  //max proposals in voting at the same time
+	MAX_PROPOSAL_DESCRIPTION = 512 //max length of proposal description
 )
 
 // candidate fee must >= 1 ONG
@@ -158,10 +180,24 @@ func RegisterGovernanceContract(native *native.NativeService) {
 	native.Register(TRANSFER_PENALTY, TransferPenalty)
 	native.Register(SET_PROMISE_POS, SetPromisePos)
 	native.Register(SET_GAS_ADDRESS, SetGasAddress)
+	native.Register(SLASH_EQUIVOCATION, SlashEquivocation)
+	native.Register(SUBMIT_PROPOSAL, SubmitProposal)
+	native.Register(VOTE_PROPOSAL, VoteProposal)
 
 	native.Register(GET_PEER_POOL, GetPeerPool)
 	native.Register(GET_PEER_INFO, GetPeerInfo)
 	native.Register(GET_PEER_POOL_BY_ADDRESS, GetPeerPoolByAddress)
+	native.Register(GET_PROPOSAL, GetProposal)
+	native.Register(GET_ACTIVE_PROPOSALS, GetActiveProposals)
+	native.Register(GET_AUTHORIZE_INFO, GetAuthorizeInfo)
+	native.Register(GET_ADDRESS_FEE, GetAddressFee)
+	native.Register(GET_TOTAL_STAKE, GetTotalStake)
+	native.Register(GET_PENALTY_STAKE, GetPenaltyStake)
+	native.Register(GET_PROMISE_POS, GetPromisePos)
+	native.Register(GET_PEER_ATTRIBUTES, GetPeerAttributes)
+	native.Register(GET_GLOBAL_PARAM2, GetGlobalParam2)
+	native.Register(GET_SPLIT_CURVE, GetSplitCurve)
+	native.Register(GET_STAKING_INFO, GetStakingInfo)
 }
 
 //Init governance contract, include vbft config, global param and ontid admin.
@@ -624,28 +660,11 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 	}
 	commit := false
 	for _, peerPubkey := range params.PeerPubkeyList {
-		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+		isConsensus, err := blackPeer(native, contract, peerPoolMap, peerPubkey)
 		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
-		}
-		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
-		if !ok {
-			return utils.BYTE_FALSE, fmt.Errorf("blackNode, peerPubkey is not in peerPoolMap")
-		}
-
-		blackListItem := &BlackListItem{
-			PeerPubkey: peerPoolItem.PeerPubkey,
-			Address:    peerPoolItem.Address,
-			InitPos:    peerPoolItem.InitPos,
+			return utils.BYTE_FALSE, fmt.Errorf("blackNode, %v", err)
 		}
-		//put peer into black list
-		native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(common.SerializeToBytes(blackListItem)))
-		//change peerPool status
-		if peerPoolItem.Status == ConsensusStatus {
-			commit = true
-		}
-		peerPoolItem.Status = BlackStatus
-		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
+		commit = commit || isConsensus
 	}
 	err = putPeerPoolMap(native, contract, view, peerPoolMap)
 	if err != nil {
@@ -654,7 +673,7 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 
 	//commitDpos
 	if commit {
-		err = executeCommitDpos(native, contract)
+		err = executeCommitDpos(native, contract, false)
 		if err != nil {
 			return utils.BYTE_FALSE, fmt.Errorf("executeCommitDpos, executeCommitDpos error: %v", err)
 		}
@@ -1012,7 +1031,7 @@ func CommitDpos(native *native.NativeService) ([]byte, error) {
 		}
 	}
 
-	err = executeCommitDpos(native, contract)
+	err = executeCommitDpos(native, contract, true)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("executeCommitDpos, executeCommitDpos error: %v", err)
 	}
@@ -1036,12 +1055,6 @@ func UpdateConfig(native *native.NativeService) ([]byte, error) {
 	}
 	contract := native.ContextRef.CurrentContext().ContractAddress
 
-	//get globalParam
-	globalParam, err := getGlobalParam(native, contract)
-	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("getGlobalParam, getGlobalParam error: %v", err)
-	}
-
 	configuration := new(Configuration)
 	if err := configuration.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize configuration error: %v", err)
@@ -1052,48 +1065,9 @@ func UpdateConfig(native *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
 	}
-	//get peerPoolMap
-	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	err = checkConfiguration(native, contract, view, configuration)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
-	}
-	candidateNum := 0
-	for _, peerPoolItem := range peerPoolMap.PeerPoolMap {
-		if peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus {
-			candidateNum = candidateNum + 1
-		}
-	}
-
-	//check the configuration
-	if configuration.C == 0 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. C can not be 0 in config")
-	}
-	if int(configuration.K) > candidateNum {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. K can not be larger than num of candidate peer in config")
-	}
-	if configuration.L < 16*configuration.K || configuration.L%configuration.K != 0 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. L can not be less than 16*K and K must be times of L in config")
-	}
-	if configuration.K < 2*configuration.C+1 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. K can not be less than 2*C+1 in config")
-	}
-	if 4*configuration.K > globalParam.CandidateNum {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. 4*K can not be more than candidateNum")
-	}
-	if configuration.N < configuration.K || configuration.K < 7 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. config not match N >= K >= 7")
-	}
-	if configuration.BlockMsgDelay < 5000 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. BlockMsgDelay must >= 5000")
-	}
-	if configuration.HashMsgDelay < 5000 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. HashMsgDelay must >= 5000")
-	}
-	if configuration.PeerHandshakeTimeout < 10 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. PeerHandshakeTimeout must >= 10")
-	}
-	if configuration.MaxBlockChangeView < 10000 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. MaxBlockChangeView must >= 10000")
+		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. %v", err)
 	}
 
 	preConfig := &PreConfig{
@@ -1134,28 +1108,9 @@ func UpdateGlobalParam(native *native.NativeService) ([]byte, error) {
 	if err := globalParam.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize globalParam error: %v", err)
 	}
-
-	//check the globalParam
-	if (globalParam.A + globalParam.B) != 100 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. A + B must equal to 100")
-	}
-	if globalParam.Yita == 0 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. Yita must > 0")
-	}
-	if globalParam.Penalty > 100 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. Penalty must <= 100")
-	}
-	if globalParam.PosLimit < 1 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. PosLimit must >= 1")
-	}
-	if globalParam.CandidateNum < 4*config.K {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. CandidateNum must >= 4*K")
-	}
-	if globalParam.CandidateFee != 0 && globalParam.CandidateFee < MIN_CANDIDATE_FEE {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. CandidateFee must >= %d", MIN_CANDIDATE_FEE)
-	}
-	if globalParam.MinInitStake < 1 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. MinInitStake must >= 1")
+	err = checkGlobalParam(config, globalParam)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. %v", err)
 	}
 	err = putGlobalParam(native, contract, globalParam)
 	if err != nil {
@@ -1194,8 +1149,9 @@ func UpdateGlobalParam2(native *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("getConfig, get config error: %v", err)
 	}
-	if globalParam2.CandidateFeeSplitNum < config.K {
-		return utils.BYTE_FALSE, fmt.Errorf("globalParam2.CandidateFeeSplitNum can not be less than config.K")
+	err = checkGlobalParam2(config, globalParam2)
+	if err != nil {
+		return utils.BYTE_FALSE, err
 	}
 
 	err = putGlobalParam2(native, contract, globalParam2)
@@ -1888,3 +1844,185 @@ func GetPeerPoolForVm(native *native.NativeService) (*PeerPoolListForVm, error)
 	}
 	return peerPoolListForVm, nil
 }
+
+func GetAuthorizeInfo(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	source := common.NewZeroCopySource(native.Input)
+	peerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetAuthorizeInfo, get peerPubkey error: %s", err)
+	}
+	address, err := utils.DecodeAddress(source)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetAuthorizeInfo, get address error: %s", err)
+	}
+	authorizeInfo, err := getAuthorizeInfo(native, contract, peerPubkey, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetAuthorizeInfo, get authorizeInfo error: %s", err)
+	}
+	return common.SerializeToBytes(authorizeInfo), nil
+}
+
+func GetAddressFee(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	address, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetAddressFee, get address error: %s", err)
+	}
+	splitFeeAddress, err := getSplitFeeAddress(native, contract, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetAddressFee, get splitFeeAddress error: %s", err)
+	}
+	return common.SerializeToBytes(splitFeeAddress), nil
+}
+
+func GetTotalStake(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	address, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetTotalStake, get address error: %s", err)
+	}
+	totalStake, err := getTotalStake(native, contract, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetTotalStake, get totalStake error: %s", err)
+	}
+	return common.SerializeToBytes(totalStake), nil
+}
+
+func GetPenaltyStake(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	peerPubkey, err := utils.DecodeString(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPenaltyStake, get peerPubkey error: %s", err)
+	}
+	penaltyStake, err := getPenaltyStake(native, contract, peerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPenaltyStake, get penaltyStake error: %s", err)
+	}
+	return common.SerializeToBytes(penaltyStake), nil
+}
+
+func GetPromisePos(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	peerPubkey, err := utils.DecodeString(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPromisePos, get peerPubkey error: %s", err)
+	}
+	promisePos, err := getPromisePos(native, contract, peerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPromisePos, get promisePos error: %s", err)
+	}
+	return common.SerializeToBytes(promisePos), nil
+}
+
+func GetPeerAttributes(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	peerPubkey, err := utils.DecodeString(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPeerAttributes, get peerPubkey error: %s", err)
+	}
+	peerAttributes, err := getPeerAttributes(native, contract, peerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPeerAttributes, get peerAttributes error: %s", err)
+	}
+	return common.SerializeToBytes(peerAttributes), nil
+}
+
+func GetGlobalParam2(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	globalParam2, err := getGlobalParam2(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetGlobalParam2, get globalParam2 error: %s", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	if err := globalParam2.Serialization(sink); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetGlobalParam2, serialize globalParam2 error: %s", err)
+	}
+	return sink.Bytes(), nil
+}
+
+func GetSplitCurve(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	splitCurve, err := getSplitCurve(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetSplitCurve, get splitCurve error: %s", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	if err := splitCurve.Serialization(sink); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetSplitCurve, serialize splitCurve error: %s", err)
+	}
+	return sink.Bytes(), nil
+}
+
+// get the authorizations of an address to the given peers, or to the peers in peer pool if no peer is given,
+// with total stake and unclaimed fee. The authorizations to peers removed from peer pool are only found if
+// the peers are given, since authorize info is keyed by peer
+func GetStakingInfo(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	params := new(GetStakingInfoParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetStakingInfo, deserialize params error: %s", err)
+	}
+	address := params.Address
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetStakingInfo, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetStakingInfo, get peerPoolMap error: %v", err)
+	}
+	totalStake, err := getTotalStake(native, contract, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetStakingInfo, get totalStake error: %s", err)
+	}
+	splitFeeAddress, err := getSplitFeeAddress(native, contract, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetStakingInfo, get splitFeeAddress error: %s", err)
+	}
+
+	stakingInfo := &AddressStakingInfo{
+		Address:      address,
+		TotalStake:   totalStake.Stake,
+		UnclaimedFee: splitFeeAddress.Amount,
+	}
+	peers := params.PeerPubkeyList
+	if len(peers) == 0 {
+		for peerPubkey := range peerPoolMap.PeerPoolMap {
+			peers = append(peers, peerPubkey)
+		}
+	}
+	seen := make(map[string]bool, len(peers))
+	for _, peerPubkey := range peers {
+		if seen[peerPubkey] {
+			continue
+		}
+		seen[peerPubkey] = true
+		authorizeInfo, err := getAuthorizeInfo(native, contract, peerPubkey, address)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("GetStakingInfo, get authorizeInfo error: %v", err)
+		}
+		if authorizeInfo.ConsensusPos == 0 && authorizeInfo.CandidatePos == 0 && authorizeInfo.NewPos == 0 &&
+			authorizeInfo.WithdrawConsensusPos == 0 && authorizeInfo.WithdrawCandidatePos == 0 && authorizeInfo.WithdrawUnfreezePos == 0 {
+			continue
+		}
+		authorization := &AuthorizeStakingInfo{
+			AuthorizeInfo:   authorizeInfo,
+			PendingPos:      authorizeInfo.NewPos,
+			FrozenPos:       authorizeInfo.WithdrawConsensusPos + authorizeInfo.WithdrawCandidatePos,
+			WithdrawablePos: authorizeInfo.WithdrawUnfreezePos,
+		}
+		if peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]; ok {
+			authorization.PeerStatus = peerPoolItem.Status
+		} else {
+			authorization.PeerRemoved = true
+		}
+		stakingInfo.Authorizations = append(stakingInfo.Authorizations, authorization)
+	}
+	sort.SliceStable(stakingInfo.Authorizations, func(i, j int) bool {
+		return stakingInfo.Authorizations[i].AuthorizeInfo.PeerPubkey < stakingInfo.Authorizations[j].AuthorizeInfo.PeerPubkey
+	})
+	return common.SerializeToBytes(stakingInfo), nil
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"testing"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	vbftconfig "github.com/qbyyf/ontology/consensus/vbft/config"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStakingInfo(t *testing.T) {
+	contract := utils.GovernanceContractAddress
+	active, quit, rejected := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	activePeer := vbftconfig.PubkeyID(active.PublicKey)
+	quitPeer := vbftconfig.PubkeyID(quit.PublicKey)
+	rejectedPeer := vbftconfig.PubkeyID(rejected.PublicKey)
+	ns := newTestNative(t, 20, nil, &PeerPoolItem{Index: 1, PeerPubkey: activePeer, Address: active.Address,
+		Status: ConsensusStatus, InitPos: 1000})
+
+	staker, other := common.Address{1}, common.Address{2}
+	assert.Nil(t, putAuthorizeInfo(ns, contract, &AuthorizeInfo{PeerPubkey: activePeer, Address: staker,
+		ConsensusPos: 800, NewPos: 100, WithdrawConsensusPos: 50}))
+	assert.Nil(t, putAuthorizeInfo(ns, contract, &AuthorizeInfo{PeerPubkey: activePeer, Address: other,
+		ConsensusPos: 500}))
+	//the quit peer has been removed from peer pool, with the stake unfrozen
+	assert.Nil(t, putAuthorizeInfo(ns, contract, &AuthorizeInfo{PeerPubkey: quitPeer, Address: staker,
+		WithdrawUnfreezePos: 300}))
+	//withdrawn authorization of a removed peer is not listed
+	assert.Nil(t, putAuthorizeInfo(ns, contract, &AuthorizeInfo{PeerPubkey: rejectedPeer, Address: staker}))
+	assert.Nil(t, putTotalStake(ns, contract, &TotalStake{Address: staker, Stake: 1250}))
+	assert.Nil(t, putSplitFeeAddress(ns, contract, staker, &SplitFeeAddress{Address: staker, Amount: 7}))
+
+	getStakingInfo := func(param *GetStakingInfoParam) *AddressStakingInfo {
+		sink := common.NewZeroCopySink(nil)
+		assert.Nil(t, param.Serialization(sink))
+		ns.Input = sink.Bytes()
+		res, err := GetStakingInfo(ns)
+		assert.Nil(t, err)
+		info := new(AddressStakingInfo)
+		assert.Nil(t, info.Deserialization(common.NewZeroCopySource(res)))
+		return info
+	}
+
+	//the removed peers are looked up when they are given
+	info := getStakingInfo(&GetStakingInfoParam{Address: staker,
+		PeerPubkeyList: []string{quitPeer, activePeer, rejectedPeer, quitPeer}})
+
+	expected := []*AuthorizeStakingInfo{
+		{
+			AuthorizeInfo: &AuthorizeInfo{PeerPubkey: activePeer, Address: staker, ConsensusPos: 800, NewPos: 100,
+				WithdrawConsensusPos: 50},
+			PeerStatus: ConsensusStatus,
+			PendingPos: 100,
+			FrozenPos:  50,
+		},
+		{
+			AuthorizeInfo:   &AuthorizeInfo{PeerPubkey: quitPeer, Address: staker, WithdrawUnfreezePos: 300},
+			PeerRemoved:     true,
+			WithdrawablePos: 300,
+		},
+	}
+	if expected[0].AuthorizeInfo.PeerPubkey > expected[1].AuthorizeInfo.PeerPubkey {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+	assert.Equal(t, staker, info.Address)
+	assert.Equal(t, uint64(1250), info.TotalStake)
+	assert.Equal(t, uint64(7), info.UnclaimedFee)
+	assert.Equal(t, expected, info.Authorizations)
+
+	//only the peers in peer pool are looked up by default, for the callers only passing the address
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeAddress(sink, staker)
+	ns.Input = sink.Bytes()
+	res, err := GetStakingInfo(ns)
+	assert.Nil(t, err)
+	info = new(AddressStakingInfo)
+	assert.Nil(t, info.Deserialization(common.NewZeroCopySource(res)))
+	assert.Equal(t, uint64(1250), info.TotalStake)
+	assert.Len(t, info.Authorizations, 1)
+	assert.Equal(t, activePeer, info.Authorizations[0].AuthorizeInfo.PeerPubkey)
+
+	//an address without authorization
+	info = getStakingInfo(&GetStakingInfoParam{Address: common.Address{3}, PeerPubkeyList: []string{activePeer, quitPeer}})
+	assert.Empty(t, info.Authorizations)
+}
@@ -689,6 +689,31 @@ func withdrawTotalStake(native *native.NativeService, contract common.Address, a
 	return nil
 }
 
+// put peer into black list and change its status in peerPoolMap, returns whether it is a consensus node
+func blackPeer(native *native.NativeService, contract common.Address, peerPoolMap *PeerPoolMap, peerPubkey string) (bool, error) {
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return false, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
+	if !ok {
+		return false, fmt.Errorf("peerPubkey is not in peerPoolMap")
+	}
+
+	blackListItem := &BlackListItem{
+		PeerPubkey: peerPoolItem.PeerPubkey,
+		Address:    peerPoolItem.Address,
+		InitPos:    peerPoolItem.InitPos,
+	}
+	//put peer into black list
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(common.SerializeToBytes(blackListItem)))
+	//change peerPool status
+	isConsensus := peerPoolItem.Status == ConsensusStatus
+	peerPoolItem.Status = BlackStatus
+	peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
+	return isConsensus, nil
+}
+
 func depositPenaltyStake(native *native.NativeService, contract common.Address, peerPubkey string, initPos uint64, authorizePos uint64) error {
 	penaltyStake, err := getPenaltyStake(native, contract, peerPubkey)
 	if err != nil {
@@ -748,7 +773,9 @@ func withdrawPenaltyStake(native *native.NativeService, contract common.Address,
 	return nil
 }
 
-func executeCommitDpos(native *native.NativeService, contract common.Address) error {
+//executeCommitDpos change to the next view, the proposals of the view are tallied if tallyProposals is set,
+//which is only for the commitDpos at the end of view but not the view change forced by removing a consensus peer
+func executeCommitDpos(native *native.NativeService, contract common.Address, tallyProposals bool) error {
 	governanceView, err := GetGovernanceView(native, contract)
 	if err != nil {
 		return fmt.Errorf("getGovernanceView, get GovernanceView error: %v", err)
@@ -759,6 +786,14 @@ func executeCommitDpos(native *native.NativeService, contract common.Address) er
 	//get current view
 	view := governanceView.View
 
+	//tally proposals before config and global params are used by commitDpos
+	if tallyProposals && native.Height >= config.GetGovProposalHeight() {
+		err = executeProposals(native, contract, view)
+		if err != nil {
+			return fmt.Errorf("executeProposals error: %v", err)
+		}
+	}
+
 	if view <= NEW_VERSION_VIEW {
 		err = executeCommitDpos1(native, contract)
 		if err != nil {
@@ -1455,3 +1490,88 @@ func splitNodeFee(native *native.NativeService, contract common.Address, peerPub
 	}
 	return nil
 }
+
+func checkConfiguration(native *native.NativeService, contract common.Address, view uint32, configuration *Configuration) error {
+	//get globalParam
+	globalParam, err := getGlobalParam(native, contract)
+	if err != nil {
+		return fmt.Errorf("getGlobalParam, getGlobalParam error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	candidateNum := 0
+	for _, peerPoolItem := range peerPoolMap.PeerPoolMap {
+		if peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus {
+			candidateNum = candidateNum + 1
+		}
+	}
+
+	//check the configuration
+	if configuration.C == 0 {
+		return fmt.Errorf("C can not be 0 in config")
+	}
+	if int(configuration.K) > candidateNum {
+		return fmt.Errorf("K can not be larger than num of candidate peer in config")
+	}
+	if configuration.L < 16*configuration.K || configuration.L%configuration.K != 0 {
+		return fmt.Errorf("L can not be less than 16*K and K must be times of L in config")
+	}
+	if configuration.K < 2*configuration.C+1 {
+		return fmt.Errorf("K can not be less than 2*C+1 in config")
+	}
+	if 4*configuration.K > globalParam.CandidateNum {
+		return fmt.Errorf("4*K can not be more than candidateNum")
+	}
+	if configuration.N < configuration.K || configuration.K < 7 {
+		return fmt.Errorf("config not match N >= K >= 7")
+	}
+	if configuration.BlockMsgDelay < 5000 {
+		return fmt.Errorf("BlockMsgDelay must >= 5000")
+	}
+	if configuration.HashMsgDelay < 5000 {
+		return fmt.Errorf("HashMsgDelay must >= 5000")
+	}
+	if configuration.PeerHandshakeTimeout < 10 {
+		return fmt.Errorf("PeerHandshakeTimeout must >= 10")
+	}
+	if configuration.MaxBlockChangeView < 10000 {
+		return fmt.Errorf("MaxBlockChangeView must >= 10000")
+	}
+
+	return nil
+}
+
+func checkGlobalParam(config *Configuration, globalParam *GlobalParam) error {
+	if (globalParam.A + globalParam.B) != 100 {
+		return fmt.Errorf("A + B must equal to 100")
+	}
+	if globalParam.Yita == 0 {
+		return fmt.Errorf("Yita must > 0")
+	}
+	if globalParam.Penalty > 100 {
+		return fmt.Errorf("Penalty must <= 100")
+	}
+	if globalParam.PosLimit < 1 {
+		return fmt.Errorf("PosLimit must >= 1")
+	}
+	if globalParam.CandidateNum < 4*config.K {
+		return fmt.Errorf("CandidateNum must >= 4*K")
+	}
+	if globalParam.CandidateFee != 0 && globalParam.CandidateFee < MIN_CANDIDATE_FEE {
+		return fmt.Errorf("CandidateFee must >= %d", MIN_CANDIDATE_FEE)
+	}
+	if globalParam.MinInitStake < 1 {
+		return fmt.Errorf("MinInitStake must >= 1")
+	}
+	return nil
+}
+
+func checkGlobalParam2(config *Configuration, globalParam2 *GlobalParam2) error {
+	if globalParam2.CandidateFeeSplitNum < config.K {
+		return fmt.Errorf("globalParam2.CandidateFeeSplitNum can not be less than config.K")
+	}
+	return nil
+}
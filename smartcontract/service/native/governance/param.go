@@ -916,3 +916,202 @@ func (this *GasAddress) Deserialization(source *common.ZeroCopySource) error {
 	this.Address = address
 	return nil
 }
+
+type SlashEquivocationParam struct {
+	PeerPubkey   string
+	ChainId      uint32 //network id of the chain which the proposals are signed in
+	EvidenceType uint32 //PROPOSAL_EVIDENCE or ENDORSEMENT_EVIDENCE
+	Header1      []byte //serialized block header signed by peer
+	Sig1         []byte
+	Header2      []byte //serialized conflicting block header signed by peer at the same height
+	Sig2         []byte
+}
+
+func (this *SlashEquivocationParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.PeerPubkey)
+	utils.EncodeVarUint(sink, uint64(this.ChainId))
+	utils.EncodeVarUint(sink, uint64(this.EvidenceType))
+	sink.WriteVarBytes(this.Header1)
+	sink.WriteVarBytes(this.Sig1)
+	sink.WriteVarBytes(this.Header2)
+	sink.WriteVarBytes(this.Sig2)
+}
+
+func (this *SlashEquivocationParam) Deserialization(source *common.ZeroCopySource) error {
+	peerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeString, deserialize peerPubkey error: %v", err)
+	}
+	chainId, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize chainId error: %v", err)
+	}
+	if chainId > math.MaxUint32 {
+		return fmt.Errorf("chainId larger than max of uint32")
+	}
+	evidenceType, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize evidenceType error: %v", err)
+	}
+	if evidenceType > math.MaxUint32 {
+		return fmt.Errorf("evidenceType larger than max of uint32")
+	}
+	header1, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize header1 error: %v", err)
+	}
+	sig1, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize sig1 error: %v", err)
+	}
+	header2, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize header2 error: %v", err)
+	}
+	sig2, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize sig2 error: %v", err)
+	}
+	this.PeerPubkey = peerPubkey
+	this.ChainId = uint32(chainId)
+	this.EvidenceType = uint32(evidenceType)
+	this.Header1 = header1
+	this.Sig1 = sig1
+	this.Header2 = header2
+	this.Sig2 = sig2
+	return nil
+}
+
+type SubmitProposalParam struct {
+	PeerPubkey  string
+	Address     common.Address
+	Type        ProposalType
+	Content     []byte
+	Description string
+}
+
+func (this *SubmitProposalParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteVarBytes(this.Address[:])
+	utils.EncodeVarUint(sink, uint64(this.Type))
+	sink.WriteVarBytes(this.Content)
+	sink.WriteString(this.Description)
+}
+
+func (this *SubmitProposalParam) Deserialization(source *common.ZeroCopySource) error {
+	peerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	address, err := utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize address error: %v", err)
+	}
+	proposalType, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize type error: %v", err)
+	}
+	if proposalType > math.MaxUint8 {
+		return fmt.Errorf("type larger than max of uint8")
+	}
+	content, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize content error: %v", err)
+	}
+	description, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize description error: %v", err)
+	}
+	this.PeerPubkey = peerPubkey
+	this.Address = address
+	this.Type = ProposalType(proposalType)
+	this.Content = content
+	this.Description = description
+	return nil
+}
+
+type VoteProposalParam struct {
+	ID         uint32
+	PeerPubkey string
+	Address    common.Address
+	Approve    bool
+}
+
+func (this *VoteProposalParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(this.ID))
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteVarBytes(this.Address[:])
+	utils.EncodeBool(sink, this.Approve)
+}
+
+func (this *VoteProposalParam) Deserialization(source *common.ZeroCopySource) error {
+	id, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize id error: %v", err)
+	}
+	if id > math.MaxUint32 {
+		return fmt.Errorf("id larger than max of uint32")
+	}
+	peerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	address, err := utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize address error: %v", err)
+	}
+	approve, err := utils.DecodeBool(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeBool, deserialize approve error: %v", err)
+	}
+	this.ID = uint32(id)
+	this.PeerPubkey = peerPubkey
+	this.Address = address
+	this.Approve = approve
+	return nil
+}
+
+type GetStakingInfoParam struct {
+	Address        common.Address
+	PeerPubkeyList []string //peers to look up, the peers in peer pool if empty
+}
+
+func (this *GetStakingInfoParam) Serialization(sink *common.ZeroCopySink) error {
+	if len(this.PeerPubkeyList) > 1024 {
+		return fmt.Errorf("length of input list > 1024")
+	}
+	sink.WriteVarBytes(this.Address[:])
+	utils.EncodeVarUint(sink, uint64(len(this.PeerPubkeyList)))
+	for _, v := range this.PeerPubkeyList {
+		sink.WriteString(v)
+	}
+	return nil
+}
+
+func (this *GetStakingInfoParam) Deserialization(source *common.ZeroCopySource) error {
+	address, err := utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize address error: %v", err)
+	}
+	peerPubkeyList := make([]string, 0)
+	//the peer list is optional, for the callers only passing the address
+	if source.Len() > 0 {
+		n, err := utils.DecodeVarUint(source)
+		if err != nil {
+			return fmt.Errorf("serialization.ReadVarUint, deserialize peerPubkeyList length error: %v", err)
+		}
+		if n > 1024 {
+			return fmt.Errorf("length of input list > 1024")
+		}
+		for i := 0; uint64(i) < n; i++ {
+			k, _, irregular, eof := source.NextString()
+			if irregular || eof {
+				return fmt.Errorf("serialization.ReadString, deserialize peerPubkey irregular: %v,eof: %v", irregular, eof)
+			}
+			peerPubkeyList = append(peerPubkeyList, k)
+		}
+	}
+	this.Address = address
+	this.PeerPubkeyList = peerPubkeyList
+	return nil
+}
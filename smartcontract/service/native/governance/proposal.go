@@ -0,0 +1,408 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	"github.com/qbyyf/ontology/smartcontract/event"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/global_params"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	//proposal event name
+	PROPOSAL_PASSED   = "proposalPassed"
+	PROPOSAL_REJECTED = "proposalRejected"
+	PROPOSAL_FAILED   = "proposalFailed"
+)
+
+// Submit a parameter change proposal, only candidate and consensus peer can submit
+func SubmitProposal(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetGovProposalHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	params := new(SubmitProposalParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, checkWitness error: %v", err)
+	}
+	if len(params.Description) > MAX_PROPOSAL_DESCRIPTION {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, description is longer than %d", MAX_PROPOSAL_DESCRIPTION)
+	}
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	err = checkProposalPeer(native, contract, view, params.PeerPubkey, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, %v", err)
+	}
+	_, err = prepareProposal(native, contract, view, params.Type, params.Content)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, check content error: %v", err)
+	}
+
+	activeProposals, err := getActiveProposals(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getActiveProposals, get activeProposals error: %v", err)
+	}
+	if len(activeProposals.IDs) >= MAX_ACTIVE_PROPOSALS {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, active proposals reach limit %d", MAX_ACTIVE_PROPOSALS)
+	}
+	for _, id := range activeProposals.IDs {
+		proposal, err := getProposal(native, contract, id)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+		}
+		if proposal.PeerPubkey == params.PeerPubkey {
+			return utils.BYTE_FALSE, fmt.Errorf("submitProposal, peer already has proposal %d in voting", id)
+		}
+	}
+
+	proposalID, err := getProposalID(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposalID, get proposalID error: %v", err)
+	}
+	proposalID = proposalID + 1
+	proposal := &Proposal{
+		ID:          proposalID,
+		PeerPubkey:  params.PeerPubkey,
+		Address:     params.Address,
+		Type:        params.Type,
+		Content:     params.Content,
+		Description: params.Description,
+		StartView:   view,
+		EndView:     view + PROPOSAL_VOTING_VIEWS,
+		Status:      ProposalVoting,
+	}
+	putProposal(native, contract, proposal)
+	putProposalID(native, contract, proposalID)
+	activeProposals.IDs = append(activeProposals.IDs, proposalID)
+	putActiveProposals(native, contract, activeProposals)
+
+	notifyProposal(native, contract, SUBMIT_PROPOSAL, proposalID, params.PeerPubkey, uint8(params.Type), proposal.EndView)
+	return utils.BYTE_TRUE, nil
+}
+
+// Vote for a proposal in voting window, vote is weighted by stake of the peer when tallied, and can be changed before then
+func VoteProposal(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetGovProposalHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("block num is not reached for this func")
+	}
+	params := new(VoteProposalParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, checkWitness error: %v", err)
+	}
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	err = checkProposalPeer(native, contract, view, params.PeerPubkey, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, %v", err)
+	}
+
+	proposal, err := getProposal(native, contract, params.ID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+	}
+	if proposal.Status != ProposalVoting {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, proposal %d is not in voting", params.ID)
+	}
+	voted := false
+	for _, vote := range proposal.Votes {
+		if vote.PeerPubkey == params.PeerPubkey {
+			vote.Approve = params.Approve
+			voted = true
+		}
+	}
+	if !voted {
+		proposal.Votes = append(proposal.Votes, &ProposalVote{PeerPubkey: params.PeerPubkey, Approve: params.Approve})
+	}
+	putProposal(native, contract, proposal)
+
+	notifyProposal(native, contract, VOTE_PROPOSAL, params.ID, params.PeerPubkey, params.Approve)
+	return utils.BYTE_TRUE, nil
+}
+
+func GetProposal(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	id, err := utils.DecodeVarUint(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetProposal, get proposal id error: %v", err)
+	}
+	if id > math.MaxUint32 {
+		return utils.BYTE_FALSE, fmt.Errorf("GetProposal, id larger than max of uint32")
+	}
+	proposal, err := getProposal(native, contract, uint32(id))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetProposal, get proposal error: %v", err)
+	}
+	return common.SerializeToBytes(proposal), nil
+}
+
+func GetActiveProposals(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	activeProposals, err := getActiveProposals(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetActiveProposals, get activeProposals error: %v", err)
+	}
+	proposalList := new(ProposalList)
+	for _, id := range activeProposals.IDs {
+		proposal, err := getProposal(native, contract, id)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("GetActiveProposals, get proposal error: %v", err)
+		}
+		proposalList.Proposals = append(proposalList.Proposals, proposal)
+	}
+	return common.SerializeToBytes(proposalList), nil
+}
+
+func checkProposalPeer(native *native.NativeService, contract common.Address, view uint32, peerPubkey string,
+	address common.Address) error {
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
+	if !ok {
+		return fmt.Errorf("peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Status != CandidateStatus && peerPoolItem.Status != ConsensusStatus {
+		return fmt.Errorf("peer status is not candidate or consensus")
+	}
+	if peerPoolItem.Address != address {
+		return fmt.Errorf("address is not peer owner")
+	}
+	return nil
+}
+
+// prepareProposal checks content of proposal, returns function to execute it
+func prepareProposal(native *native.NativeService, contract common.Address, view uint32, proposalType ProposalType,
+	content []byte) (func() error, error) {
+	config, err := getConfig(native, contract)
+	if err != nil {
+		return nil, fmt.Errorf("getConfig, get config error: %v", err)
+	}
+	source := common.NewZeroCopySource(content)
+	switch proposalType {
+	case ProposalGlobalParam:
+		globalParam := new(GlobalParam)
+		if err := globalParam.Deserialization(source); err != nil {
+			return nil, fmt.Errorf("deserialize, deserialize globalParam error: %v", err)
+		}
+		if err := checkGlobalParam(config, globalParam); err != nil {
+			return nil, err
+		}
+		return func() error {
+			return putGlobalParam(native, contract, globalParam)
+		}, nil
+	case ProposalGlobalParam2:
+		globalParam2 := new(GlobalParam2)
+		if err := globalParam2.Deserialization(source); err != nil {
+			return nil, fmt.Errorf("deserialize, deserialize globalParam2 error: %v", err)
+		}
+		if err := checkGlobalParam2(config, globalParam2); err != nil {
+			return nil, err
+		}
+		return func() error {
+			return putGlobalParam2(native, contract, globalParam2)
+		}, nil
+	case ProposalConfig:
+		configuration := new(Configuration)
+		if err := configuration.Deserialization(source); err != nil {
+			return nil, fmt.Errorf("deserialize, deserialize configuration error: %v", err)
+		}
+		if err := checkConfiguration(native, contract, view, configuration); err != nil {
+			return nil, err
+		}
+		//config is put into effect by commitDpos of the view
+		return func() error {
+			return putPreConfig(native, contract, &PreConfig{Configuration: configuration, SetView: view})
+		}, nil
+	case ProposalSplitCurve:
+		splitCurve := new(SplitCurve)
+		if err := splitCurve.Deserialization(source); err != nil {
+			return nil, fmt.Errorf("deserialize, deserialize splitCurve error: %v", err)
+		}
+		if err := checkSplitCurve(splitCurve); err != nil {
+			return nil, err
+		}
+		return func() error {
+			return putSplitCurve(native, contract, splitCurve)
+		}, nil
+	case ProposalParams:
+		params := global_params.Params{}
+		if err := params.Deserialization(source); err != nil {
+			return nil, fmt.Errorf("deserialize, deserialize params error: %v", err)
+		}
+		if len(params) == 0 {
+			return nil, fmt.Errorf("params is empty")
+		}
+		//params are set by the operator of global params contract, which is governance contract
+		operator, err := global_params.GetStorageRole(native, global_params.GenerateOperatorKey(utils.ParamContractAddress))
+		if err != nil {
+			return nil, fmt.Errorf("getOperator, get operator of global params error: %v", err)
+		}
+		if operator != contract {
+			return nil, fmt.Errorf("governance contract is not operator of global params")
+		}
+		return func() error {
+			_, err := native.NativeCall(utils.ParamContractAddress, global_params.SET_GLOBAL_PARAM_NAME,
+				common.SerializeToBytes(&params))
+			if err != nil {
+				return fmt.Errorf("appCall setGlobalParam error: %v", err)
+			}
+			_, err = native.NativeCall(utils.ParamContractAddress, global_params.CREATE_SNAPSHOT_NAME, []byte{})
+			if err != nil {
+				return fmt.Errorf("appCall createSnapshot error: %v", err)
+			}
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown proposal type %d", proposalType)
+	}
+}
+
+// tally proposals whose voting window ends in this view, and execute the passed ones
+func executeProposals(native *native.NativeService, contract common.Address, view uint32) error {
+	activeProposals, err := getActiveProposals(native, contract)
+	if err != nil {
+		return fmt.Errorf("getActiveProposals, get activeProposals error: %v", err)
+	}
+	if len(activeProposals.IDs) == 0 {
+		return nil
+	}
+
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	var totalStake uint64
+	stakes := make(map[string]uint64)
+	for _, peerPoolItem := range peerPoolMap.PeerPoolMap {
+		if peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus {
+			stake := peerPoolItem.InitPos + peerPoolItem.TotalPos
+			stakes[peerPoolItem.PeerPubkey] = stake
+			totalStake += stake
+		}
+	}
+
+	remain := make([]uint32, 0, len(activeProposals.IDs))
+	for _, id := range activeProposals.IDs {
+		proposal, err := getProposal(native, contract, id)
+		if err != nil {
+			return fmt.Errorf("getProposal, get proposal error: %v", err)
+		}
+		if proposal.EndView > view {
+			remain = append(remain, id)
+			continue
+		}
+		proposal.TotalStake = totalStake
+		for _, vote := range proposal.Votes {
+			if vote.Approve {
+				proposal.ApproveStake += stakes[vote.PeerPubkey]
+			} else {
+				proposal.RejectStake += stakes[vote.PeerPubkey]
+			}
+		}
+
+		eventName := PROPOSAL_REJECTED
+		proposal.Status = ProposalRejected
+		//more than 2/3 of all stake approved
+		if proposal.ApproveStake*3 > totalStake*2 {
+			eventName = PROPOSAL_PASSED
+			proposal.Status = ProposalPassed
+			//a failed proposal leaves no change of its execution
+			snapshot := native.CacheDB.Snapshot()
+			notifyNum := len(native.Notifications)
+			execute, err := prepareProposal(native, contract, view, proposal.Type, proposal.Content)
+			if err == nil {
+				err = execute()
+			}
+			if err != nil {
+				native.CacheDB.RevertToSnapshot(snapshot)
+				native.Notifications = native.Notifications[:notifyNum]
+				eventName = PROPOSAL_FAILED
+				proposal.Status = ProposalFailed
+			}
+		}
+		putProposal(native, contract, proposal)
+		notifyProposal(native, contract, eventName, proposal.ID, proposal.ApproveStake, proposal.RejectStake, totalStake)
+	}
+	activeProposals.IDs = remain
+	putActiveProposals(native, contract, activeProposals)
+	return nil
+}
+
+// checkSplitCurve checks the split curve starts from 0, rises to its peak and then falls, and never exceeds PRECISE
+func checkSplitCurve(splitCurve *SplitCurve) error {
+	if len(splitCurve.Yi) != len(Xi) {
+		return fmt.Errorf("length of split curve != %d", len(Xi))
+	}
+	if splitCurve.Yi[0] != 0 {
+		return fmt.Errorf("split curve must start from 0")
+	}
+	falling := false
+	for i := 1; i < len(splitCurve.Yi); i++ {
+		if splitCurve.Yi[i] > PRECISE {
+			return fmt.Errorf("split curve must <= %d", PRECISE)
+		}
+		if splitCurve.Yi[i] < splitCurve.Yi[i-1] {
+			falling = true
+		} else if falling && splitCurve.Yi[i] > splitCurve.Yi[i-1] {
+			return fmt.Errorf("split curve must rise to its peak and then fall")
+		}
+	}
+	return nil
+}
+
+func notifyProposal(native *native.NativeService, contract common.Address, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          states,
+		})
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package governance
+
+import (
+	"testing"
+
+	"github.com/qbyyf/ontology/account"
+	"github.com/qbyyf/ontology/common"
+	"github.com/qbyyf/ontology/common/config"
+	vbftconfig "github.com/qbyyf/ontology/consensus/vbft/config"
+	cstates "github.com/qbyyf/ontology/core/states"
+	"github.com/qbyyf/ontology/smartcontract/service/native"
+	"github.com/qbyyf/ontology/smartcontract/service/native/global_params"
+	"github.com/qbyyf/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newProposalNative returns the native service of governance contract in view 1, whose peers are
+// witnessed by their owners, and whose peer pool of view 2 is the same as view 1
+func newProposalNative(t *testing.T, accs []*account.Account) *native.NativeService {
+	contract := utils.GovernanceContractAddress
+	peers := make([]*PeerPoolItem, 0, len(accs))
+	witnesses := make([]common.Address, 0, len(accs))
+	for i, acc := range accs {
+		peers = append(peers, &PeerPoolItem{Index: uint32(i + 1), PeerPubkey: vbftconfig.PubkeyID(acc.PublicKey),
+			Address: acc.Address, Status: ConsensusStatus, InitPos: 1000})
+		witnesses = append(witnesses, acc.Address)
+	}
+	ns := newTestNative(t, 20, nil, peers...)
+	ns.ContextRef = &testContextRef{witnesses: witnesses}
+	peerPoolMap, err := GetPeerPoolMap(ns, contract, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, putPeerPoolMap(ns, contract, 2, peerPoolMap))
+	assert.Nil(t, putConfig(ns, contract, &Configuration{N: 7, C: 2, K: 7, L: 112, BlockMsgDelay: 10000,
+		HashMsgDelay: 10000, PeerHandshakeTimeout: 10, MaxBlockChangeView: 120000}))
+	return ns
+}
+
+// peakCurve returns a split curve rising to peak at the middle and falling to 0
+func peakCurve(peak uint32) *SplitCurve {
+	yi := make([]uint32, len(Xi))
+	for i := range yi {
+		if i <= len(yi)/2 {
+			yi[i] = peak * uint32(i) / uint32(len(yi)/2)
+		} else {
+			yi[i] = peak * uint32(len(yi)-1-i) / uint32(len(yi)/2)
+		}
+	}
+	return &SplitCurve{Yi: yi}
+}
+
+func curveBytes(t *testing.T, splitCurve *SplitCurve) []byte {
+	sink := common.NewZeroCopySink(nil)
+	assert.Nil(t, splitCurve.Serialization(sink))
+	return sink.Bytes()
+}
+
+func submitProposal(ns *native.NativeService, acc *account.Account, proposalType ProposalType, content []byte) error {
+	ns.Input = common.SerializeToBytes(&SubmitProposalParam{
+		PeerPubkey: vbftconfig.PubkeyID(acc.PublicKey),
+		Address:    acc.Address,
+		Type:       proposalType,
+		Content:    content,
+	})
+	_, err := SubmitProposal(ns)
+	return err
+}
+
+func voteProposal(ns *native.NativeService, id uint32, acc *account.Account, approve bool) error {
+	ns.Input = common.SerializeToBytes(&VoteProposalParam{
+		ID:         id,
+		PeerPubkey: vbftconfig.PubkeyID(acc.PublicKey),
+		Address:    acc.Address,
+		Approve:    approve,
+	})
+	_, err := VoteProposal(ns)
+	return err
+}
+
+func TestProposal(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	contract := utils.GovernanceContractAddress
+	accs := []*account.Account{account.NewAccount(""), account.NewAccount(""), account.NewAccount("")}
+	ns := newProposalNative(t, accs)
+	passedCurve := peakCurve(700000)
+	rejectedCurve := peakCurve(600000)
+
+	//submit
+	assert.Nil(t, submitProposal(ns, accs[0], ProposalSplitCurve, curveBytes(t, passedCurve)))
+	proposal, err := getProposal(ns, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalVoting, proposal.Status)
+	assert.Equal(t, uint32(1+PROPOSAL_VOTING_VIEWS), proposal.EndView)
+	assert.NotNil(t, submitProposal(ns, accs[0], ProposalSplitCurve, curveBytes(t, rejectedCurve)))
+	assert.Nil(t, submitProposal(ns, accs[1], ProposalSplitCurve, curveBytes(t, rejectedCurve)))
+	//only the owner of a peer submits for it
+	ns.Input = common.SerializeToBytes(&SubmitProposalParam{PeerPubkey: vbftconfig.PubkeyID(accs[2].PublicKey),
+		Address: accs[0].Address, Type: ProposalSplitCurve, Content: curveBytes(t, passedCurve)})
+	_, err = SubmitProposal(ns)
+	assert.NotNil(t, err)
+
+	//vote, and the vote can be changed before tally
+	assert.Nil(t, voteProposal(ns, 1, accs[0], true))
+	assert.Nil(t, voteProposal(ns, 1, accs[1], false))
+	assert.Nil(t, voteProposal(ns, 1, accs[1], true))
+	assert.Nil(t, voteProposal(ns, 1, accs[2], true))
+	assert.Nil(t, voteProposal(ns, 2, accs[0], true))
+	assert.Nil(t, voteProposal(ns, 2, accs[1], true))
+	assert.Nil(t, voteProposal(ns, 2, accs[2], false))
+	proposal, err = getProposal(ns, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(proposal.Votes))
+	assert.NotNil(t, voteProposal(ns, 3, accs[0], true))
+
+	//proposals are not tallied before the end of voting window
+	assert.Nil(t, executeProposals(ns, contract, 1))
+	activeProposals, err := getActiveProposals(ns, contract)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{1, 2}, activeProposals.IDs)
+
+	//execute the passed one and reject the one approved by exactly 2/3 of stake
+	assert.Nil(t, executeProposals(ns, contract, 2))
+	proposal, err = getProposal(ns, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalPassed, proposal.Status)
+	assert.Equal(t, uint64(3000), proposal.ApproveStake)
+	proposal, err = getProposal(ns, contract, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalRejected, proposal.Status)
+	assert.Equal(t, uint64(2000), proposal.ApproveStake)
+	assert.Equal(t, uint64(1000), proposal.RejectStake)
+	splitCurve, err := getSplitCurve(ns, contract)
+	assert.Nil(t, err)
+	assert.Equal(t, passedCurve, splitCurve)
+	activeProposals, err = getActiveProposals(ns, contract)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(activeProposals.IDs))
+	assert.NotNil(t, voteProposal(ns, 1, accs[0], false))
+
+	//the id is not truncated to uint32
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, 1)
+	ns.Input = sink.Bytes()
+	result, err := GetProposal(ns)
+	assert.Nil(t, err)
+	proposal, err = getProposal(ns, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, common.SerializeToBytes(proposal), result)
+	sink = common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, 1<<32+1)
+	ns.Input = sink.Bytes()
+	_, err = GetProposal(ns)
+	assert.NotNil(t, err)
+}
+
+func TestProposalParamsFailed(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	contract := utils.GovernanceContractAddress
+	accs := []*account.Account{account.NewAccount(""), account.NewAccount(""), account.NewAccount("")}
+	ns := newProposalNative(t, accs)
+	params := global_params.Params{{Key: "gasPrice", Value: "2500"}}
+	content := common.SerializeToBytes(&params)
+	operatorKey := global_params.GenerateOperatorKey(utils.ParamContractAddress)
+	putOperator := func(operator common.Address) {
+		sink := common.NewZeroCopySink(nil)
+		utils.EncodeAddress(sink, operator)
+		ns.CacheDB.Put(operatorKey, (&cstates.StorageItem{Value: sink.Bytes()}).ToArray())
+	}
+
+	//params are only proposed when governance contract is operator of global params
+	putOperator(accs[0].Address)
+	assert.NotNil(t, submitProposal(ns, accs[0], ProposalParams, content))
+	putOperator(contract)
+	assert.Nil(t, submitProposal(ns, accs[0], ProposalParams, content))
+	for _, acc := range accs {
+		assert.Nil(t, voteProposal(ns, 1, acc, true))
+	}
+
+	//the passed proposal fails when the operator is changed before execution
+	putOperator(accs[0].Address)
+	assert.Nil(t, executeProposals(ns, contract, 2))
+	proposal, err := getProposal(ns, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalFailed, proposal.Status)
+}
+
+func TestProposalActivation(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	accs := []*account.Account{account.NewAccount("")}
+	ns := newProposalNative(t, accs)
+	assert.NotNil(t, submitProposal(ns, accs[0], ProposalSplitCurve, curveBytes(t, peakCurve(700000))))
+	assert.NotNil(t, voteProposal(ns, 1, accs[0], true))
+}
+
+func TestCheckSplitCurve(t *testing.T) {
+	assert.Nil(t, checkSplitCurve(peakCurve(PRECISE)))
+	assert.NotNil(t, checkSplitCurve(&SplitCurve{Yi: []uint32{0, 1}}))
+	assert.NotNil(t, checkSplitCurve(peakCurve(PRECISE+1)))
+
+	notZero := peakCurve(700000)
+	notZero.Yi[0] = 1
+	assert.NotNil(t, checkSplitCurve(notZero))
+
+	twoPeaks := peakCurve(700000)
+	twoPeaks.Yi[len(twoPeaks.Yi)-2] = 700000
+	assert.NotNil(t, checkSplitCurve(twoPeaks))
+
+	flat := &SplitCurve{Yi: make([]uint32, len(Xi))}
+	assert.Nil(t, checkSplitCurve(flat))
+}
@@ -494,3 +494,295 @@ func (this *SplitFeeAddress) Deserialization(source *common.ZeroCopySource) erro
 	this.Amount = amount
 	return nil
 }
+
+type ProposalType uint8
+
+const (
+	ProposalGlobalParam  ProposalType = iota + 1 //content is GlobalParam
+	ProposalGlobalParam2                         //content is GlobalParam2
+	ProposalConfig                               //content is Configuration
+	ProposalSplitCurve                           //content is SplitCurve
+	ProposalParams                               //content is global_params.Params
+)
+
+type ProposalStatus uint8
+
+const (
+	ProposalVoting   ProposalStatus = iota //proposal is in voting window
+	ProposalPassed                         //proposal is passed and executed
+	ProposalRejected                       //proposal does not get enough stake
+	ProposalFailed                         //proposal is passed but failed to execute
+)
+
+type ProposalVote struct {
+	PeerPubkey string //voter peer
+	Approve    bool
+}
+
+type Proposal struct {
+	ID           uint32
+	PeerPubkey   string         //proposer peer
+	Address      common.Address //owner of proposer peer
+	Type         ProposalType
+	Content      []byte
+	Description  string
+	StartView    uint32 //view the proposal submitted in
+	EndView      uint32 //proposal is tallied at commitDpos of this view
+	Status       ProposalStatus
+	ApproveStake uint64 //stake of approve votes, set when tallied
+	RejectStake  uint64 //stake of reject votes, set when tallied
+	TotalStake   uint64 //stake of all candidate and consensus peers, set when tallied
+	Votes        []*ProposalVote
+}
+
+func (this *Proposal) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.ID)
+	sink.WriteString(this.PeerPubkey)
+	this.Address.Serialization(sink)
+	sink.WriteUint8(uint8(this.Type))
+	sink.WriteVarBytes(this.Content)
+	sink.WriteString(this.Description)
+	sink.WriteUint32(this.StartView)
+	sink.WriteUint32(this.EndView)
+	sink.WriteUint8(uint8(this.Status))
+	sink.WriteUint64(this.ApproveStake)
+	sink.WriteUint64(this.RejectStake)
+	sink.WriteUint64(this.TotalStake)
+	utils.EncodeVarUint(sink, uint64(len(this.Votes)))
+	for _, vote := range this.Votes {
+		sink.WriteString(vote.PeerPubkey)
+		sink.WriteBool(vote.Approve)
+	}
+}
+
+func (this *Proposal) Deserialization(source *common.ZeroCopySource) error {
+	var eof, irregular bool
+	this.ID, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint32, deserialize id error: %v", io.ErrUnexpectedEOF)
+	}
+	peerPubkey, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	err = this.Address.Deserialization(source)
+	if err != nil {
+		return fmt.Errorf("address.Deserialize, deserialize address error: %v", err)
+	}
+	proposalType, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint8, deserialize type error: %v", io.ErrUnexpectedEOF)
+	}
+	content, err := utils.DecodeVarBytes(source)
+	if err != nil {
+		return fmt.Errorf("utils.DecodeVarBytes, deserialize content error: %v", err)
+	}
+	description, err := utils.DecodeString(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize description error: %v", err)
+	}
+	this.StartView, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint32, deserialize startView error: %v", io.ErrUnexpectedEOF)
+	}
+	this.EndView, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint32, deserialize endView error: %v", io.ErrUnexpectedEOF)
+	}
+	status, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint8, deserialize status error: %v", io.ErrUnexpectedEOF)
+	}
+	this.ApproveStake, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize approveStake error: %v", io.ErrUnexpectedEOF)
+	}
+	this.RejectStake, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize rejectStake error: %v", io.ErrUnexpectedEOF)
+	}
+	this.TotalStake, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize totalStake error: %v", io.ErrUnexpectedEOF)
+	}
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarUint, deserialize votes length error: %v", err)
+	}
+	votes := make([]*ProposalVote, 0)
+	for i := uint64(0); i < n; i++ {
+		vote := new(ProposalVote)
+		vote.PeerPubkey, err = utils.DecodeString(source)
+		if err != nil {
+			return fmt.Errorf("serialization.ReadString, deserialize vote peerPubkey error: %v", err)
+		}
+		vote.Approve, irregular, eof = source.NextBool()
+		if irregular || eof {
+			return fmt.Errorf("serialization.ReadBool, deserialize vote approve error")
+		}
+		votes = append(votes, vote)
+	}
+	this.PeerPubkey = peerPubkey
+	this.Type = ProposalType(proposalType)
+	this.Content = content
+	this.Description = description
+	this.Status = ProposalStatus(status)
+	this.Votes = votes
+	return nil
+}
+
+type ProposalList struct {
+	Proposals []*Proposal
+}
+
+func (this *ProposalList) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(len(this.Proposals)))
+	for _, proposal := range this.Proposals {
+		proposal.Serialization(sink)
+	}
+}
+
+func (this *ProposalList) Deserialization(source *common.ZeroCopySource) error {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarUint, deserialize proposals length error: %v", err)
+	}
+	proposals := make([]*Proposal, 0)
+	for i := uint64(0); i < n; i++ {
+		proposal := new(Proposal)
+		if err := proposal.Deserialization(source); err != nil {
+			return fmt.Errorf("deserialize proposal error: %v", err)
+		}
+		proposals = append(proposals, proposal)
+	}
+	this.Proposals = proposals
+	return nil
+}
+
+type ProposalIDList struct { //ids of proposals in voting
+	IDs []uint32
+}
+
+func (this *ProposalIDList) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(len(this.IDs)))
+	for _, id := range this.IDs {
+		sink.WriteUint32(id)
+	}
+}
+
+func (this *ProposalIDList) Deserialization(source *common.ZeroCopySource) error {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarUint, deserialize ids length error: %v", err)
+	}
+	ids := make([]uint32, 0)
+	for i := uint64(0); i < n; i++ {
+		id, eof := source.NextUint32()
+		if eof {
+			return fmt.Errorf("serialization.ReadUint32, deserialize id error: %v", io.ErrUnexpectedEOF)
+		}
+		ids = append(ids, id)
+	}
+	this.IDs = ids
+	return nil
+}
+
+type AuthorizeStakingInfo struct {
+	AuthorizeInfo   *AuthorizeInfo
+	PeerStatus      Status //status of the authorized peer, meaningless if PeerRemoved
+	PeerRemoved     bool   //the peer has quit or been rejected, and is removed from peer pool
+	PendingPos      uint64 //new pos, will be calculated in next epoch
+	FrozenPos       uint64 //unAuthorized pos, frozen until it is unfrozen by commitDpos
+	WithdrawablePos uint64 //unfrozen pos, can withdraw at any time
+}
+
+func (this *AuthorizeStakingInfo) Serialization(sink *common.ZeroCopySink) {
+	this.AuthorizeInfo.Serialization(sink)
+	this.PeerStatus.Serialization(sink)
+	sink.WriteBool(this.PeerRemoved)
+	sink.WriteUint64(this.PendingPos)
+	sink.WriteUint64(this.FrozenPos)
+	sink.WriteUint64(this.WithdrawablePos)
+}
+
+func (this *AuthorizeStakingInfo) Deserialization(source *common.ZeroCopySource) error {
+	authorizeInfo := new(AuthorizeInfo)
+	if err := authorizeInfo.Deserialization(source); err != nil {
+		return fmt.Errorf("authorizeInfo.Deserialize, deserialize authorizeInfo error: %v", err)
+	}
+	status := new(Status)
+	if err := status.Deserialization(source); err != nil {
+		return fmt.Errorf("status.Deserialize. deserialize peerStatus error: %v", err)
+	}
+	peerRemoved, irregular, eof := source.NextBool()
+	if irregular || eof {
+		return fmt.Errorf("serialization.ReadBool, deserialize peerRemoved error: %v", io.ErrUnexpectedEOF)
+	}
+	pendingPos, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize pendingPos error: %v", io.ErrUnexpectedEOF)
+	}
+	frozenPos, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize frozenPos error: %v", io.ErrUnexpectedEOF)
+	}
+	withdrawablePos, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize withdrawablePos error: %v", io.ErrUnexpectedEOF)
+	}
+	this.AuthorizeInfo = authorizeInfo
+	this.PeerStatus = *status
+	this.PeerRemoved = peerRemoved
+	this.PendingPos = pendingPos
+	this.FrozenPos = frozenPos
+	this.WithdrawablePos = withdrawablePos
+	return nil
+}
+
+type AddressStakingInfo struct {
+	Address        common.Address
+	TotalStake     uint64 //total stake of the address in this contract
+	UnclaimedFee   uint64 //ong motivation can be withdrawn by withdrawFee
+	Authorizations []*AuthorizeStakingInfo
+}
+
+func (this *AddressStakingInfo) Serialization(sink *common.ZeroCopySink) {
+	this.Address.Serialization(sink)
+	sink.WriteUint64(this.TotalStake)
+	sink.WriteUint64(this.UnclaimedFee)
+	utils.EncodeVarUint(sink, uint64(len(this.Authorizations)))
+	for _, v := range this.Authorizations {
+		v.Serialization(sink)
+	}
+}
+
+func (this *AddressStakingInfo) Deserialization(source *common.ZeroCopySource) error {
+	err := this.Address.Deserialization(source)
+	if err != nil {
+		return fmt.Errorf("address.Deserialize, deserialize address error: %v", err)
+	}
+	totalStake, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize totalStake error: %v", io.ErrUnexpectedEOF)
+	}
+	unclaimedFee, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("serialization.ReadUint64, deserialize unclaimedFee error: %v", io.ErrUnexpectedEOF)
+	}
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarUint, deserialize authorizations length error: %v", err)
+	}
+	authorizations := make([]*AuthorizeStakingInfo, 0)
+	for i := uint64(0); i < n; i++ {
+		authorization := new(AuthorizeStakingInfo)
+		if err := authorization.Deserialization(source); err != nil {
+			return fmt.Errorf("deserialize authorization error: %v", err)
+		}
+		authorizations = append(authorizations, authorization)
+	}
+	this.TotalStake = totalStake
+	this.UnclaimedFee = unclaimedFee
+	this.Authorizations = authorizations
+	return nil
+}
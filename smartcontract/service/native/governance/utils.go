@@ -754,3 +754,73 @@ func putGasAddress(native *native.NativeService, contract common.Address, gasAdd
 		cstates.GenRawStorageItem(common.SerializeToBytes(gasAddress)))
 	return nil
 }
+
+func getProposalID(native *native.NativeService, contract common.Address) (uint32, error) {
+	proposalIDBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL_ID)))
+	if err != nil {
+		return 0, fmt.Errorf("native.CacheDB.Get, get proposalIDBytes error: %v", err)
+	}
+	var proposalID uint32 = 0
+	if proposalIDBytes != nil {
+		proposalIDStore, err := cstates.GetValueFromRawStorageItem(proposalIDBytes)
+		if err != nil {
+			return 0, fmt.Errorf("getProposalID, proposalIDBytes is not available")
+		}
+		proposalID, err = GetBytesUint32(proposalIDStore)
+		if err != nil {
+			return 0, fmt.Errorf("GetBytesUint32, get proposalID error: %v", err)
+		}
+	}
+	return proposalID, nil
+}
+
+func putProposalID(native *native.NativeService, contract common.Address, proposalID uint32) {
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL_ID)), cstates.GenRawStorageItem(GetUint32Bytes(proposalID)))
+}
+
+func getProposal(native *native.NativeService, contract common.Address, id uint32) (*Proposal, error) {
+	proposalBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL), GetUint32Bytes(id)))
+	if err != nil {
+		return nil, fmt.Errorf("native.CacheDB.Get, get proposalBytes error: %v", err)
+	}
+	if proposalBytes == nil {
+		return nil, fmt.Errorf("getProposal, proposal %d is not found", id)
+	}
+	proposalStore, err := cstates.GetValueFromRawStorageItem(proposalBytes)
+	if err != nil {
+		return nil, fmt.Errorf("getProposal, proposalBytes is not available")
+	}
+	proposal := new(Proposal)
+	if err := proposal.Deserialization(common.NewZeroCopySource(proposalStore)); err != nil {
+		return nil, fmt.Errorf("deserialize, deserialize proposal error: %v", err)
+	}
+	return proposal, nil
+}
+
+func putProposal(native *native.NativeService, contract common.Address, proposal *Proposal) {
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL), GetUint32Bytes(proposal.ID)),
+		cstates.GenRawStorageItem(common.SerializeToBytes(proposal)))
+}
+
+func getActiveProposals(native *native.NativeService, contract common.Address) (*ProposalIDList, error) {
+	activeProposalsBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(ACTIVE_PROPOSALS)))
+	if err != nil {
+		return nil, fmt.Errorf("native.CacheDB.Get, get activeProposalsBytes error: %v", err)
+	}
+	activeProposals := new(ProposalIDList)
+	if activeProposalsBytes != nil {
+		activeProposalsStore, err := cstates.GetValueFromRawStorageItem(activeProposalsBytes)
+		if err != nil {
+			return nil, fmt.Errorf("getActiveProposals, activeProposalsBytes is not available")
+		}
+		if err := activeProposals.Deserialization(common.NewZeroCopySource(activeProposalsStore)); err != nil {
+			return nil, fmt.Errorf("deserialize, deserialize activeProposals error: %v", err)
+		}
+	}
+	return activeProposals, nil
+}
+
+func putActiveProposals(native *native.NativeService, contract common.Address, activeProposals *ProposalIDList) {
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(ACTIVE_PROPOSALS)),
+		cstates.GenRawStorageItem(common.SerializeToBytes(activeProposals)))
+}
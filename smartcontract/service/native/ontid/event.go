@@ -81,3 +81,32 @@ func triggerAuthKeyEvent(srvc *native.NativeService, op string, id []byte, keyID
 	st := []interface{}{"AuthKey", op, string(id), keyID}
 	newEvent(srvc, st)
 }
+
+// EventSubject returns the ONT ID which the states of an ontid event are about, or empty if
+// the states are not of an ontid event
+func EventSubject(states interface{}) string {
+	var list []interface{}
+	switch v := states.(type) {
+	case []interface{}:
+		list = v
+	case []string:
+		for _, s := range v {
+			list = append(list, s)
+		}
+	default:
+		return ""
+	}
+	if len(list) < 2 {
+		return ""
+	}
+	index := 2
+	switch list[0] {
+	case "Register", "Revoke", "RemoveController":
+		index = 1
+	}
+	if len(list) <= index {
+		return ""
+	}
+	subject, _ := list[index].(string)
+	return subject
+}
@@ -661,3 +661,13 @@ func CaseAfterNewApiFork(t *testing.T, n *native.NativeService) {
 	assert.NoError(t, err)
 	fmt.Println(string(res))
 }
+
+func TestEventSubject(t *testing.T) {
+	did := "did:ont:TVuF6FH1PskzWJAFhWAFg17NSitMDEBNoa"
+	assert.Equal(t, did, EventSubject([]interface{}{"Register", did}))
+	assert.Equal(t, did, EventSubject([]interface{}{"Revoke", did}))
+	assert.Equal(t, did, EventSubject([]interface{}{"PublicKey", "add", did, float64(2)}))
+	assert.Equal(t, did, EventSubject([]string{"Attribute", "add", did}))
+	assert.Equal(t, "", EventSubject([]interface{}{"PublicKey", "add"}))
+	assert.Equal(t, "", EventSubject("Register"))
+}
@@ -440,6 +440,12 @@ func isOwner(srvc *native.NativeService, encId, pub []byte) bool {
 	return kID != 0 && !revoked
 }
 
+// KeyType returns the DID verification method type and the hex encoded key
+// material of a serialized public key.
+func KeyType(publicKey []byte) (string, string, error) {
+	return keyType(publicKey)
+}
+
 func keyType(publicKey []byte) (string, string, error) {
 	switch keypair.KeyType(publicKey[0]) {
 	case keypair.PK_P256_E, keypair.PK_P256_O, keypair.PK_P256_NC:
@@ -66,6 +66,24 @@ const (
 	FIELD_CONTEXT    byte = 9
 )
 
+// ID states stored under the ID key, exported for off-chain readers such as
+// the DID resolver
+const (
+	ID_STATE_NOT_EXIST = flag_not_exist
+	ID_STATE_VALID     = flag_valid
+	ID_STATE_REVOKED   = flag_revoke
+)
+
+// GetIDStateKey returns the storage key of the ID state, relative to the ONT ID
+// contract address.
+func GetIDStateKey(id []byte) ([]byte, error) {
+	encId, err := encodeID(id)
+	if err != nil {
+		return nil, err
+	}
+	return encId[len(utils.OntIDContractAddress):], nil
+}
+
 func encodeID(id []byte) ([]byte, error) {
 	length := len(id)
 	if length == 0 || length > 255 {
@@ -50,6 +50,8 @@ var (
 	HASH160_GAS                   uint64 = 20
 	HASH256_GAS                   uint64 = 20
 	OPCODE_GAS                    uint64 = 1
+	STORAGE_BYTE_GAS              uint64 = 0 // disabled until set by governance
+	STORAGE_REFUND_PERCENT        uint64 = 0
 
 	PER_UNIT_CODE_LEN    = 1024
 	METHOD_LENGTH_LIMIT  = 1024
@@ -126,6 +128,10 @@ var (
 	UINT_DEPLOY_CODE_LEN_NAME = "Deploy.Code.Gas"
 	UINT_INVOKE_CODE_LEN_NAME = "Invoke.Code.Gas"
 
+	// storage resource pricing, charged for the net new storage bytes of a transaction
+	STORAGE_BYTE_NAME           = "Storage.Byte.Gas"
+	STORAGE_REFUND_PERCENT_NAME = "Storage.Refund.Percent"
+
 	GAS_TABLE = initGAS_TABLE()
 
 	GAS_TABLE_KEYS = []string{
@@ -149,6 +155,8 @@ var (
 		UINT_DEPLOY_CODE_LEN_NAME,
 		UINT_INVOKE_CODE_LEN_NAME,
 		config.WASM_GAS_FACTOR,
+		STORAGE_BYTE_NAME,
+		STORAGE_REFUND_PERCENT_NAME,
 	}
 
 	INIT_GAS_TABLE = map[string]uint64{
@@ -205,5 +213,8 @@ func initGAS_TABLE() *sync.Map {
 
 	m.Store(config.WASM_GAS_FACTOR, config.DEFAULT_WASM_GAS_FACTOR)
 
+	m.Store(STORAGE_BYTE_NAME, STORAGE_BYTE_GAS)
+	m.Store(STORAGE_REFUND_PERCENT_NAME, STORAGE_REFUND_PERCENT)
+
 	return &m
 }
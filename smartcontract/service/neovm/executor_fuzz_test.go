@@ -0,0 +1,104 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"encoding/hex"
+	"testing"
+
+	vm "github.com/qbyyf/ontology/vm/neovm"
+	"github.com/qbyyf/ontology/vm/neovm/types"
+)
+
+// FuzzExecutor runs arbitrary code on the raw executor. The executor has no metering of its own, so the execution
+// is bounded by VM_STEP_LIMIT like pre-execution, where gas is unlimited. The stacks are checked after every step.
+func FuzzExecutor(f *testing.F) {
+	// invoke code of the sample getDiskPlayersList transaction used in http/test/func_test.go
+	code, _ := hex.DecodeString("0101011552c1126765744469736b506c61796572734c697374676a6f1082c6cec3a1bcbb5a3892cf770061e4b982")
+	f.Add(code, false)
+	f.Add([]byte{byte(vm.PUSH1), byte(vm.PUSH2), byte(vm.ADD), byte(vm.DUP), byte(vm.CAT), byte(vm.RET)}, false)
+	f.Add([]byte{byte(vm.PUSH3), byte(vm.NEWMAP), byte(vm.DUP), byte(vm.PUSH1), byte(vm.PUSH2), byte(vm.SETITEM), byte(vm.KEYS)}, true)
+	f.Add([]byte{byte(vm.PUSH1), byte(vm.JMP), 0xfd, 0xff}, false)
+
+	f.Fuzz(func(t *testing.T, code []byte, disableHasKey bool) {
+		exec := vm.NewExecutor(code, vm.VmFeatureFlag{DisableHasKey: disableHasKey})
+		exec.State = exec.State & (^vm.BREAK)
+		for step := 0; step < VM_STEP_LIMIT && exec.Context != nil; step++ {
+			if exec.State == vm.FAULT || exec.State == vm.HALT || exec.State == vm.BREAK {
+				break
+			}
+			opcode, eof := exec.Context.ReadOpCode()
+			if eof {
+				break
+			}
+			var err error
+			exec.State, err = exec.ExecuteOp(opcode, exec.Context)
+			if err != nil {
+				break
+			}
+			checkStackBound(t, exec.EvalStack)
+			checkStackBound(t, exec.AltStack)
+			if len(exec.Callers) > vm.MAX_INVOCATION_STACK_SIZE {
+				t.Fatalf("invocation stack over limit: %d", len(exec.Callers))
+			}
+		}
+	})
+}
+
+// checkStackBound checks the size of the stack and of every value on it
+func checkStackBound(t *testing.T, stack *vm.ValueStack) {
+	if stack.Count() > vm.STACK_LIMIT {
+		t.Fatalf("stack over limit: %d", stack.Count())
+	}
+	for i := 0; i < stack.Count(); i++ {
+		val, err := stack.Peek(int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch val.GetType() {
+		case types.ByteArrayType:
+			buf, err := val.AsBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if uint32(len(buf)) > vm.MAX_BYTEARRAY_SIZE {
+				t.Fatalf("bytearray at %d over limit: %d", i, len(buf))
+			}
+		case types.ArrayType:
+			arr, err := val.AsArrayValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if arr.Len() > vm.MAX_ARRAY_SIZE {
+				t.Fatalf("array at %d over limit: %d", i, arr.Len())
+			}
+		case types.StructType:
+			st, err := val.AsStructValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.Len() > vm.MAX_ARRAY_SIZE {
+				t.Fatalf("struct at %d over limit: %d", i, st.Len())
+			}
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/qbyyf/ontology/core/store"
 	"github.com/qbyyf/ontology/core/types"
 	"github.com/qbyyf/ontology/errors"
+	"github.com/qbyyf/ontology/events"
 	"github.com/qbyyf/ontology/smartcontract/context"
 	"github.com/qbyyf/ontology/smartcontract/event"
 	"github.com/qbyyf/ontology/smartcontract/storage"
@@ -133,6 +134,7 @@ type NeoVmService struct {
 	Time          uint32
 	Height        uint32
 	BlockHash     scommon.Uint256
+	Publisher     *events.ActorPublisher
 	Engine        *vm.Executor
 	PreExec       bool
 }
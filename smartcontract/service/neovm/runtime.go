@@ -164,7 +164,7 @@ func RuntimeLog(service *NeoVmService, engine *vm.Executor) error {
 	}
 	context := service.ContextRef.CurrentContext()
 	txHash := service.Tx.Hash()
-	event.PushSmartCodeEvent(txHash, 0, event.EVENT_LOG, &event.LogEventArgs{TxHash: txHash, ContractAddress: context.ContractAddress, Message: string(item)})
+	event.PublishSmartCodeEvent(service.Publisher, txHash, 0, event.EVENT_LOG, &event.LogEventArgs{TxHash: txHash, ContractAddress: context.ContractAddress, Message: string(item)})
 
 	scv := sitem.Dump()
 	log.Debugf("[NeoContract]Debug:%s\n", scv)
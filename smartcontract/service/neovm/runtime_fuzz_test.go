@@ -0,0 +1,51 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"testing"
+
+	vm "github.com/qbyyf/ontology/vm/neovm"
+)
+
+// FuzzRuntimeDeserialize feeds arbitrary bytes to the Runtime.Deserialize syscall, whatever it pushes back must be
+// accepted by Runtime.Serialize
+func FuzzRuntimeDeserialize(f *testing.F) {
+	f.Add([]byte{0x80, 0x02, 0x00, 0x03, 'o', 'n', 't', 0x02, 0x01, 0x05})
+	f.Add([]byte{0x82, 0x01, 0x00, 0x01, 'k', 0x81, 0x01, 0x01, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		engine := vm.NewExecutor(nil, vm.VmFeatureFlag{})
+		if err := engine.EvalStack.PushBytes(data); err != nil {
+			return
+		}
+		if err := RuntimeDeserialize(nil, engine); err != nil {
+			return
+		}
+		if err := RuntimeSerialize(nil, engine); err != nil {
+			t.Fatalf("serialize deserialized value: %s", err)
+		}
+		if engine.EvalStack.Count() != 1 {
+			t.Fatalf("unexpected stack size: %d", engine.EvalStack.Count())
+		}
+	})
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/qbyyf/ontology/common/config"
 	"github.com/qbyyf/ontology/core/store"
 	ctypes "github.com/qbyyf/ontology/core/types"
+	"github.com/qbyyf/ontology/events"
 	"github.com/qbyyf/ontology/smartcontract/context"
 	"github.com/qbyyf/ontology/smartcontract/event"
 	"github.com/qbyyf/ontology/smartcontract/service/native"
@@ -57,10 +58,11 @@ type SmartContract struct {
 
 // Config describe smart contract need parameters configuration
 type Config struct {
-	Time      uint32              // current block timestamp
-	Height    uint32              // current block height
-	BlockHash common.Uint256      // current block hash
-	Tx        *ctypes.Transaction // current transaction
+	Time      uint32                 // current block timestamp
+	Height    uint32                 // current block height
+	BlockHash common.Uint256         // current block hash
+	Tx        *ctypes.Transaction    // current transaction
+	Publisher *events.ActorPublisher // publisher of runtime events, events.DefActorPublisher if nil
 }
 
 // PushContext push current context to smart contract
@@ -167,6 +169,7 @@ func (this *SmartContract) NewExecuteEngine(code []byte, txtype ctypes.Transacti
 			Time:       this.Config.Time,
 			Height:     this.Config.Height,
 			BlockHash:  this.Config.BlockHash,
+			Publisher:  this.Config.Publisher,
 			Engine:     vm.NewExecutor(code, feature),
 			PreExec:    this.PreExec,
 		}
@@ -220,7 +223,7 @@ func (this *SmartContract) NewNativeService() (*native.NativeService, error) {
 // Else check whether address is calling contract address
 // Param address: wallet address or contract address
 func (this *SmartContract) CheckWitness(address common.Address) bool {
-	if this.checkAccountAddress(address) || this.checkContractAddress(address) {
+	if this.checkAccountAddress(address) || this.checkContractAddress(address) || IsImpersonated(address) {
 		return true
 	}
 	return false
@@ -80,4 +80,7 @@ type PreExecResult struct {
 	Gas    uint64
 	Result interface{}
 	Notify []*event.NotifyEventInfo
+
+	StorageFee    uint64 `json:",omitempty"`
+	StorageRefund uint64 `json:",omitempty"`
 }
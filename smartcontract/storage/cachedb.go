@@ -80,6 +80,44 @@ func (self *CacheDB) Commit() {
 	self.memdb.Reset()
 }
 
+// Snapshot returns a copy of transaction cache, which can be restored by RevertToSnapshot
+func (self *CacheDB) Snapshot() *overlaydb.MemDB {
+	return self.memdb.DeepClone()
+}
+
+// RevertToSnapshot drops the changes of transaction cache made after snapshot
+func (self *CacheDB) RevertToSnapshot(snapshot *overlaydb.MemDB) {
+	self.memdb = snapshot
+}
+
+// StorageDelta returns the bytes of new storage and the bytes of freed storage in transaction cache
+// compared with block cache, the size of a storage entry is the length of its key and value
+func (self *CacheDB) StorageDelta() (added, freed uint64, err error) {
+	self.memdb.ForEach(func(key, val []byte) {
+		if err != nil || len(key) == 0 || key[0] != byte(common.ST_STORAGE) {
+			return
+		}
+		old, e := self.backend.Get(key)
+		if e != nil {
+			err = e
+			return
+		}
+		oldSize, newSize := 0, 0
+		if len(old) != 0 {
+			oldSize = len(key) + len(old)
+		}
+		if len(val) != 0 {
+			newSize = len(key) + len(val)
+		}
+		if newSize > oldSize {
+			added += uint64(newSize - oldSize)
+		} else {
+			freed += uint64(oldSize - newSize)
+		}
+	})
+	return
+}
+
 func (self *CacheDB) Put(key []byte, value []byte) {
 	self.put(common.ST_STORAGE, key, value)
 }
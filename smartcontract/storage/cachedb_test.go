@@ -21,6 +21,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/qbyyf/ontology/core/payload"
 	"github.com/qbyyf/ontology/core/store/common"
 	"github.com/qbyyf/ontology/core/store/leveldbstore"
 	"github.com/qbyyf/ontology/core/store/overlaydb"
@@ -83,3 +84,32 @@ func TestCacheDB(t *testing.T) {
 	}
 
 }
+
+func TestCacheDBStorageDelta(t *testing.T) {
+	memback := leveldbstore.NewMemLevelDBStore()
+	overlay := overlaydb.NewOverlayDB(memback)
+
+	cache := NewCacheDB(overlay)
+	cache.Put([]byte("key1"), []byte("value1"))
+	cache.Put([]byte("key2"), []byte("value2"))
+	added, freed, err := cache.StorageDelta()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2*(5+6)), added)
+	assert.Equal(t, uint64(0), freed)
+	cache.Commit()
+
+	cache.Put([]byte("key1"), []byte("value1-longer"))
+	cache.Delete([]byte("key2"))
+	cache.Delete([]byte("key3"))
+	added, freed, err = cache.StorageDelta()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), added)
+	assert.Equal(t, uint64(5+6), freed)
+
+	cache.Reset()
+	cache.PutContract(&payload.DeployCode{})
+	added, freed, err = cache.StorageDelta()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), added)
+	assert.Equal(t, uint64(0), freed)
+}
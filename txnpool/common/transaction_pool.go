@@ -90,6 +90,15 @@ func NewTxPool() *TXPool {
 	}
 }
 
+// Clear removes all the transactions in the pool
+func (s *TXPool) Clear() {
+	s.Lock()
+	defer s.Unlock()
+	s.validTxMap = make(map[common.Uint256]*VerifiedTx)
+	s.eipTxPool = make(map[common.Address]*txSortedMap)
+	s.userLatestEiptxHeight = make(map[common.Address]*UserNonceInfo)
+}
+
 func (s *TXPool) CleanStaledEIPTx(height uint32) {
 	s.Lock()
 	defer s.Unlock()
@@ -94,3 +94,16 @@ func TestTxPool(t *testing.T) {
 
 	txPool.CleanCompletedTransactionList([]*types.Transaction{txn}, 0)
 }
+
+func TestTxPoolClear(t *testing.T) {
+	txPool := NewTxPool()
+	ret := txPool.AddTxList(&VerifiedTx{Tx: txn, VerifiedHeight: 10})
+	assert.True(t, ret.Success())
+	assert.Equal(t, 1, txPool.GetTransactionCount())
+
+	txPool.Clear()
+	assert.Equal(t, 0, txPool.GetTransactionCount())
+	assert.Nil(t, txPool.GetTransaction(txn.Hash()))
+	ret = txPool.AddTxList(&VerifiedTx{Tx: txn, VerifiedHeight: 10})
+	assert.True(t, ret.Success())
+}
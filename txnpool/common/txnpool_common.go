@@ -136,6 +136,12 @@ type GetTxnPoolRsp struct {
 	TxnPool []*VerifiedTx
 }
 
+// AppendTxReq submits a transaction generated by consensus, such as
+// equivocation evidence, to the pool to be verified and broadcast.
+type AppendTxReq struct {
+	Tx *types.Transaction
+}
+
 type TxPoolService interface {
 	GetTransaction(hash common.Uint256) *types.Transaction
 	GetTransactionStatus(hash common.Uint256) *TxStatus
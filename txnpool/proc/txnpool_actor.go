@@ -266,6 +266,11 @@ func (tpa *TxPoolActor) Receive(context actor.Context) {
 
 		tpa.server.verifyBlock(msg, sender)
 
+	case *tc.AppendTxReq:
+		log.Debugf("txpool actor receives append tx req %x", msg.Tx.Hash())
+
+		go NewTxPoolService(tpa.server).AppendTransactionAsync(tc.HttpSender, msg.Tx)
+
 	case *message.SaveBlockCompleteMsg:
 		sender := context.Sender()
 
@@ -21,6 +21,7 @@
 package proc
 
 import (
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -86,8 +87,8 @@ func NewTxPoolServer(disablePreExec, disableBroadcastNetTx bool) *TXPoolServer {
 
 	s.disablePreExec = disablePreExec
 	s.disableBroadcastNetTx = disableBroadcastNetTx
-	// Create the given concurrent workers
-	s.stateless = stateless.NewValidatorPool(2)
+	// Create the given concurrent workers, signature verification is cpu bound
+	s.stateless = stateless.NewValidatorPool(runtime.NumCPU())
 	s.stateful = stateful.NewValidatorPool(1)
 	s.rspCh = make(chan *types.CheckResponse, tc.MAX_PENDING_TXN)
 	s.stopCh = make(chan bool)
@@ -262,6 +263,19 @@ func (s *TXPoolServer) Stop() {
 	close(s.slots)
 }
 
+// Flush drops the transactions in the pool and under verification, which are
+// invalid after the ledger is reverted, and resets the height to the ledger
+func (s *TXPoolServer) Flush() {
+	s.mu.Lock()
+	for hash := range s.allPendingTxs {
+		s.removePendingTxLocked(hash, errors.ErrUnknown)
+	}
+	s.mu.Unlock()
+	s.txPool.Clear()
+	atomic.StoreUint32(&s.height, ledger.DefLedger.GetCurrentBlockHeight())
+	log.Infof("tx pool is flushed at block height %d", s.getHeight())
+}
+
 // returns a transaction with the transaction hash.
 func (s *TXPoolServer) getTransaction(hash common.Uint256) *txtypes.Transaction {
 	return s.txPool.GetTransaction(hash)
@@ -421,9 +435,8 @@ func (s *TXPoolServer) verifyBlock(req *tc.VerifyBlockReq, sender *actor.PID) {
 
 	if len(checkBlkResult.UnverifiedTxs) > 0 {
 		ch := make(chan *types.CheckResponse, len(checkBlkResult.UnverifiedTxs))
-		validator := stateless.NewValidatorPool(5)
 		for _, t := range checkBlkResult.UnverifiedTxs {
-			validator.SubmitVerifyTask(t, ch)
+			s.stateless.SubmitVerifyTask(t, ch)
 		}
 		for i := 0; i < len(checkBlkResult.UnverifiedTxs); i++ {
 			response := <-ch
@@ -0,0 +1,86 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package crossvm_codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/qbyyf/ontology/common"
+)
+
+func addCodecSeeds(f *testing.F) {
+	addr := common.AddressFromVmCode([]byte("123"))
+	value := []interface{}{"helloworld", []byte("1234"), 123, -1, true, big.NewInt(100), addr, common.UINT256_EMPTY,
+		[]interface{}{"nested", []interface{}{false}}}
+	for _, val := range value {
+		buf, err := EncodeValue(val)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf)
+	}
+	buf, _ := EncodeValue(value)
+	f.Add(buf)
+	h, _ := hex.DecodeString("1001000000010500000068656c6c6f")
+	f.Add(h)
+}
+
+// FuzzDecodeValue checks that every value accepted by the decoder is encoded back to the exact bytes it consumed
+func FuzzDecodeValue(f *testing.F) {
+	addCodecSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		source := common.NewZeroCopySource(data)
+		val, err := DecodeValue(source)
+		if err != nil {
+			return
+		}
+		buf, err := EncodeValue(val)
+		if err != nil {
+			t.Fatalf("encode decoded value %v: %s", val, err)
+		}
+		if !bytes.Equal(buf, data[:source.Pos()]) {
+			t.Fatalf("round trip mismatch: input %x, output %x", data[:source.Pos()], buf)
+		}
+	})
+}
+
+func FuzzDeserializeNotify(f *testing.F) {
+	addCodecSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if DeserializeNotify(append([]byte("evt\x00"), data...)) == nil {
+			t.Fatal("nil notify")
+		}
+	})
+}
+
+func FuzzDeserializeCallParam(f *testing.F) {
+	addCodecSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = DeserializeCallParam(append([]byte{VERSION}, data...))
+	})
+}
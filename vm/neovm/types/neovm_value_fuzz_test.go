@@ -0,0 +1,74 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/qbyyf/ontology/common"
+)
+
+// FuzzVmValueDeserialize covers the input of Runtime.Deserialize, a value accepted by the decoder must serialize
+// and its serialization must be a fixed point of deserialize and serialize.
+func FuzzVmValueDeserialize(f *testing.F) {
+	bigInt, _ := VmValueFromBigInt(new(big.Int).Lsh(big.NewInt(1), 100))
+	key, _ := VmValueFromBytes([]byte("key"))
+	arr := NewArrayValue()
+	arr.Append(VmValueFromInt64(-1))
+	arr.Append(bigInt)
+	arr.Append(VmValueFromBool(true))
+	m := NewMapValue()
+	m.Set(key, VmValueFromArrayVal(arr))
+	s := NewStructValue()
+	s.Append(VmValueFromMapValue(m))
+	for _, val := range []VmValue{VmValueFromArrayVal(arr), VmValueFromMapValue(m), VmValueFromStructVal(s)} {
+		sink := common.NewZeroCopySink(nil)
+		if err := val.Serialize(sink); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(sink.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		val := VmValue{}
+		if err := val.Deserialize(common.NewZeroCopySource(data)); err != nil {
+			return
+		}
+		sink := common.NewZeroCopySink(nil)
+		if err := val.Serialize(sink); err != nil {
+			t.Fatalf("serialize deserialized value: %s", err)
+		}
+		again := VmValue{}
+		if err := again.Deserialize(common.NewZeroCopySource(sink.Bytes())); err != nil {
+			t.Fatalf("deserialize serialized value: %s", err)
+		}
+		sink2 := common.NewZeroCopySink(nil)
+		if err := again.Serialize(sink2); err != nil {
+			t.Fatalf("serialize value again: %s", err)
+		}
+		if !bytes.Equal(sink.Bytes(), sink2.Bytes()) {
+			t.Fatalf("serialization not stable: %x, %x", sink.Bytes(), sink2.Bytes())
+		}
+	})
+}